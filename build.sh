@@ -20,13 +20,21 @@ echo "Building GraphQL Tester..."
 go build -o bin/graphql-tester cmd/graphql-tester/main.go
 
 echo "Building Analyze Tool..."
-go build -o bin/analyze cmd/analyze/main.go
+go build -o bin/analyze ./cmd/analyze
 
 echo "Building Test Parser..."
 go build -o bin/test-parser cmd/test-parser/main.go
 
+echo "Building Log CLI..."
+go build -o bin/logcli ./cmd/logcli
+
+echo "Building Terminal UI..."
+go build -o bin/docker-log-viewer-tui ./cmd/tui
+
 echo ""
 echo "Build complete!"
 echo "  - ./bin/docker-log-viewer - Web-based log viewer (frontend: web/dist/)"
 echo "  - ./bin/graphql-tester - GraphQL request manager CLI"
 echo "  - ./bin/analyze - Query analysis tool for two execution IDs"
+echo "  - ./bin/logcli - Filtered log lines from a container or file"
+echo "  - ./bin/docker-log-viewer-tui - Terminal log viewer"
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"docker-log-parser/pkg/store"
+)
+
+// saveBaseline writes an execution to path as a fixture, so later runs can be compared
+// against it with -baseline after the version it ran against is gone
+func saveBaseline(db *store.Store, executionID int64, path string) error {
+	fixture, err := db.GetExecutionFixture(executionID)
+	if err != nil {
+		return fmt.Errorf("failed to get execution %d: %w", executionID, err)
+	}
+	if fixture == nil {
+		return fmt.Errorf("execution %d not found", executionID)
+	}
+
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	log.Printf("Baseline of execution %d written to %s", executionID, path)
+	return nil
+}
+
+// runBaselineAnalysis compares the current run, a stored execution or a single fixture,
+// against the baseline. The baseline is execution 1 in the report, so differences read
+// as changes since the baseline. db is only used for a stored execution.
+func runBaselineAnalysis(db *store.Store, config Config) error {
+	baseline, err := loadFixture(config.Baseline)
+	if err != nil {
+		return err
+	}
+
+	var current *store.RequestDetailResponse
+	if len(config.Fixtures) == 1 {
+		current, err = loadFixture(config.Fixtures[0])
+	} else {
+		current, err = getExecution(db, config.ExecutionID1)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeAnalysis(baseline, current, config)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"docker-log-parser/pkg/sqlexplain"
+	"docker-log-parser/pkg/store"
+)
+
+// executionList collects repeated -exec flags
+type executionList []int64
+
+func (e *executionList) String() string {
+	ids := make([]string, len(*e))
+	for i, id := range *e {
+		ids[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(ids, ",")
+}
+
+func (e *executionList) Set(value string) error {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid execution ID %q", value)
+	}
+	*e = append(*e, id)
+	return nil
+}
+
+// runConsistencyAnalysis compares query counts across repeated runs of the same request,
+// given as stored executions or fixtures. db is only used for stored executions.
+func runConsistencyAnalysis(db *store.Store, config Config) error {
+	var runs []*store.RequestDetailResponse
+	for _, id := range config.Executions {
+		exec, err := getExecution(db, id)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, exec)
+	}
+	for _, path := range config.Fixtures {
+		exec, err := loadFixture(path)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, exec)
+	}
+
+	queries := make([][]sqlexplain.QueryWithPlan, len(runs))
+	for i, run := range runs {
+		queries[i] = store.QueriesWithPlan(run.SQLQueries, store.ExecutionLabel(run, i+1))
+	}
+	analysis := sqlexplain.AnalyzeMultipleRuns(queries)
+
+	output := generateConsistencyOutput(runs, analysis)
+	if config.OutputFile != "" {
+		if err := os.WriteFile(config.OutputFile, []byte(output), 0644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		log.Printf("Analysis written to %s", config.OutputFile)
+	} else {
+		fmt.Print(output)
+	}
+
+	if config.JSONFile != "" {
+		data, err := json.MarshalIndent(analysis, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode JSON report: %w", err)
+		}
+		if err := os.WriteFile(config.JSONFile, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write JSON report: %w", err)
+		}
+		log.Printf("JSON analysis written to %s", config.JSONFile)
+	}
+	return nil
+}
+
+func generateConsistencyOutput(runs []*store.RequestDetailResponse, analysis *sqlexplain.MultiRunQueryAnalysis) string {
+	var sb strings.Builder
+
+	sb.WriteString("=================================================\n")
+	sb.WriteString("       SQL Query Consistency Report\n")
+	sb.WriteString("=================================================\n\n")
+
+	sb.WriteString("RUNS\n")
+	sb.WriteString(strings.Repeat("-", 50) + "\n")
+	for i, run := range runs {
+		sb.WriteString(fmt.Sprintf("Run %d: %s, %d queries", i+1, store.ExecutionLabel(run, i+1), analysis.TotalQueries[i]))
+		if run.Execution.ID != 0 && run.Execution.RequestIDHeader != "" {
+			sb.WriteString(fmt.Sprintf(" (request ID: %s)", run.Execution.RequestIDHeader))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("CONSISTENCY SUMMARY\n")
+	sb.WriteString(strings.Repeat("-", 50) + "\n")
+	sb.WriteString(fmt.Sprintf("Unique Queries: %d\n", analysis.UniqueQueries))
+	sb.WriteString(fmt.Sprintf("Varying Queries: %d\n", len(analysis.VaryingQueries)))
+	sb.WriteString(fmt.Sprintf("Consistency: %.1f%%\n\n", analysis.ConsistencyScore))
+
+	if len(analysis.VaryingQueries) > 0 {
+		sb.WriteString("\nVARYING QUERIES\n")
+		sb.WriteString(strings.Repeat("-", 50) + "\n")
+		for i, v := range analysis.VaryingQueries {
+			counts := make([]string, len(v.CountsPerRun))
+			for j, count := range v.CountsPerRun {
+				counts[j] = strconv.Itoa(count)
+			}
+			sb.WriteString(fmt.Sprintf("\n%d. Query: %s\n", i+1, v.NormalizedQuery))
+			sb.WriteString(fmt.Sprintf("   Table: %s\n", v.QueriedTable))
+			sb.WriteString(fmt.Sprintf("   Count per run: %s (min %d, max %d)\n", strings.Join(counts, ", "), v.MinCount, v.MaxCount))
+			if v.MissingRuns > 0 {
+				sb.WriteString(fmt.Sprintf("   Missing from %d of %d runs\n", v.MissingRuns, analysis.Runs))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("=================================================\n")
+	sb.WriteString("              End of Report\n")
+	sb.WriteString("=================================================\n")
+
+	return sb.String()
+}
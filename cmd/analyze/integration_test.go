@@ -1,6 +1,8 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
@@ -8,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"docker-log-parser/pkg/sqlexplain"
 	"docker-log-parser/pkg/store"
 )
 
@@ -145,8 +148,8 @@ func TestIntegrationAnalyzeTwoExecutions(t *testing.T) {
 	expectedSections := []string{
 		"SQL Query Analysis Report",
 		"EXECUTION DETAILS",
-		"Execution 1 (ID:",
-		"Execution 2 (ID:",
+		"Execution 1: Execution ",
+		"Execution 2: Execution ",
 		"QUERY COMPARISON SUMMARY",
 		"Total Queries:",
 		"Unique Queries:",
@@ -175,6 +178,279 @@ func TestIntegrationAnalyzeTwoExecutions(t *testing.T) {
 	}
 }
 
+func TestIntegrationAnalyzeTwoFixtures(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	config := Config{
+		Fixtures: []string{
+			writeFixture(t, tmpDir, "before.json", "req-before", 40.0),
+			writeFixture(t, tmpDir, "after.json", "req-after", 5.0),
+		},
+		OutputFile: filepath.Join(tmpDir, "report.txt"),
+	}
+
+	if err := runFixtureAnalysis(config); err != nil {
+		t.Fatalf("Analysis failed: %v", err)
+	}
+
+	output, err := os.ReadFile(config.OutputFile)
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+	outputStr := string(output)
+
+	for _, expected := range []string{
+		"SQL Query Analysis Report",
+		"QUERY COMPARISON SUMMARY",
+		"Execution 1: Request req-before",
+		"Execution 2: Request req-after",
+		"req-before",
+		"req-after",
+		"Server: https://api.example.com/graphql",
+		"users",
+	} {
+		if !strings.Contains(outputStr, expected) {
+			t.Errorf("Output missing expected text: %s", expected)
+		}
+	}
+
+	if strings.Contains(outputStr, "ID: 0") {
+		t.Error("Expected fixtures not to be labelled with a zero ID")
+	}
+
+	// An unknown fixture version is rejected rather than misread
+	badPath := filepath.Join(tmpDir, "bad.json")
+	os.WriteFile(badPath, []byte(`{"version": 99}`), 0644)
+	config.Fixtures[1] = badPath
+	if err := runFixtureAnalysis(config); err == nil {
+		t.Error("Expected error for unsupported fixture version")
+	}
+}
+
+// writeFixture writes a fixture with one users query taking duration milliseconds
+func writeFixture(t *testing.T, dir, name, requestID string, duration float64) string {
+	t.Helper()
+	fixture := store.ExecutionFixture{
+		Version:     store.FixtureVersion,
+		DisplayName: "GetUser",
+		Request: store.FixtureRequest{
+			Body:            `{"query": "{ user(id: 1) { name } }"}`,
+			RequestIDHeader: requestID,
+			ServerURL:       "https://api.example.com/graphql",
+			ExecutedAt:      time.Now(),
+		},
+		Response: store.FixtureResponse{StatusCode: 200, Body: `{"data":{"user":{"name":"Ada"}}}`, DurationMS: 100},
+		SQLQueries: []store.SQLQuery{
+			{
+				Query:           "SELECT * FROM users WHERE id = $1",
+				NormalizedQuery: "SELECT * FROM users WHERE id = $N",
+				DurationMS:      duration,
+				QueriedTable:    "users",
+				Operation:       "SELECT",
+				Rows:            1,
+			},
+		},
+	}
+	data, err := json.Marshal(fixture)
+	if err != nil {
+		t.Fatalf("Failed to encode fixture: %v", err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+	return path
+}
+
+func TestIntegrationJSONReportAndRegressionGate(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	config := Config{
+		Fixtures: []string{
+			writeFixture(t, tmpDir, "before.json", "req-before", 10.0),
+			writeFixture(t, tmpDir, "after.json", "req-after", 40.0),
+		},
+		OutputFile:          filepath.Join(tmpDir, "report.txt"),
+		JSONFile:            filepath.Join(tmpDir, "report.json"),
+		FailOnRegressionPct: 50,
+	}
+
+	var regression *RegressionError
+	if err := runFixtureAnalysis(config); !errors.As(err, &regression) {
+		t.Fatalf("Expected a RegressionError for a 300%% slowdown, got %v", err)
+	}
+	if len(regression.Queries) != 1 || regression.Queries[0].DurationDiffPct != 300 {
+		t.Errorf("Unexpected regressions %+v", regression.Queries)
+	}
+
+	// The JSON report is written even when the gate fails
+	data, err := os.ReadFile(config.JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read JSON report: %v", err)
+	}
+	var report store.ComparisonReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("Failed to parse JSON report: %v", err)
+	}
+	if report.Execution1.RequestID != "req-before" || report.Execution2.QueryCount != 1 {
+		t.Errorf("Unexpected execution summaries %+v %+v", report.Execution1, report.Execution2)
+	}
+	if report.Comparison == nil || report.Comparison.Summary.CommonQueries != 1 {
+		t.Errorf("Expected the comparison with 1 common query, got %+v", report.Comparison)
+	}
+	if len(report.Regressions) != 1 || len(report.Improvements) != 0 {
+		t.Errorf("Expected 1 regression and no improvements, got %d and %d", len(report.Regressions), len(report.Improvements))
+	}
+
+	config.FailOnRegressionPct = 500
+	if err := runFixtureAnalysis(config); err != nil {
+		t.Errorf("Expected no error below the threshold, got %v", err)
+	}
+}
+
+func TestIntegrationBaseline(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	db, err := store.NewStore(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer db.Close()
+
+	createExecution := func(requestID string, duration float64) int64 {
+		id, err := db.CreateRequest(&store.Request{RequestIDHeader: requestID, StatusCode: 200, ExecutedAt: time.Now()})
+		if err != nil {
+			t.Fatalf("Failed to create execution: %v", err)
+		}
+		err = db.SaveSQLQueries(id, []store.SQLQuery{{
+			Query:           "SELECT * FROM users WHERE id = $1",
+			NormalizedQuery: "SELECT * FROM users WHERE id = $N",
+			QueryHash:       store.ComputeQueryHash("SELECT * FROM users WHERE id = $N"),
+			DurationMS:      duration,
+			QueriedTable:    "users",
+			Operation:       "SELECT",
+		}})
+		if err != nil {
+			t.Fatalf("Failed to save SQL queries: %v", err)
+		}
+		return id
+	}
+
+	baselinePath := filepath.Join(tmpDir, "base.json")
+	if err := saveBaseline(db, createExecution("req-base", 10.0), baselinePath); err != nil {
+		t.Fatalf("Failed to save baseline: %v", err)
+	}
+	if err := saveBaseline(db, 999, filepath.Join(tmpDir, "missing.json")); err == nil {
+		t.Error("Expected an error saving a missing execution")
+	}
+
+	config := Config{
+		Baseline:            baselinePath,
+		ExecutionID1:        createExecution("req-current", 40.0),
+		OutputFile:          filepath.Join(tmpDir, "report.txt"),
+		JSONFile:            filepath.Join(tmpDir, "report.json"),
+		FailOnRegressionPct: 50,
+	}
+	var regression *RegressionError
+	if err := runBaselineAnalysis(db, config); !errors.As(err, &regression) {
+		t.Fatalf("Expected a regression against the baseline, got %v", err)
+	}
+
+	data, err := os.ReadFile(config.JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read JSON report: %v", err)
+	}
+	var report store.ComparisonReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("Failed to parse JSON report: %v", err)
+	}
+	if report.Execution1.RequestID != "req-base" || report.Execution2.RequestID != "req-current" {
+		t.Errorf("Expected the baseline compared with the current execution, got %+v %+v", report.Execution1, report.Execution2)
+	}
+
+	// A fixture can stand in for the current execution
+	config.ExecutionID1 = 0
+	config.Fixtures = []string{writeFixture(t, tmpDir, "current.json", "req-fixture", 10.0)}
+	if err := runBaselineAnalysis(nil, config); err != nil {
+		t.Errorf("Expected no regression for an unchanged fixture, got %v", err)
+	}
+}
+
+func TestIntegrationConsistency(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	db, err := store.NewStore(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer db.Close()
+
+	// Each run looks the user up count times
+	createExecution := func(requestID string, count int) int64 {
+		id, err := db.CreateRequest(&store.Request{RequestIDHeader: requestID, StatusCode: 200, ExecutedAt: time.Now()})
+		if err != nil {
+			t.Fatalf("Failed to create execution: %v", err)
+		}
+		queries := make([]store.SQLQuery, count)
+		for i := range queries {
+			queries[i] = store.SQLQuery{
+				Query:           "SELECT * FROM users WHERE id = $1",
+				NormalizedQuery: "SELECT * FROM users WHERE id = $N",
+				QueryHash:       store.ComputeQueryHash("SELECT * FROM users WHERE id = $N"),
+				DurationMS:      5.0,
+				QueriedTable:    "users",
+				Operation:       "SELECT",
+			}
+		}
+		if err := db.SaveSQLQueries(id, queries); err != nil {
+			t.Fatalf("Failed to save SQL queries: %v", err)
+		}
+		return id
+	}
+
+	config := Config{
+		Consistency: true,
+		Executions:  []int64{createExecution("req-1", 1), createExecution("req-2", 3)},
+		Fixtures:    []string{writeFixture(t, tmpDir, "run.json", "req-fixture", 5.0)},
+		OutputFile:  filepath.Join(tmpDir, "report.txt"),
+		JSONFile:    filepath.Join(tmpDir, "report.json"),
+	}
+	if err := runConsistencyAnalysis(db, config); err != nil {
+		t.Fatalf("Analysis failed: %v", err)
+	}
+
+	output, err := os.ReadFile(config.OutputFile)
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+	for _, expected := range []string{
+		"SQL Query Consistency Report",
+		"Run 2: Execution 2, 3 queries (request ID: req-2)",
+		"Run 3: Request req-fixture, 1 queries",
+		"Count per run: 1, 3, 1 (min 1, max 3)",
+	} {
+		if !strings.Contains(string(output), expected) {
+			t.Errorf("Output missing expected text: %s", expected)
+		}
+	}
+
+	data, err := os.ReadFile(config.JSONFile)
+	if err != nil {
+		t.Fatalf("Failed to read JSON report: %v", err)
+	}
+	var analysis sqlexplain.MultiRunQueryAnalysis
+	if err := json.Unmarshal(data, &analysis); err != nil {
+		t.Fatalf("Failed to parse JSON report: %v", err)
+	}
+	if analysis.Runs != 3 || analysis.ConsistencyScore != 0 || len(analysis.VaryingQueries) != 1 {
+		t.Errorf("Expected the users lookup to vary across 3 runs, got %+v", analysis)
+	}
+
+	if err := runConsistencyAnalysis(db, Config{Executions: []int64{999}}); err == nil {
+		t.Error("Expected an error for a missing execution")
+	}
+}
+
 //go:fix inline
 func uintPtr(u uint) *uint {
 	return new(u)
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -15,28 +17,122 @@ type Config struct {
 	DBPath       string
 	ExecutionID1 int64
 	ExecutionID2 int64
+	Fixtures     []string
+	Executions   []int64 // Runs for -consistency
 	OutputFile   string
+	JSONFile     string
 	Verbose      bool
+	Baseline     string // Fixture to compare a single execution against
+	SaveBaseline string // Where to save -exec1 as a baseline instead of comparing
+	Consistency  bool   // Compare query counts across -exec or -fixture runs
+
+	FailOnRegressionPct float64 // Exit with regressionExitCode above this slowdown; 0 disables
+}
+
+// fixtureList collects repeated -fixture flags
+type fixtureList []string
+
+func (f *fixtureList) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *fixtureList) Set(value string) error {
+	*f = append(*f, value)
+	return nil
 }
 
 func main() {
 	config := parseFlags()
 
-	if config.ExecutionID1 == 0 || config.ExecutionID2 == 0 {
-		flag.Usage()
-		fmt.Fprintf(os.Stderr, "\nError: Both execution IDs are required\n")
-		os.Exit(1)
+	if config.FailOnRegressionPct < 0 {
+		usageError("-fail-on-regression-pct must not be negative")
 	}
 
-	// Open database
-	db, err := store.NewStore(config.DBPath)
+	switch {
+	case config.Consistency:
+		if config.ExecutionID1 != 0 || config.ExecutionID2 != 0 || config.Baseline != "" || config.SaveBaseline != "" {
+			usageError("-consistency takes only -exec or -fixture runs")
+		}
+		if len(config.Executions)+len(config.Fixtures) < 2 {
+			usageError("-consistency needs at least two runs")
+		}
+		if len(config.Executions) == 0 {
+			exitOnError(runConsistencyAnalysis(nil, config))
+			return
+		}
+		db := openStore(config.DBPath)
+		err := runConsistencyAnalysis(db, config)
+		db.Close()
+		exitOnError(err)
+
+	case config.SaveBaseline != "":
+		if config.ExecutionID1 == 0 || config.ExecutionID2 != 0 || len(config.Fixtures) > 0 {
+			usageError("-save-baseline takes only -exec1")
+		}
+		db := openStore(config.DBPath)
+		err := saveBaseline(db, config.ExecutionID1, config.SaveBaseline)
+		db.Close()
+		exitOnError(err)
+
+	case config.Baseline != "":
+		// The current run is one stored execution or one fixture
+		if config.ExecutionID2 != 0 || len(config.Fixtures) > 1 || (len(config.Fixtures) == 1) == (config.ExecutionID1 != 0) {
+			usageError("-baseline takes exactly one of -exec1 or -fixture")
+		}
+		if len(config.Fixtures) == 1 {
+			exitOnError(runBaselineAnalysis(nil, config))
+			return
+		}
+		db := openStore(config.DBPath)
+		err := runBaselineAnalysis(db, config)
+		db.Close()
+		exitOnError(err)
+
+	// Fixtures replace the database entirely
+	case len(config.Fixtures) > 0:
+		if len(config.Fixtures) != 2 {
+			usageError("Exactly two fixtures are required")
+		}
+		exitOnError(runFixtureAnalysis(config))
+
+	default:
+		if config.ExecutionID1 == 0 || config.ExecutionID2 == 0 {
+			usageError("Both execution IDs are required")
+		}
+
+		// Run analysis, closing the database before a failure exits
+		db := openStore(config.DBPath)
+		err := runAnalysis(db, config)
+		db.Close()
+		exitOnError(err)
+	}
+}
+
+// usageError prints the usage and msg, then exits
+func usageError(msg string) {
+	flag.Usage()
+	fmt.Fprintf(os.Stderr, "\nError: %s\n", msg)
+	os.Exit(1)
+}
+
+func openStore(path string) *store.Store {
+	db, err := store.NewStore(path)
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
-	defer db.Close()
+	return db
+}
 
-	// Run analysis
-	if err := runAnalysis(db, config); err != nil {
+// exitOnError exits with regressionExitCode for a regression and 1 for any other error
+func exitOnError(err error) {
+	var regression *RegressionError
+	switch {
+	case err == nil:
+		return
+	case errors.As(err, &regression):
+		fmt.Fprintf(os.Stderr, "Regression: %v\n", err)
+		os.Exit(regressionExitCode)
+	default:
 		log.Fatalf("Analysis failed: %v", err)
 	}
 }
@@ -47,11 +143,22 @@ func parseFlags() Config {
 	flag.StringVar(&config.DBPath, "db", "graphql-requests.db", "Path to SQLite database")
 	flag.Int64Var(&config.ExecutionID1, "exec1", 0, "First execution ID (required)")
 	flag.Int64Var(&config.ExecutionID2, "exec2", 0, "Second execution ID (required)")
+	flag.Var((*fixtureList)(&config.Fixtures), "fixture", "Fixture file exported from the viewer (pass twice instead of -exec1/-exec2)")
+	flag.Var((*executionList)(&config.Executions), "exec", "Execution ID to include in -consistency (repeat for each run)")
+	flag.BoolVar(&config.Consistency, "consistency", false, "Report queries whose count varies across repeated -exec or -fixture runs")
 	flag.StringVar(&config.OutputFile, "output", "", "Output file (optional, defaults to stdout)")
+	flag.StringVar(&config.Baseline, "baseline", "", "Baseline file to compare a single -exec1 or -fixture against")
+	flag.StringVar(&config.SaveBaseline, "save-baseline", "", "Save -exec1 as a baseline file instead of comparing")
+	flag.StringVar(&config.JSONFile, "json", "", "Also write the analysis as JSON to this file (optional)")
 	flag.BoolVar(&config.Verbose, "verbose", false, "Verbose output including all queries")
+	flag.Float64Var(&config.FailOnRegressionPct, "fail-on-regression-pct", 0, "Exit with code 3 when a common query slows down by more than this percentage (0 disables)")
 
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s -exec1 <id1> -exec2 <id2> [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s -exec1 <id1> -exec2 <id2> [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s -fixture <a.json> -fixture <b.json> [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s -baseline <base.json> -exec1 <id> [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s -save-baseline <base.json> -exec1 <id>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s -consistency -exec <id> -exec <id> [-exec <id>...] [options]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Analyze and compare SQL queries from two request executions.\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
@@ -63,35 +170,65 @@ func parseFlags() Config {
 
 func runAnalysis(db *store.Store, config Config) error {
 	// Get execution details for both requests
-	exec1, err := db.GetRequestDetail(config.ExecutionID1)
+	exec1, err := getExecution(db, config.ExecutionID1)
 	if err != nil {
-		return fmt.Errorf("failed to get execution %d: %w", config.ExecutionID1, err)
+		return err
 	}
-	if exec1 == nil {
-		return fmt.Errorf("execution %d not found", config.ExecutionID1)
+	exec2, err := getExecution(db, config.ExecutionID2)
+	if err != nil {
+		return err
 	}
 
-	exec2, err := db.GetRequestDetail(config.ExecutionID2)
+	return writeAnalysis(exec1, exec2, config)
+}
+
+func getExecution(db *store.Store, id int64) (*store.RequestDetailResponse, error) {
+	exec, err := db.GetRequestDetail(id)
 	if err != nil {
-		return fmt.Errorf("failed to get execution %d: %w", config.ExecutionID2, err)
+		return nil, fmt.Errorf("failed to get execution %d: %w", id, err)
 	}
-	if exec2 == nil {
-		return fmt.Errorf("execution %d not found", config.ExecutionID2)
+	if exec == nil {
+		return nil, fmt.Errorf("execution %d not found", id)
+	}
+	return exec, nil
+}
+
+// runFixtureAnalysis compares two exported fixtures without a database
+func runFixtureAnalysis(config Config) error {
+	exec1, err := loadFixture(config.Fixtures[0])
+	if err != nil {
+		return err
+	}
+	exec2, err := loadFixture(config.Fixtures[1])
+	if err != nil {
+		return err
+	}
+
+	return writeAnalysis(exec1, exec2, config)
+}
+
+func loadFixture(path string) (*store.RequestDetailResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
 	}
 
-	// Convert to QueryWithPlan format for sqlexplain package
-	queries1 := convertToQueryWithPlan(exec1.SQLQueries, fmt.Sprintf("Execution %d", config.ExecutionID1))
-	queries2 := convertToQueryWithPlan(exec2.SQLQueries, fmt.Sprintf("Execution %d", config.ExecutionID2))
+	var fixture store.ExecutionFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	if fixture.Version != store.FixtureVersion {
+		return nil, fmt.Errorf("fixture %s has unsupported version %d", path, fixture.Version)
+	}
 
-	// Perform query comparison
-	comparison := sqlexplain.CompareQuerySets(queries1, queries2)
+	return fixture.RequestDetail(), nil
+}
 
-	// Analyze index usage for each set
-	indexAnalysis1 := sqlexplain.AnalyzeIndexUsage(queries1)
-	indexAnalysis2 := sqlexplain.AnalyzeIndexUsage(queries2)
+func writeAnalysis(exec1, exec2 *store.RequestDetailResponse, config Config) error {
+	report := store.CompareExecutions(exec1, exec2)
 
 	// Generate output
-	output := generateOutput(exec1, exec2, comparison, indexAnalysis1, indexAnalysis2, config.Verbose)
+	output := generateOutput(exec1, exec2, report.Comparison, report.IndexAnalysis1, report.IndexAnalysis2, config.Verbose)
 
 	// Write to file or stdout
 	if config.OutputFile != "" {
@@ -103,29 +240,14 @@ func runAnalysis(db *store.Store, config Config) error {
 		fmt.Print(output)
 	}
 
-	return nil
-}
-
-func convertToQueryWithPlan(queries []store.SQLQuery, operationName string) []sqlexplain.QueryWithPlan {
-	result := make([]sqlexplain.QueryWithPlan, 0, len(queries))
-
-	for _, q := range queries {
-		qwp := sqlexplain.QueryWithPlan{
-			Query:           q.Query,
-			NormalizedQuery: q.NormalizedQuery,
-			OperationName:   operationName,
-			Timestamp:       q.CreatedAt.Unix(),
-			DurationMS:      q.DurationMS,
-			QueriedTable:    q.QueriedTable,
-			Operation:       q.Operation,
-			Rows:            q.Rows,
-			ExplainPlan:     q.ExplainPlan,
-			Variables:       q.Variables,
+	if config.JSONFile != "" {
+		if err := writeJSONReport(config.JSONFile, report); err != nil {
+			return err
 		}
-		result = append(result, qwp)
+		log.Printf("JSON analysis written to %s", config.JSONFile)
 	}
 
-	return result
+	return checkRegressions(report.Comparison, config.FailOnRegressionPct)
 }
 
 func generateOutput(exec1, exec2 *store.RequestDetailResponse, comparison *sqlexplain.ExplainPlanComparison,
@@ -139,7 +261,7 @@ func generateOutput(exec1, exec2 *store.RequestDetailResponse, comparison *sqlex
 	// Execution Summary
 	sb.WriteString("EXECUTION DETAILS\n")
 	sb.WriteString(strings.Repeat("-", 50) + "\n")
-	sb.WriteString(fmt.Sprintf("Execution 1 (ID: %d)\n", exec1.Execution.ID))
+	sb.WriteString(fmt.Sprintf("Execution 1: %s\n", store.ExecutionLabel(exec1, 1)))
 	if exec1.Execution.RequestIDHeader != "" {
 		sb.WriteString(fmt.Sprintf("  Request ID: %s\n", exec1.Execution.RequestIDHeader))
 	}
@@ -152,9 +274,12 @@ func generateOutput(exec1, exec2 *store.RequestDetailResponse, comparison *sqlex
 	if exec1.Execution.Server != nil {
 		sb.WriteString(fmt.Sprintf("  Server: %s\n", exec1.Execution.Server.Name))
 	}
+	if exec1.Execution.BuildVersion != "" {
+		sb.WriteString(fmt.Sprintf("  Build: %s\n", exec1.Execution.BuildVersion))
+	}
 	sb.WriteString("\n")
 
-	sb.WriteString(fmt.Sprintf("Execution 2 (ID: %d)\n", exec2.Execution.ID))
+	sb.WriteString(fmt.Sprintf("Execution 2: %s\n", store.ExecutionLabel(exec2, 2)))
 	if exec2.Execution.RequestIDHeader != "" {
 		sb.WriteString(fmt.Sprintf("  Request ID: %s\n", exec2.Execution.RequestIDHeader))
 	}
@@ -167,6 +292,9 @@ func generateOutput(exec1, exec2 *store.RequestDetailResponse, comparison *sqlex
 	if exec2.Execution.Server != nil {
 		sb.WriteString(fmt.Sprintf("  Server: %s\n", exec2.Execution.Server.Name))
 	}
+	if exec2.Execution.BuildVersion != "" {
+		sb.WriteString(fmt.Sprintf("  Build: %s\n", exec2.Execution.BuildVersion))
+	}
 	sb.WriteString("\n\n")
 
 	// Query Comparison Summary
@@ -302,7 +430,7 @@ func generateOutput(exec1, exec2 *store.RequestDetailResponse, comparison *sqlex
 				if i >= 3 { // Show top 3
 					break
 				}
-				sb.WriteString(fmt.Sprintf("  %d. [%s] %s\n", i+1, rec.Priority, rec.QueriedTable))
+				sb.WriteString(fmt.Sprintf("  %d. [%s, score %.0f] %s\n", i+1, rec.Priority, rec.Score, rec.QueriedTable))
 				sb.WriteString(fmt.Sprintf("     Columns: %v\n", rec.Columns))
 				sb.WriteString(fmt.Sprintf("     Reason: %s\n", rec.Reason))
 				sb.WriteString(fmt.Sprintf("     Impact: %s\n", rec.EstimatedImpact))
@@ -317,7 +445,7 @@ func generateOutput(exec1, exec2 *store.RequestDetailResponse, comparison *sqlex
 				if i >= 3 { // Show top 3
 					break
 				}
-				sb.WriteString(fmt.Sprintf("  %d. [%s] %s\n", i+1, rec.Priority, rec.QueriedTable))
+				sb.WriteString(fmt.Sprintf("  %d. [%s, score %.0f] %s\n", i+1, rec.Priority, rec.Score, rec.QueriedTable))
 				sb.WriteString(fmt.Sprintf("     Columns: %v\n", rec.Columns))
 				sb.WriteString(fmt.Sprintf("     Reason: %s\n", rec.Reason))
 				sb.WriteString(fmt.Sprintf("     Impact: %s\n", rec.EstimatedImpact))
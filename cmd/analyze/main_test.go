@@ -9,48 +9,6 @@ import (
 	"docker-log-parser/pkg/store"
 )
 
-func TestConvertToQueryWithPlan(t *testing.T) {
-	now := time.Now()
-	queries := []store.SQLQuery{
-		{
-			Query:           "SELECT * FROM users WHERE id = $1",
-			NormalizedQuery: "SELECT * FROM users WHERE id = $N",
-			DurationMS:      10.5,
-			QueriedTable:    "users",
-			Operation:       "SELECT",
-			Rows:            1,
-			CreatedAt:       now,
-		},
-		{
-			Query:           "SELECT * FROM posts WHERE user_id = $1",
-			NormalizedQuery: "SELECT * FROM posts WHERE user_id = $N",
-			DurationMS:      25.3,
-			QueriedTable:    "posts",
-			Operation:       "SELECT",
-			Rows:            10,
-			CreatedAt:       now,
-		},
-	}
-
-	result := convertToQueryWithPlan(queries, "TestOp")
-
-	if len(result) != 2 {
-		t.Errorf("Expected 2 queries, got %d", len(result))
-	}
-
-	if result[0].Query != queries[0].Query {
-		t.Errorf("Query mismatch: expected %s, got %s", queries[0].Query, result[0].Query)
-	}
-
-	if result[0].OperationName != "TestOp" {
-		t.Errorf("OperationName mismatch: expected TestOp, got %s", result[0].OperationName)
-	}
-
-	if result[0].DurationMS != 10.5 {
-		t.Errorf("DurationMS mismatch: expected 10.5, got %f", result[0].DurationMS)
-	}
-}
-
 func TestGenerateOutput(t *testing.T) {
 	now := time.Now()
 
@@ -94,8 +52,8 @@ func TestGenerateOutput(t *testing.T) {
 		},
 	}
 
-	queries1 := convertToQueryWithPlan(exec1.SQLQueries, "Exec1")
-	queries2 := convertToQueryWithPlan(exec2.SQLQueries, "Exec2")
+	queries1 := store.QueriesWithPlan(exec1.SQLQueries, "Exec1")
+	queries2 := store.QueriesWithPlan(exec2.SQLQueries, "Exec2")
 
 	comparison := sqlexplain.CompareQuerySets(queries1, queries2)
 	indexAnalysis1 := sqlexplain.AnalyzeIndexUsage(queries1)
@@ -116,11 +74,11 @@ func TestGenerateOutput(t *testing.T) {
 		t.Error("Output should contain comparison summary section")
 	}
 
-	if !strings.Contains(output, "Execution 1 (ID: 1)") {
+	if !strings.Contains(output, "Execution 1: Execution 1") {
 		t.Error("Output should contain execution 1 details")
 	}
 
-	if !strings.Contains(output, "Execution 2 (ID: 2)") {
+	if !strings.Contains(output, "Execution 2: Execution 2") {
 		t.Error("Output should contain execution 2 details")
 	}
 
@@ -164,8 +122,8 @@ func TestGenerateOutputVerbose(t *testing.T) {
 		SQLQueries: []store.SQLQuery{},
 	}
 
-	queries1 := convertToQueryWithPlan(exec1.SQLQueries, "Exec1")
-	queries2 := convertToQueryWithPlan(exec2.SQLQueries, "Exec2")
+	queries1 := store.QueriesWithPlan(exec1.SQLQueries, "Exec1")
+	queries2 := store.QueriesWithPlan(exec2.SQLQueries, "Exec2")
 
 	comparison := sqlexplain.CompareQuerySets(queries1, queries2)
 	indexAnalysis1 := sqlexplain.AnalyzeIndexUsage(queries1)
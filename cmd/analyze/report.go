@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"docker-log-parser/pkg/sqlexplain"
+	"docker-log-parser/pkg/store"
+)
+
+// regressionExitCode is the exit code when a query slowed down by more than
+// -fail-on-regression-pct, distinct from the exit code for a failed analysis
+const regressionExitCode = 3
+
+// RegressionError reports the common queries that slowed down by more than the
+// -fail-on-regression-pct threshold
+type RegressionError struct {
+	ThresholdPct float64
+	Queries      []sqlexplain.QueryPlanComparison
+}
+
+func (e *RegressionError) Error() string {
+	worst := e.Queries[0]
+	return fmt.Sprintf("%d queries slowed down by more than %.1f%%; worst %.1f%%: %s",
+		len(e.Queries), e.ThresholdPct, worst.DurationDiffPct, worst.NormalizedQuery)
+}
+
+// writeJSONReport writes the report given with -json
+func writeJSONReport(path string, report *store.ComparisonReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write JSON report: %w", err)
+	}
+	return nil
+}
+
+// checkRegressions returns a RegressionError when a query common to both executions
+// slowed down by more than thresholdPct. Common queries are checked rather than the
+// performance differences, so thresholds below their 20% cut off still apply.
+func checkRegressions(comparison *sqlexplain.ExplainPlanComparison, thresholdPct float64) error {
+	if thresholdPct <= 0 {
+		return nil
+	}
+
+	var slower []sqlexplain.QueryPlanComparison
+	for _, comp := range comparison.CommonQueries {
+		if comp.DurationDiffPct > thresholdPct {
+			slower = append(slower, comp)
+		}
+	}
+	if len(slower) == 0 {
+		return nil
+	}
+	sort.Slice(slower, func(i, j int) bool {
+		return slower[i].DurationDiffPct > slower[j].DurationDiffPct
+	})
+	return &RegressionError{ThresholdPct: thresholdPct, Queries: slower}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"docker-log-parser/pkg/httputil"
@@ -31,6 +32,9 @@ type Config struct {
 	List             bool
 	Delete           int64
 	BatchMode        bool
+	Parallel         int // Requests executed at once in batch mode
+	RequestIDPrefix  string
+	RequestIDs       *httputil.RequestIDSequence // Request IDs sent with executions; nil for random IDs
 }
 
 func main() {
@@ -39,6 +43,16 @@ func main() {
 
 	config := parseFlags()
 
+	if config.Parallel < 1 {
+		log.Fatalf("Invalid -parallel %d: must be at least 1", config.Parallel)
+	}
+
+	requestIDs, err := httputil.NewRequestIDSequence(config.RequestIDPrefix)
+	if err != nil {
+		log.Fatalf("Invalid -request-id-prefix: %v", err)
+	}
+	config.RequestIDs = requestIDs
+
 	// Open database
 	db, err := store.NewStore(config.DBPath)
 	if err != nil {
@@ -93,8 +107,10 @@ func parseFlags() Config {
 	flag.StringVar(&config.ExperimentalMode, "experimental", os.Getenv("X_GLUE_EXPERIMENTAL_MODE"), "x-glue-experimental-mode header value")
 	flag.BoolVar(&config.Execute, "execute", true, "Execute the request immediately (default: true)")
 	flag.BoolVar(&config.BatchMode, "batch", false, "Execute all requests in batch mode (for directory processing)")
+	flag.IntVar(&config.Parallel, "parallel", 1, "Number of requests to execute at once in batch mode (1 runs them one after another)")
 	flag.BoolVar(&config.List, "list", false, "List all saved requests")
 	flag.Int64Var(&config.Delete, "delete", 0, "Delete request by ID")
+	flag.StringVar(&config.RequestIDPrefix, "request-id-prefix", "", "Send request IDs like <prefix>-0001 instead of random ones, numbered across the run")
 
 	flag.Parse()
 	return config
@@ -272,13 +288,8 @@ func handleDirectory(db *store.Store, config Config) error {
 
 	// Execute requests if batch mode is enabled
 	if config.BatchMode && len(requestIDs) > 0 {
-		log.Printf("executing %d requests in batch mode", len(requestIDs))
-		for i, reqID := range requestIDs {
-			log.Printf("executing request %d/%d (ID: %d)", i+1, len(requestIDs), reqID)
-			if err := executeRequest(db, reqID, config); err != nil {
-				log.Printf("failed to execute request %d: %v", reqID, err)
-			}
-		}
+		log.Printf("executing %d requests in batch mode, %d at a time", len(requestIDs), config.Parallel)
+		executeBatch(db, requestIDs, config)
 		log.Printf("batch execution completed")
 	} else if len(requestIDs) > 0 {
 		log.Printf("requests saved; use -batch flag to execute them immediately, or use the web UI")
@@ -287,6 +298,25 @@ func handleDirectory(db *store.Store, config Config) error {
 	return nil
 }
 
+// executeBatch executes requestIDs, up to config.Parallel at once. Each execution sends
+// its own correlation ID and collects logs from its own stream, so concurrent executions
+// only collect their own logs.
+func executeBatch(db *store.Store, requestIDs []int64, config Config) {
+	sem := make(chan struct{}, config.Parallel)
+	var wg sync.WaitGroup
+	for i, reqID := range requestIDs {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			log.Printf("executing request %d/%d (ID: %d)", i+1, len(requestIDs), reqID)
+			if err := executeRequest(db, reqID, config); err != nil {
+				log.Printf("failed to execute request %d: %v", reqID, err)
+			}
+		})
+	}
+	wg.Wait()
+}
+
 func executeRequest(db *store.Store, requestID int64, config Config) error {
 	// Get request details
 	req, err := db.GetSampleQuery(requestID)
@@ -304,7 +334,10 @@ func executeRequest(db *store.Store, requestID int64, config Config) error {
 	}
 	defer docker.Close()
 
-	ctx := context.Background()
+	// Cancelled once the logs are collected, so the streams stop instead of piling up
+	// across a batch
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	// Start log collection
 	logChan := make(chan logs.ContainerMessage, 10000)
@@ -319,38 +352,48 @@ func executeRequest(db *store.Store, requestID int64, config Config) error {
 		}
 	}
 
-	// Generate request ID
-	requestIDHeader := httputil.GenerateRequestID()
-
 	// Get server info for execution
-	var url, bearerToken, devID, experimentalMode string
+	var url, bearerToken, devID, experimentalMode, correlationHeader, correlationFormat string
 	var serverIDForExec *uint
 	if req.Server != nil {
 		url = req.Server.URL
 		bearerToken = req.Server.BearerToken
 		devID = req.Server.DevID
 		experimentalMode = req.Server.ExperimentalMode
+		correlationHeader = req.Server.CorrelationHeader
+		correlationFormat = req.Server.CorrelationFormat
 		serverIDForExec = &req.Server.ID
 	}
 
+	// Generate the correlation header sent with the request
+	correlation := config.RequestIDs.NewCorrelation(correlationHeader, correlationFormat)
+	log.Printf("sending request %d with %s: %s", requestID, correlation.Header, correlation.ID)
+
 	// Execute request
 	sampleID := uint(requestID)
 	execution := &store.Request{
-		SampleID:    &sampleID,
-		ServerID:    serverIDForExec,
-		RequestBody: req.RequestData,
-		ExecutedAt:  time.Now(),
+		SampleID:        &sampleID,
+		ServerID:        serverIDForExec,
+		URL:             url,
+		RequestIDHeader: correlation.ID,
+		RequestBody:     req.RequestData,
+		ExecutedAt:      time.Now(),
 	}
 
 	startTime := time.Now()
-	statusCode, responseBody, responseHeaders, err := httputil.MakeHTTPRequest(url, []byte(req.RequestData), requestIDHeader, bearerToken, devID, experimentalMode)
+	statusCode, responseBody, responseHeaders, err := httputil.MakeHTTPRequest(ctx, url, []byte(req.RequestData), correlation, bearerToken, devID, experimentalMode)
 	execution.DurationMS = time.Since(startTime).Milliseconds()
 	execution.StatusCode = statusCode
 	execution.ResponseBody = responseBody
 	execution.ResponseHeaders = responseHeaders
+	if req.Server != nil {
+		execution.BuildVersion = req.Server.ResponseBuildVersion(responseHeaders)
+	}
 
 	if err != nil {
 		execution.Error = err.Error()
+	} else if responseError := httputil.DetectResponseError(httputil.DetectProtocol(req.RequestData), statusCode, responseBody); responseError != "" {
+		execution.Error = responseError
 	}
 
 	// Save execution
@@ -363,7 +406,7 @@ func executeRequest(db *store.Store, requestID int64, config Config) error {
 	time.Sleep(500 * time.Millisecond)
 
 	// Collect logs
-	collectedLogs := collectLogs(requestIDHeader, logChan, config.Timeout)
+	collectedLogs := collectLogs(correlation, logChan, config.Timeout)
 
 	// Save logs
 	if len(collectedLogs) > 0 {
@@ -383,14 +426,14 @@ func executeRequest(db *store.Store, requestID int64, config Config) error {
 	return nil
 }
 
-func collectLogs(requestID string, logChan <-chan logs.ContainerMessage, timeout time.Duration) []logs.ContainerMessage {
+func collectLogs(correlation httputil.Correlation, logChan <-chan logs.ContainerMessage, timeout time.Duration) []logs.ContainerMessage {
 	collected := []logs.ContainerMessage{}
 	deadline := time.After(timeout)
 
 	for {
 		select {
 		case msg := <-logChan:
-			if matchesRequestID(msg, requestID) {
+			if correlation.Matches(msg) {
 				collected = append(collected, msg)
 			}
 		case <-deadline:
@@ -398,17 +441,3 @@ func collectLogs(requestID string, logChan <-chan logs.ContainerMessage, timeout
 		}
 	}
 }
-
-func matchesRequestID(msg logs.ContainerMessage, requestID string) bool {
-	if msg.Entry == nil || msg.Entry.Fields == nil {
-		return false
-	}
-
-	for _, field := range []string{"request_id", "requestId", "requestID", "req_id"} {
-		if val, ok := msg.Entry.Fields[field]; ok && val == requestID {
-			return true
-		}
-	}
-
-	return false
-}
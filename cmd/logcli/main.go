@@ -0,0 +1,268 @@
+// Command logcli prints the log lines from a container or file that match a filter,
+// one compact line per entry, for piping into other tools
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"docker-log-parser/pkg/config"
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/logstore"
+	"docker-log-parser/pkg/theme"
+)
+
+// Config holds the command line options
+type Config struct {
+	Container string
+	File      string // "-" reads stdin
+	Follow    bool
+	Since     time.Duration
+
+	Levels        string
+	Search        string
+	CaseSensitive bool
+	WholeWord     bool
+	Regex         string
+	FieldFilters  flagList // name=value
+	HasFields     flagList // Fields that must be set, to any value
+	RangeFilters  flagList // e.g. duration>100
+	MinDuration   float64
+
+	Fields string
+	Color  string // auto, always or never
+	Theme  string // Color preset: dark or light
+	Raw    bool
+
+	ConfigFile string // Viewer config whose parser patterns apply
+}
+
+// flagList collects a repeated flag
+type flagList []string
+
+func (f *flagList) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *flagList) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func main() {
+	config := parseFlags()
+
+	if (config.Container == "") == (config.File == "") {
+		flag.Usage()
+		fmt.Fprintf(os.Stderr, "\nError: Exactly one of -container or -file is required\n")
+		os.Exit(2)
+	}
+
+	if err := run(config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func parseFlags() Config {
+	var config Config
+
+	flag.StringVar(&config.Container, "container", "", "Docker container name or ID to read logs from")
+	flag.StringVar(&config.File, "file", "", "Log file to read, or - for stdin")
+	flag.BoolVar(&config.Follow, "follow", false, "Keep streaming new container logs")
+	flag.DurationVar(&config.Since, "since", 15*time.Minute, "How far back to read container logs")
+	flag.StringVar(&config.Levels, "level", "", "Comma separated levels to show, e.g. ERR,WRN")
+	flag.StringVar(&config.Search, "search", "", "Whitespace separated terms that must all match")
+	flag.BoolVar(&config.CaseSensitive, "case", false, "Match search terms case sensitively")
+	flag.BoolVar(&config.WholeWord, "word", false, "Match search terms on word boundaries")
+	flag.StringVar(&config.Regex, "regex", "", "Regular expression matched against the message, raw line and field values")
+	flag.Var(&config.FieldFilters, "field", "Field filter name=value (repeatable)")
+	flag.Var(&config.HasFields, "has-field", "Only show entries where this field is set, to any value (repeatable)")
+	flag.Var(&config.RangeFilters, "range", "Numeric field filter such as duration>100 (repeatable)")
+	flag.Float64Var(&config.MinDuration, "min-duration", 0, "Only show entries with a parsed duration above this many milliseconds")
+	flag.StringVar(&config.Fields, "fields", "", "Comma separated fields to print as name=value columns, e.g. trace_id,duration")
+	flag.StringVar(&config.Color, "color", "auto", "Color output: auto, always or never")
+	flag.StringVar(&config.Theme, "theme", theme.PresetDark, "Color preset: dark or light")
+	flag.BoolVar(&config.Raw, "raw", false, "Print matching raw lines instead of parsed columns")
+	flag.StringVar(&config.ConfigFile, "config", "", "Viewer config file whose parser patterns apply (env: CONFIG_FILE)")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s -container <name|id> [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s -file <path|-> [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Print parsed log lines matching the filters, one per line.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+	return config
+}
+
+// filterOptions builds the logstore filter from the command line options
+func (c Config) filterOptions() (logstore.FilterOptions, error) {
+	opts := logstore.FilterOptions{
+		SearchTerms:   strings.Fields(c.Search),
+		HasFields:     c.HasFields,
+		CaseSensitive: c.CaseSensitive,
+		WholeWord:     c.WholeWord,
+		MinDurationMS: c.MinDuration,
+	}
+
+	if c.Levels != "" {
+		opts.Levels = logstore.ExpandLevels(splitList(c.Levels))
+	}
+
+	if c.Regex != "" {
+		pattern, err := regexp.Compile(c.Regex)
+		if err != nil {
+			return opts, fmt.Errorf("invalid -regex: %w", err)
+		}
+		opts.Pattern = pattern
+	}
+
+	for _, expr := range c.FieldFilters {
+		name, value, ok := strings.Cut(expr, "=")
+		if !ok || name == "" {
+			return opts, fmt.Errorf("invalid -field %q, expected name=value", expr)
+		}
+		opts.FieldFilters = append(opts.FieldFilters, logstore.FieldFilter{Name: name, Value: value})
+	}
+
+	for _, expr := range c.RangeFilters {
+		filter, err := logstore.ParseFieldRangeFilter(expr)
+		if err != nil {
+			return opts, err
+		}
+		opts.RangeFilters = append(opts.RangeFilters, filter)
+	}
+
+	return opts, nil
+}
+
+// useColor resolves the -color option against whether stdout is a terminal
+func (c Config) useColor() (bool, error) {
+	switch c.Color {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		info, err := os.Stdout.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+	}
+	return false, fmt.Errorf("invalid -color %q, expected auto, always or never", c.Color)
+}
+
+// loadPatterns sets the parser patterns from the viewer's config file at path, or
+// CONFIG_FILE when path is empty, so custom formats parse as they do in the viewer
+func loadPatterns(path string) error {
+	cfg, err := config.Load([]string{"-config", path})
+	if err != nil {
+		return err
+	}
+	patterns, err := cfg.NamedPatterns()
+	if err != nil {
+		return err
+	}
+	logs.SetNamedPatterns(patterns)
+	return nil
+}
+
+func run(config Config) error {
+	if err := loadPatterns(config.ConfigFile); err != nil {
+		return err
+	}
+	opts, err := config.filterOptions()
+	if err != nil {
+		return err
+	}
+	color, err := config.useColor()
+	if err != nil {
+		return err
+	}
+	colors, err := theme.Preset(config.Theme)
+	if err != nil {
+		return err
+	}
+
+	// The Docker client logs stream progress; only warnings belong next to the output
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	matches := opts.Matcher()
+	out := &printer{w: os.Stdout, fields: splitList(config.Fields), color: color, theme: colors, raw: config.Raw}
+	emit := func(msg *logs.ContainerMessage) error {
+		if !matches(msg) {
+			return nil
+		}
+		return out.Print(msg)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if config.File != "" {
+		err = readFile(config.File, emit)
+	} else {
+		err = readContainer(ctx, config, emit)
+	}
+
+	// A closed pipe, as from head, is a normal way for the output to end
+	if errors.Is(err, syscall.EPIPE) {
+		return nil
+	}
+	return err
+}
+
+func readFile(path string, emit func(*logs.ContainerMessage) error) error {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	return readEntries(r, func(entry *logs.LogEntry) error {
+		return emit(&logs.ContainerMessage{Entry: entry})
+	})
+}
+
+func readContainer(ctx context.Context, config Config, emit func(*logs.ContainerMessage) error) error {
+	docker, err := logs.NewDockerClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer docker.Close()
+
+	container, err := resolveContainer(ctx, docker, config.Container)
+	if err != nil {
+		return err
+	}
+	return streamContainer(ctx, docker, container.ID, time.Now().Add(-config.Since), config.Follow, emit)
+}
+
+// splitList splits a comma separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"docker-log-parser/pkg/logs"
+)
+
+const sampleLogs = `{"level":"info","msg":"Logger configured"}
+
+{"time":"2025-10-03T21:53:26Z","level":"trace","message":"[sql]: SELECT * FROM users","db.rows":"1","duration":"21.697855"}
+{"time":"2025-10-03T21:53:27Z","level":"error","message":"request failed","request_id":"e51c112c","status":500}
+`
+
+func TestReadEntries(t *testing.T) {
+	var entries []*logs.LogEntry
+	err := readEntries(strings.NewReader(sampleLogs), func(entry *logs.LogEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("readEntries: %v", err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	if got := entries[1].Fields["duration"]; got != "21.697855" {
+		t.Errorf("Expected the SQL entry's fields to be parsed, got duration %q", got)
+	}
+}
+
+func TestFilterAndPrint(t *testing.T) {
+	config := Config{
+		Levels:       "err,trc",
+		RangeFilters: flagList{"status>=500"},
+		Fields:       "request_id,duration",
+	}
+	opts, err := config.filterOptions()
+	if err != nil {
+		t.Fatalf("filterOptions: %v", err)
+	}
+	matches := opts.Matcher()
+
+	var out bytes.Buffer
+	p := &printer{w: &out, fields: splitList(config.Fields)}
+	err = readEntries(strings.NewReader(sampleLogs), func(entry *logs.LogEntry) error {
+		msg := &logs.ContainerMessage{Entry: entry}
+		if !matches(msg) {
+			return nil
+		}
+		return p.Print(msg)
+	})
+	if err != nil {
+		t.Fatalf("readEntries: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 matching line, got %d:\n%s", len(lines), out.String())
+	}
+	line := lines[0]
+	if !strings.Contains(line, "ERR") || !strings.Contains(line, "request failed") {
+		t.Errorf("Expected level and message in %q", line)
+	}
+	if !strings.HasSuffix(line, " request_id=e51c112c duration=-") {
+		t.Errorf("Expected selected fields as trailing columns in %q", line)
+	}
+	if strings.Contains(line, "\033[") {
+		t.Errorf("Expected no color codes without color in %q", line)
+	}
+}
+
+func TestFilterOptionsErrors(t *testing.T) {
+	tests := []Config{
+		{Regex: "("},
+		{FieldFilters: flagList{"no-equals"}},
+		{RangeFilters: flagList{"duration"}},
+	}
+	for _, config := range tests {
+		if _, err := config.filterOptions(); err == nil {
+			t.Errorf("Expected an error for %+v", config)
+		}
+	}
+}
+
+func TestQuoteValue(t *testing.T) {
+	tests := map[string]string{
+		"abc":       "abc",
+		"two words": `"two words"`,
+		"":          `""`,
+		`say "hi"`:  `"say \"hi\""`,
+		"12.5ms":    "12.5ms",
+	}
+	for value, expected := range tests {
+		if got := quoteValue(value); got != expected {
+			t.Errorf("quoteValue(%q) = %s, expected %s", value, got, expected)
+		}
+	}
+}
+
+func TestLoadPatterns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	err := os.WriteFile(path, []byte(`[[parser.patterns]]
+name = "deploy"
+regex = '^deploy (?P<app>\S+) took (?P<duration>\S+)$'
+`), 0644)
+	if err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	t.Cleanup(func() { logs.SetNamedPatterns(nil) })
+
+	if err := loadPatterns(path); err != nil {
+		t.Fatalf("loadPatterns: %v", err)
+	}
+	entry := logs.ParseLogLine("deploy billing took 12s")
+	if entry.Fields["app"] != "billing" || entry.Fields["duration"] != "12s" {
+		t.Errorf("Expected the config's pattern to extract fields, got %v", entry.Fields)
+	}
+
+	if err := loadPatterns(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Error("Expected an error for a missing config file")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/theme"
+)
+
+// ansiReset ends a colored span
+const ansiReset = "\033[0m"
+
+// printer writes one line per entry: timestamp, level, message, then the selected fields
+// as name=value pairs. Values containing spaces or quotes are quoted, so every pair is
+// one whitespace separated column.
+type printer struct {
+	w      io.Writer
+	fields []string // Fields to print, in order; printed as name=- when absent
+	color  bool
+	theme  theme.Theme
+	raw    bool // Print the raw line instead of the parsed columns
+}
+
+func (p *printer) Print(msg *logs.ContainerMessage) error {
+	entry := msg.Entry
+	if p.raw {
+		_, err := fmt.Fprintln(p.w, entry.Raw)
+		return err
+	}
+
+	var b strings.Builder
+	timestamp := entry.Timestamp
+	if timestamp == "" && !msg.Timestamp.IsZero() {
+		timestamp = msg.Timestamp.Format(time.RFC3339Nano)
+	}
+	if timestamp != "" {
+		b.WriteString(p.paint(p.theme.Muted, timestamp))
+		b.WriteByte(' ')
+	}
+
+	level := strings.ToUpper(entry.Level)
+	if level == "" {
+		level = "-"
+	}
+	b.WriteString(p.paint(p.theme.LevelColor(level), fmt.Sprintf("%-5s", level)))
+	b.WriteByte(' ')
+
+	message := entry.Message
+	if message == "" {
+		message = entry.Raw
+	}
+	b.WriteString(strings.ReplaceAll(message, "\n", `\n`))
+
+	for _, name := range p.fields {
+		value, ok := entry.Fields[name]
+		if !ok {
+			value = "-"
+		}
+		b.WriteByte(' ')
+		b.WriteString(p.paint(p.theme.Accent, name+"="))
+		b.WriteString(quoteValue(value))
+	}
+
+	b.WriteByte('\n')
+	_, err := io.WriteString(p.w, b.String())
+	return err
+}
+
+// paint wraps s in a theme color when color is enabled
+func (p *printer) paint(color, s string) string {
+	code := theme.ANSI(color)
+	if !p.color || code == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// quoteValue quotes a field value when it would otherwise split into several columns
+func quoteValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\n\"") {
+		return strconv.Quote(value)
+	}
+	return value
+}
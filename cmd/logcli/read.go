@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"docker-log-parser/pkg/logs"
+)
+
+// idleTimeout is how long a container stream may be quiet before logcli exits, unless
+// following
+const idleTimeout = 2 * time.Second
+
+// readEntries parses r line by line, joining continuation lines onto the entry before
+// them the way the Docker streams do, and calls emit with each complete entry
+func readEntries(r io.Reader, emit func(*logs.LogEntry) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var buffered *logs.LogEntry
+	flush := func() error {
+		if buffered == nil {
+			return nil
+		}
+		entry := buffered
+		buffered = nil
+		return emit(entry)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		if buffered != nil && !logs.IsLikelyNewLogEntry(line) {
+			buffered = logs.ParseLogLine(buffered.Raw + "\n" + trimmed)
+			if strings.Contains(buffered.Message, "[sql]") && len(buffered.Fields) > 0 {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if err := flush(); err != nil {
+			return err
+		}
+
+		entry := logs.ParseLogLine(trimmed)
+		// Entries without fields yet may continue on the next lines
+		if (strings.Contains(entry.Message, "[sql]") && len(entry.Fields) == 0) ||
+			(entry.Timestamp != "" && len(entry.Fields) == 0 && entry.Message != "") {
+			buffered = entry
+			continue
+		}
+		if err := emit(entry); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// resolveContainer finds a running container by ID prefix or name
+func resolveContainer(ctx context.Context, docker *logs.DockerClient, ref string) (logs.Container, error) {
+	containers, err := docker.ListRunningContainers(ctx)
+	if err != nil {
+		return logs.Container{}, err
+	}
+	for _, c := range containers {
+		if c.Name == ref || c.RawName == ref || (len(ref) >= 4 && strings.HasPrefix(c.ID, ref)) {
+			return c, nil
+		}
+	}
+	return logs.Container{}, fmt.Errorf("no running container matches %q", ref)
+}
+
+// streamContainer sends the container's logs from since to emit until the stream ends,
+// ctx is cancelled, or the stream is idle for idleTimeout when not following
+func streamContainer(ctx context.Context, docker *logs.DockerClient, containerID string, since time.Time, follow bool, emit func(*logs.ContainerMessage) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	logChan := make(chan logs.ContainerMessage, 1000)
+	ended := make(chan struct{})
+	if err := docker.StreamLogsSince(ctx, containerID, logChan, func() { close(ended) }, since); err != nil {
+		return err
+	}
+
+	idle := time.NewTimer(idleTimeout)
+	defer idle.Stop()
+	for {
+		select {
+		case msg := <-logChan:
+			if err := emit(&msg); err != nil {
+				return err
+			}
+			idle.Reset(idleTimeout)
+		case <-idle.C:
+			if !follow {
+				return nil
+			}
+		case <-ended:
+			// Send what the stream flushed before it ended
+			for {
+				select {
+				case msg := <-logChan:
+					if err := emit(&msg); err != nil {
+						return err
+					}
+				default:
+					return nil
+				}
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
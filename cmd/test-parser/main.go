@@ -3,14 +3,17 @@ package main
 import (
 	"context"
 	"encoding/csv"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"sort"
 	"strings"
 	"time"
 
+	"docker-log-parser/pkg/config"
 	"docker-log-parser/pkg/logs"
 )
 
@@ -25,6 +28,8 @@ func main() {
 		filterLevel = flag.String("level", "", "Filter by log level (DBG, TRC, INF, WRN, ERR, FATAL)")
 		filterText  = flag.String("search", "", "Filter by text (searches in message and fields)")
 		csvExport   = flag.String("csv", "", "Export to CSV file (path to output file)")
+		jsonOutput  = flag.Bool("json", false, "Print each entry as a JSON object, one per line")
+		configFile  = flag.String("config", "", "Viewer config file whose parser patterns apply (env: CONFIG_FILE)")
 	)
 	flag.Parse()
 
@@ -48,12 +53,15 @@ func main() {
 		fmt.Println("  -level      Filter by log level (DBG, TRC, INF, WRN, ERR, FATAL)")
 		fmt.Println("  -search     Filter by text (searches in message and fields)")
 		fmt.Println("  -csv        Export to CSV file (path to output file)")
+		fmt.Println("  -json       Print each entry as a JSON object, one per line")
+		fmt.Println("  -config     Viewer config file whose parser patterns apply (env: CONFIG_FILE)")
 		fmt.Println("")
 		fmt.Println("Examples:")
 		fmt.Println("  test-parser -file /var/log/app.log -level ERR")
 		fmt.Println("  test-parser -file /var/log/app.log -search \"timeout\"")
 		fmt.Println("  test-parser -file /var/log/app.log -csv output.csv")
 		fmt.Println("  test-parser -file /var/log/app.log -level INF -csv output.csv")
+		fmt.Println("  test-parser -file /var/log/app.log -json | jq .fields")
 		os.Exit(1)
 	}
 
@@ -62,6 +70,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Parse custom formats as the viewer does
+	cfg, err := config.Load([]string{"-config", *configFile})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	patterns, err := cfg.NamedPatterns()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	logs.SetNamedPatterns(patterns)
+
 	// Prepare CSV writer if export is requested
 	var csvWriter *csv.Writer
 	var csvFile *os.File
@@ -73,10 +94,19 @@ func main() {
 		csvLineNumbers = make([]int, 0)
 	}
 
+	printEntry := func(lineNum int, entry *logs.LogEntry) {
+		printLogEntry(lineNum, entry, *debug, *verbose)
+	}
+	if *jsonOutput {
+		// Keep stdout to the entries so it can be piped as NDJSON
+		status = os.Stderr
+		printEntry = newJSONPrinter(os.Stdout)
+	}
+
 	if *containerID != "" {
-		readFromDockerContainer(*containerID, *skip, *follow, *debug, *verbose, *filterLevel, *filterText, &csvEntries, &csvLineNumbers)
+		readFromDockerContainer(*containerID, *skip, *follow, *debug, printEntry, *filterLevel, *filterText, &csvEntries, &csvLineNumbers)
 	} else {
-		readFromLogFile(*logFile, *debug, *verbose, *filterLevel, *filterText, &csvEntries, &csvLineNumbers)
+		readFromLogFile(*logFile, *debug, printEntry, *filterLevel, *filterText, &csvEntries, &csvLineNumbers)
 	}
 
 	// Write CSV file if requested
@@ -84,7 +114,7 @@ func main() {
 		var err error
 		csvFile, err = os.Create(*csvExport)
 		if err != nil {
-			fmt.Printf("Error creating CSV file: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error creating CSV file: %v\n", err)
 			os.Exit(1)
 		}
 		defer csvFile.Close()
@@ -95,17 +125,17 @@ func main() {
 	}
 }
 
-func readFromDockerContainer(containerID string, skip int, follow bool, debug bool, verbose bool, filterLevel string, filterText string, csvEntries *[]*logs.LogEntry, csvLineNumbers *[]int) {
-	fmt.Printf("Reading logs from Docker container: %s\n", containerID)
+func readFromDockerContainer(containerID string, skip int, follow bool, debug bool, printEntry func(int, *logs.LogEntry), filterLevel string, filterText string, csvEntries *[]*logs.LogEntry, csvLineNumbers *[]int) {
+	fmt.Fprintf(status, "Reading logs from Docker container: %s\n", containerID)
 	if follow {
-		fmt.Println("Following logs in real-time...")
+		fmt.Fprintln(status, "Following logs in real-time...")
 	}
-	fmt.Println(strings.Repeat("=", 80))
+	fmt.Fprintln(status, strings.Repeat("=", 80))
 
 	ctx := context.Background()
 	dockerClient, err := logs.NewDockerClient()
 	if err != nil {
-		fmt.Printf("Error creating Docker client: %v\n", err)
+		fmt.Fprintf(status, "Error creating Docker client: %v\n", err)
 		os.Exit(1)
 	}
 	defer dockerClient.Close()
@@ -116,13 +146,13 @@ func readFromDockerContainer(containerID string, skip int, follow bool, debug bo
 
 	containers, err := dockerClient.ListRunningContainers(ctx)
 	if err != nil {
-		fmt.Printf("Error listing containers: %v\n", err)
+		fmt.Fprintf(status, "Error listing containers: %v\n", err)
 	} else {
-		fmt.Printf("Available containers:\n")
+		fmt.Fprintf(status, "Available containers:\n")
 		for _, c := range containers {
-			fmt.Printf("  %s (%s) - %s\n", c.ID, c.Name, c.Image)
+			fmt.Fprintf(status, "  %s (%s) - %s\n", c.ID, c.Name, c.Image)
 		}
-		fmt.Println()
+		fmt.Fprintln(status)
 	}
 
 	// Create log channel
@@ -131,7 +161,7 @@ func readFromDockerContainer(containerID string, skip int, follow bool, debug bo
 	// Start streaming logs
 	err = dockerClient.StreamLogs(ctx, containerID, logChan, nil)
 	if err != nil {
-		fmt.Printf("Error streaming logs: %v\n", err)
+		fmt.Fprintf(status, "Error streaming logs: %v\n", err)
 		os.Exit(1)
 	}
 
@@ -146,7 +176,7 @@ func readFromDockerContainer(containerID string, skip int, follow bool, debug bo
 				continue
 			}
 			if shouldShowEntry(logMsg.Entry, filterLevel, filterText) {
-				printLogEntry(lineCount, logMsg.Entry, debug, verbose)
+				printEntry(lineCount, logMsg.Entry)
 				if csvEntries != nil {
 					*csvEntries = append(*csvEntries, logMsg.Entry)
 					*csvLineNumbers = append(*csvLineNumbers, lineCount)
@@ -155,20 +185,20 @@ func readFromDockerContainer(containerID string, skip int, follow bool, debug bo
 
 		case <-time.After(5 * time.Second):
 			if !follow {
-				fmt.Printf("\nNo more logs available after 5 seconds.\n")
+				fmt.Fprintf(status, "\nNo more logs available after 5 seconds.\n")
 				return
 			}
 		}
 	}
 }
 
-func readFromLogFile(filePath string, debug bool, verbose bool, filterLevel string, filterText string, csvEntries *[]*logs.LogEntry, csvLineNumbers *[]int) {
-	fmt.Printf("Reading logs from file: %s\n", filePath)
-	fmt.Println(strings.Repeat("=", 80))
+func readFromLogFile(filePath string, debug bool, printEntry func(int, *logs.LogEntry), filterLevel string, filterText string, csvEntries *[]*logs.LogEntry, csvLineNumbers *[]int) {
+	fmt.Fprintf(status, "Reading logs from file: %s\n", filePath)
+	fmt.Fprintln(status, strings.Repeat("=", 80))
 
 	file, err := os.Open(filePath)
 	if err != nil {
-		fmt.Printf("Error opening file: %v\n", err)
+		fmt.Fprintf(status, "Error opening file: %v\n", err)
 		os.Exit(1)
 	}
 	defer file.Close()
@@ -183,7 +213,7 @@ func readFromLogFile(filePath string, debug bool, verbose bool, filterLevel stri
 		if bufferedEntry != nil {
 			entryCount++
 			if shouldShowEntry(bufferedEntry, filterLevel, filterText) {
-				printLogEntry(entryCount, bufferedEntry, debug, verbose)
+				printEntry(entryCount, bufferedEntry)
 				if csvEntries != nil {
 					*csvEntries = append(*csvEntries, bufferedEntry)
 					*csvLineNumbers = append(*csvLineNumbers, entryCount)
@@ -212,7 +242,7 @@ func readFromLogFile(filePath string, debug bool, verbose bool, filterLevel stri
 				trimmed := strings.TrimSpace(line)
 				if trimmed == "" {
 					if debug {
-						fmt.Printf("Line %d: [EMPTY LINE]\n", lineCount)
+						fmt.Fprintf(status, "Line %d: [EMPTY LINE]\n", lineCount)
 					}
 					continue
 				}
@@ -238,7 +268,7 @@ func readFromLogFile(filePath string, debug bool, verbose bool, filterLevel stri
 					} else {
 						entryCount++
 						if shouldShowEntry(entry, filterLevel, filterText) {
-							printLogEntry(entryCount, entry, debug, verbose)
+							printEntry(entryCount, entry)
 							if csvEntries != nil {
 								*csvEntries = append(*csvEntries, entry)
 								*csvLineNumbers = append(*csvLineNumbers, entryCount)
@@ -256,7 +286,22 @@ func readFromLogFile(filePath string, debug bool, verbose bool, filterLevel stri
 
 	flushBuffered()
 
-	fmt.Printf("\nProcessed %d lines from file (%d log entries).\n", lineCount, entryCount)
+	fmt.Fprintf(status, "\nProcessed %d lines from file (%d log entries).\n", lineCount, entryCount)
+}
+
+// status receives the progress and error messages printed around the entries
+var status io.Writer = os.Stdout
+
+// newJSONPrinter returns an entry printer writing each entry to w as one JSON object
+// per line
+func newJSONPrinter(w io.Writer) func(int, *logs.LogEntry) {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return func(_ int, entry *logs.LogEntry) {
+		if err := encoder.Encode(entry); err != nil {
+			fmt.Fprintf(status, "Error writing JSON entry: %v\n", err)
+		}
+	}
 }
 
 func printLogEntry(lineNum int, entry *logs.LogEntry, debug bool, verbose bool) {
@@ -324,7 +369,7 @@ func writeCSVFile(writer *csv.Writer, entries []*logs.LogEntry, lineNumbers []in
 	// Write header
 	err := writer.Write(header)
 	if err != nil {
-		fmt.Printf("Error writing CSV header: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error writing CSV header: %v\n", err)
 		return
 	}
 
@@ -349,7 +394,7 @@ func writeCSVFile(writer *csv.Writer, entries []*logs.LogEntry, lineNumbers []in
 
 		err := writer.Write(record)
 		if err != nil {
-			fmt.Printf("Error writing CSV record: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error writing CSV record: %v\n", err)
 		}
 	}
 }
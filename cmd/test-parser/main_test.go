@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docker-log-parser/pkg/logs"
+)
+
+func TestJSONOutputIsNDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	input := `{"level":"info","msg":"Logger configured"}
+{"time":"2025-10-03T21:53:27Z","level":"error","message":"request failed","request_id":"e51c112c","status":500}
+{"time":"2025-10-03T21:53:28Z","level":"error","message":"retry failed","request_id":"f00dcafe"}
+`
+	if err := os.WriteFile(path, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	status = io.Discard
+	defer func() { status = os.Stdout }()
+
+	var out bytes.Buffer
+	readFromLogFile(path, false, newJSONPrinter(&out), "ERR", "", nil, nil)
+
+	var entries []logs.LogEntry
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !json.Valid(line) {
+			t.Fatalf("Expected each line to be a JSON object, got %q", line)
+		}
+		var entry logs.LogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("Unmarshal %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("Expected the 2 ERR entries, got %d", len(entries))
+	}
+	if got := entries[0].Fields["request_id"]; got != "e51c112c" {
+		t.Errorf("Expected fields in the JSON output, got request_id %q", got)
+	}
+	if entries[1].Message != "retry failed" {
+		t.Errorf("Expected entries in input order, got %q second", entries[1].Message)
+	}
+}
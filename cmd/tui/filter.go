@@ -0,0 +1,81 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/logstore"
+)
+
+// filterState is the filter the TUI is showing, in the terms the web UI uses:
+// containers by name, level choices and a whitespace separated search query
+type filterState struct {
+	Containers    []string // Container names; empty shows every container
+	Levels        []string // logstore.LevelGroups labels; empty shows every level
+	Search        string
+	CaseSensitive bool
+	WholeWord     bool
+}
+
+// Options converts the filter to logstore.FilterOptions, resolving container names
+// against containers
+func (f filterState) Options(containers []logs.Container) logstore.FilterOptions {
+	opts := logstore.FilterOptions{}
+
+	if len(f.Containers) > 0 {
+		for _, c := range containers {
+			if slices.Contains(f.Containers, c.Name) {
+				opts.ContainerIDs = append(opts.ContainerIDs, c.ID)
+			}
+		}
+		// Selected containers that are no longer running match nothing, rather than
+		// leaving the container filter empty and matching everything
+		if len(opts.ContainerIDs) == 0 {
+			opts.ContainerIDs = []string{""}
+		}
+	}
+
+	opts.Levels = logstore.ExpandLevels(f.Levels)
+
+	if f.Search != "" {
+		opts.SearchTerms = strings.Fields(f.Search)
+		opts.CaseSensitive = f.CaseSensitive
+		opts.WholeWord = f.WholeWord
+	}
+
+	return opts
+}
+
+// toggle adds value to values, or removes it when present
+func toggle(values []string, value string) []string {
+	if i := slices.Index(values, value); i >= 0 {
+		return slices.Delete(values, i, i+1)
+	}
+	return append(values, value)
+}
+
+// Summary describes the filter for the status bar
+func (f filterState) Summary() string {
+	var parts []string
+	if len(f.Containers) > 0 {
+		parts = append(parts, "containers="+strings.Join(f.Containers, ","))
+	}
+	if len(f.Levels) > 0 {
+		parts = append(parts, "levels="+strings.Join(f.Levels, ","))
+	}
+	if f.Search != "" {
+		search := "search=" + f.Search
+		if f.CaseSensitive {
+			search += " (case)"
+		}
+		if f.WholeWord {
+			search += " (word)"
+		}
+		parts = append(parts, search)
+	}
+	if len(parts) == 0 {
+		return "no filters"
+	}
+	return strings.Join(parts, "  ")
+}
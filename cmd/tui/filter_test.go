@@ -0,0 +1,71 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"docker-log-parser/pkg/logs"
+)
+
+func TestFilterStateOptions(t *testing.T) {
+	containers := []logs.Container{
+		{ID: "aaa", Name: "api"},
+		{ID: "bbb", Name: "worker"},
+	}
+
+	tests := []struct {
+		name         string
+		filter       filterState
+		containerIDs []string
+		levels       []string
+		terms        []string
+	}{
+		{
+			name: "empty matches everything",
+		},
+		{
+			name:         "containers resolve to IDs",
+			filter:       filterState{Containers: []string{"worker"}},
+			containerIDs: []string{"bbb"},
+		},
+		{
+			name:         "stopped container matches nothing",
+			filter:       filterState{Containers: []string{"gone"}},
+			containerIDs: []string{""},
+		},
+		{
+			name:   "level choices select every spelling",
+			filter: filterState{Levels: []string{"ERR", "NONE"}},
+			levels: []string{"ERR", "ERROR", "FATAL", "NONE"},
+		},
+		{
+			name:   "search splits on whitespace",
+			filter: filterState{Search: " timeout  db "},
+			terms:  []string{"timeout", "db"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.filter.Options(containers)
+			if !slices.Equal(opts.ContainerIDs, tt.containerIDs) {
+				t.Errorf("ContainerIDs = %q, want %q", opts.ContainerIDs, tt.containerIDs)
+			}
+			if !slices.Equal(opts.Levels, tt.levels) {
+				t.Errorf("Levels = %q, want %q", opts.Levels, tt.levels)
+			}
+			if !slices.Equal(opts.SearchTerms, tt.terms) {
+				t.Errorf("SearchTerms = %q, want %q", opts.SearchTerms, tt.terms)
+			}
+		})
+	}
+}
+
+func TestToggle(t *testing.T) {
+	values := toggle(nil, "api")
+	values = toggle(values, "worker")
+	values = toggle(values, "api")
+	if !slices.Equal(values, []string{"worker"}) {
+		t.Errorf("toggle = %q, want [worker]", values)
+	}
+}
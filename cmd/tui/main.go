@@ -0,0 +1,94 @@
+// Command tui is a terminal interface to the log viewer. It streams logs from the
+// running Docker containers into a LogStore and shows them with the web UI's
+// container, level and search filters.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"docker-log-parser/pkg/config"
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/logstore"
+)
+
+// maxRows bounds the rows read from the store on each refresh
+const maxRows = 2000
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// The terminal belongs to the UI, so the application's own logs go to a file
+	logPath := filepath.Join(os.TempDir(), "docker-log-viewer-tui.log")
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer logFile.Close()
+	slog.SetDefault(slog.New(slog.NewTextHandler(logFile, &slog.HandlerOptions{Level: cfg.SlogLevel()})))
+
+	docker, err := logs.NewDockerClientForHost(cfg.Docker.Host)
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer docker.Close()
+
+	filter, err := cfg.ContainerFilter()
+	if err != nil {
+		return err
+	}
+	docker.SetContainerFilter(filter)
+	docker.SetSynthesizeTimestamps(cfg.Docker.SynthesizeTimestamps)
+
+	colors, err := cfg.ColorTheme()
+	if err != nil {
+		return err
+	}
+
+	patterns, err := cfg.NamedPatterns()
+	if err != nil {
+		return err
+	}
+	logs.SetNamedPatterns(patterns)
+	logs.SetMaxLineLength(cfg.Parser.MaxLineLength)
+
+	hostname, _ := os.Hostname()
+	store := logstore.NewLogStore(cfg.LogStore.MaxMessages, cfg.LogStore.MaxAge)
+	logChan := make(chan logs.ContainerMessage, 1000)
+	source := newDockerSource(docker, logChan, cfg.Docker.IngestSelf, hostname)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	consumed := make(chan struct{})
+	go func() {
+		defer close(consumed)
+		for msg := range logChan {
+			store.Add(&msg)
+		}
+	}()
+
+	if err := source.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start log source: %w", err)
+	}
+
+	uiErr := newUI(store, source, maxRows, colors, filterState{}).Run()
+
+	source.Stop()
+	close(logChan)
+	<-consumed
+	return uiErr
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"docker-log-parser/pkg/logs"
+)
+
+// containerPollInterval is how often the source looks for new containers and for
+// streams that ended while their container kept running
+const containerPollInterval = 5 * time.Second
+
+// dockerSource streams logs from every running container into logChan, the same way
+// the viewer's source does, and keeps the container list for the picker
+type dockerSource struct {
+	docker     *logs.DockerClient
+	logChan    chan logs.ContainerMessage
+	ingestSelf bool
+	hostname   string
+
+	cancel  context.CancelFunc
+	monitor sync.WaitGroup // The poll goroutine
+	streams sync.WaitGroup // One per container log stream
+
+	mu         sync.RWMutex
+	containers []logs.Container
+	active     map[string]bool      // Container IDs with a running stream
+	ended      map[string]time.Time // When each container's last stream ended, to resume from
+}
+
+func newDockerSource(docker *logs.DockerClient, logChan chan logs.ContainerMessage, ingestSelf bool, hostname string) *dockerSource {
+	return &dockerSource{
+		docker:     docker,
+		logChan:    logChan,
+		ingestSelf: ingestSelf,
+		hostname:   hostname,
+		active:     make(map[string]bool),
+		ended:      make(map[string]time.Time),
+	}
+}
+
+func (s *dockerSource) Name() string {
+	return "docker"
+}
+
+func (s *dockerSource) Start(ctx context.Context) error {
+	ctx, s.cancel = context.WithCancel(ctx)
+	if err := s.refresh(ctx); err != nil {
+		s.cancel()
+		return err
+	}
+
+	s.monitor.Add(1)
+	go func() {
+		defer s.monitor.Done()
+		ticker := time.NewTicker(containerPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.refresh(ctx); err != nil {
+					slog.Warn("failed to list containers", "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *dockerSource) Stop() {
+	s.cancel()
+	s.monitor.Wait()
+	s.streams.Wait()
+}
+
+// Containers returns the running containers, sorted as Docker listed them
+func (s *dockerSource) Containers() []logs.Container {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.containers
+}
+
+// refresh lists the running containers and starts a stream for each one without one,
+// resuming from when the container's previous stream ended
+func (s *dockerSource) refresh(ctx context.Context) error {
+	containers, err := s.docker.ListRunningContainers(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.containers = containers
+	s.mu.Unlock()
+
+	for _, c := range containers {
+		if !s.ingestSelf && logs.IsSelfContainer(c, s.hostname) {
+			continue
+		}
+		s.mu.Lock()
+		streaming := s.active[c.ID]
+		s.active[c.ID] = true
+		since := s.ended[c.ID]
+		s.mu.Unlock()
+		if streaming {
+			continue
+		}
+
+		id := c.ID
+		onStreamEnd := func() {
+			s.mu.Lock()
+			delete(s.active, id)
+			s.ended[id] = time.Now()
+			s.mu.Unlock()
+			s.streams.Done()
+		}
+		s.streams.Add(1)
+		if err := s.docker.StreamLogsSince(ctx, id, s.logChan, onStreamEnd, since); err != nil {
+			slog.Error("failed to stream logs", "container_id", id[:12], "container_name", c.Name, "error", err)
+			s.mu.Lock()
+			delete(s.active, id)
+			s.mu.Unlock()
+			s.streams.Done()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/logstore"
+	"docker-log-parser/pkg/theme"
+)
+
+// refreshInterval is how often the log table is re-read from the store while following
+const refreshInterval = 250 * time.Millisecond
+
+const helpText = "[yellow]/[-] search  [yellow]c[-] containers  [yellow]l[-] levels  [yellow]C[-] case  [yellow]W[-] word  [yellow]x[-] clear  " +
+	"[yellow]f[-] follow/pause  [yellow]Enter[-] details  [yellow]Esc[-] close  [yellow]q[-] quit"
+
+// ui shows the filtered contents of a LogStore. Its fields are only used from the
+// tview event loop.
+type ui struct {
+	app    *tview.Application
+	store  *logstore.LogStore
+	source *dockerSource
+	limit  int // Rows shown, newest last
+	theme  theme.Theme
+
+	filter     filterState
+	follow     bool
+	showDetail bool
+	rendering  bool // Set while the table is rebuilt, so selection changes are not the user's
+	rows       []*logs.ContainerMessage
+	names      map[string]string // Container names by ID
+
+	pages  *tview.Pages
+	body   *tview.Flex
+	table  *tview.Table
+	detail *tview.TextView
+	status *tview.TextView
+	search *tview.InputField
+}
+
+func newUI(store *logstore.LogStore, source *dockerSource, limit int, colors theme.Theme, filter filterState) *ui {
+	u := &ui{
+		app:    tview.NewApplication(),
+		store:  store,
+		source: source,
+		limit:  limit,
+		theme:  colors,
+		filter: filter,
+		follow: true,
+	}
+
+	u.table = tview.NewTable().SetSelectable(true, false).SetFixed(0, 0)
+	u.table.SetSelectionChangedFunc(u.selectionChanged)
+	u.table.SetSelectedFunc(func(int, int) { u.setDetail(true) })
+
+	u.detail = tview.NewTextView().SetDynamicColors(true).SetWrap(true)
+	u.detail.SetBorder(true).SetTitle(" Details ")
+
+	u.status = tview.NewTextView().SetDynamicColors(true)
+	help := tview.NewTextView().SetDynamicColors(true).SetText(helpText)
+
+	u.search = tview.NewInputField().SetLabel("Search: ")
+	u.search.SetDoneFunc(u.searchDone)
+
+	u.body = tview.NewFlex().AddItem(u.table, 0, 1, true)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(u.status, 1, 0, false).
+		AddItem(u.body, 0, 1, true).
+		AddItem(help, 1, 0, false)
+
+	u.pages = tview.NewPages().AddPage("main", layout, true, true)
+	u.app.SetRoot(u.pages, true).SetInputCapture(u.handleKey)
+	return u
+}
+
+// Run shows the UI until the user quits, refreshing the table in the background
+func (u *ui) Run() error {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				u.app.QueueUpdateDraw(func() {
+					if u.follow {
+						u.reload()
+					} else {
+						u.updateStatus()
+					}
+				})
+			}
+		}
+	}()
+
+	u.reload()
+	return u.app.Run()
+}
+
+// handleKey handles the keybindings while the log table has focus
+func (u *ui) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	if u.app.GetFocus() != u.table {
+		return event
+	}
+
+	switch event.Key() {
+	case tcell.KeyEscape:
+		u.setDetail(false)
+		return nil
+	case tcell.KeyRune:
+	default:
+		return event
+	}
+
+	switch event.Rune() {
+	case 'q':
+		u.app.Stop()
+	case '/':
+		u.search.SetText(u.filter.Search)
+		u.body.AddItem(u.search, 1, 0, true)
+		u.app.SetFocus(u.search)
+	case 'c':
+		u.showContainerPicker()
+	case 'l':
+		u.showLevelPicker()
+	case 'C':
+		u.filter.CaseSensitive = !u.filter.CaseSensitive
+		u.reload()
+	case 'W':
+		u.filter.WholeWord = !u.filter.WholeWord
+		u.reload()
+	case 'x':
+		u.filter = filterState{}
+		u.reload()
+	case 'f', ' ':
+		u.follow = !u.follow
+		u.reload()
+	default:
+		return event
+	}
+	return nil
+}
+
+// searchDone applies the search query on Enter and discards it on Escape
+func (u *ui) searchDone(key tcell.Key) {
+	if key == tcell.KeyEnter {
+		u.filter.Search = strings.TrimSpace(u.search.GetText())
+	}
+	u.body.RemoveItem(u.search)
+	u.app.SetFocus(u.table)
+	u.reload()
+}
+
+// showContainerPicker lists the running containers; Enter toggles one and Escape closes
+func (u *ui) showContainerPicker() {
+	var names []string
+	for _, c := range u.source.Containers() {
+		names = append(names, c.Name)
+	}
+	slices.Sort(names)
+
+	u.showPicker(" Containers ", names, func() []string { return u.filter.Containers }, func(name string) {
+		u.filter.Containers = toggle(u.filter.Containers, name)
+	})
+}
+
+// showLevelPicker lists the level choices; Enter toggles one and Escape closes
+func (u *ui) showLevelPicker() {
+	var labels []string
+	for _, group := range logstore.LevelGroups {
+		labels = append(labels, group.Label)
+	}
+
+	u.showPicker(" Levels ", labels, func() []string { return u.filter.Levels }, func(label string) {
+		u.filter.Levels = toggle(u.filter.Levels, label)
+	})
+}
+
+// showPicker shows a checklist of items over the table. selected returns the checked
+// items and toggle checks or unchecks one, reloading the table as it does.
+func (u *ui) showPicker(title string, items []string, selected func() []string, toggleItem func(string)) {
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true).SetTitle(title)
+
+	label := func(item string) string {
+		if slices.Contains(selected(), item) {
+			return "[x] " + item
+		}
+		return "[ ] " + item
+	}
+	for _, item := range items {
+		list.AddItem(tview.Escape(label(item)), "", 0, nil)
+	}
+	list.SetSelectedFunc(func(i int, _, _ string, _ rune) {
+		toggleItem(items[i])
+		list.SetItemText(i, tview.Escape(label(items[i])), "")
+		u.reload()
+	})
+
+	closePicker := func() {
+		u.pages.RemovePage("picker")
+		u.app.SetFocus(u.table)
+	}
+	list.SetDoneFunc(closePicker)
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyRune && event.Rune() == 'q' {
+			closePicker()
+			return nil
+		}
+		return event
+	})
+
+	width := len(title)
+	for _, item := range items {
+		width = max(width, len(item)+4)
+	}
+	height := min(len(items), 20) + 2
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(list, height, 0, true).
+			AddItem(nil, 0, 1, false), width+4, 0, true).
+		AddItem(nil, 0, 1, false)
+	u.pages.AddPage("picker", modal, true, true)
+	u.app.SetFocus(list)
+}
+
+// reload re-reads the filtered rows from the store and rebuilds the table. While
+// following, the newest row is selected; otherwise the selected row is kept in place.
+func (u *ui) reload() {
+	containers := u.source.Containers()
+	u.names = make(map[string]string, len(containers))
+	for _, c := range containers {
+		u.names[c.ID] = c.Name
+	}
+
+	rows := u.store.Filter(u.filter.Options(containers), u.limit)
+	// Filter returns the newest first; the table reads top to bottom like a terminal
+	slices.Reverse(rows)
+
+	selected, _ := u.table.GetSelection()
+	var keep *logs.ContainerMessage
+	if !u.follow && selected < len(u.rows) {
+		keep = u.rows[selected]
+	}
+
+	u.rendering = true
+	u.rows = rows
+	u.table.Clear()
+	for i, msg := range rows {
+		u.table.SetCell(i, 0, tview.NewTableCell(msg.Timestamp.Local().Format("15:04:05.000")).SetTextColor(color(u.theme.Muted)))
+		u.table.SetCell(i, 1, tview.NewTableCell(tview.Escape(u.containerName(msg.ContainerID))).SetTextColor(color(u.theme.Accent)).SetMaxWidth(24))
+		u.table.SetCell(i, 2, tview.NewTableCell(levelText(msg.Entry.Level)).SetTextColor(color(u.theme.LevelColor(msg.Entry.Level))))
+		u.table.SetCell(i, 3, tview.NewTableCell(tview.Escape(messageText(msg.Entry))).SetExpansion(1))
+	}
+
+	switch {
+	case len(rows) == 0:
+	case u.follow:
+		u.table.Select(len(rows)-1, 0)
+	case keep != nil:
+		// Rows only move up as older ones are evicted, so the kept row is at or before
+		// its old position
+		row := slices.Index(rows, keep)
+		if row < 0 {
+			row = min(selected, len(rows)-1)
+		}
+		u.table.Select(row, 0)
+	}
+	u.rendering = false
+
+	u.updateDetail()
+	u.updateStatus()
+}
+
+// selectionChanged pauses following when the user moves off the newest row
+func (u *ui) selectionChanged(row, _ int) {
+	if u.rendering {
+		return
+	}
+	if u.follow && row < len(u.rows)-1 {
+		u.follow = false
+		u.updateStatus()
+	}
+	u.updateDetail()
+}
+
+func (u *ui) setDetail(show bool) {
+	if show == u.showDetail {
+		return
+	}
+	u.showDetail = show
+	if show {
+		u.body.AddItem(u.detail, 0, 1, false)
+	} else {
+		u.body.RemoveItem(u.detail)
+	}
+	u.updateDetail()
+}
+
+// updateDetail shows the selected row's parsed fields in the detail pane
+func (u *ui) updateDetail() {
+	if !u.showDetail {
+		return
+	}
+	row, _ := u.table.GetSelection()
+	if row < 0 || row >= len(u.rows) {
+		u.detail.SetText("")
+		return
+	}
+	msg := u.rows[row]
+	entry := msg.Entry
+
+	var b strings.Builder
+	line := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "[%s]%s[-]: %s\n", u.theme.Accent, name, tview.Escape(value))
+		}
+	}
+	line("time", msg.Timestamp.Local().Format(time.RFC3339Nano))
+	line("container", u.containerName(msg.ContainerID))
+	line("level", entry.Level)
+	line("file", entry.File)
+	line("message", entry.Message)
+	if entry.DurationMS > 0 {
+		line("duration", fmt.Sprintf("%gms", entry.DurationMS))
+	}
+
+	if len(entry.Fields) > 0 {
+		b.WriteString("\n[::b]Fields[::-]\n")
+		for _, name := range slices.Sorted(maps.Keys(entry.Fields)) {
+			line(name, entry.Fields[name])
+		}
+	}
+
+	b.WriteString("\n[::b]Raw[::-]\n")
+	b.WriteString(tview.Escape(entry.Raw))
+
+	u.detail.SetText(b.String()).ScrollToBeginning()
+}
+
+func (u *ui) updateStatus() {
+	state := "[green]FOLLOWING[-]"
+	if !u.follow {
+		state = "[red]PAUSED[-]"
+	}
+	u.status.SetText(fmt.Sprintf("%s  %d shown / %d stored  %s",
+		state, len(u.rows), u.store.Count(), tview.Escape(u.filter.Summary())))
+}
+
+// containerName returns the container's name, or its short ID once it has stopped
+func (u *ui) containerName(id string) string {
+	if name := u.names[id]; name != "" {
+		return name
+	}
+	return id[:min(12, len(id))]
+}
+
+// messageText is the one-line text shown for an entry in the table
+func messageText(entry *logs.LogEntry) string {
+	text := entry.Message
+	if text == "" {
+		text = entry.Raw
+	}
+	return strings.ReplaceAll(text, "\n", " ")
+}
+
+func levelText(level string) string {
+	if level == "" {
+		return "-"
+	}
+	return strings.ToUpper(level)
+}
+
+// color converts a theme color to tcell's, keeping the terminal default when unset
+func color(hex string) tcell.Color {
+	if hex == "" {
+		return tcell.ColorDefault
+	}
+	return tcell.GetColor(hex)
+}
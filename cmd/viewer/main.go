@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,10 +18,12 @@ import (
 	"syscall"
 	"time"
 
+	"docker-log-parser/pkg/config"
 	"docker-log-parser/pkg/controller"
 	"docker-log-parser/pkg/logs"
 	"docker-log-parser/pkg/logstore"
 	"docker-log-parser/pkg/sqlexplain"
+	"docker-log-parser/pkg/sqlutil"
 	"docker-log-parser/pkg/store"
 
 	"github.com/gorilla/mux"
@@ -32,6 +37,20 @@ type ClientFilter struct {
 	SelectedLevels     []string           `json:"selectedLevels"`
 	SearchQuery        string             `json:"searchQuery"`
 	TraceFilters       []TraceFilterValue `json:"traceFilters"`
+	CaseSensitive      bool               `json:"caseSensitive,omitempty"`
+	WholeWord          bool               `json:"wholeWord,omitempty"`
+
+	matchers []logstore.TermMatcher // Compiled SearchQuery terms, see withTermMatchers
+}
+
+// withTermMatchers returns the filter with its search terms compiled, so matching
+// a message doesn't rebuild them
+func (f ClientFilter) withTermMatchers() ClientFilter {
+	f.matchers = nil
+	for _, term := range strings.Fields(f.SearchQuery) {
+		f.matchers = append(f.matchers, logstore.NewTermMatcher(term, f.CaseSensitive, f.WholeWord))
+	}
+	return f
 }
 
 type TraceFilterValue struct {
@@ -46,7 +65,9 @@ type Client struct {
 }
 
 type WebApp struct {
+	config              *config.Config
 	docker              *logs.DockerClient
+	hostname            string             // Matched against containers to skip the viewer's own
 	logStore            *logstore.LogStore // Indexed log storage
 	containers          []logs.Container
 	containerIDNames    map[string]string // Maps container ID to name
@@ -63,8 +84,11 @@ type WebApp struct {
 	store               *store.Store
 	lastTimestamps      map[string]time.Time // Last timestamp seen per container
 	lastTimestampsMutex sync.RWMutex
-	shutdownOnce        sync.Once       // Ensure shutdown happens only once
-	activeStreams       map[string]bool // Tracks which containers have active log streams
+	shutdownOnce        sync.Once        // Ensure shutdown happens only once
+	sources             []logs.Source    // Ingestion sources feeding logChan, stopped in order on shutdown
+	processDone         chan struct{}    // Closed once processLogs has flushed its last batch
+	suppressor          *logs.Suppressor // Drops noise lines before they are stored; nil keeps every line
+	activeStreams       map[string]bool  // Tracks which containers have active log streams
 	activeStreamsMutex  sync.RWMutex
 	decoder             *schema.Decoder        // For parsing query/form parameters
 	controller          *controller.Controller // Controller for HTTP handlers and WebSocket clients
@@ -81,11 +105,13 @@ type ContainersUpdateMessage struct {
 	PortToServerMap map[int]string           `json:"portToServerMap"`
 	LogCounts       map[string]int           `json:"logCounts"`  // container name -> log count
 	Retentions      map[string]RetentionInfo `json:"retentions"` // container name -> retention settings
+	Colors          map[string]string        `json:"colors"`     // container name -> #rrggbb color to tint its lines
 }
 
 type RetentionInfo struct {
-	Type  string `json:"type"`  // "count" or "time"
-	Value int    `json:"value"` // number of logs or seconds
+	Type    string `json:"type"`              // "count" or "time"
+	Value   int    `json:"value"`             // number of logs or seconds
+	MinKeep int    `json:"minKeep,omitempty"` // newest logs a time policy keeps; 0 for the default
 }
 
 type LogWSMessage struct {
@@ -94,28 +120,50 @@ type LogWSMessage struct {
 	Entry       *logs.LogEntry `json:"entry"`
 }
 
-func NewWebApp() (*WebApp, error) {
-	logLevel := slog.LevelInfo
-	if os.Getenv("DEBUG") != "" {
-		logLevel = slog.LevelDebug
+// newLogHandler returns the handler for the viewer's own logs: JSON for log aggregators
+// when configured, otherwise tint's colored output
+func newLogHandler(cfg *config.Config, w io.Writer) slog.Handler {
+	if cfg.LogFormat == config.LogFormatJSON {
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: cfg.SlogLevel()})
 	}
-	logger := slog.New(tint.NewHandler(os.Stderr, &tint.Options{
-		Level: logLevel,
-	}))
-	slog.SetDefault(logger)
+	return tint.NewHandler(w, &tint.Options{
+		Level: cfg.SlogLevel(),
+	})
+}
+
+func NewWebApp(cfg *config.Config) (*WebApp, error) {
+	slog.SetDefault(slog.New(newLogHandler(cfg, os.Stderr)))
 
-	docker, err := logs.NewDockerClient()
+	docker, err := logs.NewDockerClientForHost(cfg.Docker.Host)
 	if err != nil {
 		return nil, err
 	}
+	filter, err := cfg.ContainerFilter()
+	if err != nil {
+		return nil, err
+	}
+	docker.SetContainerFilter(filter)
+	docker.SetSynthesizeTimestamps(cfg.Docker.SynthesizeTimestamps)
 
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Open store
-	db, err := store.NewStore("graphql-requests.db")
+	db, err := store.NewStore(cfg.DBPath)
 	if err != nil {
 		slog.Warn("failed to open database", "error", err)
 		db = nil
+	} else {
+		db.SetLogBatchSize(cfg.LogBatchSize)
+		if n, err := db.FailUnfinishedRequests(); err != nil {
+			slog.Warn("failed to mark interrupted executions", "error", err)
+		} else if n > 0 {
+			slog.Info("marked executions interrupted by a restart as failed", "count", n)
+		}
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		slog.Warn("failed to get hostname, the viewer's own container is only detected by label", "error", err)
 	}
 
 	// Initialize schema decoder for parsing query and form parameters
@@ -123,8 +171,10 @@ func NewWebApp() (*WebApp, error) {
 	decoder.IgnoreUnknownKeys(true) // Ignore unknown keys for flexibility
 
 	app := &WebApp{
+		config:           cfg,
 		docker:           docker,
-		logStore:         logstore.NewLogStore(10000, 2*time.Hour),
+		hostname:         hostname,
+		logStore:         logstore.NewLogStore(cfg.LogStore.MaxMessages, cfg.LogStore.MaxAge),
 		containerIDNames: make(map[string]string),
 		clients:          make(map[*Client]bool),
 		logChan:          make(chan logs.ContainerMessage, 1000),
@@ -151,14 +201,30 @@ func (wa *WebApp) loadContainerRetentions() error {
 	if err != nil {
 		return err
 	}
+	configured := make(map[string]bool, len(retentionList))
 	for _, retention := range retentionList {
+		configured[retention.ContainerName] = true
 		for _, container := range wa.containers {
 			if container.Name == retention.ContainerName {
 				containerID := container.ID
 				slog.Info("setting container retention", "containerID", containerID, "retention", retention)
 				wa.logStore.SetContainerRetention(containerID, logstore.ContainerRetentionPolicy{
-					Type:  retention.RetentionType,
-					Value: retention.RetentionValue,
+					Type:    retention.RetentionType,
+					Value:   retention.RetentionValue,
+					MinKeep: retention.MinKeep,
+				})
+			}
+		}
+	}
+
+	// Containers without their own setting get the configured default, if any
+	if wa.config != nil && wa.config.Retention.Type != "" {
+		for _, container := range wa.containers {
+			if !configured[container.Name] {
+				wa.logStore.SetContainerRetention(container.ID, logstore.ContainerRetentionPolicy{
+					Type:    wa.config.Retention.Type,
+					Value:   wa.config.Retention.Value,
+					MinKeep: wa.config.Retention.MinKeep,
 				})
 			}
 		}
@@ -240,6 +306,8 @@ func (wa *WebApp) clientFilterToLogStoreFilter(filter ClientFilter) logstore.Fil
 	// Set search terms (split by whitespace for AND logic)
 	if filter.SearchQuery != "" {
 		opts.SearchTerms = strings.Fields(filter.SearchQuery)
+		opts.CaseSensitive = filter.CaseSensitive
+		opts.WholeWord = filter.WholeWord
 	}
 
 	// Set trace filters as field filters
@@ -293,27 +361,28 @@ func (wa *WebApp) matchesFilter(msg logs.ContainerMessage, filter ClientFilter)
 
 	// Search query filter - AND multiple terms together
 	if filter.SearchQuery != "" {
-		terms := strings.Fields(filter.SearchQuery) // Split on whitespace
+		if filter.matchers == nil {
+			filter = filter.withTermMatchers()
+		}
 
 		if msg.Entry != nil {
-			for _, term := range terms {
-				query := strings.ToLower(term)
+			for _, match := range filter.matchers {
 				found := false
 
 				// Search in message
-				if strings.Contains(strings.ToLower(msg.Entry.Message), query) {
+				if match(msg.Entry.Message) {
 					found = true
 				}
 
 				// Search in raw log
-				if !found && strings.Contains(strings.ToLower(msg.Entry.Raw), query) {
+				if !found && match(msg.Entry.Raw) {
 					found = true
 				}
 
 				// Search in fields
 				if !found && msg.Entry.Fields != nil {
 					for key, value := range msg.Entry.Fields {
-						if strings.Contains(strings.ToLower(key), query) || strings.Contains(strings.ToLower(value), query) {
+						if match(key) || match(value) {
 							found = true
 							break
 						}
@@ -355,6 +424,9 @@ func (wa *WebApp) broadcastBatch(batch []logs.ContainerMessage) {
 		client.mu.RLock()
 		filter := client.filter
 		client.mu.RUnlock()
+		if filter.matchers == nil {
+			filter = filter.withTermMatchers()
+		}
 
 		// Filter logs for this client using matchesFilter
 		filteredLogs := []LogWSMessage{}
@@ -389,6 +461,10 @@ func (wa *WebApp) broadcastBatch(batch []logs.ContainerMessage) {
 func (wa *WebApp) processLogs() {
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
+	summaryTicker := time.NewTicker(controller.SuppressionSummaryInterval)
+	defer summaryTicker.Stop()
+	evictionTicker := time.NewTicker(controller.EvictionSummaryInterval)
+	defer evictionTicker.Stop()
 	logCount := 0
 	receivedCount := 0
 
@@ -401,8 +477,12 @@ func (wa *WebApp) processLogs() {
 			return
 		case msg, ok := <-wa.logChan:
 			if !ok {
-				// Channel closed, exit
+				// Channel closed once every source stopped; send what is left and exit
+				wa.flushBatch()
 				slog.Info("processLogs goroutine exiting (channel closed)", "totalReceived", receivedCount, "totalProcessed", logCount)
+				if wa.processDone != nil {
+					close(wa.processDone)
+				}
 				return
 			}
 			receivedCount++
@@ -420,6 +500,11 @@ func (wa *WebApp) processLogs() {
 			wa.lastTimestamps[msg.ContainerID] = logTimestamp
 			wa.lastTimestampsMutex.Unlock()
 
+			// Noise lines are counted for the summary rather than stored or sent
+			if wa.suppressor != nil && wa.suppressor.Suppress(msg) {
+				continue
+			}
+
 			// Add to log store directly
 			wa.logStore.Add(&logs.ContainerMessage{
 				Timestamp:   logTimestamp,
@@ -438,34 +523,56 @@ func (wa *WebApp) processLogs() {
 			wa.batchMutex.Unlock()
 
 		case <-ticker.C:
-			// Send batch if non-empty
-			wa.batchMutex.Lock()
-			if len(wa.logBatch) > 0 {
-				batch := make([]logs.ContainerMessage, len(wa.logBatch))
-				copy(batch, wa.logBatch)
-				wa.logBatch = wa.logBatch[:0]
-				wa.batchMutex.Unlock()
-
-				// Use controller's BroadcastBatch if available
-				wa.controllerMutex.RLock()
-				ctrl := wa.controller
-				wa.controllerMutex.RUnlock()
+			wa.flushBatch()
+
+		case <-summaryTicker.C:
+			wa.controllerMutex.RLock()
+			ctrl := wa.controller
+			wa.controllerMutex.RUnlock()
+			if ctrl != nil {
+				ctrl.BroadcastSuppressionSummary()
+			}
 
-				if ctrl != nil {
-					ctrl.BroadcastBatch(batch)
-				} else {
-					// Fallback to WebApp's broadcastBatch if controller not set yet
-					wa.broadcastBatch(batch)
-				}
-			} else {
-				wa.batchMutex.Unlock()
+		case <-evictionTicker.C:
+			wa.controllerMutex.RLock()
+			ctrl := wa.controller
+			wa.controllerMutex.RUnlock()
+			if ctrl != nil {
+				ctrl.BroadcastEvictions()
 			}
 		}
 	}
 }
 
-func (wa *WebApp) loadContainers() error {
-	containers, err := wa.docker.ListRunningContainers(wa.ctx)
+// flushBatch broadcasts the pending batch to clients, if there is one
+func (wa *WebApp) flushBatch() {
+	wa.batchMutex.Lock()
+	if len(wa.logBatch) == 0 {
+		wa.batchMutex.Unlock()
+		return
+	}
+	batch := make([]logs.ContainerMessage, len(wa.logBatch))
+	copy(batch, wa.logBatch)
+	wa.logBatch = wa.logBatch[:0]
+	wa.batchMutex.Unlock()
+
+	// Use controller's BroadcastBatch if available
+	wa.controllerMutex.RLock()
+	ctrl := wa.controller
+	wa.controllerMutex.RUnlock()
+
+	if ctrl != nil {
+		ctrl.BroadcastBatch(batch)
+	} else {
+		// Fallback to WebApp's broadcastBatch if controller not set yet
+		wa.broadcastBatch(batch)
+	}
+}
+
+// loadContainers lists the running containers and starts a log stream for each, adding
+// the streams to streams
+func (wa *WebApp) loadContainers(ctx context.Context, streams *sync.WaitGroup) error {
+	containers, err := wa.docker.ListRunningContainers(ctx)
 	if err != nil {
 		return err
 	}
@@ -480,22 +587,13 @@ func (wa *WebApp) loadContainers() error {
 	wa.containerMutex.Unlock()
 
 	for _, c := range containers {
-		slog.Info("starting log stream for container", "container_id", c.ID[:12], "container_name", c.Name)
-		containerID := c.ID
-		onStreamEnd := func() {
-			wa.activeStreamsMutex.Lock()
-			delete(wa.activeStreams, containerID)
-			wa.activeStreamsMutex.Unlock()
-			slog.Debug("stream ended, removed from active streams", "container_id", containerID[:12])
+		if wa.skipContainer(c) {
+			slog.Info("not streaming the viewer's own container, set INGEST_SELF to include it", "container_id", c.ID[:12], "container_name", c.Name)
+			continue
 		}
-		wa.activeStreamsMutex.Lock()
-		wa.activeStreams[c.ID] = true
-		wa.activeStreamsMutex.Unlock()
-		if err := wa.docker.StreamLogs(wa.ctx, c.ID, wa.logChan, onStreamEnd); err != nil {
+		slog.Info("starting log stream for container", "container_id", c.ID[:12], "container_name", c.Name)
+		if err := wa.startStream(ctx, streams, c.ID, time.Time{}); err != nil {
 			slog.Error("failed to stream logs", "container_id", c.ID[:12], "container_name", c.Name, "error", err)
-			wa.activeStreamsMutex.Lock()
-			delete(wa.activeStreams, c.ID)
-			wa.activeStreamsMutex.Unlock()
 		}
 	}
 
@@ -503,7 +601,45 @@ func (wa *WebApp) loadContainers() error {
 	return nil
 }
 
-func (wa *WebApp) monitorContainers() {
+// skipContainer reports whether c is the viewer's own container, whose logs would feed
+// back into the viewer, and ingest_self is not set
+func (wa *WebApp) skipContainer(c logs.Container) bool {
+	if wa.config != nil && wa.config.Docker.IngestSelf {
+		return false
+	}
+	return logs.IsSelfContainer(c, wa.hostname)
+}
+
+// startStream streams a container's logs into logChan from since, or from the stream
+// default when since is zero. The stream is tracked in activeStreams and streams until
+// it ends.
+func (wa *WebApp) startStream(ctx context.Context, streams *sync.WaitGroup, containerID string, since time.Time) error {
+	onStreamEnd := func() {
+		wa.activeStreamsMutex.Lock()
+		delete(wa.activeStreams, containerID)
+		wa.activeStreamsMutex.Unlock()
+		slog.Debug("stream ended, removed from active streams", "container_id", containerID[:12])
+		streams.Done()
+	}
+
+	wa.activeStreamsMutex.Lock()
+	wa.activeStreams[containerID] = true
+	wa.activeStreamsMutex.Unlock()
+	streams.Add(1)
+
+	if err := wa.docker.StreamLogsSince(ctx, containerID, wa.logChan, onStreamEnd, since); err != nil {
+		wa.activeStreamsMutex.Lock()
+		delete(wa.activeStreams, containerID)
+		wa.activeStreamsMutex.Unlock()
+		streams.Done()
+		return err
+	}
+	return nil
+}
+
+// monitorContainers polls for started and stopped containers until ctx is cancelled,
+// adding the streams it starts to streams
+func (wa *WebApp) monitorContainers(ctx context.Context, streams *sync.WaitGroup) {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
@@ -516,11 +652,11 @@ func (wa *WebApp) monitorContainers() {
 
 	for {
 		select {
-		case <-wa.ctx.Done():
+		case <-ctx.Done():
 			slog.Info("monitorContainers goroutine exiting", "containersTracked", len(previousIDs))
 			return
 		case <-ticker.C:
-			containers, err := wa.docker.ListRunningContainers(wa.ctx)
+			containers, err := wa.docker.ListRunningContainers(ctx)
 			if err != nil {
 				slog.Error("failed to list containers", "error", err)
 				continue
@@ -539,6 +675,9 @@ func (wa *WebApp) monitorContainers() {
 			wa.activeStreamsMutex.RUnlock()
 
 			for _, c := range containers {
+				if wa.skipContainer(c) {
+					continue
+				}
 				// Check if container is new or if it's running but doesn't have an active stream
 				if !previousIDs[c.ID] {
 					// New container - start stream
@@ -548,21 +687,8 @@ func (wa *WebApp) monitorContainers() {
 					wa.containerMutex.Unlock()
 
 					slog.Info("starting log stream for new container", "container_id", c.ID[:12], "container_name", c.Name)
-					containerID := c.ID
-					onStreamEnd := func() {
-						wa.activeStreamsMutex.Lock()
-						delete(wa.activeStreams, containerID)
-						wa.activeStreamsMutex.Unlock()
-						slog.Debug("stream ended, removed from active streams", "container_id", containerID[:12])
-					}
-					wa.activeStreamsMutex.Lock()
-					wa.activeStreams[c.ID] = true
-					wa.activeStreamsMutex.Unlock()
-					if err := wa.docker.StreamLogs(wa.ctx, c.ID, wa.logChan, onStreamEnd); err != nil {
+					if err := wa.startStream(ctx, streams, c.ID, time.Time{}); err != nil {
 						slog.Error("failed to stream logs for new container", "container_id", c.ID[:12], "container_name", c.Name, "error", err)
-						wa.activeStreamsMutex.Lock()
-						delete(wa.activeStreams, c.ID)
-						wa.activeStreamsMutex.Unlock()
 					}
 				} else if !activeStreams[c.ID] {
 					// Container is running but stream ended (e.g., EOF) - restart it using Since
@@ -574,21 +700,8 @@ func (wa *WebApp) monitorContainers() {
 					wa.lastTimestampsMutex.RUnlock()
 
 					slog.Info("container stream ended, resuming stream", "container_id", c.ID[:12], "container_name", c.Name, "since", since)
-					containerID := c.ID
-					onStreamEnd := func() {
-						wa.activeStreamsMutex.Lock()
-						delete(wa.activeStreams, containerID)
-						wa.activeStreamsMutex.Unlock()
-						slog.Info("stream ended, removed from active streams", "container_id", containerID[:12])
-					}
-					wa.activeStreamsMutex.Lock()
-					wa.activeStreams[c.ID] = true
-					wa.activeStreamsMutex.Unlock()
-					if err := wa.docker.StreamLogsSince(wa.ctx, c.ID, wa.logChan, onStreamEnd, since); err != nil {
+					if err := wa.startStream(ctx, streams, c.ID, since); err != nil {
 						slog.Error("failed to restart stream for container", "container_id", c.ID[:12], "container_name", c.Name, "error", err)
-						wa.activeStreamsMutex.Lock()
-						delete(wa.activeStreams, c.ID)
-						wa.activeStreamsMutex.Unlock()
 					}
 				}
 			}
@@ -670,9 +783,11 @@ func (wa *WebApp) broadcastContainerUpdate(containers []logs.Container) {
 
 	// Get log counts for each container
 	logCounts := make(map[string]int)
+	colors := make(map[string]string)
 	for _, container := range containers {
 		count := wa.logStore.CountByContainer(container.ID)
 		logCounts[container.Name] = count
+		colors[container.Name] = logs.ContainerColor(container.Name)
 	}
 
 	// Get retention settings for all containers
@@ -682,8 +797,9 @@ func (wa *WebApp) broadcastContainerUpdate(containers []logs.Container) {
 		if err == nil {
 			for _, r := range retentionList {
 				retentions[r.ContainerName] = RetentionInfo{
-					Type:  r.RetentionType,
-					Value: r.RetentionValue,
+					Type:    r.RetentionType,
+					Value:   r.RetentionValue,
+					MinKeep: r.MinKeep,
 				}
 			}
 		}
@@ -694,6 +810,7 @@ func (wa *WebApp) broadcastContainerUpdate(containers []logs.Container) {
 		PortToServerMap: portToServerMap,
 		LogCounts:       logCounts,
 		Retentions:      retentions,
+		Colors:          colors,
 	}
 
 	wsMsg := WSMessage{
@@ -716,19 +833,52 @@ func (wa *WebApp) broadcastContainerUpdate(containers []logs.Container) {
 
 // ============================================================================
 
+// shutdown drains ingestion in order: each source stops accepting lines and sends its
+// last messages, logChan is closed so processLogs flushes the final batch to clients,
+// the logs are saved as a snapshot, and only then is the context cancelled to stop
+// everything else
 func (wa *WebApp) shutdown() {
 	wa.shutdownOnce.Do(func() {
+		for _, source := range wa.sources {
+			wa.stopSource(source)
+		}
+
+		// No source sends any more, so logChan can be closed safely
+		close(wa.logChan)
+
+		if wa.processDone != nil {
+			select {
+			case <-wa.processDone:
+				slog.Info("drained remaining logs")
+			case <-time.After(drainTimeout):
+				slog.Warn("timed out draining remaining logs", "timeout", drainTimeout)
+			}
+		}
+
+		wa.saveShutdownSnapshot()
+
 		slog.Info("cancelling context to stop goroutines")
 		wa.cancel()
+	})
+}
 
-		// Give goroutines time to see context cancellation and exit
-		// This prevents them from trying to send on a closed channel
-		time.Sleep(300 * time.Millisecond)
+// saveShutdownSnapshot persists the drained logs, which are otherwise lost on exit
+func (wa *WebApp) saveShutdownSnapshot() {
+	wa.controllerMutex.RLock()
+	ctrl := wa.controller
+	wa.controllerMutex.RUnlock()
+	if ctrl == nil {
+		return
+	}
 
-		// Close logChan to signal that no more logs will be processed
-		// sync.Once ensures this only happens once
-		close(wa.logChan)
-	})
+	id, err := ctrl.SaveShutdownSnapshot()
+	if err != nil {
+		slog.Error("failed to save shutdown snapshot", "error", err)
+		return
+	}
+	if id != "" {
+		slog.Info("saved shutdown snapshot", "id", id)
+	}
 }
 
 func loggingMiddleware(next http.Handler) http.Handler {
@@ -746,8 +896,40 @@ func loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// basicAuthMiddleware requires the configured username and password on every request.
+// API requests are refused with a JSON error like the rest of the API.
+func basicAuthMiddleware(auth config.AuthConfig) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, password, ok := r.BasicAuth()
+			if !ok ||
+				subtle.ConstantTimeCompare([]byte(username), []byte(auth.Username)) != 1 ||
+				subtle.ConstantTimeCompare([]byte(password), []byte(auth.Password)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="docker-log-viewer"`)
+				if strings.HasPrefix(r.URL.Path, "/api/") {
+					controller.WriteJSONError(w, http.StatusUnauthorized, controller.ErrCodeUnauthorized, "Unauthorized")
+				} else {
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				}
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func (wa *WebApp) Run(addr string) error {
-	if err := wa.loadContainers(); err != nil {
+	// Patterns and the line limit apply as lines are parsed, so they must be set before
+	// the sources start
+	patterns, err := wa.config.NamedPatterns()
+	if err != nil {
+		return err
+	}
+	logs.SetNamedPatterns(patterns)
+	logs.SetMaxLineLength(wa.config.Parser.MaxLineLength)
+
+	wa.sources = []logs.Source{&dockerSource{wa: wa}}
+	if err := wa.startSources(); err != nil {
 		return err
 	}
 
@@ -767,16 +949,34 @@ func (wa *WebApp) Run(addr string) error {
 		wa.cancel,
 		wa.logChan,
 	)
+	if err := ctrl.LoadContainerAliases(); err != nil {
+		slog.Error("failed to load container aliases", "error", err)
+	}
+	if err := ctrl.LoadSuppressionPatterns(); err != nil {
+		slog.Error("failed to load suppression patterns", "error", err)
+	}
 	ctrl.SetContainers(wa.containers)
 
+	ctrl.SetMaxBodyBytes(wa.config.MaxBodyBytes)
+	ctrl.SetSnapshotDir(wa.config.SnapshotDir)
+	ctrl.SetMaxConcurrentExecutions(wa.config.MaxConcurrentExecutions)
+	sqlexplain.SetIgnoredTables(wa.config.Parser.IgnoredTables)
+	sqlexplain.SetMinRecommendationRows(wa.config.Parser.MinRecommendationRows)
+	sqlexplain.SetAutoExplainThresholds(wa.config.Parser.AutoExplainMinMS, wa.config.Parser.AutoExplainMinTotalMS)
+	if err := sqlutil.SetStatementDurationMode(wa.config.Parser.MultiStatementDuration); err != nil {
+		return err
+	}
+
 	// Store controller reference in WebApp
 	wa.controllerMutex.Lock()
 	wa.controller = ctrl
 	wa.controllerMutex.Unlock()
+	wa.suppressor = ctrl.Suppressor()
 
 	slog.Info("starting background goroutines")
+	wa.processDone = make(chan struct{})
 	go wa.processLogs()
-	go wa.monitorContainers()
+	go wa.logStore.RunSweeper(wa.ctx, wa.config.LogStore.SweepInterval)
 
 	if err := wa.loadContainerRetentions(); err != nil {
 		slog.Error("failed to load container retentions", "error", err)
@@ -789,54 +989,11 @@ func (wa *WebApp) Run(addr string) error {
 
 	// Apply logging middleware to all routes
 	r.Use(loggingMiddleware)
+	if wa.config.Auth.Enabled() {
+		r.Use(basicAuthMiddleware(wa.config.Auth))
+	}
 
-	// Container and log endpoints
-	r.HandleFunc("/api/containers", ctrl.HandleContainers).Methods("GET")
-	r.HandleFunc("/api/logs", ctrl.HandleLogs).Methods("GET")
-	r.HandleFunc("/api/logs/clear", ctrl.HandleClearLogs).Methods("POST")
-	r.HandleFunc("/api/ws", ctrl.HandleWebSocket).Methods("GET")
-	r.HandleFunc("/api/debug", ctrl.HandleDebug).Methods("GET")
-
-	// SQL and trace endpoints
-	r.HandleFunc("/api/explain", ctrl.HandleExplain).Methods("POST")
-	r.HandleFunc("/api/save-trace", ctrl.HandleSaveTrace).Methods("POST")
-
-	// Server management endpoints
-	r.HandleFunc("/api/servers", ctrl.HandleListServers).Methods("GET")
-	r.HandleFunc("/api/servers", ctrl.HandleCreateServer).Methods("POST")
-	r.HandleFunc("/api/servers/{id}", ctrl.HandleGetServer).Methods("GET")
-	r.HandleFunc("/api/servers/{id}", ctrl.HandleUpdateServer).Methods("PUT")
-	r.HandleFunc("/api/servers/{id}", ctrl.HandleDeleteServer).Methods("DELETE")
-
-	// Database URL endpoints
-	r.HandleFunc("/api/database-urls", ctrl.HandleListDatabaseURLs).Methods("GET")
-	r.HandleFunc("/api/database-urls", ctrl.HandleCreateDatabaseURL).Methods("POST")
-	r.HandleFunc("/api/database-urls/{id}", ctrl.HandleGetDatabaseURL).Methods("GET")
-	r.HandleFunc("/api/database-urls/{id}", ctrl.HandleUpdateDatabaseURL).Methods("PUT")
-	r.HandleFunc("/api/database-urls/{id}", ctrl.HandleDeleteDatabaseURL).Methods("DELETE")
-
-	// Retention endpoints
-	r.HandleFunc("/api/retention", ctrl.HandleListRetentions).Methods("GET")
-	r.HandleFunc("/api/retention", ctrl.HandleCreateRetention).Methods("POST")
-	r.HandleFunc("/api/retention/{containerName}", ctrl.HandleGetRetention).Methods("GET")
-	r.HandleFunc("/api/retention/{containerName}", ctrl.HandleDeleteRetention).Methods("DELETE")
-
-	// SQL endpoints
-	r.HandleFunc("/api/sql/{hash}", ctrl.HandleSQLDetail).Methods("GET")
-	r.HandleFunc("/api/sql/{hash}/export-notion", ctrl.HandleSQLNotionExport).Methods("POST")
-
-	// Request management endpoints
-	r.HandleFunc("/api/samples/", ctrl.HandleListSampleQueries).Methods("GET")
-	r.HandleFunc("/api/samples/", ctrl.HandleCreateSampleQuery).Methods("POST")
-	r.HandleFunc("/api/samples/{id}", ctrl.HandleGetSampleQuery).Methods("GET")
-	r.HandleFunc("/api/samples/{id}", ctrl.HandleDeleteSampleQuery).Methods("DELETE")
-
-	// Execution endpoints
-	r.HandleFunc("/api/requests", ctrl.HandleCreateRequest).Methods("POST")
-	// r.HandleFunc("/api/requests", ctrl.HandleListRequestsBySample).Methods("GET")
-	r.HandleFunc("/api/requests", ctrl.HandleListAllRequests).Methods("GET")
-	r.HandleFunc("/api/requests/{id}", ctrl.HandleGetRequestDetail).Methods("GET")
-	r.HandleFunc("/api/requests/{id}/export-notion", ctrl.HandleNotionExportForRequest).Methods("POST")
+	registerAPIRoutes(r, ctrl)
 
 	// Serve static assets from Vite build output
 	// In production, serve from dist folder built by Vite
@@ -862,10 +1019,17 @@ func (wa *WebApp) Run(addr string) error {
 		http.ServeFile(w, r, indexPath)
 	})
 
+	// Requests get their own base context rather than the app's, which shutdown cancels
+	// first. It's cancelled once the graceful drain is over, so in-flight requests can
+	// finish but slow store queries don't outlive it.
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
 	// Create HTTP server with graceful shutdown
 	server := &http.Server{
-		Addr:    addr,
-		Handler: r, // Use the mux router as handler
+		Addr:        addr,
+		Handler:     r, // Use the mux router as handler
+		BaseContext: func(net.Listener) context.Context { return requestCtx },
 	}
 
 	// Set up signal handling for graceful shutdown
@@ -890,19 +1054,18 @@ func (wa *WebApp) Run(addr string) error {
 	case sig := <-sigChan:
 		slog.Info("received shutdown signal", "signal", sig)
 
-		// Shutdown gracefully
+		// Drain sources and flush remaining logs to clients before closing connections
 		wa.shutdown()
 
-		// Give goroutines a moment to exit
-		time.Sleep(200 * time.Millisecond)
-
 		// Create shutdown context with timeout
 		slog.Info("initiating graceful server shutdown", "timeout", "5s")
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer shutdownCancel()
 
-		// Shutdown server gracefully
-		if err := server.Shutdown(shutdownCtx); err != nil {
+		// Shutdown server gracefully, then stop whatever requests it timed out on
+		err := server.Shutdown(shutdownCtx)
+		cancelRequests()
+		if err != nil {
 			slog.Error("server shutdown error", "error", err)
 			return err
 		}
@@ -915,7 +1078,13 @@ func (wa *WebApp) Run(addr string) error {
 func main() {
 	slog.Info("application starting")
 
-	app, err := NewWebApp()
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		slog.Error("failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	app, err := NewWebApp(cfg)
 	if err != nil {
 		slog.Error("failed to create app", "error", err)
 		os.Exit(1)
@@ -942,8 +1111,117 @@ func main() {
 		slog.Info("cleanup complete")
 	}()
 
-	if err := app.Run(":9000"); err != nil {
+	if err := app.Run(cfg.ListenAddr); err != nil {
 		slog.Error("failed to run server", "error", err)
 		os.Exit(1)
 	}
 }
+
+// registerAPIRoutes registers all controller endpoints under /api.
+func registerAPIRoutes(r *mux.Router, ctrl *controller.Controller) {
+	// Container and log endpoints
+	r.HandleFunc("/api/containers", ctrl.HandleContainers).Methods("GET")
+	r.HandleFunc("/api/containers/history", ctrl.HandleHistoricalContainers).Methods("GET")
+	r.HandleFunc("/api/logs", ctrl.HandleLogs).Methods("GET")
+	r.HandleFunc("/api/logs/clear", ctrl.HandleClearLogs).Methods("POST")
+	r.HandleFunc("/api/logs/fields", ctrl.HandleLogFields).Methods("GET")
+	r.HandleFunc("/api/logs/fields/{name}/values", ctrl.HandleLogFieldValues).Methods("GET")
+	r.HandleFunc("/api/logs/histogram", ctrl.HandleLogFieldHistogram).Methods("GET")
+	r.HandleFunc("/api/ws", ctrl.HandleWebSocket).Methods("GET")
+	r.HandleFunc("/api/logs/stream", ctrl.HandleLogStream).Methods("GET")
+	r.HandleFunc("/api/logs/snapshot", ctrl.HandleCreateLogSnapshot).Methods("POST")
+	r.HandleFunc("/api/logs/snapshots/{id}", ctrl.HandleDownloadLogSnapshot).Methods("GET")
+	r.HandleFunc("/api/debug", ctrl.HandleDebug).Methods("GET")
+	r.HandleFunc("/api/openapi.json", ctrl.HandleOpenAPI).Methods("GET")
+
+	// SQL and trace endpoints
+	r.HandleFunc("/api/explain", ctrl.HandleExplain).Methods("POST")
+	r.HandleFunc("/api/save-trace", ctrl.HandleSaveTrace).Methods("POST")
+
+	// Server management endpoints
+	r.HandleFunc("/api/servers", ctrl.HandleListServers).Methods("GET")
+	r.HandleFunc("/api/servers", ctrl.HandleCreateServer).Methods("POST")
+	r.HandleFunc("/api/servers/compare", ctrl.HandleCompareServers).Methods("GET")
+	r.HandleFunc("/api/servers/active", ctrl.HandleListActiveServers).Methods("GET")
+	r.HandleFunc("/api/servers/{id}", ctrl.HandleGetServer).Methods("GET")
+	r.HandleFunc("/api/servers/{id}", ctrl.HandleUpdateServer).Methods("PUT")
+	r.HandleFunc("/api/servers/{id}", ctrl.HandleDeleteServer).Methods("DELETE")
+	r.HandleFunc("/api/servers/{id}/validate", ctrl.HandleValidateGraphQL).Methods("POST")
+	r.HandleFunc("/api/servers/{id}/introspect", ctrl.HandleIntrospectServer).Methods("POST")
+	r.HandleFunc("/api/servers/{id}/schema", ctrl.HandleGetServerSchema).Methods("GET")
+
+	// Database URL endpoints
+	r.HandleFunc("/api/database-urls", ctrl.HandleListDatabaseURLs).Methods("GET")
+	r.HandleFunc("/api/database-urls", ctrl.HandleCreateDatabaseURL).Methods("POST")
+	r.HandleFunc("/api/database-urls/{id}", ctrl.HandleGetDatabaseURL).Methods("GET")
+	r.HandleFunc("/api/database-urls/{id}", ctrl.HandleUpdateDatabaseURL).Methods("PUT")
+	r.HandleFunc("/api/database-urls/{id}", ctrl.HandleDeleteDatabaseURL).Methods("DELETE")
+
+	// Retention endpoints
+	r.HandleFunc("/api/retention", ctrl.HandleListRetentions).Methods("GET")
+	r.HandleFunc("/api/retention", ctrl.HandleCreateRetention).Methods("POST")
+	r.HandleFunc("/api/retention/{containerName}", ctrl.HandleGetRetention).Methods("GET")
+	r.HandleFunc("/api/retention/{containerName}", ctrl.HandleDeleteRetention).Methods("DELETE")
+
+	// Container alias endpoints
+	r.HandleFunc("/api/container-aliases", ctrl.HandleListContainerAliases).Methods("GET")
+	r.HandleFunc("/api/container-aliases", ctrl.HandleSaveContainerAlias).Methods("POST")
+	r.HandleFunc("/api/container-aliases/{id}", ctrl.HandleDeleteContainerAlias).Methods("DELETE")
+	r.HandleFunc("/api/suppression-patterns", ctrl.HandleListSuppressionPatterns).Methods("GET")
+	r.HandleFunc("/api/suppression-patterns", ctrl.HandleSaveSuppressionPattern).Methods("POST")
+	r.HandleFunc("/api/suppression-patterns/{id}", ctrl.HandleDeleteSuppressionPattern).Methods("DELETE")
+
+	// Container SQL marker endpoints
+	r.HandleFunc("/api/sql-markers", ctrl.HandleListSQLMarkers).Methods("GET")
+	r.HandleFunc("/api/sql-markers", ctrl.HandleSaveSQLMarker).Methods("POST")
+	r.HandleFunc("/api/sql-markers/presets", ctrl.HandleListSQLMarkerPresets).Methods("GET")
+	r.HandleFunc("/api/sql-markers/{containerName}", ctrl.HandleDeleteSQLMarker).Methods("DELETE")
+
+	// Bookmark endpoints
+	r.HandleFunc("/api/bookmarks", ctrl.HandleListBookmarks).Methods("GET")
+	r.HandleFunc("/api/bookmarks", ctrl.HandleCreateBookmark).Methods("POST")
+	r.HandleFunc("/api/bookmarks/{id}", ctrl.HandleDeleteBookmark).Methods("DELETE")
+
+	// Saved view endpoints
+	r.HandleFunc("/api/views", ctrl.HandleCreateView).Methods("POST")
+	r.HandleFunc("/api/views/{id}", ctrl.HandleGetView).Methods("GET")
+
+	// Field display format endpoints
+	r.HandleFunc("/api/field-formats", ctrl.HandleListFieldFormats).Methods("GET")
+	r.HandleFunc("/api/field-formats", ctrl.HandleSaveFieldFormat).Methods("POST")
+	r.HandleFunc("/api/field-formats/{fieldName}", ctrl.HandleDeleteFieldFormat).Methods("DELETE")
+
+	// SQL endpoints
+	r.HandleFunc("/api/sql/{hash}", ctrl.HandleSQLDetail).Methods("GET")
+	r.HandleFunc("/api/sql/{hash}/export-notion", ctrl.HandleSQLNotionExport).Methods("POST")
+
+	// Request management endpoints
+	r.HandleFunc("/api/samples/", ctrl.HandleListSampleQueries).Methods("GET")
+	r.HandleFunc("/api/samples/", ctrl.HandleCreateSampleQuery).Methods("POST")
+	r.HandleFunc("/api/samples/expectations/import", ctrl.HandleImportSampleExpectations).Methods("POST")
+	r.HandleFunc("/api/samples/{id}", ctrl.HandleGetSampleQuery).Methods("GET")
+	r.HandleFunc("/api/samples/{id}/executions", ctrl.HandleListSampleExecutions).Methods("GET")
+	r.HandleFunc("/api/samples/{id}/trend", ctrl.HandleSampleTrend).Methods("GET")
+	r.HandleFunc("/api/samples/{id}/expectations", ctrl.HandleGetSampleExpectations).Methods("GET")
+	r.HandleFunc("/api/samples/{id}/expectations", ctrl.HandleSetSampleExpectations).Methods("PUT")
+	r.HandleFunc("/api/samples/{id}/expectations", ctrl.HandleDeleteSampleExpectations).Methods("DELETE")
+	r.HandleFunc("/api/samples/{id}", ctrl.HandleDeleteSampleQuery).Methods("DELETE")
+
+	// Execution endpoints
+	r.HandleFunc("/api/requests", ctrl.HandleCreateRequest).Methods("POST")
+	// r.HandleFunc("/api/requests", ctrl.HandleListRequestsBySample).Methods("GET")
+	r.HandleFunc("/api/requests", ctrl.HandleListAllRequests).Methods("GET")
+	r.HandleFunc("/api/requests/retries", ctrl.HandleListRetryGroups).Methods("GET")
+	r.HandleFunc("/api/requests/{id}", ctrl.HandleGetRequestDetail).Methods("GET")
+	r.HandleFunc("/api/requests/{id}/export-notion", ctrl.HandleNotionExportForRequest).Methods("POST")
+	r.HandleFunc("/api/executions/queue", ctrl.HandleExecutionQueue).Methods("GET")
+	r.HandleFunc("/api/executions/compare", ctrl.HandleCompareExecutions).Methods("GET")
+	r.HandleFunc("/api/executions/slowest", ctrl.HandleSlowestExecutions).Methods("GET")
+	r.HandleFunc("/api/executions/export", ctrl.HandleExportExecutions).Methods("GET")
+	r.HandleFunc("/api/executions/{id}/traces", ctrl.HandleListExecutionTraces).Methods("GET")
+	r.HandleFunc("/api/executions/{id}/timeline", ctrl.HandleExecutionTimeline).Methods("GET")
+	r.HandleFunc("/api/executions/{id}/fixture", ctrl.HandleExecutionFixture).Methods("GET")
+	r.HandleFunc("/api/executions/{id}/cancel", ctrl.HandleCancelExecution).Methods("POST")
+	r.HandleFunc("/api/executions/{id}/link-logs", ctrl.HandleLinkExecutionLogs).Methods("POST")
+	r.HandleFunc("/api/operations/{name}/anomalies", ctrl.HandleOperationAnomalies).Methods("GET")
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"docker-log-parser/pkg/config"
+	"docker-log-parser/pkg/controller"
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/logstore"
+
+	"github.com/gorilla/mux"
+)
+
+func TestOpenAPICoversRegisteredRoutes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl := controller.NewController(nil, logstore.NewLogStore(100, time.Hour), nil, ctx, cancel, make(chan logs.ContainerMessage, 1))
+	r := mux.NewRouter()
+	registerAPIRoutes(r, ctrl)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var spec struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("failed to parse spec: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("expected OpenAPI 3 spec, got %q", spec.OpenAPI)
+	}
+
+	err := r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		operations, ok := spec.Paths[path]
+		if !ok {
+			t.Errorf("route %s missing from spec", path)
+			return nil
+		}
+		methods, _ := route.GetMethods()
+		for _, method := range methods {
+			if _, ok := operations[strings.ToLower(method)]; !ok {
+				t.Errorf("route %s %s missing from spec", method, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to walk routes: %v", err)
+	}
+}
+
+func TestBasicAuthMiddleware(t *testing.T) {
+	handler := basicAuthMiddleware(config.AuthConfig{Username: "admin", Password: "secret"})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/containers", nil)
+	req.SetBasicAuth("admin", "secret")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 with valid credentials, got %d", w.Code)
+	}
+
+	// API clients get the usual JSON error
+	req = httptest.NewRequest(http.MethodGet, "/api/containers", nil)
+	req.SetBasicAuth("admin", "wrong")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %d", w.Code)
+	}
+	if w.Header().Get("WWW-Authenticate") == "" {
+		t.Error("expected a WWW-Authenticate header")
+	}
+	var resp controller.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("expected a JSON error, got %q: %v", w.Body.String(), err)
+	}
+	if resp.Error.Code != controller.ErrCodeUnauthorized {
+		t.Errorf("expected code %q, got %q", controller.ErrCodeUnauthorized, resp.Error.Code)
+	}
+
+	// Pages still prompt the browser with a plain response
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/requests", nil))
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("expected a 401 challenge, got %d", w.Code)
+	}
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Error("expected a plain-text error outside the API")
+	}
+}
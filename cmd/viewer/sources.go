@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"docker-log-parser/pkg/logs"
+)
+
+// sourceStopTimeout bounds how long shutdown waits for one source to stop. A source
+// still sending after that finds logChan closed, which the Docker streams tolerate.
+const sourceStopTimeout = 5 * time.Second
+
+// drainTimeout bounds how long shutdown waits for processLogs to flush the last batch
+const drainTimeout = 5 * time.Second
+
+// dockerSource streams logs from every running container, starting streams for new
+// containers and resuming ones that ended while their container kept running
+type dockerSource struct {
+	wa      *WebApp
+	cancel  context.CancelFunc
+	monitor sync.WaitGroup // The monitorContainers goroutine
+	streams sync.WaitGroup // One per container log stream
+}
+
+func (s *dockerSource) Name() string {
+	return "docker"
+}
+
+func (s *dockerSource) Start(ctx context.Context) error {
+	ctx, s.cancel = context.WithCancel(ctx)
+	if err := s.wa.loadContainers(ctx, &s.streams); err != nil {
+		s.cancel()
+		return err
+	}
+
+	s.monitor.Add(1)
+	go func() {
+		defer s.monitor.Done()
+		s.wa.monitorContainers(ctx, &s.streams)
+	}()
+	return nil
+}
+
+func (s *dockerSource) Stop() {
+	s.cancel()
+	// Waiting for the monitor first means no new stream can start while the streams are
+	// waited on
+	s.monitor.Wait()
+	s.streams.Wait()
+}
+
+// startSources starts every source, stopping the ones already started if one fails
+func (wa *WebApp) startSources() error {
+	for i, source := range wa.sources {
+		slog.Info("starting log source", "source", source.Name())
+		if err := source.Start(wa.ctx); err != nil {
+			for _, started := range wa.sources[:i] {
+				wa.stopSource(started)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// stopSource stops source, giving up after sourceStopTimeout
+func (wa *WebApp) stopSource(source logs.Source) {
+	stopped := make(chan struct{})
+	go func() {
+		source.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		slog.Info("log source stopped", "source", source.Name())
+	case <-time.After(sourceStopTimeout):
+		slog.Warn("log source did not stop in time", "source", source.Name(), "timeout", sourceStopTimeout)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"docker-log-parser/pkg/controller"
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/logstore"
+)
+
+// fakeSource sends numbered messages until stopped, then sends one final message the
+// way a stream flushes its buffered entry
+type fakeSource struct {
+	logChan chan<- logs.ContainerMessage
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	sent    int
+	stopped bool
+}
+
+func (s *fakeSource) Name() string {
+	return "fake"
+}
+
+func (s *fakeSource) Start(ctx context.Context) error {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				s.send("final")
+				return
+			case <-time.After(time.Millisecond):
+				s.send(fmt.Sprintf("line %d", s.sent))
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *fakeSource) send(message string) {
+	s.logChan <- logs.ContainerMessage{
+		ContainerID: "fake",
+		Timestamp:   time.Now(),
+		Entry:       &logs.LogEntry{Message: message},
+	}
+	s.sent++
+}
+
+func (s *fakeSource) Stop() {
+	s.cancel()
+	s.wg.Wait()
+	s.stopped = true
+}
+
+func TestShutdownDrainsSources(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	wa := &WebApp{
+		logStore:       logstore.NewLogStore(10000, time.Hour),
+		clients:        make(map[*Client]bool),
+		logChan:        make(chan logs.ContainerMessage, 1000),
+		lastTimestamps: make(map[string]time.Time),
+		ctx:            ctx,
+		cancel:         cancel,
+		processDone:    make(chan struct{}),
+	}
+	wa.controller = controller.NewController(nil, wa.logStore, nil, ctx, cancel, wa.logChan)
+	snapshotDir := t.TempDir()
+	wa.controller.SetSnapshotDir(snapshotDir)
+	source := &fakeSource{logChan: wa.logChan}
+	wa.sources = []logs.Source{source}
+
+	if err := wa.startSources(); err != nil {
+		t.Fatalf("Failed to start sources: %v", err)
+	}
+	go wa.processLogs()
+	time.Sleep(20 * time.Millisecond)
+
+	wa.shutdown()
+
+	if !source.stopped {
+		t.Fatal("Expected the source to be stopped")
+	}
+	select {
+	case <-wa.processDone:
+	default:
+		t.Fatal("Expected processLogs to finish draining before shutdown returned")
+	}
+	if ctx.Err() == nil {
+		t.Error("Expected the context to be cancelled after draining")
+	}
+
+	if got := wa.logStore.Count(); got != source.sent {
+		t.Errorf("Expected all %d sent messages in the store, got %d", source.sent, got)
+	}
+	if len(wa.logBatch) != 0 {
+		t.Errorf("Expected the final batch to be flushed, %d messages left", len(wa.logBatch))
+	}
+
+	// The drained logs, including the final message, are saved before exit
+	files, _ := filepath.Glob(filepath.Join(snapshotDir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("Expected one shutdown snapshot, got %v", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	var snapshot controller.LogSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("Failed to decode snapshot: %v", err)
+	}
+	if snapshot.Count != source.sent || snapshot.Logs[snapshot.Count-1].Entry.Message != "final" {
+		t.Errorf("Expected all %d messages ending with the final one, got %d", source.sent, snapshot.Count)
+	}
+}
+
+func TestProcessLogsSuppressesNoise(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	suppressor := logs.NewSuppressor()
+	suppressor.SetPatterns([]*regexp.Regexp{regexp.MustCompile(`GET /healthz`)})
+	wa := &WebApp{
+		logStore:       logstore.NewLogStore(100, time.Hour),
+		clients:        make(map[*Client]bool),
+		logChan:        make(chan logs.ContainerMessage, 10),
+		lastTimestamps: make(map[string]time.Time),
+		ctx:            ctx,
+		cancel:         cancel,
+		processDone:    make(chan struct{}),
+		suppressor:     suppressor,
+	}
+	go wa.processLogs()
+
+	for _, raw := range []string{"GET /healthz 200", "GET /api/users 200", "GET /healthz 200"} {
+		wa.logChan <- logs.ContainerMessage{ContainerID: "api", Timestamp: time.Now(), Entry: &logs.LogEntry{Raw: raw, Message: raw}}
+	}
+	close(wa.logChan)
+	<-wa.processDone
+
+	if got := wa.logStore.Count(); got != 1 {
+		t.Errorf("Expected only the request line to be stored, got %d lines", got)
+	}
+	if counts := suppressor.TakeCounts(); counts["GET /healthz"] != 2 {
+		t.Errorf("Expected 2 suppressed lines counted, got %v", counts)
+	}
+	if _, ok := wa.lastTimestamps["api"]; !ok {
+		t.Error("Expected suppressed lines to still advance the container's last timestamp")
+	}
+}
@@ -0,0 +1,493 @@
+// Package config resolves the viewer's settings from defaults, an optional TOML or YAML
+// file, environment variables and command-line flags, in increasing order of precedence.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/sqlexplain"
+	"docker-log-parser/pkg/sqlutil"
+	"docker-log-parser/pkg/theme"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Defaults used when neither the file, the environment nor flags set a value
+const (
+	DefaultListenAddr    = ":9000"
+	DefaultDBPath        = "graphql-requests.db"
+	DefaultSnapshotDir   = "snapshots"
+	DefaultMaxMessages   = 10000
+	DefaultMaxAge        = 2 * time.Hour
+	DefaultSweepInterval = 30 * time.Second
+	DefaultLogFormat     = LogFormatText
+	DefaultLogLevel      = "info"
+)
+
+// Formats for the viewer's own logs
+const (
+	LogFormatText = "text" // Human readable and colored
+	LogFormatJSON = "json" // One JSON object per line, for log aggregators
+)
+
+// Config holds the resolved viewer settings
+type Config struct {
+	ListenAddr   string          `toml:"listen_addr" yaml:"listen_addr"`
+	DBPath       string          `toml:"db_path" yaml:"db_path"`
+	SnapshotDir  string          `toml:"snapshot_dir" yaml:"snapshot_dir"` // Where log snapshots are written
+	Debug        bool            `toml:"debug" yaml:"debug"`               // Shorthand for log_level = "debug"
+	LogFormat    string          `toml:"log_format" yaml:"log_format"`
+	LogLevel     string          `toml:"log_level" yaml:"log_level"`
+	MaxBodyBytes int64           `toml:"max_body_bytes" yaml:"max_body_bytes"` // 0 keeps the controller default
+	LogStore     LogStoreConfig  `toml:"logstore" yaml:"logstore"`
+	Docker       DockerConfig    `toml:"docker" yaml:"docker"`
+	Auth         AuthConfig      `toml:"auth" yaml:"auth"`
+	Retention    RetentionConfig `toml:"retention" yaml:"retention"`
+	Parser       ParserConfig    `toml:"parser" yaml:"parser"`
+	Theme        ThemeConfig     `toml:"theme" yaml:"theme"`
+
+	// MaxConcurrentExecutions bounds the requests executed at once; 0 keeps the controller default
+	MaxConcurrentExecutions int `toml:"max_concurrent_executions" yaml:"max_concurrent_executions"`
+	// LogBatchSize is the number of execution log rows saved per insert; 0 keeps the store default
+	LogBatchSize int `toml:"log_batch_size" yaml:"log_batch_size"`
+}
+
+// LogStoreConfig limits the in-memory log store
+type LogStoreConfig struct {
+	MaxMessages int           `toml:"max_messages" yaml:"max_messages"`
+	MaxAge      time.Duration `toml:"max_age" yaml:"max_age"`
+	// SweepInterval is how often messages past max_age are evicted while no new ones
+	// arrive; 0 only evicts as messages are added
+	SweepInterval time.Duration `toml:"sweep_interval" yaml:"sweep_interval"`
+}
+
+// DockerConfig selects the Docker daemon to read containers from. An empty host uses
+// the Docker client defaults.
+type DockerConfig struct {
+	Host string `toml:"host" yaml:"host"`
+	// IngestSelf streams the viewer's own container too. It is skipped by default since
+	// the viewer logs every batch it ingests, which would feed back into itself.
+	IngestSelf bool `toml:"ingest_self" yaml:"ingest_self"`
+	// SynthesizeTimestamps gives entries logged without a timestamp the one Docker
+	// recorded for the line
+	SynthesizeTimestamps bool `toml:"synthesize_timestamps" yaml:"synthesize_timestamps"`
+	// Include and Exclude select the containers to attach to by name or image, as globs or
+	// /regular expressions/. Without Include every container not excluded is streamed.
+	Include []string `toml:"include" yaml:"include"`
+	Exclude []string `toml:"exclude" yaml:"exclude"`
+}
+
+// AuthConfig protects the viewer with HTTP basic auth when a password is set
+type AuthConfig struct {
+	Username string `toml:"username" yaml:"username"`
+	Password string `toml:"password" yaml:"password"`
+}
+
+// Enabled reports whether basic auth is configured
+func (a AuthConfig) Enabled() bool {
+	return a.Password != ""
+}
+
+// RetentionConfig is the retention applied at startup to containers without their own
+// setting. An empty type keeps the log store limits only.
+type RetentionConfig struct {
+	Type    string `toml:"type" yaml:"type"`         // "count" or "time"
+	Value   int    `toml:"value" yaml:"value"`       // number of logs or seconds
+	MinKeep int    `toml:"min_keep" yaml:"min_keep"` // newest logs a time policy keeps; 0 for the default of 100
+}
+
+// ParserConfig tunes SQL extraction and analysis
+type ParserConfig struct {
+	IgnoredTables          []string `toml:"ignored_tables" yaml:"ignored_tables"`
+	MinRecommendationRows  float64  `toml:"min_recommendation_rows" yaml:"min_recommendation_rows"`
+	MultiStatementDuration string   `toml:"multi_statement_duration" yaml:"multi_statement_duration"`
+	// Saved traces EXPLAIN queries slower than AutoExplainMinMS, and queries whose runs
+	// together took longer than AutoExplainMinTotalMS (zero disables this)
+	AutoExplainMinMS      float64 `toml:"auto_explain_min_ms" yaml:"auto_explain_min_ms"`
+	AutoExplainMinTotalMS float64 `toml:"auto_explain_min_total_ms" yaml:"auto_explain_min_total_ms"`
+	// MaxLineLength is the length in bytes beyond which log lines are truncated; zero
+	// keeps lines whole
+	MaxLineLength int `toml:"max_line_length" yaml:"max_line_length"`
+	// Patterns extract fields from custom log formats the built-in parsers miss
+	Patterns []PatternConfig `toml:"patterns" yaml:"patterns"`
+}
+
+// ThemeConfig sets the colors of the terminal front ends
+type ThemeConfig struct {
+	Preset string            `toml:"preset" yaml:"preset"` // "dark" (the default) or "light"
+	Levels map[string]string `toml:"levels" yaml:"levels"` // Level to #rrggbb, overriding the preset
+}
+
+// PatternConfig is a field extraction rule, compiled into a logs.NamedPattern
+type PatternConfig struct {
+	Name   string            `toml:"name" yaml:"name"`
+	Regex  string            `toml:"regex" yaml:"regex"`   // Named capture groups become fields
+	Fields map[string]string `toml:"fields" yaml:"fields"` // Renames capture groups to field names
+}
+
+// Default returns the configuration used when nothing overrides it
+func Default() *Config {
+	return &Config{
+		ListenAddr:  DefaultListenAddr,
+		DBPath:      DefaultDBPath,
+		SnapshotDir: DefaultSnapshotDir,
+		LogFormat:   DefaultLogFormat,
+		LogLevel:    DefaultLogLevel,
+		LogStore: LogStoreConfig{
+			MaxMessages:   DefaultMaxMessages,
+			MaxAge:        DefaultMaxAge,
+			SweepInterval: DefaultSweepInterval,
+		},
+		Parser: ParserConfig{
+			IgnoredTables:          slices.Clone(sqlexplain.DefaultIgnoredTables),
+			MinRecommendationRows:  sqlexplain.DefaultMinRecommendationRows,
+			MultiStatementDuration: sqlutil.StatementDurationDivide,
+			AutoExplainMinMS:       sqlexplain.DefaultAutoExplainMinMS,
+			AutoExplainMinTotalMS:  sqlexplain.DefaultAutoExplainMinTotalMS,
+			MaxLineLength:          logs.DefaultMaxLineLength,
+		},
+	}
+}
+
+// Load resolves the configuration for args, the command-line arguments without the
+// program name. The file comes from -config or CONFIG_FILE; without either only
+// defaults, environment variables and flags apply.
+func Load(args []string) (*Config, error) {
+	return load(args, os.LookupEnv)
+}
+
+func load(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	fs := flag.NewFlagSet("viewer", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Path to a TOML or YAML config file (env: CONFIG_FILE)")
+	addr := fs.String("addr", "", "Listen address (env: LISTEN_ADDR)")
+	dbPath := fs.String("db", "", "SQLite database path (env: DB_PATH)")
+	debug := fs.Bool("debug", false, "Enable debug logging (env: DEBUG)")
+	include := fs.String("include", "", "Comma-separated container name or image patterns to stream (env: CONTAINER_INCLUDE)")
+	exclude := fs.String("exclude", "", "Comma-separated container name or image patterns to skip (env: CONTAINER_EXCLUDE)")
+	ingestSelf := fs.Bool("ingest-self", false, "Stream the viewer's own container logs (env: INGEST_SELF)")
+	synthesizeTimestamps := fs.Bool("synthesize-timestamps", false, "Timestamp entries logged without one from Docker (env: SYNTHESIZE_TIMESTAMPS)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	path := *configPath
+	if path == "" {
+		path, _ = lookupEnv("CONFIG_FILE")
+	}
+
+	cfg := Default()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(lookupEnv); err != nil {
+		return nil, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			cfg.ListenAddr = *addr
+		case "db":
+			cfg.DBPath = *dbPath
+		case "debug":
+			cfg.Debug = *debug
+		case "include":
+			cfg.Docker.Include = splitList(*include)
+		case "exclude":
+			cfg.Docker.Exclude = splitList(*exclude)
+		case "ingest-self":
+			cfg.Docker.IngestSelf = *ingestSelf
+		case "synthesize-timestamps":
+			cfg.Docker.SynthesizeTimestamps = *synthesizeTimestamps
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile decodes the file at path over cfg, choosing TOML or YAML by extension.
+// Keys that do not match a setting are reported as an error.
+func (cfg *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, key := range undecoded {
+				keys[i] = key.String()
+			}
+			return fmt.Errorf("unknown keys in config file %s: %s", path, strings.Join(keys, ", "))
+		}
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension %q, expected .toml, .yaml or .yml", ext)
+	}
+	return nil
+}
+
+// applyEnv overrides cfg with any settings present in the environment
+func (cfg *Config) applyEnv(lookupEnv func(string) (string, bool)) error {
+	str := func(name string, dst *string) {
+		if value, ok := lookupEnv(name); ok && value != "" {
+			*dst = value
+		}
+	}
+	str("LISTEN_ADDR", &cfg.ListenAddr)
+	str("DB_PATH", &cfg.DBPath)
+	str("SNAPSHOT_DIR", &cfg.SnapshotDir)
+	str("LOG_FORMAT", &cfg.LogFormat)
+	str("LOG_LEVEL", &cfg.LogLevel)
+	str("DOCKER_HOST", &cfg.Docker.Host)
+	str("AUTH_USERNAME", &cfg.Auth.Username)
+	str("AUTH_PASSWORD", &cfg.Auth.Password)
+	str("DEFAULT_RETENTION_TYPE", &cfg.Retention.Type)
+	str("MULTI_STATEMENT_DURATION", &cfg.Parser.MultiStatementDuration)
+	str("THEME", &cfg.Theme.Preset)
+
+	if value, ok := lookupEnv("DEBUG"); ok && value != "" {
+		cfg.Debug = true
+	}
+	if value, ok := lookupEnv("INGEST_SELF"); ok && value != "" {
+		cfg.Docker.IngestSelf = true
+	}
+	if value, ok := lookupEnv("SYNTHESIZE_TIMESTAMPS"); ok && value != "" {
+		cfg.Docker.SynthesizeTimestamps = true
+	}
+	if patterns, ok := lookupEnv("CONTAINER_INCLUDE"); ok {
+		cfg.Docker.Include = splitList(patterns)
+	}
+	if patterns, ok := lookupEnv("CONTAINER_EXCLUDE"); ok {
+		cfg.Docker.Exclude = splitList(patterns)
+	}
+	if tables, ok := lookupEnv("IGNORED_TABLES"); ok {
+		cfg.Parser.IgnoredTables = splitList(tables)
+	}
+
+	var errs []error
+	parse := func(name string, set func(string) error) {
+		if value, ok := lookupEnv(name); ok && value != "" {
+			if err := set(value); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s %q: %w", name, value, err))
+			}
+		}
+	}
+	parse("MAX_BODY_BYTES", func(v string) (err error) {
+		cfg.MaxBodyBytes, err = strconv.ParseInt(v, 10, 64)
+		return err
+	})
+	parse("MAX_CONCURRENT_EXECUTIONS", func(v string) (err error) {
+		cfg.MaxConcurrentExecutions, err = strconv.Atoi(v)
+		return err
+	})
+	parse("LOG_BATCH_SIZE", func(v string) (err error) {
+		cfg.LogBatchSize, err = strconv.Atoi(v)
+		return err
+	})
+	parse("LOGSTORE_MAX_MESSAGES", func(v string) (err error) {
+		cfg.LogStore.MaxMessages, err = strconv.Atoi(v)
+		return err
+	})
+	parse("LOGSTORE_MAX_AGE", func(v string) (err error) {
+		cfg.LogStore.MaxAge, err = time.ParseDuration(v)
+		return err
+	})
+	parse("LOGSTORE_SWEEP_INTERVAL", func(v string) (err error) {
+		cfg.LogStore.SweepInterval, err = time.ParseDuration(v)
+		return err
+	})
+	parse("DEFAULT_RETENTION_VALUE", func(v string) (err error) {
+		cfg.Retention.Value, err = strconv.Atoi(v)
+		return err
+	})
+	parse("DEFAULT_RETENTION_MIN_KEEP", func(v string) (err error) {
+		cfg.Retention.MinKeep, err = strconv.Atoi(v)
+		return err
+	})
+	parse("MIN_RECOMMENDATION_ROWS", func(v string) (err error) {
+		cfg.Parser.MinRecommendationRows, err = strconv.ParseFloat(v, 64)
+		return err
+	})
+	parse("AUTO_EXPLAIN_MIN_MS", func(v string) (err error) {
+		cfg.Parser.AutoExplainMinMS, err = strconv.ParseFloat(v, 64)
+		return err
+	})
+	parse("AUTO_EXPLAIN_MIN_TOTAL_MS", func(v string) (err error) {
+		cfg.Parser.AutoExplainMinTotalMS, err = strconv.ParseFloat(v, 64)
+		return err
+	})
+	parse("MAX_LINE_LENGTH", func(v string) (err error) {
+		cfg.Parser.MaxLineLength, err = strconv.Atoi(v)
+		return err
+	})
+	return errors.Join(errs...)
+}
+
+// Validate reports every setting that is out of range
+func (cfg *Config) Validate() error {
+	var errs []error
+	if cfg.ListenAddr == "" {
+		errs = append(errs, errors.New("listen_addr is required"))
+	}
+	if cfg.DBPath == "" {
+		errs = append(errs, errors.New("db_path is required"))
+	}
+	if cfg.SnapshotDir == "" {
+		errs = append(errs, errors.New("snapshot_dir is required"))
+	}
+	switch cfg.LogFormat {
+	case LogFormatText, LogFormatJSON:
+	default:
+		errs = append(errs, fmt.Errorf("log_format must be %s or %s, got %q", LogFormatText, LogFormatJSON, cfg.LogFormat))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		errs = append(errs, fmt.Errorf("log_level must be debug, info, warn or error, got %q", cfg.LogLevel))
+	}
+	if cfg.MaxBodyBytes < 0 {
+		errs = append(errs, fmt.Errorf("max_body_bytes must not be negative, got %d", cfg.MaxBodyBytes))
+	}
+	if cfg.MaxConcurrentExecutions < 0 {
+		errs = append(errs, fmt.Errorf("max_concurrent_executions must not be negative, got %d", cfg.MaxConcurrentExecutions))
+	}
+	if cfg.LogBatchSize < 0 {
+		errs = append(errs, fmt.Errorf("log_batch_size must not be negative, got %d", cfg.LogBatchSize))
+	}
+	if cfg.LogStore.MaxMessages <= 0 {
+		errs = append(errs, fmt.Errorf("logstore.max_messages must be positive, got %d", cfg.LogStore.MaxMessages))
+	}
+	if cfg.LogStore.MaxAge <= 0 {
+		errs = append(errs, fmt.Errorf("logstore.max_age must be positive, got %s", cfg.LogStore.MaxAge))
+	}
+	if cfg.LogStore.SweepInterval < 0 {
+		errs = append(errs, fmt.Errorf("logstore.sweep_interval must not be negative, got %s", cfg.LogStore.SweepInterval))
+	}
+	if _, err := cfg.ContainerFilter(); err != nil {
+		errs = append(errs, fmt.Errorf("docker: %w", err))
+	}
+	if cfg.Auth.Username != "" && cfg.Auth.Password == "" {
+		errs = append(errs, errors.New("auth.password is required when auth.username is set"))
+	}
+	switch cfg.Retention.Type {
+	case "":
+	case "count", "time":
+		if cfg.Retention.Value <= 0 {
+			errs = append(errs, fmt.Errorf("retention.value must be positive, got %d", cfg.Retention.Value))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("retention.type must be count or time, got %q", cfg.Retention.Type))
+	}
+	if cfg.Retention.MinKeep < 0 {
+		errs = append(errs, fmt.Errorf("retention.min_keep must not be negative, got %d", cfg.Retention.MinKeep))
+	}
+	if cfg.Parser.MinRecommendationRows < 0 {
+		errs = append(errs, fmt.Errorf("parser.min_recommendation_rows must not be negative, got %v", cfg.Parser.MinRecommendationRows))
+	}
+	if cfg.Parser.AutoExplainMinMS < 0 {
+		errs = append(errs, fmt.Errorf("parser.auto_explain_min_ms must not be negative, got %v", cfg.Parser.AutoExplainMinMS))
+	}
+	if cfg.Parser.AutoExplainMinTotalMS < 0 {
+		errs = append(errs, fmt.Errorf("parser.auto_explain_min_total_ms must not be negative, got %v", cfg.Parser.AutoExplainMinTotalMS))
+	}
+	if cfg.Parser.MaxLineLength < 0 {
+		errs = append(errs, fmt.Errorf("parser.max_line_length must not be negative, got %d", cfg.Parser.MaxLineLength))
+	}
+	switch cfg.Parser.MultiStatementDuration {
+	case sqlutil.StatementDurationDivide, sqlutil.StatementDurationFirst:
+	default:
+		errs = append(errs, fmt.Errorf("parser.multi_statement_duration must be %s or %s, got %q",
+			sqlutil.StatementDurationDivide, sqlutil.StatementDurationFirst, cfg.Parser.MultiStatementDuration))
+	}
+	if _, err := cfg.NamedPatterns(); err != nil {
+		errs = append(errs, fmt.Errorf("parser.patterns: %w", err))
+	}
+	if _, err := cfg.ColorTheme(); err != nil {
+		errs = append(errs, fmt.Errorf("theme: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// SlogLevel returns the minimum level of the viewer's own logs. Debug lowers it to
+// debug whatever log_level says.
+func (cfg *Config) SlogLevel() slog.Level {
+	if cfg.Debug {
+		return slog.LevelDebug
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// ContainerFilter compiles the docker include and exclude patterns
+func (cfg *Config) ContainerFilter() (*logs.ContainerFilter, error) {
+	return logs.NewContainerFilter(cfg.Docker.Include, cfg.Docker.Exclude)
+}
+
+// NamedPatterns compiles the parser's field extraction patterns, in order
+func (cfg *Config) NamedPatterns() ([]logs.NamedPattern, error) {
+	var patterns []logs.NamedPattern
+	for i, p := range cfg.Parser.Patterns {
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		pattern, err := logs.NewNamedPattern(name, p.Regex, p.Fields)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// ColorTheme resolves the theme preset with its level color overrides
+func (cfg *Config) ColorTheme() (theme.Theme, error) {
+	t, err := theme.Preset(cfg.Theme.Preset)
+	if err != nil {
+		return t, err
+	}
+	return t.WithLevels(cfg.Theme.Levels)
+}
+
+// splitList splits a comma-separated setting, dropping blank entries
+func splitList(value string) []string {
+	items := []string{}
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
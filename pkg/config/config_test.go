@@ -0,0 +1,292 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := load(nil, env(nil))
+	if err != nil {
+		t.Fatalf("Failed to load defaults: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("Expected defaults, got %+v", cfg)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	files := map[string]string{
+		"viewer.toml": `
+listen_addr = ":8080"
+db_path = "/data/viewer.db"
+snapshot_dir = "/data/snapshots"
+log_format = "json"
+log_level = "warn"
+
+[logstore]
+max_messages = 500
+max_age = "30m"
+sweep_interval = "1m"
+
+[docker]
+host = "tcp://10.0.0.5:2375"
+
+[auth]
+username = "admin"
+password = "secret"
+
+[retention]
+type = "count"
+value = 200
+min_keep = 20
+
+[parser]
+ignored_tables = ["audit_log"]
+min_recommendation_rows = 0
+multi_statement_duration = "first"
+auto_explain_min_ms = 5
+auto_explain_min_total_ms = 0
+max_line_length = 0
+
+[[parser.patterns]]
+name = "nginx"
+regex = '(?P<status>\d{3}) (?P<latency>\S+)$'
+fields = { latency = "duration" }
+
+[theme]
+preset = "light"
+levels = { ERR = "#ff0000" }
+`,
+		"viewer.yaml": `
+listen_addr: ":8080"
+db_path: /data/viewer.db
+snapshot_dir: /data/snapshots
+log_format: json
+log_level: warn
+logstore:
+  max_messages: 500
+  max_age: 30m
+  sweep_interval: 1m
+docker:
+  host: tcp://10.0.0.5:2375
+auth:
+  username: admin
+  password: secret
+retention:
+  type: count
+  value: 200
+  min_keep: 20
+parser:
+  ignored_tables: [audit_log]
+  min_recommendation_rows: 0
+  multi_statement_duration: first
+  auto_explain_min_ms: 5
+  auto_explain_min_total_ms: 0
+  max_line_length: 0
+  patterns:
+    - name: nginx
+      regex: '(?P<status>\d{3}) (?P<latency>\S+)$'
+      fields:
+        latency: duration
+theme:
+  preset: light
+  levels:
+    ERR: "#ff0000"
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := load([]string{"-config", writeConfig(t, name, content)}, env(nil))
+			if err != nil {
+				t.Fatalf("Failed to load %s: %v", name, err)
+			}
+
+			expected := &Config{
+				ListenAddr:  ":8080",
+				DBPath:      "/data/viewer.db",
+				SnapshotDir: "/data/snapshots",
+				LogFormat:   LogFormatJSON,
+				LogLevel:    "warn",
+				LogStore:    LogStoreConfig{MaxMessages: 500, MaxAge: 30 * time.Minute, SweepInterval: time.Minute},
+				Docker:      DockerConfig{Host: "tcp://10.0.0.5:2375"},
+				Auth:        AuthConfig{Username: "admin", Password: "secret"},
+				Retention:   RetentionConfig{Type: "count", Value: 200, MinKeep: 20},
+				Parser: ParserConfig{
+					IgnoredTables:          []string{"audit_log"},
+					MultiStatementDuration: "first",
+					AutoExplainMinMS:       5,
+					Patterns: []PatternConfig{{
+						Name:   "nginx",
+						Regex:  `(?P<status>\d{3}) (?P<latency>\S+)$`,
+						Fields: map[string]string{"latency": "duration"},
+					}},
+				},
+				Theme: ThemeConfig{Preset: "light", Levels: map[string]string{"ERR": "#ff0000"}},
+			}
+			if !reflect.DeepEqual(cfg, expected) {
+				t.Errorf("Expected %+v, got %+v", expected, cfg)
+			}
+		})
+	}
+}
+
+func TestLoadUnknownKeys(t *testing.T) {
+	files := map[string]string{
+		"viewer.toml": "listen_addr = \":8080\"\nlisten_port = 8080\n\n[logstore]\nmax_size = 10\n",
+		"viewer.yaml": "listen_addr: \":8080\"\nlisten_port: 8080\n",
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			_, err := load([]string{"-config", writeConfig(t, name, content)}, env(nil))
+			if err == nil || !strings.Contains(err.Error(), "listen_port") {
+				t.Fatalf("Expected an error naming the unknown key, got %v", err)
+			}
+			if strings.HasSuffix(name, ".toml") && !strings.Contains(err.Error(), "logstore.max_size") {
+				t.Errorf("Expected every unknown key to be reported, got %v", err)
+			}
+		})
+	}
+
+	if _, err := load([]string{"-config", writeConfig(t, "viewer.ini", "")}, env(nil)); err == nil {
+		t.Error("Expected an error for an unsupported extension")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfig(t, "viewer.toml", "listen_addr = \":8080\"\ndb_path = \"file.db\"\n\n[logstore]\nmax_age = \"1h\"\n")
+
+	cfg, err := load([]string{"-addr", ":7000", "-exclude", "redis, *worker*", "-synthesize-timestamps"}, env(map[string]string{
+		"CONFIG_FILE":       path,
+		"LISTEN_ADDR":       ":9090",
+		"DB_PATH":           "env.db",
+		"LOGSTORE_MAX_AGE":  "15m",
+		"IGNORED_TABLES":    "",
+		"DEBUG":             "1",
+		"INGEST_SELF":       "1",
+		"CONTAINER_INCLUDE": "api-*",
+		"CONTAINER_EXCLUDE": "postgres",
+	}))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.ListenAddr != ":7000" {
+		t.Errorf("Expected the flag to win for listen_addr, got %q", cfg.ListenAddr)
+	}
+	if cfg.DBPath != "env.db" {
+		t.Errorf("Expected the environment to win over the file for db_path, got %q", cfg.DBPath)
+	}
+	if cfg.LogStore.MaxAge != 15*time.Minute || cfg.LogStore.MaxMessages != DefaultMaxMessages {
+		t.Errorf("Unexpected logstore settings: %+v", cfg.LogStore)
+	}
+	if cfg.Parser.IgnoredTables == nil || len(cfg.Parser.IgnoredTables) != 0 {
+		t.Errorf("Expected an empty IGNORED_TABLES to ignore nothing, got %v", cfg.Parser.IgnoredTables)
+	}
+	if !cfg.Debug {
+		t.Error("Expected DEBUG to enable debug logging")
+	}
+	if !slices.Equal(cfg.Docker.Include, []string{"api-*"}) || !slices.Equal(cfg.Docker.Exclude, []string{"redis", "*worker*"}) {
+		t.Errorf("Unexpected container patterns: include %v, exclude %v", cfg.Docker.Include, cfg.Docker.Exclude)
+	}
+	if !cfg.Docker.IngestSelf {
+		t.Error("Expected INGEST_SELF to stream the viewer's own container")
+	}
+	if !cfg.Docker.SynthesizeTimestamps {
+		t.Error("Expected -synthesize-timestamps to enable Docker timestamps")
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	_, err := load(nil, env(map[string]string{
+		"LOGSTORE_MAX_MESSAGES": "lots",
+		"MAX_BODY_BYTES":        "-1",
+	}))
+	if err == nil || !strings.Contains(err.Error(), "LOGSTORE_MAX_MESSAGES") {
+		t.Errorf("Expected an error for an unparseable setting, got %v", err)
+	}
+
+	path := writeConfig(t, "viewer.yaml", `
+auth:
+  username: admin
+retention:
+  type: size
+  min_keep: -5
+parser:
+  multi_statement_duration: last
+  auto_explain_min_total_ms: -10
+  max_line_length: -1
+  patterns:
+    - name: unnamed-groups
+      regex: '(\d+)'
+log_format: xml
+log_level: verbose
+max_concurrent_executions: -2
+log_batch_size: -1
+snapshot_dir: ""
+logstore:
+  sweep_interval: -1s
+theme:
+  preset: solarized
+docker:
+  include: ["api-["]
+`)
+	_, err = load([]string{"-config", path}, env(nil))
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+	for _, key := range []string{"auth.password", "retention.type", "retention.min_keep", "parser.multi_statement_duration", "parser.auto_explain_min_total_ms", "parser.max_line_length", "log_format", "log_level", "max_concurrent_executions", "log_batch_size", "snapshot_dir", "logstore.sweep_interval", "api-[", "unnamed-groups", "solarized"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected %s to be reported, got %v", key, err)
+		}
+	}
+}
+
+func TestSlogLevel(t *testing.T) {
+	tests := []struct {
+		env   map[string]string
+		level slog.Level
+	}{
+		{nil, slog.LevelInfo},
+		{map[string]string{"LOG_LEVEL": "warn"}, slog.LevelWarn},
+		{map[string]string{"LOG_LEVEL": "ERROR"}, slog.LevelError},
+		{map[string]string{"LOG_LEVEL": "error", "DEBUG": "1"}, slog.LevelDebug},
+	}
+	for _, tt := range tests {
+		cfg, err := load(nil, env(tt.env))
+		if err != nil {
+			t.Fatalf("Failed to load config for %v: %v", tt.env, err)
+		}
+		if got := cfg.SlogLevel(); got != tt.level {
+			t.Errorf("Expected level %v for %v, got %v", tt.level, tt.env, got)
+		}
+	}
+
+	cfg, err := load(nil, env(map[string]string{"LOG_FORMAT": "json"}))
+	if err != nil || cfg.LogFormat != LogFormatJSON {
+		t.Errorf("Expected LOG_FORMAT to select JSON, got %+v %v", cfg, err)
+	}
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/store"
+
+	"github.com/gorilla/mux"
+)
+
+// LoadContainerAliases loads container aliases from the database and re-applies them
+// to the current container list
+func (c *Controller) LoadContainerAliases() error {
+	if c.store == nil {
+		return nil
+	}
+
+	stored, err := c.store.ListContainerAliases()
+	if err != nil {
+		return err
+	}
+
+	aliases := make([]logs.ContainerAlias, 0, len(stored))
+	for _, a := range stored {
+		alias, err := logs.NewContainerAlias(a.Pattern, a.Alias)
+		if err != nil {
+			slog.Warn("skipping invalid container alias", "pattern", a.Pattern, "error", err)
+			continue
+		}
+		aliases = append(aliases, alias)
+	}
+
+	c.containerMutex.Lock()
+	c.aliases = aliases
+	c.containerMutex.Unlock()
+
+	c.SetContainers(c.GetContainers())
+	return nil
+}
+
+// applyAliases returns containers with the configured aliases applied to their names
+func (c *Controller) applyAliases(containers []logs.Container) []logs.Container {
+	c.containerMutex.RLock()
+	aliases := c.aliases
+	c.containerMutex.RUnlock()
+
+	return logs.ApplyAliases(containers, aliases)
+}
+
+// HandleListContainerAliases lists all container aliases
+func (c *Controller) HandleListContainerAliases(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	aliases, err := c.store.ListContainerAliases()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(aliases)
+}
+
+// HandleSaveContainerAlias creates or updates the alias for a container name pattern
+func (c *Controller) HandleSaveContainerAlias(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	var alias store.ContainerAlias
+	if err := json.NewDecoder(r.Body).Decode(&alias); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+	if _, err := logs.NewContainerAlias(alias.Pattern, alias.Alias); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	if err := c.store.SaveContainerAlias(&alias); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	c.reloadContainerAliases()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alias)
+}
+
+// HandleDeleteContainerAlias deletes a container alias
+func (c *Controller) HandleDeleteContainerAlias(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid alias ID")
+		return
+	}
+
+	if err := c.store.DeleteContainerAlias(uint(id)); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	c.reloadContainerAliases()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reloadContainerAliases reloads aliases after a change and notifies clients
+func (c *Controller) reloadContainerAliases() {
+	if err := c.LoadContainerAliases(); err != nil {
+		slog.Error("failed to reload container aliases", "error", err)
+		return
+	}
+	c.BroadcastContainerUpdate(c.GetContainers())
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/store"
+)
+
+func TestContainersUpdateGroupsLogCountsByAlias(t *testing.T) {
+	c := newTestController(t)
+
+	if err := c.store.SaveContainerAlias(&store.ContainerAlias{Pattern: `^web-`, Alias: "web"}); err != nil {
+		t.Fatalf("Failed to save alias: %v", err)
+	}
+	if err := c.LoadContainerAliases(); err != nil {
+		t.Fatalf("Failed to load aliases: %v", err)
+	}
+
+	containers := []logs.Container{
+		{ID: "c1", Name: "web-7d9f8-abcde"},
+		{ID: "c2", Name: "web-1a2b3-fghij"},
+		{ID: "c3", Name: "postgres"},
+	}
+	c.SetContainers(containers)
+
+	for i, id := range []string{"c1", "c1", "c2", "c3"} {
+		c.logStore.Add(&logs.ContainerMessage{
+			ContainerID: id,
+			Timestamp:   time.Now().Add(time.Duration(i) * time.Millisecond),
+			Entry:       &logs.LogEntry{Message: "hello"},
+		})
+	}
+
+	update := c.buildContainersUpdate(containers)
+
+	if update.LogCounts["web"] != 3 {
+		t.Errorf("Expected 3 logs for alias 'web', got %d", update.LogCounts["web"])
+	}
+	if update.LogCounts["postgres"] != 1 {
+		t.Errorf("Expected 1 log for 'postgres', got %d", update.LogCounts["postgres"])
+	}
+	if _, ok := update.LogCounts["web-7d9f8-abcde"]; ok {
+		t.Error("Expected raw container name not to appear in log counts")
+	}
+	if update.Colors["web"] != logs.ContainerColor("web") || len(update.Colors) != 2 {
+		t.Errorf("Expected one color per container name, keyed by alias, got %v", update.Colors)
+	}
+	if update.Containers[0].RawName != "web-7d9f8-abcde" {
+		t.Errorf("Expected raw name to be preserved, got %q", update.Containers[0].RawName)
+	}
+
+	// Filtering by alias selects every container that shares it
+	opts := c.clientFilterToLogStoreFilter(ClientFilter{SelectedContainers: []string{"web"}})
+	if len(opts.ContainerIDs) != 2 {
+		t.Errorf("Expected 2 container IDs for alias 'web', got %v", opts.ContainerIDs)
+	}
+}
+
+func TestSaveContainerAliasRejectsInvalidPattern(t *testing.T) {
+	c := newTestController(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/container-aliases", bytes.NewBufferString(`{"pattern":"web-(","alias":"web"}`))
+	rec := httptest.NewRecorder()
+	c.HandleSaveContainerAlias(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+}
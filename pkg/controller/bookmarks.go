@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"docker-log-parser/pkg/store"
+
+	"github.com/gorilla/mux"
+)
+
+// HandleListBookmarks lists bookmarked log lines, optionally filtered by trace ID
+func (c *Controller) HandleListBookmarks(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	type QueryParams struct {
+		TraceID string `schema:"traceId"`
+	}
+
+	var params QueryParams
+	if err := c.decoder.Decode(&params, r.URL.Query()); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	bookmarks, err := c.store.ListBookmarks(params.TraceID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bookmarks)
+}
+
+// HandleCreateBookmark bookmarks a log line
+func (c *Controller) HandleCreateBookmark(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	var input struct {
+		ContainerName string    `json:"containerName"`
+		Timestamp     time.Time `json:"timestamp"`
+		Raw           string    `json:"raw"`
+		Message       string    `json:"message"`
+		TraceID       string    `json:"traceId"`
+		Note          string    `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+	if input.ContainerName == "" || input.Timestamp.IsZero() {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "containerName and timestamp are required")
+		return
+	}
+
+	// Hash the raw line when available so the bookmark survives message reformatting
+	hashSource := input.Raw
+	if hashSource == "" {
+		hashSource = input.Message
+	}
+
+	bookmark := store.Bookmark{
+		ContainerName: input.ContainerName,
+		Timestamp:     input.Timestamp,
+		MessageHash:   store.ComputeMessageHash(hashSource),
+		TraceID:       input.TraceID,
+		Message:       input.Message,
+		Note:          input.Note,
+	}
+
+	if _, err := c.store.CreateBookmark(&bookmark); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bookmark)
+}
+
+// HandleDeleteBookmark deletes a bookmark
+func (c *Controller) HandleDeleteBookmark(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid bookmark ID")
+		return
+	}
+
+	if err := c.store.DeleteBookmark(id); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
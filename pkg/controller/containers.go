@@ -16,8 +16,9 @@ import (
 
 // RetentionInfo represents container retention settings
 type RetentionInfo struct {
-	Type  string `json:"type"`  // "count" or "time"
-	Value int    `json:"value"` // number of logs or seconds
+	Type    string `json:"type"`              // "count" or "time"
+	Value   int    `json:"value"`             // number of logs or seconds
+	MinKeep int    `json:"minKeep,omitempty"` // newest logs a time policy keeps; 0 for the default
 }
 
 // ContainersUpdateMessage represents the containers update response
@@ -26,48 +27,67 @@ type ContainersUpdateMessage struct {
 	PortToServerMap map[int]string           `json:"portToServerMap"`
 	LogCounts       map[string]int           `json:"logCounts"`
 	Retentions      map[string]RetentionInfo `json:"retentions"`
+	Colors          map[string]string        `json:"colors"` // container name -> #rrggbb color to tint its lines
 }
 
 // HandleContainers lists all running containers with associated metadata
 func (c *Controller) HandleContainers(w http.ResponseWriter, r *http.Request) {
 	containers, err := c.docker.ListRunningContainers(c.ctx)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 
-	portToServerMap := c.buildPortToServerMap(containers)
+	response := c.buildContainersUpdate(containers)
 
-	// Get log counts for each container
-	logCounts := make(map[string]int)
-	for _, container := range containers {
-		count := c.logStore.CountByContainer(container.ID)
-		logCounts[container.Name] = count
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// HandleHistoricalContainers lists every container seen in stored execution logs,
+// including ones that are no longer running, with names resolved where known
+func (c *Controller) HandleHistoricalContainers(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
 	}
 
-	// Get retention settings for all containers
-	retentions := make(map[string]RetentionInfo)
-	if c.store != nil {
-		retentionList, err := c.store.ListContainerRetentions()
-		if err == nil {
-			for _, r := range retentionList {
-				retentions[r.ContainerName] = RetentionInfo{
-					Type:  r.RetentionType,
-					Value: r.RetentionValue,
-				}
-			}
-		}
+	params := c.decodePageParams(r, defaultListLimit)
+
+	containers, err := c.store.WithContext(r.Context()).ListHistoricalContainers()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
 	}
 
-	response := ContainersUpdateMessage{
-		Containers:      containers,
-		PortToServerMap: portToServerMap,
-		LogCounts:       logCounts,
-		Retentions:      retentions,
+	c.containerMutex.RLock()
+	running := make(map[string]bool, len(c.containers))
+	for _, container := range c.containers {
+		running[container.ID] = true
+	}
+	for i := range containers {
+		containers[i].Name = c.containerIDNames[containers[i].ContainerID]
+		containers[i].Running = running[containers[i].ContainerID]
 	}
+	c.containerMutex.RUnlock()
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	writeList(w, containers, params)
+}
+
+// containerIDsFor resolves a container ID or name to the IDs it may appear under in
+// stored logs. Names only resolve for containers seen since the viewer started, so the
+// value itself is always included as an ID.
+func (c *Controller) containerIDsFor(value string) []string {
+	ids := []string{value}
+
+	c.containerMutex.RLock()
+	defer c.containerMutex.RUnlock()
+	for id, name := range c.containerIDNames {
+		if name == value && id != value {
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
 
 // HandleDebug returns debug information about the system state
@@ -110,6 +130,7 @@ func (c *Controller) HandleDebug(w http.ResponseWriter, r *http.Request) {
 
 	debugInfo := map[string]any{
 		"totalLogsInMemory": totalLogs,
+		"logsByLevel":       c.logStore.LevelHistogram(),
 		"containerCount":    len(containers),
 		"containers":        containers,
 		"connectedClients":  clientCount,
@@ -125,33 +146,38 @@ func (c *Controller) HandleDebug(w http.ResponseWriter, r *http.Request) {
 // HandleListRetentions lists all container retention settings
 func (c *Controller) HandleListRetentions(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
+	params := c.decodePageParams(r, defaultListLimit)
+
 	retentions, err := c.store.ListContainerRetentions()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(retentions)
+	writeList(w, retentions, params)
 }
 
 // HandleCreateRetention creates or updates container retention settings
 func (c *Controller) HandleCreateRetention(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	var retention store.ContainerRetention
 	if err := json.NewDecoder(r.Body).Decode(&retention); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+	if retention.MinKeep < 0 {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "minKeep must not be negative")
 		return
 	}
 	if err := c.store.SaveContainerRetention(&retention); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 
@@ -164,8 +190,9 @@ func (c *Controller) HandleCreateRetention(w http.ResponseWriter, r *http.Reques
 			containerID := container.ID
 			slog.Info("setting container retention", "containerID", containerID, "retention", retention)
 			c.logStore.SetContainerRetention(containerID, logstore.ContainerRetentionPolicy{
-				Type:  retention.RetentionType,
-				Value: retention.RetentionValue,
+				Type:    retention.RetentionType,
+				Value:   retention.RetentionValue,
+				MinKeep: retention.MinKeep,
 			})
 		}
 	}
@@ -176,24 +203,24 @@ func (c *Controller) HandleCreateRetention(w http.ResponseWriter, r *http.Reques
 // HandleGetRetention gets retention settings for a specific container
 func (c *Controller) HandleGetRetention(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	vars := mux.Vars(r)
 	containerName := vars["containerName"]
 	if containerName == "" {
-		http.Error(w, "Container name required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Container name required")
 		return
 	}
 
 	retention, err := c.store.GetContainerRetention(containerName)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	if retention == nil {
-		http.Error(w, "Retention settings not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Retention settings not found")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -203,19 +230,19 @@ func (c *Controller) HandleGetRetention(w http.ResponseWriter, r *http.Request)
 // HandleDeleteRetention deletes retention settings for a specific container
 func (c *Controller) HandleDeleteRetention(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	vars := mux.Vars(r)
 	containerName := vars["containerName"]
 	if containerName == "" {
-		http.Error(w, "Container name required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Container name required")
 		return
 	}
 
 	if err := c.store.DeleteContainerRetention(containerName); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -254,14 +281,17 @@ func (c *Controller) buildPortToServerMap(containers []logs.Container) map[int]s
 	return portToServerMap
 }
 
-// BroadcastContainerUpdate sends container updates to all connected WebSocket clients
-func (c *Controller) BroadcastContainerUpdate(containers []logs.Container) {
+// buildContainersUpdate applies aliases to containers and collects their ports, log counts
+// and retention settings. Log counts are summed across containers sharing an alias.
+func (c *Controller) buildContainersUpdate(containers []logs.Container) ContainersUpdateMessage {
+	containers = c.applyAliases(containers)
 	portToServerMap := c.buildPortToServerMap(containers)
 
 	logCounts := make(map[string]int)
+	colors := make(map[string]string)
 	for _, container := range containers {
-		count := c.logStore.CountByContainer(container.ID)
-		logCounts[container.Name] = count
+		logCounts[container.Name] += c.logStore.CountByContainer(container.ID)
+		colors[container.Name] = logs.ContainerColor(container.Name)
 	}
 
 	retentions := make(map[string]RetentionInfo)
@@ -270,19 +300,26 @@ func (c *Controller) BroadcastContainerUpdate(containers []logs.Container) {
 		if err == nil {
 			for _, r := range retentionList {
 				retentions[r.ContainerName] = RetentionInfo{
-					Type:  r.RetentionType,
-					Value: r.RetentionValue,
+					Type:    r.RetentionType,
+					Value:   r.RetentionValue,
+					MinKeep: r.MinKeep,
 				}
 			}
 		}
 	}
 
-	update := ContainersUpdateMessage{
+	return ContainersUpdateMessage{
 		Containers:      containers,
 		PortToServerMap: portToServerMap,
 		LogCounts:       logCounts,
 		Retentions:      retentions,
+		Colors:          colors,
 	}
+}
+
+// BroadcastContainerUpdate sends container updates to all connected WebSocket clients
+func (c *Controller) BroadcastContainerUpdate(containers []logs.Container) {
+	update := c.buildContainersUpdate(containers)
 
 	wsMsg := WSMessage{
 		Type: "containers",
@@ -294,7 +331,7 @@ func (c *Controller) BroadcastContainerUpdate(containers []logs.Container) {
 	defer c.clientsMutex.Unlock()
 
 	for client := range c.clients {
-		err := client.conn.WriteJSON(wsMsg)
+		err := client.writeJSON(wsMsg)
 		if err != nil {
 			client.conn.Close()
 			delete(c.clients, client)
@@ -323,8 +360,9 @@ func (c *Controller) LoadContainerRetentions() error {
 				containerID := container.ID
 				slog.Info("setting container retention", "containerID", containerID, "retention", retention)
 				c.logStore.SetContainerRetention(containerID, logstore.ContainerRetentionPolicy{
-					Type:  retention.RetentionType,
-					Value: retention.RetentionValue,
+					Type:    retention.RetentionType,
+					Value:   retention.RetentionValue,
+					MinKeep: retention.MinKeep,
 				})
 			}
 		}
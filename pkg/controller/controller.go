@@ -2,7 +2,12 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,9 +25,17 @@ type Controller struct {
 	logStore            *logstore.LogStore
 	containers          []logs.Container
 	containerIDNames    map[string]string
+	aliases             []logs.ContainerAlias
+	suppressor          *logs.Suppressor
 	containerMutex      sync.RWMutex
 	clients             map[*Client]bool
 	clientsMutex        sync.RWMutex
+	sseClients          map[*SSEClient]bool
+	sseClientsMutex     sync.RWMutex
+	savedFilters        map[string]savedFilter
+	savedFiltersMutex   sync.Mutex
+	subscriptions       map[*logSubscription]bool
+	subscriptionsMutex  sync.RWMutex
 	logChan             chan logs.ContainerMessage
 	batchChan           chan struct{}
 	logBatch            []logs.ContainerMessage
@@ -36,22 +49,84 @@ type Controller struct {
 	shutdownOnce        sync.Once
 	activeStreams       map[string]bool
 	activeStreamsMutex  sync.RWMutex
+	executions          map[int64]*runningExecution
+	executionsMutex     sync.Mutex
+	executionSlots      chan struct{} // Holds a token per execution running; see SetMaxConcurrentExecutions
 	decoder             *schema.Decoder
+	maxBodyBytes        int64
+	snapshotDir         string
 }
 
+// DefaultMaxBodyBytes is the default limit on JSON request bodies accepted by handlers
+const DefaultMaxBodyBytes int64 = 1 << 20
+
 // Client represents a WebSocket client connection
 type Client struct {
-	conn   *websocket.Conn
-	filter ClientFilter
-	mu     sync.RWMutex
+	conn    *websocket.Conn
+	token   string // Identifies the client across reconnects, if it sent one
+	filter  ClientFilter
+	mu      sync.RWMutex
+	writeMu sync.Mutex // Serializes writes, as a connection allows only one writer at a time
+}
+
+// writeJSON sends v to the client. Every write to conn must go through it, since
+// broadcasts come from several goroutines at once.
+func (client *Client) writeJSON(v any) error {
+	client.writeMu.Lock()
+	defer client.writeMu.Unlock()
+	return client.conn.WriteJSON(v)
+}
+
+// clientFilterTTL is how long a disconnected client's filter is kept for it to reconnect
+const clientFilterTTL = 30 * time.Minute
+
+// savedFilter is the last filter a token's client used
+type savedFilter struct {
+	filter  ClientFilter
+	expires time.Time
+}
+
+// SSEClient represents a server-sent events connection, a fallback for
+// networks that break WebSockets. Its filter is fixed for the connection.
+type SSEClient struct {
+	filter   ClientFilter
+	messages chan WSMessage
 }
 
 // ClientFilter holds filter criteria for a client
 type ClientFilter struct {
-	SelectedContainers []string           `json:"selectedContainers"`
-	SelectedLevels     []string           `json:"selectedLevels"`
-	SearchQuery        string             `json:"searchQuery"`
-	TraceFilters       []TraceFilterValue `json:"traceFilters"`
+	SelectedContainers []string                    `json:"selectedContainers"`
+	ExcludedContainers []string                    `json:"excludedContainers,omitempty"` // Never shown, even when selected
+	SelectedLevels     []string                    `json:"selectedLevels"`
+	SearchQuery        string                      `json:"searchQuery"`
+	TraceFilters       []TraceFilterValue          `json:"traceFilters"`
+	HasFields          []string                    `json:"hasFields,omitempty"`       // Fields that must be set, to any value
+	RangeFilters       []logstore.FieldRangeFilter `json:"rangeFilters,omitempty"`    // Numeric field comparisons, e.g. duration > 100
+	SlowThresholdMS    float64                     `json:"slowThresholdMs,omitempty"` // Only entries slower than this, when positive
+	MaxAgeSeconds      float64                     `json:"maxAgeSeconds,omitempty"`   // Only entries newer than this, when positive
+	CaseSensitive      bool                        `json:"caseSensitive,omitempty"`
+	WholeWord          bool                        `json:"wholeWord,omitempty"`
+
+	matchers []logstore.TermMatcher // Compiled SearchQuery terms, see withTermMatchers
+}
+
+// withTermMatchers returns the filter with its search terms compiled, so matching
+// a message doesn't rebuild them. Call it whenever a client's filter changes.
+func (f ClientFilter) withTermMatchers() ClientFilter {
+	f.matchers = nil
+	for _, term := range strings.Fields(f.SearchQuery) {
+		f.matchers = append(f.matchers, logstore.NewTermMatcher(term, f.CaseSensitive, f.WholeWord))
+	}
+	return f
+}
+
+// cutoff returns the oldest timestamp the filter accepts, or the zero time when
+// it has no MaxAgeSeconds
+func (f ClientFilter) cutoff(now time.Time) time.Time {
+	if f.MaxAgeSeconds <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-time.Duration(f.MaxAgeSeconds * float64(time.Second)))
 }
 
 // TraceFilterValue represents a trace filter
@@ -82,7 +157,11 @@ func NewController(
 		batchChan:        make(chan struct{}),
 		logBatch:         make([]logs.ContainerMessage, 0, 100),
 		containerIDNames: make(map[string]string),
+		suppressor:       logs.NewSuppressor(),
 		clients:          make(map[*Client]bool),
+		sseClients:       make(map[*SSEClient]bool),
+		savedFilters:     make(map[string]savedFilter),
+		subscriptions:    make(map[*logSubscription]bool),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
@@ -90,12 +169,18 @@ func NewController(
 		},
 		lastTimestamps: make(map[string]time.Time),
 		activeStreams:  make(map[string]bool),
+		executions:     make(map[int64]*runningExecution),
+		executionSlots: make(chan struct{}, DefaultMaxConcurrentExecutions),
 		decoder:        decoder,
+		maxBodyBytes:   DefaultMaxBodyBytes,
+		snapshotDir:    DefaultSnapshotDir,
 	}
 }
 
-// SetContainers updates the controller's container list
+// SetContainers updates the controller's container list, applying any configured aliases
 func (c *Controller) SetContainers(containers []logs.Container) {
+	containers = c.applyAliases(containers)
+
 	c.containerMutex.Lock()
 	defer c.containerMutex.Unlock()
 	c.containers = containers
@@ -111,6 +196,39 @@ func (c *Controller) GetContainers() []logs.Container {
 	return c.containers
 }
 
+// SetMaxBodyBytes sets the limit on JSON request bodies; non-positive values restore the default
+func (c *Controller) SetMaxBodyBytes(n int64) {
+	if n <= 0 {
+		n = DefaultMaxBodyBytes
+	}
+	c.maxBodyBytes = n
+}
+
+// decodeJSONBody decodes a size-limited JSON request body into dst, rejecting unknown fields
+// and trailing data. On failure it writes a 413 or 400 error response and returns false.
+func (c *Controller) decodeJSONBody(w http.ResponseWriter, r *http.Request, dst any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, c.maxBodyBytes)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(dst)
+	if err == nil && decoder.Decode(&struct{}{}) != io.EOF {
+		err = errors.New("request body must contain a single JSON value")
+	}
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, ErrCodeTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit))
+		return false
+	}
+	writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+	return false
+}
+
 // Shutdown safely cancels context and closes channels
 func (c *Controller) Shutdown() {
 	c.shutdownOnce.Do(func() {
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/logstore"
+	"docker-log-parser/pkg/store"
+)
+
+// newTestController creates a controller backed by an in-memory store and no Docker client
+func newTestController(t *testing.T) *Controller {
+	t.Helper()
+
+	db, err := store.NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return NewController(
+		nil,
+		logstore.NewLogStore(1000, time.Hour),
+		db,
+		ctx,
+		cancel,
+		make(chan logs.ContainerMessage, 10),
+	)
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes returned in JSON error responses
+const (
+	ErrCodeValidation    = "validation"        // The request was malformed or failed validation
+	ErrCodeTooLarge      = "payload_too_large" // The request body exceeded the size limit
+	ErrCodeNotFound      = "not_found"         // The requested resource does not exist
+	ErrCodeUnauthorized  = "unauthorized"      // The request lacked valid credentials
+	ErrCodeConflict      = "conflict"          // The resource is not in a state that allows the operation
+	ErrCodeDBUnavailable = "db_unavailable"    // The database is not configured or reachable
+	ErrCodeNotConfigured = "not_configured"    // A required integration is not configured
+	ErrCodeUpstream      = "upstream_error"    // An external service returned an error
+	ErrCodeInternal      = "internal"          // An unexpected server-side failure
+)
+
+// ErrorResponse is the JSON body of every error returned by the API
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes an API error with a machine-readable code
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeJSONError writes an ErrorResponse with the given status code
+func writeJSONError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error: ErrorDetail{Code: code, Message: message},
+	})
+}
+
+// WriteJSONError is writeJSONError for middleware outside the controller, so every
+// API error shares the ErrorResponse shape
+func WriteJSONError(w http.ResponseWriter, status int, code, message string) {
+	writeJSONError(w, status, code, message)
+}
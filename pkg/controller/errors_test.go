@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func decodeErrorResponse(t *testing.T, rec *httptest.ResponseRecorder) ErrorDetail {
+	t.Helper()
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected application/json error, got %q", ct)
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode error response %q: %v", rec.Body.String(), err)
+	}
+	return resp.Error
+}
+
+func TestStructuredErrorResponses(t *testing.T) {
+	c := newTestController(t)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		vars    map[string]string
+		status  int
+		code    string
+	}{
+		{"invalid ID", c.HandleGetServer, map[string]string{"id": "abc"}, http.StatusBadRequest, ErrCodeValidation},
+		{"missing server", c.HandleGetServer, map[string]string{"id": "999"}, http.StatusNotFound, ErrCodeNotFound},
+		{"missing execution", c.HandleGetRequestDetail, map[string]string{"id": "999"}, http.StatusNotFound, ErrCodeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), tt.vars)
+			rec := httptest.NewRecorder()
+			tt.handler(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			detail := decodeErrorResponse(t, rec)
+			if detail.Code != tt.code || detail.Message == "" {
+				t.Errorf("Expected code %s with a message, got %+v", tt.code, detail)
+			}
+		})
+	}
+}
+
+func TestStructuredErrorWithoutDatabase(t *testing.T) {
+	c := newTestController(t)
+	c.store = nil
+
+	rec := httptest.NewRecorder()
+	c.HandleListServers(rec, httptest.NewRequest(http.MethodGet, "/api/servers", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d", rec.Code)
+	}
+	if detail := decodeErrorResponse(t, rec); detail.Code != ErrCodeDBUnavailable {
+		t.Errorf("Expected %s, got %+v", ErrCodeDBUnavailable, detail)
+	}
+}
+
+func TestRequestBodyLimits(t *testing.T) {
+	c := newTestController(t)
+	c.SetMaxBodyBytes(1024)
+
+	oversized := `{"serverId":1,"requestData":"` + strings.Repeat("x", 2048) + `"}`
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+		status  int
+		code    string
+	}{
+		{"oversized execute", c.HandleCreateRequest, oversized, http.StatusRequestEntityTooLarge, ErrCodeTooLarge},
+		{"oversized server", c.HandleCreateServer, `{"name":"` + strings.Repeat("x", 2048) + `"}`, http.StatusRequestEntityTooLarge, ErrCodeTooLarge},
+		{"oversized sample", c.HandleCreateSampleQuery, oversized, http.StatusRequestEntityTooLarge, ErrCodeTooLarge},
+		{"oversized suppression pattern", c.HandleSaveSuppressionPattern, `{"pattern":"` + strings.Repeat("x", 2048) + `"}`, http.StatusRequestEntityTooLarge, ErrCodeTooLarge},
+		{"oversized SQL marker", c.HandleSaveSQLMarker, `{"containerName":"api","pattern":"` + strings.Repeat("x", 2048) + `"}`, http.StatusRequestEntityTooLarge, ErrCodeTooLarge},
+		{"unknown field", c.HandleCreateServer, `{"name":"api","url":"http://api","token":"x"}`, http.StatusBadRequest, ErrCodeValidation},
+		{"trailing data", c.HandleCreateRequest, `{"serverId":1,"requestData":"{}"} {}`, http.StatusBadRequest, ErrCodeValidation},
+		{"invalid request ID", c.HandleCreateRequest, `{"serverId":1,"requestData":"{}","requestId":"my run"}`, http.StatusBadRequest, ErrCodeValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			tt.handler(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if detail := decodeErrorResponse(t, rec); detail.Code != tt.code {
+				t.Errorf("Expected code %s, got %+v", tt.code, detail)
+			}
+		})
+	}
+
+	// Bodies within the limit are still accepted
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"api","url":"http://api"}`))
+	rec := httptest.NewRecorder()
+	c.HandleCreateServer(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,358 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"docker-log-parser/pkg/httputil"
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/logstore"
+	"docker-log-parser/pkg/store"
+
+	"github.com/gorilla/mux"
+)
+
+// DefaultMaxConcurrentExecutions is the default number of executions run at once.
+// Each one holds a request to the target backend, a log subscription and database
+// writes until its logs are collected.
+const DefaultMaxConcurrentExecutions = 4
+
+// executionCancelWait bounds how long HandleCancelExecution waits for a cancelled
+// execution to save its outcome
+const executionCancelWait = 5 * time.Second
+
+// runningExecution is an execution whose request has not yet been answered
+type runningExecution struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	saved  chan struct{} // Closed once the execution's outcome is saved
+	queued bool          // Waiting for an execution slot; guarded by executionsMutex
+}
+
+// ExecutionQueue reports how many executions hold a slot and how many wait for one
+type ExecutionQueue struct {
+	Running int `json:"running"`
+	Queued  int `json:"queued"`
+	Limit   int `json:"limit"`
+}
+
+// ExecutionUpdateMessage reports an execution that has finished, sent to clients as an
+// "execution_update" message so they need not poll for the outcome
+type ExecutionUpdateMessage struct {
+	ExecutionID          int64  `json:"executionId"`
+	Status               string `json:"status"`
+	StatusCode           int    `json:"statusCode"`
+	DurationMS           int64  `json:"durationMs"`
+	Error                string `json:"error,omitempty"`
+	QueryCount           int    `json:"queryCount"`                // SQL queries saved for the execution
+	ExpectationsMet      *bool  `json:"expectationsMet,omitempty"` // Set when the sample query has expectations
+	ExpectationViolation string `json:"expectationViolation,omitempty"`
+}
+
+// SetMaxConcurrentExecutions sets how many executions run at once; non-positive values
+// restore the default. It must be called before any request is handled.
+func (c *Controller) SetMaxConcurrentExecutions(n int) {
+	if n <= 0 {
+		n = DefaultMaxConcurrentExecutions
+	}
+	c.executionSlots = make(chan struct{}, n)
+}
+
+// trackExecution registers execID as running, taking an execution slot if one is free
+// and queueing it otherwise. Shutting down cancels it.
+func (c *Controller) trackExecution(execID int64) *runningExecution {
+	ctx, cancel := context.WithCancel(c.ctx)
+	running := &runningExecution{ctx: ctx, cancel: cancel, saved: make(chan struct{})}
+
+	select {
+	case c.executionSlots <- struct{}{}:
+	default:
+		running.queued = true
+	}
+
+	c.executionsMutex.Lock()
+	c.executions[execID] = running
+	c.executionsMutex.Unlock()
+
+	return running
+}
+
+// waitForSlot blocks a queued execution until it gets a slot, and returns false if it
+// is cancelled first. An execution that has a slot must releaseExecutionSlot when done.
+func (c *Controller) waitForSlot(running *runningExecution) bool {
+	c.executionsMutex.Lock()
+	queued := running.queued
+	c.executionsMutex.Unlock()
+	if !queued {
+		return true
+	}
+
+	select {
+	case c.executionSlots <- struct{}{}:
+		c.executionsMutex.Lock()
+		running.queued = false
+		c.executionsMutex.Unlock()
+		return true
+	case <-running.ctx.Done():
+		return false
+	}
+}
+
+// releaseExecutionSlot frees a slot taken by trackExecution or waitForSlot
+func (c *Controller) releaseExecutionSlot() {
+	<-c.executionSlots
+}
+
+// untrackExecution removes execID from the running executions once its outcome is saved
+func (c *Controller) untrackExecution(execID int64) {
+	c.executionsMutex.Lock()
+	running, ok := c.executions[execID]
+	delete(c.executions, execID)
+	c.executionsMutex.Unlock()
+
+	if ok {
+		running.cancel()
+		close(running.saved)
+	}
+}
+
+// broadcastExecutionUpdate tells clients that execution has finished with queryCount
+// SQL queries saved
+func (c *Controller) broadcastExecutionUpdate(execution *store.Request, queryCount int) {
+	data, err := json.Marshal(ExecutionUpdateMessage{
+		ExecutionID:          int64(execution.ID),
+		Status:               execution.Status,
+		StatusCode:           execution.StatusCode,
+		DurationMS:           execution.DurationMS,
+		Error:                execution.Error,
+		QueryCount:           queryCount,
+		ExpectationsMet:      execution.ExpectationsMet,
+		ExpectationViolation: execution.ExpectationViolation,
+	})
+	if err != nil {
+		slog.Error("failed to marshal execution update", "error", err)
+		return
+	}
+	c.broadcast(WSMessage{Type: "execution_update", Data: data})
+}
+
+// executionQueued reports whether execID is waiting for an execution slot
+func (c *Controller) executionQueued(execID int64) bool {
+	c.executionsMutex.Lock()
+	defer c.executionsMutex.Unlock()
+
+	running, ok := c.executions[execID]
+	return ok && running.queued
+}
+
+// executionQueue counts the executions holding and waiting for a slot
+func (c *Controller) executionQueue() ExecutionQueue {
+	c.executionsMutex.Lock()
+	defer c.executionsMutex.Unlock()
+
+	queue := ExecutionQueue{
+		Running: len(c.executionSlots),
+		Limit:   cap(c.executionSlots),
+	}
+	for _, running := range c.executions {
+		if running.queued {
+			queue.Queued++
+		}
+	}
+	return queue
+}
+
+// HandleExecutionQueue returns how many executions are running and queued
+func (c *Controller) HandleExecutionQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.executionQueue())
+}
+
+// HandleCancelExecution cancels a running execution's request. The execution is
+// saved as cancelled unless its response arrived first.
+func (c *Controller) HandleCancelExecution(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid execution ID")
+		return
+	}
+
+	c.executionsMutex.Lock()
+	running, ok := c.executions[id]
+	c.executionsMutex.Unlock()
+
+	if ok {
+		running.cancel()
+		select {
+		case <-running.saved:
+		case <-time.After(executionCancelWait):
+			slog.Warn("cancelled execution was not saved in time", "execution_id", id)
+		}
+	}
+
+	execution, err := c.store.GetRequest(id)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	if execution == nil {
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Execution not found")
+		return
+	}
+	if !ok {
+		writeJSONError(w, http.StatusConflict, ErrCodeConflict, "Execution is not running")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"status":      execution.Status,
+		"executionId": id,
+		"execution":   execution,
+	})
+}
+
+// defaultLinkLogsWindow is how far either side of an execution HandleLinkExecutionLogs
+// looks for its logs by default
+const defaultLinkLogsWindow = 30 * time.Second
+
+// LinkLogsResponse reports the logs HandleLinkExecutionLogs saved for an execution
+type LinkLogsResponse struct {
+	ExecutionID   int64 `json:"executionId"`
+	Linked        int   `json:"linked"`        // Logs newly saved for the execution
+	AlreadyLinked int   `json:"alreadyLinked"` // Matching logs the execution already had
+	QueryCount    int   `json:"queryCount"`    // SQL queries saved from the newly linked logs
+}
+
+// HandleLinkExecutionLogs saves logs from the live log store that carry an execution's
+// request or trace ID but were missed by its own collection, such as those logged after
+// it stopped collecting. Only logs within window seconds of the execution are linked.
+func (c *Controller) HandleLinkExecutionLogs(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid execution ID")
+		return
+	}
+
+	type QueryParams struct {
+		Window *int `schema:"window"`
+	}
+
+	var params QueryParams
+	if err := c.decoder.Decode(&params, r.URL.Query()); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid query parameters")
+		return
+	}
+	window := defaultLinkLogsWindow
+	if params.Window != nil {
+		if *params.Window < 0 {
+			writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "window must not be negative")
+			return
+		}
+		window = time.Duration(*params.Window) * time.Second
+	}
+
+	c.executionsMutex.Lock()
+	_, running := c.executions[id]
+	c.executionsMutex.Unlock()
+	if running {
+		writeJSONError(w, http.StatusConflict, ErrCodeConflict, "Execution is still running")
+		return
+	}
+
+	execution, err := c.store.GetRequest(id)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	if execution == nil {
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Execution not found")
+		return
+	}
+
+	var server *store.Server
+	if execution.ServerID != nil {
+		server, err = c.store.GetServer(int64(*execution.ServerID))
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+			return
+		}
+	}
+
+	existing, err := c.store.GetRequestLogs(id)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	stored := make(map[string]bool, len(existing))
+	for _, l := range existing {
+		stored[linkedLogKey(l.ContainerID, l.Timestamp, l.Message, l.RawLog)] = true
+	}
+
+	after := execution.ExecutedAt.Add(-window)
+	before := execution.ExecutedAt.Add(time.Duration(execution.DurationMS)*time.Millisecond + window)
+	matches := c.logStore.SearchByTrace(executionLogIDs(execution, server), after, before, 100000)
+
+	response := LinkLogsResponse{ExecutionID: id}
+	var linked []logs.ContainerMessage
+	for _, msg := range matches {
+		key := linkedLogKey(msg.ContainerID, msg.Timestamp, msg.Entry.Message, msg.Entry.Raw)
+		if stored[key] {
+			response.AlreadyLinked++
+			continue
+		}
+		stored[key] = true
+		linked = append(linked, *msg)
+	}
+
+	if len(linked) > 0 {
+		if err := c.store.SaveRequestLogs(id, linked); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+			return
+		}
+		progress := c.newSQLProgress(id)
+		progress.add(linked)
+		progress.finish()
+		response.QueryCount = progress.total
+	}
+	response.Linked = len(linked)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// executionLogIDs returns the log fields identifying a finished execution's logs: the
+// correlation ID it was sent with and any ID its server echoed back
+func executionLogIDs(execution *store.Request, server *store.Server) []logstore.FieldFilter {
+	correlation := httputil.Correlation{ID: execution.RequestIDHeader, Field: "request_id"}
+	traceHeader := ""
+	if server != nil {
+		if server.CorrelationFormat == httputil.CorrelationFormatTraceparent {
+			correlation.Field = "trace_id"
+		}
+		traceHeader = server.ResponseTraceHeader
+	}
+	return correlationFilters(correlation, execution.ResponseHeaders, traceHeader)
+}
+
+// linkedLogKey identifies a log line, so a line already saved for an execution is not
+// linked twice
+func linkedLogKey(containerID string, timestamp time.Time, message, raw string) string {
+	return containerID + "\x00" + strconv.FormatInt(timestamp.UnixNano(), 10) + "\x00" + message + "\x00" + raw
+}
@@ -0,0 +1,258 @@
+package controller
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"docker-log-parser/pkg/store"
+
+	"github.com/gorilla/mux"
+)
+
+// checkExpectations records whether a completed execution of a sample query stayed within
+// the sample's expectations. Executions without a sample query or expectations, and ones
+// that did not complete, are left unchecked.
+func (c *Controller) checkExpectations(execution *store.Request, queryCount int) {
+	if execution.SampleID == nil || execution.Status != store.RequestStatusCompleted {
+		return
+	}
+
+	sample, err := c.store.GetSampleQuery(int64(*execution.SampleID))
+	if err != nil {
+		slog.Error("failed to load sample query expectations", "execution_id", execution.ID, "error", err)
+		return
+	}
+	if sample == nil || !sample.SampleExpectations.Set() {
+		return
+	}
+
+	violations := sample.SampleExpectations.Violations(queryCount, execution.DurationMS)
+	met := len(violations) == 0
+	execution.ExpectationsMet = &met
+	execution.ExpectationViolation = strings.Join(violations, "; ")
+	if err := c.store.SaveExpectationResult(int64(execution.ID), met, execution.ExpectationViolation); err != nil {
+		slog.Error("failed to save expectation result", "execution_id", execution.ID, "error", err)
+	}
+}
+
+// sampleFromPath loads the sample query named by the {id} path variable, writing an error
+// response and returning nil if it cannot
+func (c *Controller) sampleFromPath(w http.ResponseWriter, r *http.Request) *store.SampleQuery {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return nil
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid request ID")
+		return nil
+	}
+
+	sample, err := c.store.GetSampleQuery(id)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return nil
+	}
+	if sample == nil {
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Request not found")
+		return nil
+	}
+	return sample
+}
+
+// validateExpectations rejects limits below zero
+func validateExpectations(e store.SampleExpectations) error {
+	if e.MaxQueries != nil && *e.MaxQueries < 0 {
+		return errors.New("maxQueries must not be negative")
+	}
+	if e.MaxDurationMS != nil && *e.MaxDurationMS < 0 {
+		return errors.New("maxDurationMs must not be negative")
+	}
+	return nil
+}
+
+// HandleGetSampleExpectations returns a sample query's expectations; limits that are not
+// set are omitted
+func (c *Controller) HandleGetSampleExpectations(w http.ResponseWriter, r *http.Request) {
+	sample := c.sampleFromPath(w, r)
+	if sample == nil {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sample.SampleExpectations)
+}
+
+// HandleSetSampleExpectations replaces a sample query's expectations. A limit left out of
+// the body is cleared.
+func (c *Controller) HandleSetSampleExpectations(w http.ResponseWriter, r *http.Request) {
+	sample := c.sampleFromPath(w, r)
+	if sample == nil {
+		return
+	}
+
+	var expectations store.SampleExpectations
+	if !c.decodeJSONBody(w, r, &expectations) {
+		return
+	}
+	if err := validateExpectations(expectations); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	if err := c.store.SetSampleExpectations(int64(sample.ID), expectations); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(expectations)
+}
+
+// HandleDeleteSampleExpectations clears a sample query's expectations
+func (c *Controller) HandleDeleteSampleExpectations(w http.ResponseWriter, r *http.Request) {
+	sample := c.sampleFromPath(w, r)
+	if sample == nil {
+		return
+	}
+
+	if err := c.store.SetSampleExpectations(int64(sample.ID), store.SampleExpectations{}); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleImportSampleExpectations sets the expectations of several sample queries from a
+// CSV body with a header row naming the columns sample, max_queries and max_duration_ms.
+// sample is a sample query's ID or name, and an empty limit clears it. Nothing is changed
+// unless every row is valid.
+func (c *Controller) HandleImportSampleExpectations(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	samples, err := c.store.ListSampleQueries()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, c.maxBodyBytes)
+	expectations, err := parseExpectationsCSV(r.Body, samples)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, ErrCodeTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit))
+			return
+		}
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	if err := c.store.ImportSampleExpectations(expectations); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"imported": len(expectations)})
+}
+
+// parseExpectationsCSV reads expectations keyed by sample query ID, resolving names
+// against samples
+func parseExpectationsCSV(body io.Reader, samples []store.SampleQuery) (map[int64]store.SampleExpectations, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	sampleColumn, ok := columns["sample"]
+	if !ok {
+		return nil, errors.New("CSV header must have a sample column")
+	}
+
+	expectations := make(map[int64]store.SampleExpectations)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		sampleID, err := resolveSample(record[sampleColumn], samples)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		var e store.SampleExpectations
+		if i, ok := columns["max_queries"]; ok && strings.TrimSpace(record[i]) != "" {
+			n, err := strconv.Atoi(strings.TrimSpace(record[i]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: max_queries must be a whole number", line)
+			}
+			e.MaxQueries = &n
+		}
+		if i, ok := columns["max_duration_ms"]; ok && strings.TrimSpace(record[i]) != "" {
+			n, err := strconv.ParseInt(strings.TrimSpace(record[i]), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: max_duration_ms must be a whole number", line)
+			}
+			e.MaxDurationMS = &n
+		}
+		if err := validateExpectations(e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		expectations[sampleID] = e
+	}
+	return expectations, nil
+}
+
+// resolveSample finds the sample query a CSV row refers to by ID or by name
+func resolveSample(ref string, samples []store.SampleQuery) (int64, error) {
+	ref = strings.TrimSpace(ref)
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		for _, sample := range samples {
+			if int64(sample.ID) == id {
+				return id, nil
+			}
+		}
+		return 0, fmt.Errorf("no sample query with ID %d", id)
+	}
+
+	var found []store.SampleQuery
+	for _, sample := range samples {
+		if sample.Name == ref || sample.Name == "" && sample.DisplayName == ref {
+			found = append(found, sample)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return 0, fmt.Errorf("no sample query named %q", ref)
+	case 1:
+		return int64(found[0].ID), nil
+	default:
+		return 0, fmt.Errorf("%d sample queries are named %q; use an ID", len(found), ref)
+	}
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"docker-log-parser/pkg/store"
+
+	"github.com/gorilla/mux"
+)
+
+func TestSampleExpectations(t *testing.T) {
+	c := newTestController(t)
+
+	upstream := newReachableServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer upstream.Close()
+
+	serverID, err := c.store.CreateServer(&store.Server{Name: "upstream", URL: upstream.URL})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	sampleID, err := c.store.CreateSampleQuery(&store.SampleQuery{Name: "FetchUsers", RequestData: `{"query": "{ users { id } }"}`})
+	if err != nil {
+		t.Fatalf("Failed to create sample query: %v", err)
+	}
+
+	serve := func(handler http.HandlerFunc, method, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/", strings.NewReader(body))
+		handler(rec, mux.SetURLVars(req, map[string]string{"id": fmt.Sprint(sampleID)}))
+		return rec
+	}
+	execute := func() store.Request {
+		body := fmt.Sprintf(`{"serverId":%d,"sampleId":%d,"requestData":%q,"sync":true}`, serverID, sampleID, `{"query": "{ users { id } }"}`)
+		rec := httptest.NewRecorder()
+		c.HandleCreateRequest(rec, httptest.NewRequest(http.MethodPost, "/api/requests", strings.NewReader(body)))
+		var result struct {
+			Execution store.Request `json:"execution"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to decode execution: %v (%s)", err, rec.Body.String())
+		}
+		return result.Execution
+	}
+
+	if execution := execute(); execution.ExpectationsMet != nil {
+		t.Errorf("Expected no check without expectations, got %+v", execution)
+	}
+
+	if rec := serve(c.HandleSetSampleExpectations, http.MethodPut, `{"maxQueries": 5, "maxDurationMs": 1}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(c.HandleGetSampleExpectations, http.MethodGet, ""); rec.Body.String() != `{"maxQueries":5,"maxDurationMs":1}`+"\n" {
+		t.Errorf("Expected the saved expectations, got %s", rec.Body.String())
+	}
+
+	execution := execute()
+	if execution.ExpectationsMet == nil || *execution.ExpectationsMet || !strings.Contains(execution.ExpectationViolation, "expected at most 1ms") {
+		t.Errorf("Expected the duration budget to fail, got %+v", execution)
+	}
+	detail, err := c.store.GetRequestDetail(int64(execution.ID))
+	if err != nil || detail.Execution.ExpectationsMet == nil || *detail.Execution.ExpectationsMet {
+		t.Errorf("Expected the failure to be saved, got %+v (%v)", detail, err)
+	}
+
+	if rec := serve(c.HandleSetSampleExpectations, http.MethodPut, `{"maxQueries": 0}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if execution := execute(); execution.ExpectationsMet == nil || !*execution.ExpectationsMet {
+		t.Errorf("Expected no SQL queries to meet a budget of 0, got %+v", execution)
+	}
+
+	if rec := serve(c.HandleSetSampleExpectations, http.MethodPut, `{"maxQueries": -1}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a negative limit, got %d", rec.Code)
+	}
+	if rec := serve(c.HandleDeleteSampleExpectations, http.MethodDelete, ""); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", rec.Code)
+	}
+	if execution := execute(); execution.ExpectationsMet != nil {
+		t.Errorf("Expected no check once expectations are cleared, got %+v", execution)
+	}
+}
+
+func TestImportSampleExpectations(t *testing.T) {
+	c := newTestController(t)
+
+	var ids []int64
+	for _, name := range []string{"FetchUsers", "FetchPosts"} {
+		id, err := c.store.CreateSampleQuery(&store.SampleQuery{Name: name, RequestData: `{"query": "{ id }"}`})
+		if err != nil {
+			t.Fatalf("Failed to create sample query: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	importCSV := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c.HandleImportSampleExpectations(rec, httptest.NewRequest(http.MethodPost, "/api/samples/expectations/import", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := importCSV("sample,max_queries,max_duration_ms\nFetchUsers,10,\n" + fmt.Sprint(ids[1]) + ",,250\n")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"imported":2`) {
+		t.Fatalf("Expected 2 rows imported, got %d: %s", rec.Code, rec.Body.String())
+	}
+	users, _ := c.store.GetSampleQuery(ids[0])
+	posts, _ := c.store.GetSampleQuery(ids[1])
+	if users.MaxQueries == nil || *users.MaxQueries != 10 || users.MaxDurationMS != nil {
+		t.Errorf("Expected FetchUsers limited to 10 queries, got %+v", users.SampleExpectations)
+	}
+	if posts.MaxDurationMS == nil || *posts.MaxDurationMS != 250 || posts.MaxQueries != nil {
+		t.Errorf("Expected FetchPosts limited to 250ms, got %+v", posts.SampleExpectations)
+	}
+
+	rec = importCSV("sample,max_queries\nFetchPosts,1\nFetchComments,3\n")
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `line 3: no sample query named \"FetchComments\"`) {
+		t.Errorf("Expected 400 naming the unknown sample, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if posts, _ := c.store.GetSampleQuery(ids[1]); posts.MaxQueries != nil {
+		t.Errorf("Expected a failed import to change nothing, got %+v", posts.SampleExpectations)
+	}
+}
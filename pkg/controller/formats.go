@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+
+	"docker-log-parser/pkg/store"
+
+	"github.com/gorilla/mux"
+)
+
+// Supported field display formats
+const (
+	FieldFormatDuration  = "duration"
+	FieldFormatBytes     = "bytes"
+	FieldFormatTimestamp = "timestamp"
+)
+
+var validFieldFormats = []string{FieldFormatDuration, FieldFormatBytes, FieldFormatTimestamp}
+
+// defaultFieldFormats are applied when no stored format exists for a field
+var defaultFieldFormats = map[string]string{
+	"duration":    FieldFormatDuration,
+	"duration_ms": FieldFormatDuration,
+}
+
+// ConfigMessage is sent to WebSocket clients on connect and whenever display config changes
+type ConfigMessage struct {
+	FieldFormats map[string]string `json:"fieldFormats"` // field name -> format
+}
+
+// fieldFormats returns the effective field formats, stored values overriding defaults
+func (c *Controller) fieldFormats() map[string]string {
+	formats := maps.Clone(defaultFieldFormats)
+
+	if c.store == nil {
+		return formats
+	}
+
+	stored, err := c.store.ListFieldFormats()
+	if err != nil {
+		slog.Error("failed to list field formats", "error", err)
+		return formats
+	}
+	for _, f := range stored {
+		formats[f.FieldName] = f.Format
+	}
+	return formats
+}
+
+// configMessage builds the WebSocket config message for clients
+func (c *Controller) configMessage() WSMessage {
+	data, _ := json.Marshal(ConfigMessage{
+		FieldFormats: c.fieldFormats(),
+	})
+	return WSMessage{
+		Type: "config",
+		Data: data,
+	}
+}
+
+// BroadcastConfig sends the current display config to all connected WebSocket and SSE clients
+func (c *Controller) BroadcastConfig() {
+	wsMsg := c.configMessage()
+	c.broadcastSSE(wsMsg)
+
+	c.clientsMutex.Lock()
+	defer c.clientsMutex.Unlock()
+
+	for client := range c.clients {
+		if err := client.writeJSON(wsMsg); err != nil {
+			client.conn.Close()
+			delete(c.clients, client)
+		}
+	}
+}
+
+// HandleListFieldFormats returns the effective field display formats
+func (c *Controller) HandleListFieldFormats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.fieldFormats())
+}
+
+// HandleSaveFieldFormat creates or updates the display format for a field
+func (c *Controller) HandleSaveFieldFormat(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	var format store.FieldFormat
+	if err := json.NewDecoder(r.Body).Decode(&format); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+	if format.FieldName == "" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "fieldName is required")
+		return
+	}
+	if !slices.Contains(validFieldFormats, format.Format) {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "format must be one of duration, bytes, timestamp")
+		return
+	}
+
+	if err := c.store.SaveFieldFormat(&format); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	c.BroadcastConfig()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(format)
+}
+
+// HandleDeleteFieldFormat deletes the stored display format for a field
+func (c *Controller) HandleDeleteFieldFormat(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	vars := mux.Vars(r)
+	fieldName := vars["fieldName"]
+	if fieldName == "" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Field name required")
+		return
+	}
+
+	if err := c.store.DeleteFieldFormat(fieldName); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	c.BroadcastConfig()
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"docker-log-parser/pkg/store"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWebSocketHandshakeIncludesFieldFormats(t *testing.T) {
+	c := newTestController(t)
+
+	if err := c.store.SaveFieldFormat(&store.FieldFormat{FieldName: "response_size", Format: FieldFormatBytes}); err != nil {
+		t.Fatalf("Failed to save field format: %v", err)
+	}
+	// Stored formats override defaults
+	if err := c.store.SaveFieldFormat(&store.FieldFormat{FieldName: "duration_ms", Format: FieldFormatTimestamp}); err != nil {
+		t.Fatalf("Failed to save field format: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(c.HandleWebSocket))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to dial websocket: %v", err)
+	}
+	defer conn.Close()
+
+	var msg WSMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("Failed to read handshake message: %v", err)
+	}
+	if msg.Type != "config" {
+		t.Fatalf("Expected first message type 'config', got %q", msg.Type)
+	}
+
+	var config ConfigMessage
+	if err := json.Unmarshal(msg.Data, &config); err != nil {
+		t.Fatalf("Failed to decode config message: %v", err)
+	}
+
+	expected := map[string]string{
+		"duration":      FieldFormatDuration,
+		"duration_ms":   FieldFormatTimestamp,
+		"response_size": FieldFormatBytes,
+	}
+	for field, format := range expected {
+		if config.FieldFormats[field] != format {
+			t.Errorf("Expected format %q for field %q, got %q", format, field, config.FieldFormats[field])
+		}
+	}
+}
+
+func TestSaveFieldFormatRejectsUnknownFormat(t *testing.T) {
+	c := newTestController(t)
+
+	req := httptest.NewRequest("POST", "/api/field-formats", strings.NewReader(`{"fieldName":"size","format":"furlongs"}`))
+	w := httptest.NewRecorder()
+	c.HandleSaveFieldFormat(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
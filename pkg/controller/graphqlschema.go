@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"docker-log-parser/pkg/graphql"
+	"docker-log-parser/pkg/httputil"
+	"docker-log-parser/pkg/store"
+
+	"github.com/gorilla/mux"
+)
+
+// graphQLSchemaTTL is how long a server's introspected schema is used before it is
+// fetched again
+const graphQLSchemaTTL = time.Hour
+
+// GraphQLValidation is the result of checking a request against its server's schema
+type GraphQLValidation struct {
+	Valid  bool                      `json:"valid"`
+	Errors []graphql.ValidationError `json:"errors"`
+}
+
+// ServerSchema is a server's cached introspection result
+type ServerSchema struct {
+	ServerID  uint            `json:"serverId"`
+	FetchedAt time.Time       `json:"fetchedAt"`
+	Schema    json.RawMessage `json:"schema"` // The __schema object of the introspection response
+}
+
+// graphQLSchema returns the server's schema, introspecting the server when the cached
+// copy is missing, older than graphQLSchemaTTL or refresh is set
+func (c *Controller) graphQLSchema(ctx context.Context, server *store.Server, refresh bool) (*graphql.Schema, error) {
+	if !refresh && server.GraphQLSchema != "" && server.GraphQLSchemaAt != nil && time.Since(*server.GraphQLSchemaAt) < graphQLSchemaTTL {
+		return graphql.ParseIntrospection([]byte(server.GraphQLSchema))
+	}
+
+	body, err := json.Marshal(map[string]string{"query": graphql.IntrospectionQuery})
+	if err != nil {
+		return nil, err
+	}
+	correlation := httputil.NewCorrelation(server.CorrelationHeader, server.CorrelationFormat)
+	statusCode, responseBody, _, err := httputil.MakeHTTPRequest(ctx, server.URL, body, correlation, server.BearerToken, server.DevID, server.ExperimentalMode)
+	if err != nil {
+		return nil, fmt.Errorf("introspection request failed: %w", err)
+	}
+	if statusCode != http.StatusOK {
+		// Servers that disable introspection often reject it with a 400 and a GraphQL
+		// error body, which explains the failure better than the status does
+		if _, err := graphql.ParseIntrospection([]byte(responseBody)); errors.Is(err, graphql.ErrIntrospectionRejected) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("introspection request returned status %d", statusCode)
+	}
+
+	schema, err := graphql.ParseIntrospection([]byte(responseBody))
+	if err != nil {
+		return nil, err
+	}
+
+	fetchedAt := time.Now()
+	if err := c.store.SaveServerSchema(int64(server.ID), responseBody, fetchedAt); err != nil {
+		slog.Warn("failed to cache GraphQL schema", "server_id", server.ID, "error", err)
+	}
+	server.GraphQLSchema, server.GraphQLSchemaAt = responseBody, &fetchedAt
+	return schema, nil
+}
+
+// graphQLQuery returns the query in a GraphQL request body, or false if the body is
+// not a single GraphQL request
+func graphQLQuery(requestData string) (string, bool) {
+	var body struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal([]byte(requestData), &body); err != nil || body.Query == "" {
+		return "", false
+	}
+	return body.Query, true
+}
+
+// validateGraphQLQuery checks a query against the server's schema
+func (c *Controller) validateGraphQLQuery(ctx context.Context, server *store.Server, query string, refresh bool) (GraphQLValidation, error) {
+	schema, err := c.graphQLSchema(ctx, server, refresh)
+	if err != nil {
+		return GraphQLValidation{}, err
+	}
+
+	problems := graphql.ValidateAgainstSchema(query, schema)
+	if problems == nil {
+		problems = []graphql.ValidationError{}
+	}
+	return GraphQLValidation{Valid: len(problems) == 0, Errors: problems}, nil
+}
+
+// HandleValidateGraphQL checks a GraphQL request against the server's introspected
+// schema without sending it
+func (c *Controller) HandleValidateGraphQL(w http.ResponseWriter, r *http.Request) {
+	server := c.serverFromPath(w, r)
+	if server == nil {
+		return
+	}
+
+	var input struct {
+		RequestData string `json:"requestData"`
+		Refresh     bool   `json:"refresh,omitempty"` // Introspect again even if the cached schema is fresh
+	}
+	if !c.decodeJSONBody(w, r, &input) {
+		return
+	}
+	query, ok := graphQLQuery(input.RequestData)
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "requestData must be a GraphQL request with a query")
+		return
+	}
+
+	validation, err := c.validateGraphQLQuery(r.Context(), server, query, input.Refresh)
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, ErrCodeUpstream, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(validation)
+}
+
+// serverSchema returns the server's cached schema in its API form, or nil if none has
+// been fetched
+func serverSchema(server *store.Server) (*ServerSchema, error) {
+	if server.GraphQLSchema == "" || server.GraphQLSchemaAt == nil {
+		return nil, nil
+	}
+	var response struct {
+		Data struct {
+			Schema json.RawMessage `json:"__schema"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(server.GraphQLSchema), &response); err != nil {
+		return nil, fmt.Errorf("failed to parse cached schema: %w", err)
+	}
+	return &ServerSchema{ServerID: server.ID, FetchedAt: *server.GraphQLSchemaAt, Schema: response.Data.Schema}, nil
+}
+
+// serverFromPath loads the server named by the {id} path variable, writing an error
+// response and returning nil if it cannot
+func (c *Controller) serverFromPath(w http.ResponseWriter, r *http.Request) *store.Server {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return nil
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid server ID")
+		return nil
+	}
+
+	server, err := c.store.GetServer(id)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return nil
+	}
+	if server == nil {
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Server not found")
+		return nil
+	}
+	return server
+}
+
+// HandleIntrospectServer runs the introspection query against a server, with its auth
+// headers, and caches the schema for validation and autocomplete
+func (c *Controller) HandleIntrospectServer(w http.ResponseWriter, r *http.Request) {
+	server := c.serverFromPath(w, r)
+	if server == nil {
+		return
+	}
+
+	if _, err := c.graphQLSchema(r.Context(), server, true); err != nil {
+		writeJSONError(w, http.StatusBadGateway, ErrCodeUpstream, err.Error())
+		return
+	}
+	c.writeServerSchema(w, server)
+}
+
+// HandleGetServerSchema returns a server's cached schema without contacting the server
+func (c *Controller) HandleGetServerSchema(w http.ResponseWriter, r *http.Request) {
+	server := c.serverFromPath(w, r)
+	if server == nil {
+		return
+	}
+
+	if server.GraphQLSchema == "" {
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, fmt.Sprintf("No schema cached for this server; POST /api/servers/%d/introspect to fetch it", server.ID))
+		return
+	}
+	c.writeServerSchema(w, server)
+}
+
+func (c *Controller) writeServerSchema(w http.ResponseWriter, server *store.Server) {
+	schema, err := serverSchema(server)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schema)
+}
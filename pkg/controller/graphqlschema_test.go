@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"docker-log-parser/pkg/graphql"
+	"docker-log-parser/pkg/store"
+
+	"github.com/gorilla/mux"
+)
+
+// testSchemaResponse introspects as: type Query { user(id: ID!): User } type User { id: ID!, name: String }
+const testSchemaResponse = `{"data": {"__schema": {
+  "queryType": {"name": "Query"},
+  "types": [
+    {"kind": "OBJECT", "name": "Query", "fields": [{"name": "user",
+      "args": [{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}],
+      "type": {"kind": "OBJECT", "name": "User"}}]},
+    {"kind": "OBJECT", "name": "User", "fields": [
+      {"name": "id", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+      {"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String"}}]},
+    {"kind": "SCALAR", "name": "ID"},
+    {"kind": "SCALAR", "name": "String"}
+  ]
+}}}`
+
+func TestValidateGraphQLAgainstServerSchema(t *testing.T) {
+	c := newTestController(t)
+
+	var introspections, executions atomic.Int32
+	upstream := newReachableServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "__schema") {
+			introspections.Add(1)
+			w.Write([]byte(testSchemaResponse))
+			return
+		}
+		executions.Add(1)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer upstream.Close()
+
+	serverID, err := c.store.CreateServer(&store.Server{Name: "upstream", URL: upstream.URL})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	validate := func(requestData string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"requestData": requestData})
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body)))
+		rec := httptest.NewRecorder()
+		c.HandleValidateGraphQL(rec, mux.SetURLVars(req, map[string]string{"id": fmt.Sprint(serverID)}))
+		return rec
+	}
+
+	rec := validate(`{"query": "{ user(id: 1) { id nmae } }"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var validation GraphQLValidation
+	if err := json.Unmarshal(rec.Body.Bytes(), &validation); err != nil {
+		t.Fatalf("Failed to decode validation: %v", err)
+	}
+	want := []graphql.ValidationError{{Message: `Cannot query field "nmae" on type "User"`, Position: graphql.Position{Line: 1, Column: 20}}}
+	if validation.Valid || len(validation.Errors) != 1 || validation.Errors[0] != want[0] {
+		t.Errorf("Expected %+v, got %+v", want, validation)
+	}
+
+	if rec := validate(`{"query": "{ user(id: 1) { name } }"}`); !strings.Contains(rec.Body.String(), `"valid":true`) {
+		t.Errorf("Expected a valid query, got %s", rec.Body.String())
+	}
+	if got := introspections.Load(); got != 1 {
+		t.Errorf("Expected the schema to be introspected once and then cached, got %d", got)
+	}
+	if rec := validate(`{"jsonrpc": "2.0"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a body without a query, got %d", rec.Code)
+	}
+
+	// Executing with validate rejects the query without sending it
+	body := fmt.Sprintf(`{"serverId":%d,"requestData":%q,"sync":true,"validate":true}`, serverID, `{"query": "{ user { id } }"}`)
+	rec = httptest.NewRecorder()
+	c.HandleCreateRequest(rec, httptest.NewRequest(http.MethodPost, "/api/requests", strings.NewReader(body)))
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), `argument \"id\" of type \"ID!\" is required`) {
+		t.Errorf("Expected 422 for a missing argument, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := executions.Load(); got != 0 {
+		t.Errorf("Expected the invalid query not to be sent, got %d requests", got)
+	}
+}
+
+func TestIntrospectServer(t *testing.T) {
+	c := newTestController(t)
+
+	upstream := newReachableServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":[{"message":"GraphQL introspection is not allowed"}]}`))
+			return
+		}
+		w.Write([]byte(testSchemaResponse))
+	}))
+	defer upstream.Close()
+
+	serve := func(handler http.HandlerFunc, method string, serverID int64) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/", nil)
+		handler(rec, mux.SetURLVars(req, map[string]string{"id": fmt.Sprint(serverID)}))
+		return rec
+	}
+
+	serverID, err := c.store.CreateServer(&store.Server{Name: "upstream", URL: upstream.URL, BearerToken: "secret"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	if rec := serve(c.HandleGetServerSchema, http.MethodGet, serverID); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 before introspecting, got %d", rec.Code)
+	}
+
+	rec := serve(c.HandleIntrospectServer, http.MethodPost, serverID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = serve(c.HandleGetServerSchema, http.MethodGet, serverID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the cached schema, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var cached ServerSchema
+	if err := json.Unmarshal(rec.Body.Bytes(), &cached); err != nil {
+		t.Fatalf("Failed to decode schema: %v", err)
+	}
+	if _, err := graphql.ParseIntrospection([]byte(`{"__schema":` + string(cached.Schema) + `}`)); err != nil || cached.FetchedAt.IsZero() {
+		t.Errorf("Expected the __schema object and fetch time, got %s (%v)", rec.Body.String(), err)
+	}
+
+	// Without the token the server rejects introspection, as when it is disabled
+	anonID, err := c.store.CreateServer(&store.Server{Name: "anonymous", URL: upstream.URL})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	rec = serve(c.HandleIntrospectServer, http.MethodPost, anonID)
+	if rec.Code != http.StatusBadGateway || !strings.Contains(rec.Body.String(), "introspection may be disabled: GraphQL introspection is not allowed") {
+		t.Errorf("Expected 502 explaining the rejection, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
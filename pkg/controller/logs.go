@@ -10,12 +10,17 @@ import (
 
 	"docker-log-parser/pkg/logs"
 	"docker-log-parser/pkg/logstore"
+
+	"github.com/gorilla/mux"
 )
 
 // WSMessage represents a WebSocket message
 type WSMessage struct {
 	Type string          `json:"type"`
 	Data json.RawMessage `json:"data,omitempty"`
+	// ServerTime is when logs and logs_initial messages were sent, so clients can show
+	// how long ago a log was written by the server's clock rather than their own
+	ServerTime time.Time `json:"serverTime,omitzero"`
 }
 
 // LogWSMessage represents a log message sent over WebSocket
@@ -25,6 +30,60 @@ type LogWSMessage struct {
 	Entry       *logs.LogEntry `json:"entry"`
 }
 
+// logSubscription receives live log batches matching a predicate, for in-process
+// consumers that are not WebSocket clients
+type logSubscription struct {
+	match   func(logs.ContainerMessage) bool
+	batches chan []logs.ContainerMessage
+}
+
+// subscribeLogs registers a subscription for live logs matching match.
+// Callers must unsubscribeLogs when done.
+func (c *Controller) subscribeLogs(match func(logs.ContainerMessage) bool) *logSubscription {
+	sub := &logSubscription{
+		match:   match,
+		batches: make(chan []logs.ContainerMessage, 64),
+	}
+
+	c.subscriptionsMutex.Lock()
+	c.subscriptions[sub] = true
+	c.subscriptionsMutex.Unlock()
+
+	return sub
+}
+
+// unsubscribeLogs removes a subscription registered with subscribeLogs
+func (c *Controller) unsubscribeLogs(sub *logSubscription) {
+	c.subscriptionsMutex.Lock()
+	delete(c.subscriptions, sub)
+	c.subscriptionsMutex.Unlock()
+}
+
+// publishToSubscriptions sends the matching part of a batch to each subscription.
+// Slow subscribers miss batches rather than blocking the broadcast.
+func (c *Controller) publishToSubscriptions(batch []logs.ContainerMessage) {
+	c.subscriptionsMutex.RLock()
+	defer c.subscriptionsMutex.RUnlock()
+
+	for sub := range c.subscriptions {
+		matched := []logs.ContainerMessage{}
+		for _, msg := range batch {
+			if sub.match(msg) {
+				matched = append(matched, msg)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		select {
+		case sub.batches <- matched:
+		default:
+			slog.Warn("log subscription is full, dropping batch", "count", len(matched))
+		}
+	}
+}
+
 // HandleLogs returns recent logs
 func (c *Controller) HandleLogs(w http.ResponseWriter, r *http.Request) {
 	// Get recent logs from the store (limit to 1000)
@@ -43,12 +102,101 @@ func (c *Controller) HandleLogs(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(logMessages)
 }
 
+// maxFieldValues caps how many distinct values are returned for a single field
+const maxFieldValues = 500
+
+// HandleLogFields returns the field names present in stored logs, most frequent first
+func (c *Controller) HandleLogFields(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.logStore.FieldNames())
+}
+
+// HandleLogFieldValues returns the distinct values seen for a field, most frequent first
+func (c *Controller) HandleLogFieldValues(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+	if name == "" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Field name required")
+		return
+	}
+
+	type QueryParams struct {
+		Limit int `schema:"limit"`
+	}
+
+	params := QueryParams{
+		Limit: 50,
+	}
+
+	if err := c.decoder.Decode(&params, r.URL.Query()); err != nil {
+		slog.Warn("failed to decode query parameters", "error", err)
+	}
+	if params.Limit <= 0 || params.Limit > maxFieldValues {
+		params.Limit = maxFieldValues
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.logStore.FieldValues(name, params.Limit))
+}
+
+// defaultHistogramValues is how many values a field histogram returns before folding
+// the rest into an other bucket
+const defaultHistogramValues = 10
+
+// HandleLogFieldHistogram returns how many stored logs matching the filter in the query
+// carry each value of a field, most frequent first
+func (c *Controller) HandleLogFieldHistogram(w http.ResponseWriter, r *http.Request) {
+	type QueryParams struct {
+		Field string `schema:"field"`
+		Top   int    `schema:"top"`
+	}
+
+	params := QueryParams{
+		Top: defaultHistogramValues,
+	}
+
+	if err := c.decoder.Decode(&params, r.URL.Query()); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+	if params.Field == "" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Field name required")
+		return
+	}
+	if params.Top <= 0 || params.Top > maxFieldValues {
+		params.Top = maxFieldValues
+	}
+
+	filter, err := c.filterFromQuery(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	histogram := c.logStore.FieldHistogram(params.Field, c.clientFilterToLogStoreFilter(filter), params.Top)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(histogram)
+}
+
 // HandleClearLogs clears all logs from the log store
 func (c *Controller) HandleClearLogs(w http.ResponseWriter, r *http.Request) {
 	c.logStore.Clear()
 	slog.Info("cleared all logs from log store")
 
-	// Broadcast a clear message to all WebSocket clients
+	c.broadcast(WSMessage{
+		Type: "logs_clear",
+		Data: json.RawMessage("[]"),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Logs cleared successfully",
+	})
+}
+
+// broadcast sends msg to all WebSocket and SSE clients
+func (c *Controller) broadcast(msg WSMessage) {
 	c.clientsMutex.RLock()
 	clients := make([]*Client, 0, len(c.clients))
 	for client := range c.clients {
@@ -56,25 +204,37 @@ func (c *Controller) HandleClearLogs(w http.ResponseWriter, r *http.Request) {
 	}
 	c.clientsMutex.RUnlock()
 
-	clearMsg := WSMessage{
-		Type: "logs_clear",
-		Data: json.RawMessage("[]"),
-	}
+	c.broadcastSSE(msg)
 
 	for _, client := range clients {
-		if err := client.conn.WriteJSON(clearMsg); err != nil {
-			slog.Error("failed to send clear message", "error", err)
+		if err := client.writeJSON(msg); err != nil {
+			slog.Error("failed to send message", "type", msg.Type, "error", err)
 			client.conn.Close()
 			c.clientsMutex.Lock()
 			delete(c.clients, client)
 			c.clientsMutex.Unlock()
 		}
 	}
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Logs cleared successfully",
-	})
+// EvictionSummaryInterval is how often logs evicted by retention are reported to clients
+const EvictionSummaryInterval = 2 * time.Second
+
+// BroadcastEvictions reports the logs evicted from each container since the previous
+// report, if there were any, as an "eviction" message. Clients showing older lines
+// can then tell they are no longer available.
+func (c *Controller) BroadcastEvictions() {
+	evictions := c.logStore.TakeEvictions()
+	if len(evictions) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(evictions)
+	if err != nil {
+		slog.Error("failed to marshal evictions", "error", err)
+		return
+	}
+	c.broadcast(WSMessage{Type: "eviction", Data: data})
 }
 
 // HandleWebSocket manages WebSocket connections for real-time log streaming
@@ -86,7 +246,8 @@ func (c *Controller) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 
 	client := &Client{
-		conn: conn,
+		conn:  conn,
+		token: r.URL.Query().Get("token"),
 		filter: ClientFilter{
 			SelectedContainers: []string{},
 			SelectedLevels:     []string{},
@@ -95,6 +256,33 @@ func (c *Controller) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
+	// A returning client gets the filter it last used
+	restored := false
+	if client.token != "" {
+		client.filter, restored = c.restoreClientFilter(client.token, client.filter)
+	}
+
+	// Send display config before the client can receive any broadcasts
+	if err := client.writeJSON(c.configMessage()); err != nil {
+		slog.Error("failed to send config", "error", err)
+		conn.Close()
+		return
+	}
+
+	// Tell a client with a token whether its filter was restored; data is null when
+	// it was not and the client needs to send one
+	if client.token != "" {
+		filterMsg := WSMessage{Type: "filter", Data: json.RawMessage("null")}
+		if restored {
+			filterMsg.Data, _ = json.Marshal(client.filter)
+		}
+		if err := client.writeJSON(filterMsg); err != nil {
+			slog.Error("failed to send restored filter", "error", err)
+			conn.Close()
+			return
+		}
+	}
+
 	c.clientsMutex.Lock()
 	c.clients[client] = true
 	c.clientsMutex.Unlock()
@@ -104,8 +292,19 @@ func (c *Controller) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		delete(c.clients, client)
 		c.clientsMutex.Unlock()
 		conn.Close()
+
+		// Keep the filter for the client's TTL from when it disconnected
+		if client.token != "" {
+			client.mu.RLock()
+			c.saveClientFilter(client.token, client.filter)
+			client.mu.RUnlock()
+		}
 	}()
 
+	if restored {
+		go c.sendInitialLogs(client)
+	}
+
 	// Read filter updates from client
 	for {
 		var msg struct {
@@ -123,28 +322,120 @@ func (c *Controller) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 				slog.Error("failed to parse filter", "error", err)
 				continue
 			}
+			filter = filter.withTermMatchers()
 			client.mu.Lock()
 			client.filter = filter
 			client.mu.Unlock()
 
+			if client.token != "" {
+				c.saveClientFilter(client.token, filter)
+			}
+
 			// Send initial filtered logs to the client
 			go c.sendInitialLogs(client)
 		}
 	}
 }
 
+// saveClientFilter remembers the filter for a client token, dropping expired entries
+func (c *Controller) saveClientFilter(token string, filter ClientFilter) {
+	c.savedFiltersMutex.Lock()
+	defer c.savedFiltersMutex.Unlock()
+
+	now := time.Now()
+	for t, saved := range c.savedFilters {
+		if now.After(saved.expires) {
+			delete(c.savedFilters, t)
+		}
+	}
+	c.savedFilters[token] = savedFilter{filter: filter, expires: now.Add(clientFilterTTL)}
+}
+
+// restoreClientFilter returns the saved filter for a token, or fallback if there is
+// none or it has expired
+func (c *Controller) restoreClientFilter(token string, fallback ClientFilter) (ClientFilter, bool) {
+	c.savedFiltersMutex.Lock()
+	defer c.savedFiltersMutex.Unlock()
+
+	saved, ok := c.savedFilters[token]
+	if !ok || time.Now().After(saved.expires) {
+		return fallback, false
+	}
+	return saved.filter, true
+}
+
 // sendInitialLogs sends filtered logs to a WebSocket client
 func (c *Controller) sendInitialLogs(client *Client) {
 	client.mu.RLock()
 	filter := client.filter
 	client.mu.RUnlock()
 
-	filterOpts := c.clientFilterToLogStoreFilter(filter)
-	recentStoreLogs := c.logStore.Filter(filterOpts, 10000)
+	wsMsg, err := c.initialLogsMessage(filter)
+	if err != nil {
+		slog.Error("failed to marshal filtered logs", "error", err)
+		return
+	}
 
-	filteredLogs := make([]LogWSMessage, 0, len(recentStoreLogs))
+	if err := client.writeJSON(wsMsg); err != nil {
+		slog.Error("failed to send initial logs", "error", err)
+	}
+}
+
+// initialLogsMessage builds the logs_initial message with stored logs matching filter
+func (c *Controller) initialLogsMessage(filter ClientFilter) (WSMessage, error) {
+	data, err := json.Marshal(c.filteredLogs(filter, DefaultSnapshotLimit))
+	if err != nil {
+		return WSMessage{}, err
+	}
+
+	return WSMessage{
+		Type:       "logs_initial",
+		Data:       data,
+		ServerTime: time.Now(),
+	}, nil
+}
+
+// matchingContainerNames returns the names of the known containers that patterns select,
+// matched with logs.MatchContainers. Caller must hold containerMutex.
+func (c *Controller) matchingContainerNames(patterns []string) map[string]bool {
+	matching := make(map[string]bool)
+	if len(patterns) == 0 {
+		return matching
+	}
+
+	names := make([]string, 0, len(c.containerIDNames))
+	for _, name := range c.containerIDNames {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	for _, pattern := range patterns {
+		for _, name := range logs.MatchContainers(pattern, names) {
+			matching[name] = true
+		}
+	}
+	return matching
+}
+
+// containerSelected reports whether patterns select the container name. Caller must hold
+// containerMutex.
+func (c *Controller) containerSelected(patterns []string, name string) bool {
+	// An exact name is always the closest match, so skip resolving the patterns
+	if slices.Contains(patterns, name) {
+		return true
+	}
+	if name == "" || !slices.ContainsFunc(patterns, func(pattern string) bool { return logs.MatchContainer(pattern, name) }) {
+		return false
+	}
+	return c.matchingContainerNames(patterns)[name]
+}
+
+// filteredLogs returns the most recent limit stored logs matching filter, oldest first
+func (c *Controller) filteredLogs(filter ClientFilter, limit int) []LogWSMessage {
+	recentStoreLogs := c.logStore.Filter(c.clientFilterToLogStoreFilter(filter), limit)
 	slices.Reverse(recentStoreLogs)
 
+	filteredLogs := make([]LogWSMessage, 0, len(recentStoreLogs))
 	for _, storeMsg := range recentStoreLogs {
 		filteredLogs = append(filteredLogs, LogWSMessage{
 			ContainerID: storeMsg.ContainerID,
@@ -152,36 +443,26 @@ func (c *Controller) sendInitialLogs(client *Client) {
 			Entry:       storeMsg.Entry,
 		})
 	}
-
-	wsMsg := WSMessage{
-		Type: "logs_initial",
-	}
-	data, err := json.Marshal(filteredLogs)
-	if err != nil {
-		slog.Error("failed to marshal filtered logs", "error", err)
-		return
-	}
-	wsMsg.Data = data
-
-	if err := client.conn.WriteJSON(wsMsg); err != nil {
-		slog.Error("failed to send initial logs", "error", err)
-	}
+	return filteredLogs
 }
 
 // clientFilterToLogStoreFilter converts a ClientFilter to logstore.FilterOptions
 func (c *Controller) clientFilterToLogStoreFilter(filter ClientFilter) logstore.FilterOptions {
 	opts := logstore.FilterOptions{}
 
-	if len(filter.SelectedContainers) > 0 {
+	if len(filter.SelectedContainers) > 0 || len(filter.ExcludedContainers) > 0 {
 		c.containerMutex.RLock()
-		containerIDs := make([]string, 0, len(filter.SelectedContainers))
+		selected := c.matchingContainerNames(filter.SelectedContainers)
+		excluded := c.matchingContainerNames(filter.ExcludedContainers)
 		for containerID, containerName := range c.containerIDNames {
-			if slices.Contains(filter.SelectedContainers, containerName) {
-				containerIDs = append(containerIDs, containerID)
+			if selected[containerName] {
+				opts.ContainerIDs = append(opts.ContainerIDs, containerID)
+			}
+			if excluded[containerName] {
+				opts.ExcludedContainerIDs = append(opts.ExcludedContainerIDs, containerID)
 			}
 		}
 		c.containerMutex.RUnlock()
-		opts.ContainerIDs = containerIDs
 	}
 
 	if len(filter.SelectedLevels) > 0 {
@@ -190,6 +471,8 @@ func (c *Controller) clientFilterToLogStoreFilter(filter ClientFilter) logstore.
 
 	if filter.SearchQuery != "" {
 		opts.SearchTerms = strings.Fields(filter.SearchQuery)
+		opts.CaseSensitive = filter.CaseSensitive
+		opts.WholeWord = filter.WholeWord
 	}
 
 	if len(filter.TraceFilters) > 0 {
@@ -203,17 +486,29 @@ func (c *Controller) clientFilterToLogStoreFilter(filter ClientFilter) logstore.
 		opts.FieldFilters = fieldFilters
 	}
 
+	opts.HasFields = filter.HasFields
+	opts.RangeFilters = filter.RangeFilters
+	opts.MinDurationMS = filter.SlowThresholdMS
+	opts.After = filter.cutoff(time.Now())
+
 	return opts
 }
 
 // matchesFilter checks if a log matches the client's filter criteria
 func (c *Controller) matchesFilter(msg logs.ContainerMessage, filter ClientFilter) bool {
-	if len(filter.SelectedContainers) > 0 {
+	if cutoff := filter.cutoff(time.Now()); !cutoff.IsZero() && msg.Timestamp.Before(cutoff) {
+		return false
+	}
+
+	if len(filter.SelectedContainers) > 0 || len(filter.ExcludedContainers) > 0 {
 		c.containerMutex.RLock()
 		containerName := c.containerIDNames[msg.ContainerID]
+		excluded := c.containerSelected(filter.ExcludedContainers, containerName)
+		selected := len(filter.SelectedContainers) == 0 || c.containerSelected(filter.SelectedContainers, containerName)
 		c.containerMutex.RUnlock()
 
-		if !slices.Contains(filter.SelectedContainers, containerName) {
+		// Exclusion wins over inclusion
+		if excluded || !selected {
 			return false
 		}
 	}
@@ -236,24 +531,25 @@ func (c *Controller) matchesFilter(msg logs.ContainerMessage, filter ClientFilte
 	}
 
 	if filter.SearchQuery != "" {
-		terms := strings.Fields(filter.SearchQuery)
+		if filter.matchers == nil {
+			filter = filter.withTermMatchers()
+		}
 
 		if msg.Entry != nil {
-			for _, term := range terms {
-				query := strings.ToLower(term)
+			for _, match := range filter.matchers {
 				found := false
 
-				if strings.Contains(strings.ToLower(msg.Entry.Message), query) {
+				if match(msg.Entry.Message) {
 					found = true
 				}
 
-				if !found && strings.Contains(strings.ToLower(msg.Entry.Raw), query) {
+				if !found && match(msg.Entry.Raw) {
 					found = true
 				}
 
 				if !found && msg.Entry.Fields != nil {
 					for key, value := range msg.Entry.Fields {
-						if strings.Contains(strings.ToLower(key), query) || strings.Contains(strings.ToLower(value), query) {
+						if match(key) || match(value) {
 							found = true
 							break
 						}
@@ -277,10 +573,29 @@ func (c *Controller) matchesFilter(msg logs.ContainerMessage, filter ClientFilte
 		}
 	}
 
+	for _, name := range filter.HasFields {
+		if msg.Entry == nil || msg.Entry.Fields[name] == "" {
+			return false
+		}
+	}
+
+	if filter.SlowThresholdMS > 0 && (msg.Entry == nil || msg.Entry.DurationMS <= filter.SlowThresholdMS) {
+		return false
+	}
+
+	for _, rf := range filter.RangeFilters {
+		if msg.Entry == nil {
+			return false
+		}
+		if val, ok := msg.Entry.Fields[rf.Name]; !ok || !rf.Matches(val) {
+			return false
+		}
+	}
+
 	return true
 }
 
-// BroadcastBatch sends a batch of logs to all connected WebSocket clients
+// BroadcastBatch sends a batch of logs to all connected WebSocket and SSE clients and log subscriptions
 func (c *Controller) BroadcastBatch(batch []logs.ContainerMessage) {
 	c.clientsMutex.RLock()
 	clients := make([]*Client, 0, len(c.clients))
@@ -293,37 +608,20 @@ func (c *Controller) BroadcastBatch(batch []logs.ContainerMessage) {
 		return
 	}
 
+	c.publishToSubscriptions(batch)
+	c.broadcastBatchSSE(batch)
+
 	for _, client := range clients {
 		client.mu.RLock()
 		filter := client.filter
 		client.mu.RUnlock()
 
-		filteredLogs := []LogWSMessage{}
-		for _, msg := range batch {
-			if c.matchesFilter(msg, filter) {
-				filteredLogs = append(filteredLogs, LogWSMessage{
-					ContainerID: msg.ContainerID,
-					Timestamp:   msg.Timestamp,
-					Entry:       msg.Entry,
-				})
-			}
-		}
-
-		if len(filteredLogs) == 0 {
+		wsMsg, ok := c.batchMessage(batch, filter)
+		if !ok {
 			continue
 		}
 
-		wsMsg := WSMessage{
-			Type: "logs",
-		}
-		data, err := json.Marshal(filteredLogs)
-		if err != nil {
-			slog.Error("failed to marshal logs batch", "error", err)
-			continue
-		}
-		wsMsg.Data = data
-
-		if err := client.conn.WriteJSON(wsMsg); err != nil {
+		if err := client.writeJSON(wsMsg); err != nil {
 			// Close connection on error and remove from clients map
 			client.conn.Close()
 
@@ -333,3 +631,34 @@ func (c *Controller) BroadcastBatch(batch []logs.ContainerMessage) {
 		}
 	}
 }
+
+// batchMessage builds the logs message for the part of a batch matching filter.
+// It returns false when nothing matches.
+func (c *Controller) batchMessage(batch []logs.ContainerMessage, filter ClientFilter) (WSMessage, bool) {
+	filteredLogs := []LogWSMessage{}
+	for _, msg := range batch {
+		if c.matchesFilter(msg, filter) {
+			filteredLogs = append(filteredLogs, LogWSMessage{
+				ContainerID: msg.ContainerID,
+				Timestamp:   msg.Timestamp,
+				Entry:       msg.Entry,
+			})
+		}
+	}
+
+	if len(filteredLogs) == 0 {
+		return WSMessage{}, false
+	}
+
+	data, err := json.Marshal(filteredLogs)
+	if err != nil {
+		slog.Error("failed to marshal logs batch", "error", err)
+		return WSMessage{}, false
+	}
+
+	return WSMessage{
+		Type:       "logs",
+		Data:       data,
+		ServerTime: time.Now(),
+	}, true
+}
@@ -0,0 +1,549 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/logstore"
+	"docker-log-parser/pkg/store"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestMatchesFilterCaseSensitive(t *testing.T) {
+	c := newTestController(t)
+
+	messages := map[string]logs.ContainerMessage{
+		"message": {Entry: &logs.LogEntry{Message: "Error connecting"}},
+		"raw":     {Entry: &logs.LogEntry{Raw: "reason=error"}},
+		"field":   {Entry: &logs.LogEntry{Fields: map[string]string{"status": "Error"}}},
+	}
+
+	tests := []struct {
+		query         string
+		caseSensitive bool
+		matches       []string
+	}{
+		{"error", false, []string{"message", "raw", "field"}},
+		{"Error", true, []string{"message", "field"}},
+		{"error", true, []string{"raw"}},
+	}
+
+	for _, tt := range tests {
+		filter := ClientFilter{SearchQuery: tt.query, CaseSensitive: tt.caseSensitive}
+		for name, msg := range messages {
+			expected := slices.Contains(tt.matches, name)
+			if got := c.matchesFilter(msg, filter); got != expected {
+				t.Errorf("query %q caseSensitive=%v on %s: expected %v, got %v", tt.query, tt.caseSensitive, name, expected, got)
+			}
+		}
+	}
+}
+
+func TestMatchesFilterWholeWord(t *testing.T) {
+	c := newTestController(t)
+
+	invalid := logs.ContainerMessage{Entry: &logs.LogEntry{Message: "invalid token"}}
+	requestID := logs.ContainerMessage{Entry: &logs.LogEntry{Message: "missing request id"}}
+
+	filter := ClientFilter{SearchQuery: "id", WholeWord: true}
+	if c.matchesFilter(invalid, filter) {
+		t.Error("Expected whole-word 'id' not to match 'invalid token'")
+	}
+	if !c.matchesFilter(requestID, filter) {
+		t.Error("Expected whole-word 'id' to match 'missing request id'")
+	}
+
+	filter.WholeWord = false
+	if !c.matchesFilter(invalid, filter) {
+		t.Error("Expected substring 'id' to match 'invalid token'")
+	}
+}
+
+func TestMatchesFilterCompiledMatchers(t *testing.T) {
+	c := newTestController(t)
+
+	filter := ClientFilter{SearchQuery: "id token", WholeWord: true}.withTermMatchers()
+	if len(filter.matchers) != 2 {
+		t.Fatalf("Expected 2 compiled matchers, got %d", len(filter.matchers))
+	}
+
+	msg := logs.ContainerMessage{Entry: &logs.LogEntry{Message: "missing id for token"}}
+	if !c.matchesFilter(msg, filter) {
+		t.Error("Expected compiled whole-word terms to match 'missing id for token'")
+	}
+	msg.Entry.Message = "invalid token"
+	if c.matchesFilter(msg, filter) {
+		t.Error("Expected compiled whole-word 'id' not to match 'invalid token'")
+	}
+}
+
+func TestMatchesFilterRange(t *testing.T) {
+	c := newTestController(t)
+
+	slow := logs.ContainerMessage{Entry: &logs.LogEntry{Message: "query", Fields: map[string]string{"duration": "250.5"}}}
+	fast := logs.ContainerMessage{Entry: &logs.LogEntry{Message: "query", Fields: map[string]string{"duration": "3"}}}
+	untimed := logs.ContainerMessage{Entry: &logs.LogEntry{Message: "query"}}
+
+	filter := ClientFilter{RangeFilters: []logstore.FieldRangeFilter{{Name: "duration", Op: logstore.RangeOpGreater, Value: "100"}}}
+	if !c.matchesFilter(slow, filter) {
+		t.Error("Expected duration 250.5 to match duration>100")
+	}
+	if c.matchesFilter(fast, filter) {
+		t.Error("Expected duration 3 not to match duration>100")
+	}
+	if c.matchesFilter(untimed, filter) {
+		t.Error("Expected log without duration not to match duration>100")
+	}
+}
+
+func TestMatchesFilterHasFields(t *testing.T) {
+	c := newTestController(t)
+
+	traced := logs.ContainerMessage{Entry: &logs.LogEntry{Message: "query", Fields: map[string]string{"trace_id": "abc"}}}
+	emptyTrace := logs.ContainerMessage{Entry: &logs.LogEntry{Message: "query", Fields: map[string]string{"trace_id": ""}}}
+	untraced := logs.ContainerMessage{Entry: &logs.LogEntry{Message: "query"}}
+
+	filter := ClientFilter{HasFields: []string{"trace_id"}}
+	if !c.matchesFilter(traced, filter) {
+		t.Error("Expected a log with a trace_id to match")
+	}
+	if c.matchesFilter(emptyTrace, filter) || c.matchesFilter(untraced, filter) {
+		t.Error("Expected logs without a trace_id value not to match")
+	}
+
+	filter.TraceFilters = []TraceFilterValue{{Type: "trace_id", Value: "other"}}
+	if c.matchesFilter(traced, filter) {
+		t.Error("Expected a trace filter to still constrain the value")
+	}
+}
+
+func TestExcludedContainers(t *testing.T) {
+	c := newTestController(t)
+	c.SetContainers([]logs.Container{
+		{ID: "c1", Name: "api"},
+		{ID: "c2", Name: "sidecar"},
+		{ID: "c3", Name: "worker"},
+	})
+	for i, id := range []string{"c1", "c2", "c3"} {
+		c.logStore.Add(&logs.ContainerMessage{
+			ContainerID: id,
+			Timestamp:   time.Now().Add(time.Duration(i) * time.Millisecond),
+			Entry:       &logs.LogEntry{Message: "hello"},
+		})
+	}
+	api := logs.ContainerMessage{ContainerID: "c1", Entry: &logs.LogEntry{Message: "hello"}}
+	sidecar := logs.ContainerMessage{ContainerID: "c2", Entry: &logs.LogEntry{Message: "hello"}}
+
+	tests := []struct {
+		name       string
+		filter     ClientFilter
+		containers []string // Sorted IDs expected from the log store
+	}{
+		{"exclude only", ClientFilter{ExcludedContainers: []string{"sidecar"}}, []string{"c1", "c3"}},
+		{"include and exclude overlap", ClientFilter{SelectedContainers: []string{"api", "sidecar"}, ExcludedContainers: []string{"sidecar"}}, []string{"c1"}},
+		{"exclude the only selection", ClientFilter{SelectedContainers: []string{"sidecar"}, ExcludedContainers: []string{"sidecar"}}, nil},
+	}
+	for _, tt := range tests {
+		if c.matchesFilter(sidecar, tt.filter) {
+			t.Errorf("%s: expected the excluded container not to match", tt.name)
+		}
+		if wantAPI := slices.Contains(tt.containers, "c1"); c.matchesFilter(api, tt.filter) != wantAPI {
+			t.Errorf("%s: expected api to match %v", tt.name, wantAPI)
+		}
+
+		var containers []string
+		for _, msg := range c.logStore.Filter(c.clientFilterToLogStoreFilter(tt.filter), 100) {
+			containers = append(containers, msg.ContainerID)
+		}
+		slices.Sort(containers)
+		if !slices.Equal(containers, tt.containers) {
+			t.Errorf("%s: expected logs from %v, got %v", tt.name, tt.containers, containers)
+		}
+	}
+}
+
+func TestPartialContainerNames(t *testing.T) {
+	c := newTestController(t)
+	c.SetContainers([]logs.Container{
+		{ID: "c1", Name: "web-1"},
+		{ID: "c2", Name: "web-2"},
+		{ID: "c3", Name: "api"},
+		{ID: "c4", Name: "api-worker"},
+	})
+	message := func(id string) logs.ContainerMessage {
+		return logs.ContainerMessage{ContainerID: id, Entry: &logs.LogEntry{Message: "hello"}}
+	}
+
+	filter := ClientFilter{SelectedContainers: []string{"web"}}
+	opts := c.clientFilterToLogStoreFilter(filter)
+	slices.Sort(opts.ContainerIDs)
+	if !slices.Equal(opts.ContainerIDs, []string{"c1", "c2"}) {
+		t.Errorf("Expected web to select web-1 and web-2, got %v", opts.ContainerIDs)
+	}
+	if !c.matchesFilter(message("c2"), filter) || c.matchesFilter(message("c3"), filter) {
+		t.Error("Expected web to match web-2 and not api")
+	}
+
+	// api names a container exactly, so it doesn't also select api-worker
+	filter = ClientFilter{SelectedContainers: []string{"api"}}
+	if opts := c.clientFilterToLogStoreFilter(filter); !slices.Equal(opts.ContainerIDs, []string{"c3"}) {
+		t.Errorf("Expected api to select only api, got %v", opts.ContainerIDs)
+	}
+	if !c.matchesFilter(message("c3"), filter) || c.matchesFilter(message("c4"), filter) {
+		t.Error("Expected api to match api and not api-worker")
+	}
+
+	filter = ClientFilter{SelectedContainers: []string{"web-*"}, ExcludedContainers: []string{"web-2"}}
+	if opts := c.clientFilterToLogStoreFilter(filter); !slices.Equal(opts.ExcludedContainerIDs, []string{"c2"}) {
+		t.Errorf("Expected web-2 excluded, got %v", opts.ExcludedContainerIDs)
+	}
+	if !c.matchesFilter(message("c1"), filter) || c.matchesFilter(message("c2"), filter) {
+		t.Error("Expected the glob to match web-1 but the exclusion to win for web-2")
+	}
+}
+
+func TestLogFieldHistogram(t *testing.T) {
+	c := newTestController(t)
+	c.SetContainers([]logs.Container{
+		{ID: "c1", Name: "api"},
+		{ID: "c2", Name: "worker"},
+	})
+	for _, msg := range []logs.ContainerMessage{
+		{ContainerID: "c1", Entry: &logs.LogEntry{Level: "ERR", Fields: map[string]string{"db.table": "users"}}},
+		{ContainerID: "c1", Entry: &logs.LogEntry{Level: "INF", Fields: map[string]string{"db.table": "users"}}},
+		{ContainerID: "c1", Entry: &logs.LogEntry{Level: "INF", Fields: map[string]string{"db.table": "orders"}}},
+		{ContainerID: "c1", Entry: &logs.LogEntry{Level: "INF", Fields: map[string]string{"db.table": "payments"}}},
+		{ContainerID: "c2", Entry: &logs.LogEntry{Level: "INF", Fields: map[string]string{"db.table": "jobs"}}},
+	} {
+		msg.Timestamp = time.Now()
+		c.logStore.Add(&msg)
+	}
+
+	rec := httptest.NewRecorder()
+	c.HandleLogFieldHistogram(rec, httptest.NewRequest(http.MethodGet, "/api/logs/histogram?field=db.table&top=2&container=api&level=INF", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var histogram []logstore.ValueCount
+	if err := json.Unmarshal(rec.Body.Bytes(), &histogram); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	expected := []logstore.ValueCount{
+		{Value: "orders", Count: 1},
+		{Value: "payments", Count: 1},
+		{Value: logstore.HistogramOther, Count: 1, Other: true},
+	}
+	if !slices.Equal(histogram, expected) {
+		t.Errorf("Expected the filtered top values and an other bucket, got %+v", histogram)
+	}
+
+	for _, query := range []string{"", "?field=db.table&trace=no-separator"} {
+		rec := httptest.NewRecorder()
+		c.HandleLogFieldHistogram(rec, httptest.NewRequest(http.MethodGet, "/api/logs/histogram"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %q, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestMatchesFilterSlowThreshold(t *testing.T) {
+	c := newTestController(t)
+
+	slow := logs.ContainerMessage{Entry: logs.ParseLogLine("INF [sql] SELECT 1 duration=1.5s")}
+	fast := logs.ContainerMessage{Entry: logs.ParseLogLine("INF [sql] SELECT 1 duration=12.3")}
+
+	filter := ClientFilter{SlowThresholdMS: 100}
+	if !c.matchesFilter(slow, filter) {
+		t.Error("Expected 1.5s entry to exceed a 100ms threshold")
+	}
+	if c.matchesFilter(fast, filter) {
+		t.Error("Expected 12.3ms entry not to exceed a 100ms threshold")
+	}
+
+	if !c.matchesFilter(fast, ClientFilter{}) {
+		t.Error("Expected no threshold to match every entry")
+	}
+}
+
+func TestMatchesFilterMaxAge(t *testing.T) {
+	c := newTestController(t)
+
+	now := time.Now()
+	recent := logs.ContainerMessage{ContainerID: "api", Timestamp: now.Add(-time.Minute), Entry: &logs.LogEntry{Level: "INF", Message: "recent"}}
+	old := logs.ContainerMessage{ContainerID: "api", Timestamp: now.Add(-time.Hour), Entry: &logs.LogEntry{Level: "INF", Message: "old"}}
+
+	filter := ClientFilter{MaxAgeSeconds: 600}
+	if !c.matchesFilter(recent, filter) {
+		t.Error("Expected a minute-old entry within a 10 minute max age")
+	}
+	if c.matchesFilter(old, filter) {
+		t.Error("Expected an hour-old entry to be dropped by a 10 minute max age")
+	}
+	if !c.matchesFilter(old, ClientFilter{}) {
+		t.Error("Expected no max age to match every entry")
+	}
+
+	// Stored logs sent on connect honor the same limit
+	c.logStore.Add(&recent)
+	c.logStore.Add(&old)
+	msg, err := c.initialLogsMessage(filter)
+	if err != nil {
+		t.Fatalf("Failed to build initial logs: %v", err)
+	}
+	var initial []LogWSMessage
+	if err := json.Unmarshal(msg.Data, &initial); err != nil {
+		t.Fatalf("Failed to decode initial logs: %v", err)
+	}
+	if len(initial) != 1 || initial[0].Entry.Message != "recent" {
+		t.Errorf("Expected only the recent entry, got %s", msg.Data)
+	}
+}
+
+func TestLogMessagesCarryServerTime(t *testing.T) {
+	c := newTestController(t)
+	msg := logs.ContainerMessage{ContainerID: "api", Timestamp: time.Now(), Entry: &logs.LogEntry{Message: "hello"}}
+	c.logStore.Add(&msg)
+
+	before := time.Now()
+	initial, err := c.initialLogsMessage(ClientFilter{})
+	if err != nil {
+		t.Fatalf("Failed to build initial logs: %v", err)
+	}
+	batch, ok := c.batchMessage([]logs.ContainerMessage{msg}, ClientFilter{})
+	if !ok {
+		t.Fatal("Expected the batch to match an empty filter")
+	}
+	for _, m := range []WSMessage{initial, batch} {
+		if m.ServerTime.Before(before) || m.ServerTime.After(time.Now()) {
+			t.Errorf("Expected %s to carry the time it was built, got %v", m.Type, m.ServerTime)
+		}
+		data, _ := json.Marshal(m)
+		if !strings.Contains(string(data), `"serverTime":`) {
+			t.Errorf("Expected serverTime in the %s envelope, got %s", m.Type, data)
+		}
+	}
+
+	// Other messages leave it out rather than sending the zero time
+	data, _ := json.Marshal(WSMessage{Type: "logs_clear"})
+	if strings.Contains(string(data), "serverTime") {
+		t.Errorf("Expected no serverTime on other messages, got %s", data)
+	}
+}
+
+func TestBroadcastEvictions(t *testing.T) {
+	c := newTestController(t)
+
+	client := &SSEClient{messages: make(chan WSMessage, 8)}
+	c.sseClients[client] = true
+
+	// Nothing evicted, nothing to report
+	c.logStore.Add(&logs.ContainerMessage{ContainerID: "api", Timestamp: time.Now(), Entry: &logs.LogEntry{Message: "first"}})
+	c.BroadcastEvictions()
+	if len(client.messages) != 0 {
+		t.Fatalf("Expected no message without evictions, got %d messages", len(client.messages))
+	}
+
+	c.logStore.SetContainerRetention("api", logstore.ContainerRetentionPolicy{Type: "count", Value: 2})
+	oldest := time.Now()
+	for i := range 3 {
+		c.logStore.Add(&logs.ContainerMessage{ContainerID: "api", Timestamp: oldest.Add(time.Duration(i) * time.Second), Entry: &logs.LogEntry{Message: "next"}})
+	}
+	c.BroadcastEvictions()
+
+	select {
+	case msg := <-client.messages:
+		var evictions []logstore.Eviction
+		if err := json.Unmarshal(msg.Data, &evictions); err != nil {
+			t.Fatalf("Failed to decode evictions: %v", err)
+		}
+		if msg.Type != "eviction" || len(evictions) != 1 {
+			t.Fatalf("Expected one eviction message, got %s %+v", msg.Type, evictions)
+		}
+		if evictions[0].ContainerID != "api" || evictions[0].Count != 2 {
+			t.Errorf("Expected 2 logs evicted from api, got %+v", evictions[0])
+		}
+		if want := oldest.Add(time.Second); !evictions[0].Oldest.Equal(want) {
+			t.Errorf("Expected the oldest log left at %v, got %v", want, evictions[0].Oldest)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the eviction message")
+	}
+
+	// Each message covers the evictions since the previous one
+	c.BroadcastEvictions()
+	if len(client.messages) != 0 {
+		t.Error("Expected counts to restart after a message")
+	}
+}
+
+// broadcastDuringBatches connects a WebSocket client and calls send n times while log
+// batches are broadcast to it from another goroutine, as processLogs does. It returns
+// how many messages of msgType the client received.
+func broadcastDuringBatches(t *testing.T, c *Controller, msgType string, n int, send func()) int {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(c.HandleWebSocket))
+	defer server.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to dial websocket: %v", err)
+	}
+	defer conn.Close()
+
+	// The client is registered just after its config message is sent
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		c.clientsMutex.RLock()
+		registered := len(c.clients) > 0
+		c.clientsMutex.RUnlock()
+		if registered {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the client to register")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	received := make(chan int)
+	go func() {
+		count := 0
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for count < n {
+			var msg WSMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				break
+			}
+			if msg.Type == msgType {
+				count++
+			}
+		}
+		received <- count
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		batch := []logs.ContainerMessage{{ContainerID: "api", Timestamp: time.Now(), Entry: &logs.LogEntry{Message: "tick"}}}
+		for range n {
+			c.BroadcastBatch(batch)
+		}
+	}()
+	for range n {
+		send()
+	}
+	<-done
+
+	return <-received
+}
+
+func TestSQLProgressDuringLogBatches(t *testing.T) {
+	c := newTestController(t)
+	progress := c.newSQLProgress(1)
+
+	if got := broadcastDuringBatches(t, c, "sql_progress", 200, progress.finish); got != 200 {
+		t.Errorf("Expected all 200 sql_progress messages, got %d", got)
+	}
+}
+
+func TestSQLProgressPlanInNextBatch(t *testing.T) {
+	c := newTestController(t)
+	execID, err := c.store.CreateRequest(&store.Request{RequestIDHeader: "req-1", ExecutedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to create execution: %v", err)
+	}
+	progress := c.newSQLProgress(execID)
+
+	plan := `{"Plan": {"Node Type": "Seq Scan", "Relation Name": "users", "Total Cost": 1834.00, "Plan Rows": 1, "Plan Width": 96}}`
+	line := func(container, message string) logs.ContainerMessage {
+		return logs.ContainerMessage{ContainerID: container, Entry: &logs.LogEntry{Message: message, Fields: map[string]string{}}}
+	}
+
+	// The query ends one batch and its plan starts the next
+	progress.add([]logs.ContainerMessage{line("api", "[sql]: SELECT * FROM users WHERE email = $1")})
+	progress.add([]logs.ContainerMessage{line("worker", "unrelated"), line("api", plan)})
+
+	queries, err := c.store.GetSQLQueries(execID)
+	if err != nil {
+		t.Fatalf("Failed to get queries: %v", err)
+	}
+	if len(queries) != 1 {
+		t.Fatalf("Expected the plan not to be saved as a query, got %d queries", len(queries))
+	}
+	if !strings.Contains(queries[0].ExplainPlan, "Seq Scan") {
+		t.Errorf("Expected the plan from the next batch to be attached, got %q", queries[0].ExplainPlan)
+	}
+}
+
+func TestWebSocketRestoresFilterForReturningToken(t *testing.T) {
+	c := newTestController(t)
+
+	server := httptest.NewServer(http.HandlerFunc(c.HandleWebSocket))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "?token=tab-1"
+
+	// readUntil reads messages until one of the given type arrives
+	readUntil := func(conn *websocket.Conn, msgType string) WSMessage {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for {
+			var msg WSMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("Failed to read %s message: %v", msgType, err)
+			}
+			if msg.Type == msgType {
+				return msg
+			}
+		}
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial websocket: %v", err)
+	}
+	if msg := readUntil(conn, "filter"); string(msg.Data) != "null" {
+		t.Fatalf("Expected no filter for a new token, got %s", msg.Data)
+	}
+
+	filter := ClientFilter{SelectedLevels: []string{"ERR"}, SearchQuery: "timeout", TraceFilters: []TraceFilterValue{}}
+	data, _ := json.Marshal(filter)
+	if err := conn.WriteJSON(WSMessage{Type: "filter", Data: data}); err != nil {
+		t.Fatalf("Failed to send filter: %v", err)
+	}
+	readUntil(conn, "logs_initial")
+	conn.Close()
+
+	// The search terms are compiled once, when the filter arrives
+	if saved, _ := c.restoreClientFilter("tab-1", ClientFilter{}); len(saved.matchers) != 1 {
+		t.Errorf("Expected the saved filter to carry 1 compiled matcher, got %d", len(saved.matchers))
+	}
+
+	// The returning client gets its filter back without sending it again
+	conn, _, err = websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial websocket: %v", err)
+	}
+	defer conn.Close()
+
+	var restored ClientFilter
+	if err := json.Unmarshal(readUntil(conn, "filter").Data, &restored); err != nil {
+		t.Fatalf("Failed to decode restored filter: %v", err)
+	}
+	if restored.SearchQuery != "timeout" || !slices.Equal(restored.SelectedLevels, []string{"ERR"}) {
+		t.Errorf("Expected restored filter %+v, got %+v", filter, restored)
+	}
+	readUntil(conn, "logs_initial")
+
+	// Another token starts fresh
+	if _, ok := c.restoreClientFilter("tab-2", ClientFilter{}); ok {
+		t.Error("Expected no saved filter for an unknown token")
+	}
+}
@@ -0,0 +1,18 @@
+package controller
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec is the OpenAPI 3 description of the routes registered in cmd/viewer.
+// Keep it in sync when adding or changing endpoints.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// HandleOpenAPI serves the OpenAPI description of the API
+func (c *Controller) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
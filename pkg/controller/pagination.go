@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// Page is the envelope returned by list endpoints when called with ?paginated=true
+type Page[T any] struct {
+	Items  []T   `json:"items"`
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+// Page size limits for list endpoints
+const (
+	defaultListLimit = 100
+	maxPageLimit     = 1000
+)
+
+// pageParams are the query parameters shared by paginated list endpoints
+type pageParams struct {
+	Paginated bool `schema:"paginated"`
+	Limit     int  `schema:"limit"`
+	Offset    int  `schema:"offset"`
+}
+
+// decodePageParams reads pagination query parameters, applying defaultLimit and clamping
+// out-of-range values
+func (c *Controller) decodePageParams(r *http.Request, defaultLimit int) pageParams {
+	params := pageParams{
+		Limit: defaultLimit,
+	}
+
+	if err := c.decoder.Decode(&params, r.URL.Query()); err != nil {
+		slog.Warn("failed to decode query parameters", "error", err)
+	}
+	if params.Limit <= 0 {
+		params.Limit = defaultLimit
+	}
+	params.Limit = min(params.Limit, maxPageLimit)
+	params.Offset = max(params.Offset, 0)
+	return params
+}
+
+// paginate returns one page of items loaded in full from the store
+func paginate[T any](items []T, params pageParams) Page[T] {
+	start := min(params.Offset, len(items))
+	end := min(start+params.Limit, len(items))
+	return Page[T]{
+		Items:  items[start:end],
+		Total:  int64(len(items)),
+		Limit:  params.Limit,
+		Offset: params.Offset,
+	}
+}
+
+// writeList writes items as a Page when the client asked for pagination, or as a bare array otherwise
+func writeList[T any](w http.ResponseWriter, items []T, params pageParams) {
+	w.Header().Set("Content-Type", "application/json")
+	if params.Paginated {
+		json.NewEncoder(w).Encode(paginate(items, params))
+		return
+	}
+	json.NewEncoder(w).Encode(items)
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"docker-log-parser/pkg/store"
+)
+
+func TestListServersPagination(t *testing.T) {
+	c := newTestController(t)
+
+	for i := range 3 {
+		if _, err := c.store.CreateServer(&store.Server{Name: fmt.Sprintf("server-%d", i), URL: "http://api"}); err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	c.HandleListServers(rec, httptest.NewRequest(http.MethodGet, "/api/servers?paginated=true&limit=2&offset=1", nil))
+
+	var page Page[store.Server]
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to decode page: %v", err)
+	}
+	if page.Total != 3 || page.Limit != 2 || page.Offset != 1 || len(page.Items) != 2 {
+		t.Errorf("Unexpected page: total=%d limit=%d offset=%d items=%d", page.Total, page.Limit, page.Offset, len(page.Items))
+	}
+
+	// An offset past the end returns an empty page rather than an error
+	rec = httptest.NewRecorder()
+	c.HandleListServers(rec, httptest.NewRequest(http.MethodGet, "/api/servers?paginated=true&offset=10", nil))
+	page = Page[store.Server]{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to decode page: %v", err)
+	}
+	if page.Total != 3 || len(page.Items) != 0 || page.Limit != defaultListLimit {
+		t.Errorf("Expected empty page with default limit, got %+v", page)
+	}
+
+	// Without the flag the bare array is returned for existing clients
+	rec = httptest.NewRecorder()
+	c.HandleListServers(rec, httptest.NewRequest(http.MethodGet, "/api/servers", nil))
+	var servers []store.Server
+	if err := json.Unmarshal(rec.Body.Bytes(), &servers); err != nil {
+		t.Fatalf("Expected bare array: %v", err)
+	}
+	if len(servers) != 3 {
+		t.Errorf("Expected 3 servers, got %d", len(servers))
+	}
+}
+
+func TestListRequestsPagination(t *testing.T) {
+	c := newTestController(t)
+
+	for i := range 3 {
+		_, err := c.store.CreateRequest(&store.Request{
+			RequestIDHeader: fmt.Sprintf("req-%d", i),
+			RequestBody:     `{"query":"{ me { id } }"}`,
+			ExecutedAt:      time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	c.HandleListAllRequests(rec, httptest.NewRequest(http.MethodGet, "/api/requests?paginated=true&limit=2", nil))
+
+	var page Page[store.Request]
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to decode page: %v", err)
+	}
+	if page.Total != 3 || len(page.Items) != 2 {
+		t.Errorf("Expected 2 of 3 executions, got %d of %d", len(page.Items), page.Total)
+	}
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -13,6 +14,8 @@ import (
 	"time"
 
 	"docker-log-parser/pkg/httputil"
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/logstore"
 	"docker-log-parser/pkg/sqlutil"
 	"docker-log-parser/pkg/store"
 
@@ -23,7 +26,7 @@ import (
 // HandleCreateRequest creates a new request
 func (c *Controller) HandleCreateRequest(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
@@ -35,31 +38,38 @@ func (c *Controller) HandleCreateRequest(w http.ResponseWriter, r *http.Request)
 		ExperimentalModeOverride string `json:"experimentalModeOverride,omitempty"`
 		RequestData              string `json:"requestData"`
 		Sync                     bool   `json:"sync,omitempty"`
+		Stream                   bool   `json:"stream,omitempty"` // Stream correlated logs while the request runs
 		SampleID                 *uint  `json:"sampleId,omitempty"`
+		RequestID                string `json:"requestId,omitempty"` // Fixed ID to send instead of a random one
+		Validate                 bool   `json:"validate,omitempty"`  // Check a GraphQL query against the server's schema first
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !c.decodeJSONBody(w, r, &input) {
 		return
 	}
 
 	if input.RequestData == "" {
-		http.Error(w, "requestData is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "requestData is required")
 		return
 	}
 
 	if input.ServerID == nil {
-		http.Error(w, "serverId is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "serverId is required")
+		return
+	}
+
+	if input.RequestID != "" && !httputil.ValidRequestID(input.RequestID) {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "requestId must be up to 64 letters, digits, -, _, . or :")
 		return
 	}
 
 	server, err := c.store.GetServer(int64(*input.ServerID))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	if server == nil {
-		http.Error(w, "Server not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Server not found")
 		return
 	}
 
@@ -81,15 +91,38 @@ func (c *Controller) HandleCreateRequest(w http.ResponseWriter, r *http.Request)
 		experimentalMode = input.ExperimentalModeOverride
 	}
 
-	requestIDHeader := httputil.GenerateRequestID()
+	if input.RequestID != "" && server.CorrelationFormat == httputil.CorrelationFormatTraceparent {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "requestId cannot be used with a traceparent server")
+		return
+	}
+
+	// Reject a query the schema would reject instead of spending a run on it
+	if query, isGraphQL := graphQLQuery(input.RequestData); input.Validate && isGraphQL {
+		validation, err := c.validateGraphQLQuery(r.Context(), server, query, false)
+		if err != nil {
+			writeJSONError(w, http.StatusBadGateway, ErrCodeUpstream, "Schema validation failed: "+err.Error())
+			return
+		}
+		if !validation.Valid {
+			messages := make([]string, len(validation.Errors))
+			for i, problem := range validation.Errors {
+				messages[i] = problem.Error()
+			}
+			writeJSONError(w, http.StatusUnprocessableEntity, ErrCodeValidation, "Query does not match the server's schema: "+strings.Join(messages, "; "))
+			return
+		}
+	}
+
+	correlation := httputil.NewCorrelationWithID(server.CorrelationHeader, server.CorrelationFormat, input.RequestID)
 
 	execution := &store.Request{
 		ServerID:            input.ServerID,
-		RequestIDHeader:     requestIDHeader,
+		URL:                 url,
+		RequestIDHeader:     correlation.ID,
 		RequestBody:         input.RequestData,
 		ExecutedAt:          time.Now(),
-		StatusCode:          0,
-		IsSync:              input.Sync,
+		Status:              store.RequestStatusPending,
+		IsSync:              input.Sync || input.Stream,
 		BearerTokenOverride: input.BearerTokenOverride,
 		DevIDOverride:       input.DevIDOverride,
 		SampleID:            input.SampleID,
@@ -97,45 +130,90 @@ func (c *Controller) HandleCreateRequest(w http.ResponseWriter, r *http.Request)
 
 	execID, err := c.store.CreateRequest(execution)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 
+	// The request can be cancelled through HandleCancelExecution until its response arrives.
+	// It is queued while the maximum number of executions are running.
+	running := c.trackExecution(execID)
+
 	executeRequest := func() {
+		// A queued execution waits here; one cancelled while queued is never sent
+		sent := c.waitForSlot(running)
+		if sent {
+			defer c.releaseExecutionSlot()
+		}
+
+		// Save SQL queries as the request's logs arrive instead of only once it finishes
+		progress := c.newSQLProgress(execID)
+		sub := c.subscribeLogs(correlation.Matches)
+		stopProgress := progress.follow(sub)
+
+		if sent {
+			execution.Status = store.RequestStatusRunning
+			if err := c.store.UpdateRequest(execution); err != nil {
+				slog.Error("failed to update execution", "error", err)
+			}
+		}
+
 		startTime := time.Now()
-		statusCode, responseBody, responseHeaders, err := httputil.MakeHTTPRequest(url, []byte(input.RequestData), requestIDHeader, bearerToken, devID, experimentalMode)
+		statusCode, responseBody, responseHeaders, err := 0, "", "", running.ctx.Err()
+		if sent {
+			statusCode, responseBody, responseHeaders, err = httputil.MakeHTTPRequest(running.ctx, url, []byte(input.RequestData), correlation, bearerToken, devID, experimentalMode)
+		}
 		execution.DurationMS = time.Since(startTime).Milliseconds()
 		execution.StatusCode = statusCode
 		execution.ResponseBody = responseBody
 		execution.ResponseHeaders = responseHeaders
+		execution.BuildVersion = server.ResponseBuildVersion(responseHeaders)
 
-		if err != nil {
+		cancelled := err != nil && errors.Is(running.ctx.Err(), context.Canceled)
+		switch {
+		case cancelled:
+			execution.Error = "Cancelled"
+		case err != nil:
 			execution.Error = err.Error()
+		default:
+			execution.Error = httputil.DetectResponseError(execution.Protocol, statusCode, responseBody)
+		}
+		execution.Status = store.FinishedStatus(execution.Error)
+		if cancelled {
+			execution.Status = store.RequestStatusCancelled
 		}
 
 		execution.ID = uint(execID)
 		if err := c.store.UpdateRequest(execution); err != nil {
 			slog.Error("failed to update execution", "error", err)
 		}
+		c.untrackExecution(execID)
+
+		// Collect and save logs for this request, including any ID the server echoed back
+		filters := correlationFilters(correlation, responseHeaders, server.ResponseTraceHeader)
+		collectedLogs := httputil.CollectLogsMatchingAny(filters, c.logStore, 500*time.Millisecond)
+		stopProgress()
+		c.unsubscribeLogs(sub)
 
-		// Collect and save logs for this request
-		collectedLogs := httputil.CollectLogsForRequest(requestIDHeader, c.logStore, 500*time.Millisecond)
 		if len(collectedLogs) > 0 {
 			if err := c.store.SaveRequestLogs(execID, collectedLogs); err != nil {
 				slog.Error("failed to save request logs", "error", err)
 			}
 
-			// Extract and save SQL queries from logs
-			sqlQueries := sqlutil.ExtractSQLQueries(collectedLogs)
-			if len(sqlQueries) > 0 {
-				if err := c.store.SaveSQLQueries(execID, sqlQueries); err != nil {
-					slog.Error("failed to save SQL queries", "error", err)
-				}
-			}
+			// Save the queries from logs that were missed live, such as those matched by
+			// the response's trace header
+			progress.add(collectedLogs)
 		}
+		progress.finish()
+		c.correlateResolvers(execID, execution)
+		c.checkExpectations(execution, progress.total)
+
+		// Reported once the logs and queries are saved, so the query count is final
+		c.broadcastExecutionUpdate(execution, progress.total)
 	}
 
-	if input.Sync {
+	if input.Stream {
+		c.streamExecution(w, r, correlation, execID, execution, executeRequest)
+	} else if input.Sync {
 		executeRequest()
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]any{
@@ -144,19 +222,119 @@ func (c *Controller) HandleCreateRequest(w http.ResponseWriter, r *http.Request)
 			"execution":   execution,
 		})
 	} else {
+		status := "started"
+		if c.executionQueued(execID) {
+			status = "queued"
+		}
 		go executeRequest()
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]any{
-			"status":      "started",
+			"status":      status,
 			"executionId": execID,
 		})
 	}
 }
 
+// correlationFilters returns the log filters to collect an execution's logs with: the ID we sent,
+// plus the request ID in the server's configured trace header if the response carries a different one
+func correlationFilters(correlation httputil.Correlation, responseHeaders, traceHeader string) []logstore.FieldFilter {
+	filters := []logstore.FieldFilter{{Name: correlation.Field, Value: correlation.ID}}
+	if traceHeader == "" {
+		return filters
+	}
+
+	headers, err := httputil.ParseResponseHeaders(responseHeaders)
+	if err != nil || headers == nil {
+		return filters
+	}
+
+	if traceID := http.Header(headers.Headers).Get(traceHeader); traceID != "" && traceID != correlation.ID {
+		filters = append(filters, logstore.FieldFilter{Name: "request_id", Value: traceID})
+	}
+	return filters
+}
+
+// streamExecution runs executeRequest while streaming logs matching correlation to the client
+// as newline-delimited JSON "logs" messages as they arrive, then writes a final "result"
+// message with the completed execution
+func (c *Controller) streamExecution(w http.ResponseWriter, r *http.Request, correlation httputil.Correlation, execID int64, execution *store.Request, executeRequest func()) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Streaming not supported")
+		return
+	}
+
+	sub := c.subscribeLogs(correlation.Matches)
+	defer c.unsubscribeLogs(sub)
+
+	done := make(chan struct{})
+	go func() {
+		executeRequest()
+		close(done)
+	}()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	encoder := json.NewEncoder(w)
+	writeMessage := func(msgType string, payload any) {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			slog.Error("failed to marshal stream message", "type", msgType, "error", err)
+			return
+		}
+		if err := encoder.Encode(WSMessage{Type: msgType, Data: data}); err != nil {
+			slog.Warn("failed to write stream message", "type", msgType, "error", err)
+			return
+		}
+		flusher.Flush()
+	}
+	writeLogs := func(batch []logs.ContainerMessage) {
+		logMessages := make([]LogWSMessage, 0, len(batch))
+		for _, msg := range batch {
+			logMessages = append(logMessages, LogWSMessage{
+				ContainerID: msg.ContainerID,
+				Timestamp:   msg.Timestamp,
+				Entry:       msg.Entry,
+			})
+		}
+		writeMessage("logs", logMessages)
+	}
+
+	for running := true; running; {
+		select {
+		case batch := <-sub.batches:
+			writeLogs(batch)
+		case <-done:
+			running = false
+		case <-r.Context().Done():
+			// Client went away; the execution still completes and is saved in the background
+			return
+		}
+	}
+
+	// Flush anything broadcast while the execution was finishing
+	for drained := false; !drained; {
+		select {
+		case batch := <-sub.batches:
+			writeLogs(batch)
+		default:
+			drained = true
+		}
+	}
+
+	writeMessage("result", map[string]any{
+		"status":      "completed",
+		"executionId": execID,
+		"execution":   execution,
+	})
+}
+
 // HandleListRequestsBySample lists executions for a request
 func (c *Controller) HandleListRequestsBySample(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
@@ -166,13 +344,13 @@ func (c *Controller) HandleListRequestsBySample(w http.ResponseWriter, r *http.R
 
 	var params QueryParams
 	if err := c.decoder.Decode(&params, r.URL.Query()); err != nil {
-		http.Error(w, "request_id parameter required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "request_id parameter required")
 		return
 	}
 
 	executions, err := c.store.ListRequestsBySample(params.RequestID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 
@@ -180,66 +358,213 @@ func (c *Controller) HandleListRequestsBySample(w http.ResponseWriter, r *http.R
 	json.NewEncoder(w).Encode(executions)
 }
 
+// requestFilter reads the search, container, status and server query parameters that
+// narrow a listing of executions, writing an error response and returning false if one
+// is invalid
+func (c *Controller) requestFilter(w http.ResponseWriter, r *http.Request) (store.RequestFilter, bool) {
+	filter := store.RequestFilter{
+		Search: r.URL.Query().Get("search"),
+		Status: r.URL.Query().Get("status"),
+	}
+
+	if container := r.URL.Query().Get("container"); container != "" {
+		filter.ContainerIDs = c.containerIDsFor(container)
+	}
+
+	if filter.Status != "" && !store.ValidRequestStatus(filter.Status) {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "status must be pending, running, completed, failed or cancelled")
+		return filter, false
+	}
+
+	if server := r.URL.Query().Get("server"); server != "" {
+		serverID, err := strconv.ParseUint(server, 10, 64)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "server must be a server ID")
+			return filter, false
+		}
+		filter.ServerID = uint(serverID)
+	}
+	return filter, true
+}
+
 // HandleListAllRequests lists all executions with pagination
 func (c *Controller) HandleListAllRequests(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	params := c.decodePageParams(r, 20)
+	filter, ok := c.requestFilter(w, r)
+	if !ok {
+		return
+	}
+
+	executions, total, err := c.store.WithContext(r.Context()).ListRequests(params.Limit, params.Offset, filter.Search, true, filter.ContainerIDs, filter.Status, filter.ServerID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	if params.Paginated {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Page[store.Request]{
+			Items:  executions,
+			Total:  total,
+			Limit:  params.Limit,
+			Offset: params.Offset,
+		})
+		return
+	}
+
+	response := map[string]any{
+		"executions": executions,
+		"total":      total,
+		"limit":      params.Limit,
+		"offset":     params.Offset,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// exportFlushEvery is how many exported executions are written between flushes
+const exportFlushEvery = 100
+
+// HandleExportExecutions streams every execution matching the list filters as
+// newline-delimited JSON, newest first, without holding them all in memory
+func (c *Controller) HandleExportExecutions(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	if format := r.URL.Query().Get("format"); format != "" && format != "ndjson" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "format must be ndjson")
+		return
+	}
+	filter, ok := c.requestFilter(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="executions.ndjson"`)
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	written := 0
+	err := c.store.WithContext(r.Context()).ExportRequests(filter, func(execution *store.Request) error {
+		if err := encoder.Encode(execution); err != nil {
+			return err
+		}
+		if written++; written%exportFlushEvery == 0 {
+			flusher.Flush()
+		}
+		return r.Context().Err()
+	})
+	if err != nil {
+		// The status is already sent, so the truncated body is all the client sees
+		slog.Warn("execution export stopped", "written", written, "error", err)
+		return
+	}
+	flusher.Flush()
+}
+
+// HandleListRetryGroups lists executions of the same request body that look like retries
+func (c *Controller) HandleListRetryGroups(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	type QueryParams struct {
-		Limit  int    `schema:"limit"`
-		Offset int    `schema:"offset"`
-		Search string `schema:"search"`
+		Window int `schema:"window"` // seconds between executions
 	}
 
 	params := QueryParams{
-		Limit:  20,
-		Offset: 0,
+		Window: 300,
 	}
 
 	if err := c.decoder.Decode(&params, r.URL.Query()); err != nil {
 		slog.Warn("failed to decode query parameters", "error", err)
 	}
 
-	executions, total, err := c.store.ListRequests(params.Limit, params.Offset, params.Search, true)
+	groups, err := c.store.WithContext(r.Context()).ListRetryGroups(time.Duration(params.Window) * time.Second)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 
-	response := map[string]any{
-		"executions": executions,
-		"total":      total,
-		"limit":      params.Limit,
-		"offset":     params.Offset,
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// HandleOperationAnomalies compares the latest execution of an operation with the
+// rolling average of the executions before it
+func (c *Controller) HandleOperationAnomalies(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	type QueryParams struct {
+		Window int `schema:"window"` // prior executions to average
+	}
+
+	params := QueryParams{
+		Window: 10,
+	}
+
+	if err := c.decoder.Decode(&params, r.URL.Query()); err != nil {
+		slog.Warn("failed to decode query parameters", "error", err)
+	}
+	if params.Window <= 0 {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Window must be positive")
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	anomalies, err := c.store.WithContext(r.Context()).GetOperationAnomalies(name, params.Window)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	if anomalies == nil {
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "No executions found for operation")
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(anomalies)
 }
 
 // HandleGetRequestDetail gets execution details by ID
 func (c *Controller) HandleGetRequestDetail(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid execution ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid execution ID")
 		return
 	}
 
-	detail, err := c.store.GetRequestDetail(id)
+	detail, err := c.store.WithContext(r.Context()).GetRequestDetail(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	if detail == nil {
-		http.Error(w, "Execution not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Execution not found")
 		return
 	}
 
@@ -247,22 +572,193 @@ func (c *Controller) HandleGetRequestDetail(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(detail)
 }
 
+// HandleListExecutionTraces lists the traces found in an execution's logs with per-trace counts
+func (c *Controller) HandleListExecutionTraces(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid execution ID")
+		return
+	}
+
+	exec, err := c.store.GetRequest(id)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	if exec == nil {
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Execution not found")
+		return
+	}
+
+	traces, err := c.store.WithContext(r.Context()).ListExecutionTraces(id)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(traces)
+}
+
+// HandleExecutionTimeline returns an execution's SQL queries and notable log lines as a
+// timeline, optionally limited to one trace
+func (c *Controller) HandleExecutionTimeline(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid execution ID")
+		return
+	}
+
+	timeline, err := c.store.WithContext(r.Context()).BuildTraceTimeline(id, r.URL.Query().Get("traceId"))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	if timeline == nil {
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Execution not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timeline)
+}
+
+// HandleExecutionFixture exports an execution's request, response, logs and SQL queries as a JSON test fixture
+func (c *Controller) HandleExecutionFixture(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid execution ID")
+		return
+	}
+
+	fixture, err := c.store.WithContext(r.Context()).GetExecutionFixture(id)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	if fixture == nil {
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Execution not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="execution-%d-fixture.json"`, id))
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(fixture)
+}
+
+// HandleCompareExecutions compares the SQL of two executions, a before and b after, and
+// returns the same report as the analyze tool's -json output
+func (c *Controller) HandleCompareExecutions(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	type QueryParams struct {
+		A int64 `schema:"a,required"`
+		B int64 `schema:"b,required"`
+	}
+
+	var params QueryParams
+	if err := c.decoder.Decode(&params, r.URL.Query()); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "a and b must be execution IDs")
+		return
+	}
+
+	var details [2]*store.RequestDetailResponse
+	for i, id := range []int64{params.A, params.B} {
+		detail, err := c.store.WithContext(r.Context()).GetRequestDetail(id)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+			return
+		}
+		if detail == nil {
+			writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, fmt.Sprintf("Execution %d not found", id))
+			return
+		}
+		details[i] = detail
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(store.CompareExecutions(details[0], details[1]))
+}
+
+// HandleSlowestExecutions lists the slowest executions within a recent window, for
+// triaging at the request level rather than per SQL query
+func (c *Controller) HandleSlowestExecutions(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	type QueryParams struct {
+		Since string `schema:"since"` // How far back to look, as a duration
+		Limit int    `schema:"limit"`
+	}
+
+	params := QueryParams{
+		Since: "24h",
+		Limit: 20,
+	}
+
+	if err := c.decoder.Decode(&params, r.URL.Query()); err != nil {
+		slog.Warn("failed to decode query parameters", "error", err)
+	}
+	window, err := time.ParseDuration(params.Since)
+	if err != nil || window <= 0 {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "since must be a positive duration such as 24h or 90m")
+		return
+	}
+	if params.Limit <= 0 || params.Limit > maxPageLimit {
+		params.Limit = maxPageLimit
+	}
+
+	executions, err := c.store.WithContext(r.Context()).SlowestExecutions(time.Now().Add(-window), params.Limit)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(executions)
+}
+
 // HandleNotionExportForRequest exports request to Notion
 func (c *Controller) HandleNotionExportForRequest(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid execution ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid execution ID")
 		return
 	}
 
-	detail, err := c.store.GetRequestDetail(id)
+	detail, err := c.store.WithContext(r.Context()).GetRequestDetail(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	if detail == nil {
-		http.Error(w, "Execution not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Execution not found")
 		return
 	}
 
@@ -270,18 +766,18 @@ func (c *Controller) HandleNotionExportForRequest(w http.ResponseWriter, r *http
 	notionDatabaseID := os.Getenv("NOTION_DATABASE_ID")
 
 	if notionAPIKey == "" {
-		http.Error(w, "Notion API key not configured. Set NOTION_API_KEY environment variable.", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeNotConfigured, "Notion API key not configured. Set NOTION_API_KEY environment variable.")
 		return
 	}
 
 	if notionDatabaseID == "" {
-		http.Error(w, "Notion database ID not configured. Set NOTION_DATABASE_ID environment variable.", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeNotConfigured, "Notion database ID not configured. Set NOTION_DATABASE_ID environment variable.")
 		return
 	}
 
 	pageURL, err := createNotionPageForRequest(notionAPIKey, notionDatabaseID, detail)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to create Notion page: %v", err), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusBadGateway, ErrCodeUpstream, fmt.Sprintf("Failed to create Notion page: %v", err))
 		return
 	}
 
@@ -0,0 +1,925 @@
+package controller
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"docker-log-parser/pkg/httputil"
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/store"
+	"docker-log-parser/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+func TestCollectLogsUsingResponseTraceHeader(t *testing.T) {
+	c := newTestController(t)
+
+	// The gateway rewrites our request ID and echoes its own
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Gateway-Request-Id", "gw-999")
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer upstream.Close()
+
+	resp, err := http.Post(upstream.URL, "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	headersJSON, _ := json.Marshal(resp.Header)
+
+	c.logStore.Add(&logs.ContainerMessage{
+		ContainerID: "api",
+		Timestamp:   time.Now(),
+		Entry:       &logs.LogEntry{Message: "handled by gateway id", Fields: map[string]string{"request_id": "gw-999"}},
+	})
+	c.logStore.Add(&logs.ContainerMessage{
+		ContainerID: "edge",
+		Timestamp:   time.Now(),
+		Entry:       &logs.LogEntry{Message: "handled by our id", Fields: map[string]string{"request_id": "abc12345"}},
+	})
+
+	correlation := httputil.Correlation{Header: "X-Request-Id", Value: "abc12345", ID: "abc12345", Field: "request_id"}
+	filters := correlationFilters(correlation, string(headersJSON), "X-Gateway-Request-Id")
+	if len(filters) != 2 || filters[1].Value != "gw-999" {
+		t.Fatalf("Expected filters for abc12345 and gw-999, got %v", filters)
+	}
+
+	collected := httputil.CollectLogsMatchingAny(filters, c.logStore, 0)
+	if len(collected) != 2 {
+		t.Errorf("Expected logs for both IDs, got %d", len(collected))
+	}
+
+	// Without a configured header only the sent ID is used
+	if filters := correlationFilters(correlation, string(headersJSON), ""); len(filters) != 1 {
+		t.Errorf("Expected only the sent request ID, got %v", filters)
+	}
+}
+
+// newReachableServer starts a test server that MakeHTTPRequest can reach. Inside Docker
+// localhost URLs are rewritten to host.docker.internal, so listen on a non-loopback address.
+func newReachableServer(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+
+	if !utils.IsRunningInDocker() {
+		return httptest.NewServer(handler)
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		t.Skipf("Cannot list interface addresses: %v", err)
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || ipNet.IP.To4() == nil {
+			continue
+		}
+		listener, err := net.Listen("tcp", net.JoinHostPort(ipNet.IP.String(), "0"))
+		if err != nil {
+			continue
+		}
+		server := httptest.NewUnstartedServer(handler)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+		return server
+	}
+
+	t.Skip("No non-loopback address available for test server")
+	return nil
+}
+
+func TestStreamExecutionSendsLogsBeforeResult(t *testing.T) {
+	c := newTestController(t)
+
+	release := make(chan struct{})
+	upstream := newReachableServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := logs.ContainerMessage{
+			ContainerID: "api",
+			Timestamp:   time.Now(),
+			Entry: &logs.LogEntry{
+				Message: "processing mutation",
+				Fields:  map[string]string{"request_id": r.Header.Get("X-Request-Id")},
+			},
+		}
+		c.logStore.Add(&msg)
+		c.BroadcastBatch([]logs.ContainerMessage{msg})
+
+		// Hold the response until the client has seen the streamed log
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+		}
+		w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer upstream.Close()
+
+	serverID, err := c.store.CreateServer(&store.Server{Name: "upstream", URL: upstream.URL})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	api := httptest.NewServer(http.HandlerFunc(c.HandleCreateRequest))
+	defer api.Close()
+
+	body := fmt.Sprintf(`{"serverId":%d,"requestData":"{\"query\":\"mutation { run }\"}","stream":true}`, serverID)
+	resp, err := http.Post(api.URL, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Expected application/x-ndjson, got %s", ct)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	readMessage := func() WSMessage {
+		t.Helper()
+		if !scanner.Scan() {
+			t.Fatalf("Stream ended early: %v", scanner.Err())
+		}
+		var msg WSMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("Failed to decode stream line %q: %v", scanner.Text(), err)
+		}
+		return msg
+	}
+
+	// The upstream request is still blocked, so this can only be a streamed log
+	first := readMessage()
+	if first.Type != "logs" {
+		t.Fatalf("Expected logs before the result, got %s", first.Type)
+	}
+	var streamed []LogWSMessage
+	if err := json.Unmarshal(first.Data, &streamed); err != nil {
+		t.Fatalf("Failed to decode logs: %v", err)
+	}
+	if len(streamed) != 1 || streamed[0].Entry.Message != "processing mutation" {
+		t.Errorf("Unexpected streamed logs: %+v", streamed)
+	}
+
+	close(release)
+
+	last := readMessage()
+	if last.Type != "result" {
+		t.Fatalf("Expected result, got %s", last.Type)
+	}
+	var result struct {
+		Status      string        `json:"status"`
+		ExecutionID int64         `json:"executionId"`
+		Execution   store.Request `json:"execution"`
+	}
+	if err := json.Unmarshal(last.Data, &result); err != nil {
+		t.Fatalf("Failed to decode result: %v", err)
+	}
+	if result.Status != "completed" || result.Execution.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected result: %s, status code %d", result.Status, result.Execution.StatusCode)
+	}
+
+	saved, err := c.store.GetRequestLogs(result.ExecutionID)
+	if err != nil {
+		t.Fatalf("Failed to get request logs: %v", err)
+	}
+	if len(saved) != 1 {
+		t.Errorf("Expected the streamed log to be saved with the execution, got %d", len(saved))
+	}
+}
+
+func TestCreateRequestWithTraceparentCorrelation(t *testing.T) {
+	c := newTestController(t)
+
+	var received string
+	upstream := newReachableServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+		parts := strings.Split(received, "-")
+		if len(parts) == 4 {
+			// The service logs the trace ID from traceparent, not a request ID
+			c.logStore.Add(&logs.ContainerMessage{
+				ContainerID: "api",
+				Timestamp:   time.Now(),
+				Entry:       &logs.LogEntry{Message: "traced", Fields: map[string]string{"trace_id": parts[1]}},
+			})
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer upstream.Close()
+
+	serverID, err := c.store.CreateServer(&store.Server{
+		Name:              "traced",
+		URL:               upstream.URL,
+		CorrelationFormat: httputil.CorrelationFormatTraceparent,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	body := fmt.Sprintf(`{"serverId":%d,"requestData":"{}","sync":true}`, serverID)
+	req := httptest.NewRequest(http.MethodPost, "/api/requests", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	c.HandleCreateRequest(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if received == "" {
+		t.Fatal("Expected traceparent header to be sent")
+	}
+
+	var result struct {
+		ExecutionID int64         `json:"executionId"`
+		Execution   store.Request `json:"execution"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !strings.Contains(received, result.Execution.RequestIDHeader) {
+		t.Errorf("Expected stored ID %s to be the traceparent trace ID in %s", result.Execution.RequestIDHeader, received)
+	}
+
+	saved, err := c.store.GetRequestLogs(result.ExecutionID)
+	if err != nil {
+		t.Fatalf("Failed to get request logs: %v", err)
+	}
+	if len(saved) != 1 {
+		t.Errorf("Expected the traced log to be collected, got %d", len(saved))
+	}
+}
+
+func TestCreateRequestNamedFromOverrideURL(t *testing.T) {
+	c := newTestController(t)
+
+	var path string
+	upstream := newReachableServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer upstream.Close()
+
+	serverID, err := c.store.CreateServer(&store.Server{Name: "rest", URL: upstream.URL + "/v1/users", NameField: store.NameFieldURLPath})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	body := fmt.Sprintf(`{"serverId":%d,"requestData":"{}","urlOverride":%q,"sync":true}`, serverID, upstream.URL+"/v1/orders")
+	w := httptest.NewRecorder()
+	c.HandleCreateRequest(w, httptest.NewRequest(http.MethodPost, "/api/requests", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if path != "/v1/orders" {
+		t.Fatalf("Expected the override URL to be called, got %s", path)
+	}
+
+	var result struct {
+		ExecutionID int64 `json:"executionId"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	detail, err := c.store.GetRequestDetail(result.ExecutionID)
+	if err != nil || detail == nil {
+		t.Fatalf("Failed to get execution: %v", err)
+	}
+	if detail.Execution.DisplayName != "/v1/orders" {
+		t.Errorf("Expected the execution named from the URL it was sent to, got %q", detail.Execution.DisplayName)
+	}
+}
+
+func TestExecutionFixtureContainsAllComponents(t *testing.T) {
+	c := newTestController(t)
+
+	id, err := c.store.CreateRequest(&store.Request{
+		RequestIDHeader: "fixture-1",
+		RequestBody:     `{"query":"{ users { id } }"}`,
+		StatusCode:      200,
+		ResponseBody:    `{"data":{"users":[]}}`,
+		DurationMS:      42,
+		ExecutedAt:      time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	err = c.store.SaveRequestLogs(id, []logs.ContainerMessage{{
+		ContainerID: "api",
+		Timestamp:   time.Now(),
+		Entry:       &logs.LogEntry{Message: "listing users", Fields: map[string]string{"request_id": "fixture-1"}},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to save logs: %v", err)
+	}
+	err = c.store.SaveSQLQueries(id, []store.SQLQuery{{
+		Query:           `SELECT * FROM "users"`,
+		NormalizedQuery: `SELECT * FROM "users"`,
+		QueriedTable:    "users",
+		Operation:       "SELECT",
+		DurationMS:      1.5,
+	}})
+	if err != nil {
+		t.Fatalf("Failed to save SQL queries: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"id": fmt.Sprint(id)})
+	c.HandleExecutionFixture(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Header().Get("Content-Disposition"), "attachment") {
+		t.Errorf("Expected fixture to be served as an attachment")
+	}
+
+	var fixture store.ExecutionFixture
+	if err := json.Unmarshal(rec.Body.Bytes(), &fixture); err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	if fixture.Version != store.FixtureVersion {
+		t.Errorf("Expected version %d, got %d", store.FixtureVersion, fixture.Version)
+	}
+	if fixture.Request.Body != `{"query":"{ users { id } }"}` || fixture.Request.RequestIDHeader != "fixture-1" {
+		t.Errorf("Unexpected request in fixture: %+v", fixture.Request)
+	}
+	if fixture.Response.StatusCode != 200 || fixture.Response.Body != `{"data":{"users":[]}}` {
+		t.Errorf("Unexpected response in fixture: %+v", fixture.Response)
+	}
+	if len(fixture.Logs) != 1 || fixture.Logs[0].Message != "listing users" {
+		t.Errorf("Expected 1 log in fixture, got %+v", fixture.Logs)
+	}
+	if len(fixture.SQLQueries) != 1 || fixture.SQLQueries[0].QueriedTable != "users" {
+		t.Errorf("Expected 1 SQL query in fixture, got %+v", fixture.SQLQueries)
+	}
+
+	// The decoded fixture can be analyzed without the store
+	analysis, _ := fixture.Analyze()
+	if analysis == nil || analysis.TotalQueries != 1 {
+		t.Errorf("Expected offline analysis of 1 query, got %+v", analysis)
+	}
+
+	rec = httptest.NewRecorder()
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"id": "999"})
+	c.HandleExecutionFixture(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for missing execution, got %d", rec.Code)
+	}
+}
+
+func TestSQLQueriesSavedWhileRequestRuns(t *testing.T) {
+	c := newTestController(t)
+
+	// Capture broadcasts the way an SSE client would receive them
+	client := &SSEClient{messages: make(chan WSMessage, 64)}
+	c.sseClients[client] = true
+
+	release := make(chan struct{})
+	upstream := newReachableServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := logs.ContainerMessage{
+			ContainerID: "api",
+			Timestamp:   time.Now(),
+			Entry: &logs.LogEntry{
+				Message: `[sql]: SELECT * FROM "users"`,
+				Fields:  map[string]string{"request_id": r.Header.Get("X-Request-Id")},
+			},
+		}
+		c.logStore.Add(&msg)
+		c.BroadcastBatch([]logs.ContainerMessage{msg})
+
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer upstream.Close()
+
+	serverID, err := c.store.CreateServer(&store.Server{Name: "upstream", URL: upstream.URL})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		body := fmt.Sprintf(`{"serverId":%d,"requestData":"{}","sync":true}`, serverID)
+		c.HandleCreateRequest(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/requests", strings.NewReader(body)))
+	}()
+
+	readProgress := func() SQLProgressMessage {
+		t.Helper()
+		for {
+			select {
+			case msg := <-client.messages:
+				if msg.Type != "sql_progress" {
+					continue
+				}
+				var progress SQLProgressMessage
+				if err := json.Unmarshal(msg.Data, &progress); err != nil {
+					t.Fatalf("Failed to decode progress: %v", err)
+				}
+				return progress
+			case <-time.After(5 * time.Second):
+				t.Fatal("Timed out waiting for sql_progress")
+			}
+		}
+	}
+
+	// The upstream request is still blocked, so the query was saved from the live log
+	progress := readProgress()
+	if progress.Complete || len(progress.Queries) != 1 || progress.Queries[0].ID == 0 {
+		t.Fatalf("Expected one saved query before completion, got %+v", progress)
+	}
+	saved, err := c.store.GetSQLQueries(progress.ExecutionID)
+	if err != nil {
+		t.Fatalf("Failed to get SQL queries: %v", err)
+	}
+	if len(saved) != 1 {
+		t.Fatalf("Expected the query to be saved while the request runs, got %d", len(saved))
+	}
+
+	close(release)
+	<-done
+
+	final := readProgress()
+	if !final.Complete || final.Total != 1 {
+		t.Errorf("Expected completion with 1 query in total, got %+v", final)
+	}
+
+	// Collecting the logs at the end does not save the query a second time
+	saved, err = c.store.GetSQLQueries(progress.ExecutionID)
+	if err != nil {
+		t.Fatalf("Failed to get SQL queries: %v", err)
+	}
+	if len(saved) != 1 {
+		t.Errorf("Expected 1 saved query after completion, got %d", len(saved))
+	}
+
+	// Clients are told the outcome instead of having to poll for it
+	select {
+	case msg := <-client.messages:
+		var update ExecutionUpdateMessage
+		if msg.Type != "execution_update" || json.Unmarshal(msg.Data, &update) != nil {
+			t.Fatalf("Expected an execution_update after the final progress, got %s %s", msg.Type, msg.Data)
+		}
+		if update.ExecutionID != progress.ExecutionID || update.Status != store.RequestStatusCompleted || update.StatusCode != http.StatusOK || update.QueryCount != 1 {
+			t.Errorf("Unexpected execution update %+v", update)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for execution_update")
+	}
+}
+
+func TestCancelExecution(t *testing.T) {
+	c := newTestController(t)
+
+	received := make(chan struct{})
+	upstream := newReachableServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body) // The server only notices the client leaving once the body is read
+		close(received)
+		// A hung backend: only the client giving up ends the request
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer upstream.Close()
+
+	serverID, err := c.store.CreateServer(&store.Server{Name: "hung", URL: upstream.URL})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	body := fmt.Sprintf(`{"serverId":%d,"requestData":"{}"}`, serverID)
+	w := httptest.NewRecorder()
+	c.HandleCreateRequest(w, httptest.NewRequest(http.MethodPost, "/api/requests", strings.NewReader(body)))
+	var started struct {
+		Status      string `json:"status"`
+		ExecutionID int64  `json:"executionId"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &started); err != nil || started.Status != "started" {
+		t.Fatalf("Expected a started execution, got %s", w.Body.String())
+	}
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the request to reach the backend")
+	}
+
+	cancel := func(id string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/", nil), map[string]string{"id": id})
+		c.HandleCancelExecution(rec, req)
+		return rec
+	}
+
+	rec := cancel(fmt.Sprint(started.ExecutionID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result struct {
+		Status    string        `json:"status"`
+		Execution store.Request `json:"execution"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Status != "cancelled" || result.Execution.Status != store.RequestStatusCancelled || result.Execution.Error == "" {
+		t.Errorf("Expected the execution to be saved as cancelled, got %s %+v", result.Status, result.Execution)
+	}
+
+	if rec := cancel(fmt.Sprint(started.ExecutionID)); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for an execution that is no longer running, got %d", rec.Code)
+	}
+	if rec := cancel("999"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing execution, got %d", rec.Code)
+	}
+}
+
+func TestLinkExecutionLogs(t *testing.T) {
+	c := newTestController(t)
+
+	serverID, err := c.store.CreateServer(&store.Server{Name: "gateway", URL: "http://gateway", ResponseTraceHeader: "X-Gateway-Request-Id"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	sid := uint(serverID)
+	executedAt := time.Now().Add(-time.Minute)
+	execID, err := c.store.CreateRequest(&store.Request{
+		ServerID:        &sid,
+		RequestIDHeader: "abc12345",
+		ResponseHeaders: `{"X-Gateway-Request-Id":["gw-999"]}`,
+		DurationMS:      200,
+		Status:          store.RequestStatusCompleted,
+		ExecutedAt:      executedAt,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create execution: %v", err)
+	}
+
+	message := func(container, text, requestID string, at time.Time) *logs.ContainerMessage {
+		return &logs.ContainerMessage{
+			ContainerID: container,
+			Timestamp:   at,
+			Entry:       &logs.LogEntry{Message: text, Raw: text, Fields: map[string]string{"request_id": requestID}},
+		}
+	}
+	collected := message("api", "collected in time", "abc12345", executedAt.Add(50*time.Millisecond))
+	if err := c.store.SaveRequestLogs(execID, []logs.ContainerMessage{*collected}); err != nil {
+		t.Fatalf("Failed to save logs: %v", err)
+	}
+	c.logStore.Add(collected)
+	c.logStore.Add(message("api", "[sql]: SELECT * FROM users WHERE id = 1", "abc12345", executedAt.Add(time.Second)))
+	c.logStore.Add(message("edge", "logged under the gateway id", "gw-999", executedAt.Add(2*time.Second)))
+	c.logStore.Add(message("api", "too late", "abc12345", executedAt.Add(45*time.Second)))
+	c.logStore.Add(message("api", "another request", "other", executedAt.Add(time.Second)))
+
+	link := func(id, query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/?"+query, nil), map[string]string{"id": id})
+		c.HandleLinkExecutionLogs(rec, req)
+		return rec
+	}
+
+	rec := link(fmt.Sprint(execID), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result LinkLogsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Linked != 2 || result.AlreadyLinked != 1 || result.QueryCount != 1 {
+		t.Errorf("Expected 2 logs linked, 1 already linked and 1 query, got %+v", result)
+	}
+
+	saved, err := c.store.GetRequestLogs(execID)
+	if err != nil || len(saved) != 3 {
+		t.Fatalf("Expected 3 saved logs, got %d (%v)", len(saved), err)
+	}
+	queries, err := c.store.GetSQLQueries(execID)
+	if err != nil || len(queries) != 1 {
+		t.Errorf("Expected the linked query to be saved, got %d (%v)", len(queries), err)
+	}
+
+	// Linking again finds nothing new, while a wider window reaches the late log
+	if rec := link(fmt.Sprint(execID), ""); !strings.Contains(rec.Body.String(), `"linked":0`) {
+		t.Errorf("Expected nothing to link a second time, got %s", rec.Body.String())
+	}
+	if rec := link(fmt.Sprint(execID), "window=60"); !strings.Contains(rec.Body.String(), `"linked":1`) {
+		t.Errorf("Expected the wider window to link the late log, got %s", rec.Body.String())
+	}
+
+	if rec := link(fmt.Sprint(execID), "window=-1"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a negative window, got %d", rec.Code)
+	}
+	if rec := link("999", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing execution, got %d", rec.Code)
+	}
+}
+
+func TestLinkExecutionLogsDuringLogBatches(t *testing.T) {
+	c := newTestController(t)
+
+	executedAt := time.Now().Add(-time.Minute)
+	execID, err := c.store.CreateRequest(&store.Request{
+		RequestIDHeader: "abc12345",
+		Status:          store.RequestStatusCompleted,
+		ExecutedAt:      executedAt,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create execution: %v", err)
+	}
+
+	// Each link finds one new log, so it reports its progress from the handler goroutine
+	i := 0
+	send := func() {
+		i++
+		c.logStore.Add(&logs.ContainerMessage{
+			ContainerID: "api",
+			Timestamp:   executedAt.Add(time.Duration(i) * time.Millisecond),
+			Entry:       &logs.LogEntry{Message: fmt.Sprintf("line %d", i), Fields: map[string]string{"request_id": "abc12345"}},
+		})
+		rec := httptest.NewRecorder()
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/", nil), map[string]string{"id": fmt.Sprint(execID)})
+		c.HandleLinkExecutionLogs(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	if got := broadcastDuringBatches(t, c, "sql_progress", 50, send); got != 50 {
+		t.Errorf("Expected a sql_progress message for each of 50 links, got %d", got)
+	}
+}
+
+func TestExecutionsQueueBeyondLimit(t *testing.T) {
+	c := newTestController(t)
+	c.SetMaxConcurrentExecutions(1)
+
+	var hits atomic.Int32
+	received := make(chan struct{}, 2)
+	release := make(chan struct{})
+	upstream := newReachableServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		received <- struct{}{}
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer upstream.Close()
+
+	serverID, err := c.store.CreateServer(&store.Server{Name: "slow", URL: upstream.URL})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	start := func() (string, int64) {
+		t.Helper()
+		body := fmt.Sprintf(`{"serverId":%d,"requestData":"{}"}`, serverID)
+		w := httptest.NewRecorder()
+		c.HandleCreateRequest(w, httptest.NewRequest(http.MethodPost, "/api/requests", strings.NewReader(body)))
+		var result struct {
+			Status      string `json:"status"`
+			ExecutionID int64  `json:"executionId"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to decode response %q: %v", w.Body.String(), err)
+		}
+		return result.Status, result.ExecutionID
+	}
+	queue := func() ExecutionQueue {
+		rec := httptest.NewRecorder()
+		c.HandleExecutionQueue(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		var queue ExecutionQueue
+		json.Unmarshal(rec.Body.Bytes(), &queue)
+		return queue
+	}
+
+	if status, _ := start(); status != "started" {
+		t.Fatalf("Expected the first execution to start, got %s", status)
+	}
+	<-received
+
+	status, queuedID := start()
+	if status != "queued" {
+		t.Fatalf("Expected the second execution to be queued, got %s", status)
+	}
+	if got := queue(); got != (ExecutionQueue{Running: 1, Queued: 1, Limit: 1}) {
+		t.Errorf("Unexpected queue: %+v", got)
+	}
+
+	rec := httptest.NewRecorder()
+	c.HandleGetRequestDetail(rec, mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"id": fmt.Sprint(queuedID)}))
+	var detail store.RequestDetailResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil || detail.Execution.Status != store.RequestStatusPending {
+		t.Errorf("Expected the detail to show the execution as queued, got %s", rec.Body.String())
+	}
+
+	// A third execution waits its turn and runs once the first finishes
+	if status, _ := start(); status != "queued" {
+		t.Fatalf("Expected the third execution to be queued, got %s", status)
+	}
+
+	// Cancelling a queued execution means it is never sent
+	rec = httptest.NewRecorder()
+	c.HandleCancelExecution(rec, mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/", nil), map[string]string{"id": fmt.Sprint(queuedID)}))
+	if !strings.Contains(rec.Body.String(), `"status":"cancelled"`) {
+		t.Errorf("Expected the queued execution to be cancelled, got %s", rec.Body.String())
+	}
+
+	close(release)
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the third execution to be sent")
+	}
+	if got := hits.Load(); got != 2 {
+		t.Errorf("Expected 2 requests to reach the backend, got %d", got)
+	}
+}
+
+func TestCompareExecutions(t *testing.T) {
+	c := newTestController(t)
+
+	serverID, err := c.store.CreateServer(&store.Server{Name: "staging", URL: "http://staging"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	server := uint(serverID)
+
+	var ids []int64
+	for i, durationMS := range []float64{10, 40} {
+		id, err := c.store.CreateRequest(&store.Request{
+			ServerID:        &server,
+			RequestIDHeader: fmt.Sprintf("run-%d", i),
+			StatusCode:      200,
+			ExecutedAt:      time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		queries := []store.SQLQuery{{
+			Query:           `SELECT * FROM "users" WHERE id = 1`,
+			NormalizedQuery: `SELECT * FROM "users" WHERE id = ?`,
+			QueriedTable:    "users",
+			Operation:       "SELECT",
+			DurationMS:      durationMS,
+		}}
+		if i == 1 {
+			queries = append(queries, store.SQLQuery{Query: `SELECT * FROM "posts"`, NormalizedQuery: `SELECT * FROM "posts"`, DurationMS: 1})
+		}
+		if err := c.store.SaveSQLQueries(id, queries); err != nil {
+			t.Fatalf("Failed to save SQL queries: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	compare := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c.HandleCompareExecutions(rec, httptest.NewRequest(http.MethodGet, "/api/executions/compare?"+query, nil))
+		return rec
+	}
+
+	rec := compare(fmt.Sprintf("a=%d&b=%d", ids[0], ids[1]))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var report store.ComparisonReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	if report.Execution1.ID != uint(ids[0]) || report.Execution2.QueryCount != 2 || report.Execution1.Server != "staging" {
+		t.Errorf("Unexpected execution summaries: %+v %+v", report.Execution1, report.Execution2)
+	}
+	if len(report.Regressions) != 1 || report.Regressions[0].DurationDiffPct != 300 {
+		t.Errorf("Expected the users query as a 300%% regression, got %+v", report.Regressions)
+	}
+	if len(report.Comparison.QueriesOnlyInSet2) != 1 || report.IndexAnalysis1 == nil || report.IndexAnalysis2 == nil {
+		t.Errorf("Expected the posts query only in b and both index analyses, got %+v", report.Comparison)
+	}
+
+	if rec := compare(fmt.Sprintf("a=%d&b=999", ids[0])); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing execution, got %d", rec.Code)
+	}
+	if rec := compare("a=1"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without b, got %d", rec.Code)
+	}
+}
+
+func TestSlowestExecutions(t *testing.T) {
+	c := newTestController(t)
+
+	for i, ago := range []time.Duration{time.Minute, 2 * time.Hour} {
+		if _, err := c.store.CreateRequest(&store.Request{
+			RequestIDHeader: fmt.Sprintf("run-%d", i),
+			DurationMS:      int64(100 * (i + 1)),
+			ExecutedAt:      time.Now().Add(-ago),
+		}); err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+	}
+
+	slowest := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c.HandleSlowestExecutions(rec, httptest.NewRequest(http.MethodGet, "/api/executions/slowest?"+query, nil))
+		return rec
+	}
+
+	rec := slowest("since=1h")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var executions []store.Request
+	if err := json.Unmarshal(rec.Body.Bytes(), &executions); err != nil {
+		t.Fatalf("Failed to decode executions: %v", err)
+	}
+	if len(executions) != 1 || executions[0].RequestIDHeader != "run-0" {
+		t.Errorf("Expected only the run within the hour, got %+v", executions)
+	}
+
+	if rec := slowest(""); !strings.Contains(rec.Body.String(), `"requestIdHeader":"run-1"`) {
+		t.Errorf("Expected the default window of a day to include both runs, got %s", rec.Body.String())
+	}
+	if rec := slowest("since=yesterday"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid since, got %d", rec.Code)
+	}
+}
+
+func TestExportExecutions(t *testing.T) {
+	c := newTestController(t)
+
+	sampleID, err := c.store.CreateSampleQuery(&store.SampleQuery{Name: "Nightly", RequestData: `{"id": 1}`})
+	if err != nil {
+		t.Fatalf("Failed to create sample query: %v", err)
+	}
+	sample := uint(sampleID)
+	for i := range 150 {
+		body := `{"query": "query FetchUsers { users { id } }"}`
+		var sampleRef *uint
+		if i%3 == 0 {
+			body, sampleRef = `{"id": 1}`, &sample
+		}
+		if _, err := c.store.CreateRequest(&store.Request{
+			SampleID:        sampleRef,
+			RequestIDHeader: fmt.Sprintf("export-%03d", i),
+			RequestBody:     body,
+			ExecutedAt:      time.Now().Add(time.Duration(i) * time.Second),
+		}); err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+	}
+
+	export := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c.HandleExportExecutions(rec, httptest.NewRequest(http.MethodGet, "/api/executions/export?"+query, nil))
+		return rec
+	}
+
+	rec := export("format=ndjson")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" || !rec.Flushed {
+		t.Fatalf("Expected a flushed NDJSON stream, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 150 {
+		t.Fatalf("Expected 150 lines, got %d", len(lines))
+	}
+	var newest, sampled store.Request
+	if err := json.Unmarshal([]byte(lines[0]), &newest); err != nil {
+		t.Fatalf("Failed to decode line: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &sampled); err != nil {
+		t.Fatalf("Failed to decode line: %v", err)
+	}
+	if newest.RequestIDHeader != "export-149" || newest.DisplayName != "FetchUsers" || sampled.DisplayName != "Nightly" {
+		t.Errorf("Expected the newest first with display names, got %q %q and %q", newest.RequestIDHeader, newest.DisplayName, sampled.DisplayName)
+	}
+
+	if rec := export("search=export-01"); strings.Count(rec.Body.String(), "\n") != 10 {
+		t.Errorf("Expected the search filter to match 10 executions, got %s", rec.Body.String())
+	}
+	if rec := export("format=csv"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unsupported format, got %d", rec.Code)
+	}
+}
+
+func TestExecutionUpdateDuringLogBatches(t *testing.T) {
+	c := newTestController(t)
+	execution := &store.Request{ID: 1, Status: store.RequestStatusCompleted, StatusCode: 200}
+
+	// executeRequest reports from its own goroutine while processLogs sends batches
+	send := func() { c.broadcastExecutionUpdate(execution, 3) }
+	if got := broadcastDuringBatches(t, c, "execution_update", 200, send); got != 200 {
+		t.Errorf("Expected all 200 execution_update messages, got %d", got)
+	}
+}
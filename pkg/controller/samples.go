@@ -3,8 +3,10 @@ package controller
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"docker-log-parser/pkg/store"
 
@@ -14,13 +16,13 @@ import (
 // HandleListSampleQueries lists all saved requests
 func (c *Controller) HandleListSampleQueries(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	requests, err := c.store.ListSampleQueries()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 
@@ -31,7 +33,7 @@ func (c *Controller) HandleListSampleQueries(w http.ResponseWriter, r *http.Requ
 // HandleCreateSampleQuery creates a new request
 func (c *Controller) HandleCreateSampleQuery(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
@@ -44,8 +46,7 @@ func (c *Controller) HandleCreateSampleQuery(w http.ResponseWriter, r *http.Requ
 		RequestData string `json:"requestData"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !c.decodeJSONBody(w, r, &input) {
 		return
 	}
 
@@ -62,7 +63,7 @@ func (c *Controller) HandleCreateSampleQuery(w http.ResponseWriter, r *http.Requ
 
 		sid, err := c.store.CreateServer(server)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to create server: %v", err), http.StatusInternalServerError)
+			writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, fmt.Sprintf("Failed to create server: %v", err))
 			return
 		}
 		sidUint := uint(sid)
@@ -77,7 +78,7 @@ func (c *Controller) HandleCreateSampleQuery(w http.ResponseWriter, r *http.Requ
 
 	id, err := c.store.CreateSampleQuery(req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 
@@ -88,24 +89,24 @@ func (c *Controller) HandleCreateSampleQuery(w http.ResponseWriter, r *http.Requ
 // HandleGetSampleQuery gets a request by ID
 func (c *Controller) HandleGetSampleQuery(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid request ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid request ID")
 		return
 	}
 
 	req, err := c.store.GetSampleQuery(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	if req == nil {
-		http.Error(w, "Request not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Request not found")
 		return
 	}
 
@@ -113,22 +114,112 @@ func (c *Controller) HandleGetSampleQuery(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(req)
 }
 
+// HandleListSampleExecutions lists a sample query's executions compactly, with their
+// SQL query counts, for picking two runs to compare
+func (c *Controller) HandleListSampleExecutions(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid request ID")
+		return
+	}
+
+	req, err := c.store.GetSampleQuery(id)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	if req == nil {
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Request not found")
+		return
+	}
+
+	params := c.decodePageParams(r, defaultListLimit)
+
+	executions, err := c.store.WithContext(r.Context()).ListExecutionsForComparison(id)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	writeList(w, executions, params)
+}
+
+// minTrendBucket is the smallest bucket a sample query's trend can be grouped by
+const minTrendBucket = time.Minute
+
+// HandleSampleTrend returns a sample query's execution durations over time, grouped into
+// buckets for charting
+func (c *Controller) HandleSampleTrend(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid request ID")
+		return
+	}
+
+	type QueryParams struct {
+		Bucket string `schema:"bucket"` // Bucket size, as a duration
+	}
+
+	params := QueryParams{
+		Bucket: "1h",
+	}
+
+	if err := c.decoder.Decode(&params, r.URL.Query()); err != nil {
+		slog.Warn("failed to decode query parameters", "error", err)
+	}
+	bucket, err := time.ParseDuration(params.Bucket)
+	if err != nil || bucket < minTrendBucket {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "bucket must be a duration of at least 1m, such as 1h or 24h")
+		return
+	}
+
+	req, err := c.store.GetSampleQuery(id)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	if req == nil {
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Request not found")
+		return
+	}
+
+	buckets, err := c.store.WithContext(r.Context()).ExecutionDurationBuckets(id, bucket)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buckets)
+}
+
 // HandleDeleteSampleQuery deletes a request
 func (c *Controller) HandleDeleteSampleQuery(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid request ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid request ID")
 		return
 	}
 
 	if err := c.store.DeleteSampleQuery(id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 
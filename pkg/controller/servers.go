@@ -2,9 +2,14 @@ package controller
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 
+	"docker-log-parser/pkg/httputil"
 	"docker-log-parser/pkg/store"
 
 	"github.com/gorilla/mux"
@@ -13,89 +18,319 @@ import (
 // HandleListServers lists all servers
 func (c *Controller) HandleListServers(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
+	params := c.decodePageParams(r, defaultListLimit)
+
 	servers, err := c.store.ListServers()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(servers)
+	writeList(w, servers, params)
+}
+
+// HandleListActiveServers lists the servers that have executions, with their execution
+// counts, for filtering executions by server without listing unused servers
+func (c *Controller) HandleListActiveServers(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	params := c.decodePageParams(r, defaultListLimit)
+
+	servers, err := c.store.WithContext(r.Context()).ListServersWithExecutions()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	writeList(w, servers, params)
 }
 
 // HandleCreateServer creates a new server
 func (c *Controller) HandleCreateServer(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	var server store.Server
-	if err := json.NewDecoder(r.Body).Decode(&server); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !c.decodeJSONBody(w, r, &server) {
+		return
+	}
+	if !httputil.ValidCorrelationFormat(server.CorrelationFormat) {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid correlation format")
 		return
 	}
 	id, err := c.store.CreateServer(&server)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]int64{"id": id})
 }
 
+// ServerFieldDiff describes a single configuration field compared between two servers
+type ServerFieldDiff struct {
+	Field string `json:"field"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+	Equal bool   `json:"equal"`
+}
+
+// ServerComparison is the field-level diff of two server configurations
+type ServerComparison struct {
+	A      store.Server      `json:"a"`
+	B      store.Server      `json:"b"`
+	Fields []ServerFieldDiff `json:"fields"`
+}
+
+// HandleCompareServers returns a field-level diff of two server configurations with secrets masked
+func (c *Controller) HandleCompareServers(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	type QueryParams struct {
+		A int64 `schema:"a,required"`
+		B int64 `schema:"b,required"`
+	}
+
+	var params QueryParams
+	if err := c.decoder.Decode(&params, r.URL.Query()); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "a and b server IDs required")
+		return
+	}
+
+	servers := make([]*store.Server, 0, 2)
+	for _, id := range []int64{params.A, params.B} {
+		server, err := c.store.GetServer(id)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+			return
+		}
+		if server == nil {
+			writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Server not found")
+			return
+		}
+		servers = append(servers, server)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(compareServers(servers[0], servers[1]))
+}
+
+// compareServers diffs two servers field by field. Equality is decided on the real values,
+// but secrets are masked in the output.
+func compareServers(a, b *store.Server) ServerComparison {
+	fields := []struct {
+		name   string
+		value  func(s *store.Server) string
+		secret bool
+	}{
+		{"name", func(s *store.Server) string { return s.Name }, false},
+		{"url", func(s *store.Server) string { return s.URL }, false},
+		{"bearerToken", func(s *store.Server) string { return s.BearerToken }, true},
+		{"devId", func(s *store.Server) string { return s.DevID }, false},
+		{"experimentalMode", func(s *store.Server) string { return s.ExperimentalMode }, false},
+		{"responseTraceHeader", func(s *store.Server) string { return s.ResponseTraceHeader }, false},
+		{"correlationHeader", func(s *store.Server) string { return s.CorrelationHeader }, false},
+		{"correlationFormat", func(s *store.Server) string { return s.CorrelationFormat }, false},
+		{"nameField", func(s *store.Server) string { return s.NameField }, false},
+		{"buildVersionHeader", func(s *store.Server) string { return s.BuildVersionHeader }, false},
+		{"buildVersion", func(s *store.Server) string { return s.BuildVersion }, false},
+		{"defaultDatabase.name", func(s *store.Server) string {
+			if s.DefaultDatabase == nil {
+				return ""
+			}
+			return s.DefaultDatabase.Name
+		}, false},
+		{"defaultDatabase.databaseType", func(s *store.Server) string {
+			if s.DefaultDatabase == nil {
+				return ""
+			}
+			return s.DefaultDatabase.DatabaseType
+		}, false},
+		{"defaultDatabase.connectionString", func(s *store.Server) string {
+			if s.DefaultDatabase == nil {
+				return ""
+			}
+			return s.DefaultDatabase.ConnectionString
+		}, true},
+	}
+
+	comparison := ServerComparison{
+		A:      maskServer(*a),
+		B:      maskServer(*b),
+		Fields: make([]ServerFieldDiff, 0, len(fields)),
+	}
+
+	for _, f := range fields {
+		valueA, valueB := f.value(a), f.value(b)
+		diff := ServerFieldDiff{
+			Field: f.name,
+			A:     valueA,
+			B:     valueB,
+			Equal: valueA == valueB,
+		}
+		if f.secret {
+			if f.name == "defaultDatabase.connectionString" {
+				diff.A, diff.B = maskConnectionString(valueA), maskConnectionString(valueB)
+			} else {
+				diff.A, diff.B = maskSecret(valueA), maskSecret(valueB)
+			}
+		}
+		comparison.Fields = append(comparison.Fields, diff)
+	}
+
+	return comparison
+}
+
+// maskServer returns a copy of the server with secrets masked
+func maskServer(server store.Server) store.Server {
+	server.BearerToken = maskSecret(server.BearerToken)
+	if server.DefaultDatabase != nil {
+		db := *server.DefaultDatabase
+		db.ConnectionString = maskConnectionString(db.ConnectionString)
+		server.DefaultDatabase = &db
+	}
+	return server
+}
+
+// maskSecret hides a secret, keeping the last 4 characters of long values so they can be told apart
+func maskSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= 8 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
+
+// maskConnectionString hides the password in a database connection URL.
+// Strings that are not URLs (e.g. key=value DSNs) are masked entirely.
+func maskConnectionString(connStr string) string {
+	if connStr == "" {
+		return ""
+	}
+	u, err := url.Parse(connStr)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return maskSecret(connStr)
+	}
+	return u.Redacted()
+}
+
 // HandleGetServer gets a server by ID
 func (c *Controller) HandleGetServer(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid server ID")
 		return
 	}
 
 	server, err := c.store.GetServer(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	if server == nil {
-		http.Error(w, "Server not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Server not found")
 		return
 	}
+	server.Warnings = serverWarnings(server)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(server)
 }
 
+// localHosts are hostnames that all refer to the machine running the viewer
+var localHosts = []string{"localhost", "127.0.0.1", "::1", "0.0.0.0", "host.docker.internal"}
+
+// serverWarnings runs best-effort sanity checks on a server configuration
+func serverWarnings(server *store.Server) []string {
+	var warnings []string
+
+	if server.DefaultDatabase != nil {
+		serverHost := urlHostname(server.URL)
+		dbHost := connectionStringHost(server.DefaultDatabase.ConnectionString)
+		if serverHost != "" && dbHost != "" && !sameHost(serverHost, dbHost) {
+			warnings = append(warnings, fmt.Sprintf(
+				"default database %q is on host %s but the server is on %s; EXPLAIN may run against the wrong database",
+				server.DefaultDatabase.Name, dbHost, serverHost,
+			))
+		}
+	}
+
+	return warnings
+}
+
+// urlHostname returns the lowercased hostname of a URL, or "" if it cannot be parsed
+func urlHostname(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// connectionStringHost extracts the host from a database connection URL or key=value DSN
+func connectionStringHost(connStr string) string {
+	if host := urlHostname(connStr); host != "" {
+		return host
+	}
+	for _, part := range strings.Fields(connStr) {
+		if host, ok := strings.CutPrefix(part, "host="); ok {
+			return strings.ToLower(strings.Trim(host, "'\""))
+		}
+	}
+	return ""
+}
+
+// sameHost reports whether two hostnames refer to the same machine
+func sameHost(a, b string) bool {
+	if a == b {
+		return true
+	}
+	return slices.Contains(localHosts, a) && slices.Contains(localHosts, b)
+}
+
 // HandleUpdateServer updates a server
 func (c *Controller) HandleUpdateServer(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid server ID")
 		return
 	}
 
 	var server store.Server
-	if err := json.NewDecoder(r.Body).Decode(&server); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !c.decodeJSONBody(w, r, &server) {
+		return
+	}
+	if !httputil.ValidCorrelationFormat(server.CorrelationFormat) {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid correlation format")
 		return
 	}
 	server.ID = uint(id)
 	if err := c.store.UpdateServer(&server); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -104,19 +339,19 @@ func (c *Controller) HandleUpdateServer(w http.ResponseWriter, r *http.Request)
 // HandleDeleteServer deletes a server
 func (c *Controller) HandleDeleteServer(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid server ID")
 		return
 	}
 
 	if err := c.store.DeleteServer(id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -125,34 +360,35 @@ func (c *Controller) HandleDeleteServer(w http.ResponseWriter, r *http.Request)
 // HandleListDatabaseURLs lists all database URLs
 func (c *Controller) HandleListDatabaseURLs(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
+	params := c.decodePageParams(r, defaultListLimit)
+
 	dbURLs, err := c.store.ListDatabaseURLs()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(dbURLs)
+	writeList(w, dbURLs, params)
 }
 
 // HandleCreateDatabaseURL creates a new database URL
 func (c *Controller) HandleCreateDatabaseURL(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	var dbURL store.Database
 	if err := json.NewDecoder(r.Body).Decode(&dbURL); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
 		return
 	}
 	id, err := c.store.CreateDatabaseURL(&dbURL)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -162,24 +398,24 @@ func (c *Controller) HandleCreateDatabaseURL(w http.ResponseWriter, r *http.Requ
 // HandleGetDatabaseURL gets a database URL by ID
 func (c *Controller) HandleGetDatabaseURL(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid database URL ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid database URL ID")
 		return
 	}
 
 	dbURL, err := c.store.GetDatabaseURL(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	if dbURL == nil {
-		http.Error(w, "Database URL not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Database URL not found")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -189,25 +425,25 @@ func (c *Controller) HandleGetDatabaseURL(w http.ResponseWriter, r *http.Request
 // HandleUpdateDatabaseURL updates a database URL
 func (c *Controller) HandleUpdateDatabaseURL(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid database URL ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid database URL ID")
 		return
 	}
 
 	var dbURL store.Database
 	if err := json.NewDecoder(r.Body).Decode(&dbURL); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
 		return
 	}
 	dbURL.ID = uint(id)
 	if err := c.store.UpdateDatabaseURL(&dbURL); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -216,19 +452,19 @@ func (c *Controller) HandleUpdateDatabaseURL(w http.ResponseWriter, r *http.Requ
 // HandleDeleteDatabaseURL deletes a database URL
 func (c *Controller) HandleDeleteDatabaseURL(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid database URL ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid database URL ID")
 		return
 	}
 
 	if err := c.store.DeleteDatabaseURL(id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Snapshot defaults and limits
+const (
+	DefaultSnapshotDir   = "snapshots"
+	DefaultSnapshotLimit = 10000 // As many logs as a client is sent when it connects
+	maxSnapshotLimit     = 100000
+)
+
+// snapshotIDPattern matches the IDs newSnapshotID generates, so a download can't name a
+// file outside the snapshot directory
+var snapshotIDPattern = regexp.MustCompile(`^\d{8}-\d{6}-[0-9a-f]{8}$`)
+
+// LogSnapshotRequest is the body for taking a log snapshot
+type LogSnapshotRequest struct {
+	Filter ClientFilter `json:"filter"`
+	Limit  int          `json:"limit,omitempty"` // Most recent logs to keep; 0 for DefaultSnapshotLimit
+}
+
+// LogSnapshot is the file a snapshot is saved as: the logs a client with Filter saw when
+// it was taken, with what's needed to make sense of them later
+type LogSnapshot struct {
+	ID         string         `json:"id"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	Filter     ClientFilter   `json:"filter"`
+	Count      int            `json:"count"`
+	Truncated  bool           `json:"truncated"`      // More logs matched than the limit; the oldest were left out
+	From       *time.Time     `json:"from,omitempty"` // Timestamp of the oldest log
+	To         *time.Time     `json:"to,omitempty"`   // Timestamp of the newest log
+	Containers map[string]int `json:"containers"`     // Logs per container name
+	Levels     map[string]int `json:"levels"`         // Logs per level, NONE for lines without one
+	Logs       []LogWSMessage `json:"logs"`           // Oldest first
+}
+
+// LogSnapshotInfo describes a snapshot that was taken and where to download it
+type LogSnapshotInfo struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Count     int       `json:"count"`
+	Truncated bool      `json:"truncated"`
+	URL       string    `json:"url"`
+}
+
+// SetSnapshotDir sets the directory log snapshots are written to; empty restores the default
+func (c *Controller) SetSnapshotDir(dir string) {
+	if dir == "" {
+		dir = DefaultSnapshotDir
+	}
+	c.snapshotDir = dir
+}
+
+// HandleCreateLogSnapshot saves the logs matching a filter, as a client with that filter
+// sees them now, to a file and returns its download link
+func (c *Controller) HandleCreateLogSnapshot(w http.ResponseWriter, r *http.Request) {
+	var input LogSnapshotRequest
+	if !c.decodeJSONBody(w, r, &input) {
+		return
+	}
+	if input.Limit < 0 || input.Limit > maxSnapshotLimit {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, fmt.Sprintf("limit must be between 0 and %d", maxSnapshotLimit))
+		return
+	}
+	if input.Limit == 0 {
+		input.Limit = DefaultSnapshotLimit
+	}
+
+	snapshot, err := c.takeLogSnapshot(input.Filter, input.Limit)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	if err := c.writeLogSnapshot(snapshot); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(LogSnapshotInfo{
+		ID:        snapshot.ID,
+		CreatedAt: snapshot.CreatedAt,
+		Count:     snapshot.Count,
+		Truncated: snapshot.Truncated,
+		URL:       "/api/logs/snapshots/" + snapshot.ID,
+	})
+}
+
+// HandleDownloadLogSnapshot serves a snapshot file as an attachment
+func (c *Controller) HandleDownloadLogSnapshot(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if !snapshotIDPattern.MatchString(id) {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid snapshot ID")
+		return
+	}
+
+	f, err := os.Open(c.snapshotPath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "Snapshot not found")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="logs-%s.json"`, id))
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+// SaveShutdownSnapshot saves the most recent logs, unfiltered, so they can still be read
+// after the viewer exits. It returns the snapshot ID, or "" when there were no logs.
+func (c *Controller) SaveShutdownSnapshot() (string, error) {
+	snapshot, err := c.takeLogSnapshot(ClientFilter{}, DefaultSnapshotLimit)
+	if err != nil {
+		return "", err
+	}
+	if snapshot.Count == 0 {
+		return "", nil
+	}
+	if err := c.writeLogSnapshot(snapshot); err != nil {
+		return "", err
+	}
+	return snapshot.ID, nil
+}
+
+// takeLogSnapshot collects the most recent limit logs matching filter
+func (c *Controller) takeLogSnapshot(filter ClientFilter, limit int) (*LogSnapshot, error) {
+	id, err := newSnapshotID(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// One more than the limit tells whether any were left out
+	logMessages := c.filteredLogs(filter, limit+1)
+	snapshot := &LogSnapshot{
+		ID:         id,
+		CreatedAt:  time.Now().UTC(),
+		Filter:     filter,
+		Truncated:  len(logMessages) > limit,
+		Containers: make(map[string]int),
+		Levels:     make(map[string]int),
+	}
+	if snapshot.Truncated {
+		logMessages = logMessages[1:]
+	}
+	snapshot.Logs = logMessages
+	snapshot.Count = len(logMessages)
+
+	if len(logMessages) > 0 {
+		snapshot.From = &logMessages[0].Timestamp
+		snapshot.To = &logMessages[len(logMessages)-1].Timestamp
+	}
+
+	c.containerMutex.RLock()
+	for _, msg := range logMessages {
+		name := c.containerIDNames[msg.ContainerID]
+		if name == "" {
+			name = msg.ContainerID
+		}
+		snapshot.Containers[name]++
+
+		level := "NONE"
+		if msg.Entry != nil && msg.Entry.Level != "" {
+			level = strings.ToUpper(msg.Entry.Level)
+		}
+		snapshot.Levels[level]++
+	}
+	c.containerMutex.RUnlock()
+
+	return snapshot, nil
+}
+
+// writeLogSnapshot saves a snapshot to the snapshot directory
+func (c *Controller) writeLogSnapshot(snapshot *LogSnapshot) error {
+	if err := os.MkdirAll(c.snapshotDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	if err := os.WriteFile(c.snapshotPath(snapshot.ID), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+func (c *Controller) snapshotPath(id string) string {
+	return filepath.Join(c.snapshotDir, id+".json")
+}
+
+// newSnapshotID returns an ID that sorts by the time the snapshot was taken
+func newSnapshotID(now time.Time) (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate snapshot ID: %w", err)
+	}
+	return now.UTC().Format("20060102-150405") + "-" + hex.EncodeToString(b), nil
+}
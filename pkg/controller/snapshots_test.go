@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"docker-log-parser/pkg/logs"
+
+	"github.com/gorilla/mux"
+)
+
+func TestLogSnapshotRoundTrip(t *testing.T) {
+	c := newTestController(t)
+	c.SetSnapshotDir(t.TempDir())
+	c.SetContainers([]logs.Container{
+		{ID: "c1", Name: "api"},
+		{ID: "c2", Name: "worker"},
+	})
+
+	start := time.Now().Add(-time.Minute)
+	for i, msg := range []logs.ContainerMessage{
+		{ContainerID: "c1", Entry: &logs.LogEntry{Level: "ERR", Message: "first failure"}},
+		{ContainerID: "c1", Entry: &logs.LogEntry{Level: "INF", Message: "ok"}},
+		{ContainerID: "c2", Entry: &logs.LogEntry{Message: "second failure"}},
+		{ContainerID: "c1", Entry: &logs.LogEntry{Level: "ERR", Message: "third failure"}},
+		{ContainerID: "c2", Entry: &logs.LogEntry{Level: "err", Message: "fourth failure"}},
+	} {
+		msg.Timestamp = start.Add(time.Duration(i) * time.Second)
+		c.logStore.Add(&msg)
+	}
+
+	body, _ := json.Marshal(LogSnapshotRequest{Filter: ClientFilter{SearchQuery: "failure"}, Limit: 3})
+	rec := httptest.NewRecorder()
+	c.HandleCreateLogSnapshot(rec, httptest.NewRequest(http.MethodPost, "/api/logs/snapshot", bytes.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var info LogSnapshotInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if info.Count != 3 || !info.Truncated {
+		t.Errorf("Expected 3 logs flagged truncated, got %+v", info)
+	}
+	if info.URL != "/api/logs/snapshots/"+info.ID {
+		t.Errorf("Expected a download link for %s, got %q", info.ID, info.URL)
+	}
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, info.URL, nil), map[string]string{"id": info.ID})
+	rec = httptest.NewRecorder()
+	c.HandleDownloadLogSnapshot(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Header().Get("Content-Disposition"), "attachment") {
+		t.Error("Expected the snapshot to be served as an attachment")
+	}
+
+	var snapshot LogSnapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &snapshot); err != nil {
+		t.Fatalf("Failed to decode snapshot: %v", err)
+	}
+	if snapshot.Filter.SearchQuery != "failure" {
+		t.Errorf("Expected the filter to be recorded, got %+v", snapshot.Filter)
+	}
+	var messages []string
+	for _, msg := range snapshot.Logs {
+		messages = append(messages, msg.Entry.Message)
+	}
+	if got := strings.Join(messages, ","); got != "second failure,third failure,fourth failure" {
+		t.Errorf("Expected the most recent matches oldest first, got %s", got)
+	}
+	if snapshot.Containers["api"] != 1 || snapshot.Containers["worker"] != 2 {
+		t.Errorf("Expected logs counted by container name, got %v", snapshot.Containers)
+	}
+	if snapshot.Levels["ERR"] != 2 || snapshot.Levels["NONE"] != 1 {
+		t.Errorf("Expected logs counted by level, got %v", snapshot.Levels)
+	}
+	if snapshot.From == nil || snapshot.To == nil || !snapshot.To.After(*snapshot.From) {
+		t.Errorf("Expected the time range of the logs, got %v to %v", snapshot.From, snapshot.To)
+	}
+}
+
+func TestLogSnapshotValidation(t *testing.T) {
+	c := newTestController(t)
+	c.SetSnapshotDir(t.TempDir())
+
+	body, _ := json.Marshal(LogSnapshotRequest{Limit: maxSnapshotLimit + 1})
+	rec := httptest.NewRecorder()
+	c.HandleCreateLogSnapshot(rec, httptest.NewRequest(http.MethodPost, "/api/logs/snapshot", bytes.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a limit over the maximum, got %d", rec.Code)
+	}
+
+	for id, want := range map[string]int{
+		"../../etc/passwd":         http.StatusBadRequest,
+		"20240301-120000-deadbeef": http.StatusNotFound,
+	} {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/logs/snapshots/x", nil), map[string]string{"id": id})
+		rec := httptest.NewRecorder()
+		c.HandleDownloadLogSnapshot(rec, req)
+		if rec.Code != want {
+			t.Errorf("Expected status %d for %q, got %d", want, id, rec.Code)
+		}
+	}
+}
+
+func TestSaveShutdownSnapshotSkipsEmptyStore(t *testing.T) {
+	c := newTestController(t)
+	dir := t.TempDir()
+	c.SetSnapshotDir(dir)
+
+	id, err := c.SaveShutdownSnapshot()
+	if err != nil || id != "" {
+		t.Fatalf("Expected no snapshot without logs, got %q, %v", id, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected no snapshot file, got %d", len(entries))
+	}
+}
@@ -29,7 +29,7 @@ import (
 func (c *Controller) HandleExplain(w http.ResponseWriter, r *http.Request) {
 	var req sqlexplain.Request
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
 		return
 	}
 
@@ -42,7 +42,7 @@ func (c *Controller) HandleExplain(w http.ResponseWriter, r *http.Request) {
 // HandleSaveTrace saves a trace with associated logs and SQL queries
 func (c *Controller) HandleSaveTrace(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
@@ -56,7 +56,7 @@ func (c *Controller) HandleSaveTrace(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
 		return
 	}
 
@@ -163,17 +163,17 @@ func (c *Controller) HandleSaveTrace(w http.ResponseWriter, r *http.Request) {
 
 	id, err := c.store.CreateRequest(exec)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 
 	if len(messages) > 0 {
-		if err := c.store.SaveRequestLogs(id, messages); err != nil {
+		if err := c.store.WithContext(r.Context()).SaveRequestLogs(id, messages); err != nil {
 			slog.Error("failed to save execution logs", "error", err)
 		}
 	}
 
-	sqlQueries := sqlutil.ExtractSQLQueries(messages)
+	sqlQueries := c.extractSQLQueries(messages)
 	if len(sqlQueries) > 0 {
 		if err := c.store.SaveSQLQueries(id, sqlQueries); err != nil {
 			slog.Error("failed to save SQL queries from trace", "error", err)
@@ -194,9 +194,11 @@ func (c *Controller) HandleSaveTrace(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 
-			for i, q := range sqlQueries {
+			minMS, minTotalMS := sqlexplain.AutoExplainThresholds()
+			for _, i := range autoExplainQueries(sqlQueries, minMS, minTotalMS) {
+				q := sqlQueries[i]
 				connectionString := containerIDToConnectionString[q.ContainerID]
-				if q.DurationMS > 2.0 && connectionString != "" {
+				if connectionString != "" {
 					variables := make(map[string]string)
 					if q.Variables != "" {
 						var varsArray []any
@@ -240,24 +242,24 @@ func (c *Controller) HandleSaveTrace(w http.ResponseWriter, r *http.Request) {
 // HandleSQLDetail retrieves details for a specific SQL query by hash
 func (c *Controller) HandleSQLDetail(w http.ResponseWriter, r *http.Request) {
 	if c.store == nil {
-		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
 		return
 	}
 
 	vars := mux.Vars(r)
 	queryHash := strings.TrimSpace(vars["hash"])
 	if queryHash == "" {
-		http.Error(w, "Invalid query hash", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid query hash")
 		return
 	}
 
-	detail, err := c.store.GetSQLQueryDetailByHash(queryHash)
+	detail, err := c.store.WithContext(r.Context()).GetSQLQueryDetailByHash(queryHash)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	if detail == nil {
-		http.Error(w, "SQL query not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "SQL query not found")
 		return
 	}
 
@@ -271,17 +273,17 @@ func (c *Controller) HandleSQLNotionExport(w http.ResponseWriter, r *http.Reques
 	queryHash := strings.TrimSpace(vars["hash"])
 
 	if queryHash == "" {
-		http.Error(w, "Invalid query hash", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid query hash")
 		return
 	}
 
-	detail, err := c.store.GetSQLQueryDetailByHash(queryHash)
+	detail, err := c.store.WithContext(r.Context()).GetSQLQueryDetailByHash(queryHash)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 	if detail == nil {
-		http.Error(w, "SQL query not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "SQL query not found")
 		return
 	}
 
@@ -289,18 +291,18 @@ func (c *Controller) HandleSQLNotionExport(w http.ResponseWriter, r *http.Reques
 	notionDatabaseID := os.Getenv("NOTION_DATABASE_ID")
 
 	if notionAPIKey == "" {
-		http.Error(w, "Notion API key not configured. Set NOTION_API_KEY environment variable.", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeNotConfigured, "Notion API key not configured. Set NOTION_API_KEY environment variable.")
 		return
 	}
 
 	if notionDatabaseID == "" {
-		http.Error(w, "Notion database ID not configured. Set NOTION_DATABASE_ID environment variable.", http.StatusServiceUnavailable)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeNotConfigured, "Notion database ID not configured. Set NOTION_DATABASE_ID environment variable.")
 		return
 	}
 
 	pageURL, err := createNotionPage(notionAPIKey, notionDatabaseID, detail)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to create Notion page: %v", err), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusBadGateway, ErrCodeUpstream, fmt.Sprintf("Failed to create Notion page: %v", err))
 		return
 	}
 
@@ -626,3 +628,40 @@ func generateDaliboExplainLink(explainPlan, query, title string) string {
 
 	return "https://explain.dalibo.com/new"
 }
+
+// autoExplainQueries returns the indexes of the queries to EXPLAIN automatically: one per
+// normalized query, its slowest run, when that run took longer than minMS or all its runs
+// together took longer than minTotalMS. Plans are saved by query hash, so explaining one
+// run covers the rest, and queries with a plan from the logs are skipped. A minTotalMS of
+// zero disables the combined check.
+func autoExplainQueries(queries []store.SQLQuery, minMS, minTotalMS float64) []int {
+	slowest := make(map[string]int)
+	totals := make(map[string]float64)
+	planned := make(map[string]bool)
+	var hashes []string
+	for i, q := range queries {
+		if q.ExplainPlan != "" {
+			planned[q.QueryHash] = true
+		}
+		j, seen := slowest[q.QueryHash]
+		if !seen {
+			hashes = append(hashes, q.QueryHash)
+		}
+		if !seen || q.DurationMS > queries[j].DurationMS {
+			slowest[q.QueryHash] = i
+		}
+		totals[q.QueryHash] += q.DurationMS
+	}
+
+	var selected []int
+	for _, hash := range hashes {
+		i := slowest[hash]
+		if planned[hash] {
+			continue
+		}
+		if queries[i].DurationMS > minMS || minTotalMS > 0 && totals[hash] > minTotalMS {
+			selected = append(selected, i)
+		}
+	}
+	return selected
+}
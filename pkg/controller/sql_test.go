@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"docker-log-parser/pkg/store"
+)
+
+func TestAutoExplainQueries(t *testing.T) {
+	query := func(hash string, durationMS float64) store.SQLQuery {
+		return store.SQLQuery{QueryHash: hash, DurationMS: durationMS}
+	}
+	repeated := func(hash string, n int, durationMS float64) []store.SQLQuery {
+		queries := make([]store.SQLQuery, n)
+		for i := range queries {
+			queries[i] = query(hash, durationMS)
+		}
+		return queries
+	}
+
+	tests := []struct {
+		name       string
+		queries    []store.SQLQuery
+		minMS      float64
+		minTotalMS float64
+		expected   []int
+	}{
+		{
+			name:       "slow query",
+			queries:    []store.SQLQuery{query("a", 1), query("b", 5)},
+			minMS:      2,
+			minTotalMS: 50,
+			expected:   []int{1},
+		},
+		{
+			name:       "slowest run of a repeated slow query",
+			queries:    []store.SQLQuery{query("a", 3), query("a", 8), query("a", 4)},
+			minMS:      2,
+			minTotalMS: 50,
+			expected:   []int{1},
+		},
+		{
+			name:       "fast query repeated past the combined threshold",
+			queries:    append([]store.SQLQuery{query("b", 1)}, repeated("a", 200, 0.5)...),
+			minMS:      2,
+			minTotalMS: 50,
+			expected:   []int{1},
+		},
+		{
+			name:       "fast query repeated within the combined threshold",
+			queries:    repeated("a", 20, 0.5),
+			minMS:      2,
+			minTotalMS: 50,
+		},
+		{
+			name:       "combined threshold disabled",
+			queries:    repeated("a", 200, 0.5),
+			minMS:      2,
+			minTotalMS: 0,
+		},
+		{
+			name:       "plan already logged",
+			queries:    []store.SQLQuery{query("a", 5), {QueryHash: "a", DurationMS: 1, ExplainPlan: `[{"Plan": {}}]`}},
+			minMS:      2,
+			minTotalMS: 50,
+		},
+		{
+			name:       "both thresholds in query order",
+			queries:    append(append(repeated("n+1", 100, 1), query("slow", 10)), query("fast", 1)),
+			minMS:      2,
+			minTotalMS: 50,
+			expected:   []int{0, 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected := autoExplainQueries(tt.queries, tt.minMS, tt.minTotalMS)
+			if !reflect.DeepEqual(selected, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, selected)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/sqlutil"
+	"docker-log-parser/pkg/store"
+
+	"github.com/gorilla/mux"
+)
+
+// extractSQLQueries extracts SQL queries from logs using each container's configured SQL marker
+func (c *Controller) extractSQLQueries(messages []logs.ContainerMessage) []store.SQLQuery {
+	return sqlutil.ExtractSQLQueriesWithMarkers(messages, c.sqlMarkers())
+}
+
+// extractSQLQueriesAwaitingPlans is extractSQLQueries for one batch of a stream, see
+// sqlutil.ExtractSQLQueriesAwaitingPlans
+func (c *Controller) extractSQLQueriesAwaitingPlans(messages []logs.ContainerMessage) ([]store.SQLQuery, map[string]int) {
+	return sqlutil.ExtractSQLQueriesAwaitingPlans(messages, c.sqlMarkers())
+}
+
+// sqlMarkers resolves the stored SQL markers to the IDs of the current containers
+func (c *Controller) sqlMarkers() map[string]*sqlutil.SQLMarker {
+	if c.store == nil {
+		return nil
+	}
+
+	stored, err := c.store.ListContainerSQLMarkers()
+	if err != nil {
+		slog.Error("failed to list SQL markers", "error", err)
+		return nil
+	}
+	if len(stored) == 0 {
+		return nil
+	}
+
+	byName := make(map[string]*sqlutil.SQLMarker, len(stored))
+	for _, m := range stored {
+		marker, err := sqlutil.NewSQLMarker(m.Preset, m.Pattern)
+		if err != nil {
+			slog.Warn("skipping invalid SQL marker", "container", m.ContainerName, "error", err)
+			continue
+		}
+		byName[m.ContainerName] = marker
+	}
+
+	markers := make(map[string]*sqlutil.SQLMarker)
+	for _, container := range c.GetContainers() {
+		if marker, ok := byName[container.Name]; ok {
+			markers[container.ID] = marker
+		}
+	}
+	return markers
+}
+
+// HandleListSQLMarkers lists the SQL marker configured for each container
+func (c *Controller) HandleListSQLMarkers(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	params := c.decodePageParams(r, defaultListLimit)
+
+	markers, err := c.store.ListContainerSQLMarkers()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	writeList(w, markers, params)
+}
+
+// HandleListSQLMarkerPresets lists the built-in SQL marker presets and their patterns
+func (c *Controller) HandleListSQLMarkerPresets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sqlutil.SQLMarkerPresets)
+}
+
+// HandleSaveSQLMarker creates or updates the SQL marker for a container
+func (c *Controller) HandleSaveSQLMarker(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	var marker store.ContainerSQLMarker
+	if !c.decodeJSONBody(w, r, &marker) {
+		return
+	}
+	if marker.ContainerName == "" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Container name required")
+		return
+	}
+	if _, err := sqlutil.NewSQLMarker(marker.Preset, marker.Pattern); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+	if marker.Preset != "" {
+		marker.Pattern = ""
+	}
+
+	if err := c.store.SaveContainerSQLMarker(&marker); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(marker)
+}
+
+// HandleDeleteSQLMarker deletes the SQL marker for a container
+func (c *Controller) HandleDeleteSQLMarker(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	containerName := mux.Vars(r)["containerName"]
+	if containerName == "" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Container name required")
+		return
+	}
+
+	if err := c.store.DeleteContainerSQLMarker(containerName); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/sqlutil"
+	"docker-log-parser/pkg/store"
+)
+
+// SQLProgressMessage reports the SQL queries saved for an execution while its logs
+// are still arriving, sent to clients as a "sql_progress" message
+type SQLProgressMessage struct {
+	ExecutionID int64            `json:"executionId"`
+	Queries     []store.SQLQuery `json:"queries"`  // Saved since the previous message
+	Total       int              `json:"total"`    // Saved so far
+	Complete    bool             `json:"complete"` // Collection has finished and the analysis is final
+}
+
+// sqlProgress extracts and saves an execution's SQL queries as its logs arrive, so a
+// long-running request's queries show up before it finishes. Each log is only
+// extracted once, however many times it is seen.
+type sqlProgress struct {
+	c            *Controller
+	execID       int64
+	seen         map[*logs.LogEntry]bool
+	awaitingPlan map[string]string // Container ID to the hash of its last query, while its next line could be the plan
+	total        int
+}
+
+func (c *Controller) newSQLProgress(execID int64) *sqlProgress {
+	return &sqlProgress{
+		c:            c,
+		execID:       execID,
+		seen:         make(map[*logs.LogEntry]bool),
+		awaitingPlan: make(map[string]string),
+	}
+}
+
+// follow saves the queries in sub's batches in the background. The returned function
+// stops following and waits for the batch in hand to be saved.
+func (p *sqlProgress) follow(sub *logSubscription) (stop func()) {
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case batch := <-sub.batches:
+				p.add(batch)
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(quit)
+		<-done
+	}
+}
+
+// add saves the queries in the messages not seen before and reports them to clients
+func (p *sqlProgress) add(messages []logs.ContainerMessage) {
+	fresh := make([]logs.ContainerMessage, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Entry == nil || p.seen[msg.Entry] {
+			continue
+		}
+		p.seen[msg.Entry] = true
+		fresh = append(fresh, msg)
+	}
+
+	fresh = p.attachLatePlans(fresh)
+	queries, awaiting := p.c.extractSQLQueriesAwaitingPlans(fresh)
+	for containerID, i := range awaiting {
+		p.awaitingPlan[containerID] = queries[i].QueryHash
+	}
+	if len(queries) == 0 {
+		return
+	}
+	if err := p.c.store.SaveSQLQueries(p.execID, queries); err != nil {
+		slog.Error("failed to save SQL queries", "execution_id", p.execID, "error", err)
+		return
+	}
+
+	p.total += len(queries)
+	p.send(queries, false)
+}
+
+// attachLatePlans saves the plans of queries from an earlier batch that were logged as
+// their container's first line in this one, and returns the remaining messages
+func (p *sqlProgress) attachLatePlans(messages []logs.ContainerMessage) []logs.ContainerMessage {
+	if len(p.awaitingPlan) == 0 {
+		return messages
+	}
+
+	remaining := messages[:0]
+	for _, msg := range messages {
+		hash, ok := p.awaitingPlan[msg.ContainerID]
+		if !ok || msg.Entry.Message == "" {
+			remaining = append(remaining, msg)
+			continue
+		}
+		delete(p.awaitingPlan, msg.ContainerID)
+
+		plan, ok := sqlutil.ParseLoggedPlan(msg.Entry.Message)
+		if !ok {
+			remaining = append(remaining, msg)
+			continue
+		}
+		if err := p.c.store.UpdateQueryExplainPlan(p.execID, hash, plan); err != nil {
+			slog.Error("failed to save logged plan", "execution_id", p.execID, "error", err)
+		}
+	}
+	return remaining
+}
+
+// finish tells clients that no more queries will be saved
+func (p *sqlProgress) finish() {
+	p.send([]store.SQLQuery{}, true)
+}
+
+func (p *sqlProgress) send(queries []store.SQLQuery, complete bool) {
+	data, err := json.Marshal(SQLProgressMessage{
+		ExecutionID: p.execID,
+		Queries:     queries,
+		Total:       p.total,
+		Complete:    complete,
+	})
+	if err != nil {
+		slog.Error("failed to marshal SQL progress", "error", err)
+		return
+	}
+	p.c.broadcast(WSMessage{Type: "sql_progress", Data: data})
+}
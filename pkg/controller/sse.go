@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/logstore"
+)
+
+// sseKeepAliveInterval is how often an idle SSE stream sends a comment so proxies keep it open
+const sseKeepAliveInterval = 15 * time.Second
+
+// HandleLogStream streams filtered logs as server-sent events. Each event's data is a
+// WSMessage, the same messages WebSocket clients receive, starting with config and
+// logs_initial. The filter comes from query parameters and is fixed for the connection.
+func (c *Controller) HandleLogStream(w http.ResponseWriter, r *http.Request) {
+	filter, err := c.filterFromQuery(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Streaming not supported")
+		return
+	}
+
+	initial, err := c.initialLogsMessage(filter)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	client := &SSEClient{
+		filter:   filter,
+		messages: make(chan WSMessage, 64),
+	}
+
+	c.sseClientsMutex.Lock()
+	c.sseClients[client] = true
+	c.sseClientsMutex.Unlock()
+
+	defer func() {
+		c.sseClientsMutex.Lock()
+		delete(c.sseClients, client)
+		c.sseClientsMutex.Unlock()
+	}()
+
+	if writeSSE(w, c.configMessage()) != nil || writeSSE(w, initial) != nil {
+		return
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-c.ctx.Done():
+			return
+		case msg := <-client.messages:
+			if err := writeSSE(w, msg); err != nil {
+				slog.Debug("SSE client write failed", "error", err)
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// filterFromQuery builds a client filter from query parameters, for endpoints that
+// filter logs without a WebSocket connection to carry it
+func (c *Controller) filterFromQuery(r *http.Request) (ClientFilter, error) {
+	type QueryParams struct {
+		Containers    []string `schema:"container"`
+		Excluded      []string `schema:"excludeContainer"`
+		Levels        []string `schema:"level"`
+		Search        string   `schema:"search"`
+		CaseSensitive bool     `schema:"caseSensitive"`
+		WholeWord     bool     `schema:"wholeWord"`
+		Traces        []string `schema:"trace"` // field:value
+		HasFields     []string `schema:"hasField"`
+		Ranges        []string `schema:"range"` // e.g. duration>100
+		SlowMS        float64  `schema:"slowThresholdMs"`
+		MaxAgeSeconds float64  `schema:"maxAgeSeconds"`
+	}
+
+	var params QueryParams
+	if err := c.decoder.Decode(&params, r.URL.Query()); err != nil {
+		return ClientFilter{}, err
+	}
+
+	filter := ClientFilter{
+		SelectedContainers: params.Containers,
+		ExcludedContainers: params.Excluded,
+		SelectedLevels:     params.Levels,
+		SearchQuery:        params.Search,
+		TraceFilters:       []TraceFilterValue{},
+		HasFields:          params.HasFields,
+		SlowThresholdMS:    params.SlowMS,
+		MaxAgeSeconds:      params.MaxAgeSeconds,
+		CaseSensitive:      params.CaseSensitive,
+		WholeWord:          params.WholeWord,
+	}
+	for _, trace := range params.Traces {
+		field, value, ok := strings.Cut(trace, ":")
+		if !ok || field == "" {
+			return ClientFilter{}, fmt.Errorf("invalid trace filter %q, expected field:value", trace)
+		}
+		filter.TraceFilters = append(filter.TraceFilters, TraceFilterValue{Type: field, Value: value})
+	}
+	for _, expr := range params.Ranges {
+		rangeFilter, err := logstore.ParseFieldRangeFilter(expr)
+		if err != nil {
+			return ClientFilter{}, err
+		}
+		filter.RangeFilters = append(filter.RangeFilters, rangeFilter)
+	}
+	return filter.withTermMatchers(), nil
+}
+
+// writeSSE writes msg as a single server-sent event
+func writeSSE(w http.ResponseWriter, msg WSMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
+
+// broadcastSSE queues msg for every SSE client
+func (c *Controller) broadcastSSE(msg WSMessage) {
+	c.sseClientsMutex.RLock()
+	defer c.sseClientsMutex.RUnlock()
+
+	for client := range c.sseClients {
+		client.send(msg)
+	}
+}
+
+// broadcastBatchSSE queues the matching part of a batch for each SSE client
+func (c *Controller) broadcastBatchSSE(batch []logs.ContainerMessage) {
+	c.sseClientsMutex.RLock()
+	defer c.sseClientsMutex.RUnlock()
+
+	for client := range c.sseClients {
+		if msg, ok := c.batchMessage(batch, client.filter); ok {
+			client.send(msg)
+		}
+	}
+}
+
+// send hands msg to the client's writer. Slow clients miss messages rather than
+// blocking the broadcast.
+func (client *SSEClient) send(msg WSMessage) {
+	select {
+	case client.messages <- msg:
+	default:
+		slog.Warn("SSE client is full, dropping message", "type", msg.Type)
+	}
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"docker-log-parser/pkg/logs"
+)
+
+// readSSEMessage reads the next data event from an SSE stream, skipping comments
+func readSSEMessage(t *testing.T, reader *bufio.Reader) WSMessage {
+	t.Helper()
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: ")
+		if !ok {
+			continue
+		}
+		var msg WSMessage
+		if err := json.Unmarshal([]byte(data), &msg); err != nil {
+			t.Fatalf("Failed to decode event %q: %v", data, err)
+		}
+		return msg
+	}
+}
+
+func TestLogStreamSendsFilteredBatches(t *testing.T) {
+	c := newTestController(t)
+
+	c.logStore.Add(&logs.ContainerMessage{
+		ContainerID: "api",
+		Timestamp:   time.Now(),
+		Entry:       &logs.LogEntry{Level: "ERR", Message: "stored error"},
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(c.HandleLogStream))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?level=ERR", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected text/event-stream, got %q", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	if msg := readSSEMessage(t, reader); msg.Type != "config" {
+		t.Fatalf("Expected config first, got %s", msg.Type)
+	}
+	initial := readSSEMessage(t, reader)
+	if initial.Type != "logs_initial" || !strings.Contains(string(initial.Data), "stored error") {
+		t.Fatalf("Expected stored error in logs_initial, got %s %s", initial.Type, initial.Data)
+	}
+
+	c.BroadcastBatch([]logs.ContainerMessage{
+		{ContainerID: "api", Timestamp: time.Now(), Entry: &logs.LogEntry{Level: "INF", Message: "live info"}},
+		{ContainerID: "api", Timestamp: time.Now(), Entry: &logs.LogEntry{Level: "ERR", Message: "live error"}},
+	})
+
+	live := readSSEMessage(t, reader)
+	var batch []LogWSMessage
+	if err := json.Unmarshal(live.Data, &batch); err != nil {
+		t.Fatalf("Failed to decode batch: %v", err)
+	}
+	if live.Type != "logs" || len(batch) != 1 || batch[0].Entry.Message != "live error" {
+		t.Errorf("Expected only the live error, got %s %s", live.Type, live.Data)
+	}
+
+	// Disconnecting unregisters the client
+	cancel()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		c.sseClientsMutex.RLock()
+		remaining := len(c.sseClients)
+		c.sseClientsMutex.RUnlock()
+		if remaining == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected SSE client to be removed after disconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLogStreamRejectsInvalidTraceFilter(t *testing.T) {
+	c := newTestController(t)
+
+	rec := httptest.NewRecorder()
+	c.HandleLogStream(rec, httptest.NewRequest(http.MethodGet, "/api/logs/stream?trace=no-separator", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", rec.Code)
+	}
+}
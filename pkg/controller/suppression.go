@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/store"
+
+	"github.com/gorilla/mux"
+)
+
+// SuppressionSummaryInterval is how often the lines dropped by suppression patterns
+// are reported to clients
+const SuppressionSummaryInterval = 30 * time.Second
+
+// SuppressionSummary reports the lines suppression patterns dropped since the previous
+// summary, sent to clients as a "suppressed" message
+type SuppressionSummary struct {
+	Counts map[string]int `json:"counts"` // pattern -> lines suppressed
+	Total  int            `json:"total"`
+}
+
+// Suppressor returns the suppressor applied to lines as they are ingested
+func (c *Controller) Suppressor() *logs.Suppressor {
+	return c.suppressor
+}
+
+// LoadSuppressionPatterns loads suppression patterns from the database into the
+// suppressor
+func (c *Controller) LoadSuppressionPatterns() error {
+	if c.store == nil {
+		return nil
+	}
+
+	stored, err := c.store.ListSuppressionPatterns()
+	if err != nil {
+		return err
+	}
+
+	patterns := make([]*regexp.Regexp, 0, len(stored))
+	for _, p := range stored {
+		re, err := logs.CompileSuppressionPattern(p.Pattern)
+		if err != nil {
+			slog.Warn("skipping invalid suppression pattern", "pattern", p.Pattern, "error", err)
+			continue
+		}
+		patterns = append(patterns, re)
+	}
+
+	c.suppressor.SetPatterns(patterns)
+	return nil
+}
+
+// BroadcastSuppressionSummary reports the lines suppressed since the previous summary,
+// if there were any
+func (c *Controller) BroadcastSuppressionSummary() {
+	counts := c.suppressor.TakeCounts()
+	if len(counts) == 0 {
+		return
+	}
+
+	summary := SuppressionSummary{Counts: counts}
+	for _, n := range counts {
+		summary.Total += n
+	}
+	slog.Info("suppressed noise lines", "total", summary.Total, "patterns", len(counts))
+
+	data, err := json.Marshal(summary)
+	if err != nil {
+		slog.Error("failed to marshal suppression summary", "error", err)
+		return
+	}
+	c.broadcast(WSMessage{Type: "suppressed", Data: data})
+}
+
+// HandleListSuppressionPatterns lists all suppression patterns
+func (c *Controller) HandleListSuppressionPatterns(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	patterns, err := c.store.ListSuppressionPatterns()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(patterns)
+}
+
+// HandleSaveSuppressionPattern saves a suppression pattern and applies it to lines
+// ingested from then on
+func (c *Controller) HandleSaveSuppressionPattern(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	var pattern store.SuppressionPattern
+	if !c.decodeJSONBody(w, r, &pattern) {
+		return
+	}
+	if _, err := logs.CompileSuppressionPattern(pattern.Pattern); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	if err := c.store.SaveSuppressionPattern(&pattern); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	c.reloadSuppressionPatterns()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pattern)
+}
+
+// HandleDeleteSuppressionPattern deletes a suppression pattern
+func (c *Controller) HandleDeleteSuppressionPattern(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid suppression pattern ID")
+		return
+	}
+
+	if err := c.store.DeleteSuppressionPattern(uint(id)); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	c.reloadSuppressionPatterns()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reloadSuppressionPatterns reloads suppression patterns after a change
+func (c *Controller) reloadSuppressionPatterns() {
+	if err := c.LoadSuppressionPatterns(); err != nil {
+		slog.Error("failed to reload suppression patterns", "error", err)
+	}
+}
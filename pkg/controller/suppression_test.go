@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/store"
+
+	"github.com/gorilla/mux"
+)
+
+func TestSuppressionPatterns(t *testing.T) {
+	c := newTestController(t)
+
+	save := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c.HandleSaveSuppressionPattern(rec, httptest.NewRequest(http.MethodPost, "/api/suppression-patterns", bytes.NewBufferString(body)))
+		return rec
+	}
+
+	rec := save(`{"pattern":"GET /healthz"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var saved store.SuppressionPattern
+	if err := json.Unmarshal(rec.Body.Bytes(), &saved); err != nil || saved.ID == 0 {
+		t.Fatalf("Expected the saved pattern, got %s", rec.Body.String())
+	}
+	if rec := save(`{"pattern":"GET /healthz"}`); !bytes.Contains(rec.Body.Bytes(), []byte(fmt.Sprintf(`"id":%d`, saved.ID))) {
+		t.Errorf("Expected saving a pattern twice to keep one, got %s", rec.Body.String())
+	}
+	if rec := save(`{"pattern":"health(z"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid pattern, got %d", rec.Code)
+	}
+
+	healthz := logs.ContainerMessage{ContainerID: "api", Entry: &logs.LogEntry{Raw: `"GET /healthz HTTP/1.1" 200`}}
+	if !c.Suppressor().Suppress(healthz) {
+		t.Error("Expected a saved pattern to apply straight away")
+	}
+
+	// Patterns persist, so a new controller on the same store suppresses them too
+	restarted := NewController(nil, c.logStore, c.store, c.ctx, c.cancel, c.logChan)
+	if err := restarted.LoadSuppressionPatterns(); err != nil {
+		t.Fatalf("Failed to load patterns: %v", err)
+	}
+	if !restarted.Suppressor().Suppress(healthz) {
+		t.Error("Expected loaded patterns to be applied")
+	}
+
+	rec = httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/", nil), map[string]string{"id": fmt.Sprint(saved.ID)})
+	c.HandleDeleteSuppressionPattern(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", rec.Code)
+	}
+	if c.Suppressor().Suppress(healthz) {
+		t.Error("Expected a deleted pattern to stop applying")
+	}
+}
+
+func TestBroadcastSuppressionSummary(t *testing.T) {
+	c := newTestController(t)
+
+	client := &SSEClient{messages: make(chan WSMessage, 8)}
+	c.sseClients[client] = true
+
+	re, _ := logs.CompileSuppressionPattern(`/healthz`)
+	c.Suppressor().SetPatterns([]*regexp.Regexp{re})
+
+	// Nothing suppressed, nothing to report
+	c.BroadcastSuppressionSummary()
+	if len(client.messages) != 0 {
+		t.Fatalf("Expected no summary without suppressed lines, got %d messages", len(client.messages))
+	}
+
+	for range 3 {
+		c.Suppressor().Suppress(logs.ContainerMessage{ContainerID: "api", Entry: &logs.LogEntry{Raw: "GET /healthz"}})
+	}
+	c.BroadcastSuppressionSummary()
+
+	select {
+	case msg := <-client.messages:
+		var summary SuppressionSummary
+		if err := json.Unmarshal(msg.Data, &summary); err != nil {
+			t.Fatalf("Failed to decode summary: %v", err)
+		}
+		if msg.Type != "suppressed" || summary.Total != 3 || summary.Counts["/healthz"] != 3 {
+			t.Errorf("Expected 3 lines suppressed by /healthz, got %s %+v", msg.Type, summary)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the summary")
+	}
+
+	// Each summary covers the lines since the previous one
+	c.BroadcastSuppressionSummary()
+	if len(client.messages) != 0 {
+		t.Error("Expected counts to restart after a summary")
+	}
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"log/slog"
+	"sort"
+	"time"
+
+	"docker-log-parser/pkg/graphql"
+	"docker-log-parser/pkg/store"
+)
+
+// correlateResolvers maps the resolvers in the response's tracing extension to the SQL
+// queries logged while each ran, and saves the mapping with the execution. Responses
+// without tracing are left alone.
+func (c *Controller) correlateResolvers(execID int64, execution *store.Request) {
+	tracing := graphql.ParseTracing([]byte(execution.ResponseBody))
+	if tracing == nil || len(tracing.Execution.Resolvers) == 0 {
+		return
+	}
+	if tracing.StartTime.IsZero() {
+		tracing.StartTime = execution.ExecutedAt
+	}
+
+	queries, err := c.store.GetSQLQueries(execID)
+	if err != nil {
+		slog.Error("failed to load SQL queries for resolver correlation", "execution_id", execID, "error", err)
+		return
+	}
+
+	resolvers := resolverSQL(tracing, queries)
+	if len(resolvers) == 0 {
+		return
+	}
+	if err := c.store.SaveResolverSQL(execID, resolvers); err != nil {
+		slog.Error("failed to save resolver SQL", "execution_id", execID, "error", err)
+	}
+}
+
+// resolverSQL assigns each query to the resolver running at its midpoint, estimated from
+// its log time less half its duration since queries are logged as they finish. Only
+// resolvers that ran queries are returned, in the order they started.
+func resolverSQL(tracing *graphql.Tracing, queries []store.SQLQuery) []store.ResolverSQL {
+	byResolver := make(map[int]*store.ResolverSQL)
+	for _, q := range queries {
+		if q.LoggedAt == nil {
+			continue
+		}
+		midpoint := q.LoggedAt.Add(-time.Duration(q.DurationMS / 2 * float64(time.Millisecond)))
+		i := tracing.ResolverAt(midpoint)
+		if i < 0 {
+			continue
+		}
+
+		entry := byResolver[i]
+		if entry == nil {
+			r := tracing.Execution.Resolvers[i]
+			entry = &store.ResolverSQL{
+				Path:          r.PathString(),
+				ParentType:    r.ParentType,
+				FieldName:     r.FieldName,
+				ReturnType:    r.ReturnType,
+				StartOffsetMS: float64(r.StartOffset) / float64(time.Millisecond),
+				DurationMS:    float64(r.Duration) / float64(time.Millisecond),
+			}
+			byResolver[i] = entry
+		}
+		entry.QueryIDs = append(entry.QueryIDs, q.ID)
+		entry.SQLDurationMS += q.DurationMS
+	}
+
+	resolvers := make([]store.ResolverSQL, 0, len(byResolver))
+	for _, entry := range byResolver {
+		resolvers = append(resolvers, *entry)
+	}
+	sort.Slice(resolvers, func(i, j int) bool {
+		if resolvers[i].StartOffsetMS != resolvers[j].StartOffsetMS {
+			return resolvers[i].StartOffsetMS < resolvers[j].StartOffsetMS
+		}
+		return resolvers[i].Path < resolvers[j].Path
+	})
+	return resolvers
+}
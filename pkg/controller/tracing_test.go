@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"docker-log-parser/pkg/store"
+)
+
+// createTracedExecution saves an execution with the Apollo tracing fixture as its
+// response, and SQL queries logged while its user and posts resolvers ran
+func createTracedExecution(t *testing.T, c *Controller, sampleID *uint) (int64, *store.Request) {
+	t.Helper()
+
+	responseBody, err := os.ReadFile("../graphql/testdata/apollo_tracing_response.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	execution := &store.Request{
+		RequestIDHeader: "traced-1",
+		RequestBody:     `{"query":"{ user(id: 1) { name posts { title } } }"}`,
+		StatusCode:      200,
+		ResponseBody:    string(responseBody),
+		ExecutedAt:      time.Now(),
+		SampleID:        sampleID,
+		Status:          store.RequestStatusCompleted,
+	}
+	id, err := c.store.CreateRequest(execution)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	// The fixture's user resolver runs from 1-6ms and posts from 6.1-18.1ms
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	at := func(ms float64) *time.Time {
+		t := start.Add(time.Duration(ms * float64(time.Millisecond)))
+		return &t
+	}
+	err = c.store.SaveSQLQueries(id, []store.SQLQuery{
+		{Query: `SELECT * FROM "users"`, NormalizedQuery: `SELECT * FROM "users"`, DurationMS: 2, LoggedAt: at(5)},
+		{Query: `SELECT * FROM "posts"`, NormalizedQuery: `SELECT * FROM "posts"`, DurationMS: 1, LoggedAt: at(10)},
+		{Query: `SELECT * FROM "tags"`, NormalizedQuery: `SELECT * FROM "tags"`, DurationMS: 1, LoggedAt: at(12)},
+		{Query: `SELECT 1`, NormalizedQuery: `SELECT 1`, DurationMS: 1, LoggedAt: at(30)}, // After the response
+		{Query: `SELECT 2`, NormalizedQuery: `SELECT 2`, DurationMS: 1},                   // No log time
+	})
+	if err != nil {
+		t.Fatalf("Failed to save SQL queries: %v", err)
+	}
+	return id, execution
+}
+
+func TestCorrelateResolversWithSQL(t *testing.T) {
+	c := newTestController(t)
+	id, execution := createTracedExecution(t, c, nil)
+
+	c.correlateResolvers(id, execution)
+
+	detail, err := c.store.GetRequestDetail(id)
+	if err != nil {
+		t.Fatalf("Failed to get detail: %v", err)
+	}
+	if len(detail.ResolverSQL) != 2 {
+		t.Fatalf("Expected 2 resolvers with queries, got %+v", detail.ResolverSQL)
+	}
+	user, posts := detail.ResolverSQL[0], detail.ResolverSQL[1]
+	if user.Path != "user" || !slices.Equal(user.QueryIDs, []uint{detail.SQLQueries[0].ID}) {
+		t.Errorf("Expected the users query under user, got %+v", user)
+	}
+	if posts.Path != "user.posts" || posts.ReturnType != "[Post!]!" || len(posts.QueryIDs) != 2 || posts.SQLDurationMS != 2 || posts.DurationMS != 12 {
+		t.Errorf("Expected the posts and tags queries under user.posts, got %+v", posts)
+	}
+}
+
+func TestExpectationsKeepResolverSQL(t *testing.T) {
+	c := newTestController(t)
+
+	maxQueries := 1
+	sampleID, err := c.store.CreateSampleQuery(&store.SampleQuery{
+		Name:               "FetchUser",
+		RequestData:        `{"query":"{ user(id: 1) { name posts { title } } }"}`,
+		SampleExpectations: store.SampleExpectations{MaxQueries: &maxQueries},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create sample query: %v", err)
+	}
+	sample := uint(sampleID)
+	id, execution := createTracedExecution(t, c, &sample)
+
+	// Run in the same order as executeRequest
+	c.correlateResolvers(id, execution)
+	c.checkExpectations(execution, 5)
+
+	detail, err := c.store.GetRequestDetail(id)
+	if err != nil {
+		t.Fatalf("Failed to get detail: %v", err)
+	}
+	if detail.Execution.ExpectationsMet == nil || *detail.Execution.ExpectationsMet {
+		t.Errorf("Expected 5 queries to fail a budget of 1, got %+v", detail.Execution)
+	}
+	if len(detail.ResolverSQL) != 2 {
+		t.Errorf("Expected the resolver mapping to survive the expectation check, got %+v", detail.ResolverSQL)
+	}
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"docker-log-parser/pkg/store"
+
+	"github.com/gorilla/mux"
+)
+
+// SavedViewRequest is the body for creating a shareable log view
+type SavedViewRequest struct {
+	Filter    ClientFilter `json:"filter"`
+	StartTime *time.Time   `json:"startTime,omitempty"`
+	EndTime   *time.Time   `json:"endTime,omitempty"`
+	ExpiresIn int          `json:"expiresIn,omitempty"` // seconds until the view expires, 0 for never
+}
+
+// SavedViewResponse is a saved log view with its filter decoded
+type SavedViewResponse struct {
+	ID        string       `json:"id"`
+	Filter    ClientFilter `json:"filter"`
+	StartTime *time.Time   `json:"startTime,omitempty"`
+	EndTime   *time.Time   `json:"endTime,omitempty"`
+	ExpiresAt *time.Time   `json:"expiresAt,omitempty"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+// HandleCreateView persists a filter and time window and returns its short ID
+func (c *Controller) HandleCreateView(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	var input SavedViewRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+	if input.StartTime != nil && input.EndTime != nil && input.EndTime.Before(*input.StartTime) {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "endTime must be after startTime")
+		return
+	}
+	if input.ExpiresIn < 0 {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "expiresIn must not be negative")
+		return
+	}
+
+	filterJSON, err := json.Marshal(input.Filter)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	view := store.SavedView{
+		Filter:    string(filterJSON),
+		StartTime: input.StartTime,
+		EndTime:   input.EndTime,
+	}
+	if input.ExpiresIn > 0 {
+		view.ExpiresAt = new(time.Now().Add(time.Duration(input.ExpiresIn) * time.Second))
+	}
+
+	if _, err := c.store.CreateSavedView(&view); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SavedViewResponse{
+		ID:        view.ID,
+		Filter:    input.Filter,
+		StartTime: view.StartTime,
+		EndTime:   view.EndTime,
+		ExpiresAt: view.ExpiresAt,
+		CreatedAt: view.CreatedAt,
+	})
+}
+
+// HandleGetView returns a saved log view by ID
+func (c *Controller) HandleGetView(w http.ResponseWriter, r *http.Request) {
+	if c.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodeDBUnavailable, "Database not available")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if id == "" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeValidation, "View ID required")
+		return
+	}
+
+	view, err := c.store.GetSavedView(id)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+	if view == nil {
+		writeJSONError(w, http.StatusNotFound, ErrCodeNotFound, "View not found")
+		return
+	}
+
+	var filter ClientFilter
+	if err := json.Unmarshal([]byte(view.Filter), &filter); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SavedViewResponse{
+		ID:        view.ID,
+		Filter:    filter,
+		StartTime: view.StartTime,
+		EndTime:   view.EndTime,
+		ExpiresAt: view.ExpiresAt,
+		CreatedAt: view.CreatedAt,
+	})
+}
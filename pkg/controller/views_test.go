@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"docker-log-parser/pkg/logstore"
+
+	"github.com/gorilla/mux"
+)
+
+func TestSavedViewRoundTrip(t *testing.T) {
+	c := newTestController(t)
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(15 * time.Minute)
+	input := SavedViewRequest{
+		Filter: ClientFilter{
+			SelectedContainers: []string{"api", "worker", "web-7d9f8-abcde"},
+			SelectedLevels:     []string{"ERR", "ERROR", "FATAL", "NONE"},
+			SearchQuery:        `timeout "payment service" db`,
+			TraceFilters: []TraceFilterValue{
+				{Type: "trace_id", Value: "abc123"},
+				{Type: "request_id", Value: "req-42"},
+				{Type: "user.email", Value: "a+b@example.com"},
+			},
+			ExcludedContainers: []string{"web-7d9f8-abcde"},
+			HasFields:          []string{"user.email"},
+			RangeFilters:       []logstore.FieldRangeFilter{{Name: "duration", Op: ">", Value: "100"}},
+			SlowThresholdMS:    250,
+			MaxAgeSeconds:      600,
+			CaseSensitive:      true,
+			WholeWord:          true,
+		},
+		StartTime: &start,
+		EndTime:   &end,
+		ExpiresIn: 3600,
+	}
+	body, _ := json.Marshal(input)
+
+	rec := httptest.NewRecorder()
+	c.HandleCreateView(rec, httptest.NewRequest(http.MethodPost, "/api/views", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var created SavedViewResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if created.ID == "" {
+		t.Fatal("Expected a view ID")
+	}
+	if created.ExpiresAt == nil {
+		t.Error("Expected an expiry to be set")
+	}
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/views/"+created.ID, nil), map[string]string{"id": created.ID})
+	rec = httptest.NewRecorder()
+	c.HandleGetView(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var view SavedViewResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &view); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !reflect.DeepEqual(view.Filter, input.Filter) {
+		t.Errorf("Filter did not round-trip:\n got %+v\nwant %+v", view.Filter, input.Filter)
+	}
+	if view.StartTime == nil || !view.StartTime.Equal(start) {
+		t.Errorf("Expected start time %v, got %v", start, view.StartTime)
+	}
+	if view.EndTime == nil || !view.EndTime.Equal(end) {
+		t.Errorf("Expected end time %v, got %v", end, view.EndTime)
+	}
+}
+
+func TestSavedViewNotFound(t *testing.T) {
+	c := newTestController(t)
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/views/missing", nil), map[string]string{"id": "missing"})
+	rec := httptest.NewRecorder()
+	c.HandleGetView(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", rec.Code)
+	}
+}
+
+func TestCreateViewRejectsInvertedTimeRange(t *testing.T) {
+	c := newTestController(t)
+
+	body := `{"filter":{},"startTime":"2024-03-01T12:00:00Z","endTime":"2024-03-01T11:00:00Z"}`
+	rec := httptest.NewRecorder()
+	c.HandleCreateView(rec, httptest.NewRequest(http.MethodPost, "/api/views", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+}
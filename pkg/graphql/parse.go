@@ -0,0 +1,563 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Position is a 1-based line and column in a query
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// SyntaxError is a query that could not be parsed
+type SyntaxError struct {
+	Position
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at %d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// document is the part of an executable document that validation needs: the
+// selections, with argument names but not their values
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	Position
+	kind       string // query, mutation or subscription
+	selections []*selection
+}
+
+type fragment struct {
+	Position
+	name          string
+	typeCondition string
+	selections    []*selection
+}
+
+type selectionKind int
+
+const (
+	fieldSelection selectionKind = iota
+	fragmentSpread
+	inlineFragment
+)
+
+type selection struct {
+	Position
+	kind          selectionKind
+	name          string // Field or spread fragment name
+	typeCondition string // Inline fragments only; empty applies to the enclosing type
+	arguments     []argument
+	selections    []*selection
+}
+
+type argument struct {
+	Position
+	name string
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenNumber
+	tokenString
+)
+
+type token struct {
+	Position
+	kind  tokenKind
+	value string
+}
+
+// lexer splits a query into tokens, dropping whitespace, commas and comments
+type lexer struct {
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+func (l *lexer) errorf(format string, args ...any) error {
+	return &SyntaxError{Position: Position{l.line, l.col}, Message: fmt.Sprintf(format, args...)}
+}
+
+func (l *lexer) advance(n int) {
+	for range n {
+		if l.src[l.pos] == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+		l.pos++
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '#' {
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.advance(1)
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != ',' {
+			break
+		}
+		l.advance(1)
+	}
+
+	tok := token{Position: Position{l.line, l.col}}
+	if l.pos >= len(l.src) {
+		return tok, nil
+	}
+
+	rest := l.src[l.pos:]
+	c := rest[0]
+	switch {
+	case strings.HasPrefix(rest, "..."):
+		tok.kind, tok.value = tokenPunct, "..."
+		l.advance(3)
+	case strings.ContainsRune("!$&()=:@[]{}|", rune(c)):
+		tok.kind, tok.value = tokenPunct, string(c)
+		l.advance(1)
+	case c == '_' || isLetter(c):
+		n := 1
+		for n < len(rest) && (rest[n] == '_' || isLetter(rest[n]) || isDigit(rest[n])) {
+			n++
+		}
+		tok.kind, tok.value = tokenName, rest[:n]
+		l.advance(n)
+	case c == '-' || isDigit(c):
+		n := 1
+		for n < len(rest) && (isDigit(rest[n]) || strings.ContainsRune(".eE+-", rune(rest[n]))) {
+			n++
+		}
+		tok.kind, tok.value = tokenNumber, rest[:n]
+		l.advance(n)
+	case strings.HasPrefix(rest, `"""`):
+		end := -1
+		for i := 3; i+3 <= len(rest); i++ {
+			if rest[i] == '\\' && strings.HasPrefix(rest[i+1:], `"""`) {
+				i += 3
+				continue
+			}
+			if strings.HasPrefix(rest[i:], `"""`) {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return tok, l.errorf("unterminated block string")
+		}
+		tok.kind, tok.value = tokenString, rest[3:end]
+		l.advance(end + 3)
+	case c == '"':
+		n := 1
+		for n < len(rest) && rest[n] != '"' && rest[n] != '\n' {
+			if rest[n] == '\\' {
+				n++
+			}
+			n++
+		}
+		if n >= len(rest) || rest[n] != '"' {
+			return tok, l.errorf("unterminated string")
+		}
+		tok.kind, tok.value = tokenString, rest[1:n]
+		l.advance(n + 1)
+	default:
+		return tok, l.errorf("unexpected character %q", c)
+	}
+	return tok, nil
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parser is a recursive descent parser over the lexer's tokens with one token of lookahead
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+// parse parses an executable document: operations and fragment definitions
+func parse(query string) (*document, error) {
+	p := &parser{lex: &lexer{src: strings.TrimPrefix(query, "\uFEFF"), line: 1, col: 1}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek("{"):
+			op := &operation{Position: p.tok.Position, kind: "query"}
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			op.selections = sels
+			doc.operations = append(doc.operations, op)
+		case p.peekName("query"), p.peekName("mutation"), p.peekName("subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.peekName("fragment"):
+			frag, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[frag.name]; ok {
+				return nil, &SyntaxError{Position: frag.Position, Message: fmt.Sprintf("fragment %q is defined more than once", frag.name)}
+			}
+			doc.fragments[frag.name] = frag
+		default:
+			return nil, p.unexpected()
+		}
+	}
+
+	if len(doc.operations) == 0 {
+		return nil, &SyntaxError{Position: p.tok.Position, Message: "no operation found"}
+	}
+	return doc, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) peek(punct string) bool {
+	return p.tok.kind == tokenPunct && p.tok.value == punct
+}
+
+func (p *parser) peekName(name string) bool {
+	return p.tok.kind == tokenName && p.tok.value == name
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokenEOF {
+		return &SyntaxError{Position: p.tok.Position, Message: "unexpected end of query"}
+	}
+	return &SyntaxError{Position: p.tok.Position, Message: fmt.Sprintf("unexpected %q", p.tok.value)}
+}
+
+func (p *parser) expect(punct string) error {
+	if !p.peek(punct) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.unexpected()
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{Position: p.tok.Position, kind: p.tok.value}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenName {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek("(") {
+		if err := p.variableDefinitions(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = sels
+	return op, nil
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	frag := &fragment{Position: p.tok.Position}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if !p.peekName("on") {
+		return nil, p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	typeCondition, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	frag.name, frag.typeCondition, frag.selections = name, typeCondition, sels
+	return frag, nil
+}
+
+// variableDefinitions skips ($name: Type = default @directive ...)
+func (p *parser) variableDefinitions() error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for !p.peek(")") {
+		if err := p.expect("$"); err != nil {
+			return err
+		}
+		if _, err := p.name(); err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		if err := p.typeRef(); err != nil {
+			return err
+		}
+		if p.peek("=") {
+			if err := p.advance(); err != nil {
+				return err
+			}
+			if err := p.value(); err != nil {
+				return err
+			}
+		}
+		if err := p.directives(); err != nil {
+			return err
+		}
+	}
+	return p.advance()
+}
+
+func (p *parser) typeRef() error {
+	if p.peek("[") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.typeRef(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+	if p.peek("!") {
+		return p.advance()
+	}
+	return nil
+}
+
+func (p *parser) directives() error {
+	for p.peek("@") {
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if _, err := p.name(); err != nil {
+			return err
+		}
+		if p.peek("(") {
+			if _, err := p.arguments(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *parser) selectionSet() ([]*selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var sels []*selection
+	for !p.peek("}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	if len(sels) == 0 {
+		return nil, &SyntaxError{Position: p.tok.Position, Message: "empty selection set"}
+	}
+	return sels, p.advance()
+}
+
+func (p *parser) selection() (*selection, error) {
+	sel := &selection{Position: p.tok.Position}
+
+	if p.peek("...") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokenName && !p.peekName("on") {
+			sel.kind = fragmentSpread
+			sel.name = p.tok.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			return sel, p.directives()
+		}
+
+		sel.kind = inlineFragment
+		if p.peekName("on") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			typeCondition, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			sel.typeCondition = typeCondition
+		}
+		if err := p.directives(); err != nil {
+			return nil, err
+		}
+		sels, err := p.selectionSet()
+		if err != nil {
+			return nil, err
+		}
+		sel.selections = sels
+		return sel, nil
+	}
+
+	sel.kind = fieldSelection
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	// An alias comes first: alias: name
+	if p.peek(":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		sel.Position = p.tok.Position
+		if name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	sel.name = name
+
+	if p.peek("(") {
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		sel.arguments = args
+	}
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		sels, err := p.selectionSet()
+		if err != nil {
+			return nil, err
+		}
+		sel.selections = sels
+	}
+	return sel, nil
+}
+
+func (p *parser) arguments() ([]argument, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []argument
+	for !p.peek(")") {
+		arg := argument{Position: p.tok.Position}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		arg.name = name
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if err := p.value(); err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, p.advance()
+}
+
+// value skips a value: a variable, scalar, enum, list or input object
+func (p *parser) value() error {
+	switch {
+	case p.peek("$"):
+		if err := p.advance(); err != nil {
+			return err
+		}
+		_, err := p.name()
+		return err
+	case p.peek("["):
+		if err := p.advance(); err != nil {
+			return err
+		}
+		for !p.peek("]") {
+			if err := p.value(); err != nil {
+				return err
+			}
+		}
+		return p.advance()
+	case p.peek("{"):
+		if err := p.advance(); err != nil {
+			return err
+		}
+		for !p.peek("}") {
+			if _, err := p.name(); err != nil {
+				return err
+			}
+			if err := p.expect(":"); err != nil {
+				return err
+			}
+			if err := p.value(); err != nil {
+				return err
+			}
+		}
+		return p.advance()
+	case p.tok.kind == tokenName, p.tok.kind == tokenNumber, p.tok.kind == tokenString:
+		return p.advance()
+	}
+	return p.unexpected()
+}
@@ -0,0 +1,235 @@
+// Package graphql checks GraphQL queries against a server's schema, fetched by
+// introspection, so that unknown fields and arguments are reported before a request
+// is sent
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// IntrospectionQuery is the standard full introspection query. Validation only needs
+// each type's fields and their arguments; the rest (descriptions, enum values, input
+// fields and directives) is kept for clients that use the cached schema for autocomplete.
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives {
+      name
+      description
+      locations
+      args { ...InputValue }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) {
+    name
+    description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } } }
+}`
+
+// ErrIntrospectionRejected is returned when the server answers the introspection query
+// with errors instead of a schema, which is usually because introspection is disabled
+var ErrIntrospectionRejected = errors.New("the server rejected the introspection query; introspection may be disabled")
+
+// Type kinds reported by introspection
+const (
+	KindScalar      = "SCALAR"
+	KindObject      = "OBJECT"
+	KindInterface   = "INTERFACE"
+	KindUnion       = "UNION"
+	KindEnum        = "ENUM"
+	KindInputObject = "INPUT_OBJECT"
+	KindList        = "LIST"
+	KindNonNull     = "NON_NULL"
+)
+
+// Schema is an introspected schema, as returned in the __schema field of IntrospectionQuery
+type Schema struct {
+	QueryType        *TypeName `json:"queryType"`
+	MutationType     *TypeName `json:"mutationType"`
+	SubscriptionType *TypeName `json:"subscriptionType"`
+	Types            []*Type   `json:"types"`
+
+	types map[string]*Type
+}
+
+// TypeName refers to a type by name
+type TypeName struct {
+	Name string `json:"name"`
+}
+
+// Type is a named type in the schema
+type Type struct {
+	Kind          string     `json:"kind"`
+	Name          string     `json:"name"`
+	Fields        []*Field   `json:"fields"`
+	PossibleTypes []TypeName `json:"possibleTypes"`
+}
+
+// Field is a field of an object or interface type
+type Field struct {
+	Name string        `json:"name"`
+	Args []*InputValue `json:"args"`
+	Type *TypeRef      `json:"type"`
+}
+
+// InputValue is an argument of a field
+type InputValue struct {
+	Name         string   `json:"name"`
+	DefaultValue *string  `json:"defaultValue"`
+	Type         *TypeRef `json:"type"`
+}
+
+// TypeRef is a possibly wrapped reference to a named type, such as [User!]!
+type TypeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *TypeRef `json:"ofType"`
+}
+
+// ParseIntrospection reads a schema from an introspection response, either the whole
+// response ({"data": {"__schema": ...}}) or just its data
+func ParseIntrospection(data []byte) (*Schema, error) {
+	var response struct {
+		Data *struct {
+			Schema *Schema `json:"__schema"`
+		} `json:"data"`
+		Schema *Schema `json:"__schema"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse introspection response: %w", err)
+	}
+
+	schema := response.Schema
+	if response.Data != nil && response.Data.Schema != nil {
+		schema = response.Data.Schema
+	}
+	if schema == nil {
+		if len(response.Errors) > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrIntrospectionRejected, response.Errors[0].Message)
+		}
+		return nil, errors.New("introspection response has no __schema")
+	}
+	if schema.QueryType == nil {
+		return nil, errors.New("introspected schema has no query type")
+	}
+
+	schema.types = make(map[string]*Type, len(schema.Types))
+	for _, t := range schema.Types {
+		schema.types[t.Name] = t
+	}
+	return schema, nil
+}
+
+// Type returns the named type, or nil if the schema has no such type
+func (s *Schema) Type(name string) *Type {
+	return s.types[name]
+}
+
+// rootType returns the root type for an operation kind, or nil if the schema does
+// not support the operation
+func (s *Schema) rootType(kind string) *Type {
+	var root *TypeName
+	switch kind {
+	case "query":
+		root = s.QueryType
+	case "mutation":
+		root = s.MutationType
+	case "subscription":
+		root = s.SubscriptionType
+	}
+	if root == nil {
+		return nil
+	}
+	return s.Type(root.Name)
+}
+
+// Field returns the named field, or nil if the type has no such field
+func (t *Type) Field(name string) *Field {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// composite reports whether a selection set is required on the type
+func (t *Type) composite() bool {
+	return t.Kind == KindObject || t.Kind == KindInterface || t.Kind == KindUnion
+}
+
+// arg returns the named argument, or nil if the field has no such argument
+func (f *Field) arg(name string) *InputValue {
+	for _, a := range f.Args {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// NamedType returns the name of the type inside any list and non-null wrappers
+func (r *TypeRef) NamedType() string {
+	for r.OfType != nil {
+		r = r.OfType
+	}
+	return r.Name
+}
+
+// String formats the reference as in a query, such as [User!]!
+func (r *TypeRef) String() string {
+	switch r.Kind {
+	case KindNonNull:
+		if r.OfType != nil {
+			return r.OfType.String() + "!"
+		}
+	case KindList:
+		if r.OfType != nil {
+			return "[" + r.OfType.String() + "]"
+		}
+	}
+	return r.Name
+}
+
+// required reports whether an argument must be given: it is non-null without a default
+func (v *InputValue) required() bool {
+	return v.Type != nil && v.Type.Kind == KindNonNull && v.DefaultValue == nil
+}
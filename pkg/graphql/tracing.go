@@ -0,0 +1,79 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Tracing is the Apollo tracing extension (extensions.tracing) a server can add to a
+// response, with the time spent in each resolver. Durations are in nanoseconds.
+type Tracing struct {
+	Version   int       `json:"version"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Duration  int64     `json:"duration"`
+	Execution struct {
+		Resolvers []ResolverTrace `json:"resolvers"`
+	} `json:"execution"`
+}
+
+// ResolverTrace is one resolver call in a Tracing
+type ResolverTrace struct {
+	Path        []any  `json:"path"` // Field names and list indexes from the response root
+	ParentType  string `json:"parentType"`
+	FieldName   string `json:"fieldName"`
+	ReturnType  string `json:"returnType"`
+	StartOffset int64  `json:"startOffset"` // Nanoseconds after Tracing.StartTime
+	Duration    int64  `json:"duration"`
+}
+
+// ParseTracing returns the tracing extension of a GraphQL response, or nil if the
+// response has none or is not JSON
+func ParseTracing(responseBody []byte) *Tracing {
+	var response struct {
+		Extensions struct {
+			Tracing *Tracing `json:"tracing"`
+		} `json:"extensions"`
+	}
+	if err := json.Unmarshal(responseBody, &response); err != nil {
+		return nil
+	}
+	return response.Extensions.Tracing
+}
+
+// PathString formats the resolver's path as dotted segments, such as user.friends.0
+func (r ResolverTrace) PathString() string {
+	parts := make([]string, len(r.Path))
+	for i, p := range r.Path {
+		parts[i] = fmt.Sprint(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Start returns when the resolver started
+func (t *Tracing) Start(r ResolverTrace) time.Time {
+	return t.StartTime.Add(time.Duration(r.StartOffset))
+}
+
+// ResolverAt returns the index of the resolver running at the given time, or -1 if
+// none was. Where windows overlap, as with sibling resolvers awaited together, the one
+// that started last is the most specific; of those starting together, the shortest is.
+func (t *Tracing) ResolverAt(at time.Time) int {
+	found := -1
+	for i, r := range t.Execution.Resolvers {
+		start := t.Start(r)
+		if at.Before(start) || at.After(start.Add(time.Duration(r.Duration))) {
+			continue
+		}
+		if found >= 0 {
+			best := t.Execution.Resolvers[found]
+			if r.StartOffset < best.StartOffset || r.StartOffset == best.StartOffset && r.Duration >= best.Duration {
+				continue
+			}
+		}
+		found = i
+	}
+	return found
+}
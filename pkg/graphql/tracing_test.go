@@ -0,0 +1,43 @@
+package graphql
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseTracing(t *testing.T) {
+	body, err := os.ReadFile("testdata/apollo_tracing_response.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	tracing := ParseTracing(body)
+	if tracing == nil || len(tracing.Execution.Resolvers) != 5 {
+		t.Fatalf("Expected 5 resolvers, got %+v", tracing)
+	}
+	if got := tracing.Execution.Resolvers[3].PathString(); got != "user.posts.0.title" {
+		t.Errorf("Expected user.posts.0.title, got %s", got)
+	}
+
+	tests := []struct {
+		offset time.Duration
+		want   int
+	}{
+		{3 * time.Millisecond, 0},     // Only user is running
+		{6110 * time.Microsecond, 1},  // name and posts started together; name is shorter
+		{10 * time.Millisecond, 2},    // posts is waiting on its query
+		{18205 * time.Microsecond, 3}, // The first title
+		{500 * time.Microsecond, -1},  // Still validating
+		{25 * time.Millisecond, -1},   // After the response
+	}
+	for _, tt := range tests {
+		if got := tracing.ResolverAt(tracing.StartTime.Add(tt.offset)); got != tt.want {
+			t.Errorf("ResolverAt(+%s) = %d, want %d", tt.offset, got, tt.want)
+		}
+	}
+
+	if ParseTracing([]byte(`{"data": {}}`)) != nil || ParseTracing([]byte("not json")) != nil {
+		t.Error("Expected no tracing for a response without the extension")
+	}
+}
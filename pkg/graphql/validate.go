@@ -0,0 +1,176 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidationError is a problem with a query that the server's schema would reject
+type ValidationError struct {
+	Message string `json:"message"`
+	Position
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// ValidateAgainstSchema reports the fields, arguments, types and fragments in query
+// that schema does not define, and the required arguments it leaves out. A query
+// that cannot be parsed is reported as a single error. Variable and argument values
+// are not type checked.
+func ValidateAgainstSchema(query string, schema *Schema) []ValidationError {
+	doc, err := parse(query)
+	if err != nil {
+		var syntaxErr *SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return []ValidationError{{Message: "Syntax error: " + syntaxErr.Message, Position: syntaxErr.Position}}
+		}
+		return []ValidationError{{Message: err.Error()}}
+	}
+
+	v := &validator{schema: schema, doc: doc}
+	for _, op := range doc.operations {
+		root := schema.rootType(op.kind)
+		if root == nil {
+			v.errorf(op.Position, "Schema does not support %s operations", op.kind)
+			continue
+		}
+		v.selections(root, op.selections, root == schema.rootType("query"))
+	}
+
+	// Fragments are checked once each against their own type condition, however
+	// many times they are spread
+	names := make([]string, 0, len(doc.fragments))
+	for name := range doc.fragments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		frag := doc.fragments[name]
+		if t := v.compositeType(frag.Position, frag.typeCondition); t != nil {
+			v.selections(t, frag.selections, false)
+		}
+	}
+
+	sort.SliceStable(v.errors, func(i, j int) bool {
+		a, b := v.errors[i].Position, v.errors[j].Position
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return v.errors
+}
+
+type validator struct {
+	schema *Schema
+	doc    *document
+	errors []ValidationError
+}
+
+func (v *validator) errorf(pos Position, format string, args ...any) {
+	v.errors = append(v.errors, ValidationError{Message: fmt.Sprintf(format, args...), Position: pos})
+}
+
+// compositeType returns the named type a fragment applies to, reporting it when
+// the schema has no such type or it cannot have selections
+func (v *validator) compositeType(pos Position, name string) *Type {
+	t := v.schema.Type(name)
+	if t == nil {
+		v.errorf(pos, "Unknown type %q", name)
+		return nil
+	}
+	if !t.composite() {
+		v.errorf(pos, "Fragment cannot condition on non composite type %q", name)
+		return nil
+	}
+	return t
+}
+
+// selections checks a selection set against the type it selects from. The
+// introspection fields __schema and __type are only allowed on the query root.
+func (v *validator) selections(parent *Type, sels []*selection, queryRoot bool) {
+	for _, sel := range sels {
+		switch sel.kind {
+		case fragmentSpread:
+			if _, ok := v.doc.fragments[sel.name]; !ok {
+				v.errorf(sel.Position, "Unknown fragment %q", sel.name)
+			}
+		case inlineFragment:
+			t := parent
+			if sel.typeCondition != "" {
+				if t = v.compositeType(sel.Position, sel.typeCondition); t == nil {
+					continue
+				}
+			}
+			v.selections(t, sel.selections, queryRoot && t == parent)
+		case fieldSelection:
+			v.field(parent, sel, queryRoot)
+		}
+	}
+}
+
+func (v *validator) field(parent *Type, sel *selection, queryRoot bool) {
+	switch {
+	case sel.name == "__typename":
+		return
+	case queryRoot && (sel.name == "__schema" || sel.name == "__type"):
+		// Introspection types are not listed in every schema, so they are not checked
+		return
+	}
+
+	field := parent.Field(sel.name)
+	if field == nil {
+		if parent.Kind == KindUnion {
+			possible := make([]string, len(parent.PossibleTypes))
+			for i, t := range parent.PossibleTypes {
+				possible[i] = t.Name
+			}
+			v.errorf(sel.Position, "Cannot query field %q on union %q; select it in a fragment on %s", sel.name, parent.Name, typeList(possible))
+		} else {
+			v.errorf(sel.Position, "Cannot query field %q on type %q", sel.name, parent.Name)
+		}
+		return
+	}
+
+	given := make(map[string]bool, len(sel.arguments))
+	for _, arg := range sel.arguments {
+		given[arg.name] = true
+		if field.arg(arg.name) == nil {
+			v.errorf(arg.Position, "Unknown argument %q on field \"%s.%s\"", arg.name, parent.Name, field.Name)
+		}
+	}
+	for _, arg := range field.Args {
+		if arg.required() && !given[arg.Name] {
+			v.errorf(sel.Position, "Field \"%s.%s\" argument %q of type %q is required", parent.Name, field.Name, arg.Name, arg.Type.String())
+		}
+	}
+
+	if field.Type == nil {
+		return
+	}
+	t := v.schema.Type(field.Type.NamedType())
+	if t == nil {
+		return
+	}
+	switch {
+	case t.composite() && len(sel.selections) == 0:
+		v.errorf(sel.Position, "Field \"%s.%s\" of type %q must have a selection of subfields", parent.Name, field.Name, field.Type.String())
+	case !t.composite() && len(sel.selections) > 0:
+		v.errorf(sel.Position, "Field \"%s.%s\" must not have a selection since type %q has no subfields", parent.Name, field.Name, field.Type.String())
+	case t.composite():
+		v.selections(t, sel.selections, false)
+	}
+}
+
+// typeList formats type names for messages, such as "A", "B" or "C"
+func typeList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	if len(quoted) <= 1 {
+		return strings.Join(quoted, "")
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
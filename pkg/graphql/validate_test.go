@@ -0,0 +1,167 @@
+package graphql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testIntrospection is the introspection response for:
+//
+//	type Query { user(id: ID!): User, search(term: String, limit: Int = 10): [Result!]! }
+//	type Mutation { rename(id: ID!, name: String!): User }
+//	type User { id: ID!, name: String, friends(first: Int): [User] }
+//	type Post { title: String }
+//	union Result = User | Post
+const testIntrospection = `{"data": {"__schema": {
+  "queryType": {"name": "Query"},
+  "mutationType": {"name": "Mutation"},
+  "subscriptionType": null,
+  "types": [
+    {"kind": "OBJECT", "name": "Query", "fields": [
+      {"name": "user", "args": [{"name": "id", "defaultValue": null, "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID", "ofType": null}}}],
+       "type": {"kind": "OBJECT", "name": "User", "ofType": null}},
+      {"name": "search", "args": [
+         {"name": "term", "defaultValue": null, "type": {"kind": "SCALAR", "name": "String", "ofType": null}},
+         {"name": "limit", "defaultValue": "10", "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "Int", "ofType": null}}}],
+       "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "LIST", "name": null, "ofType": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "UNION", "name": "Result", "ofType": null}}}}}
+    ]},
+    {"kind": "OBJECT", "name": "Mutation", "fields": [
+      {"name": "rename", "args": [
+         {"name": "id", "defaultValue": null, "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID", "ofType": null}}},
+         {"name": "name", "defaultValue": null, "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "String", "ofType": null}}}],
+       "type": {"kind": "OBJECT", "name": "User", "ofType": null}}
+    ]},
+    {"kind": "OBJECT", "name": "User", "fields": [
+      {"name": "id", "args": [], "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID", "ofType": null}}},
+      {"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String", "ofType": null}},
+      {"name": "friends", "args": [{"name": "first", "defaultValue": null, "type": {"kind": "SCALAR", "name": "Int", "ofType": null}}],
+       "type": {"kind": "LIST", "name": null, "ofType": {"kind": "OBJECT", "name": "User", "ofType": null}}}
+    ]},
+    {"kind": "OBJECT", "name": "Post", "fields": [
+      {"name": "title", "args": [], "type": {"kind": "SCALAR", "name": "String", "ofType": null}}
+    ]},
+    {"kind": "UNION", "name": "Result", "fields": null, "possibleTypes": [{"name": "User"}, {"name": "Post"}]},
+    {"kind": "SCALAR", "name": "ID", "fields": null},
+    {"kind": "SCALAR", "name": "String", "fields": null},
+    {"kind": "SCALAR", "name": "Int", "fields": null}
+  ]
+}}}`
+
+func testSchema(t *testing.T) *Schema {
+	t.Helper()
+	schema, err := ParseIntrospection([]byte(testIntrospection))
+	if err != nil {
+		t.Fatalf("ParseIntrospection: %v", err)
+	}
+	return schema
+}
+
+func TestParseIntrospection(t *testing.T) {
+	schema := testSchema(t)
+	if schema.Type("User") == nil || schema.Type("Missing") != nil {
+		t.Error("Expected the schema's types to be indexed by name")
+	}
+	if got := schema.Type("Query").Field("search").Type.String(); got != "[Result!]!" {
+		t.Errorf("Expected [Result!]!, got %s", got)
+	}
+
+	if _, err := ParseIntrospection([]byte(`{"errors": [{"message": "introspection is disabled"}]}`)); !errors.Is(err, ErrIntrospectionRejected) || !strings.Contains(err.Error(), "introspection is disabled") {
+		t.Errorf("Expected the server's error, got %v", err)
+	}
+	if _, err := ParseIntrospection([]byte(`{"data": {}}`)); err == nil {
+		t.Error("Expected an error for a response without __schema")
+	}
+}
+
+func TestValidateAgainstSchema(t *testing.T) {
+	schema := testSchema(t)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string // Error messages, with their line:col prefix
+	}{
+		{
+			name: "valid",
+			query: `query GetUser($id: ID!, $n: Int = 5) @cached {
+  user(id: $id) { id name friends(first: $n) { ...UserFields } }
+  search(term: "a, \"b\"", limit: 3) { __typename ... on Post { title } ...UserFields }
+  __schema { types { name } }
+}
+fragment UserFields on User { id # comment
+  alias: name }`,
+		},
+		{
+			name:  "shorthand and mutation",
+			query: `{ user(id: 1) { name } } mutation { rename(id: 1, name: """new "name" \""" """) { id } }`,
+		},
+		{
+			name:  "unknown field",
+			query: "{\n  user(id: 1) { id nmae }\n}",
+			want:  []string{`2:20: Cannot query field "nmae" on type "User"`},
+		},
+		{
+			name:  "unknown and missing arguments",
+			query: `{ user(userId: 1) { id } }`,
+			want: []string{
+				`1:3: Field "Query.user" argument "id" of type "ID!" is required`,
+				`1:8: Unknown argument "userId" on field "Query.user"`,
+			},
+		},
+		{
+			name:  "field on a union",
+			query: `{ search { title } }`,
+			want:  []string{`1:12: Cannot query field "title" on union "Result"; select it in a fragment on "User" or "Post"`},
+		},
+		{
+			name:  "subfields",
+			query: `{ user(id: 1) { name { first } friends } }`,
+			want: []string{
+				`1:17: Field "User.name" must not have a selection since type "String" has no subfields`,
+				`1:32: Field "User.friends" of type "[User]" must have a selection of subfields`,
+			},
+		},
+		{
+			name:  "fragments",
+			query: "{ user(id: 1) { ...Missing ... on Robot { id } ...F } }\nfragment F on User { age }",
+			want: []string{
+				`1:17: Unknown fragment "Missing"`,
+				`1:28: Unknown type "Robot"`,
+				`2:22: Cannot query field "age" on type "User"`,
+			},
+		},
+		{
+			name:  "unsupported operation",
+			query: `subscription { user(id: 1) { id } }`,
+			want:  []string{`1:1: Schema does not support subscription operations`},
+		},
+		{
+			name:  "introspection only on the root",
+			query: `{ user(id: 1) { __schema { types { name } } } }`,
+			want:  []string{`1:17: Cannot query field "__schema" on type "User"`},
+		},
+		{
+			name:  "syntax error",
+			query: "{ user(id: 1) {\n  id\n}",
+			want:  []string{`3:2: Syntax error: unexpected end of query`},
+		},
+		{
+			name:  "unterminated string",
+			query: `{ user(id: "1) { id } }`,
+			want:  []string{`1:12: Syntax error: unterminated string`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range ValidateAgainstSchema(tt.query, schema) {
+				got = append(got, err.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Expected errors:\n%s\ngot:\n%s", strings.Join(tt.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
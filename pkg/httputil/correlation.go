@@ -0,0 +1,160 @@
+package httputil
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"docker-log-parser/pkg/logs"
+)
+
+// Correlation header formats
+const (
+	CorrelationFormatID          = "id"          // Short random ID, matched against request ID log fields
+	CorrelationFormatTraceparent = "traceparent" // W3C trace context, matched against trace ID log fields
+)
+
+// DefaultCorrelationHeader is sent when a server does not configure its own header
+const DefaultCorrelationHeader = "X-Request-Id"
+
+var (
+	requestIDFields = []string{"request_id", "requestId", "requestID", "req_id"}
+	traceIDFields   = []string{"trace_id", "traceId", "traceID"}
+)
+
+// Correlation is the header sent with an outgoing request and the ID its logs are expected to carry
+type Correlation struct {
+	Header string // Outgoing header name
+	Value  string // Outgoing header value
+	ID     string // ID the server logs for this request
+	Field  string // Log field carrying ID in the log store
+}
+
+// NewCorrelation generates a correlation for a request using the given header name and format.
+// An empty header uses DefaultCorrelationHeader, or "traceparent" for the traceparent format.
+func NewCorrelation(header, format string) Correlation {
+	return NewCorrelationWithID(header, format, "")
+}
+
+// NewCorrelationWithID is NewCorrelation sending requestID instead of a random ID.
+// The traceparent format needs a random trace ID, so it ignores requestID.
+func NewCorrelationWithID(header, format, requestID string) Correlation {
+	if format == CorrelationFormatTraceparent {
+		if header == "" {
+			header = "traceparent"
+		}
+		traceID := randomHex(16)
+		return Correlation{
+			Header: header,
+			Value:  fmt.Sprintf("00-%s-%s-01", traceID, randomHex(8)),
+			ID:     traceID,
+			Field:  "trace_id",
+		}
+	}
+
+	if header == "" {
+		header = DefaultCorrelationHeader
+	}
+	if requestID == "" {
+		requestID = GenerateRequestID()
+	}
+	return Correlation{
+		Header: header,
+		Value:  requestID,
+		ID:     requestID,
+		Field:  "request_id",
+	}
+}
+
+// ValidCorrelationFormat reports whether format is a supported correlation format.
+// An empty format is treated as CorrelationFormatID.
+func ValidCorrelationFormat(format string) bool {
+	return format == "" || format == CorrelationFormatID || format == CorrelationFormatTraceparent
+}
+
+// ValidRequestID reports whether id can be sent as a request ID: up to 64 letters, digits
+// and the separators - _ . :, so it survives headers and log field parsing unchanged
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDSequence hands out request IDs made of a prefix and a counter, such as
+// myrun-0001, so a run can be found again in external logging systems. IDs are unique
+// within the sequence. It is safe for concurrent use.
+type RequestIDSequence struct {
+	prefix string
+	next   atomic.Int64
+}
+
+// NewRequestIDSequence returns a sequence of IDs starting with prefix, or nil when prefix
+// is empty so correlations keep random IDs
+func NewRequestIDSequence(prefix string) (*RequestIDSequence, error) {
+	if prefix == "" {
+		return nil, nil
+	}
+	if !ValidRequestID(prefix) {
+		return nil, fmt.Errorf("invalid request ID prefix %q: use letters, digits, -, _, . or :", prefix)
+	}
+	return &RequestIDSequence{prefix: prefix}, nil
+}
+
+// Next returns the next ID in the sequence, or a random ID for a nil sequence
+func (s *RequestIDSequence) Next() string {
+	if s == nil {
+		return GenerateRequestID()
+	}
+	return fmt.Sprintf("%s-%04d", s.prefix, s.next.Add(1))
+}
+
+// NewCorrelation generates a correlation like the package-level NewCorrelation, sending
+// the sequence's next ID in the ID format
+func (s *RequestIDSequence) NewCorrelation(header, format string) Correlation {
+	if s == nil || format == CorrelationFormatTraceparent {
+		return NewCorrelation(header, format)
+	}
+	return NewCorrelationWithID(header, format, s.Next())
+}
+
+// Apply sets the correlation header on an outgoing request
+func (c Correlation) Apply(req *http.Request) {
+	req.Header.Set(c.Header, c.Value)
+}
+
+// Matches reports whether a log message carries the correlation ID
+func (c Correlation) Matches(msg logs.ContainerMessage) bool {
+	if msg.Entry == nil || msg.Entry.Fields == nil {
+		return false
+	}
+
+	fields := requestIDFields
+	if c.Field == "trace_id" {
+		fields = traceIDFields
+	}
+	for _, field := range fields {
+		if val, ok := msg.Entry.Fields[field]; ok && strings.EqualFold(val, c.ID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
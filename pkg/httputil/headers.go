@@ -0,0 +1,121 @@
+package httputil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// traceHeaders are response headers that commonly carry a trace or request ID, in order of preference
+var traceHeaders = []string{"Traceparent", "X-Trace-Id", "X-B3-Traceid", "X-Amzn-Trace-Id", "X-Cloud-Trace-Context"}
+
+// requestIDHeaders are response headers that commonly echo a request ID, in order of preference
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "Request-Id"}
+
+// ResponseHeaders is a parsed set of response headers with commonly analyzed values extracted
+type ResponseHeaders struct {
+	Headers      map[string][]string  `json:"headers"`
+	ServerTiming []ServerTimingMetric `json:"serverTiming,omitempty"`
+	RateLimit    *RateLimit           `json:"rateLimit,omitempty"`
+	TraceID      string               `json:"traceId,omitempty"`
+	RequestID    string               `json:"requestId,omitempty"`
+}
+
+// ServerTimingMetric is a single metric from a Server-Timing header
+type ServerTimingMetric struct {
+	Name        string  `json:"name"`
+	Duration    float64 `json:"duration"` // milliseconds
+	Description string  `json:"description,omitempty"`
+}
+
+// RateLimit holds values from X-RateLimit-* / RateLimit-* headers
+type RateLimit struct {
+	Limit     string `json:"limit,omitempty"`
+	Remaining string `json:"remaining,omitempty"`
+	Reset     string `json:"reset,omitempty"`
+}
+
+// ParseResponseHeaders parses headers stored as JSON (as captured by MakeHTTPRequest)
+// and extracts server timing, rate limit and trace values
+func ParseResponseHeaders(headersJSON string) (*ResponseHeaders, error) {
+	if headersJSON == "" {
+		return nil, nil
+	}
+
+	var raw map[string][]string
+	if err := json.Unmarshal([]byte(headersJSON), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse response headers: %w", err)
+	}
+
+	// Canonicalize so lookups work regardless of how the headers were stored
+	headers := make(http.Header, len(raw))
+	for k, values := range raw {
+		for _, v := range values {
+			headers.Add(k, v)
+		}
+	}
+
+	parsed := &ResponseHeaders{
+		Headers:   headers,
+		TraceID:   firstHeader(headers, traceHeaders),
+		RequestID: firstHeader(headers, requestIDHeaders),
+	}
+
+	for _, value := range headers.Values("Server-Timing") {
+		parsed.ServerTiming = append(parsed.ServerTiming, ParseServerTiming(value)...)
+	}
+
+	rateLimit := RateLimit{
+		Limit:     firstHeader(headers, []string{"X-Ratelimit-Limit", "Ratelimit-Limit"}),
+		Remaining: firstHeader(headers, []string{"X-Ratelimit-Remaining", "Ratelimit-Remaining"}),
+		Reset:     firstHeader(headers, []string{"X-Ratelimit-Reset", "Ratelimit-Reset"}),
+	}
+	if rateLimit != (RateLimit{}) {
+		parsed.RateLimit = &rateLimit
+	}
+
+	return parsed, nil
+}
+
+// ParseServerTiming parses a Server-Timing header value, e.g.
+// `db;dur=53.2;desc="Database", app;dur=47.2, cache;desc="Cache Read"`
+func ParseServerTiming(value string) []ServerTimingMetric {
+	var metrics []ServerTimingMetric
+
+	for entry := range strings.SplitSeq(value, ",") {
+		parts := strings.Split(entry, ";")
+		name := strings.TrimSpace(parts[0])
+		if name == "" {
+			continue
+		}
+
+		metric := ServerTimingMetric{Name: name}
+		for _, param := range parts[1:] {
+			key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+			val = strings.Trim(strings.TrimSpace(val), `"`)
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "dur":
+				if d, err := strconv.ParseFloat(val, 64); err == nil {
+					metric.Duration = d
+				}
+			case "desc":
+				metric.Description = val
+			}
+		}
+		metrics = append(metrics, metric)
+	}
+
+	return metrics
+}
+
+// firstHeader returns the first non-empty value among the given header names
+func firstHeader(headers http.Header, names []string) string {
+	for _, name := range names {
+		if v := headers.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -2,8 +2,7 @@ package httputil
 
 import (
 	"bytes"
-	"crypto/rand"
-	"encoding/hex"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,24 +17,23 @@ import (
 
 // GenerateRequestID generates a random 8-character hex string for request tracking
 func GenerateRequestID() string {
-	b := make([]byte, 4)
-	rand.Read(b)
-	return hex.EncodeToString(b)
+	return randomHex(4)
 }
 
-// MakeHTTPRequest executes an HTTP POST request with the given parameters
+// MakeHTTPRequest executes an HTTP POST request with the given parameters. Cancelling ctx
+// aborts the request.
 // Returns: statusCode, responseBody, responseHeaders (as JSON), error
-func MakeHTTPRequest(url string, data []byte, requestID, bearerToken, devID, experimentalMode string) (int, string, string, error) {
+func MakeHTTPRequest(ctx context.Context, url string, data []byte, correlation Correlation, bearerToken, devID, experimentalMode string) (int, string, string, error) {
 	// Replace localhost with host.docker.internal if running in Docker
 	url = utils.ReplaceLocalhostWithDockerHost(url)
 
-	req, err := http.NewRequest("POST", url, bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
 	if err != nil {
 		return 0, "", "", err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Request-Id", requestID)
+	correlation.Apply(req)
 
 	if bearerToken != "" {
 		req.Header.Set("Authorization", "Bearer "+bearerToken)
@@ -67,19 +65,38 @@ func MakeHTTPRequest(url string, data []byte, requestID, bearerToken, devID, exp
 
 // CollectLogsForRequest searches the log store for logs matching the given request ID
 func CollectLogsForRequest(requestID string, logStore *logstore.LogStore, timeout time.Duration) []logs.ContainerMessage {
+	return CollectLogsForRequestIDs([]string{requestID}, logStore, timeout)
+}
+
+// CollectLogsForRequestIDs searches the log store for logs matching any of the given request IDs.
+// Logs matching more than one ID are only returned once.
+func CollectLogsForRequestIDs(requestIDs []string, logStore *logstore.LogStore, timeout time.Duration) []logs.ContainerMessage {
+	filters := make([]logstore.FieldFilter, 0, len(requestIDs))
+	for _, requestID := range requestIDs {
+		filters = append(filters, logstore.FieldFilter{Name: "request_id", Value: requestID})
+	}
+	return CollectLogsMatchingAny(filters, logStore, timeout)
+}
+
+// CollectLogsMatchingAny searches the log store for logs matching any one of the given field filters.
+// Logs matching more than one filter are only returned once.
+func CollectLogsMatchingAny(filters []logstore.FieldFilter, logStore *logstore.LogStore, timeout time.Duration) []logs.ContainerMessage {
 	// Wait for logs to arrive
 	time.Sleep(timeout)
 
-	// Search LogStore for matching request ID
-	filters := []logstore.FieldFilter{
-		{Name: "request_id", Value: requestID},
-	}
-	storeResults := logStore.SearchByFields(filters, 100000)
+	seen := make(map[*logs.ContainerMessage]bool)
+	collected := make([]logs.ContainerMessage, 0)
+	for _, filter := range filters {
+		storeResults := logStore.SearchByFields([]logstore.FieldFilter{filter}, 100000)
 
-	// Convert pointers to values
-	collected := make([]logs.ContainerMessage, 0, len(storeResults))
-	for _, storeMsg := range storeResults {
-		collected = append(collected, *storeMsg)
+		// Convert pointers to values
+		for _, storeMsg := range storeResults {
+			if seen[storeMsg] {
+				continue
+			}
+			seen[storeMsg] = true
+			collected = append(collected, *storeMsg)
+		}
 	}
 
 	return collected
@@ -1,7 +1,15 @@
 package httputil
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+
+	"docker-log-parser/pkg/logs"
 )
 
 func TestGenerateRequestID(t *testing.T) {
@@ -23,7 +31,7 @@ func TestGenerateRequestID(t *testing.T) {
 }
 
 func TestMakeHTTPRequest_InvalidURL(t *testing.T) {
-	_, _, _, err := MakeHTTPRequest("://invalid-url", []byte("test"), "test-id", "", "", "")
+	_, _, _, err := MakeHTTPRequest(context.Background(), "://invalid-url", []byte("test"), NewCorrelation("", ""), "", "", "")
 	if err == nil {
 		t.Error("Expected error for invalid URL, got nil")
 	}
@@ -67,3 +75,230 @@ func TestContainsErrorsKey(t *testing.T) {
 		})
 	}
 }
+
+func TestParseResponseHeaders(t *testing.T) {
+	headersJSON := `{
+		"Content-Type": ["application/json"],
+		"Server-Timing": ["db;dur=53.2;desc=\"Database\", app;dur=47.2", "cache;desc=\"Cache Read\""],
+		"X-Ratelimit-Limit": ["100"],
+		"X-Ratelimit-Remaining": ["97"],
+		"x-trace-id": ["trace-abc"],
+		"X-Request-Id": ["req-123"]
+	}`
+
+	parsed, err := ParseResponseHeaders(headersJSON)
+	if err != nil {
+		t.Fatalf("ParseResponseHeaders returned error: %v", err)
+	}
+
+	if got := parsed.Headers["Content-Type"]; len(got) != 1 || got[0] != "application/json" {
+		t.Errorf("Expected Content-Type header, got %v", got)
+	}
+
+	if len(parsed.ServerTiming) != 3 {
+		t.Fatalf("Expected 3 server timing metrics, got %d: %+v", len(parsed.ServerTiming), parsed.ServerTiming)
+	}
+	db := parsed.ServerTiming[0]
+	if db.Name != "db" || db.Duration != 53.2 || db.Description != "Database" {
+		t.Errorf("Unexpected db metric: %+v", db)
+	}
+	if parsed.ServerTiming[1].Name != "app" || parsed.ServerTiming[1].Duration != 47.2 {
+		t.Errorf("Unexpected app metric: %+v", parsed.ServerTiming[1])
+	}
+	if parsed.ServerTiming[2].Duration != 0 || parsed.ServerTiming[2].Description != "Cache Read" {
+		t.Errorf("Unexpected cache metric: %+v", parsed.ServerTiming[2])
+	}
+
+	if parsed.RateLimit == nil || parsed.RateLimit.Limit != "100" || parsed.RateLimit.Remaining != "97" {
+		t.Errorf("Unexpected rate limit: %+v", parsed.RateLimit)
+	}
+	if parsed.TraceID != "trace-abc" {
+		t.Errorf("Expected trace ID trace-abc, got %q", parsed.TraceID)
+	}
+	if parsed.RequestID != "req-123" {
+		t.Errorf("Expected request ID req-123, got %q", parsed.RequestID)
+	}
+}
+
+func TestParseResponseHeaders_Empty(t *testing.T) {
+	parsed, err := ParseResponseHeaders("")
+	if err != nil || parsed != nil {
+		t.Errorf("Expected nil result for empty headers, got %+v, %v", parsed, err)
+	}
+
+	if _, err := ParseResponseHeaders("not json"); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+func TestNewCorrelation(t *testing.T) {
+	correlation := NewCorrelation("", "")
+	if correlation.Header != "X-Request-Id" || correlation.Field != "request_id" {
+		t.Errorf("Unexpected default correlation: %+v", correlation)
+	}
+	if correlation.Value != correlation.ID || len(correlation.ID) != 8 {
+		t.Errorf("Expected 8-character request ID sent as-is, got %+v", correlation)
+	}
+
+	custom := NewCorrelation("X-Correlation-ID", CorrelationFormatID)
+	if custom.Header != "X-Correlation-ID" {
+		t.Errorf("Expected custom header, got %s", custom.Header)
+	}
+
+	msg := logs.ContainerMessage{Entry: &logs.LogEntry{Fields: map[string]string{"requestId": custom.ID}}}
+	if !custom.Matches(msg) {
+		t.Error("Expected log with requestId field to match")
+	}
+}
+
+func TestNewCorrelationTraceparent(t *testing.T) {
+	correlation := NewCorrelation("", CorrelationFormatTraceparent)
+	if correlation.Header != "traceparent" || correlation.Field != "trace_id" {
+		t.Errorf("Unexpected traceparent correlation: %+v", correlation)
+	}
+
+	traceparent := regexp.MustCompile(`^00-([0-9a-f]{32})-[0-9a-f]{16}-01$`)
+	matches := traceparent.FindStringSubmatch(correlation.Value)
+	if matches == nil {
+		t.Fatalf("Invalid traceparent header: %s", correlation.Value)
+	}
+	if matches[1] != correlation.ID {
+		t.Errorf("Expected ID to be the embedded trace ID %s, got %s", matches[1], correlation.ID)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	correlation.Apply(req)
+	if req.Header.Get("traceparent") != correlation.Value {
+		t.Errorf("Expected traceparent header to be set, got %q", req.Header.Get("traceparent"))
+	}
+
+	traced := logs.ContainerMessage{Entry: &logs.LogEntry{Fields: map[string]string{"trace_id": correlation.ID}}}
+	if !correlation.Matches(traced) {
+		t.Error("Expected log with matching trace_id to match")
+	}
+	byRequestID := logs.ContainerMessage{Entry: &logs.LogEntry{Fields: map[string]string{"request_id": correlation.ID}}}
+	if correlation.Matches(byRequestID) {
+		t.Error("Expected traceparent correlation to ignore request_id fields")
+	}
+}
+
+func TestRequestIDSequence(t *testing.T) {
+	seq, err := NewRequestIDSequence("myrun")
+	if err != nil {
+		t.Fatalf("Failed to create sequence: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	ids := make([]string, 100)
+	for i := range ids {
+		wg.Go(func() {
+			ids[i] = seq.NewCorrelation("", CorrelationFormatID).ID
+		})
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if !strings.HasPrefix(id, "myrun-") || seen[id] {
+			t.Fatalf("Expected unique myrun- IDs, got duplicate or unprefixed %q", id)
+		}
+		seen[id] = true
+	}
+	if !seen["myrun-0001"] || !seen["myrun-0100"] {
+		t.Errorf("Expected IDs myrun-0001 through myrun-0100, got %v", ids)
+	}
+
+	if correlation := seq.NewCorrelation("", CorrelationFormatTraceparent); strings.HasPrefix(correlation.ID, "myrun") {
+		t.Errorf("Expected traceparent to keep a random trace ID, got %s", correlation.ID)
+	}
+
+	var random *RequestIDSequence
+	if id := random.Next(); len(id) != 8 {
+		t.Errorf("Expected a nil sequence to generate random IDs, got %q", id)
+	}
+	if seq, err := NewRequestIDSequence(""); seq != nil || err != nil {
+		t.Errorf("Expected no sequence for an empty prefix, got %v, %v", seq, err)
+	}
+	if _, err := NewRequestIDSequence("my run"); err == nil {
+		t.Error("Expected an error for a prefix with a space")
+	}
+}
+
+func TestValidCorrelationFormat(t *testing.T) {
+	for _, format := range []string{"", CorrelationFormatID, CorrelationFormatTraceparent} {
+		if !ValidCorrelationFormat(format) {
+			t.Errorf("Expected %q to be valid", format)
+		}
+	}
+	if ValidCorrelationFormat("b3") {
+		t.Error("Expected unsupported format to be invalid")
+	}
+}
+
+func TestDetectProtocol(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{"graphql query", `{"query":"{ me { id } }"}`, ProtocolGraphQL},
+		{"graphql batch", `[{"operationName":"A","query":"query A { a }"}]`, ProtocolGraphQL},
+		{"graphql persisted query", `{"extensions":{"persistedQuery":{"sha256Hash":"abc"}}}`, ProtocolGraphQL},
+		{"jsonrpc", `{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":1}`, ProtocolJSONRPC},
+		{"jsonrpc batch", `[{"jsonrpc":"2.0","method":"sum","params":[1,2],"id":"1"}]`, ProtocolJSONRPC},
+		{"jsonrpc without method", `{"jsonrpc":"2.0","id":1}`, ProtocolREST},
+		{"rest object", `{"name":"widget","price":10}`, ProtocolREST},
+		{"not json", `name=widget`, ProtocolREST},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectProtocol(tt.body); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDetectResponseErrorJSONRPC(t *testing.T) {
+	body := `{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}`
+	protocol := DetectProtocol(body)
+	if protocol != ProtocolJSONRPC {
+		t.Fatalf("Expected jsonrpc, got %s", protocol)
+	}
+
+	// JSON-RPC errors are reported with a 200 status
+	failed := `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":1}`
+	if got := DetectResponseError(protocol, 200, failed); got != "-32601: Method not found" {
+		t.Errorf("Unexpected error message: %q", got)
+	}
+
+	succeeded := `{"jsonrpc":"2.0","result":19,"id":1}`
+	if got := DetectResponseError(protocol, 200, succeeded); got != "" {
+		t.Errorf("Expected no error, got %q", got)
+	}
+
+	batch := `[{"jsonrpc":"2.0","result":7,"id":"1"},{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":"2"}]`
+	if got := DetectResponseError(protocol, 200, batch); got != "id 2: -32602: Invalid params" {
+		t.Errorf("Unexpected batch error message: %q", got)
+	}
+}
+
+func TestDetectResponseErrorGraphQLAndREST(t *testing.T) {
+	if got := DetectResponseError(ProtocolGraphQL, 200, `{"errors":[{"message":"boom"}]}`); got != `{"message":"boom"}` {
+		t.Errorf("Unexpected GraphQL error: %q", got)
+	}
+	if got := DetectResponseError(ProtocolGraphQL, 200, `{"data":{"me":null}}`); got != "" {
+		t.Errorf("Expected no GraphQL error, got %q", got)
+	}
+
+	if got := DetectResponseError(ProtocolREST, 422, `{"error":"name is required"}`); got != "422 Unprocessable Entity: name is required" {
+		t.Errorf("Unexpected REST error: %q", got)
+	}
+	if got := DetectResponseError(ProtocolREST, 503, ``); got != "503 Service Unavailable" {
+		t.Errorf("Unexpected REST error without body: %q", got)
+	}
+	if got := DetectResponseError(ProtocolREST, 200, `{"error":"ignored on success"}`); got != "" {
+		t.Errorf("Expected no REST error on success, got %q", got)
+	}
+}
@@ -0,0 +1,125 @@
+package httputil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Request body protocols
+const (
+	ProtocolGraphQL = "graphql"
+	ProtocolJSONRPC = "jsonrpc"
+	ProtocolREST    = "rest"
+)
+
+// DetectProtocol infers the protocol of a request body. JSON-RPC 2.0 bodies carry
+// "jsonrpc" and "method", GraphQL bodies carry "query" (or a persisted query), and
+// anything else is treated as plain REST JSON. Batches are detected from their first element.
+func DetectProtocol(body string) string {
+	var single map[string]any
+	if err := json.Unmarshal([]byte(body), &single); err != nil {
+		var batch []map[string]any
+		if err := json.Unmarshal([]byte(body), &batch); err != nil || len(batch) == 0 {
+			return ProtocolREST
+		}
+		single = batch[0]
+	}
+
+	if version, ok := single["jsonrpc"].(string); ok && version == "2.0" {
+		if _, ok := single["method"].(string); ok {
+			return ProtocolJSONRPC
+		}
+	}
+
+	if _, ok := single["query"].(string); ok {
+		return ProtocolGraphQL
+	}
+	if extensions, ok := single["extensions"].(map[string]any); ok {
+		if _, ok := extensions["persistedQuery"]; ok {
+			return ProtocolGraphQL
+		}
+	}
+
+	return ProtocolREST
+}
+
+// DetectResponseError checks a response body for a protocol-level error and returns its
+// message, or an empty string if the response succeeded:
+//   - GraphQL: an "errors" key anywhere in the response
+//   - JSON-RPC: an "error" object on the response (or any response in a batch)
+//   - REST: a 4xx/5xx status code
+func DetectResponseError(protocol string, statusCode int, body string) string {
+	switch protocol {
+	case ProtocolGraphQL:
+		var data any
+		if err := json.Unmarshal([]byte(body), &data); err != nil {
+			return ""
+		}
+		if hasErrors, message, _ := ContainsErrorsKey(data, ""); hasErrors {
+			return strings.TrimSpace(message)
+		}
+	case ProtocolJSONRPC:
+		return jsonRPCError(body)
+	default:
+		if statusCode >= http.StatusBadRequest {
+			return restError(statusCode, body)
+		}
+	}
+	return ""
+}
+
+type jsonRPCResponse struct {
+	ID    any `json:"id"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// jsonRPCError returns the error of a JSON-RPC response, or the errors of a batch response
+func jsonRPCError(body string) string {
+	var responses []jsonRPCResponse
+	var single jsonRPCResponse
+	if err := json.Unmarshal([]byte(body), &single); err == nil {
+		responses = []jsonRPCResponse{single}
+	} else if err := json.Unmarshal([]byte(body), &responses); err != nil {
+		return ""
+	}
+
+	var errors []string
+	for _, resp := range responses {
+		if resp.Error == nil {
+			continue
+		}
+		message := fmt.Sprintf("%d: %s", resp.Error.Code, resp.Error.Message)
+		if len(responses) > 1 {
+			message = fmt.Sprintf("id %v: %s", resp.ID, message)
+		}
+		errors = append(errors, message)
+	}
+	return strings.Join(errors, "\n")
+}
+
+// restError describes a failed REST response using its "error" or "message" field when present
+func restError(statusCode int, body string) string {
+	status := fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode))
+
+	var data map[string]any
+	if err := json.Unmarshal([]byte(body), &data); err == nil {
+		for _, key := range []string{"error", "message"} {
+			switch v := data[key].(type) {
+			case string:
+				if v != "" {
+					return fmt.Sprintf("%s: %s", status, v)
+				}
+			case map[string]any:
+				if message, ok := v["message"].(string); ok && message != "" {
+					return fmt.Sprintf("%s: %s", status, message)
+				}
+			}
+		}
+	}
+	return status
+}
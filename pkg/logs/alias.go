@@ -0,0 +1,56 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ContainerAlias maps container names matching Pattern to a stable display name.
+// This keeps names like web-7d9f8-abcde grouped under "web" across redeploys.
+type ContainerAlias struct {
+	Pattern *regexp.Regexp
+	Name    string
+}
+
+// NewContainerAlias compiles a pattern into a ContainerAlias
+func NewContainerAlias(pattern, name string) (ContainerAlias, error) {
+	if name == "" {
+		return ContainerAlias{}, fmt.Errorf("alias name is required")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return ContainerAlias{}, fmt.Errorf("invalid alias pattern %q: %w", pattern, err)
+	}
+	return ContainerAlias{Pattern: re, Name: name}, nil
+}
+
+// ResolveAlias returns the display name for a container name.
+// Aliases are checked in order and the first match wins; unmatched names are returned unchanged.
+func ResolveAlias(name string, aliases []ContainerAlias) string {
+	for _, alias := range aliases {
+		if alias.Pattern.MatchString(name) {
+			return alias.Name
+		}
+	}
+	return name
+}
+
+// ApplyAliases returns a copy of containers with aliased names.
+// The original Docker name is preserved in RawName so aliases can be re-applied.
+func ApplyAliases(containers []Container, aliases []ContainerAlias) []Container {
+	result := make([]Container, len(containers))
+	for i, c := range containers {
+		rawName := c.Name
+		if c.RawName != "" {
+			rawName = c.RawName
+		}
+
+		c.Name = ResolveAlias(rawName, aliases)
+		c.RawName = ""
+		if c.Name != rawName {
+			c.RawName = rawName
+		}
+		result[i] = c
+	}
+	return result
+}
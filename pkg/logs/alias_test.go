@@ -0,0 +1,97 @@
+package logs
+
+import "testing"
+
+func mustAlias(t *testing.T, pattern, name string) ContainerAlias {
+	t.Helper()
+	alias, err := NewContainerAlias(pattern, name)
+	if err != nil {
+		t.Fatalf("Failed to create alias: %v", err)
+	}
+	return alias
+}
+
+func TestResolveAlias(t *testing.T) {
+	aliases := []ContainerAlias{
+		mustAlias(t, `^web-[0-9a-f]+-[a-z0-9]+$`, "web"),
+		mustAlias(t, `^worker-`, "worker"),
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"web-7d9f8-abcde", "web"},
+		{"web-1a2b3-zzzzz", "web"},
+		{"worker-0", "worker"},
+		{"web", "web"},
+		{"postgres", "postgres"},
+		{"my-web-7d9f8-abcde", "my-web-7d9f8-abcde"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveAlias(tt.name, aliases); got != tt.expected {
+				t.Errorf("ResolveAlias(%q) = %q, expected %q", tt.name, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveAliasCollision(t *testing.T) {
+	// Both patterns match; the first one configured wins
+	aliases := []ContainerAlias{
+		mustAlias(t, `^api-`, "api"),
+		mustAlias(t, `-abcde$`, "other"),
+	}
+
+	if got := ResolveAlias("api-7d9f8-abcde", aliases); got != "api" {
+		t.Errorf("Expected first matching alias 'api', got %q", got)
+	}
+	if got := ResolveAlias("web-7d9f8-abcde", aliases); got != "other" {
+		t.Errorf("Expected alias 'other', got %q", got)
+	}
+}
+
+func TestApplyAliases(t *testing.T) {
+	aliases := []ContainerAlias{mustAlias(t, `^web-`, "web")}
+	containers := []Container{
+		{ID: "1", Name: "web-7d9f8-abcde"},
+		{ID: "2", Name: "web-1a2b3-fghij"},
+		{ID: "3", Name: "postgres"},
+	}
+
+	aliased := ApplyAliases(containers, aliases)
+
+	// Distinct containers collide on the same alias
+	if aliased[0].Name != "web" || aliased[1].Name != "web" {
+		t.Errorf("Expected both web containers aliased to 'web', got %q and %q", aliased[0].Name, aliased[1].Name)
+	}
+	if aliased[0].RawName != "web-7d9f8-abcde" {
+		t.Errorf("Expected raw name to be preserved, got %q", aliased[0].RawName)
+	}
+	if aliased[2].Name != "postgres" || aliased[2].RawName != "" {
+		t.Errorf("Expected unaliased container unchanged, got %+v", aliased[2])
+	}
+	if containers[0].Name != "web-7d9f8-abcde" {
+		t.Error("ApplyAliases should not modify the input slice")
+	}
+
+	// Re-applying with different aliases uses the raw name
+	reapplied := ApplyAliases(aliased, []ContainerAlias{mustAlias(t, `-abcde$`, "canary")})
+	if reapplied[0].Name != "canary" {
+		t.Errorf("Expected re-applied alias 'canary', got %q", reapplied[0].Name)
+	}
+	if reapplied[1].Name != "web-1a2b3-fghij" || reapplied[1].RawName != "" {
+		t.Errorf("Expected alias removed when no pattern matches, got %+v", reapplied[1])
+	}
+}
+
+func TestNewContainerAliasInvalidPattern(t *testing.T) {
+	if _, err := NewContainerAlias(`web-(`, "web"); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+	if _, err := NewContainerAlias(`^web-`, ""); err == nil {
+		t.Error("Expected error for empty alias name")
+	}
+}
@@ -0,0 +1,32 @@
+package logs
+
+import "hash/fnv"
+
+// containerPalette is the set of colors ContainerColor picks from, distinct from one
+// another and readable on both dark and light backgrounds
+var containerPalette = []string{
+	"#e6194b", // red
+	"#3cb44b", // green
+	"#4363d8", // blue
+	"#f58231", // orange
+	"#911eb4", // purple
+	"#42d4f4", // cyan
+	"#f032e6", // magenta
+	"#9a6324", // brown
+	"#469990", // teal
+	"#bfa600", // olive yellow
+	"#dc7fbd", // pink
+	"#808000", // olive
+	"#6f7fbf", // lavender
+	"#e08e5c", // apricot
+	"#2e8b57", // sea green
+	"#b04a5a", // rose
+}
+
+// ContainerColor returns the #rrggbb color to tint a container's lines with. It is
+// derived from the name alone, so a container keeps its color across restarts.
+func ContainerColor(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return containerPalette[h.Sum32()%uint32(len(containerPalette))]
+}
@@ -0,0 +1,32 @@
+package logs
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestContainerColor(t *testing.T) {
+	hexColor := regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+	names := []string{"api", "api-1", "postgres", "redis", "worker", "web", "nginx", "graphql-gateway"}
+	used := make(map[string]bool)
+	for _, name := range names {
+		color := ContainerColor(name)
+		if !hexColor.MatchString(color) {
+			t.Errorf("Expected a #rrggbb color for %s, got %q", name, color)
+		}
+		if again := ContainerColor(name); again != color {
+			t.Errorf("Expected %s to always get %s, got %s", name, color, again)
+		}
+		used[color] = true
+	}
+	if len(used) < 4 {
+		t.Errorf("Expected containers to be spread across colors, got %d for %d names", len(used), len(names))
+	}
+
+	// Pinned so a change to the hash or palette, which would recolor every container
+	// for users, is deliberate
+	if color := ContainerColor("api"); color != "#9a6324" {
+		t.Errorf("Expected api to keep #9a6324, got %s", color)
+	}
+}
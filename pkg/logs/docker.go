@@ -19,7 +19,9 @@ type ContainerMessage struct {
 	Entry       *LogEntry
 }
 
-var dockerTimestampRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?Z)\s+(.*)$`)
+// dockerTimestampRegex matches the timestamp Docker prefixes each line with. A blank line
+// arrives as the timestamp alone.
+var dockerTimestampRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?Z)(?:\s+(.*))?$`)
 
 func parseDockerTimestamp(line string) (time.Time, string) {
 	matches := dockerTimestampRegex.FindStringSubmatch(line)
@@ -34,16 +36,22 @@ func parseDockerTimestamp(line string) (time.Time, string) {
 }
 
 type DockerClient struct {
-	cli *client.Client
+	cli    *client.Client
+	filter *ContainerFilter // Limits ListRunningContainers; nil lists every container
+	// synthesizeTimestamps fills the timestamp of entries logged without one from the
+	// timestamp Docker prefixes each line with
+	synthesizeTimestamps bool
 }
 
 type Container struct {
 	ID      string
 	Name    string
+	RawName string `json:",omitempty"` // Original Docker name when Name has been aliased
 	Image   string
 	Ports   []PortMapping
-	Project string // Docker Compose project name
-	Service string // Docker Compose service name
+	Project string            // Docker Compose project name
+	Service string            // Docker Compose service name
+	Labels  map[string]string `json:"-"`
 }
 
 type PortMapping struct {
@@ -53,13 +61,43 @@ type PortMapping struct {
 }
 
 func NewDockerClient() (*DockerClient, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	return NewDockerClientForHost("")
+}
+
+// NewDockerClientForHost connects to the Docker daemon at host, such as
+// tcp://10.0.0.5:2375, or to the one configured by the environment when host is empty
+func NewDockerClientForHost(host string) (*DockerClient, error) {
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if host != "" {
+		opts = append(opts, client.WithHost(host))
+	}
+	cli, err := client.NewClientWithOpts(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Docker client: %w", err)
 	}
 	return &DockerClient{cli: cli}, nil
 }
 
+// SetContainerFilter limits the containers ListRunningContainers returns, so only the
+// selected ones are streamed and shown. It must be called before the client is shared.
+func (dc *DockerClient) SetContainerFilter(filter *ContainerFilter) {
+	dc.filter = filter
+}
+
+// SetSynthesizeTimestamps sets whether entries without an inline timestamp take the
+// one Docker recorded for the line, so apps that log without timestamps still show and
+// sort by when they logged. It must be called before streaming starts.
+func (dc *DockerClient) SetSynthesizeTimestamps(enabled bool) {
+	dc.synthesizeTimestamps = enabled
+}
+
+// synthesizeTimestamp sets entry's timestamp to dockerTs when entry has none
+func synthesizeTimestamp(entry *LogEntry, dockerTs time.Time) {
+	if entry.Timestamp == "" && !dockerTs.IsZero() {
+		entry.Timestamp = dockerTs.UTC().Format(time.RFC3339Nano)
+	}
+}
+
 func (dc *DockerClient) ListRunningContainers(ctx context.Context) ([]Container, error) {
 	containers, err := dc.cli.ContainerList(ctx, types.ContainerListOptions{})
 	if err != nil {
@@ -99,9 +137,10 @@ func (dc *DockerClient) ListRunningContainers(ctx context.Context) ([]Container,
 			Ports:   ports,
 			Project: project,
 			Service: service,
+			Labels:  c.Labels,
 		})
 	}
-	return result, nil
+	return dc.filter.Filter(result), nil
 }
 
 func (dc *DockerClient) StreamLogs(ctx context.Context, containerID string, logChan chan<- ContainerMessage, onStreamEnd func()) error {
@@ -146,10 +185,13 @@ func (dc *DockerClient) StreamLogsSince(ctx context.Context, containerID string,
 		var leftover []byte
 		var bufferedEntry *LogEntry
 		var bufferedTimestamp time.Time
+		var panicJoiner GoPanicJoiner
+		var panicTimestamp time.Time
 		lineCount := 0
 
-		// safeSend attempts to send a message to the channel, handling closed channel gracefully
-		// Returns true if sent successfully, false if context cancelled or channel closed
+		// safeSend attempts to send a message to the channel, handling closed channel gracefully.
+		// Returns true if sent successfully, false if the channel is full or closed. It still
+		// sends after ctx is cancelled so the final flush reaches a draining consumer.
 		safeSend := func(msg ContainerMessage) bool {
 			if channelClosed {
 				return false
@@ -165,9 +207,6 @@ func (dc *DockerClient) StreamLogsSince(ctx context.Context, containerID string,
 					}
 				}()
 				select {
-				case <-ctx.Done():
-					// Context cancelled, don't send
-					sent = false
 				case logChan <- msg:
 					// Successfully sent
 					sent = true
@@ -182,6 +221,9 @@ func (dc *DockerClient) StreamLogsSince(ctx context.Context, containerID string,
 		flushBuffered := func() {
 			if bufferedEntry != nil {
 				ts := bufferedTimestamp
+				if dc.synthesizeTimestamps {
+					synthesizeTimestamp(bufferedEntry, ts)
+				}
 				if ts.IsZero() {
 					ts = time.Now()
 				}
@@ -198,6 +240,27 @@ func (dc *DockerClient) StreamLogsSince(ctx context.Context, containerID string,
 			}
 		}
 
+		flushPanic := func() {
+			entry := panicJoiner.Flush()
+			if entry == nil {
+				return
+			}
+			ts := panicTimestamp
+			if dc.synthesizeTimestamps {
+				synthesizeTimestamp(entry, ts)
+			}
+			if ts.IsZero() {
+				ts = time.Now()
+			}
+			if safeSend(ContainerMessage{
+				ContainerID: containerID,
+				Timestamp:   ts,
+				Entry:       entry,
+			}) {
+				lineCount++
+			}
+		}
+
 		for {
 			if channelClosed {
 				slog.Info("Container log channel closed, stopping stream", "container_id", containerID[:12], "linesProcessed", lineCount)
@@ -206,6 +269,7 @@ func (dc *DockerClient) StreamLogsSince(ctx context.Context, containerID string,
 			select {
 			case <-ctx.Done():
 				flushBuffered()
+				flushPanic()
 				if channelClosed {
 					slog.Info("Container log channel closed during flush, stopping stream", "container_id", containerID[:12], "linesProcessed", lineCount)
 				} else {
@@ -252,16 +316,37 @@ func (dc *DockerClient) StreamLogsSince(ctx context.Context, containerID string,
 						// Parse Docker timestamp prefix (format: 2024-12-04T10:30:00.123456789Z <log line>)
 						dockerTs, logContent := parseDockerTimestamp(trimmed)
 						trimmed = logContent
+						if trimmed == "" {
+							// A blank line under its timestamp, such as those in a panic dump
+							emptyCount++
+							continue
+						}
+
+						// Go panic dumps are joined by their own rules, since stack lines
+						// aren't recognized as continuations
+						if panicJoiner.Add(trimmed) {
+							continue
+						}
+						flushPanic()
+						if IsGoPanicStart(trimmed) {
+							flushBuffered()
+							panicJoiner.Start(trimmed)
+							panicTimestamp = dockerTs
+							continue
+						}
 
 						// Use ANSI codes and other heuristics to detect new log entries
 						isNewEntry := IsLikelyNewLogEntry(trimmed)
 
 						// If this looks like a continuation line and we have a buffered entry, append to it
 						if !isNewEntry && bufferedEntry != nil {
-							// Combine with the buffered entry
-							bufferedEntry.Raw = bufferedEntry.Raw + "\n" + trimmed
-							// Re-parse the combined raw text
-							bufferedEntry = ParseLogLine(bufferedEntry.Raw)
+							if bufferedEntry.Truncated {
+								// The entry already fills the limit, so the line is cut with the rest
+								bufferedEntry.dropMore(len("\n") + len(trimmed))
+							} else {
+								// Combine with the buffered entry and re-parse the combined raw text
+								bufferedEntry = ParseLogLine(bufferedEntry.Raw + "\n" + trimmed)
+							}
 							// Check if the buffered entry now looks complete (has structured fields)
 							// For SQL entries, we need fields. For other entries, we'll flush on next new entry.
 							if strings.Contains(bufferedEntry.Message, "[sql]") && len(bufferedEntry.Fields) > 0 {
@@ -287,8 +372,13 @@ func (dc *DockerClient) StreamLogsSince(ctx context.Context, containerID string,
 							if shouldBuffer {
 								// Buffer it, waiting for potential continuation lines
 								bufferedEntry = entry
+								// Re-parsing continuation lines would drop a synthesized timestamp,
+								// so it is set when the entry is flushed
 								bufferedTimestamp = ts
 							} else {
+								if dc.synthesizeTimestamps {
+									synthesizeTimestamp(entry, dockerTs)
+								}
 								// Send immediately, but check context first
 								if safeSend(ContainerMessage{
 									ContainerID: containerID,
@@ -298,7 +388,7 @@ func (dc *DockerClient) StreamLogsSince(ctx context.Context, containerID string,
 									lineCount++
 									sentCount++
 								} else {
-									// Context cancelled or channel closed, exit
+									// Channel full or closed, exit
 									return
 								}
 							}
@@ -317,6 +407,7 @@ func (dc *DockerClient) StreamLogsSince(ctx context.Context, containerID string,
 
 				if err == io.EOF {
 					flushBuffered()
+					flushPanic()
 					if channelClosed {
 						slog.Info("Container log channel closed during EOF flush, stopping stream", "container_id", containerID[:12], "linesProcessed", lineCount)
 					} else {
@@ -326,6 +417,7 @@ func (dc *DockerClient) StreamLogsSince(ctx context.Context, containerID string,
 				}
 				if err != nil {
 					flushBuffered()
+					flushPanic()
 					if channelClosed {
 						slog.Info("Container log channel closed during error flush, stopping stream", "container_id", containerID[:12], "linesProcessed", lineCount)
 					} else {
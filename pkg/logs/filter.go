@@ -0,0 +1,188 @@
+package logs
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ContainerFilter selects which containers the viewer attaches to. Patterns are exact
+// names, globs such as api-* or postgres:*, or regular expressions when wrapped in
+// slashes, such as /^web-\d+$/. They are matched against the container name, its
+// original name when aliased, and its image. Each container is judged on its own, so
+// whether it's selected never depends on which others are running: api selects only a
+// container named api, and api-* selects the rest.
+type ContainerFilter struct {
+	include []containerPattern
+	exclude []containerPattern
+}
+
+// NewContainerFilter compiles include and exclude patterns. Without include patterns
+// every container not excluded is selected; exclusions win over inclusions.
+func NewContainerFilter(include, exclude []string) (*ContainerFilter, error) {
+	f := &ContainerFilter{}
+	var err error
+	if f.include, err = compileContainerPatterns(include); err != nil {
+		return nil, fmt.Errorf("invalid include pattern: %w", err)
+	}
+	if f.exclude, err = compileContainerPatterns(exclude); err != nil {
+		return nil, fmt.Errorf("invalid exclude pattern: %w", err)
+	}
+	return f, nil
+}
+
+// Match reports whether c is selected. A nil filter selects every container.
+func (f *ContainerFilter) Match(c Container) bool {
+	if f == nil {
+		return true
+	}
+	for _, p := range f.exclude {
+		if p.match(c) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, p := range f.include {
+		if p.match(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the containers in containers that f selects
+func (f *ContainerFilter) Filter(containers []Container) []Container {
+	if f == nil {
+		return containers
+	}
+	result := make([]Container, 0, len(containers))
+	for _, c := range containers {
+		if f.Match(c) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// containerPattern is a glob matched with path.Match or a compiled /regex/
+type containerPattern struct {
+	glob  string
+	regex *regexp.Regexp
+}
+
+// match reports whether p selects c by its name, its original name or its image. Plain
+// names match exactly, ignoring case; unlike MatchContainers there are no partial matches.
+func (p containerPattern) match(c Container) bool {
+	if p.regex != nil {
+		return p.regex.MatchString(c.Name) || (c.RawName != "" && p.regex.MatchString(c.RawName)) || p.regex.MatchString(c.Image)
+	}
+	return matchesName(p.glob, c.Name) || (c.RawName != "" && matchesName(p.glob, c.RawName)) || matchesImage(p.glob, c.Image)
+}
+
+// matchesName reports whether pattern is a glob matching name, or name itself
+func matchesName(pattern, name string) bool {
+	if strings.ContainsAny(pattern, `*?[\`) {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}
+	return strings.EqualFold(pattern, name)
+}
+
+// matchesImage reports whether pattern matches image. Globs are tried against the full
+// reference and against its last path segment, since * doesn't cross "/": *postgres*
+// matches docker.io/library/postgres:16 as well as postgres:16. Plain names match the
+// repository with or without its registry path, tag and digest, so db doesn't select
+// every container running a mongodb image.
+func matchesImage(pattern, image string) bool {
+	if image == "" {
+		return false
+	}
+	if strings.ContainsAny(pattern, `*?[\`) {
+		return matchesName(pattern, image) || matchesName(pattern, path.Base(image))
+	}
+	repo, _, _ := strings.Cut(image, "@")
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	return strings.EqualFold(pattern, image) || strings.EqualFold(pattern, repo) || strings.EqualFold(pattern, path.Base(repo))
+}
+
+func compileContainerPatterns(patterns []string) ([]containerPattern, error) {
+	compiled := make([]containerPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", pattern, err)
+			}
+			compiled = append(compiled, containerPattern{regex: re})
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%q: %w", pattern, err)
+		}
+		compiled = append(compiled, containerPattern{glob: pattern})
+	}
+	return compiled, nil
+}
+
+// How closely a pattern matches a container name, weakest first
+const (
+	containerMatchNone = iota
+	containerMatchSubstring
+	containerMatchPrefix
+	containerMatchExact // Including globs, which say exactly what they match
+)
+
+// MatchContainer reports whether pattern selects the container name. A pattern with
+// glob characters is matched with path.Match; any other matches the name exactly or as
+// a case-insensitive prefix or substring, so web matches web-1 and web-2.
+func MatchContainer(pattern, name string) bool {
+	return containerMatch(pattern, name) != containerMatchNone
+}
+
+// MatchContainers returns the names pattern selects. Only the closest matches are kept,
+// so when one name matches exactly a partial pattern isn't ambiguous: api selects api
+// and not api-worker, while ap selects both.
+func MatchContainers(pattern string, names []string) []string {
+	var matches []string
+	best := containerMatchNone
+	for _, name := range names {
+		match := containerMatch(pattern, name)
+		if match == containerMatchNone || match < best {
+			continue
+		}
+		if match > best {
+			best = match
+			matches = matches[:0]
+		}
+		matches = append(matches, name)
+	}
+	return matches
+}
+
+func containerMatch(pattern, name string) int {
+	if pattern == "" {
+		return containerMatchNone
+	}
+	if strings.ContainsAny(pattern, `*?[\`) {
+		if ok, _ := path.Match(pattern, name); ok {
+			return containerMatchExact
+		}
+		return containerMatchNone
+	}
+
+	pattern, name = strings.ToLower(pattern), strings.ToLower(name)
+	switch {
+	case name == pattern:
+		return containerMatchExact
+	case strings.HasPrefix(name, pattern):
+		return containerMatchPrefix
+	case strings.Contains(name, pattern):
+		return containerMatchSubstring
+	}
+	return containerMatchNone
+}
@@ -0,0 +1,149 @@
+package logs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestContainerFilter(t *testing.T) {
+	containers := []Container{
+		{Name: "api-1", Image: "acme/api:latest"},
+		{Name: "api-worker-1", Image: "acme/api:latest"},
+		{Name: "web", RawName: "web-7d9f8-abcde", Image: "nginx:1.27"},
+		{Name: "postgres", Image: "postgres:16"},
+		{Name: "redis", Image: "redis:7"},
+	}
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{"no patterns", nil, nil, []string{"api-1", "api-worker-1", "web", "postgres", "redis"}},
+		{"include by name glob", []string{"api-*"}, nil, []string{"api-1", "api-worker-1"}},
+		{"include by image glob", []string{"postgres:*", "redis:*"}, nil, []string{"postgres", "redis"}},
+		{"include by raw name", []string{"web-*"}, nil, []string{"web"}},
+		{"include by regex", []string{`/^api-\d+$/`}, nil, []string{"api-1"}},
+		{"exclude", nil, []string{"*worker*", "redis"}, []string{"api-1", "web", "postgres"}},
+		{"exclude wins over include", []string{"api-*"}, []string{"/worker/"}, []string{"api-1"}},
+		{"partial names select nothing", []string{"worker"}, nil, []string{}},
+		{"include by image name", []string{"nginx"}, nil, []string{"web"}},
+		{"include matching nothing", []string{"mysql"}, nil, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewContainerFilter(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("Failed to create filter: %v", err)
+			}
+			names := []string{}
+			for _, c := range filter.Filter(containers) {
+				names = append(names, c.Name)
+			}
+			if !slices.Equal(names, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+
+	var nilFilter *ContainerFilter
+	if !nilFilter.Match(containers[0]) {
+		t.Error("Expected a nil filter to match every container")
+	}
+
+	for _, pattern := range []string{"api-[", "/api-(/"} {
+		if _, err := NewContainerFilter([]string{pattern}, nil); err == nil {
+			t.Errorf("Expected an error for pattern %q", pattern)
+		}
+	}
+}
+
+func TestContainerFilterPerContainer(t *testing.T) {
+	api := Container{Name: "api", Image: "acme/backend:latest"}
+	worker := Container{Name: "api-worker", Image: "acme/backend:latest"}
+	mongo := Container{Name: "mongo", Image: "mongodb/mongodb-community-server:7.0"}
+
+	tests := []struct {
+		name      string
+		include   []string
+		exclude   []string
+		container Container
+		expected  bool
+	}{
+		{"exclude an exact name", nil, []string{"api"}, api, false},
+		{"exclude leaves other names", nil, []string{"api"}, worker, true},
+		{"exclude by prefix glob", nil, []string{"api-*"}, worker, false},
+		{"include an exact name", []string{"api-worker"}, nil, worker, true},
+		{"include leaves out prefixed names", []string{"api"}, nil, worker, false},
+		{"partial image", nil, []string{"db"}, mongo, true},
+		{"image without registry or tag", []string{"mongodb-community-server"}, nil, mongo, true},
+		{"image glob with registry", []string{"*postgres*"}, nil, Container{Name: "db", Image: "docker.io/library/postgres:16"}, true},
+		{"image glob across the registry", []string{"docker.io/*"}, nil, Container{Name: "db", Image: "docker.io/library/postgres:16"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewContainerFilter(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("Failed to create filter: %v", err)
+			}
+			if got := filter.Match(tt.container); got != tt.expected {
+				t.Errorf("Match(%s) = %v, expected %v", tt.container.Name, got, tt.expected)
+			}
+		})
+	}
+
+	// The same container is selected whether or not others are running
+	filter, _ := NewContainerFilter(nil, []string{"api"})
+	alone := filter.Filter([]Container{worker})
+	together := filter.Filter([]Container{api, worker})
+	if len(alone) != 1 || len(together) != 1 || together[0].Name != "api-worker" {
+		t.Errorf("Expected api-worker kept either way, got %v alone and %v with api", alone, together)
+	}
+}
+
+func TestMatchContainer(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		expected      bool
+	}{
+		{"web-1", "web-1", true},
+		{"web", "web-1", true},           // Prefix
+		{"WEB", "web-2", true},           // Case-insensitive
+		{"worker", "api-worker-1", true}, // Substring
+		{"web-*", "web-2", true},         // Glob
+		{"web-?", "web-12", false},
+		{"*-1", "api-worker-1", true},
+		{"api", "web-1", false},
+		{"web-[", "web-[", false}, // Malformed glob
+		{"", "web-1", false},
+	}
+	for _, tt := range tests {
+		if got := MatchContainer(tt.pattern, tt.name); got != tt.expected {
+			t.Errorf("MatchContainer(%q, %q) = %v, expected %v", tt.pattern, tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestMatchContainers(t *testing.T) {
+	names := []string{"api", "api-worker", "web-1", "web-2", "admin-web"}
+
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"api", []string{"api"}},                           // An exact name wins over the names it prefixes
+		{"ap", []string{"api", "api-worker"}},              // Equally close prefixes are all selected
+		{"web", []string{"web-1", "web-2"}},                // Prefixes win over substrings
+		{"-web", []string{"admin-web"}},                    // A substring when nothing closer matches
+		{"*web*", []string{"web-1", "web-2", "admin-web"}}, // A glob selects everything it matches
+		{"mysql", nil},
+	}
+	for _, tt := range tests {
+		if got := MatchContainers(tt.pattern, names); !slices.Equal(got, tt.expected) {
+			t.Errorf("MatchContainers(%q) = %v, expected %v", tt.pattern, got, tt.expected)
+		}
+	}
+}
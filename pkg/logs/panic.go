@@ -0,0 +1,119 @@
+package logs
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// goroutineHeaderRegex matches the line starting each goroutine's stack, e.g. "goroutine 1 [running]:"
+	goroutineHeaderRegex = regexp.MustCompile(`^goroutine (\d+) \[[^\]]*\]:$`)
+	// goFrameFuncRegex matches a stack frame's function, e.g. "main.(*Server).handle(0x0, {0x8c2f40, 0xc0001a2000})"
+	goFrameFuncRegex = regexp.MustCompile(`^[^\s(]+(?:\(\*[^\s)]+\)[^\s(]*)?\(.*\)$`)
+	// goFrameFileRegex matches a stack frame's file and line, e.g. "/app/main.go:42 +0x2a"
+	goFrameFileRegex = regexp.MustCompile(`^(\S+\.go:\d+)(?: \+0x[0-9a-f]+)?$`)
+	// goPanicExtraRegex matches the other lines a panic dump can contain
+	goPanicExtraRegex = regexp.MustCompile(`^(?:\[signal .*\]|created by \S+.*|\.\.\.additional frames elided\.\.\.|exit status \d+)$`)
+)
+
+// IsGoPanicStart reports whether line starts a Go panic dump
+func IsGoPanicStart(line string) bool {
+	return strings.HasPrefix(stripANSI(strings.TrimSpace(line)), "panic: ")
+}
+
+// GoPanicJoiner coalesces a Go panic dump, the panic message followed by the stack of
+// each goroutine, into a single entry. Stack lines carry no timestamp or level, and
+// Go's format is specific enough to tell exactly where the dump ends.
+type GoPanicJoiner struct {
+	lines         []string
+	size          int
+	dropped       int // Bytes left out once the dump grew past MaxLineLength
+	seenGoroutine bool
+}
+
+// Start begins a dump with its "panic:" line, discarding any dump in progress
+func (j *GoPanicJoiner) Start(line string) {
+	head, dropped := truncateLine(stripANSI(strings.TrimSpace(line)), MaxLineLength())
+	*j = GoPanicJoiner{lines: []string{head}, size: len(head), dropped: dropped}
+}
+
+// Active reports whether a dump is in progress
+func (j *GoPanicJoiner) Active() bool {
+	return len(j.lines) > 0
+}
+
+// Add appends line to the dump in progress if it belongs to it, reporting whether it did
+func (j *GoPanicJoiner) Add(line string) bool {
+	if !j.Active() {
+		return false
+	}
+
+	line = stripANSI(strings.TrimSpace(line))
+	switch {
+	case goroutineHeaderRegex.MatchString(line):
+		j.seenGoroutine = true
+	case goPanicExtraRegex.MatchString(line):
+	case !j.seenGoroutine && strings.HasPrefix(line, "panic: "):
+		// A panic raised while recovering from another, e.g. "panic: boom [recovered]"
+		line = "\t" + line
+	case j.seenGoroutine && goFrameFuncRegex.MatchString(line):
+	case j.seenGoroutine && goFrameFileRegex.MatchString(line):
+		// Docker output is trimmed, so restore the indentation Go gives file lines
+		line = "\t" + line
+	default:
+		return false
+	}
+
+	if limit := MaxLineLength(); limit > 0 && j.size+1+len(line) > limit {
+		j.dropped += 1 + len(line)
+		return true
+	}
+	j.lines = append(j.lines, line)
+	j.size += 1 + len(line)
+	return true
+}
+
+// Flush returns the dump in progress as a FATAL entry and resets the joiner, or nil
+// when there is none. The panic value, the panicking goroutine and the stack are set
+// as fields, and File is the first frame outside the Go runtime.
+func (j *GoPanicJoiner) Flush() *LogEntry {
+	if !j.Active() {
+		return nil
+	}
+	lines := j.lines
+	dropped := j.dropped
+	*j = GoPanicJoiner{}
+
+	entry := &LogEntry{
+		Raw:     strings.Join(lines, "\n"),
+		Level:   "FATAL",
+		Message: lines[0],
+		Fields:  map[string]string{"panic": strings.TrimPrefix(lines[0], "panic: ")},
+	}
+	if len(lines) > 1 {
+		entry.Fields["stack"] = strings.Join(lines[1:], "\n")
+	}
+
+	for _, line := range lines[1:] {
+		line = strings.TrimPrefix(line, "\t")
+		if m := goroutineHeaderRegex.FindStringSubmatch(line); m != nil && entry.Fields["goroutine"] == "" {
+			entry.Fields["goroutine"] = m[1]
+		}
+		if m := goFrameFileRegex.FindStringSubmatch(line); m != nil && entry.File == "" && !strings.Contains(m[1], "/src/runtime/") {
+			entry.File = m[1]
+		}
+	}
+
+	if dropped > 0 {
+		marker := truncationMarker(dropped)
+		entry.Raw += marker
+		if entry.Fields["stack"] != "" {
+			entry.Fields["stack"] += marker
+		} else {
+			entry.Message += marker
+		}
+		entry.Truncated = true
+		entry.dropped = dropped
+	}
+	return entry
+}
@@ -0,0 +1,148 @@
+package logs
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// joinPanics splits text into entries the way StreamLogsSince does for Go panics,
+// after stripping any Docker timestamp prefix
+func joinPanics(text string) []*LogEntry {
+	var entries []*LogEntry
+	var j GoPanicJoiner
+	for _, line := range strings.Split(text, "\n") {
+		_, line = parseDockerTimestamp(strings.TrimSpace(line))
+		if line == "" || j.Add(line) {
+			continue
+		}
+		if entry := j.Flush(); entry != nil {
+			entries = append(entries, entry)
+		}
+		if IsGoPanicStart(line) {
+			j.Start(line)
+			continue
+		}
+		entries = append(entries, ParseLogLine(line))
+	}
+	if entry := j.Flush(); entry != nil {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestGoPanicJoiner(t *testing.T) {
+	data, err := os.ReadFile("testdata/go_panic.txt")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	entries := joinPanics(string(data))
+	if len(entries) != 3 {
+		for _, e := range entries {
+			t.Logf("entry: %q", e.Raw)
+		}
+		t.Fatalf("Expected the panic to be joined between two log lines, got %d entries", len(entries))
+	}
+
+	entry := entries[1]
+	if entry.Level != "FATAL" {
+		t.Errorf("Expected level FATAL, got %q", entry.Level)
+	}
+	if entry.Message != "panic: runtime error: invalid memory address or nil pointer dereference" {
+		t.Errorf("Expected the panic line as the message, got %q", entry.Message)
+	}
+	if entry.Fields["panic"] != "runtime error: invalid memory address or nil pointer dereference" {
+		t.Errorf("Expected the panic value as a field, got %q", entry.Fields["panic"])
+	}
+	if entry.Fields["goroutine"] != "42" {
+		t.Errorf("Expected the panicking goroutine 42, got %q", entry.Fields["goroutine"])
+	}
+	if entry.File != "/app/internal/mailer/worker.go:88" {
+		t.Errorf("Expected the panicking frame as the file, got %q", entry.File)
+	}
+	stack := entry.Fields["stack"]
+	for _, want := range []string{
+		"[signal SIGSEGV: segmentation violation code=0x1 addr=0x18 pc=0x6f4b2a]",
+		"goroutine 42 [running]:\nexample.com/app/internal/mailer.(*Worker).send(",
+		"\t/app/internal/mailer/worker.go:88 +0x2a",
+		"goroutine 7 [chan receive]:",
+		"created by database/sql.OpenDB in goroutine 1",
+	} {
+		if !strings.Contains(stack, want) {
+			t.Errorf("Expected the stack to contain %q, got:\n%s", want, stack)
+		}
+	}
+	if !strings.HasSuffix(entry.Raw, "exit status 2") {
+		t.Errorf("Expected the dump to end at the exit status, got %q", entry.Raw)
+	}
+	if !strings.Contains(entries[2].Raw, "restarting worker") {
+		t.Errorf("Expected the next log line kept separate, got %q", entries[2].Raw)
+	}
+}
+
+func TestGoPanicJoinerDockerTimestamps(t *testing.T) {
+	data, err := os.ReadFile("testdata/go_panic.txt")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	// Docker prefixes every line with a timestamp, so blank lines arrive as one alone
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("2024-05-02T14:03:11.600000000Z "+line, " ")
+	}
+
+	entries := joinPanics(strings.Join(lines, "\n"))
+	if len(entries) != 3 {
+		t.Fatalf("Expected the panic to be joined across blank lines, got %d entries", len(entries))
+	}
+	if stack := entries[1].Fields["stack"]; !strings.Contains(stack, "goroutine 7 [chan receive]:") {
+		t.Errorf("Expected every goroutine in the stack, got:\n%s", stack)
+	}
+}
+
+func TestGoPanicJoinerRecovered(t *testing.T) {
+	entries := joinPanics(`panic: boom [recovered]
+	panic: boom
+
+goroutine 1 [running]:
+main.main.func1()
+	/app/main.go:9 +0x45
+panic({0x4a2f40?, 0x4e5d98?})
+	/usr/local/go/src/runtime/panic.go:770 +0x132
+main.main()
+	/app/main.go:13 +0x4f
+panic: a second crash`)
+
+	if len(entries) != 2 {
+		t.Fatalf("Expected a later panic to start its own entry, got %d entries", len(entries))
+	}
+	if !strings.HasPrefix(entries[0].Fields["stack"], "\tpanic: boom\n") {
+		t.Errorf("Expected the re-panic kept in the stack, got %q", entries[0].Fields["stack"])
+	}
+	if entries[0].File != "/app/main.go:9" {
+		t.Errorf("Expected the first frame outside the runtime, got %q", entries[0].File)
+	}
+	if entries[1].Fields["panic"] != "a second crash" || entries[1].Fields["stack"] != "" {
+		t.Errorf("Expected a panic without a stack, got %+v", entries[1])
+	}
+}
+
+func TestGoPanicJoinerTruncates(t *testing.T) {
+	defer SetMaxLineLength(DefaultMaxLineLength)
+	SetMaxLineLength(200)
+
+	data, err := os.ReadFile("testdata/go_panic.txt")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	entry := joinPanics(string(data))[1]
+	if !entry.Truncated || !strings.Contains(entry.Raw, "…[truncated ") {
+		t.Errorf("Expected a dump over the limit to be truncated, got %q", entry.Raw)
+	}
+	if entry.Fields["goroutine"] != "42" {
+		t.Errorf("Expected fields parsed from the part kept, got %v", entry.Fields)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"maps"
+	"math"
 	"regexp"
 	"slices"
 	"strconv"
@@ -21,6 +22,42 @@ type LogEntry struct {
 	Fields     map[string]string `json:"fields"`
 	IsJSON     bool              `json:"isJson"`
 	JSONFields map[string]any    `json:"jsonFields,omitempty"`
+	DurationMS float64           `json:"durationMs,omitempty"` // Parsed from the duration field, if any
+	Truncated  bool              `json:"truncated,omitempty"`  // The line was longer than MaxLineLength and was cut
+
+	dropped int // Bytes cut when Truncated, as given in the marker
+}
+
+// durationFields are the fields read into LogEntry.DurationMS, in priority order
+var durationFields = []string{"duration", "duration_ms"}
+
+// ParseDurationMS parses a duration field value into milliseconds. Bare numbers such
+// as "68.405061" are already milliseconds; Go durations such as "1.2s" or "340 ms" are converted.
+func ParseDurationMS(value string) (float64, bool) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+	if value == "" {
+		return 0, false
+	}
+	if ms, err := strconv.ParseFloat(value, 64); err == nil {
+		if math.IsNaN(ms) || math.IsInf(ms, 0) {
+			return 0, false
+		}
+		return ms, true
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return float64(d) / float64(time.Millisecond), true
+	}
+	return 0, false
+}
+
+// parseDuration sets DurationMS from the first duration field that parses
+func (e *LogEntry) parseDuration() {
+	for _, key := range durationFields {
+		if ms, ok := ParseDurationMS(e.Fields[key]); ok {
+			e.DurationMS = ms
+			return
+		}
+	}
 }
 
 var (
@@ -566,7 +603,23 @@ func parseANSIFields(line string) (*LogEntry, string) {
 	return entry, line
 }
 
+// ParseLogLine parses a log line into an entry. Lines longer than MaxLineLength are cut
+// first, so fields are parsed from the head of the line, and Raw and Message end with a
+// marker giving the number of bytes dropped.
 func ParseLogLine(line string) *LogEntry {
+	head, dropped := truncateLine(line, MaxLineLength())
+	entry := parseLogLine(head)
+	if dropped > 0 {
+		marker := truncationMarker(dropped)
+		entry.Raw += marker
+		entry.Message += marker
+		entry.Truncated = true
+		entry.dropped = dropped
+	}
+	return entry
+}
+
+func parseLogLine(line string) *LogEntry {
 	if strings.TrimSpace(line) == "" {
 		return &LogEntry{
 			Raw:    line,
@@ -576,7 +629,9 @@ func ParseLogLine(line string) *LogEntry {
 
 	// Keep original line with ANSI codes for field boundary detection
 	if json.Valid([]byte(line)) {
-		return parseJSONFields(line)
+		entry := parseJSONFields(line)
+		entry.parseDuration()
+		return entry
 	}
 
 	entry, line := parseANSIFields(line)
@@ -632,6 +687,11 @@ func ParseLogLine(line string) *LogEntry {
 		}
 	}
 
+	// Custom formats the built-in parsers found no fields in
+	if len(entry.Fields) == 0 {
+		applyNamedPatterns(stripANSI(entry.Raw), entry)
+	}
+
 	// Check all fields for double-encoded JSON and normalize them
 	for key, val := range entry.Fields {
 		if val == "" {
@@ -642,6 +702,8 @@ func ParseLogLine(line string) *LogEntry {
 		}
 	}
 
+	entry.parseDuration()
+
 	return entry
 }
 
@@ -1,6 +1,7 @@
 package logs
 
 import (
+	"math"
 	"strings"
 	"testing"
 )
@@ -568,3 +569,53 @@ func TestANSIAwareFieldParsing(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDurationMS(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected float64
+		ok       bool
+	}{
+		{"68.405061", 68.405061, true},
+		{"12", 12, true},
+		{"1.2s", 1200, true},
+		{"350ms", 350, true},
+		{"1m2s", 62000, true},
+		{"250µs", 0.25, true},
+		{" 5 ", 5, true},
+		{"", 0, false},
+		{"slow", 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, ok := ParseDurationMS(tc.input)
+			if ok != tc.ok || math.Abs(got-tc.expected) > 1e-9 {
+				t.Errorf("ParseDurationMS(%q) = %v, %v; expected %v, %v", tc.input, got, ok, tc.expected, tc.ok)
+			}
+		})
+	}
+}
+
+func TestParseLogLineDuration(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected float64
+	}{
+		{"Key-value milliseconds", `INF [sql] SELECT 1 duration=68.405061 db.rows=1`, 68.405061},
+		{"Key-value Go duration", `INF request handled duration=1.2s`, 1200},
+		{"JSON number", `{"level":"info","msg":"done","duration":42.5}`, 42.5},
+		{"duration_ms field", `{"level":"info","msg":"done","duration_ms":"7"}`, 7},
+		{"No duration", `INF request handled status=200`, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			entry := ParseLogLine(tc.input)
+			if math.Abs(entry.DurationMS-tc.expected) > 1e-9 {
+				t.Errorf("Expected DurationMS %v, got %v (fields %v)", tc.expected, entry.DurationMS, entry.Fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NamedPattern extracts fields from lines in a custom format, such as an access log,
+// with a regular expression's named capture groups. ParseLogLine applies the patterns
+// in order to lines the built-in parsers find no fields in, and the first that matches
+// wins. Groups named level, message, timestamp or file set the entry's own values; the
+// others become fields.
+type NamedPattern struct {
+	Name   string
+	Regexp *regexp.Regexp
+	// Fields renames capture groups, as in {"request_time": "duration"}; groups not
+	// listed keep their own name
+	Fields map[string]string
+}
+
+var (
+	namedPatterns      []NamedPattern
+	namedPatternsMutex sync.RWMutex
+)
+
+// NewNamedPattern compiles expr, which must have at least one named capture group
+func NewNamedPattern(name, expr string, fields map[string]string) (NamedPattern, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return NamedPattern{}, fmt.Errorf("pattern %q: %w", name, err)
+	}
+
+	groups := make(map[string]bool)
+	for _, group := range re.SubexpNames() {
+		if group != "" {
+			groups[group] = true
+		}
+	}
+	if len(groups) == 0 {
+		return NamedPattern{}, fmt.Errorf("pattern %q has no named capture groups, such as (?P<status>\\d+)", name)
+	}
+	for group := range fields {
+		if !groups[group] {
+			return NamedPattern{}, fmt.Errorf("pattern %q maps unknown capture group %q", name, group)
+		}
+	}
+
+	return NamedPattern{Name: name, Regexp: re, Fields: fields}, nil
+}
+
+// SetNamedPatterns replaces the patterns ParseLogLine applies. Nil removes them all.
+func SetNamedPatterns(patterns []NamedPattern) {
+	namedPatternsMutex.Lock()
+	defer namedPatternsMutex.Unlock()
+	namedPatterns = patterns
+}
+
+// apply fills entry from the groups p captures in line, reporting whether it matched
+func (p NamedPattern) apply(line string, entry *LogEntry) bool {
+	match := p.Regexp.FindStringSubmatch(line)
+	if match == nil {
+		return false
+	}
+
+	for i, group := range p.Regexp.SubexpNames() {
+		value := match[i]
+		if group == "" || value == "" {
+			continue
+		}
+		name := group
+		if renamed, ok := p.Fields[group]; ok {
+			name = renamed
+		}
+
+		switch name {
+		case "level":
+			entry.Level = value
+			if level, ok := ParseLevel(value); ok {
+				entry.Level = level
+			}
+		case "message":
+			entry.Message = strings.TrimSpace(value)
+		case "timestamp":
+			entry.Timestamp = value
+			if ts, ok := ParseTimestamp(value); ok {
+				entry.Timestamp = ts.Format(time.RFC3339Nano)
+			}
+		case "file":
+			entry.File = value
+		default:
+			entry.Fields[name] = value
+		}
+	}
+	return true
+}
+
+// applyNamedPatterns fills entry from the first configured pattern matching line
+func applyNamedPatterns(line string, entry *LogEntry) {
+	namedPatternsMutex.RLock()
+	defer namedPatternsMutex.RUnlock()
+
+	for _, p := range namedPatterns {
+		if p.apply(line, entry) {
+			return
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package logs
+
+import "testing"
+
+const nginxPattern = `^(?P<client>\S+) \S+ \S+ \[(?P<time>[^\]]+)\] "(?P<method>\S+) (?P<path>\S+) [^"]*" (?P<status>\d{3}) (?P<bytes>\d+) (?P<latency>\S+)$`
+
+func TestNamedPatternExtractsFields(t *testing.T) {
+	pattern, err := NewNamedPattern("nginx", nginxPattern, map[string]string{"latency": "duration"})
+	if err != nil {
+		t.Fatalf("NewNamedPattern: %v", err)
+	}
+	SetNamedPatterns([]NamedPattern{pattern})
+	defer SetNamedPatterns(nil)
+
+	entry := ParseLogLine(`10.0.0.7 - - [10/Oct/2025:13:55:36 +0000] "GET /api/users?page=2 HTTP/1.1" 503 512 42ms`)
+
+	expected := map[string]string{
+		"client":   "10.0.0.7",
+		"method":   "GET",
+		"path":     "/api/users?page=2",
+		"status":   "503",
+		"bytes":    "512",
+		"duration": "42ms",
+	}
+	for name, value := range expected {
+		if got := entry.Fields[name]; got != value {
+			t.Errorf("Fields[%q] = %q, expected %q", name, got, value)
+		}
+	}
+	if _, ok := entry.Fields["latency"]; ok {
+		t.Error("Expected the renamed latency group not to be stored under its own name")
+	}
+	if entry.DurationMS != 42 {
+		t.Errorf("Expected the renamed duration to be parsed as 42ms, got %v", entry.DurationMS)
+	}
+}
+
+func TestNamedPatternSetsEntryValues(t *testing.T) {
+	pattern, err := NewNamedPattern("app", `^(?P<level>[A-Z]+) \| (?P<message>.*?) \| (?P<took>\d+)ms$`, nil)
+	if err != nil {
+		t.Fatalf("NewNamedPattern: %v", err)
+	}
+	SetNamedPatterns([]NamedPattern{pattern})
+	defer SetNamedPatterns(nil)
+
+	entry := ParseLogLine("WARNING | cache miss for key | 12ms")
+	if entry.Level != "WRN" {
+		t.Errorf("Level = %q, expected WRN", entry.Level)
+	}
+	if entry.Message != "cache miss for key" {
+		t.Errorf("Message = %q, expected the captured message", entry.Message)
+	}
+	if entry.Fields["took"] != "12" {
+		t.Errorf("Fields[took] = %q, expected 12", entry.Fields["took"])
+	}
+}
+
+func TestNamedPatternsOnlyApplyWithoutFields(t *testing.T) {
+	pattern, err := NewNamedPattern("any", `(?P<word>\w+)`, nil)
+	if err != nil {
+		t.Fatalf("NewNamedPattern: %v", err)
+	}
+	SetNamedPatterns([]NamedPattern{pattern})
+	defer SetNamedPatterns(nil)
+
+	entry := ParseLogLine(`{"level":"info","msg":"structured","user":"ada"}`)
+	if _, ok := entry.Fields["word"]; ok {
+		t.Errorf("Expected patterns not to apply to lines with parsed fields, got %v", entry.Fields)
+	}
+}
+
+func TestNewNamedPatternErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		expr   string
+		fields map[string]string
+	}{
+		{"invalid regex", `(?P<status>\d+`, nil},
+		{"no named groups", `(\d+) (\d+)`, nil},
+		{"unknown mapped group", `(?P<status>\d+)`, map[string]string{"latency": "duration"}},
+	}
+	for _, tt := range tests {
+		if _, err := NewNamedPattern(tt.name, tt.expr, tt.fields); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package logs
+
+import "strings"
+
+// SelfLabel marks the viewer's own container. The image sets it, and it can be added to
+// any container that should never be streamed back into the viewer.
+const SelfLabel = "docker-log-viewer.self"
+
+// minSelfIDPrefix is the length of the short container ID Docker uses as the default
+// hostname inside a container
+const minSelfIDPrefix = 12
+
+// IsSelfContainer reports whether c is the container the viewer itself runs in, so its
+// own logs are not streamed back in. c matches when it carries SelfLabel or when
+// hostname, the viewer's hostname, is c's short ID or its name.
+func IsSelfContainer(c Container, hostname string) bool {
+	if value, ok := c.Labels[SelfLabel]; ok && value != "false" {
+		return true
+	}
+	if hostname == "" {
+		return false
+	}
+	if len(hostname) >= minSelfIDPrefix && strings.HasPrefix(c.ID, hostname) {
+		return true
+	}
+	return hostname == c.Name || (c.RawName != "" && hostname == c.RawName)
+}
@@ -0,0 +1,33 @@
+package logs
+
+import "testing"
+
+func TestIsSelfContainer(t *testing.T) {
+	const id = "3f2b1c4e9a7d4e21b6c50d8e7f6a5b4c3f2b1c4e9a7d4e21b6c50d8e7f6a5b4c"
+
+	tests := []struct {
+		name      string
+		container Container
+		hostname  string
+		self      bool
+	}{
+		{"default hostname is the short ID", Container{ID: id, Name: "viewer-app-1"}, id[:12], true},
+		{"full ID as hostname", Container{ID: id, Name: "viewer-app-1"}, id, true},
+		{"hostname set to the container name", Container{ID: id, Name: "viewer"}, "viewer", true},
+		{"aliased container matches its raw name", Container{ID: id, Name: "api", RawName: "viewer-app-1"}, "viewer-app-1", true},
+		{"self label", Container{ID: id, Name: "viewer", Labels: map[string]string{SelfLabel: "true"}}, "laptop", true},
+		{"self label outside docker", Container{ID: id, Name: "viewer", Labels: map[string]string{SelfLabel: ""}}, "", true},
+		{"self label set to false", Container{ID: id, Name: "viewer", Labels: map[string]string{SelfLabel: "false"}}, "laptop", false},
+		{"other container", Container{ID: id, Name: "postgres"}, "a1b2c3d4e5f6", false},
+		{"short hostname is not an ID prefix", Container{ID: id, Name: "postgres"}, id[:4], false},
+		{"running on the host", Container{ID: id, Name: "postgres"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSelfContainer(tt.container, tt.hostname); got != tt.self {
+				t.Errorf("Expected IsSelfContainer to be %v, got %v", tt.self, got)
+			}
+		})
+	}
+}
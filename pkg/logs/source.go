@@ -0,0 +1,18 @@
+package logs
+
+import "context"
+
+// Source is an ingestion backend, such as the Docker log streams, that sends
+// ContainerMessages into a channel shared with the other sources
+type Source interface {
+	// Name identifies the source in logs
+	Name() string
+
+	// Start begins ingesting and returns once the source is running. Cancelling ctx
+	// stops the source as Stop does, without waiting for it.
+	Start(ctx context.Context) error
+
+	// Stop stops accepting new lines and returns once the source has sent its last
+	// message, so the shared channel can then be drained and closed
+	Stop()
+}
@@ -0,0 +1,78 @@
+package logs
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"sync"
+)
+
+// Suppressor drops noise lines, such as health checks and readiness probes, before
+// they are stored or sent to clients. It counts the lines each pattern drops so a
+// summary can be reported in their place.
+type Suppressor struct {
+	mu       sync.Mutex
+	patterns []*regexp.Regexp
+	counts   map[string]int // pattern -> lines suppressed since the last TakeCounts
+}
+
+// NewSuppressor returns a Suppressor without patterns, which suppresses nothing
+func NewSuppressor() *Suppressor {
+	return &Suppressor{counts: make(map[string]int)}
+}
+
+// CompileSuppressionPattern compiles a pattern matched against each line's raw text
+func CompileSuppressionPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("suppression pattern is required")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid suppression pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// SetPatterns replaces the patterns lines are suppressed by. Nil removes them all.
+func (s *Suppressor) SetPatterns(patterns []*regexp.Regexp) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.patterns = patterns
+}
+
+// Suppress reports whether msg matches a pattern, counting it against the first that
+// matches. ANSI codes are stripped before matching.
+func (s *Suppressor) Suppress(msg ContainerMessage) bool {
+	if msg.Entry == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.patterns) == 0 {
+		return false
+	}
+
+	text := msg.Entry.Raw
+	if text == "" {
+		text = msg.Entry.Message
+	}
+	text = stripANSI(text)
+	for _, re := range s.patterns {
+		if re.MatchString(text) {
+			s.counts[re.String()]++
+			return true
+		}
+	}
+	return false
+}
+
+// TakeCounts returns the lines suppressed by each pattern since the last call, and
+// starts counting afresh
+func (s *Suppressor) TakeCounts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := maps.Clone(s.counts)
+	clear(s.counts)
+	return counts
+}
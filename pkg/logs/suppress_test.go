@@ -0,0 +1,57 @@
+package logs
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSuppressor(t *testing.T) {
+	s := NewSuppressor()
+
+	line := func(raw string) ContainerMessage {
+		return ContainerMessage{ContainerID: "api", Entry: &LogEntry{Raw: raw, Message: raw}}
+	}
+	healthz := line(`10.0.0.1 - - "GET /healthz HTTP/1.1" 200 2`)
+	ready := line("\x1b[32mINF\x1b[0m readiness probe ok")
+	request := line(`10.0.0.1 - - "GET /api/users HTTP/1.1" 200 512`)
+
+	if s.Suppress(healthz) {
+		t.Fatal("Expected nothing to be suppressed without patterns")
+	}
+
+	s.SetPatterns([]*regexp.Regexp{
+		regexp.MustCompile(`GET /(healthz|readyz)`),
+		regexp.MustCompile(`^INF readiness probe`),
+	})
+
+	for _, msg := range []ContainerMessage{healthz, healthz, ready} {
+		if !s.Suppress(msg) {
+			t.Errorf("Expected %q to be suppressed", msg.Entry.Raw)
+		}
+	}
+	if s.Suppress(request) {
+		t.Error("Expected a regular request to be kept")
+	}
+	if s.Suppress(ContainerMessage{ContainerID: "api"}) {
+		t.Error("Expected a message without an entry to be kept")
+	}
+
+	counts := s.TakeCounts()
+	if counts[`GET /(healthz|readyz)`] != 2 || counts[`^INF readiness probe`] != 1 || len(counts) != 2 {
+		t.Errorf("Expected suppressed lines counted by pattern, got %v", counts)
+	}
+	if counts := s.TakeCounts(); len(counts) != 0 {
+		t.Errorf("Expected counts to reset once taken, got %v", counts)
+	}
+}
+
+func TestCompileSuppressionPattern(t *testing.T) {
+	if _, err := CompileSuppressionPattern(`GET /healthz`); err != nil {
+		t.Errorf("Expected a valid pattern to compile, got %v", err)
+	}
+	for _, pattern := range []string{"", "health(z"} {
+		if _, err := CompileSuppressionPattern(pattern); err == nil {
+			t.Errorf("Expected an error for %q", pattern)
+		}
+	}
+}
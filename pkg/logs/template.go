@@ -0,0 +1,235 @@
+package logs
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Placeholders substituted for the variable parts of a message by ExtractTemplate
+const (
+	TemplateNumber    = "<num>"
+	TemplateUUID      = "<uuid>"
+	TemplateIP        = "<ip>"
+	TemplateHex       = "<hex>"
+	TemplateID        = "<id>"
+	TemplateString    = "<str>"
+	TemplateTimestamp = "<ts>"
+	TemplateVersion   = "<ver>"
+)
+
+// minIDLength is the length from which a word mixing letters and digits, such as
+// a1b2c3d4 or job8xk2mq, counts as an ID. Shorter ones such as sha256 or utf8 are
+// more likely to be part of the message itself.
+const minIDLength = 8
+
+var (
+	templateUUIDPattern      = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	templateTimestampPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`)
+	templateIPPattern        = regexp.MustCompile(`^\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?`)
+	templateNumberPattern    = regexp.MustCompile(`^\d+(?:\.\d+)?(?:[eE][+-]?\d+)?`)
+	templateVersionPattern   = regexp.MustCompile(`^(?:v\d+(?:\.\d+)+|\d+(?:\.\d+){2,})(?:-[0-9A-Za-z]+(?:\.[0-9A-Za-z]+)*)?`)
+)
+
+// ExtractTemplate replaces the variable parts of a log message, such as numbers, UUIDs,
+// IP addresses, versions, hex strings, IDs, quoted strings and timestamps, with placeholders and
+// returns them in order as params. Messages that differ only in those parts share a
+// template, so "User 123 logged in" and "User 456 logged in" both become
+// "User <num> logged in". Units stay in the template, so 12ms and 12.5ms both become <num>ms.
+func ExtractTemplate(msg string) (template string, params []string) {
+	var b strings.Builder
+	b.Grow(len(msg))
+	params = []string{}
+
+	for i := 0; i < len(msg); {
+		c := msg[i]
+		rest := msg[i:]
+		atWordStart := !isTemplateWordByte(templatePrev(msg, i))
+
+		if !atWordStart || !(isTemplateAlnum(c) || c == '"' || c == '\'' || c == '-') {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+
+		if c == '"' || c == '\'' {
+			if end := closingQuote(msg, i); end > 0 {
+				b.WriteString(TemplateString)
+				params = append(params, msg[i+1:end])
+				i = end + 1
+				continue
+			}
+			b.WriteByte(c)
+			i++
+			continue
+		}
+
+		if c == '-' {
+			// A minus sign only when it starts a number, as in "offset -5"
+			if n := templateNumberPattern.FindString(msg[i+1:]); n != "" && !isTemplateWordByte(templatePeek(msg, i+1+len(n))) {
+				b.WriteString(TemplateNumber)
+				params = append(params, "-"+n)
+				i += 1 + len(n)
+				continue
+			}
+			b.WriteByte(c)
+			i++
+			continue
+		}
+
+		if placeholder, match, suffix := matchTemplatePattern(rest); match != "" {
+			b.WriteString(placeholder)
+			b.WriteString(suffix)
+			params = append(params, match)
+			i += len(match) + len(suffix)
+			continue
+		}
+
+		end := i
+		for end < len(msg) && isTemplateAlnum(msg[end]) {
+			end++
+		}
+		word := msg[i:end]
+		placeholder, value, suffix := classifyTemplateWord(word)
+		if placeholder == "" {
+			b.WriteString(word)
+		} else {
+			b.WriteString(placeholder)
+			b.WriteString(suffix)
+			params = append(params, value)
+		}
+		i = end
+	}
+
+	return b.String(), params
+}
+
+// matchTemplatePattern matches the multi-part values that span punctuation at the start
+// of s: UUIDs, timestamps, IP addresses, dotted versions and decimal numbers. A decimal
+// may carry a unit, returned as suffix, as classifyTemplateWord does for integers.
+func matchTemplatePattern(s string) (placeholder, match, suffix string) {
+	for _, p := range []struct {
+		placeholder string
+		pattern     *regexp.Regexp
+	}{
+		{TemplateUUID, templateUUIDPattern},
+		{TemplateTimestamp, templateTimestampPattern},
+		{TemplateIP, templateIPPattern},
+		{TemplateVersion, templateVersionPattern},
+	} {
+		if m := p.pattern.FindString(s); m != "" && !isTemplateWordByte(templatePeek(s, len(m))) {
+			return p.placeholder, m, ""
+		}
+	}
+
+	// Decimals and exponents; plain integers and integers with a unit are left to
+	// classifyTemplateWord
+	m := templateNumberPattern.FindString(s)
+	if !strings.ContainsAny(m, ".eE") {
+		return "", "", ""
+	}
+	unit := len(m)
+	for unit < len(s) && isTemplateAlpha(s[unit]) {
+		unit++
+	}
+	if unit-len(m) > 3 || isTemplateWordByte(templatePeek(s, unit)) {
+		return "", "", ""
+	}
+	return TemplateNumber, m, s[len(m):unit]
+}
+
+// classifyTemplateWord decides whether an alphanumeric word is variable. Words that are
+// a number followed by letters keep the letters as suffix, so they read as a unit.
+func classifyTemplateWord(word string) (placeholder, value, suffix string) {
+	digits := 0
+	for i := 0; i < len(word); i++ {
+		if isTemplateDigit(word[i]) {
+			digits++
+		}
+	}
+	if digits == 0 {
+		return "", "", ""
+	}
+
+	if len(word) > 2 && word[0] == '0' && (word[1] == 'x' || word[1] == 'X') && isHexString(word[2:]) {
+		return TemplateHex, word, ""
+	}
+
+	leading := 0
+	for leading < len(word) && isTemplateDigit(word[leading]) {
+		leading++
+	}
+	if leading == len(word) {
+		return TemplateNumber, word, ""
+	}
+	if leading == digits && leading > 0 && len(word)-leading <= 3 {
+		// 12ms or 5s
+		return TemplateNumber, word[:leading], word[leading:]
+	}
+
+	if len(word) >= minIDLength {
+		if isHexString(word) {
+			return TemplateHex, word, ""
+		}
+		return TemplateID, word, ""
+	}
+	return "", "", ""
+}
+
+// closingQuote returns the index of the quote closing the one at start, or -1. The quote
+// must be followed by a non-word byte, so an apostrophe in "don't" opens nothing.
+func closingQuote(s string, start int) int {
+	q := s[start]
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case q:
+			if !isTemplateWordByte(templatePeek(s, i+1)) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isHexString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !isTemplateDigit(c) && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isTemplateDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isTemplateAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isTemplateAlnum(c byte) bool {
+	return isTemplateDigit(c) || isTemplateAlpha(c)
+}
+
+// isTemplateWordByte reports whether c continues a word, so a value embedded in one,
+// as in user123, is not split out
+func isTemplateWordByte(c byte) bool {
+	return isTemplateAlnum(c) || c >= 0x80
+}
+
+func templatePeek(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return 0
+}
+
+func templatePrev(s string, i int) byte {
+	if i > 0 {
+		return s[i-1]
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package logs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractTemplate(t *testing.T) {
+	tests := []struct {
+		message  string
+		template string
+		params   []string
+	}{
+		{"User 123 logged in", "User <num> logged in", []string{"123"}},
+		{"Request took 12ms", "Request took <num>ms", []string{"12"}},
+		{"cache hit ratio 0.93", "cache hit ratio <num>", []string{"0.93"}},
+		{"took 12.5ms", "took <num>ms", []string{"12.5"}},
+		{"upgraded to v1.2.3 from 1.10.0-rc.1", "upgraded to <ver> from <ver>", []string{"v1.2.3", "1.10.0-rc.1"}},
+		{"offset -5 out of range", "offset <num> out of range", []string{"-5"}},
+		{"loaded order 550e8400-e29b-41d4-a716-446655440000", "loaded order <uuid>", []string{"550e8400-e29b-41d4-a716-446655440000"}},
+		{"connection from 10.0.0.12:5432 closed", "connection from <ip> closed", []string{"10.0.0.12:5432"}},
+		{"commit 9fceb02d0ae598e95dc970b74767f19372d61af8 deployed", "commit <hex> deployed", []string{"9fceb02d0ae598e95dc970b74767f19372d61af8"}},
+		{"pointer 0x1f3a freed", "pointer <hex> freed", []string{"0x1f3a"}},
+		{"job kq8x2mzp9 queued", "job <id> queued", []string{"kq8x2mzp9"}},
+		{`user "alice" not found`, `user <str> not found`, []string{"alice"}},
+		{`key 'a b' missing`, `key <str> missing`, []string{"a b"}},
+		{"started at 2024-01-02T10:00:00.123Z", "started at <ts>", []string{"2024-01-02T10:00:00.123Z"}},
+		{"user_id=42 status=200", "user_id=<num> status=<num>", []string{"42", "200"}},
+		// Values embedded in words and short alphanumerics are part of the message
+		{"don't retry user123 over http2 with sha256", "don't retry user123 over http2 with sha256", []string{}},
+		{"no variables here", "no variables here", []string{}},
+		{"héllo 7 wörld", "héllo <num> wörld", []string{"7"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			template, params := ExtractTemplate(tt.message)
+			if template != tt.template {
+				t.Errorf("Expected template %q, got %q", tt.template, template)
+			}
+			if !slices.Equal(params, tt.params) {
+				t.Errorf("Expected params %q, got %q", tt.params, params)
+			}
+		})
+	}
+}
+
+func TestExtractTemplateGroupsVariants(t *testing.T) {
+	variants := [][]string{
+		{
+			"User 123 logged in",
+			"User 456 logged in",
+			"User 7 logged in",
+		},
+		{
+			`Fetched 3 rows for tenant "acme" in 12.5ms`,
+			`Fetched 250 rows for tenant "globex" in 0.8ms`,
+		},
+		{
+			"deployed v1.2.3 in 12.5ms",
+			"deployed v10.0.12 in 300ms",
+		},
+		{
+			"session 3f2b1c4e-9a7d-4e21-b6c5-0d8e7f6a5b4c expired after 30s",
+			"session 7c6d5e4f-3b2a-4190-8f7e-6d5c4b3a2910 expired after 900s",
+		},
+	}
+
+	for _, group := range variants {
+		first, firstParams := ExtractTemplate(group[0])
+		for _, message := range group[1:] {
+			template, params := ExtractTemplate(message)
+			if template != first {
+				t.Errorf("Expected %q to share template %q, got %q", message, first, template)
+			}
+			if len(params) != len(firstParams) {
+				t.Errorf("Expected %d params for %q, got %q", len(firstParams), message, params)
+			}
+		}
+	}
+}
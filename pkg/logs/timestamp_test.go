@@ -121,3 +121,31 @@ func TestParseTimestampPreservesNanoseconds(t *testing.T) {
 		t.Errorf("ParseTimestamp(%q) nanoseconds = %d, want ~76536000", input, ts.Nanosecond())
 	}
 }
+
+func TestParseDockerTimestamp(t *testing.T) {
+	ts, line := parseDockerTimestamp("2024-12-04T10:30:00.123456789Z worker started job=42")
+	expected := time.Date(2024, 12, 4, 10, 30, 0, 123456789, time.UTC)
+	if !ts.Equal(expected) {
+		t.Errorf("Expected Docker timestamp %v, got %v", expected, ts)
+	}
+	if line != "worker started job=42" {
+		t.Errorf("Expected the prefix to be stripped, got %q", line)
+	}
+
+	entry := ParseLogLine(line)
+	synthesizeTimestamp(entry, ts)
+	if entry.Timestamp != "2024-12-04T10:30:00.123456789Z" {
+		t.Errorf("Expected the Docker timestamp on an entry without one, got %q", entry.Timestamp)
+	}
+
+	// Lines without the prefix, and entries with their own timestamp, are left alone
+	ts, line = parseDockerTimestamp("worker started")
+	if !ts.IsZero() || line != "worker started" {
+		t.Errorf("Expected an unprefixed line to be unchanged, got %v %q", ts, line)
+	}
+	entry = &LogEntry{Timestamp: "2024-12-04T10:29:59Z"}
+	synthesizeTimestamp(entry, expected)
+	if entry.Timestamp != "2024-12-04T10:29:59Z" {
+		t.Errorf("Expected an inline timestamp to be kept, got %q", entry.Timestamp)
+	}
+}
@@ -0,0 +1,62 @@
+package logs
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// DefaultMaxLineLength is the length in bytes beyond which ParseLogLine truncates a
+// line. Lines this long are usually serialized blobs or base64 images rather than
+// anything worth reading in full.
+const DefaultMaxLineLength = 64 * 1024
+
+var maxLineLength atomic.Int64
+
+func init() {
+	maxLineLength.Store(DefaultMaxLineLength)
+}
+
+// SetMaxLineLength sets the length in bytes beyond which ParseLogLine truncates lines.
+// Zero keeps lines whole; negative values restore the default.
+func SetMaxLineLength(n int) {
+	if n < 0 {
+		n = DefaultMaxLineLength
+	}
+	maxLineLength.Store(int64(n))
+}
+
+// MaxLineLength returns the length set by SetMaxLineLength
+func MaxLineLength() int {
+	return int(maxLineLength.Load())
+}
+
+// truncateLine cuts line to at most limit bytes, backing off to a rune boundary, and
+// returns the bytes dropped. A limit of zero keeps the line whole.
+func truncateLine(line string, limit int) (head string, dropped int) {
+	if limit <= 0 || len(line) <= limit {
+		return line, 0
+	}
+	end := limit
+	for end > 0 && !utf8.RuneStart(line[end]) {
+		end--
+	}
+	return line[:end], len(line) - end
+}
+
+// truncationMarker is appended to an entry's Raw and Message when dropped bytes were
+// cut from its line
+func truncationMarker(dropped int) string {
+	return fmt.Sprintf("…[truncated %d bytes]", dropped)
+}
+
+// dropMore counts n more bytes as cut from a truncated entry, such as a continuation
+// line that would have fallen past the limit, and updates the marker to match
+func (e *LogEntry) dropMore(n int) {
+	old := truncationMarker(e.dropped)
+	e.dropped += n
+	marker := truncationMarker(e.dropped)
+	e.Raw = strings.TrimSuffix(e.Raw, old) + marker
+	e.Message = strings.TrimSuffix(e.Message, old) + marker
+}
@@ -0,0 +1,72 @@
+package logs
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseLogLineTruncatesLongLines(t *testing.T) {
+	defer SetMaxLineLength(DefaultMaxLineLength)
+	SetMaxLineLength(1024)
+
+	// A multi-megabyte base64 image logged after the fields
+	blob := strings.Repeat("iVBORw0KGgoAAAANSUhEUgAA", 100_000)
+	line := `2024-01-01T10:00:00Z INF avatar uploaded request_id=abc123 duration=12 data=` + blob
+
+	entry := ParseLogLine(line)
+	if !entry.Truncated {
+		t.Fatal("Expected the entry to be marked truncated")
+	}
+	dropped := len(line) - 1024
+	marker := truncationMarker(dropped)
+	if !strings.HasSuffix(entry.Raw, marker) || !strings.HasSuffix(entry.Message, marker) {
+		t.Errorf("Expected raw and message to end with %q", marker)
+	}
+	if len(entry.Raw) != 1024+len(marker) {
+		t.Errorf("Expected raw to keep 1024 bytes of the line, got %d", len(entry.Raw)-len(marker))
+	}
+	if entry.Fields["request_id"] != "abc123" || entry.Level != "INF" || entry.DurationMS != 12 {
+		t.Errorf("Expected fields parsed from the head of the line, got level %q, fields %v and duration %v", entry.Level, entry.Fields, entry.DurationMS)
+	}
+
+	short := ParseLogLine(`2024-01-01T10:00:00Z INF ok request_id=abc123`)
+	if short.Truncated || strings.Contains(short.Raw, "truncated") {
+		t.Errorf("Expected a short line to be kept whole, got %+v", short)
+	}
+
+	SetMaxLineLength(0)
+	if entry := ParseLogLine(line); entry.Truncated || len(entry.Raw) != len(line) {
+		t.Error("Expected a zero limit to keep lines whole")
+	}
+}
+
+func TestTruncateLineKeepsRunesWhole(t *testing.T) {
+	head, dropped := truncateLine("héllo", 2)
+	if head != "h" || dropped != 5 {
+		t.Errorf("Expected to back off to the start of é, got %q and %d dropped", head, dropped)
+	}
+	if !utf8.ValidString(head) {
+		t.Errorf("Expected valid UTF-8, got %q", head)
+	}
+}
+
+func TestDropMoreKeepsOneMarker(t *testing.T) {
+	defer SetMaxLineLength(DefaultMaxLineLength)
+	SetMaxLineLength(64)
+
+	first := `2024-01-01T10:00:00Z INF query failed ` + strings.Repeat("x", 100)
+	continuation := "  at the continuation line"
+
+	entry := ParseLogLine(first)
+	entry.dropMore(len("\n") + len(continuation))
+
+	// The same cut as parsing the joined lines in one go
+	whole := ParseLogLine(first + "\n" + continuation)
+	if entry.Raw != whole.Raw || entry.Message != whole.Message {
+		t.Errorf("Expected %q, got %q", whole.Raw, entry.Raw)
+	}
+	if n := strings.Count(entry.Raw, "…[truncated "); n != 1 {
+		t.Errorf("Expected a single truncation marker, got %d in %q", n, entry.Raw)
+	}
+}
@@ -2,9 +2,14 @@ package logstore
 
 import (
 	"container/list"
+	"context"
 	"docker-log-parser/pkg/logs"
+	"fmt"
+	"maps"
+	"regexp"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -28,26 +33,78 @@ type LogStore struct {
 	// Element tracking
 	messageCount int
 
+	// Running counts kept in step with Add and removeMessage, keyed by normalized level
+	levelCounts          map[string]int            // level -> count
+	containerLevelCounts map[string]map[string]int // container_id -> level -> count
+
 	// Per-container retention settings
 	containerRetention map[string]ContainerRetentionPolicy
+
+	// Messages evicted since the last TakeEvictions
+	evicted map[string]int // container_id -> count
 }
 
-// ContainerRetentionPolicy defines retention for a specific container
+// DefaultMinKeep is how many of a container's newest logs a time policy keeps however
+// old they are, unless the policy sets its own MinKeep
+const DefaultMinKeep = 100
+
+// ContainerRetentionPolicy defines retention for a specific container. A "time" policy
+// replaces the store's maxAge for the container, whether shorter or longer, while the
+// store's per-container maxMessages still applies.
 type ContainerRetentionPolicy struct {
-	Type  string // "count" or "time"
-	Value int    // number of logs or seconds
+	Type    string // "count" or "time"
+	Value   int    // number of logs or seconds
+	MinKeep int    // newest logs a time policy always keeps; 0 for DefaultMinKeep
 }
 
 // NewLogStore creates a new log store
 func NewLogStore(maxMessages int, maxAge time.Duration) *LogStore {
 	return &LogStore{
-		messages:           list.New(),
-		byContainer:        make(map[string]*list.List),
-		byField:            make(map[string]map[string]*list.List),
-		maxMessages:        maxMessages,
-		maxAge:             maxAge,
-		messageCount:       0,
-		containerRetention: make(map[string]ContainerRetentionPolicy),
+		messages:             list.New(),
+		byContainer:          make(map[string]*list.List),
+		byField:              make(map[string]map[string]*list.List),
+		maxMessages:          maxMessages,
+		maxAge:               maxAge,
+		messageCount:         0,
+		levelCounts:          make(map[string]int),
+		containerLevelCounts: make(map[string]map[string]int),
+		containerRetention:   make(map[string]ContainerRetentionPolicy),
+		evicted:              make(map[string]int),
+	}
+}
+
+// NoLevel is the level messages without a parsed level are counted under, matching the
+// NONE level filter
+const NoLevel = "NONE"
+
+// normalizeLevel returns the key a level is counted under
+func normalizeLevel(level string) string {
+	if level == "" {
+		return NoLevel
+	}
+	return strings.ToUpper(level)
+}
+
+// countLevel adjusts the level counters for msg by delta. Must be called with lock held.
+func (ls *LogStore) countLevel(msg *logs.ContainerMessage, delta int) {
+	level := normalizeLevel(msg.Entry.Level)
+
+	ls.levelCounts[level] += delta
+	if ls.levelCounts[level] <= 0 {
+		delete(ls.levelCounts, level)
+	}
+
+	counts := ls.containerLevelCounts[msg.ContainerID]
+	if counts == nil {
+		counts = make(map[string]int)
+		ls.containerLevelCounts[msg.ContainerID] = counts
+	}
+	counts[level] += delta
+	if counts[level] <= 0 {
+		delete(counts, level)
+	}
+	if len(counts) == 0 {
+		delete(ls.containerLevelCounts, msg.ContainerID)
 	}
 }
 
@@ -59,6 +116,7 @@ func (ls *LogStore) Add(msg *logs.ContainerMessage) {
 	// Add to main list (most recent at front)
 	elem := ls.messages.PushFront(msg)
 	ls.messageCount++
+	ls.countLevel(msg, 1)
 
 	// Index by container
 	if ls.byContainer[msg.ContainerID] == nil {
@@ -77,10 +135,13 @@ func (ls *LogStore) Add(msg *logs.ContainerMessage) {
 		ls.byField[k][v].PushFront(elem)
 	}
 
-	// Check if this container has a retention policy, otherwise use default per-container limit
-	if _, exists := ls.containerRetention[msg.ContainerID]; exists {
+	// Check if this container has a retention policy, otherwise use default per-container limit.
+	// Time policies only replace maxAge, so the default limit still applies to them.
+	policy, exists := ls.containerRetention[msg.ContainerID]
+	if exists {
 		ls.applyContainerRetention(msg.ContainerID)
-	} else {
+	}
+	if !exists || policy.Type == "time" {
 		// Apply default per-container limit (maxMessages is per-container, not global)
 		containerList := ls.byContainer[msg.ContainerID]
 		if containerList != nil && containerList.Len() > ls.maxMessages {
@@ -115,28 +176,107 @@ func (ls *LogStore) evictOldestFromContainer(containerID string) {
 
 // evictExpired removes messages older than maxAge
 func (ls *LogStore) evictExpired() {
+	ls.evictExpiredUpTo(0)
+}
+
+// evictExpiredUpTo removes up to limit messages older than maxAge, or all of them when
+// limit is 0, and returns how many it removed. Containers with a time policy are left
+// to applyContainerRetention. Must be called with lock held.
+func (ls *LogStore) evictExpiredUpTo(limit int) int {
 	cutoff := time.Now().Add(-ls.maxAge)
 
-	for {
-		elem := ls.messages.Back()
-		if elem == nil {
+	removed := 0
+	for containerID, containerList := range ls.byContainer {
+		if ls.containerRetention[containerID].Type == "time" {
+			continue
+		}
+
+		for limit <= 0 || removed < limit {
+			e := containerList.Back()
+			if e == nil {
+				break
+			}
+
+			elem := e.Value.(*list.Element)
+			msg := elem.Value.(*logs.ContainerMessage)
+			if msg.Timestamp.After(cutoff) {
+				break
+			}
+
+			ls.removeMessage(elem, msg)
+			removed++
+		}
+		if limit > 0 && removed >= limit {
 			break
 		}
+	}
+	return removed
+}
 
-		msg := elem.Value.(*logs.ContainerMessage)
-		if msg.Timestamp.After(cutoff) {
+// sweepBatchSize is the most expired messages Sweep removes per hold of the write lock,
+// so a large backlog doesn't keep readers waiting
+const sweepBatchSize = 1000
+
+// Sweep evicts messages older than maxAge, and those past their container's time
+// retention, without waiting for Add. It returns how many messages it removed.
+func (ls *LogStore) Sweep() int {
+	removed := 0
+	for {
+		ls.mu.Lock()
+		n := ls.evictExpiredUpTo(sweepBatchSize)
+		ls.mu.Unlock()
+		removed += n
+		if n < sweepBatchSize {
 			break
 		}
+	}
 
-		ls.removeMessage(elem, msg)
+	ls.mu.RLock()
+	var timed []string
+	for containerID, policy := range ls.containerRetention {
+		if policy.Type == "time" {
+			timed = append(timed, containerID)
+		}
+	}
+	ls.mu.RUnlock()
+
+	for _, containerID := range timed {
+		ls.mu.Lock()
+		before := ls.messageCount
+		ls.applyContainerRetention(containerID)
+		removed += before - ls.messageCount
+		ls.mu.Unlock()
 	}
+	return removed
 }
 
-// removeMessage removes a message from all indexes
+// RunSweeper calls Sweep every interval until ctx is done, so age-based retention holds
+// while ingestion is paused. A non-positive interval returns at once.
+func (ls *LogStore) RunSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ls.Sweep()
+		}
+	}
+}
+
+// removeMessage removes a message from all indexes, counting it as evicted
 func (ls *LogStore) removeMessage(elem *list.Element, msg *logs.ContainerMessage) {
 	// Remove from main list
 	ls.messages.Remove(elem)
 	ls.messageCount--
+	ls.countLevel(msg, -1)
+	ls.evicted[msg.ContainerID]++
 
 	// Remove from container index
 	if containerList := ls.byContainer[msg.ContainerID]; containerList != nil {
@@ -172,6 +312,42 @@ func (ls *LogStore) removeMessage(elem *list.Element, msg *logs.ContainerMessage
 	}
 }
 
+// Eviction reports the messages evicted from a container since the previous
+// TakeEvictions
+type Eviction struct {
+	ContainerID string    `json:"containerId"`
+	Count       int       `json:"count"`
+	Oldest      time.Time `json:"oldest,omitzero"` // The container's oldest message left; zero when none are
+}
+
+// TakeEvictions returns the messages evicted per container since the previous call,
+// sorted by container, and resets the counts
+func (ls *LogStore) TakeEvictions() []Eviction {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if len(ls.evicted) == 0 {
+		return nil
+	}
+
+	evictions := make([]Eviction, 0, len(ls.evicted))
+	for containerID, count := range ls.evicted {
+		eviction := Eviction{ContainerID: containerID, Count: count}
+		if containerList := ls.byContainer[containerID]; containerList != nil {
+			if e := containerList.Back(); e != nil {
+				eviction.Oldest = e.Value.(*list.Element).Value.(*logs.ContainerMessage).Timestamp
+			}
+		}
+		evictions = append(evictions, eviction)
+	}
+	sort.Slice(evictions, func(i, j int) bool {
+		return evictions[i].ContainerID < evictions[j].ContainerID
+	})
+
+	ls.evicted = make(map[string]int)
+	return evictions
+}
+
 // SearchByContainer returns all messages for a specific container
 func (ls *LogStore) SearchByContainer(containerID string, limit int) []*logs.ContainerMessage {
 	ls.mu.RLock()
@@ -391,6 +567,30 @@ func (ls *LogStore) SearchComplex(criteria SearchCriteria, limit int) []*logs.Co
 	return results
 }
 
+// SearchByTrace returns messages carrying ANY of the given trace or request IDs (OR
+// operation) logged between after and before, each message once
+func (ls *LogStore) SearchByTrace(ids []FieldFilter, after, before time.Time, limit int) []*logs.ContainerMessage {
+	seen := make(map[*logs.ContainerMessage]bool)
+	results := make([]*logs.ContainerMessage, 0)
+	for _, id := range ids {
+		if len(results) >= limit {
+			break
+		}
+		criteria := SearchCriteria{
+			Fields: []FieldFilter{id},
+			After:  &after,
+			Before: &before,
+		}
+		for _, msg := range ls.SearchComplex(criteria, limit-len(results)) {
+			if !seen[msg] {
+				seen[msg] = true
+				results = append(results, msg)
+			}
+		}
+	}
+	return results
+}
+
 // matchesCriteria checks if a message matches all search criteria
 func (ls *LogStore) matchesCriteria(msg *logs.ContainerMessage, criteria SearchCriteria) bool {
 	// Check container
@@ -456,6 +656,135 @@ func (ls *LogStore) CountByContainer(containerID string) int {
 	return containerList.Len()
 }
 
+// CountByLevel returns the number of messages at level, compared case-insensitively.
+// Messages without a parsed level are counted under NoLevel.
+func (ls *LogStore) CountByLevel(level string) int {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.levelCounts[normalizeLevel(level)]
+}
+
+// LevelHistogram returns the number of messages at each level present in the store
+func (ls *LogStore) LevelHistogram() map[string]int {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return maps.Clone(ls.levelCounts)
+}
+
+// ContainerLevelHistogram returns the number of messages at each level for a container
+func (ls *LogStore) ContainerLevelHistogram(containerID string) map[string]int {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	counts := maps.Clone(ls.containerLevelCounts[containerID])
+	if counts == nil {
+		counts = make(map[string]int)
+	}
+	return counts
+}
+
+// FieldNames returns the names of all indexed fields, most frequent first
+func (ls *LogStore) FieldNames() []string {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	counts := make(map[string]int, len(ls.byField))
+	for name, values := range ls.byField {
+		for _, valueList := range values {
+			counts[name] += valueList.Len()
+		}
+	}
+
+	return sortByFrequency(counts, 0)
+}
+
+// FieldValues returns up to limit distinct values seen for a field, most frequent first
+func (ls *LogStore) FieldValues(name string, limit int) []string {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	fieldMap := ls.byField[name]
+	if fieldMap == nil {
+		return []string{}
+	}
+
+	counts := make(map[string]int, len(fieldMap))
+	for value, valueList := range fieldMap {
+		counts[value] = valueList.Len()
+	}
+
+	return sortByFrequency(counts, limit)
+}
+
+// HistogramOther is the value of the bucket FieldHistogram folds values beyond topN into
+const HistogramOther = "(other)"
+
+// ValueCount is how many messages carry one value of a field
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+	Other bool   `json:"other,omitempty"` // The HistogramOther bucket, rather than a real value
+}
+
+// FieldHistogram returns how many messages matching opts carry each value of a field,
+// most frequent first. Beyond topN values the rest are summed into a HistogramOther
+// bucket; a topN of 0 or less returns every value. Messages without the field are not
+// counted.
+func (ls *LogStore) FieldHistogram(fieldName string, opts FilterOptions, topN int) []ValueCount {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	matchers := opts.termMatchers()
+	counts := make(map[string]int)
+	for value, valueList := range ls.byField[fieldName] {
+		if value == "" {
+			continue
+		}
+		for e := valueList.Front(); e != nil; e = e.Next() {
+			msg := e.Value.(*list.Element).Value.(*logs.ContainerMessage)
+			if matchesFilterOptions(msg, opts, matchers) {
+				counts[value]++
+			}
+		}
+	}
+
+	values := sortByFrequency(counts, 0)
+	histogram := []ValueCount{}
+	other := 0
+	for i, value := range values {
+		if topN > 0 && i >= topN {
+			other += counts[value]
+			continue
+		}
+		histogram = append(histogram, ValueCount{Value: value, Count: counts[value]})
+	}
+	if other > 0 {
+		histogram = append(histogram, ValueCount{Value: HistogramOther, Count: other, Other: true})
+	}
+	return histogram
+}
+
+// sortByFrequency returns map keys ordered by descending count, then name.
+// A limit of 0 or less returns all keys.
+func sortByFrequency(counts map[string]int, limit int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
+
 // SetMaxMessages updates the maximum message limit per container
 func (ls *LogStore) SetMaxMessages(max int) {
 	ls.mu.Lock()
@@ -476,6 +805,113 @@ type FilterOptions struct {
 	Levels       []string // Empty means all levels
 	SearchTerms  []string // All terms must match (AND)
 	FieldFilters []FieldFilter
+	HasFields    []string           // All must be present with a non-empty value, whatever it is
+	RangeFilters []FieldRangeFilter // All must match; fields are compared numerically
+	// MinDurationMS only matches entries whose parsed duration exceeds it, when positive
+	MinDurationMS float64
+	// After only matches entries timestamped at or after it, when set
+	After time.Time
+	// ExcludedContainerIDs never match, even when also in ContainerIDs
+	ExcludedContainerIDs []string
+
+	// CaseSensitive matches search terms exactly instead of lowercasing both sides
+	CaseSensitive bool
+	// WholeWord only matches search terms on word boundaries, so "id" does not match "invalid"
+	WholeWord bool
+	// Pattern only matches entries whose message, raw line or a field value matches it,
+	// when set
+	Pattern *regexp.Regexp
+}
+
+// Comparison operators for FieldRangeFilter
+const (
+	RangeOpGreater      = ">"
+	RangeOpGreaterEqual = ">="
+	RangeOpLess         = "<"
+	RangeOpLessEqual    = "<="
+	RangeOpEqual        = "="
+)
+
+// FieldRangeFilter compares a field against a value, numerically when both parse as
+// floats. Non-numeric values only match RangeOpEqual, as an exact string comparison.
+type FieldRangeFilter struct {
+	Name  string `json:"name"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// ParseFieldRangeFilter parses an expression such as "duration>100" or "db.rows<=5"
+func ParseFieldRangeFilter(expr string) (FieldRangeFilter, error) {
+	// Two-character operators first so ">=" is not read as ">"
+	for _, op := range []string{RangeOpGreaterEqual, RangeOpLessEqual, RangeOpGreater, RangeOpLess, RangeOpEqual} {
+		name, value, ok := strings.Cut(expr, op)
+		if !ok {
+			continue
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if name == "" || value == "" {
+			break
+		}
+		return FieldRangeFilter{Name: name, Op: op, Value: value}, nil
+	}
+	return FieldRangeFilter{}, fmt.Errorf("invalid range filter %q, expected field followed by >, >=, <, <= or = and a value", expr)
+}
+
+// Matches reports whether a field value satisfies the filter
+func (f FieldRangeFilter) Matches(fieldValue string) bool {
+	actual, errActual := strconv.ParseFloat(strings.TrimSpace(fieldValue), 64)
+	expected, errExpected := strconv.ParseFloat(f.Value, 64)
+	if errActual != nil || errExpected != nil {
+		return f.Op == RangeOpEqual && fieldValue == f.Value
+	}
+
+	switch f.Op {
+	case RangeOpGreater:
+		return actual > expected
+	case RangeOpGreaterEqual:
+		return actual >= expected
+	case RangeOpLess:
+		return actual < expected
+	case RangeOpLessEqual:
+		return actual <= expected
+	case RangeOpEqual:
+		return actual == expected
+	}
+	return false
+}
+
+// TermMatcher reports whether a search term occurs in a string
+type TermMatcher func(s string) bool
+
+// NewTermMatcher builds a matcher for a single search term
+func NewTermMatcher(term string, caseSensitive, wholeWord bool) TermMatcher {
+	if wholeWord {
+		pattern := `\b` + regexp.QuoteMeta(term) + `\b`
+		if !caseSensitive {
+			pattern = "(?i)" + pattern
+		}
+		return regexp.MustCompile(pattern).MatchString
+	}
+
+	if caseSensitive {
+		return func(s string) bool {
+			return strings.Contains(s, term)
+		}
+	}
+
+	query := strings.ToLower(term)
+	return func(s string) bool {
+		return strings.Contains(strings.ToLower(s), query)
+	}
+}
+
+// termMatchers compiles the search terms once so they can be applied to many messages
+func (opts FilterOptions) termMatchers() []TermMatcher {
+	matchers := make([]TermMatcher, 0, len(opts.SearchTerms))
+	for _, term := range opts.SearchTerms {
+		matchers = append(matchers, NewTermMatcher(term, opts.CaseSensitive, opts.WholeWord))
+	}
+	return matchers
 }
 
 // Filter returns messages matching all filter criteria with a limit
@@ -485,9 +921,17 @@ func (ls *LogStore) Filter(opts FilterOptions, limit int) []*logs.ContainerMessa
 
 	results := make([]*logs.ContainerMessage, 0, limit)
 	count := 0
+	matchers := opts.termMatchers()
 
 	// Priority: FieldFilters > Single Container > Multiple Containers > All Messages
 
+	// A field no message carries rules out every message
+	for _, name := range opts.HasFields {
+		if !ls.hasFieldValue(name) {
+			return results
+		}
+	}
+
 	// If field filters specified, use the smallest field index
 	if len(opts.FieldFilters) > 0 {
 		smallestSize := -1
@@ -514,7 +958,7 @@ func (ls *LogStore) Filter(opts FilterOptions, limit int) []*logs.ContainerMessa
 		for e := fieldIndexList.Front(); e != nil && count < limit; e = e.Next() {
 			elem := e.Value.(*list.Element)
 			msg := elem.Value.(*logs.ContainerMessage)
-			if ls.matchesFilterOptions(msg, opts) {
+			if matchesFilterOptions(msg, opts, matchers) {
 				results = append(results, msg)
 				count++
 			}
@@ -525,14 +969,14 @@ func (ls *LogStore) Filter(opts FilterOptions, limit int) []*logs.ContainerMessa
 	// Single container - use container index
 	if len(opts.ContainerIDs) == 1 {
 		containerList := ls.byContainer[opts.ContainerIDs[0]]
-		if containerList == nil {
+		if containerList == nil || slices.Contains(opts.ExcludedContainerIDs, opts.ContainerIDs[0]) {
 			return results
 		}
 
 		for e := containerList.Front(); e != nil && count < limit; e = e.Next() {
 			elem := e.Value.(*list.Element)
 			msg := elem.Value.(*logs.ContainerMessage)
-			if ls.matchesFilterOptions(msg, opts) {
+			if matchesFilterOptions(msg, opts, matchers) {
 				results = append(results, msg)
 				count++
 			}
@@ -546,14 +990,14 @@ func (ls *LogStore) Filter(opts FilterOptions, limit int) []*logs.ContainerMessa
 		candidateResults := make([]*logs.ContainerMessage, 0)
 		for _, containerID := range opts.ContainerIDs {
 			containerList := ls.byContainer[containerID]
-			if containerList == nil {
+			if containerList == nil || slices.Contains(opts.ExcludedContainerIDs, containerID) {
 				continue
 			}
 
 			for e := containerList.Front(); e != nil; e = e.Next() {
 				elem := e.Value.(*list.Element)
 				msg := elem.Value.(*logs.ContainerMessage)
-				if ls.matchesFilterOptions(msg, opts) {
+				if matchesFilterOptions(msg, opts, matchers) {
 					candidateResults = append(candidateResults, msg)
 				}
 			}
@@ -576,7 +1020,7 @@ func (ls *LogStore) Filter(opts FilterOptions, limit int) []*logs.ContainerMessa
 	// No indexes to use, search main list
 	for e := ls.messages.Front(); e != nil && count < limit; e = e.Next() {
 		msg := e.Value.(*logs.ContainerMessage)
-		if ls.matchesFilterOptions(msg, opts) {
+		if matchesFilterOptions(msg, opts, matchers) {
 			results = append(results, msg)
 			count++
 		}
@@ -585,9 +1029,28 @@ func (ls *LogStore) Filter(opts FilterOptions, limit int) []*logs.ContainerMessa
 	return results
 }
 
-// matchesFilterOptions checks if a message matches all filter criteria
-func (ls *LogStore) matchesFilterOptions(msg *logs.ContainerMessage, opts FilterOptions) bool {
-	// Container filter
+// hasFieldValue reports whether any message has a non-empty value for the field, from the
+// field index. Caller must hold the lock.
+func (ls *LogStore) hasFieldValue(name string) bool {
+	for value := range ls.byField[name] {
+		if value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesFilterOptions checks if a message matches all filter criteria.
+// matchers are the compiled opts.SearchTerms.
+func matchesFilterOptions(msg *logs.ContainerMessage, opts FilterOptions, matchers []TermMatcher) bool {
+	if !opts.After.IsZero() && msg.Timestamp.Before(opts.After) {
+		return false
+	}
+
+	// Container filter, where exclusion wins over inclusion
+	if slices.Contains(opts.ExcludedContainerIDs, msg.ContainerID) {
+		return false
+	}
 	if len(opts.ContainerIDs) > 0 {
 		found := slices.Contains(opts.ContainerIDs, msg.ContainerID)
 		if !found {
@@ -621,40 +1084,41 @@ func (ls *LogStore) matchesFilterOptions(msg *logs.ContainerMessage, opts Filter
 	}
 
 	// Search terms filter - AND multiple terms together
-	if len(opts.SearchTerms) > 0 {
-		for _, term := range opts.SearchTerms {
-			query := strings.ToLower(term)
-			found := false
+	for _, match := range matchers {
+		found := false
+
+		// Search in message
+		if match(msg.Entry.Message) {
+			found = true
+		}
 
-			// Search in message
-			if strings.Contains(strings.ToLower(msg.Entry.Message), query) {
+		// Search in raw log
+		if !found {
+			if match(msg.Entry.Raw) {
 				found = true
 			}
+		}
 
-			// Search in raw log
-			if !found {
-				if strings.Contains(strings.ToLower(msg.Entry.Raw), query) {
+		// Search in fields
+		if !found {
+			for key, value := range msg.Entry.Fields {
+				if match(key) || match(value) {
 					found = true
+					break
 				}
 			}
+		}
 
-			// Search in fields
-			if !found {
-				for key, value := range msg.Entry.Fields {
-					if strings.Contains(strings.ToLower(key), query) || strings.Contains(strings.ToLower(value), query) {
-						found = true
-						break
-					}
-				}
-			}
-
-			// If any term is not found, the log doesn't match (AND logic)
-			if !found {
-				return false
-			}
+		// If any term is not found, the log doesn't match (AND logic)
+		if !found {
+			return false
 		}
 	}
 
+	if opts.Pattern != nil && !matchesPattern(msg.Entry, opts.Pattern) {
+		return false
+	}
+
 	// Field filters - all must match
 	for _, filter := range opts.FieldFilters {
 		if msg.Entry.Fields[filter.Name] != filter.Value {
@@ -662,9 +1126,88 @@ func (ls *LogStore) matchesFilterOptions(msg *logs.ContainerMessage, opts Filter
 		}
 	}
 
+	// Field presence - all must be set, to any value
+	for _, name := range opts.HasFields {
+		if msg.Entry.Fields[name] == "" {
+			return false
+		}
+	}
+
+	if opts.MinDurationMS > 0 && msg.Entry.DurationMS <= opts.MinDurationMS {
+		return false
+	}
+
+	// Range filters - all must match, and the field must be present
+	for _, filter := range opts.RangeFilters {
+		value, ok := msg.Entry.Fields[filter.Name]
+		if !ok || !filter.Matches(value) {
+			return false
+		}
+	}
+
 	return true
 }
 
+func matchesPattern(entry *logs.LogEntry, pattern *regexp.Regexp) bool {
+	if pattern.MatchString(entry.Message) || pattern.MatchString(entry.Raw) {
+		return true
+	}
+	for _, value := range entry.Fields {
+		if pattern.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matcher returns a function reporting whether a message matches opts, for filtering
+// messages as they stream rather than from a store
+func (opts FilterOptions) Matcher() func(msg *logs.ContainerMessage) bool {
+	matchers := opts.termMatchers()
+	return func(msg *logs.ContainerMessage) bool {
+		return matchesFilterOptions(msg, opts, matchers)
+	}
+}
+
+// LevelGroup is a level choice offered by the UIs, with every spelling it selects
+type LevelGroup struct {
+	Label  string
+	Levels []string
+}
+
+// LevelGroups are the level choices, matching the web UI's level checkboxes
+var LevelGroups = []LevelGroup{
+	{"TRC", []string{"TRC", "TRACE"}},
+	{"DBG", []string{"DBG", "DEBUG"}},
+	{"INF", []string{"INF", "INFO"}},
+	{"WRN", []string{"WRN", "WARN"}},
+	{"ERR", []string{"ERR", "ERROR", "FATAL"}},
+	{"NONE", []string{"NONE"}},
+}
+
+// ExpandLevels returns every spelling of the given levels, for FilterOptions.Levels.
+// Levels are matched case-insensitively against both labels and spellings, so "warn"
+// selects WRN; levels outside LevelGroups are kept as given.
+func ExpandLevels(levels []string) []string {
+	var expanded []string
+	for _, level := range levels {
+		found := false
+		for _, group := range LevelGroups {
+			if strings.EqualFold(group.Label, level) || slices.ContainsFunc(group.Levels, func(l string) bool {
+				return strings.EqualFold(l, level)
+			}) {
+				expanded = append(expanded, group.Levels...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			expanded = append(expanded, level)
+		}
+	}
+	return expanded
+}
+
 // SetContainerRetention sets retention policy for a specific container
 func (ls *LogStore) SetContainerRetention(containerID string, policy ContainerRetentionPolicy) {
 	ls.mu.Lock()
@@ -715,11 +1258,14 @@ func (ls *LogStore) applyContainerRetention(containerID string) {
 			}
 		}
 	case "time":
-		// Remove logs older than specified seconds, but always keep at least 100
+		// Remove logs older than specified seconds, but always keep the newest MinKeep
 		cutoff := time.Now().Add(-time.Duration(policy.Value) * time.Second)
 
 		count := containerList.Len()
-		minToKeep := 100
+		minToKeep := policy.MinKeep
+		if minToKeep <= 0 {
+			minToKeep = DefaultMinKeep
+		}
 
 		removedCount := 0
 		for e := containerList.Back(); e != nil; {
@@ -750,4 +1296,7 @@ func (ls *LogStore) Clear() {
 	ls.byContainer = make(map[string]*list.List)
 	ls.byField = make(map[string]map[string]*list.List)
 	ls.messageCount = 0
+	ls.levelCounts = make(map[string]int)
+	ls.containerLevelCounts = make(map[string]map[string]int)
+	ls.evicted = make(map[string]int)
 }
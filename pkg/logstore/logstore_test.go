@@ -1,7 +1,11 @@
 package logstore
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"regexp"
+	"slices"
 	"sync"
 	"testing"
 	"time"
@@ -129,6 +133,86 @@ func TestEvictionByAge(t *testing.T) {
 	}
 }
 
+func TestSweeperEvictsQuietStore(t *testing.T) {
+	store := NewLogStore(100, 100*time.Millisecond)
+	store.Add(newTestMessage("container1", "Quiet message", map[string]string{"trace_id": "t1"}))
+	store.Add(newTestMessage("container2", "Quiet message", map[string]string{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		store.RunSweeper(ctx, 20*time.Millisecond)
+		close(done)
+	}()
+
+	// Nothing is added, so only the sweeper can evict once the messages age out
+	time.Sleep(50 * time.Millisecond)
+	if store.Count() != 2 {
+		t.Fatalf("Expected messages kept before they expire, got count %d", store.Count())
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for store.Count() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if store.Count() != 0 {
+		t.Fatalf("Expected the sweeper to evict expired messages, got count %d", store.Count())
+	}
+	if names := store.FieldNames(); len(names) != 0 {
+		t.Errorf("Expected swept messages removed from the field index, got %v", names)
+	}
+	if histogram := store.LevelHistogram(); len(histogram) != 0 {
+		t.Errorf("Expected swept messages removed from the level counts, got %v", histogram)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the sweeper to stop when its context is cancelled")
+	}
+}
+
+func TestSweepInBatchesWithContainerRetention(t *testing.T) {
+	store := NewLogStore(5000, time.Hour)
+
+	total := 2*sweepBatchSize + 5
+	for i := range total {
+		store.Add(newTestMessage("busy", fmt.Sprintf("Message %d", i), map[string]string{}))
+	}
+
+	// A quiet container whose own time policy has expired most of its messages
+	oldTime := time.Now().Add(-time.Minute)
+	for i := range 150 {
+		store.Add(newTestMessageWithTime("quiet", fmt.Sprintf("Old message %d", i), map[string]string{}, oldTime))
+	}
+	store.mu.Lock()
+	store.containerRetention["quiet"] = ContainerRetentionPolicy{Type: "time", Value: 10}
+	store.mu.Unlock()
+
+	if removed := store.Sweep(); removed != 50 {
+		t.Errorf("Expected the time policy to remove all but 100 quiet messages, removed %d", removed)
+	}
+	if count := store.CountByContainer("busy"); count != total {
+		t.Errorf("Expected unexpired messages kept, got %d of %d", count, total)
+	}
+
+	// Age out every busy message, more than one batch's worth. The quiet container's
+	// policy replaces maxAge, so it keeps its minimum.
+	store.mu.Lock()
+	store.maxAge = time.Millisecond
+	store.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+
+	if removed := store.Sweep(); removed != total {
+		t.Errorf("Expected every expired message swept across batches, removed %d of %d", removed, total)
+	}
+	if store.Count() != 100 || store.CountByContainer("quiet") != 100 {
+		t.Errorf("Expected only the quiet container's 100 messages left, got count %d", store.Count())
+	}
+}
+
 func TestSearchByContainer(t *testing.T) {
 	store := NewLogStore(100, 1*time.Hour)
 
@@ -329,6 +413,31 @@ func TestSearchComplex(t *testing.T) {
 	}
 }
 
+func TestSearchByTrace(t *testing.T) {
+	store := NewLogStore(100, 2*time.Hour)
+
+	now := time.Now()
+	store.Add(newTestMessageWithTime("api", "Handling request", map[string]string{"request_id": "req1"}, now))
+	store.Add(newTestMessageWithTime("api", "Traced query", map[string]string{"request_id": "req1", "trace_id": "trace1"}, now))
+	store.Add(newTestMessageWithTime("worker", "Traced job", map[string]string{"trace_id": "trace1"}, now))
+	store.Add(newTestMessageWithTime("api", "Earlier request reusing the ID", map[string]string{"request_id": "req1"}, now.Add(-time.Hour)))
+	store.Add(newTestMessageWithTime("api", "Other request", map[string]string{"request_id": "req2"}, now))
+
+	ids := []FieldFilter{
+		{Name: "request_id", Value: "req1"},
+		{Name: "trace_id", Value: "trace1"},
+	}
+	results := store.SearchByTrace(ids, now.Add(-time.Minute), now.Add(time.Minute), 10)
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 messages within the window, each once, got %d", len(results))
+	}
+
+	results = store.SearchByTrace(ids, now.Add(-time.Minute), now.Add(time.Minute), 2)
+	if len(results) != 2 {
+		t.Errorf("Expected the limit to cap results at 2, got %d", len(results))
+	}
+}
+
 func TestSetMaxMessages(t *testing.T) {
 	store := NewLogStore(10, 1*time.Hour)
 
@@ -718,6 +827,67 @@ func TestContainerRetentionByTime(t *testing.T) {
 	}
 }
 
+func TestContainerRetentionOverridesMaxAge(t *testing.T) {
+	store := NewLogStore(1000, 2*time.Minute)
+
+	// A debug build kept for 30 minutes, and a quieter one for 30 seconds
+	store.SetContainerRetention("debug", ContainerRetentionPolicy{Type: "time", Value: 30 * 60})
+	store.SetContainerRetention("short", ContainerRetentionPolicy{Type: "time", Value: 30})
+
+	now := time.Now()
+	for i := range 150 {
+		ts := now.Add(-10 * time.Minute)
+		if i >= 140 {
+			ts = now.Add(-45 * time.Minute)
+		}
+		store.Add(newTestMessageWithTime("debug", fmt.Sprintf("Debug message %d", i), map[string]string{}, ts))
+	}
+	for i := range 150 {
+		store.Add(newTestMessageWithTime("short", fmt.Sprintf("Short message %d", i), map[string]string{}, now.Add(-time.Minute)))
+	}
+	store.Add(newTestMessageWithTime("api", "Old api message", map[string]string{}, now.Add(-10*time.Minute)))
+	store.Add(newTestMessage("api", "New api message", map[string]string{}))
+
+	// The global 2 minutes evicts api's old message but not debug's 10 minute old ones
+	if count := store.CountByContainer("api"); count != 1 {
+		t.Errorf("Expected the global max age for containers without a policy, got %d api messages", count)
+	}
+	if count := store.CountByContainer("debug"); count != 140 {
+		t.Errorf("Expected debug to keep its messages within 30 minutes, got %d", count)
+	}
+	// A shorter policy evicts sooner than the global max age, down to the minimum kept
+	if count := store.CountByContainer("short"); count != 100 {
+		t.Errorf("Expected short to drop minute old messages down to 100, got %d", count)
+	}
+
+	// The sweeper honors the same ages
+	if removed := store.Sweep(); removed != 0 {
+		t.Errorf("Expected nothing left to sweep, removed %d", removed)
+	}
+	if count := store.CountByContainer("debug"); count != 140 {
+		t.Errorf("Expected a sweep to keep debug's messages, got %d", count)
+	}
+
+	// Without the policy, debug falls back to the global max age
+	store.RemoveContainerRetention("debug")
+	store.Sweep()
+	if count := store.CountByContainer("debug"); count != 0 {
+		t.Errorf("Expected debug's messages swept by the global max age once its policy is removed, got %d", count)
+	}
+}
+
+func TestContainerRetentionByTimeKeepsMaxMessages(t *testing.T) {
+	store := NewLogStore(50, time.Hour)
+	store.SetContainerRetention("debug", ContainerRetentionPolicy{Type: "time", Value: 24 * 60 * 60})
+
+	for i := range 80 {
+		store.Add(newTestMessage("debug", fmt.Sprintf("Message %d", i), map[string]string{}))
+	}
+	if count := store.CountByContainer("debug"); count != 50 {
+		t.Errorf("Expected the per-container message limit to still apply, got %d", count)
+	}
+}
+
 func TestContainerRetentionByCount(t *testing.T) {
 	store := NewLogStore(1000, 1*time.Hour)
 
@@ -761,6 +931,35 @@ func TestContainerRetentionByCount(t *testing.T) {
 	}
 }
 
+func TestTakeEvictions(t *testing.T) {
+	store := NewLogStore(1000, time.Hour)
+	store.SetContainerRetention("api", ContainerRetentionPolicy{Type: "count", Value: 5})
+
+	base := time.Now().Add(-time.Minute)
+	for i := range 8 {
+		msg := newTestMessage("api", fmt.Sprintf("Message %d", i), nil)
+		msg.Timestamp = base.Add(time.Duration(i) * time.Second)
+		store.Add(msg)
+	}
+	store.Add(newTestMessage("worker", "kept", nil))
+
+	evictions := store.TakeEvictions()
+	if len(evictions) != 1 {
+		t.Fatalf("Expected evictions from one container, got %+v", evictions)
+	}
+	if evictions[0].ContainerID != "api" || evictions[0].Count != 3 {
+		t.Errorf("Expected 3 messages evicted from api, got %+v", evictions[0])
+	}
+	if want := base.Add(3 * time.Second); !evictions[0].Oldest.Equal(want) {
+		t.Errorf("Expected the oldest message left to be Message 3 at %v, got %v", want, evictions[0].Oldest)
+	}
+
+	// Each call covers the evictions since the previous one
+	if evictions := store.TakeEvictions(); evictions != nil {
+		t.Errorf("Expected no evictions after they were taken, got %+v", evictions)
+	}
+}
+
 func TestContainerRetentionMinKeep(t *testing.T) {
 	store := NewLogStore(1000, 1*time.Hour)
 
@@ -785,3 +984,432 @@ func TestContainerRetentionMinKeep(t *testing.T) {
 		t.Errorf("Expected 100 messages kept (minimum), got %d", count)
 	}
 }
+
+func TestContainerRetentionCustomMinKeep(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		minKeep  int
+		expected int // Kept once the policy is set
+		afterAdd int // Kept once another old log arrives
+	}{
+		{"default floor", 0, DefaultMinKeep, DefaultMinKeep},
+		{"last few lines", 5, 5, 5},
+		{"higher floor", 140, 140, 140},
+		{"floor above the logs held", 200, 150, 151},
+	}
+	for _, tt := range tests {
+		store := NewLogStore(1000, 1*time.Hour)
+		for i := range 150 {
+			store.Add(newTestMessageWithTime("test-container", fmt.Sprintf("Old message %d", i), map[string]string{}, now.Add(-20*time.Second)))
+		}
+
+		store.SetContainerRetention("test-container", ContainerRetentionPolicy{Type: "time", Value: 1, MinKeep: tt.minKeep})
+		if count := store.CountByContainer("test-container"); count != tt.expected {
+			t.Errorf("%s: expected %d messages kept, got %d", tt.name, tt.expected, count)
+		}
+
+		// The newest are the ones kept
+		if recent := store.SearchByContainer("test-container", 1); len(recent) != 1 || recent[0].Entry.Message != "Old message 149" {
+			t.Errorf("%s: expected the newest message kept, got %v", tt.name, recent)
+		}
+
+		// New old-stamped logs are trimmed to the same floor
+		store.Add(newTestMessageWithTime("test-container", "Another old message", map[string]string{}, now.Add(-20*time.Second)))
+		if count := store.CountByContainer("test-container"); count != tt.afterAdd {
+			t.Errorf("%s: expected %d messages kept as logs arrive, got %d", tt.name, tt.afterAdd, count)
+		}
+	}
+}
+
+func TestFieldNamesAndValues(t *testing.T) {
+	store := NewLogStore(100, 1*time.Hour)
+
+	store.Add(newTestMessage("c1", "msg1", map[string]string{"trace_id": "t1", "user": "alice"}))
+	store.Add(newTestMessage("c1", "msg2", map[string]string{"trace_id": "t1", "status": "200"}))
+	store.Add(newTestMessage("c2", "msg3", map[string]string{"trace_id": "t2", "user": "bob"}))
+	store.Add(newTestMessage("c2", "msg4", map[string]string{"trace_id": "t1"}))
+
+	names := store.FieldNames()
+	expectedNames := []string{"trace_id", "user", "status"}
+	if fmt.Sprint(names) != fmt.Sprint(expectedNames) {
+		t.Errorf("Expected field names %v, got %v", expectedNames, names)
+	}
+
+	values := store.FieldValues("trace_id", 10)
+	expectedValues := []string{"t1", "t2"}
+	if fmt.Sprint(values) != fmt.Sprint(expectedValues) {
+		t.Errorf("Expected trace_id values %v, got %v", expectedValues, values)
+	}
+
+	// Ties are broken alphabetically and the limit caps cardinality
+	values = store.FieldValues("user", 1)
+	if len(values) != 1 || values[0] != "alice" {
+		t.Errorf("Expected [alice] with limit 1, got %v", values)
+	}
+
+	if values := store.FieldValues("missing", 10); len(values) != 0 {
+		t.Errorf("Expected no values for unknown field, got %v", values)
+	}
+
+	// Cleared messages no longer contribute fields
+	store.Clear()
+	if names := store.FieldNames(); len(names) != 0 {
+		t.Errorf("Expected no field names after clear, got %v", names)
+	}
+}
+
+func TestFieldHistogram(t *testing.T) {
+	store := NewLogStore(100, 1*time.Hour)
+
+	for _, status := range []string{"500", "500", "500", "404", "404", "502", "503"} {
+		store.Add(newTestMessage("api", "request", map[string]string{"status": status}))
+	}
+	store.Add(newTestMessage("worker", "request", map[string]string{"status": "500"}))
+	store.Add(newTestMessage("api", "no status", map[string]string{"status": ""}))
+	store.Add(newTestMessage("api", "unrelated", map[string]string{}))
+
+	histogram := store.FieldHistogram("status", FilterOptions{}, 0)
+	expected := []ValueCount{{"500", 4, false}, {"404", 2, false}, {"502", 1, false}, {"503", 1, false}}
+	if !slices.Equal(histogram, expected) {
+		t.Errorf("Expected every value most frequent first, got %v", histogram)
+	}
+
+	// Values beyond topN are summed into one bucket
+	histogram = store.FieldHistogram("status", FilterOptions{ContainerIDs: []string{"api"}}, 2)
+	expected = []ValueCount{{"500", 3, false}, {"404", 2, false}, {HistogramOther, 2, true}}
+	if !slices.Equal(histogram, expected) {
+		t.Errorf("Expected the top 2 values for api and an other bucket, got %v", histogram)
+	}
+
+	histogram = store.FieldHistogram("status", FilterOptions{SearchTerms: []string{"nothing matches"}}, 2)
+	if histogram == nil || len(histogram) != 0 {
+		t.Errorf("Expected an empty histogram when nothing matches, got %v", histogram)
+	}
+	if histogram := store.FieldHistogram("missing", FilterOptions{}, 10); len(histogram) != 0 {
+		t.Errorf("Expected no values for unknown field, got %v", histogram)
+	}
+}
+
+func TestFilterCaseSensitive(t *testing.T) {
+	store := NewLogStore(100, 1*time.Hour)
+
+	store.Add(&logs.ContainerMessage{
+		Timestamp:   time.Now(),
+		ContainerID: "c1",
+		Entry:       &logs.LogEntry{Message: "Error connecting", Raw: "Error connecting"},
+	})
+	store.Add(&logs.ContainerMessage{
+		Timestamp:   time.Now(),
+		ContainerID: "c1",
+		Entry:       &logs.LogEntry{Message: "request done", Raw: "ts=1 request done reason=error"},
+	})
+	store.Add(&logs.ContainerMessage{
+		Timestamp:   time.Now(),
+		ContainerID: "c1",
+		Entry:       &logs.LogEntry{Message: "request done", Fields: map[string]string{"status": "Error"}},
+	})
+
+	tests := []struct {
+		term          string
+		caseSensitive bool
+		expected      int
+	}{
+		{"error", false, 3},
+		{"Error", false, 3},
+		{"Error", true, 2}, // message and field value
+		{"error", true, 1}, // raw only
+		{"ERROR", true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%v", tt.term, tt.caseSensitive), func(t *testing.T) {
+			results := store.Filter(FilterOptions{
+				SearchTerms:   []string{tt.term},
+				CaseSensitive: tt.caseSensitive,
+			}, 100)
+			if len(results) != tt.expected {
+				t.Errorf("Expected %d results, got %d", tt.expected, len(results))
+			}
+		})
+	}
+}
+
+func TestFilterWholeWord(t *testing.T) {
+	store := NewLogStore(100, 1*time.Hour)
+
+	store.Add(newTestMessage("c1", "invalid width", nil))
+	store.Add(newTestMessage("c1", "missing request id", nil))
+	store.Add(newTestMessage("c1", "lookup done", map[string]string{"key": "ID"}))
+	store.Add(newTestMessage("c1", "user_id=5 done", nil))
+
+	tests := []struct {
+		name     string
+		opts     FilterOptions
+		expected int
+	}{
+		{"substring", FilterOptions{SearchTerms: []string{"id"}}, 4},
+		{"whole word", FilterOptions{SearchTerms: []string{"id"}, WholeWord: true}, 2},
+		{"whole word case sensitive", FilterOptions{SearchTerms: []string{"id"}, WholeWord: true, CaseSensitive: true}, 1},
+		{"whole word AND", FilterOptions{SearchTerms: []string{"id", "request"}, WholeWord: true}, 1},
+		{"whole word AND no match", FilterOptions{SearchTerms: []string{"id", "width"}, WholeWord: true}, 0},
+		{"regex characters are literal", FilterOptions{SearchTerms: []string{"id.*"}, WholeWord: true}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := store.Filter(tt.opts, 100)
+			if len(results) != tt.expected {
+				t.Errorf("Expected %d results, got %d", tt.expected, len(results))
+			}
+		})
+	}
+
+	match := NewTermMatcher("id", false, true)
+	if match("invalid") {
+		t.Error("Expected whole-word 'id' not to match 'invalid'")
+	}
+	if !match("request id") {
+		t.Error("Expected whole-word 'id' to match 'request id'")
+	}
+}
+
+func TestFilterByFieldRange(t *testing.T) {
+	store := NewLogStore(1000, 1*time.Hour)
+
+	store.Add(newTestMessage("db", "fast query", map[string]string{"duration": "4.5", "db.rows": "1"}))
+	store.Add(newTestMessage("db", "slow query", map[string]string{"duration": "250", "db.rows": "1200"}))
+	store.Add(newTestMessage("db", "timed out", map[string]string{"duration": "timeout"}))
+	store.Add(newTestMessage("db", "no duration", map[string]string{}))
+
+	tests := []struct {
+		filters  []FieldRangeFilter
+		expected []string
+	}{
+		{[]FieldRangeFilter{{Name: "duration", Op: RangeOpGreater, Value: "100"}}, []string{"slow query"}},
+		{[]FieldRangeFilter{{Name: "duration", Op: RangeOpLessEqual, Value: "4.5"}}, []string{"fast query"}},
+		{[]FieldRangeFilter{{Name: "duration", Op: RangeOpGreaterEqual, Value: "0"}, {Name: "db.rows", Op: RangeOpLess, Value: "10"}}, []string{"fast query"}},
+		{[]FieldRangeFilter{{Name: "duration", Op: RangeOpEqual, Value: "250.0"}}, []string{"slow query"}},
+		// Non-numeric values only match an exact string comparison
+		{[]FieldRangeFilter{{Name: "duration", Op: RangeOpEqual, Value: "timeout"}}, []string{"timed out"}},
+		{[]FieldRangeFilter{{Name: "duration", Op: RangeOpGreater, Value: "timeout"}}, nil},
+	}
+
+	for _, tt := range tests {
+		results := store.Filter(FilterOptions{RangeFilters: tt.filters}, 100)
+		var messages []string
+		for _, msg := range results {
+			messages = append(messages, msg.Entry.Message)
+		}
+		if !slices.Equal(messages, tt.expected) {
+			t.Errorf("Filter %v: expected %v, got %v", tt.filters, tt.expected, messages)
+		}
+	}
+}
+
+func TestFilterExcludedContainers(t *testing.T) {
+	store := NewLogStore(1000, 1*time.Hour)
+
+	store.Add(newTestMessage("api", "request", map[string]string{"request_id": "r1"}))
+	store.Add(newTestMessage("sidecar", "proxy", map[string]string{"request_id": "r1"}))
+	store.Add(newTestMessage("worker", "job", map[string]string{}))
+
+	tests := []struct {
+		name     string
+		opts     FilterOptions
+		expected []string
+	}{
+		{"exclude only", FilterOptions{ExcludedContainerIDs: []string{"sidecar"}}, []string{"job", "request"}},
+		{"include and exclude overlap", FilterOptions{ContainerIDs: []string{"api", "sidecar"}, ExcludedContainerIDs: []string{"sidecar"}}, []string{"request"}},
+		{"exclude the only inclusion", FilterOptions{ContainerIDs: []string{"sidecar"}, ExcludedContainerIDs: []string{"sidecar"}}, nil},
+		{"with field filter", FilterOptions{FieldFilters: []FieldFilter{{Name: "request_id", Value: "r1"}}, ExcludedContainerIDs: []string{"sidecar"}}, []string{"request"}},
+	}
+
+	for _, tt := range tests {
+		var messages []string
+		for _, msg := range store.Filter(tt.opts, 100) {
+			messages = append(messages, msg.Entry.Message)
+		}
+		if !slices.Equal(messages, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, messages)
+		}
+	}
+}
+
+func TestFilterHasFields(t *testing.T) {
+	store := NewLogStore(1000, 1*time.Hour)
+
+	store.Add(newTestMessage("api", "traced span", map[string]string{"trace_id": "a", "span_id": "1"}))
+	store.Add(newTestMessage("api", "traced", map[string]string{"trace_id": "b"}))
+	store.Add(newTestMessage("api", "empty trace", map[string]string{"trace_id": ""}))
+	store.Add(newTestMessage("api", "uninstrumented", map[string]string{}))
+
+	tests := []struct {
+		name     string
+		opts     FilterOptions
+		expected []string
+	}{
+		{"present", FilterOptions{HasFields: []string{"trace_id"}}, []string{"traced", "traced span"}},
+		{"all present", FilterOptions{HasFields: []string{"trace_id", "span_id"}}, []string{"traced span"}},
+		{"never present", FilterOptions{HasFields: []string{"user_id"}}, nil},
+		{"with value", FilterOptions{HasFields: []string{"span_id"}, FieldFilters: []FieldFilter{{Name: "trace_id", Value: "a"}}}, []string{"traced span"}},
+		{"with other value", FilterOptions{HasFields: []string{"span_id"}, FieldFilters: []FieldFilter{{Name: "trace_id", Value: "b"}}}, nil},
+		{"with container", FilterOptions{HasFields: []string{"trace_id"}, ContainerIDs: []string{"api"}}, []string{"traced", "traced span"}},
+	}
+
+	for _, tt := range tests {
+		results := store.Filter(tt.opts, 100)
+		var messages []string
+		for _, msg := range results {
+			messages = append(messages, msg.Entry.Message)
+		}
+		if !slices.Equal(messages, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, messages)
+		}
+	}
+}
+
+func TestParseFieldRangeFilter(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected FieldRangeFilter
+		wantErr  bool
+	}{
+		{"duration>100", FieldRangeFilter{Name: "duration", Op: RangeOpGreater, Value: "100"}, false},
+		{"db.rows >= 5", FieldRangeFilter{Name: "db.rows", Op: RangeOpGreaterEqual, Value: "5"}, false},
+		{"duration<=1.5", FieldRangeFilter{Name: "duration", Op: RangeOpLessEqual, Value: "1.5"}, false},
+		{"status=200", FieldRangeFilter{Name: "status", Op: RangeOpEqual, Value: "200"}, false},
+		{"duration", FieldRangeFilter{}, true},
+		{">100", FieldRangeFilter{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFieldRangeFilter(tt.expr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFieldRangeFilter(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ParseFieldRangeFilter(%q) = %+v, expected %+v", tt.expr, got, tt.expected)
+		}
+	}
+}
+
+// recountLevels computes the level histogram by walking every message, for checking the
+// running counters against
+func recountLevels(store *LogStore, containerID string) map[string]int {
+	counts := make(map[string]int)
+	for _, msg := range store.GetRecent(store.Count()) {
+		if containerID == "" || msg.ContainerID == containerID {
+			counts[normalizeLevel(msg.Entry.Level)]++
+		}
+	}
+	return counts
+}
+
+func TestLevelCounters(t *testing.T) {
+	store := NewLogStore(6, 1*time.Hour)
+	levels := []string{"INFO", "info", "ERROR", "", "WARN"}
+
+	add := func(containerID string, n int, ts time.Time) {
+		for i := range n {
+			msg := newTestMessageWithTime(containerID, fmt.Sprintf("Message %d", i), map[string]string{}, ts)
+			msg.Entry.Level = levels[i%len(levels)]
+			store.Add(msg)
+		}
+	}
+	check := func(stage string) {
+		t.Helper()
+		if got, want := store.LevelHistogram(), recountLevels(store, ""); !maps.Equal(got, want) {
+			t.Errorf("%s: expected histogram %v, got %v", stage, want, got)
+		}
+		for _, containerID := range []string{"api", "worker"} {
+			if got, want := store.ContainerLevelHistogram(containerID), recountLevels(store, containerID); !maps.Equal(got, want) {
+				t.Errorf("%s: expected %s histogram %v, got %v", stage, containerID, want, got)
+			}
+		}
+	}
+
+	add("api", 5, time.Now())
+	add("worker", 3, time.Now())
+	check("after add")
+	if got := store.CountByLevel("info"); got != 4 {
+		t.Errorf("Expected INFO and info counted together as 4, got %d", got)
+	}
+	if got := store.CountByLevel(NoLevel); got != 1 {
+		t.Errorf("Expected 1 message without a level, got %d", got)
+	}
+
+	// Exceeding the per-container limit evicts the oldest api messages
+	add("api", 4, time.Now())
+	if store.CountByContainer("api") != 6 {
+		t.Fatalf("Expected api capped at 6 messages, got %d", store.CountByContainer("api"))
+	}
+	check("after eviction")
+
+	store.SetContainerRetention("api", ContainerRetentionPolicy{Type: "count", Value: 2})
+	check("after retention")
+
+	// Expired messages are evicted on the next add
+	store.maxAge = 100 * time.Millisecond
+	add("worker", 1, time.Now().Add(-time.Second))
+	check("after expiry")
+
+	store.Clear()
+	if len(store.LevelHistogram()) != 0 || store.CountByLevel("INFO") != 0 || len(store.ContainerLevelHistogram("api")) != 0 {
+		t.Errorf("Expected empty counters after Clear, got %v", store.LevelHistogram())
+	}
+}
+
+func TestFilterPattern(t *testing.T) {
+	store := NewLogStore(100, 1*time.Hour)
+
+	store.Add(newTestMessage("c1", "user 42 signed in", nil))
+	store.Add(newTestMessage("c1", "request done", map[string]string{"trace_id": "abc-123"}))
+	store.Add(newTestMessage("c1", "request failed", nil))
+
+	tests := []struct {
+		pattern  string
+		expected int
+	}{
+		{`user \d+`, 1},
+		{`^abc-\d+$`, 1},
+		{`request (done|failed)`, 2},
+		{`nothing`, 0},
+	}
+
+	for _, tt := range tests {
+		opts := FilterOptions{Pattern: regexp.MustCompile(tt.pattern)}
+		if results := store.Filter(opts, 100); len(results) != tt.expected {
+			t.Errorf("Pattern %q: expected %d results, got %d", tt.pattern, tt.expected, len(results))
+		}
+	}
+}
+
+func TestFilterOptionsMatcher(t *testing.T) {
+	match := FilterOptions{
+		Levels:      ExpandLevels([]string{"warn"}),
+		SearchTerms: []string{"slow"},
+	}.Matcher()
+
+	warning := newTestMessage("c1", "slow query", nil)
+	warning.Entry.Level = "WRN"
+	info := newTestMessage("c1", "slow query", nil)
+	info.Entry.Level = "INF"
+
+	if !match(warning) {
+		t.Error("Expected WRN entry to match a warn level filter")
+	}
+	if match(info) {
+		t.Error("Expected INF entry not to match a warn level filter")
+	}
+}
+
+func TestExpandLevels(t *testing.T) {
+	got := ExpandLevels([]string{"err", "INFO", "custom"})
+	expected := []string{"ERR", "ERROR", "FATAL", "INF", "INFO", "custom"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("ExpandLevels = %v, expected %v", got, expected)
+	}
+}
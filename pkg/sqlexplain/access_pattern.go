@@ -0,0 +1,127 @@
+package sqlexplain
+
+import (
+	"regexp"
+	"strings"
+)
+
+// AccessPattern classifies how a query reads or writes data
+type AccessPattern string
+
+const (
+	AccessPointLookup AccessPattern = "point_lookup" // Single row by primary key
+	AccessRangeScan   AccessPattern = "range_scan"   // Rows matching a filter or range
+	AccessFullScan    AccessPattern = "full_scan"    // Every row of a table
+	AccessAggregate   AccessPattern = "aggregate"    // GROUP BY or aggregate functions
+	AccessJoinHeavy   AccessPattern = "join_heavy"   // Two or more joins
+	AccessWrite       AccessPattern = "write"        // INSERT, UPDATE or DELETE
+	AccessOther       AccessPattern = "other"
+)
+
+// joinHeavyThreshold is the number of joins at which a query counts as join heavy
+const joinHeavyThreshold = 2
+
+var (
+	stringLiteralPattern  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	aggregatePattern      = regexp.MustCompile(`(?i)\bGROUP\s+BY\b|\b(?:COUNT|SUM|AVG|MIN|MAX|ARRAY_AGG|STRING_AGG|JSON_AGG)\s*\(`)
+	joinPattern           = regexp.MustCompile(`(?i)\bJOIN\b`)
+	wherePattern          = regexp.MustCompile(`(?is)\bWHERE\b(.*)`)
+	rangePattern          = regexp.MustCompile(`(?i)\bBETWEEN\b|[<>]=?|\bIN\s*\(|\bLIKE\b|\bOR\b|!=`)
+	pkEqualityPattern     = regexp.MustCompile(`(?i)(?:^|\bAND|\()\s*(?:"?\w+"?\.)?"?id"?\s*=\s*(?:\$\d+|\?|\d+|'')`)
+	writePattern          = regexp.MustCompile(`(?i)^\s*(?:INSERT|UPDATE|DELETE|MERGE)\b`)
+	selectPattern         = regexp.MustCompile(`(?i)^\s*(?:SELECT|WITH)\b`)
+	trailingClausePattern = regexp.MustCompile(`(?is)\b(?:ORDER\s+BY|LIMIT|OFFSET|FOR\s+UPDATE|RETURNING)\b.*$`)
+)
+
+// ClassifyAccessPattern classifies a query from its shape and, when available, its
+// JSON explain plan. The plan takes precedence for reads since it shows what the
+// database actually did.
+func ClassifyAccessPattern(query, explainPlan string) AccessPattern {
+	if writePattern.MatchString(query) {
+		return AccessWrite
+	}
+
+	if plan := parseExplainPlan(explainPlan); plan != nil {
+		if pattern := classifyPlan(plan); pattern != AccessOther {
+			return pattern
+		}
+	}
+
+	return classifyShape(query)
+}
+
+// classifyShape classifies a query from its SQL text alone
+func classifyShape(query string) AccessPattern {
+	if !selectPattern.MatchString(query) {
+		return AccessOther
+	}
+
+	// Literals could contain anything, so leave them out of the keyword checks
+	query = stringLiteralPattern.ReplaceAllString(query, "''")
+
+	if aggregatePattern.MatchString(query) {
+		return AccessAggregate
+	}
+	if len(joinPattern.FindAllString(query, -1)) >= joinHeavyThreshold {
+		return AccessJoinHeavy
+	}
+
+	match := wherePattern.FindStringSubmatch(query)
+	if match == nil {
+		return AccessFullScan
+	}
+	where := trailingClausePattern.ReplaceAllString(match[1], "")
+	// A primary key equality alongside other equalities, such as a soft delete check,
+	// still returns at most one row
+	if !rangePattern.MatchString(where) && pkEqualityPattern.MatchString(strings.TrimSpace(where)) {
+		return AccessPointLookup
+	}
+	return AccessRangeScan
+}
+
+// classifyPlan classifies a read from the node types in its explain plan
+func classifyPlan(plan *ParsedExplainPlan) AccessPattern {
+	var aggregate, pkLookup, seqScan, filtered, otherScan bool
+	joins := 0
+
+	var walk func(node *ParsedExplainPlan)
+	walk = func(node *ParsedExplainPlan) {
+		switch node.NodeType {
+		case "Aggregate", "HashAggregate", "GroupAggregate", "WindowAgg":
+			aggregate = true
+		case "Nested Loop", "Hash Join", "Merge Join":
+			joins++
+		case "Index Scan", "Index Only Scan":
+			if strings.HasSuffix(node.IndexName, "_pkey") && node.PlanRows <= 1 {
+				pkLookup = true
+			} else {
+				otherScan = true
+			}
+		case "Bitmap Heap Scan":
+			otherScan = true
+		case "Seq Scan":
+			seqScan = true
+			if extractFilterCondition(node) != "" {
+				filtered = true
+			}
+		}
+		for i := range node.Plans {
+			walk(&node.Plans[i])
+		}
+	}
+	walk(plan)
+
+	switch {
+	case aggregate:
+		return AccessAggregate
+	case joins >= joinHeavyThreshold:
+		return AccessJoinHeavy
+	case otherScan || (seqScan && filtered):
+		return AccessRangeScan
+	case seqScan:
+		return AccessFullScan
+	case pkLookup:
+		return AccessPointLookup
+	}
+	return AccessOther
+}
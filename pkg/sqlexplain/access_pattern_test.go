@@ -0,0 +1,52 @@
+package sqlexplain
+
+import "testing"
+
+func TestClassifyAccessPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		plan     string
+		expected AccessPattern
+	}{
+		{"point lookup", `SELECT * FROM users WHERE id = $1`, "", AccessPointLookup},
+		{"point lookup with soft delete", `SELECT * FROM "users" WHERE "users"."id" = $1 AND "users"."deleted_at" IS NULL ORDER BY "users"."id" LIMIT 1`, "", AccessPointLookup},
+		{"between", `SELECT * FROM orders WHERE created_at BETWEEN $1 AND $2`, "", AccessRangeScan},
+		{"non-key equality", `SELECT * FROM orders WHERE user_id = $1`, "", AccessRangeScan},
+		{"id list", `SELECT * FROM users WHERE id IN ($1, $2)`, "", AccessRangeScan},
+		{"group by", `SELECT status, COUNT(*) FROM orders GROUP BY status`, "", AccessAggregate},
+		{"count", `SELECT count(*) FROM orders WHERE id = $1`, "", AccessAggregate},
+		{"joins", `SELECT * FROM orders o JOIN users u ON u.id = o.user_id LEFT JOIN items i ON i.order_id = o.id WHERE o.id = $1`, "", AccessJoinHeavy},
+		{"no where", `SELECT * FROM settings`, "", AccessFullScan},
+		{"literal keywords", `SELECT * FROM users WHERE id = 'GROUP BY x'`, "", AccessPointLookup},
+		{"insert", `INSERT INTO users (name) VALUES ($1)`, "", AccessWrite},
+		{"update by id", `UPDATE users SET name = $1 WHERE id = $2`, "", AccessWrite},
+		{"other", `BEGIN`, "", AccessOther},
+		{
+			"plan pk lookup",
+			`SELECT * FROM users WHERE email = $1`,
+			`[{"Plan": {"Node Type": "Index Scan", "Index Name": "users_pkey", "Relation Name": "users", "Plan Rows": 1}}]`,
+			AccessPointLookup,
+		},
+		{
+			"plan overrides shape",
+			`SELECT * FROM users WHERE id = $1`,
+			`[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "users", "Filter": "(id = 1)", "Plan Rows": 1}}]`,
+			AccessRangeScan,
+		},
+		{
+			"plan aggregate",
+			`SELECT * FROM order_totals`,
+			`[{"Plan": {"Node Type": "HashAggregate", "Plans": [{"Node Type": "Seq Scan", "Relation Name": "orders"}]}}]`,
+			AccessAggregate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyAccessPattern(tt.query, tt.plan); got != tt.expected {
+				t.Errorf("ClassifyAccessPattern(%q) = %s, expected %s", tt.query, got, tt.expected)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package sqlexplain
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -9,16 +10,37 @@ import (
 
 // QueryWithPlan represents a SQL query with its explain plan
 type QueryWithPlan struct {
-	Query           string
-	NormalizedQuery string
-	OperationName   string // GraphQL operation or other grouping identifier
-	Timestamp       int64  // Unix timestamp for ordering
-	DurationMS      float64
-	QueriedTable    string
-	Operation       string
-	Rows            int
-	ExplainPlan     string // JSON string of the explain plan
-	Variables       string
+	Query           string  `json:"query"`
+	NormalizedQuery string  `json:"normalizedQuery"`
+	OperationName   string  `json:"operationName,omitempty"` // GraphQL operation or other grouping identifier
+	Timestamp       int64   `json:"timestamp"`               // Unix timestamp for ordering
+	DurationMS      float64 `json:"durationMs"`
+	QueriedTable    string  `json:"tableName,omitempty"`
+	Operation       string  `json:"operation,omitempty"`
+	Rows            int     `json:"rows"`
+	ExplainPlan     string  `json:"explainPlan,omitempty"` // JSON string of the explain plan
+	Variables       string  `json:"variables,omitempty"`
+}
+
+// UnmarshalJSON also reads the table from QueriedTable, the key used before
+// QueryWithPlan had JSON tags, so older comparison reports keep their tables. The other
+// Go field names already match their tags case-insensitively.
+//
+// Deprecated: the QueriedTable key; write tableName.
+func (q *QueryWithPlan) UnmarshalJSON(data []byte) error {
+	type plain QueryWithPlan
+	var decoded struct {
+		plain
+		LegacyTable string `json:"QueriedTable"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*q = QueryWithPlan(decoded.plain)
+	if q.QueriedTable == "" {
+		q.QueriedTable = decoded.LegacyTable
+	}
+	return nil
 }
 
 // ExplainPlanComparison represents comparison between two query sets
@@ -36,6 +58,7 @@ type QueryPlanComparison struct {
 	NormalizedQuery string             `json:"normalizedQuery"`
 	ExampleQuery    string             `json:"exampleQuery"`
 	OperationName   string             `json:"operationName"`
+	QueriedTable    string             `json:"tableName,omitempty"`
 	Set1Count       int                `json:"set1Count"`
 	Set2Count       int                `json:"set2Count"`
 	Set1AvgDuration float64            `json:"set1AvgDuration"`
@@ -180,6 +203,7 @@ func compareQueryGroup(normalized string, set1, set2 []QueryWithPlan) QueryPlanC
 		NormalizedQuery: normalized,
 		ExampleQuery:    set1[0].Query,
 		OperationName:   set1[0].OperationName,
+		QueriedTable:    cmp.Or(set1[0].QueriedTable, set2[0].QueriedTable),
 		Set1Count:       len(set1),
 		Set2Count:       len(set2),
 	}
@@ -119,6 +119,9 @@ func TestCompareQuerySets(t *testing.T) {
 		if common.Plan1 == nil || common.Plan2 == nil {
 			t.Error("Expected both plans to be parsed")
 		}
+		if common.QueriedTable != "users" {
+			t.Errorf("Expected the common query's table to be users, got %q", common.QueriedTable)
+		}
 		if common.Plan1 != nil && common.Plan1.NodeType != "Seq Scan" {
 			t.Errorf("Expected plan1 NodeType = 'Seq Scan', got '%s'", common.Plan1.NodeType)
 		}
@@ -401,3 +404,30 @@ func TestExplainPlanComparisonJSON(t *testing.T) {
 		t.Error("JSON marshaling/unmarshaling changed TotalQueriesSet1")
 	}
 }
+
+func TestQueryWithPlanTableName(t *testing.T) {
+	q := QueryWithPlan{Query: "SELECT * FROM users", NormalizedQuery: "SELECT * FROM users", DurationMS: 4, QueriedTable: "users"}
+
+	data, err := json.Marshal(q)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	var decoded QueryWithPlan
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded != q {
+		t.Errorf("Expected %+v to survive a round trip through %s, got %+v (%v)", q, data, decoded, err)
+	}
+
+	legacy := `{"Query": "SELECT * FROM users", "NormalizedQuery": "SELECT * FROM users", "DurationMS": 4, "QueriedTable": "users"}`
+	decoded = QueryWithPlan{}
+	if err := json.Unmarshal([]byte(legacy), &decoded); err != nil || decoded != q {
+		t.Errorf("Expected the Go field names to decode to %+v, got %+v (%v)", q, decoded, err)
+	}
+
+	// A plan without a relation name, such as one logged by a service, falls back to
+	// the caller's table
+	q.ExplainPlan = `[{"Plan": {"Node Type": "Seq Scan", "Total Cost": 10, "Plan Rows": 100}}]`
+	analysis := AnalyzeIndexUsage([]QueryWithPlan{q})
+	if len(analysis.SequentialScans) != 1 || analysis.SequentialScans[0].QueriedTable != "users" {
+		t.Errorf("Expected the sequential scan on users, got %+v", analysis.SequentialScans)
+	}
+}
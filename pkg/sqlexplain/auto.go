@@ -0,0 +1,43 @@
+package sqlexplain
+
+import "sync"
+
+// DefaultAutoExplainMinMS is the duration above which a single query is explained
+// automatically when a trace is saved
+const DefaultAutoExplainMinMS = 2
+
+// DefaultAutoExplainMinTotalMS is the combined duration of every run of a normalized
+// query above which it is explained automatically, so fast queries repeated many times,
+// such as an N+1, get plans too
+const DefaultAutoExplainMinTotalMS = 50
+
+var (
+	autoExplainMinMS      float64 = DefaultAutoExplainMinMS
+	autoExplainMinTotalMS float64 = DefaultAutoExplainMinTotalMS
+	autoExplainMutex      sync.RWMutex
+)
+
+// SetAutoExplainThresholds sets the single-query and combined durations, in milliseconds,
+// above which queries are explained automatically. Zero disables the combined check; a
+// negative value restores the default.
+func SetAutoExplainThresholds(minMS, minTotalMS float64) {
+	if minMS < 0 {
+		minMS = DefaultAutoExplainMinMS
+	}
+	if minTotalMS < 0 {
+		minTotalMS = DefaultAutoExplainMinTotalMS
+	}
+
+	autoExplainMutex.Lock()
+	defer autoExplainMutex.Unlock()
+	autoExplainMinMS = minMS
+	autoExplainMinTotalMS = minTotalMS
+}
+
+// AutoExplainThresholds returns the single-query and combined durations above which
+// queries are explained automatically
+func AutoExplainThresholds() (minMS, minTotalMS float64) {
+	autoExplainMutex.RLock()
+	defer autoExplainMutex.RUnlock()
+	return autoExplainMinMS, autoExplainMinTotalMS
+}
@@ -0,0 +1,229 @@
+package sqlexplain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Recommendation types
+const (
+	RecommendationSeqScan         = "seq_scan"          // Index the columns a sequential scan filters on
+	RecommendationForeignKeyIndex = "foreign_key_index" // Index a foreign key column used as a join key
+)
+
+var (
+	joinCastPattern     = regexp.MustCompile(`::[a-zA-Z_ ]+(\[\])?`)
+	joinEqualityPattern = regexp.MustCompile(`(\w+)\.(\w+)\s*=\s*(\w+)\.(\w+)`)
+)
+
+// ForeignKeyIssue is a foreign key column joined on while its table is read by a
+// sequential scan, so every join probe scans the table instead of using an index.
+// Without the index, deletes from the referenced table also scan it to check the
+// constraint, holding locks for longer.
+type ForeignKeyIssue struct {
+	QueriedTable    string  `json:"tableName"`
+	Column          string  `json:"column"`
+	ReferencedTable string  `json:"referencedTable"`
+	ReferencedKey   string  `json:"referencedKey"`
+	JoinType        string  `json:"joinType"` // Plan node joining the tables, e.g. "Nested Loop"
+	Cost            float64 `json:"cost"`     // Highest scan cost of one query, over every time the join ran it
+	DurationMS      float64 `json:"durationMs"`
+	Occurrences     int     `json:"occurrences"`
+	Query           string  `json:"query"`
+}
+
+// joinScan is a table read under a join node
+type joinScan struct {
+	relation string
+	node     *ParsedExplainPlan
+	loops    float64 // Times the join ran the side the scan is on
+}
+
+// analyzeForeignKeyJoins records the join keys in plan that look like foreign keys and
+// are read by sequential scan. Each issue is counted once per query.
+func analyzeForeignKeyJoins(plan *ParsedExplainPlan, query QueryWithPlan, issues map[string]*ForeignKeyIssue) {
+	found := make(map[string]*ForeignKeyIssue)
+	collectForeignKeyJoins(plan, query, found)
+
+	for key, issue := range found {
+		if existing, ok := issues[key]; ok {
+			existing.Occurrences++
+			existing.DurationMS += query.DurationMS
+			existing.Cost = max(existing.Cost, issue.Cost)
+			continue
+		}
+		issues[key] = issue
+	}
+}
+
+func collectForeignKeyJoins(plan *ParsedExplainPlan, query QueryWithPlan, found map[string]*ForeignKeyIssue) {
+	switch plan.NodeType {
+	case "Nested Loop", "Hash Join", "Merge Join":
+		scans := make(map[string]joinScan)
+		for i := range plan.Plans {
+			collectJoinScans(&plan.Plans[i], joinSideLoops(plan, i), scans)
+		}
+
+		for _, cond := range joinConditions(plan) {
+			for _, m := range joinEqualityPattern.FindAllStringSubmatch(cond, -1) {
+				left, right := [2]string{m[1], m[2]}, [2]string{m[3], m[4]}
+				addForeignKeyIssue(plan, query, scans, left, right, found)
+				addForeignKeyIssue(plan, query, scans, right, left, found)
+			}
+		}
+	}
+
+	for i := range plan.Plans {
+		collectForeignKeyJoins(&plan.Plans[i], query, found)
+	}
+}
+
+// addForeignKeyIssue records key, an alias and column pair, when it looks like a foreign
+// key referencing ref and its table is read by a sequential scan
+func addForeignKeyIssue(join *ParsedExplainPlan, query QueryWithPlan, scans map[string]joinScan, key, ref [2]string, found map[string]*ForeignKeyIssue) {
+	column := key[1]
+	if !strings.HasSuffix(strings.ToLower(column), "_id") {
+		return
+	}
+
+	scan, ok := scans[key[0]]
+	if !ok || scan.node.NodeType != "Seq Scan" || IsIgnoredTable(scan.relation) {
+		return
+	}
+	if minRows := MinRecommendationRows(); minRows > 0 {
+		if rows := scannedRows(scan.node); rows > 0 && rows < minRows {
+			return
+		}
+	}
+
+	referenced := ref[0]
+	if refScan, ok := scans[ref[0]]; ok {
+		referenced = refScan.relation
+	}
+
+	id := scan.relation + "." + column
+	if _, exists := found[id]; exists {
+		return
+	}
+	found[id] = &ForeignKeyIssue{
+		QueriedTable:    scan.relation,
+		Column:          column,
+		ReferencedTable: referenced,
+		ReferencedKey:   ref[1],
+		JoinType:        join.NodeType,
+		Cost:            scan.node.TotalCost * scan.loops,
+		DurationMS:      query.DurationMS,
+		Occurrences:     1,
+		Query:           query.Query,
+	}
+}
+
+// joinSideLoops returns how many times join ran its i-th child. A nested loop runs its
+// inner side once per outer row, estimated from the outer side's rows without ANALYZE.
+func joinSideLoops(join *ParsedExplainPlan, i int) float64 {
+	side := &join.Plans[i]
+	if side.ActualLoops > 0 {
+		return float64(side.ActualLoops)
+	}
+	if join.NodeType == "Nested Loop" && i == 1 {
+		return max(join.Plans[0].PlanRows, 1)
+	}
+	return 1
+}
+
+// collectJoinScans maps the alias of every table read under node to its scan. loops is
+// how many times node runs, replaced by the node's own count when the plan was analyzed.
+func collectJoinScans(node *ParsedExplainPlan, loops float64, scans map[string]joinScan) {
+	if node.ActualLoops > 0 {
+		loops = float64(node.ActualLoops)
+	}
+	if node.RelationName != "" {
+		alias, _ := node.RawPlan["Alias"].(string)
+		if alias == "" {
+			alias = node.RelationName
+		}
+		scans[alias] = joinScan{relation: node.RelationName, node: node, loops: loops}
+	}
+
+	// A Materialize node reads its input once and replays it on every later loop
+	if node.NodeType == "Materialize" {
+		loops = 1
+	}
+	for i := range node.Plans {
+		collectJoinScans(&node.Plans[i], loops, scans)
+	}
+}
+
+// joinConditions returns the join's conditions with casts, quotes and parentheses
+// removed, including the filter of a sequential scan on a nested loop's inner side
+func joinConditions(join *ParsedExplainPlan) []string {
+	var conds []string
+	for _, key := range []string{"Hash Cond", "Merge Cond", "Join Filter"} {
+		if cond, ok := join.RawPlan[key].(string); ok {
+			conds = append(conds, cond)
+		}
+	}
+	if join.NodeType == "Nested Loop" && len(join.Plans) > 1 {
+		inner := &join.Plans[1]
+		for inner.NodeType == "Materialize" && len(inner.Plans) > 0 {
+			inner = &inner.Plans[0]
+		}
+		if filter, ok := inner.RawPlan["Filter"].(string); ok {
+			conds = append(conds, filter)
+		}
+	}
+
+	for i, cond := range conds {
+		cond = joinCastPattern.ReplaceAllString(cond, "")
+		conds[i] = strings.NewReplacer("(", " ", ")", " ", `"`, "").Replace(cond)
+	}
+	return conds
+}
+
+// foreignKeyRecommendations turns foreign key issues into index recommendations
+func foreignKeyRecommendations(issues map[string]*ForeignKeyIssue) []IndexRecommendation {
+	var recommendations []IndexRecommendation
+	for _, issue := range issues {
+		score := scanScore(issue.DurationMS, issue.Occurrences, issue.Cost)
+		recommendations = append(recommendations, IndexRecommendation{
+			Type:         RecommendationForeignKeyIndex,
+			QueriedTable: issue.QueriedTable,
+			Columns:      []string{issue.Column},
+			Reason: fmt.Sprintf("Foreign key index missing: %s.%s is joined to %s.%s by %s but read by sequential scan (occurred %d times)",
+				issue.QueriedTable, issue.Column, issue.ReferencedTable, issue.ReferencedKey, issue.JoinType, issue.Occurrences),
+			EstimatedImpact: "High - Joins probe the index instead of scanning, and deletes on the referenced table stop scanning to check the constraint",
+			Priority:        priorityForScore(score),
+			Score:           score,
+			SQLCommand: fmt.Sprintf("CREATE INDEX idx_%s_%s ON %s (%s);",
+				issue.QueriedTable, issue.Column, issue.QueriedTable, issue.Column),
+			AffectedQueries: issue.Occurrences,
+		})
+	}
+	return recommendations
+}
+
+// mergeForeignKeyRecommendations adds fkRecs to recs, replacing the generic sequential
+// scan recommendations on the same tables, whose filter column could not be determined
+func mergeForeignKeyRecommendations(recs, fkRecs []IndexRecommendation) []IndexRecommendation {
+	if len(fkRecs) == 0 {
+		return recs
+	}
+
+	covered := make(map[string]bool)
+	for _, rec := range fkRecs {
+		covered[rec.QueriedTable] = true
+	}
+
+	merged := make([]IndexRecommendation, 0, len(recs)+len(fkRecs))
+	for _, rec := range recs {
+		generic := len(rec.Columns) == 1 && rec.Columns[0] == "<filter_column>"
+		if generic && covered[rec.QueriedTable] {
+			continue
+		}
+		merged = append(merged, rec)
+	}
+	merged = append(merged, fkRecs...)
+	sortRecommendations(merged)
+	return merged
+}
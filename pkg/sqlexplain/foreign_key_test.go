@@ -0,0 +1,162 @@
+package sqlexplain
+
+import (
+	"strings"
+	"testing"
+)
+
+// nestedLoopFKPlan joins orders to users on orders.user_id, which has no index, so the
+// inner side scans orders once per user
+const nestedLoopFKPlan = `[{"Plan": {
+	"Node Type": "Nested Loop",
+	"Join Type": "Inner",
+	"Startup Cost": 0.00,
+	"Total Cost": 48210.50,
+	"Plan Rows": 120,
+	"Plan Width": 64,
+	"Actual Rows": 118,
+	"Actual Loops": 1,
+	"Join Filter": "(o.user_id = u.id)",
+	"Rows Removed by Join Filter": 1199882,
+	"Plans": [
+		{
+			"Node Type": "Seq Scan",
+			"Parent Relationship": "Outer",
+			"Relation Name": "users",
+			"Alias": "u",
+			"Startup Cost": 0.00,
+			"Total Cost": 25.00,
+			"Plan Rows": 12,
+			"Plan Width": 32,
+			"Actual Rows": 12,
+			"Actual Loops": 1,
+			"Filter": "((status)::text = 'active'::text)",
+			"Rows Removed by Filter": 1988
+		},
+		{
+			"Node Type": "Materialize",
+			"Parent Relationship": "Inner",
+			"Startup Cost": 0.00,
+			"Total Cost": 2005.00,
+			"Plan Rows": 100000,
+			"Plan Width": 32,
+			"Actual Rows": 100000,
+			"Actual Loops": 12,
+			"Plans": [
+				{
+					"Node Type": "Seq Scan",
+					"Parent Relationship": "Outer",
+					"Relation Name": "orders",
+					"Alias": "o",
+					"Startup Cost": 0.00,
+					"Total Cost": 1540.00,
+					"Plan Rows": 100000,
+					"Plan Width": 32,
+					"Actual Rows": 100000,
+					"Actual Loops": 1
+				}
+			]
+		}
+	]
+}}]`
+
+// indexedJoinPlan probes orders.user_id through an index, so nothing is missing
+const indexedJoinPlan = `[{"Plan": {
+	"Node Type": "Nested Loop",
+	"Total Cost": 120.50,
+	"Plan Rows": 10,
+	"Plans": [
+		{"Node Type": "Index Scan", "Relation Name": "users", "Alias": "u", "Index Name": "users_pkey", "Total Cost": 8.29, "Plan Rows": 1},
+		{"Node Type": "Index Scan", "Relation Name": "orders", "Alias": "o", "Index Name": "idx_orders_user_id", "Total Cost": 12.10, "Plan Rows": 10, "Index Cond": "(user_id = u.id)"}
+	]
+}}]`
+
+func TestAnalyzeForeignKeyJoins(t *testing.T) {
+	query := QueryWithPlan{
+		Query:           "SELECT o.* FROM users u JOIN orders o ON o.user_id = u.id WHERE u.status = 'active'",
+		NormalizedQuery: "SELECT o.* FROM users u JOIN orders o ON o.user_id = u.id WHERE u.status = $N",
+		DurationMS:      450,
+		QueriedTable:    "users",
+		ExplainPlan:     nestedLoopFKPlan,
+	}
+
+	analysis := AnalyzeIndexUsage([]QueryWithPlan{query, query})
+
+	if len(analysis.ForeignKeys) != 1 {
+		t.Fatalf("Expected 1 missing foreign key index, got %+v", analysis.ForeignKeys)
+	}
+	issue := analysis.ForeignKeys[0]
+	if issue.QueriedTable != "orders" || issue.Column != "user_id" || issue.ReferencedTable != "users" || issue.ReferencedKey != "id" {
+		t.Errorf("Expected orders.user_id referencing users.id, got %+v", issue)
+	}
+	if issue.JoinType != "Nested Loop" || issue.Occurrences != 2 || issue.DurationMS != 900 {
+		t.Errorf("Unexpected join type or totals: %+v", issue)
+	}
+	// The inner side runs once per outer row, but the Materialize node above the scan
+	// reads orders only once
+	if issue.Cost != 1540 {
+		t.Errorf("Expected the cost of the one scan, got %v", issue.Cost)
+	}
+
+	var fkRec *IndexRecommendation
+	for i, rec := range analysis.Recommendations {
+		if rec.Type == RecommendationForeignKeyIndex {
+			fkRec = &analysis.Recommendations[i]
+		}
+		if rec.QueriedTable == "orders" && rec.Type == RecommendationSeqScan {
+			t.Errorf("Expected the foreign key recommendation to replace the generic orders one, got %+v", rec)
+		}
+	}
+	if fkRec == nil {
+		t.Fatalf("Expected a foreign key index recommendation, got %+v", analysis.Recommendations)
+	}
+	if fkRec.SQLCommand != "CREATE INDEX idx_orders_user_id ON orders (user_id);" {
+		t.Errorf("Unexpected SQL command: %s", fkRec.SQLCommand)
+	}
+	// 900ms observed plus 2 occurrences of 1540 cost units
+	if fkRec.Priority != "high" || fkRec.Score != 900+2*1540/100.0 {
+		t.Errorf("Expected a high priority recommendation, got %+v", fkRec)
+	}
+}
+
+func TestAnalyzeForeignKeyJoinsMaterializeEstimated(t *testing.T) {
+	// Without ANALYZE the inner side is estimated to run once per outer row, but
+	// Materialize still reads orders once
+	plan := strings.NewReplacer(`"Actual Loops": 12,`, ``, `"Actual Loops": 1`, `"Actual Loops": 0`).Replace(nestedLoopFKPlan)
+	analysis := AnalyzeIndexUsage([]QueryWithPlan{{
+		Query:           "SELECT o.* FROM users u JOIN orders o ON o.user_id = u.id",
+		NormalizedQuery: "SELECT o.* FROM users u JOIN orders o ON o.user_id = u.id",
+		ExplainPlan:     plan,
+	}})
+
+	if len(analysis.ForeignKeys) != 1 || analysis.ForeignKeys[0].Cost != 1540 {
+		t.Errorf("Expected orders scanned once under Materialize, got %+v", analysis.ForeignKeys)
+	}
+}
+
+func TestAnalyzeForeignKeyJoinsSorted(t *testing.T) {
+	invoices := strings.NewReplacer(`"orders"`, `"invoices"`, `"Total Cost": 1540.00`, `"Total Cost": 3000.00`).Replace(nestedLoopFKPlan)
+	queries := []QueryWithPlan{
+		{Query: "SELECT o.* FROM users u JOIN orders o ON o.user_id = u.id", NormalizedQuery: "orders", ExplainPlan: nestedLoopFKPlan},
+		{Query: "SELECT o.* FROM users u JOIN invoices o ON o.user_id = u.id", NormalizedQuery: "invoices", ExplainPlan: invoices},
+	}
+
+	for range 5 {
+		analysis := AnalyzeIndexUsage(queries)
+		if len(analysis.ForeignKeys) != 2 || analysis.ForeignKeys[0].QueriedTable != "invoices" || analysis.ForeignKeys[1].QueriedTable != "orders" {
+			t.Fatalf("Expected the costliest foreign key first, got %+v", analysis.ForeignKeys)
+		}
+	}
+}
+
+func TestAnalyzeForeignKeyJoinsIndexed(t *testing.T) {
+	analysis := AnalyzeIndexUsage([]QueryWithPlan{{
+		Query:           "SELECT o.* FROM users u JOIN orders o ON o.user_id = u.id WHERE u.id = $1",
+		NormalizedQuery: "SELECT o.* FROM users u JOIN orders o ON o.user_id = u.id WHERE u.id = $N",
+		ExplainPlan:     indexedJoinPlan,
+	}})
+
+	if len(analysis.ForeignKeys) != 0 {
+		t.Errorf("Expected no missing foreign key index when the join uses one, got %+v", analysis.ForeignKeys)
+	}
+}
@@ -0,0 +1,68 @@
+package sqlexplain
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultIgnoredTables are migration bookkeeping tables left out of analysis unless
+// SetIgnoredTables says otherwise
+var DefaultIgnoredTables = []string{"goose_db_version", "schema_migrations"}
+
+var (
+	ignoredTables      = tableSet(DefaultIgnoredTables)
+	ignoredTablesMutex sync.RWMutex
+)
+
+// SetIgnoredTables replaces the tables left out of table stats and index recommendations.
+// A nil slice restores DefaultIgnoredTables; an empty one ignores nothing.
+func SetIgnoredTables(tables []string) {
+	if tables == nil {
+		tables = DefaultIgnoredTables
+	}
+
+	ignoredTablesMutex.Lock()
+	defer ignoredTablesMutex.Unlock()
+	ignoredTables = tableSet(tables)
+}
+
+// IsIgnoredTable reports whether a table is left out of analysis. Matching ignores
+// case, quoting and any schema prefix, so "public.Schema_Migrations" matches schema_migrations.
+func IsIgnoredTable(table string) bool {
+	if table == "" {
+		return false
+	}
+
+	ignoredTablesMutex.RLock()
+	defer ignoredTablesMutex.RUnlock()
+	return ignoredTables[normalizeTableName(table)]
+}
+
+// FilterIgnoredTables returns the queries that do not target an ignored table
+func FilterIgnoredTables(queries []QueryWithPlan) []QueryWithPlan {
+	result := make([]QueryWithPlan, 0, len(queries))
+	for _, q := range queries {
+		if !IsIgnoredTable(q.QueriedTable) {
+			result = append(result, q)
+		}
+	}
+	return result
+}
+
+func tableSet(tables []string) map[string]bool {
+	set := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		if name := normalizeTableName(table); name != "" {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+func normalizeTableName(table string) string {
+	table = strings.TrimSpace(table)
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		table = table[i+1:]
+	}
+	return strings.ToLower(strings.Trim(table, `"`))
+}
@@ -0,0 +1,73 @@
+package sqlexplain
+
+import (
+	"testing"
+)
+
+func TestAnalyzeIndexUsageSkipsIgnoredTables(t *testing.T) {
+	t.Cleanup(func() { SetIgnoredTables(nil) })
+
+	seqScanPlan := func(table string) string {
+		return `[{"Plan": {
+			"Node Type": "Seq Scan",
+			"Relation Name": "` + table + `",
+			"Total Cost": 15234.00,
+			"Plan Rows": 10000,
+			"Actual Rows": 9500,
+			"Filter": "(version_id = 1)"
+		}}]`
+	}
+
+	queries := []QueryWithPlan{
+		{
+			Query:           "SELECT * FROM goose_db_version WHERE version_id = $1",
+			NormalizedQuery: "SELECT * FROM goose_db_version WHERE version_id = $N",
+			QueriedTable:    "goose_db_version",
+			ExplainPlan:     seqScanPlan("goose_db_version"),
+		},
+		{
+			Query:           "SELECT * FROM users WHERE version_id = $1",
+			NormalizedQuery: "SELECT * FROM users WHERE version_id = $N",
+			QueriedTable:    "users",
+			ExplainPlan:     seqScanPlan("users"),
+		},
+	}
+
+	analysis := AnalyzeIndexUsage(queries)
+	if analysis.Summary.TotalQueries != 1 {
+		t.Errorf("Expected ignored table's query to be dropped, got %d queries", analysis.Summary.TotalQueries)
+	}
+	for _, rec := range analysis.Recommendations {
+		if rec.QueriedTable == "goose_db_version" {
+			t.Errorf("Expected no recommendation for ignored table, got %+v", rec)
+		}
+	}
+	if len(analysis.SequentialScans) != 1 || analysis.SequentialScans[0].QueriedTable != "users" {
+		t.Errorf("Expected only the users sequential scan, got %+v", analysis.SequentialScans)
+	}
+
+	// An empty list ignores nothing
+	SetIgnoredTables([]string{})
+	if analysis := AnalyzeIndexUsage(queries); analysis.Summary.TotalQueries != 2 {
+		t.Errorf("Expected both queries with no ignored tables, got %d", analysis.Summary.TotalQueries)
+	}
+}
+
+func TestIsIgnoredTable(t *testing.T) {
+	t.Cleanup(func() { SetIgnoredTables(nil) })
+
+	SetIgnoredTables([]string{"schema_migrations", "heartbeats"})
+
+	for table, expected := range map[string]bool{
+		"schema_migrations":          true,
+		`public."Schema_Migrations"`: true,
+		"HEARTBEATS":                 true,
+		"goose_db_version":           false,
+		"users":                      false,
+		"":                           false,
+	} {
+		if got := IsIgnoredTable(table); got != expected {
+			t.Errorf("IsIgnoredTable(%q) = %v, expected %v", table, got, expected)
+		}
+	}
+}
@@ -1,17 +1,50 @@
 package sqlexplain
 
 import (
+	"cmp"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 )
 
+// DefaultMinRecommendationRows is the table size below which index recommendations are
+// suppressed, since a sequential scan of a small table is usually as fast as an index
+const DefaultMinRecommendationRows = 1000
+
+var (
+	minRecommendationRows      float64 = DefaultMinRecommendationRows
+	minRecommendationRowsMutex sync.RWMutex
+)
+
+// SetMinRecommendationRows sets the table size below which index recommendations are
+// suppressed. Zero disables suppression; a negative value restores the default.
+func SetMinRecommendationRows(rows float64) {
+	if rows < 0 {
+		rows = DefaultMinRecommendationRows
+	}
+
+	minRecommendationRowsMutex.Lock()
+	defer minRecommendationRowsMutex.Unlock()
+	minRecommendationRows = rows
+}
+
+// MinRecommendationRows returns the table size below which index recommendations are suppressed
+func MinRecommendationRows() float64 {
+	minRecommendationRowsMutex.RLock()
+	defer minRecommendationRowsMutex.RUnlock()
+	return minRecommendationRows
+}
+
 // IndexRecommendation represents a suggestion to add or modify an index
 type IndexRecommendation struct {
+	Type            string   `json:"type"` // RecommendationSeqScan or RecommendationForeignKeyIndex
 	QueriedTable    string   `json:"tableName"`
 	Columns         []string `json:"columns"`
 	Reason          string   `json:"reason"`
 	EstimatedImpact string   `json:"estimatedImpact"`
-	Priority        string   `json:"priority"` // "high", "medium", "low"
+	Priority        string   `json:"priority"` // "high", "medium", "low", bucketed from Score
+	Score           float64  `json:"score"`    // Estimated total ms spent in the scan; see recommendationScore
 	SQLCommand      string   `json:"sqlCommand"`
 	AffectedQueries int      `json:"affectedQueries"`
 }
@@ -20,6 +53,8 @@ type IndexRecommendation struct {
 type IndexAnalysis struct {
 	Recommendations []IndexRecommendation `json:"recommendations"`
 	SequentialScans []SequentialScanIssue `json:"sequentialScans"`
+	ForeignKeys     []ForeignKeyIssue     `json:"missingForeignKeyIndexes,omitempty"` // Join keys without an index
+	Unbounded       []UnboundedQueryIssue `json:"unboundedQueries,omitempty"`         // Large reads without a LIMIT
 	UnusedIndexes   []string              `json:"unusedIndexes,omitempty"`
 	IndexUsageStats []IndexUsageStat      `json:"indexUsageStats"`
 	Summary         IndexAnalysisSummary  `json:"summary"`
@@ -32,6 +67,7 @@ type SequentialScanIssue struct {
 	QueriedTable    string  `json:"tableName"`
 	EstimatedRows   float64 `json:"estimatedRows"`
 	ActualRows      float64 `json:"actualRows"`
+	TableRows       float64 `json:"tableRows,omitempty"` // Rows the scan read, approximating table size; 0 when unknown
 	Cost            float64 `json:"cost"`
 	DurationMS      float64 `json:"durationMs"`
 	Occurrences     int     `json:"occurrences"`
@@ -58,8 +94,11 @@ type IndexAnalysisSummary struct {
 	AvgQueryCost         float64 `json:"avgQueryCost"`
 }
 
-// AnalyzeIndexUsage analyzes a set of queries for index usage and recommendations
+// AnalyzeIndexUsage analyzes a set of queries for index usage and recommendations.
+// Queries and plan nodes on ignored tables (see SetIgnoredTables) are skipped.
 func AnalyzeIndexUsage(queries []QueryWithPlan) *IndexAnalysis {
+	queries = FilterIgnoredTables(queries)
+
 	analysis := &IndexAnalysis{
 		Summary: IndexAnalysisSummary{
 			TotalQueries: len(queries),
@@ -76,6 +115,12 @@ func AnalyzeIndexUsage(queries []QueryWithPlan) *IndexAnalysis {
 	// Track index usage
 	indexUsageMap := make(map[string]*IndexUsageStat)
 
+	// Track foreign key join columns read by sequential scan, by table and column
+	foreignKeyMap := make(map[string]*ForeignKeyIssue)
+
+	// Track large reads without a LIMIT by normalized query
+	unboundedMap := make(map[string]*UnboundedQueryIssue)
+
 	var totalCost float64
 	queriesWithPlans := 0
 
@@ -94,6 +139,8 @@ func AnalyzeIndexUsage(queries []QueryWithPlan) *IndexAnalysis {
 
 		// Analyze the plan for issues and index usage
 		analyzeNode(plan, q, seqScanMap, indexUsageMap, &analysis.Summary)
+		analyzeForeignKeyJoins(plan, q, foreignKeyMap)
+		analyzeUnboundedQuery(plan, q, unboundedMap)
 	}
 
 	analysis.Summary.QueriesWithPlans = queriesWithPlans
@@ -108,9 +155,26 @@ func AnalyzeIndexUsage(queries []QueryWithPlan) *IndexAnalysis {
 	for _, stat := range indexUsageMap {
 		analysis.IndexUsageStats = append(analysis.IndexUsageStats, *stat)
 	}
+	for _, issue := range foreignKeyMap {
+		analysis.ForeignKeys = append(analysis.ForeignKeys, *issue)
+	}
+	slices.SortFunc(analysis.ForeignKeys, func(a, b ForeignKeyIssue) int {
+		return cmp.Or(
+			cmp.Compare(b.Cost, a.Cost),
+			cmp.Compare(a.QueriedTable, b.QueriedTable),
+			cmp.Compare(a.Column, b.Column),
+		)
+	})
+	for _, issue := range unboundedMap {
+		analysis.Unbounded = append(analysis.Unbounded, *issue)
+	}
+	slices.SortFunc(analysis.Unbounded, func(a, b UnboundedQueryIssue) int {
+		return cmp.Compare(b.EstimatedRows, a.EstimatedRows)
+	})
 
 	// Generate recommendations based on findings
-	analysis.Recommendations = generateRecommendations(seqScanMap, indexUsageMap)
+	analysis.Recommendations = mergeForeignKeyRecommendations(
+		generateRecommendations(seqScanMap, indexUsageMap), foreignKeyRecommendations(foreignKeyMap))
 	analysis.Summary.TotalRecommendations = len(analysis.Recommendations)
 
 	for _, rec := range analysis.Recommendations {
@@ -127,7 +191,8 @@ func analyzeNode(plan *ParsedExplainPlan, query QueryWithPlan, seqScanMap map[st
 	indexUsageMap map[string]*IndexUsageStat, summary *IndexAnalysisSummary) {
 
 	// Check for sequential scans
-	if plan.NodeType == "Seq Scan" {
+	ignored := IsIgnoredTable(plan.RelationName)
+	if plan.NodeType == "Seq Scan" && !ignored {
 		summary.SequentialScans++
 
 		key := query.NormalizedQuery
@@ -141,9 +206,10 @@ func analyzeNode(plan *ParsedExplainPlan, query QueryWithPlan, seqScanMap map[st
 			seqScanMap[key] = &SequentialScanIssue{
 				Query:           query.Query,
 				NormalizedQuery: query.NormalizedQuery,
-				QueriedTable:    plan.RelationName,
+				QueriedTable:    cmp.Or(plan.RelationName, query.QueriedTable),
 				EstimatedRows:   plan.PlanRows,
 				ActualRows:      plan.ActualRows,
+				TableRows:       scannedRows(plan),
 				Cost:            plan.TotalCost,
 				DurationMS:      query.DurationMS,
 				Occurrences:     1,
@@ -153,7 +219,7 @@ func analyzeNode(plan *ParsedExplainPlan, query QueryWithPlan, seqScanMap map[st
 	}
 
 	// Track index scans
-	if strings.Contains(plan.NodeType, "Index") {
+	if strings.Contains(plan.NodeType, "Index") && !ignored {
 		summary.IndexScans++
 
 		if plan.IndexName != "" {
@@ -209,7 +275,8 @@ func generateRecommendations(seqScans map[string]*SequentialScanIssue,
 			continue
 		}
 
-		// Determine priority based on frequency, cost, and row count
+		// Determine priority from the time the scan costs across all its occurrences
+		score := recommendationScore(issue)
 		priority := determinePriority(issue)
 
 		// Skip low priority single-occurrence scans on small tables
@@ -217,16 +284,23 @@ func generateRecommendations(seqScans map[string]*SequentialScanIssue,
 			continue
 		}
 
+		// Skip tables known to be too small for an index to matter
+		if minRows := MinRecommendationRows(); issue.TableRows > 0 && issue.TableRows < minRows {
+			continue
+		}
+
 		columns := extractColumnsFromFilter(issue.FilterCondition)
 		if len(columns) == 0 {
 			// Generic recommendation if we can't determine columns
 			rec := IndexRecommendation{
+				Type:         RecommendationSeqScan,
 				QueriedTable: issue.QueriedTable,
 				Columns:      []string{"<filter_column>"},
 				Reason: fmt.Sprintf("Sequential scan detected on table '%s' (occurred %d times, avg cost: %.2f)",
 					issue.QueriedTable, issue.Occurrences, issue.Cost),
 				EstimatedImpact: estimateImpact(issue),
 				Priority:        priority,
+				Score:           score,
 				SQLCommand: fmt.Sprintf("CREATE INDEX idx_%s_<column> ON %s (<filter_column>);",
 					issue.QueriedTable, issue.QueriedTable),
 				AffectedQueries: issue.Occurrences,
@@ -236,12 +310,14 @@ func generateRecommendations(seqScans map[string]*SequentialScanIssue,
 			// Specific recommendation with detected columns
 			indexName := fmt.Sprintf("idx_%s_%s", issue.QueriedTable, strings.Join(columns, "_"))
 			rec := IndexRecommendation{
+				Type:         RecommendationSeqScan,
 				QueriedTable: issue.QueriedTable,
 				Columns:      columns,
 				Reason: fmt.Sprintf("Sequential scan on '%s' filtering by %s (occurred %d times)",
 					issue.QueriedTable, strings.Join(columns, ", "), issue.Occurrences),
 				EstimatedImpact: estimateImpact(issue),
 				Priority:        priority,
+				Score:           score,
 				SQLCommand: fmt.Sprintf("CREATE INDEX %s ON %s (%s);",
 					indexName, issue.QueriedTable, strings.Join(columns, ", ")),
 				AffectedQueries: issue.Occurrences,
@@ -250,51 +326,59 @@ func generateRecommendations(seqScans map[string]*SequentialScanIssue,
 		}
 	}
 
-	// Sort recommendations by priority (high first)
+	// Sort recommendations by score, so the first saves the most total time
 	sortRecommendations(recommendations)
 
 	return recommendations
 }
 
-// determinePriority calculates recommendation priority
-func determinePriority(issue *SequentialScanIssue) string {
-	score := 0
-
-	// Factor 1: Frequency
-	if issue.Occurrences >= 10 {
-		score += 3
-	} else if issue.Occurrences >= 5 {
-		score += 2
-	} else if issue.Occurrences > 1 {
-		score += 1
+// scannedRows estimates how many rows a sequential scan read. Plan Rows counts rows left
+// after the filter, so a filtered scan's size is only known from EXPLAIN ANALYZE's
+// "Rows Removed by Filter"; without it 0 is returned.
+func scannedRows(plan *ParsedExplainPlan) float64 {
+	if removed, ok := plan.RawPlan["Rows Removed by Filter"].(float64); ok {
+		return plan.ActualRows + removed
 	}
-
-	// Factor 2: Cost
-	if issue.Cost > 10000 {
-		score += 3
-	} else if issue.Cost > 1000 {
-		score += 2
-	} else if issue.Cost > 100 {
-		score += 1
+	if _, filtered := plan.RawPlan["Filter"]; filtered {
+		return 0
 	}
+	return max(plan.PlanRows, plan.ActualRows)
+}
 
-	// Factor 3: Row count
-	if issue.EstimatedRows > 10000 {
-		score += 2
-	} else if issue.EstimatedRows > 1000 {
-		score += 1
-	}
+// costUnitsPerMS converts planner cost to an approximate duration. Postgres cost units
+// are roughly one sequential page read, of which about 100 fit in a millisecond.
+const costUnitsPerMS = 100
 
-	// Factor 4: Duration
-	if issue.DurationMS > 100 {
-		score += 2
-	} else if issue.DurationMS > 50 {
-		score += 1
-	}
+// Score thresholds for recommendation priorities, in estimated total milliseconds
+const (
+	highPriorityScore   = 200
+	mediumPriorityScore = 50
+)
+
+// recommendationScore estimates the total milliseconds a sequential scan costs across
+// the analyzed queries: the observed query durations plus the plan's Total Cost,
+// converted with costUnitsPerMS, for every occurrence. A cheap scan run often can
+// outrank an expensive one run once.
+func recommendationScore(issue *SequentialScanIssue) float64 {
+	return scanScore(issue.DurationMS, issue.Occurrences, issue.Cost)
+}
 
-	if score >= 6 {
+// scanScore is the recommendation score of a scan with the given total duration,
+// occurrence count and plan cost per occurrence
+func scanScore(durationMS float64, occurrences int, cost float64) float64 {
+	return durationMS + float64(occurrences)*cost/costUnitsPerMS
+}
+
+// determinePriority buckets an issue's recommendationScore into a priority
+func determinePriority(issue *SequentialScanIssue) string {
+	return priorityForScore(recommendationScore(issue))
+}
+
+// priorityForScore buckets a recommendation score into a priority
+func priorityForScore(score float64) string {
+	if score >= highPriorityScore {
 		return "high"
-	} else if score >= 3 {
+	} else if score >= mediumPriorityScore {
 		return "medium"
 	}
 	return "low"
@@ -375,16 +459,17 @@ func deduplicateStrings(slice []string) []string {
 	return result
 }
 
-// sortRecommendations sorts recommendations by priority
+// sortRecommendations sorts recommendations by priority, then by score, highest first.
+// Ties keep a stable order by table and SQL command, since the scans come from a map.
 func sortRecommendations(recs []IndexRecommendation) {
 	priorityOrder := map[string]int{"high": 0, "medium": 1, "low": 2}
 
-	// Use a simple bubble sort since we're modifying in place
-	for i := range recs {
-		for j := i + 1; j < len(recs); j++ {
-			if priorityOrder[recs[i].Priority] > priorityOrder[recs[j].Priority] {
-				recs[i], recs[j] = recs[j], recs[i]
-			}
-		}
-	}
+	slices.SortFunc(recs, func(a, b IndexRecommendation) int {
+		return cmp.Or(
+			cmp.Compare(priorityOrder[a.Priority], priorityOrder[b.Priority]),
+			cmp.Compare(b.Score, a.Score),
+			cmp.Compare(a.QueriedTable, b.QueriedTable),
+			cmp.Compare(a.SQLCommand, b.SQLCommand),
+		)
+	})
 }
@@ -2,6 +2,7 @@ package sqlexplain
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
 )
 
@@ -396,6 +397,53 @@ func TestGenerateRecommendations(t *testing.T) {
 	}
 }
 
+func TestRecommendationsSortedByScore(t *testing.T) {
+	seqScans := map[string]*SequentialScanIssue{
+		// An expensive scan that ran once
+		"report": {
+			QueriedTable:    "orders",
+			EstimatedRows:   50000,
+			Cost:            5000,
+			Occurrences:     1,
+			DurationMS:      30,
+			FilterCondition: "(created_at > $1)",
+		},
+		// A cheap scan run for every row of a list: less costly each time, more in total
+		"lookup": {
+			QueriedTable:    "users",
+			EstimatedRows:   5000,
+			Cost:            200,
+			Occurrences:     40,
+			DurationMS:      80,
+			FilterCondition: "(email = $1)",
+		},
+	}
+
+	recs := generateRecommendations(seqScans, map[string]*IndexUsageStat{})
+	if len(recs) != 2 {
+		t.Fatalf("Expected 2 recommendations, got %d", len(recs))
+	}
+
+	// users: 80ms observed + 40 * 200 / 100; orders: 30ms observed + 1 * 5000 / 100
+	if recs[0].QueriedTable != "users" || recs[0].Score != 160 {
+		t.Errorf("Expected users first with score 160, got %s with %v", recs[0].QueriedTable, recs[0].Score)
+	}
+	if recs[1].QueriedTable != "orders" || recs[1].Score != 80 {
+		t.Errorf("Expected orders second with score 80, got %s with %v", recs[1].QueriedTable, recs[1].Score)
+	}
+	if recs[0].Priority != "medium" || recs[1].Priority != "medium" {
+		t.Errorf("Expected both to be medium priority, got %s and %s", recs[0].Priority, recs[1].Priority)
+	}
+
+	// Running the report often enough makes it the bigger saving
+	seqScans["report"].Occurrences = 5
+	seqScans["report"].DurationMS = 150
+	recs = generateRecommendations(seqScans, map[string]*IndexUsageStat{})
+	if recs[0].QueriedTable != "orders" || recs[0].Priority != "high" {
+		t.Errorf("Expected the frequent report to rank first as high priority, got %+v", recs[0])
+	}
+}
+
 func TestSortRecommendations(t *testing.T) {
 	recs := []IndexRecommendation{
 		{Priority: "low", QueriedTable: "table1"},
@@ -493,3 +541,74 @@ func TestExtractFilterCondition(t *testing.T) {
 		t.Errorf("Expected empty string, got %s", result3)
 	}
 }
+
+func TestRecommendationsSuppressedForSmallTables(t *testing.T) {
+	t.Cleanup(func() { SetMinRecommendationRows(-1) })
+
+	// EXPLAIN ANALYZE of a filtered scan: the table size is rows kept plus rows removed
+	analyzePlan := func(table string, removed int) string {
+		return fmt.Sprintf(`[{"Plan": {
+			"Node Type": "Seq Scan",
+			"Relation Name": "%s",
+			"Total Cost": 20000.00,
+			"Plan Rows": 1,
+			"Actual Rows": 1,
+			"Actual Loops": 1,
+			"Rows Removed by Filter": %d,
+			"Filter": "(code = 'abc'::text)"
+		}}]`, table, removed)
+	}
+
+	queries := []QueryWithPlan{
+		{
+			Query:           "SELECT * FROM countries WHERE code = $1",
+			NormalizedQuery: "SELECT * FROM countries WHERE code = $N",
+			DurationMS:      150,
+			QueriedTable:    "countries",
+			ExplainPlan:     analyzePlan("countries", 49),
+		},
+		{
+			Query:           "SELECT * FROM events WHERE code = $1",
+			NormalizedQuery: "SELECT * FROM events WHERE code = $N",
+			DurationMS:      150,
+			QueriedTable:    "events",
+			ExplainPlan:     analyzePlan("events", 999999),
+		},
+	}
+
+	recommendedTables := func() []string {
+		var tables []string
+		for _, rec := range AnalyzeIndexUsage(queries).Recommendations {
+			tables = append(tables, rec.QueriedTable)
+		}
+		return tables
+	}
+
+	if tables := recommendedTables(); len(tables) != 1 || tables[0] != "events" {
+		t.Errorf("Expected only the 1M-row table to be recommended, got %v", tables)
+	}
+
+	// Zero disables suppression
+	SetMinRecommendationRows(0)
+	if tables := recommendedTables(); len(tables) != 2 {
+		t.Errorf("Expected both tables with suppression disabled, got %v", tables)
+	}
+}
+
+func TestScannedRows(t *testing.T) {
+	tests := []struct {
+		name     string
+		plan     ParsedExplainPlan
+		expected float64
+	}{
+		{"unfiltered estimate", ParsedExplainPlan{PlanRows: 50, RawPlan: map[string]any{}}, 50},
+		{"filtered estimate is unknown", ParsedExplainPlan{PlanRows: 1, RawPlan: map[string]any{"Filter": "(id = 1)"}}, 0},
+		{"filtered with analyze", ParsedExplainPlan{PlanRows: 1, ActualRows: 2, RawPlan: map[string]any{"Filter": "(id = 1)", "Rows Removed by Filter": float64(98)}}, 100},
+	}
+
+	for _, tt := range tests {
+		if got := scannedRows(&tt.plan); got != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package sqlexplain
+
+import (
+	"sort"
+)
+
+// MultiRunQueryAnalysis summarizes how consistent query counts are across
+// repeated runs of the same request
+type MultiRunQueryAnalysis struct {
+	Runs             int                  `json:"runs"`
+	TotalQueries     []int                `json:"totalQueries"`     // Total queries per run
+	UniqueQueries    int                  `json:"uniqueQueries"`    // Distinct normalized queries across all runs
+	ConsistencyScore float64              `json:"consistencyScore"` // Percentage of unique queries with the same count in every run
+	VaryingQueries   []QueryCountVariance `json:"varyingQueries"`
+}
+
+// QueryCountVariance reports a normalized query whose occurrence count differs between runs
+type QueryCountVariance struct {
+	NormalizedQuery string `json:"normalizedQuery"`
+	ExampleQuery    string `json:"exampleQuery"`
+	QueriedTable    string `json:"tableName"`
+	CountsPerRun    []int  `json:"countsPerRun"` // Indexed like the runs passed in
+	MinCount        int    `json:"minCount"`
+	MaxCount        int    `json:"maxCount"`
+	MissingRuns     int    `json:"missingRuns"` // Runs in which the query never appeared
+}
+
+// AnalyzeMultipleRuns compares query counts across repeated runs and reports the
+// normalized queries whose occurrence count is not the same in every run, which
+// points at non-determinism such as conditional caching
+func AnalyzeMultipleRuns(runs [][]QueryWithPlan) *MultiRunQueryAnalysis {
+	result := &MultiRunQueryAnalysis{
+		Runs:           len(runs),
+		TotalQueries:   make([]int, len(runs)),
+		VaryingQueries: []QueryCountVariance{},
+	}
+
+	counts := make(map[string][]int)
+	examples := make(map[string]QueryWithPlan)
+	for i, run := range runs {
+		result.TotalQueries[i] = len(run)
+		for _, q := range run {
+			if _, ok := counts[q.NormalizedQuery]; !ok {
+				counts[q.NormalizedQuery] = make([]int, len(runs))
+				examples[q.NormalizedQuery] = q
+			}
+			counts[q.NormalizedQuery][i]++
+		}
+	}
+
+	result.UniqueQueries = len(counts)
+	if result.UniqueQueries == 0 {
+		result.ConsistencyScore = 100
+		return result
+	}
+
+	for norm, perRun := range counts {
+		variance := QueryCountVariance{
+			NormalizedQuery: norm,
+			ExampleQuery:    examples[norm].Query,
+			QueriedTable:    examples[norm].QueriedTable,
+			CountsPerRun:    perRun,
+			MinCount:        perRun[0],
+			MaxCount:        perRun[0],
+		}
+		for _, count := range perRun {
+			variance.MinCount = min(variance.MinCount, count)
+			variance.MaxCount = max(variance.MaxCount, count)
+			if count == 0 {
+				variance.MissingRuns++
+			}
+		}
+		if variance.MinCount != variance.MaxCount {
+			result.VaryingQueries = append(result.VaryingQueries, variance)
+		}
+	}
+
+	consistent := result.UniqueQueries - len(result.VaryingQueries)
+	result.ConsistencyScore = float64(consistent) / float64(result.UniqueQueries) * 100
+
+	// Largest spread first, so the noisiest queries lead the report
+	sort.Slice(result.VaryingQueries, func(i, j int) bool {
+		a, b := result.VaryingQueries[i], result.VaryingQueries[j]
+		if spreadA, spreadB := a.MaxCount-a.MinCount, b.MaxCount-b.MinCount; spreadA != spreadB {
+			return spreadA > spreadB
+		}
+		return a.NormalizedQuery < b.NormalizedQuery
+	})
+
+	return result
+}
@@ -0,0 +1,65 @@
+package sqlexplain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAnalyzeMultipleRunsFlagsVaryingQueries(t *testing.T) {
+	users := QueryWithPlan{
+		Query:           "SELECT * FROM users WHERE id = $1",
+		NormalizedQuery: "SELECT * FROM users WHERE id = $N",
+		QueriedTable:    "users",
+	}
+	settings := QueryWithPlan{
+		Query:           "SELECT * FROM settings WHERE user_id = $1",
+		NormalizedQuery: "SELECT * FROM settings WHERE user_id = $N",
+		QueriedTable:    "settings",
+	}
+
+	// The settings lookup is cached after the first run
+	runs := [][]QueryWithPlan{
+		{users, settings},
+		{users},
+		{users},
+	}
+
+	analysis := AnalyzeMultipleRuns(runs)
+
+	if analysis.Runs != 3 || analysis.UniqueQueries != 2 {
+		t.Fatalf("Expected 3 runs and 2 unique queries, got %d and %d", analysis.Runs, analysis.UniqueQueries)
+	}
+	if !slices.Equal(analysis.TotalQueries, []int{2, 1, 1}) {
+		t.Errorf("Expected totals [2 1 1], got %v", analysis.TotalQueries)
+	}
+	if analysis.ConsistencyScore != 50 {
+		t.Errorf("Expected consistency score 50, got %f", analysis.ConsistencyScore)
+	}
+
+	if len(analysis.VaryingQueries) != 1 {
+		t.Fatalf("Expected 1 varying query, got %d", len(analysis.VaryingQueries))
+	}
+	varying := analysis.VaryingQueries[0]
+	if varying.NormalizedQuery != settings.NormalizedQuery || varying.QueriedTable != "settings" {
+		t.Errorf("Expected settings query to be flagged, got %s", varying.NormalizedQuery)
+	}
+	if !slices.Equal(varying.CountsPerRun, []int{1, 0, 0}) {
+		t.Errorf("Expected counts [1 0 0], got %v", varying.CountsPerRun)
+	}
+	if varying.MinCount != 0 || varying.MaxCount != 1 || varying.MissingRuns != 2 {
+		t.Errorf("Expected min 0, max 1, missing 2, got %d, %d, %d", varying.MinCount, varying.MaxCount, varying.MissingRuns)
+	}
+}
+
+func TestAnalyzeMultipleRunsConsistent(t *testing.T) {
+	q := QueryWithPlan{Query: "SELECT 1", NormalizedQuery: "SELECT $N"}
+
+	analysis := AnalyzeMultipleRuns([][]QueryWithPlan{{q, q}, {q, q}})
+	if analysis.ConsistencyScore != 100 || len(analysis.VaryingQueries) != 0 {
+		t.Errorf("Expected fully consistent runs, got score %f with %d varying", analysis.ConsistencyScore, len(analysis.VaryingQueries))
+	}
+
+	if empty := AnalyzeMultipleRuns(nil); empty.ConsistencyScore != 100 {
+		t.Errorf("Expected no runs to be consistent, got %f", empty.ConsistencyScore)
+	}
+}
@@ -0,0 +1,197 @@
+package sqlexplain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rewrite suggestion types
+const (
+	RewriteSelectStar     = "select_star"     // SELECT * reading wide rows
+	RewriteOrCondition    = "or_condition"    // OR across columns, defeating a single index
+	RewriteFunctionColumn = "function_column" // A function applied to a filtered column
+	RewriteNotIn          = "not_in_subquery" // NOT IN over a subquery, which NULLs break
+)
+
+// wideRowBytes is the plan width at which a SELECT * is reported, since narrow rows cost
+// little more to read whole
+const wideRowBytes = 200
+
+var (
+	selectStarPattern     = regexp.MustCompile(`(?is)^\s*SELECT\s+(?:DISTINCT\s+)?(?:\w+\.)?\*\s*(?:,|\bFROM\b)`)
+	orPattern             = regexp.MustCompile(`(?i)\bOR\b`)
+	comparedColumnPattern = regexp.MustCompile(`(?i)(?:"?\w+"?\.)?"?(\w+)"?\s*(?:=|<>|!=|<=|>=|<|>|\bIN\b|\bLIKE\b|\bILIKE\b|\bIS\b)`)
+	functionColumnPattern = regexp.MustCompile(`(?i)\b(\w+)\s*\(\s*((?:"?\w+"?\.)?"?[a-z_]\w*"?)\s*(?:::\s*\w+)?\)\s*(?:=|<>|!=|<=|>=|<|>|\bIN\b|\bLIKE\b|\bILIKE\b|\bBETWEEN\b)`)
+	notInSubqueryPattern  = regexp.MustCompile(`(?is)((?:"?\w+"?\.)?"?\w+"?)\s+NOT\s+IN\s*\(\s*SELECT\b`)
+	equalityPattern       = regexp.MustCompile(`(?i)^\s*(?:"?\w+"?\.)?"?\w+"?\s*=\s*(?:\$\d+|''|[\w.]+)\s*$`)
+)
+
+// RewriteSuggestion is an anti-pattern found in a query, with why it is slow or wrong and
+// how to rewrite it
+type RewriteSuggestion struct {
+	Type    string `json:"type"` // One of the Rewrite constants
+	Problem string `json:"problem"`
+	Rewrite string `json:"rewrite"`
+}
+
+// SuggestRewrites looks for query shapes that defeat indexes or return wrong results and
+// suggests rewrites. The plan, when present, confirms what the SQL alone can't, such as
+// how wide the selected rows are.
+func SuggestRewrites(q QueryWithPlan) []RewriteSuggestion {
+	// Literals could contain anything, so leave them out of the pattern checks
+	query := stringLiteralPattern.ReplaceAllString(q.Query, "''")
+	plan := parseExplainPlan(q.ExplainPlan)
+
+	var suggestions []RewriteSuggestion
+	if s, ok := suggestSelectStar(query, plan); ok {
+		suggestions = append(suggestions, s)
+	}
+
+	where := ""
+	if match := wherePattern.FindStringSubmatch(query); match != nil {
+		where = trailingClausePattern.ReplaceAllString(match[1], "")
+	}
+	if s, ok := suggestOrRewrite(where); ok {
+		suggestions = append(suggestions, s)
+	}
+	if s, ok := suggestFunctionColumn(where); ok {
+		suggestions = append(suggestions, s)
+	}
+	if s, ok := suggestNotExists(query); ok {
+		suggestions = append(suggestions, s)
+	}
+	return suggestions
+}
+
+// suggestSelectStar reports a SELECT * whose plan shows rows at least wideRowBytes wide
+func suggestSelectStar(query string, plan *ParsedExplainPlan) (RewriteSuggestion, bool) {
+	if plan == nil || plan.PlanWidth < wideRowBytes || !selectStarPattern.MatchString(query) {
+		return RewriteSuggestion{}, false
+	}
+	return RewriteSuggestion{
+		Type:    RewriteSelectStar,
+		Problem: fmt.Sprintf("SELECT * reads %d-byte rows, including columns the caller may not use, and rules out index-only scans", plan.PlanWidth),
+		Rewrite: "List only the columns needed, e.g. SELECT id, name FROM ...",
+	}, true
+}
+
+// suggestOrRewrite reports an OR between conditions on different columns, which a single
+// index can't serve, so the planner falls back to a scan or a bitmap OR. Only the operands
+// of each OR are compared, so a condition ANDed alongside a parenthesized OR doesn't count.
+func suggestOrRewrite(where string) (RewriteSuggestion, bool) {
+	if where == "" || !orPattern.MatchString(where) {
+		return RewriteSuggestion{}, false
+	}
+
+	for _, operands := range orOperands(where) {
+		columns := make(map[string]bool)
+		var names []string
+		equalities := true
+		for _, operand := range operands {
+			for _, m := range comparedColumnPattern.FindAllStringSubmatch(operand, -1) {
+				column := strings.ToLower(m[1])
+				if !columns[column] {
+					columns[column] = true
+					names = append(names, column)
+				}
+			}
+			equalities = equalities && equalityPattern.MatchString(strings.Trim(operand, " \t\n()"))
+		}
+
+		// IN only replaces plain equalities; IS NULL or a range in a branch can't join the list
+		if len(names) == 1 && equalities {
+			return RewriteSuggestion{
+				Type:    RewriteOrCondition,
+				Problem: fmt.Sprintf("OR conditions on %s are checked one by one", names[0]),
+				Rewrite: fmt.Sprintf("Combine them into %s IN (...) or %s = ANY($1)", names[0], names[0]),
+			}, true
+		}
+		if len(names) > 1 {
+			return RewriteSuggestion{
+				Type:    RewriteOrCondition,
+				Problem: fmt.Sprintf("OR across %s can't use a single index, so the table is often scanned", strings.Join(names, ", ")),
+				Rewrite: "Split the branches into queries joined with UNION (or UNION ALL when they can't overlap) so each can use its own index",
+			}, true
+		}
+	}
+	return RewriteSuggestion{}, false
+}
+
+// orOperands returns the operands of each OR in where: one list for the clause itself and
+// for every parenthesized group that ORs conditions at its own level
+func orOperands(where string) [][]string {
+	var groups [][]string
+	var visit func(group string)
+	visit = func(group string) {
+		// Depth of each byte, visiting nested groups as they close
+		depth := make([]int, len(group))
+		level, start := 0, 0
+		for i := 0; i < len(group); i++ {
+			depth[i] = level
+			switch group[i] {
+			case '(':
+				if level == 0 {
+					start = i + 1
+				}
+				level++
+			case ')':
+				if level > 0 {
+					level--
+					if level == 0 {
+						visit(group[start:i])
+					}
+				}
+			}
+		}
+
+		var operands []string
+		last := 0
+		for _, loc := range orPattern.FindAllStringIndex(group, -1) {
+			if depth[loc[0]] == 0 {
+				operands = append(operands, group[last:loc[0]])
+				last = loc[1]
+			}
+		}
+		if len(operands) > 0 {
+			groups = append(groups, append(operands, group[last:]))
+		}
+	}
+	visit(where)
+	return groups
+}
+
+// suggestFunctionColumn reports a function applied to a column in a comparison, which
+// hides the column from any plain index on it
+func suggestFunctionColumn(where string) (RewriteSuggestion, bool) {
+	for _, m := range functionColumnPattern.FindAllStringSubmatch(where, -1) {
+		fn := strings.ToLower(m[1])
+		switch fn {
+		case "any", "all", "in", "exists", "not", "and", "or":
+			continue
+		}
+		column := strings.ReplaceAll(m[2], `"`, "")
+		return RewriteSuggestion{
+			Type:    RewriteFunctionColumn,
+			Problem: fmt.Sprintf("%s(%s) is computed for every row, so an index on %s can't be used", fn, column, column),
+			Rewrite: fmt.Sprintf("Compare %s directly, e.g. a range instead of a date function, or add an expression index: CREATE INDEX ON <table> (%s(%s))", column, fn, column),
+		}, true
+	}
+	return RewriteSuggestion{}, false
+}
+
+// suggestNotExists reports NOT IN over a subquery. If the subquery returns a NULL the
+// condition is never true, silently returning no rows, and the planner can't turn it into
+// an anti-join.
+func suggestNotExists(query string) (RewriteSuggestion, bool) {
+	m := notInSubqueryPattern.FindStringSubmatch(query)
+	if m == nil {
+		return RewriteSuggestion{}, false
+	}
+	column := strings.ReplaceAll(m[1], `"`, "")
+	return RewriteSuggestion{
+		Type:    RewriteNotIn,
+		Problem: fmt.Sprintf("%s NOT IN (SELECT ...) returns no rows if the subquery yields a NULL, and can't be planned as an anti-join", column),
+		Rewrite: "Use NOT EXISTS (SELECT 1 FROM ... WHERE ... = " + column + ") instead",
+	}, true
+}
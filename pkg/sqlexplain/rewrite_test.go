@@ -0,0 +1,62 @@
+package sqlexplain
+
+import (
+	"strings"
+	"testing"
+)
+
+// widePlan reads users rows 412 bytes wide
+const widePlan = `[{"Plan": {
+	"Node Type": "Index Scan",
+	"Relation Name": "users",
+	"Index Name": "users_pkey",
+	"Startup Cost": 0.29,
+	"Total Cost": 8.31,
+	"Plan Rows": 1,
+	"Plan Width": 412
+}}]`
+
+func TestSuggestRewrites(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		plan     string
+		expected string // Rewrite type, or empty for no suggestion
+		contains string // Expected in the problem or rewrite
+	}{
+		{"select star on wide rows", "SELECT * FROM users WHERE id = $1", widePlan, RewriteSelectStar, "412-byte rows"},
+		{"qualified select star", "SELECT u.* FROM users u WHERE u.id = $1", widePlan, RewriteSelectStar, "List only the columns"},
+		{"select star on narrow rows", "SELECT * FROM tags WHERE id = $1", strings.ReplaceAll(widePlan, "412", "24"), "", ""},
+		{"select star without a plan", "SELECT * FROM users WHERE id = $1", "", "", ""},
+		{"or across columns", "SELECT id FROM users WHERE email = $1 OR username = $2", "", RewriteOrCondition, "UNION"},
+		{"or on one column", "SELECT id FROM users WHERE status = 'a' OR status = 'b'", "", RewriteOrCondition, "status IN (...)"},
+		{"or alongside an and", "SELECT id FROM orders WHERE tenant_id = $1 AND (status = 'a' OR status = 'b')", "", RewriteOrCondition, "Combine them into status IN (...)"},
+		{"or with is null", "SELECT id FROM users WHERE deleted_at IS NULL OR deleted_at > $1", "", "", ""},
+		{"or inside parentheses across columns", "SELECT id FROM users WHERE tenant_id = $1 AND (email = $2 OR username = $3)", "", RewriteOrCondition, "OR across email, username can't"},
+		{"or inside a literal", "SELECT id FROM users WHERE name = 'this or that'", "", "", ""},
+		{"function on a column", "SELECT id FROM users WHERE lower(email) = $1", "", RewriteFunctionColumn, "CREATE INDEX ON <table> (lower(email))"},
+		{"function on a qualified column", `SELECT id FROM events e WHERE date(e."created_at") >= $1`, "", RewriteFunctionColumn, "date(e.created_at)"},
+		{"function on a parameter", "SELECT id FROM users WHERE email = lower($1)", "", "", ""},
+		{"any over a parameter", "SELECT id FROM users WHERE id = ANY($1)", "", "", ""},
+		{"not in subquery", "SELECT id FROM users WHERE id NOT IN (SELECT user_id FROM bans)", "", RewriteNotIn, "NOT EXISTS"},
+		{"not in list", "SELECT id FROM users WHERE status NOT IN ('banned', 'deleted')", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suggestions := SuggestRewrites(QueryWithPlan{Query: tt.query, ExplainPlan: tt.plan})
+			if tt.expected == "" {
+				if len(suggestions) != 0 {
+					t.Errorf("Expected no suggestions, got %+v", suggestions)
+				}
+				return
+			}
+			if len(suggestions) != 1 || suggestions[0].Type != tt.expected {
+				t.Fatalf("Expected one %s suggestion, got %+v", tt.expected, suggestions)
+			}
+			if s := suggestions[0]; !strings.Contains(s.Problem+" "+s.Rewrite, tt.contains) {
+				t.Errorf("Expected %q in %+v", tt.contains, s)
+			}
+		})
+	}
+}
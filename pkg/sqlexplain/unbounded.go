@@ -0,0 +1,94 @@
+package sqlexplain
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// unboundedRowsThreshold is the number of rows a query without a LIMIT must return to be
+// reported as unbounded
+const unboundedRowsThreshold = 10000
+
+var limitPattern = regexp.MustCompile(`(?i)\bLIMIT\b|\bFETCH\s+(?:FIRST|NEXT)\b`)
+
+// UnboundedQueryIssue is a read returning many rows with no LIMIT, usually an accidental
+// fetch of a whole table that should be paginated
+type UnboundedQueryIssue struct {
+	Query           string  `json:"query"`
+	NormalizedQuery string  `json:"normalizedQuery"`
+	QueriedTable    string  `json:"tableName,omitempty"` // Largest table scanned; empty when unknown
+	EstimatedRows   float64 `json:"estimatedRows"`       // Plan Rows of the top node
+	ActualRows      float64 `json:"actualRows,omitempty"`
+	DurationMS      float64 `json:"durationMs"`
+	Occurrences     int     `json:"occurrences"`
+	Reason          string  `json:"reason"`
+}
+
+// analyzeUnboundedQuery records query when it is a read whose plan returns at least
+// unboundedRowsThreshold rows and neither the plan nor the SQL limits them
+func analyzeUnboundedQuery(plan *ParsedExplainPlan, query QueryWithPlan, issues map[string]*UnboundedQueryIssue) {
+	if !selectPattern.MatchString(query.Query) || writePattern.MatchString(query.Query) {
+		return
+	}
+	if limitPattern.MatchString(stringLiteralPattern.ReplaceAllString(query.Query, "''")) || hasLimitNode(plan) {
+		return
+	}
+	rows := max(plan.PlanRows, plan.ActualRows)
+	if rows < unboundedRowsThreshold {
+		return
+	}
+
+	if issue, ok := issues[query.NormalizedQuery]; ok {
+		issue.Occurrences++
+		issue.DurationMS += query.DurationMS
+		issue.EstimatedRows = max(issue.EstimatedRows, plan.PlanRows)
+		issue.ActualRows = max(issue.ActualRows, plan.ActualRows)
+		return
+	}
+
+	table := largestScan(plan)
+	reason := fmt.Sprintf("Returns about %.0f rows without a LIMIT; paginate with LIMIT and a keyset or OFFSET", rows)
+	if table != "" {
+		reason = fmt.Sprintf("Returns about %.0f rows from %s without a LIMIT; paginate with LIMIT and a keyset or OFFSET", rows, table)
+	}
+	issues[query.NormalizedQuery] = &UnboundedQueryIssue{
+		Query:           query.Query,
+		NormalizedQuery: query.NormalizedQuery,
+		QueriedTable:    table,
+		EstimatedRows:   plan.PlanRows,
+		ActualRows:      plan.ActualRows,
+		DurationMS:      query.DurationMS,
+		Occurrences:     1,
+		Reason:          reason,
+	}
+}
+
+// hasLimitNode reports whether any node of plan is a Limit
+func hasLimitNode(plan *ParsedExplainPlan) bool {
+	if plan.NodeType == "Limit" {
+		return true
+	}
+	for i := range plan.Plans {
+		if hasLimitNode(&plan.Plans[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// largestScan returns the relation of the scan in plan returning the most rows
+func largestScan(plan *ParsedExplainPlan) string {
+	var table string
+	var most float64 = -1
+	var walk func(node *ParsedExplainPlan)
+	walk = func(node *ParsedExplainPlan) {
+		if rows := max(node.PlanRows, node.ActualRows); node.RelationName != "" && rows > most {
+			table, most = node.RelationName, rows
+		}
+		for i := range node.Plans {
+			walk(&node.Plans[i])
+		}
+	}
+	walk(plan)
+	return table
+}
@@ -0,0 +1,70 @@
+package sqlexplain
+
+import (
+	"strings"
+	"testing"
+)
+
+// limitlessSeqScanPlan reads every row of events with nothing limiting the result
+const limitlessSeqScanPlan = `[{"Plan": {
+	"Node Type": "Seq Scan",
+	"Relation Name": "events",
+	"Alias": "events",
+	"Startup Cost": 0.00,
+	"Total Cost": 1834.00,
+	"Plan Rows": 100000,
+	"Plan Width": 96,
+	"Actual Rows": 100000,
+	"Actual Loops": 1
+}}]`
+
+// limitedSeqScanPlan reads the same table under a Limit node
+const limitedSeqScanPlan = `[{"Plan": {
+	"Node Type": "Limit",
+	"Startup Cost": 0.00,
+	"Total Cost": 1.83,
+	"Plan Rows": 100,
+	"Plan Width": 96,
+	"Plans": [
+		{
+			"Node Type": "Seq Scan",
+			"Parent Relationship": "Outer",
+			"Relation Name": "events",
+			"Alias": "events",
+			"Startup Cost": 0.00,
+			"Total Cost": 1834.00,
+			"Plan Rows": 100000,
+			"Plan Width": 96
+		}
+	]
+}}]`
+
+func TestAnalyzeUnboundedQueries(t *testing.T) {
+	const query = "SELECT * FROM events"
+	unbounded := QueryWithPlan{Query: query, NormalizedQuery: query, DurationMS: 40, ExplainPlan: limitlessSeqScanPlan}
+
+	analysis := AnalyzeIndexUsage([]QueryWithPlan{unbounded, unbounded})
+	if len(analysis.Unbounded) != 1 {
+		t.Fatalf("Expected 1 unbounded query, got %+v", analysis.Unbounded)
+	}
+	issue := analysis.Unbounded[0]
+	if issue.QueriedTable != "events" || issue.EstimatedRows != 100000 || issue.Occurrences != 2 || issue.DurationMS != 80 {
+		t.Errorf("Unexpected issue: %+v", issue)
+	}
+	if !strings.Contains(issue.Reason, "100000 rows") || !strings.Contains(issue.Reason, "LIMIT") {
+		t.Errorf("Expected the reason to give the rows and suggest a LIMIT, got %q", issue.Reason)
+	}
+
+	bounded := []QueryWithPlan{
+		{Query: query + " LIMIT 100", NormalizedQuery: query + " LIMIT $1", ExplainPlan: limitlessSeqScanPlan},
+		{Query: query + " FETCH FIRST 10 ROWS ONLY", NormalizedQuery: query + " FETCH FIRST $1 ROWS ONLY", ExplainPlan: limitlessSeqScanPlan},
+		{Query: query, NormalizedQuery: query, ExplainPlan: limitedSeqScanPlan},
+		{Query: "DELETE FROM events", NormalizedQuery: "DELETE FROM events", ExplainPlan: limitlessSeqScanPlan},
+		{Query: query, NormalizedQuery: query, ExplainPlan: strings.ReplaceAll(limitlessSeqScanPlan, "100000", "500")},
+	}
+	for _, q := range bounded {
+		if analysis := AnalyzeIndexUsage([]QueryWithPlan{q}); len(analysis.Unbounded) != 0 {
+			t.Errorf("Expected %q not to be reported, got %+v", q.Query, analysis.Unbounded)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package sqlutil
+
+import (
+	"slices"
+	"strings"
+)
+
+// InferTableAndOperation extracts the primary table and operation from a SQL statement,
+// for logs that lack db.table and db.operation fields. The operation is lowercase like
+// GORM's (select, insert, update or delete) and the table drops any schema prefix and
+// quoting. For a SELECT the primary table is the first one after FROM. Either value is
+// empty when it cannot be determined.
+func InferTableAndOperation(query string) (table, op string) {
+	tokens := topLevelTokens(query)
+
+	start := slices.IndexFunc(tokens, func(tok string) bool {
+		switch strings.ToLower(tok) {
+		case "select", "insert", "update", "delete":
+			return true
+		}
+		return false
+	})
+	if start < 0 {
+		return "", ""
+	}
+	op = strings.ToLower(tokens[start])
+
+	rest := tokens[start+1:]
+	switch op {
+	case "select", "delete":
+		rest = afterKeyword(rest, "from")
+	case "insert":
+		rest = afterKeyword(rest, "into")
+	}
+	if len(rest) > 0 && strings.EqualFold(rest[0], "only") {
+		rest = rest[1:]
+	}
+
+	return qualifiedName(rest), op
+}
+
+// afterKeyword returns the tokens following the first occurrence of keyword
+func afterKeyword(tokens []string, keyword string) []string {
+	for i, tok := range tokens {
+		if strings.EqualFold(tok, keyword) {
+			return tokens[i+1:]
+		}
+	}
+	return nil
+}
+
+// qualifiedName reads a possibly schema-qualified name from the start of tokens and
+// returns its last part without quotes
+func qualifiedName(tokens []string) string {
+	name := ""
+	for i, tok := range tokens {
+		if i%2 == 1 {
+			if tok != "." {
+				break
+			}
+			continue
+		}
+		if !isIdentifier(tok) {
+			return ""
+		}
+		name = unquoteIdentifier(tok)
+	}
+	return name
+}
+
+// isIdentifier reports whether tok is a bare or quoted identifier
+func isIdentifier(tok string) bool {
+	if tok == "" {
+		return false
+	}
+	switch tok[0] {
+	case '"', '`', '[':
+		return true
+	}
+	return isWordByte(tok[0]) && (tok[0] < '0' || tok[0] > '9') && tok[0] != '$'
+}
+
+// isWordByte reports whether c can be part of an unquoted word. Bytes of multibyte
+// UTF-8 characters count, so non-ASCII identifiers stay whole.
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func unquoteIdentifier(tok string) string {
+	if len(tok) >= 2 {
+		switch tok[0] {
+		case '"':
+			return strings.ReplaceAll(tok[1:len(tok)-1], `""`, `"`)
+		case '`':
+			return tok[1 : len(tok)-1]
+		case '[':
+			return tok[1 : len(tok)-1]
+		}
+	}
+	return tok
+}
+
+// topLevelTokens splits query into words, quoted identifiers and punctuation outside
+// any parentheses. Parenthesized groups become a single "(" token, and comments and
+// string literals are dropped, so CTEs and subqueries do not hide the main statement.
+func topLevelTokens(query string) []string {
+	var tokens []string
+	depth := 0
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return tokens
+			}
+			i += end + 1
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return tokens
+			}
+			i += end + 4
+		case c == '\'':
+			i = skipQuoted(query, i, '\'')
+		case c == '(':
+			if depth == 0 {
+				tokens = append(tokens, "(")
+			}
+			depth++
+			i++
+		case c == ')':
+			depth = max(depth-1, 0)
+			i++
+		case c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := skipQuoted(query, i, closing)
+			if depth == 0 {
+				tokens = append(tokens, query[i:end])
+			}
+			i = end
+		case isWordByte(c):
+			end := i
+			for end < len(query) && isWordByte(query[end]) {
+				end++
+			}
+			if depth == 0 {
+				tokens = append(tokens, query[i:end])
+			}
+			i = end
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		default:
+			if depth == 0 {
+				tokens = append(tokens, string(c))
+			}
+			i++
+		}
+	}
+	return tokens
+}
+
+// skipQuoted returns the index just past the quoted section starting at start. A doubled
+// closing character is an escaped one.
+func skipQuoted(query string, start int, closing byte) int {
+	for i := start + 1; i < len(query); i++ {
+		if query[i] != closing {
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == closing && closing != ']' {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(query)
+}
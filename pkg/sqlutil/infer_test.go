@@ -0,0 +1,68 @@
+package sqlutil
+
+import (
+	"testing"
+
+	"docker-log-parser/pkg/logs"
+)
+
+func TestInferTableAndOperation(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		table string
+		op    string
+	}{
+		{"simple select", `SELECT * FROM users WHERE id = $1`, "users", "select"},
+		{"quoted", `SELECT * FROM "users" WHERE "users"."id" = $1`, "users", "select"},
+		{"schema qualified", `SELECT id FROM public.orders`, "orders", "select"},
+		{"quoted schema qualified", `SELECT id FROM "Billing"."Invoice Lines"`, "Invoice Lines", "select"},
+		{"backticks", "SELECT id FROM `app`.`accounts`", "accounts", "select"},
+		{"multi-table join", `SELECT o.id FROM orders o JOIN users u ON u.id = o.user_id`, "orders", "select"},
+		{"comma join", `SELECT * FROM "external_objects" , unnest($1::text[]) previous_id WHERE external_objects.id IN ($2,$3)`, "external_objects", "select"},
+		{"subquery in select list", `SELECT (SELECT count(*) FROM items WHERE items.order_id = orders.id) FROM orders`, "orders", "select"},
+		{"cte", `WITH recent AS (SELECT * FROM events WHERE ts > now()) SELECT * FROM recent_summary JOIN recent USING (id)`, "recent_summary", "select"},
+		{"literal with keywords", `SELECT 'FROM fake' AS x FROM real_table`, "real_table", "select"},
+		{"leading comment", "-- load user\nSELECT * FROM users", "users", "select"},
+		{"insert", `INSERT INTO "audit_log" ("action") VALUES ($1) RETURNING "id"`, "audit_log", "insert"},
+		{"insert select", `INSERT INTO archive SELECT * FROM orders`, "archive", "insert"},
+		{"update", `UPDATE "threads" SET "updated_at"=$1 WHERE id = $2`, "threads", "update"},
+		{"update only", `UPDATE ONLY public.threads SET x = 1`, "threads", "update"},
+		{"delete", `DELETE FROM sessions WHERE expires_at < now()`, "sessions", "delete"},
+		{"lowercase", `select * from users`, "users", "select"},
+		{"select without from", `SELECT 1`, "", "select"},
+		{"subquery in from", `SELECT * FROM (SELECT 1) sub`, "", "select"},
+		{"not a statement", `BEGIN`, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, op := InferTableAndOperation(tt.query)
+			if table != tt.table || op != tt.op {
+				t.Errorf("InferTableAndOperation(%q) = (%q, %q), expected (%q, %q)", tt.query, table, op, tt.table, tt.op)
+			}
+		})
+	}
+}
+
+func TestExtractSQLQueriesInfersMissingFields(t *testing.T) {
+	messages := []logs.ContainerMessage{
+		{Entry: &logs.LogEntry{Message: `[sql]: SELECT * FROM "users" WHERE id = 1`}},
+		// Logged fields win over inference; the empty table is still filled in
+		{Entry: &logs.LogEntry{
+			Message: `[sql]: UPDATE "threads" SET x = 1`,
+			Fields:  map[string]string{"db.operation": "exec", "db.table": ""},
+		}},
+	}
+
+	queries := ExtractSQLQueries(messages)
+	if len(queries) != 2 {
+		t.Fatalf("Expected 2 queries, got %d", len(queries))
+	}
+	if queries[0].QueriedTable != "users" || queries[0].Operation != "select" {
+		t.Errorf("Expected users/select, got %s/%s", queries[0].QueriedTable, queries[0].Operation)
+	}
+	if queries[1].QueriedTable != "threads" || queries[1].Operation != "exec" {
+		t.Errorf("Expected threads/exec, got %s/%s", queries[1].QueriedTable, queries[1].Operation)
+	}
+}
@@ -0,0 +1,88 @@
+package sqlutil
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SQLMarker recognizes SQL statements in log messages. The pattern must capture the
+// statement in a group named sql. An optional duration group is parsed like other logged
+// durations, and an optional seconds group holds a bare number of seconds.
+type SQLMarker struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// SQLMarkerPresets are the built-in markers for common ORMs, keyed by name
+var SQLMarkerPresets = map[string]string{
+	// [sql]: SELECT * FROM users
+	"gorm": `\[sql\]:\s*(?P<sql>.+)`,
+	// (0.001) SELECT "users"."id" FROM "users"; args=(1,); alias=default
+	"django": `^\((?P<seconds>\d+(?:\.\d+)?)\)\s+(?P<sql>.+?)(?:;\s*args=.*)?$`,
+	// User Load (0.5ms)  SELECT "users".* FROM "users" WHERE "users"."id" = $1
+	"activerecord": `^\s*(?:[A-Z][\w:]*(?: [A-Z]\w*\??)*|SQL|CACHE [\w:]+ \w+) \((?P<duration>\d+(?:\.\d+)?ms)\)\s+(?P<sql>.+)$`,
+}
+
+// SQLMarkerPresetNames returns the preset names in sorted order
+func SQLMarkerPresetNames() []string {
+	names := make([]string, 0, len(SQLMarkerPresets))
+	for name := range SQLMarkerPresets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// NewSQLMarker builds a marker from a preset name or, when preset is empty, a custom
+// regex with a named sql group
+func NewSQLMarker(preset, pattern string) (*SQLMarker, error) {
+	name := preset
+	if preset != "" {
+		var ok bool
+		pattern, ok = SQLMarkerPresets[preset]
+		if !ok {
+			return nil, fmt.Errorf("unknown SQL marker preset %q", preset)
+		}
+	} else {
+		name = "custom"
+	}
+
+	if pattern == "" {
+		return nil, fmt.Errorf("SQL marker needs a preset or a pattern")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SQL marker pattern: %w", err)
+	}
+	if re.SubexpIndex("sql") < 0 {
+		return nil, fmt.Errorf("SQL marker pattern must have a named group (?P<sql>...)")
+	}
+
+	return &SQLMarker{Name: name, Pattern: re}, nil
+}
+
+// Match returns the SQL statement in message and its duration in milliseconds, if logged
+func (m *SQLMarker) Match(message string) (sql string, durationMS float64, ok bool) {
+	match := m.Pattern.FindStringSubmatch(message)
+	if match == nil {
+		return "", 0, false
+	}
+
+	sql = strings.TrimSpace(match[m.Pattern.SubexpIndex("sql")])
+	if sql == "" {
+		return "", 0, false
+	}
+
+	if i := m.Pattern.SubexpIndex("duration"); i >= 0 && match[i] != "" {
+		durationMS, _ = ParseDuration(match[i])
+	} else if i := m.Pattern.SubexpIndex("seconds"); i >= 0 && match[i] != "" {
+		if seconds, err := strconv.ParseFloat(match[i], 64); err == nil {
+			durationMS = seconds * 1000
+		}
+	}
+
+	return sql, durationMS, true
+}
@@ -0,0 +1,123 @@
+package sqlutil
+
+import (
+	"strings"
+	"testing"
+
+	"docker-log-parser/pkg/logs"
+)
+
+func TestSQLMarkerPresets(t *testing.T) {
+	tests := []struct {
+		preset     string
+		message    string
+		sql        string
+		durationMS float64
+	}{
+		{
+			preset:  "gorm",
+			message: "[sql]: SELECT * FROM users WHERE id = 1",
+			sql:     "SELECT * FROM users WHERE id = 1",
+		},
+		{
+			preset:     "django",
+			message:    `(0.002) SELECT "auth_user"."id" FROM "auth_user" WHERE "auth_user"."id" = 1; args=(1,); alias=default`,
+			sql:        `SELECT "auth_user"."id" FROM "auth_user" WHERE "auth_user"."id" = 1`,
+			durationMS: 2,
+		},
+		{
+			preset:     "activerecord",
+			message:    `  User Load (0.5ms)  SELECT "users".* FROM "users" WHERE "users"."id" = $1 LIMIT $2`,
+			sql:        `SELECT "users".* FROM "users" WHERE "users"."id" = $1 LIMIT $2`,
+			durationMS: 0.5,
+		},
+		{
+			preset:     "activerecord",
+			message:    `  Admin::Account Exists? (1.2ms)  SELECT 1 AS one FROM "accounts" LIMIT $1`,
+			sql:        `SELECT 1 AS one FROM "accounts" LIMIT $1`,
+			durationMS: 1.2,
+		},
+		{
+			preset:     "activerecord",
+			message:    `  SQL (3.1ms)  UPDATE "users" SET "name" = $1 WHERE "users"."id" = $2`,
+			sql:        `UPDATE "users" SET "name" = $1 WHERE "users"."id" = $2`,
+			durationMS: 3.1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			marker, err := NewSQLMarker(tt.preset, "")
+			if err != nil {
+				t.Fatalf("NewSQLMarker(%q) failed: %v", tt.preset, err)
+			}
+
+			sql, durationMS, ok := marker.Match(tt.message)
+			if !ok {
+				t.Fatalf("Expected %s marker to match %q", tt.preset, tt.message)
+			}
+			if sql != tt.sql {
+				t.Errorf("Expected SQL %q, got %q", tt.sql, sql)
+			}
+			if durationMS != tt.durationMS {
+				t.Errorf("Expected duration %v, got %v", tt.durationMS, durationMS)
+			}
+
+			if _, _, ok := marker.Match("Completed 200 OK in 12ms"); ok {
+				t.Errorf("Expected %s marker not to match a plain log line", tt.preset)
+			}
+		})
+	}
+}
+
+func TestNewSQLMarkerCustomPattern(t *testing.T) {
+	marker, err := NewSQLMarker("", `executing query: (?P<sql>.+) \[(?P<duration>[\d.]+ms)\]`)
+	if err != nil {
+		t.Fatalf("Failed to create custom marker: %v", err)
+	}
+	sql, durationMS, ok := marker.Match("executing query: SELECT 1 [4.5ms]")
+	if !ok || sql != "SELECT 1" || durationMS != 4.5 {
+		t.Errorf("Expected SELECT 1 in 4.5ms, got %q %v %v", sql, durationMS, ok)
+	}
+
+	invalid := []struct{ preset, pattern string }{
+		{"hibernate", ""},
+		{"", ""},
+		{"", `SQL: (.+)`},
+		{"", `(?P<sql>`},
+	}
+	for _, tt := range invalid {
+		if _, err := NewSQLMarker(tt.preset, tt.pattern); err == nil {
+			t.Errorf("Expected error for preset %q pattern %q", tt.preset, tt.pattern)
+		}
+	}
+}
+
+func TestExtractSQLQueriesWithMarkers(t *testing.T) {
+	django, err := NewSQLMarker("django", "")
+	if err != nil {
+		t.Fatalf("Failed to create marker: %v", err)
+	}
+
+	messages := []logs.ContainerMessage{
+		{ContainerID: "web", Entry: &logs.LogEntry{
+			Message: `(0.010) SELECT "orders"."id" FROM "orders"; args=()`,
+			Fields:  map[string]string{"trace_id": "abc"},
+		}},
+		// The built-in format still applies alongside the marker
+		{ContainerID: "web", Entry: &logs.LogEntry{Message: "[sql]: SELECT 1"}},
+		// Other containers are not matched against the marker
+		{ContainerID: "worker", Entry: &logs.LogEntry{Message: `(0.010) SELECT "jobs"."id" FROM "jobs"`}},
+	}
+
+	queries := ExtractSQLQueriesWithMarkers(messages, map[string]*SQLMarker{"web": django})
+	if len(queries) != 2 {
+		t.Fatalf("Expected 2 queries, got %d", len(queries))
+	}
+	if queries[0].Query != `SELECT "orders"."id" FROM "orders"` || queries[0].DurationMS != 10 || queries[0].TraceID != "abc" {
+		t.Errorf("Unexpected django query: %+v", queries[0])
+	}
+	if strings.TrimSpace(queries[1].Query) != "SELECT 1" {
+		t.Errorf("Expected the [sql] query, got %q", queries[1].Query)
+	}
+}
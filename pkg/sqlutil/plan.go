@@ -0,0 +1,70 @@
+package sqlutil
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// planFields are the log fields that can carry a query's JSON EXPLAIN plan
+var planFields = []string{"explain", "plan", "db.explain", "db.plan"}
+
+// planPrefixes are the labels a plan logged on its own line may start with
+var planPrefixes = []string{"plan:", "explain:", "query plan:"}
+
+// ParseLoggedPlan reads a JSON EXPLAIN plan logged by a service, such as Postgres'
+// auto_explain with log_format=json. It accepts the [{"Plan": ...}] array EXPLAIN
+// returns, a single {"Plan": ...} object or a bare plan node, optionally after a "plan:"
+// label, and returns it as the array the viewer stores. ok is false if s is not a plan.
+func ParseLoggedPlan(s string) (plan string, ok bool) {
+	s = strings.TrimSpace(s)
+	lower := strings.ToLower(s)
+	for _, prefix := range planPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			s = strings.TrimSpace(s[len(prefix):])
+			break
+		}
+	}
+	if s == "" || (s[0] != '{' && s[0] != '[') {
+		return "", false
+	}
+
+	var plans []map[string]any
+	if err := json.Unmarshal([]byte(s), &plans); err != nil {
+		var single map[string]any
+		if err := json.Unmarshal([]byte(s), &single); err != nil {
+			return "", false
+		}
+		plans = []map[string]any{single}
+	}
+	if len(plans) == 0 {
+		return "", false
+	}
+
+	for i, p := range plans {
+		if _, isNode := p["Node Type"]; isNode {
+			plans[i] = map[string]any{"Plan": p}
+		}
+		node, _ := plans[i]["Plan"].(map[string]any)
+		if _, isNode := node["Node Type"].(string); !isNode {
+			return "", false
+		}
+	}
+
+	normalized, err := json.Marshal(plans)
+	if err != nil {
+		return "", false
+	}
+	return string(normalized), true
+}
+
+// loggedPlanField returns the plan in one of the planFields, and the field it came from
+func loggedPlanField(fields map[string]string) (plan, field string) {
+	for _, field := range planFields {
+		if v, ok := fields[field]; ok {
+			if plan, ok := ParseLoggedPlan(v); ok {
+				return plan, field
+			}
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,81 @@
+package sqlutil
+
+import (
+	"strings"
+	"testing"
+
+	"docker-log-parser/pkg/logs"
+)
+
+// inlinePlan is a plan as auto_explain logs it with log_format=json: a single object
+// rather than the array EXPLAIN returns
+const inlinePlan = `{"Query Text": "SELECT * FROM users WHERE email = $1", "Plan": {"Node Type": "Seq Scan", "Relation Name": "users", "Startup Cost": 0.00, "Total Cost": 1834.00, "Plan Rows": 1, "Plan Width": 96, "Filter": "(email = $1)"}}`
+
+func TestParseLoggedPlan(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		ok    bool
+	}{
+		{"plan object", inlinePlan, true},
+		{"plan array", "[" + inlinePlan + "]", true},
+		{"bare plan node", `{"Node Type": "Seq Scan", "Relation Name": "users"}`, true},
+		{"labelled plan", "plan: " + inlinePlan, true},
+		{"JSON without a plan", `{"user": "ada"}`, false},
+		{"empty array", `[]`, false},
+		{"text plan", "Seq Scan on users  (cost=0.00..1834.00 rows=1 width=96)", false},
+		{"log message", "request finished", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, ok := ParseLoggedPlan(tt.input)
+			if ok != tt.ok {
+				t.Fatalf("Expected ok=%v, got %v (%s)", tt.ok, ok, plan)
+			}
+			if ok && (!strings.HasPrefix(plan, `[{`) || !strings.Contains(plan, `"Plan":{`) || !strings.Contains(plan, `"Node Type":"Seq Scan"`)) {
+				t.Errorf("Expected a plan array, got %s", plan)
+			}
+		})
+	}
+}
+
+func TestExtractSQLQueriesLoggedPlans(t *testing.T) {
+	query := func(container, sql string, fields map[string]string) logs.ContainerMessage {
+		if fields == nil {
+			fields = map[string]string{}
+		}
+		return logs.ContainerMessage{ContainerID: container, Entry: &logs.LogEntry{Message: "[sql]: " + sql, Fields: fields}}
+	}
+	line := func(container, message string) logs.ContainerMessage {
+		return logs.ContainerMessage{ContainerID: container, Entry: &logs.LogEntry{Message: message}}
+	}
+
+	queries := ExtractSQLQueries([]logs.ContainerMessage{
+		query("api", "SELECT * FROM users WHERE email = $1", map[string]string{"plan": inlinePlan, "db.table": "users"}),
+		query("api", "SELECT * FROM orders", nil),
+		line("worker", "unrelated line"),
+		line("api", "plan: "+inlinePlan),
+		query("api", "SELECT * FROM posts", nil),
+		line("api", "request finished"),
+		line("api", inlinePlan),
+		query("worker", "SELECT 1; SELECT 2", nil),
+		line("worker", inlinePlan),
+	})
+
+	if len(queries) != 5 {
+		t.Fatalf("Expected 5 queries, got %d", len(queries))
+	}
+	if queries[0].ExplainPlan == "" || strings.Contains(queries[0].LogFields, "Seq Scan") {
+		t.Errorf("Expected the plan field to become the explain plan, got plan %q and fields %q", queries[0].ExplainPlan, queries[0].LogFields)
+	}
+	if queries[1].ExplainPlan == "" {
+		t.Error("Expected the container's next line to be the plan of its last query")
+	}
+	if queries[2].ExplainPlan != "" {
+		t.Errorf("Expected a plan after an unrelated line to be ignored, got %q", queries[2].ExplainPlan)
+	}
+	if queries[3].ExplainPlan != "" || queries[4].ExplainPlan != "" {
+		t.Error("Expected a plan after a batch of statements to be ignored")
+	}
+}
@@ -0,0 +1,155 @@
+package sqlutil
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"docker-log-parser/pkg/store"
+	"docker-log-parser/pkg/utils"
+)
+
+// How the logged duration of a multi-statement line is spread over its statements
+const (
+	StatementDurationDivide = "divide" // Each statement gets an equal share
+	StatementDurationFirst  = "first"  // The first statement gets all of it, the rest zero
+)
+
+var (
+	statementDurationMode      = StatementDurationDivide
+	statementDurationModeMutex sync.RWMutex
+)
+
+// SetStatementDurationMode sets how durations are attributed when a log line holds
+// several statements. An empty mode restores StatementDurationDivide.
+func SetStatementDurationMode(mode string) error {
+	if mode == "" {
+		mode = StatementDurationDivide
+	}
+	if mode != StatementDurationDivide && mode != StatementDurationFirst {
+		return fmt.Errorf("unknown statement duration mode %q, expected %s or %s", mode, StatementDurationDivide, StatementDurationFirst)
+	}
+
+	statementDurationModeMutex.Lock()
+	defer statementDurationModeMutex.Unlock()
+	statementDurationMode = mode
+	return nil
+}
+
+// StatementDurationMode returns how durations are attributed to multiple statements
+func StatementDurationMode() string {
+	statementDurationModeMutex.RLock()
+	defer statementDurationModeMutex.RUnlock()
+	return statementDurationMode
+}
+
+// SplitStatements splits sql on the semicolons that end statements. Semicolons inside
+// string literals, quoted identifiers, dollar-quoted bodies, comments and parentheses
+// are left alone. Statements are trimmed and empty ones dropped.
+func SplitStatements(sql string) []string {
+	var statements []string
+	start, depth := 0, 0
+
+	add := func(end int) {
+		if stmt := strings.TrimSpace(sql[start:end]); stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 1
+			}
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 4
+			}
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(sql, i, c)
+		case c == '$' && (i == 0 || !isWordByte(sql[i-1])):
+			i = skipDollarQuoted(sql, i)
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			depth = max(depth-1, 0)
+			i++
+		case c == ';' && depth == 0:
+			add(i)
+			i++
+			start = i
+		default:
+			i++
+		}
+	}
+	add(len(sql))
+
+	return statements
+}
+
+// skipDollarQuoted returns the index past a $tag$...$tag$ body starting at start, or
+// just past the $ when it does not open one, as with a $1 placeholder
+func skipDollarQuoted(sql string, start int) int {
+	tagEnd := start + 1
+	for tagEnd < len(sql) && sql[tagEnd] != '$' {
+		if !isWordByte(sql[tagEnd]) || (sql[tagEnd] >= '0' && sql[tagEnd] <= '9') {
+			return start + 1
+		}
+		tagEnd++
+	}
+	if tagEnd >= len(sql) {
+		return start + 1
+	}
+
+	tag := sql[start : tagEnd+1]
+	end := strings.Index(sql[tagEnd+1:], tag)
+	if end < 0 {
+		return len(sql)
+	}
+	return tagEnd + 1 + end + len(tag)
+}
+
+// splitQuery expands a query whose text holds several statements into one query per
+// statement, each normalized and timed separately. Table and operation are inferred per
+// statement, keeping the logged ones where inference finds nothing.
+func splitQuery(query store.SQLQuery) []store.SQLQuery {
+	statements := SplitStatements(query.Query)
+	if len(statements) <= 1 {
+		fillMissingTableAndOperation(&query)
+		return []store.SQLQuery{query}
+	}
+
+	divide := StatementDurationMode() == StatementDurationDivide
+	queries := make([]store.SQLQuery, 0, len(statements))
+	for i, stmt := range statements {
+		q := query
+		q.Query = stmt
+		q.NormalizedQuery = utils.NormalizeQuery(stmt)
+		q.QueryHash = store.ComputeQueryHash(q.NormalizedQuery)
+		q.ExplainPlan = "" // A logged plan covers one statement, and which is unknown
+		if divide {
+			q.DurationMS = query.DurationMS / float64(len(statements))
+		} else if i > 0 {
+			q.DurationMS = 0
+		}
+
+		table, op := InferTableAndOperation(stmt)
+		if table != "" {
+			q.QueriedTable = table
+		}
+		if op != "" {
+			q.Operation = op
+		}
+		queries = append(queries, q)
+	}
+	return queries
+}
@@ -0,0 +1,71 @@
+package sqlutil
+
+import (
+	"slices"
+	"testing"
+
+	"docker-log-parser/pkg/logs"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected []string
+	}{
+		{"single", "SELECT 1", []string{"SELECT 1"}},
+		{"trailing semicolon", "SELECT 1;", []string{"SELECT 1"}},
+		{"two statements", "SELECT 1; SELECT 2", []string{"SELECT 1", "SELECT 2"}},
+		{"semicolon in string", "INSERT INTO notes (body) VALUES ('a; b'); SELECT 2", []string{"INSERT INTO notes (body) VALUES ('a; b')", "SELECT 2"}},
+		{"escaped quote in string", "SELECT 'it''s; fine'; SELECT 2", []string{"SELECT 'it''s; fine'", "SELECT 2"}},
+		{"semicolon in quoted identifier", `SELECT "a;b" FROM t; SELECT 2`, []string{`SELECT "a;b" FROM t`, "SELECT 2"}},
+		{"semicolon in dollar quotes", "DO $$ BEGIN PERFORM 1; END $$; SELECT 2", []string{"DO $$ BEGIN PERFORM 1; END $$", "SELECT 2"}},
+		{"placeholders are not dollar quotes", "UPDATE t SET a = $1 WHERE id = $2; SELECT $1", []string{"UPDATE t SET a = $1 WHERE id = $2", "SELECT $1"}},
+		{"semicolon in comment", "SELECT 1 -- one; two\n; SELECT 2", []string{"SELECT 1 -- one; two", "SELECT 2"}},
+		{"empty statements", " ; ;SELECT 1;; ", []string{"SELECT 1"}},
+		{"empty", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitStatements(tt.sql); !slices.Equal(got, tt.expected) {
+				t.Errorf("SplitStatements(%q) = %q, expected %q", tt.sql, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExtractSQLQueriesSplitsStatements(t *testing.T) {
+	messages := []logs.ContainerMessage{{Entry: &logs.LogEntry{
+		Message: "[sql]: UPDATE users SET name = 'a;b' WHERE id = 1; SELECT * FROM orders WHERE user_id = 1",
+		Fields:  map[string]string{"duration": "10", "trace_id": "abc"},
+	}}}
+
+	queries := ExtractSQLQueries(messages)
+	if len(queries) != 2 {
+		t.Fatalf("Expected 2 queries, got %d", len(queries))
+	}
+	if queries[0].QueriedTable != "users" || queries[0].Operation != "update" || queries[1].QueriedTable != "orders" {
+		t.Errorf("Expected users/update then orders, got %s/%s and %s", queries[0].QueriedTable, queries[0].Operation, queries[1].QueriedTable)
+	}
+	if queries[0].QueryHash == queries[1].QueryHash || queries[1].TraceID != "abc" {
+		t.Errorf("Expected separate hashes sharing the trace ID, got %+v", queries)
+	}
+	if queries[0].DurationMS != 5 || queries[1].DurationMS != 5 {
+		t.Errorf("Expected the duration divided evenly, got %v and %v", queries[0].DurationMS, queries[1].DurationMS)
+	}
+
+	if err := SetStatementDurationMode(StatementDurationFirst); err != nil {
+		t.Fatalf("Failed to set duration mode: %v", err)
+	}
+	defer SetStatementDurationMode("")
+
+	queries = ExtractSQLQueries(messages)
+	if queries[0].DurationMS != 10 || queries[1].DurationMS != 0 {
+		t.Errorf("Expected the duration on the first statement, got %v and %v", queries[0].DurationMS, queries[1].DurationMS)
+	}
+
+	if err := SetStatementDurationMode("proportional"); err == nil {
+		t.Error("Expected an unknown mode to be rejected")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"docker-log-parser/pkg/logs"
 	"docker-log-parser/pkg/sqlexplain"
@@ -18,137 +19,242 @@ import (
 	"docker-log-parser/pkg/utils"
 )
 
+// ParseDuration parses a logged duration into milliseconds. Plain numbers are already
+// milliseconds; values with unit suffixes such as "1.2s", "340ms" or "1m30s" are converted.
+func ParseDuration(s string) (float64, error) {
+	ms, ok := logs.ParseDurationMS(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return ms, nil
+}
+
 // ExtractSQLQueries extracts SQL queries from log messages and returns them as store.SQLQuery objects
 func ExtractSQLQueries(logMessages []logs.ContainerMessage) []store.SQLQuery {
-	queries := []store.SQLQuery{}
+	return ExtractSQLQueriesWithMarkers(logMessages, nil)
+}
+
+// ExtractSQLQueriesWithMarkers is ExtractSQLQueries with per-container SQL markers, keyed
+// by container ID. Messages from a container with a marker are matched against it first;
+// the built-in [sql] and [query] formats still apply to messages it does not match.
+//
+// A query's EXPLAIN plan is taken from an explain or plan field on its line, or from a
+// JSON plan logged as the container's next line (see ParseLoggedPlan).
+func ExtractSQLQueriesWithMarkers(logMessages []logs.ContainerMessage, markers map[string]*SQLMarker) []store.SQLQuery {
+	queries, _ := ExtractSQLQueriesAwaitingPlans(logMessages, markers)
+	return queries
+}
+
+// ExtractSQLQueriesAwaitingPlans is ExtractSQLQueriesWithMarkers for logs that arrive in
+// batches. It also returns, by container ID, the index of the query whose plan could
+// still be the container's next line, which may come in the next batch.
+func ExtractSQLQueriesAwaitingPlans(logMessages []logs.ContainerMessage, markers map[string]*SQLMarker) (queries []store.SQLQuery, awaitingPlan map[string]int) {
+	queries = []store.SQLQuery{}
+	// The index of each container's last query while its next line could be that
+	// query's plan
+	awaitingPlan = make(map[string]int)
 
 	for _, msg := range logMessages {
 		if msg.Entry == nil || msg.Entry.Message == "" {
 			continue
 		}
 
-		message := msg.Entry.Message
-		// Check for [sql] or [query] format
-		if strings.Contains(message, "[sql]") || (msg.Entry.Fields != nil && msg.Entry.Fields["type"] == "query") {
-			var sqlText string
-			var normalizedQuery string
-			var query store.SQLQuery
-
-			// Handle [sql] format
-			index := strings.Index(message, "[sql]:")
-			if index != -1 {
-				message = message[index+6:]
-				normalizedQuery = utils.NormalizeQuery(message)
-				query = store.SQLQuery{
-					Query:           message,
-					NormalizedQuery: normalizedQuery,
-					QueryHash:       store.ComputeQueryHash(normalizedQuery),
-					ContainerID:     msg.ContainerID,
-				}
-			} else if msg.Entry.Fields != nil && msg.Entry.Fields["type"] == "query" {
-				// Handle [query] format - message is the SQL query
-				sqlText = message
-				normalizedQuery = utils.NormalizeQuery(sqlText)
-				query = store.SQLQuery{
-					Query:           sqlText,
-					NormalizedQuery: normalizedQuery,
-					QueryHash:       store.ComputeQueryHash(normalizedQuery),
-					ContainerID:     msg.ContainerID,
-				}
-
-				// Extract duration and rows from fields
-				if duration, ok := msg.Entry.Fields["duration_ms"]; ok {
-					if durationVal, err := strconv.ParseFloat(duration, 64); err == nil {
-						query.DurationMS = durationVal
-					}
-				}
-				if rows, ok := msg.Entry.Fields["rows"]; ok {
-					if rowsVal, err := strconv.Atoi(rows); err == nil {
-						query.Rows = rowsVal
-					}
-				}
-			} else {
+		if i, ok := awaitingPlan[msg.ContainerID]; ok {
+			delete(awaitingPlan, msg.ContainerID)
+			if plan, ok := ParseLoggedPlan(msg.Entry.Message); ok {
+				queries[i].ExplainPlan = plan
 				continue
 			}
+		}
+		before := len(queries)
+		queries = extractMessageQueries(queries, msg, markers)
+		// A plan can't be matched to one statement of a batch
+		if len(queries) == before+1 && queries[before].ExplainPlan == "" {
+			awaitingPlan[msg.ContainerID] = before
+		}
+	}
 
-			if msg.Entry.Fields != nil {
-				// These apply to both [sql] and [query] formats
-				excludedFields := map[string]bool{}
-
-				if duration, ok := msg.Entry.Fields["duration"]; ok {
-					var durationVal float64
-					if _, err := strconv.ParseFloat(duration, 64); err == nil {
-						durationVal, _ = strconv.ParseFloat(duration, 64)
-						query.DurationMS = durationVal
-						excludedFields["duration"] = true
-					}
-				}
-				if table, ok := msg.Entry.Fields["db.table"]; ok {
-					query.QueriedTable = table
-					excludedFields["db.table"] = true
-				}
-				if op, ok := msg.Entry.Fields["db.operation"]; ok {
-					query.Operation = op
-					excludedFields["db.operation"] = true
-				}
-				if rows, ok := msg.Entry.Fields["db.rows"]; ok {
-					var rowsVal int
-					if _, err := strconv.Atoi(rows); err == nil {
-						rowsVal, _ = strconv.Atoi(rows)
-						query.Rows = rowsVal
-						excludedFields["db.rows"] = true
-					}
-				}
-				// Store db.vars as JSON for later use in EXPLAIN
-				if vars, ok := msg.Entry.Fields["db.vars"]; ok {
-					query.Variables = vars
-					excludedFields["db.vars"] = true
-				}
-
-				for _, k := range []string{"gql.operation", "gql.operationName", "graphql.operation", "graphql.operation.name"} {
-					if _, ok := msg.Entry.Fields[k]; ok {
-						query.GraphQLOperation = msg.Entry.Fields[k]
-						excludedFields[k] = true
-						break
-					}
-				}
+	return queries, awaitingPlan
+}
 
-				// Extract trace/request/span IDs
-				if requestID, ok := msg.Entry.Fields["request_id"]; ok {
-					query.LogRequestID = requestID
-					excludedFields["request_id"] = true
-				}
-				if spanID, ok := msg.Entry.Fields["span_id"]; ok {
-					query.SpanID = spanID
-					excludedFields["span_id"] = true
-				}
-				if traceID, ok := msg.Entry.Fields["trace_id"]; ok {
-					query.TraceID = traceID
-					excludedFields["trace_id"] = true
-				}
+// extractMessageQueries appends the queries logged in msg to queries
+func extractMessageQueries(queries []store.SQLQuery, msg logs.ContainerMessage, markers map[string]*SQLMarker) []store.SQLQuery {
+	message := msg.Entry.Message
+	if marker := markers[msg.ContainerID]; marker != nil {
+		if sqlText, durationMS, ok := marker.Match(message); ok {
+			normalizedQuery := utils.NormalizeQuery(sqlText)
+			query := store.SQLQuery{
+				Query:           sqlText,
+				NormalizedQuery: normalizedQuery,
+				QueryHash:       store.ComputeQueryHash(normalizedQuery),
+				ContainerID:     msg.ContainerID,
+				DurationMS:      durationMS,
+			}
+			applyLogFields(&query, msg.Entry.Fields)
+			query.LoggedAt = loggedAt(msg)
+			queries = append(queries, splitQuery(query)...)
+			return queries
+		}
+	}
 
-				// Store all other log fields as JSON for reference
-				otherFields := make(map[string]string)
+	// Check for [sql] or [query] format
+	if strings.Contains(message, "[sql]") || (msg.Entry.Fields != nil && msg.Entry.Fields["type"] == "query") {
+		var sqlText string
+		var normalizedQuery string
+		var query store.SQLQuery
+
+		// Handle [sql] format
+		index := strings.Index(message, "[sql]:")
+		if index != -1 {
+			message = message[index+6:]
+			normalizedQuery = utils.NormalizeQuery(message)
+			query = store.SQLQuery{
+				Query:           message,
+				NormalizedQuery: normalizedQuery,
+				QueryHash:       store.ComputeQueryHash(normalizedQuery),
+				ContainerID:     msg.ContainerID,
+			}
+		} else if msg.Entry.Fields != nil && msg.Entry.Fields["type"] == "query" {
+			// Handle [query] format - message is the SQL query
+			sqlText = message
+			normalizedQuery = utils.NormalizeQuery(sqlText)
+			query = store.SQLQuery{
+				Query:           sqlText,
+				NormalizedQuery: normalizedQuery,
+				QueryHash:       store.ComputeQueryHash(normalizedQuery),
+				ContainerID:     msg.ContainerID,
+			}
 
-				for k, v := range msg.Entry.Fields {
-					if !excludedFields[k] {
-						otherFields[k] = v
-					}
+			// Extract duration and rows from fields
+			if duration, ok := msg.Entry.Fields["duration_ms"]; ok {
+				if durationVal, err := ParseDuration(duration); err == nil {
+					query.DurationMS = durationVal
 				}
-				if len(otherFields) > 0 {
-					if fieldsJSON, err := json.Marshal(otherFields); err == nil {
-						query.LogFields = string(fieldsJSON)
-					}
+			}
+			if rows, ok := msg.Entry.Fields["rows"]; ok {
+				if rowsVal, err := strconv.Atoi(rows); err == nil {
+					query.Rows = rowsVal
 				}
 			}
-
-			queries = append(queries, query)
+		} else {
+			return queries
 		}
+
+		// These apply to both [sql] and [query] formats
+		applyLogFields(&query, msg.Entry.Fields)
+		query.LoggedAt = loggedAt(msg)
+
+		// A line can batch several statements; each is counted on its own
+		queries = append(queries, splitQuery(query)...)
 	}
 
 	return queries
 }
 
+// loggedAt returns when msg was logged, or nil if its timestamp is unknown
+func loggedAt(msg logs.ContainerMessage) *time.Time {
+	if msg.Timestamp.IsZero() {
+		return nil
+	}
+	t := msg.Timestamp
+	return &t
+}
+
+// fillMissingTableAndOperation infers the table and operation from the SQL text when
+// the log fields did not supply them
+func fillMissingTableAndOperation(query *store.SQLQuery) {
+	if query.QueriedTable != "" && query.Operation != "" {
+		return
+	}
+
+	table, op := InferTableAndOperation(query.Query)
+	if query.QueriedTable == "" {
+		query.QueriedTable = table
+	}
+	if query.Operation == "" {
+		query.Operation = op
+	}
+}
+
+// applyLogFields copies durations, table, operation, rows, variables, plans and trace IDs
+// from log fields onto query and keeps the remaining fields as JSON for reference
+func applyLogFields(query *store.SQLQuery, fields map[string]string) {
+	if fields == nil {
+		return
+	}
+
+	excludedFields := map[string]bool{}
+
+	if duration, ok := fields["duration"]; ok {
+		if durationVal, err := ParseDuration(duration); err == nil {
+			query.DurationMS = durationVal
+			excludedFields["duration"] = true
+		}
+	}
+	if table, ok := fields["db.table"]; ok {
+		query.QueriedTable = table
+		excludedFields["db.table"] = true
+	}
+	if op, ok := fields["db.operation"]; ok {
+		query.Operation = op
+		excludedFields["db.operation"] = true
+	}
+	if rows, ok := fields["db.rows"]; ok {
+		var rowsVal int
+		if _, err := strconv.Atoi(rows); err == nil {
+			rowsVal, _ = strconv.Atoi(rows)
+			query.Rows = rowsVal
+			excludedFields["db.rows"] = true
+		}
+	}
+	// Store db.vars as JSON for later use in EXPLAIN
+	if vars, ok := fields["db.vars"]; ok {
+		query.Variables = vars
+		excludedFields["db.vars"] = true
+	}
+	// A plan logged with the query saves running EXPLAIN against the database
+	if plan, field := loggedPlanField(fields); plan != "" {
+		query.ExplainPlan = plan
+		excludedFields[field] = true
+	}
+
+	for _, k := range []string{"gql.operation", "gql.operationName", "graphql.operation", "graphql.operation.name"} {
+		if _, ok := fields[k]; ok {
+			query.GraphQLOperation = fields[k]
+			excludedFields[k] = true
+			break
+		}
+	}
+
+	// Extract trace/request/span IDs
+	if requestID, ok := fields["request_id"]; ok {
+		query.LogRequestID = requestID
+		excludedFields["request_id"] = true
+	}
+	if spanID, ok := fields["span_id"]; ok {
+		query.SpanID = spanID
+		excludedFields["span_id"] = true
+	}
+	if traceID, ok := fields["trace_id"]; ok {
+		query.TraceID = traceID
+		excludedFields["trace_id"] = true
+	}
+
+	// Store all other log fields as JSON for reference
+	otherFields := make(map[string]string)
+
+	for k, v := range fields {
+		if !excludedFields[k] {
+			otherFields[k] = v
+		}
+	}
+	if len(otherFields) > 0 {
+		if fieldsJSON, err := json.Marshal(otherFields); err == nil {
+			query.LogFields = string(fieldsJSON)
+		}
+	}
+}
+
 // InterpolateSQLQuery replaces placeholder variables in a SQL query with their actual values
 func InterpolateSQLQuery(query string, variables any) string {
 	if variables == nil {
@@ -1,6 +1,7 @@
 package sqlutil
 
 import (
+	"math"
 	"testing"
 
 	"docker-log-parser/pkg/logs"
@@ -67,6 +68,54 @@ func TestExtractSQLQueries(t *testing.T) {
 	}
 }
 
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+		wantErr  bool
+	}{
+		{"68.405061", 68.405061, false},
+		{"340ms", 340, false},
+		{"340 ms", 340, false},
+		{"1.2s", 1200, false},
+		{"1m30s", 90000, false},
+		{"500us", 0.5, false},
+		{"", 0, true},
+		{"fast", 0, true},
+		{"NaN", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDuration(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDuration(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("ParseDuration(%q) = %v, expected %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExtractSQLQueriesDurationUnits(t *testing.T) {
+	messages := []logs.ContainerMessage{
+		{Entry: &logs.LogEntry{Message: "[sql]: SELECT * FROM users", Fields: map[string]string{"duration": "1.2s"}}},
+		{Entry: &logs.LogEntry{Message: "[sql]: SELECT * FROM posts", Fields: map[string]string{"duration": "340ms"}}},
+		{Entry: &logs.LogEntry{Message: "SELECT * FROM tags", Fields: map[string]string{"type": "query", "duration_ms": "12.5"}}},
+	}
+
+	queries := ExtractSQLQueries(messages)
+	if len(queries) != 3 {
+		t.Fatalf("Expected 3 queries, got %d", len(queries))
+	}
+	for i, expected := range []float64{1200, 340, 12.5} {
+		if queries[i].DurationMS != expected {
+			t.Errorf("Query %d: expected %vms, got %vms", i, expected, queries[i].DurationMS)
+		}
+	}
+}
+
 func TestInterpolateSQLQuery(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE field_formats (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    field_name TEXT NOT NULL UNIQUE,
+    format TEXT NOT NULL CHECK (format IN ('duration', 'bytes', 'timestamp')),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_field_formats_field_name ON field_formats(field_name);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_field_formats_field_name;
+DROP TABLE IF EXISTS field_formats;
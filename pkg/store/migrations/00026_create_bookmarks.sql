@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE bookmarks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    container_name TEXT NOT NULL,
+    timestamp DATETIME NOT NULL,
+    message_hash TEXT NOT NULL,
+    trace_id TEXT,
+    message TEXT,
+    note TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_bookmarks_trace_id ON bookmarks(trace_id);
+CREATE INDEX idx_bookmarks_timestamp ON bookmarks(timestamp);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_bookmarks_timestamp;
+DROP INDEX IF EXISTS idx_bookmarks_trace_id;
+DROP TABLE IF EXISTS bookmarks;
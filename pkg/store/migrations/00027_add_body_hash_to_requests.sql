@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE requests ADD COLUMN body_hash TEXT;
+
+CREATE INDEX idx_executed_requests_body_hash ON requests(body_hash);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_executed_requests_body_hash;
+ALTER TABLE requests DROP COLUMN body_hash;
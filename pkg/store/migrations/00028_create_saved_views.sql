@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE saved_views (
+    id TEXT PRIMARY KEY,
+    filter TEXT NOT NULL,
+    start_time DATETIME,
+    end_time DATETIME,
+    expires_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_saved_views_expires_at ON saved_views(expires_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_saved_views_expires_at;
+DROP TABLE IF EXISTS saved_views;
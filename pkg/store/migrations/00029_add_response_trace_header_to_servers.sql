@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE servers ADD COLUMN response_trace_header TEXT;
+
+-- +goose Down
+ALTER TABLE servers DROP COLUMN response_trace_header;
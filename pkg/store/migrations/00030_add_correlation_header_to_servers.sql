@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE servers ADD COLUMN correlation_header TEXT;
+ALTER TABLE servers ADD COLUMN correlation_format TEXT;
+
+-- +goose Down
+ALTER TABLE servers DROP COLUMN correlation_format;
+ALTER TABLE servers DROP COLUMN correlation_header;
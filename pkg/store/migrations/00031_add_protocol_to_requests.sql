@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE requests ADD COLUMN protocol TEXT;
+
+-- +goose Down
+ALTER TABLE requests DROP COLUMN protocol;
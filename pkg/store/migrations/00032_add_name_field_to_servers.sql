@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE servers ADD COLUMN name_field TEXT;
+
+-- +goose Down
+ALTER TABLE servers DROP COLUMN name_field;
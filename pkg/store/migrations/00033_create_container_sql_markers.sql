@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE container_sql_markers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    container_name TEXT NOT NULL UNIQUE,
+    preset TEXT,
+    pattern TEXT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS container_sql_markers;
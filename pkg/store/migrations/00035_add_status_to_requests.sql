@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE requests ADD COLUMN status TEXT NOT NULL DEFAULT 'pending';
+
+-- Nothing is executing while migrations run, so a request without a response never got one
+UPDATE requests SET status = CASE
+    WHEN cancelled THEN 'cancelled'
+    WHEN error IS NOT NULL AND error != '' THEN 'failed'
+    WHEN status_code = 0 THEN 'failed'
+    ELSE 'completed'
+END;
+
+ALTER TABLE requests DROP COLUMN cancelled;
+CREATE INDEX idx_requests_status ON requests(status);
+
+-- +goose Down
+ALTER TABLE requests ADD COLUMN cancelled BOOLEAN NOT NULL DEFAULT 0;
+UPDATE requests SET cancelled = 1 WHERE status = 'cancelled';
+DROP INDEX idx_requests_status;
+ALTER TABLE requests DROP COLUMN status;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE servers ADD COLUMN graphql_schema TEXT;
+ALTER TABLE servers ADD COLUMN graphql_schema_fetched_at DATETIME;
+
+-- +goose Down
+ALTER TABLE servers DROP COLUMN graphql_schema_fetched_at;
+ALTER TABLE servers DROP COLUMN graphql_schema;
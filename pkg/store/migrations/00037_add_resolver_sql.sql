@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE request_sql_statements ADD COLUMN logged_at DATETIME;
+ALTER TABLE requests ADD COLUMN resolver_sql TEXT;
+
+-- +goose Down
+ALTER TABLE requests DROP COLUMN resolver_sql;
+ALTER TABLE request_sql_statements DROP COLUMN logged_at;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE servers ADD COLUMN build_version_header TEXT;
+ALTER TABLE servers ADD COLUMN build_version TEXT;
+ALTER TABLE requests ADD COLUMN build_version TEXT;
+
+-- +goose Down
+ALTER TABLE requests DROP COLUMN build_version;
+ALTER TABLE servers DROP COLUMN build_version;
+ALTER TABLE servers DROP COLUMN build_version_header;
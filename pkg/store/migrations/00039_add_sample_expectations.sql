@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE sample_requests ADD COLUMN max_queries INTEGER;
+ALTER TABLE sample_requests ADD COLUMN max_duration_ms INTEGER;
+ALTER TABLE requests ADD COLUMN expectations_met BOOLEAN;
+ALTER TABLE requests ADD COLUMN expectation_violation TEXT;
+
+-- +goose Down
+ALTER TABLE requests DROP COLUMN expectation_violation;
+ALTER TABLE requests DROP COLUMN expectations_met;
+ALTER TABLE sample_requests DROP COLUMN max_duration_ms;
+ALTER TABLE sample_requests DROP COLUMN max_queries;
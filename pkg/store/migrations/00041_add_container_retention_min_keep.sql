@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE container_retention ADD COLUMN min_keep INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE container_retention DROP COLUMN min_keep;
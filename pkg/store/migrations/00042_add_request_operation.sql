@@ -0,0 +1,8 @@
+-- +goose Up
+-- Filled with each request's display name when it is created, and for older requests at startup
+ALTER TABLE requests ADD COLUMN operation TEXT;
+CREATE INDEX idx_requests_operation ON requests(operation, executed_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_requests_operation;
+ALTER TABLE requests DROP COLUMN operation;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- The URL each request was sent to, which differs from its server's with a URL override.
+-- Older requests have none and are named from their server's URL.
+ALTER TABLE requests ADD COLUMN url TEXT;
+
+-- +goose Down
+ALTER TABLE requests DROP COLUMN url;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- NormalizeQuery now lexes queries, which changed how some literals normalize, so
+-- stored hashes are cleared and recomputed from each query at startup
+UPDATE request_sql_statements SET query_hash = NULL;
+
+-- +goose Down
+-- The previous normalization can't be restored; the recomputed hashes are kept
@@ -1,17 +1,25 @@
 package store
 
 import (
+	"cmp"
+	"context"
+	"crypto/rand"
 	"crypto/sha256"
 	"embed"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"docker-log-parser/pkg/httputil"
 	"docker-log-parser/pkg/logs"
 	"docker-log-parser/pkg/sqlexplain"
+	"docker-log-parser/pkg/utils"
 
 	"github.com/pressly/goose/v3"
 	"gorm.io/driver/sqlite"
@@ -21,9 +29,31 @@ import (
 //go:embed migrations/*.sql
 var embedMigrations embed.FS
 
+// DefaultLogBatchSize is the number of execution log rows SaveRequestLogs inserts per
+// statement
+const DefaultLogBatchSize = 500
+
 // Store manages the SQLite database for request tracking
 type Store struct {
-	db *gorm.DB
+	db           *gorm.DB
+	logBatchSize int // See SetLogBatchSize
+}
+
+// WithContext returns a Store whose queries run under ctx, so they stop when it is
+// cancelled or its deadline passes. Handlers pass the request's context so an abandoned
+// request doesn't keep a heavy query running. The receiver is unchanged.
+func (s *Store) WithContext(ctx context.Context) *Store {
+	return &Store{db: s.db.WithContext(ctx), logBatchSize: s.logBatchSize}
+}
+
+// SetLogBatchSize sets the number of execution log rows inserted per statement, keeping
+// each insert well under SQLite's limit on bound variables. A size of zero or less
+// restores DefaultLogBatchSize.
+func (s *Store) SetLogBatchSize(n int) {
+	if n <= 0 {
+		n = DefaultLogBatchSize
+	}
+	s.logBatchSize = n
 }
 
 // Database represents a database connection configuration for EXPLAIN queries
@@ -43,64 +73,248 @@ func (Database) TableName() string {
 
 // Server represents a server configuration with URL and authentication
 type Server struct {
-	ID                uint           `gorm:"primaryKey" json:"id"`
-	Name              string         `gorm:"not null" json:"name"`
-	URL               string         `gorm:"not null" json:"url"`
-	BearerToken       string         `gorm:"column:bearer_token" json:"bearerToken,omitempty"`
-	DevID             string         `gorm:"column:dev_id" json:"devId,omitempty"`
-	ExperimentalMode  string         `gorm:"column:experimental_mode" json:"experimentalMode,omitempty"`
-	DefaultDatabaseID *uint          `gorm:"column:default_database_id;index" json:"defaultDatabaseId,omitempty"`
-	DefaultDatabase   *Database      `gorm:"foreignKey:DefaultDatabaseID" json:"defaultDatabase,omitempty"`
-	CreatedAt         time.Time      `json:"createdAt"`
-	UpdatedAt         time.Time      `json:"updatedAt"`
-	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                  uint           `gorm:"primaryKey" json:"id"`
+	Name                string         `gorm:"not null" json:"name"`
+	URL                 string         `gorm:"not null" json:"url"`
+	BearerToken         string         `gorm:"column:bearer_token" json:"bearerToken,omitempty"`
+	DevID               string         `gorm:"column:dev_id" json:"devId,omitempty"`
+	ExperimentalMode    string         `gorm:"column:experimental_mode" json:"experimentalMode,omitempty"`
+	ResponseTraceHeader string         `gorm:"column:response_trace_header" json:"responseTraceHeader,omitempty"` // Response header echoing the server's own request ID
+	CorrelationHeader   string         `gorm:"column:correlation_header" json:"correlationHeader,omitempty"`      // Outgoing request ID header, defaults to X-Request-Id
+	CorrelationFormat   string         `gorm:"column:correlation_format" json:"correlationFormat,omitempty"`      // "id" (default) or "traceparent"
+	NameField           string         `gorm:"column:name_field" json:"nameField,omitempty"`                      // Body field (or "$path") naming non-GraphQL requests
+	BuildVersionHeader  string         `gorm:"column:build_version_header" json:"buildVersionHeader,omitempty"`   // Response header naming the build that served a request, such as X-Build-Version
+	BuildVersion        string         `gorm:"column:build_version" json:"buildVersion,omitempty"`                // Build recorded when the response has no build version header
+	DefaultDatabaseID   *uint          `gorm:"column:default_database_id;index" json:"defaultDatabaseId,omitempty"`
+	DefaultDatabase     *Database      `gorm:"foreignKey:DefaultDatabaseID" json:"defaultDatabase,omitempty"`
+	GraphQLSchema       string         `gorm:"column:graphql_schema" json:"-"` // Cached introspection response; cleared when the server is updated
+	GraphQLSchemaAt     *time.Time     `gorm:"column:graphql_schema_fetched_at" json:"graphqlSchemaFetchedAt,omitempty"`
+	Warnings            []string       `gorm:"-" json:"warnings,omitempty"` // Computed field, not stored in DB
+	CreatedAt           time.Time      `json:"createdAt"`
+	UpdatedAt           time.Time      `json:"updatedAt"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// ResponseBuildVersion returns the build that served a request: the value of the
+// server's BuildVersionHeader in the stored response headers if present, otherwise
+// the configured BuildVersion
+func (s *Server) ResponseBuildVersion(responseHeaders string) string {
+	if s.BuildVersionHeader != "" {
+		if headers, err := httputil.ParseResponseHeaders(responseHeaders); err == nil && headers != nil {
+			if version := http.Header(headers.Headers).Get(s.BuildVersionHeader); version != "" {
+				return version
+			}
+		}
+	}
+	return s.BuildVersion
 }
 
 // SampleQuery represents a saved GraphQL/API request template (sample query)
 type SampleQuery struct {
-	ID          uint           `gorm:"primaryKey" json:"id"`
-	Name        string         `gorm:"not null" json:"name"`
-	ServerID    *uint          `gorm:"column:server_id;index" json:"serverId,omitempty"`
-	Server      *Server        `gorm:"foreignKey:ServerID" json:"server,omitempty"`
-	RequestData string         `gorm:"not null;column:request_data" json:"requestData"`
-	DisplayName string         `gorm:"-" json:"displayName"` // Computed field, not stored in DB
-	CreatedAt   time.Time      `json:"createdAt"`
-	UpdatedAt   time.Time      `json:"updatedAt"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                 uint    `gorm:"primaryKey" json:"id"`
+	Name               string  `gorm:"not null" json:"name"`
+	ServerID           *uint   `gorm:"column:server_id;index" json:"serverId,omitempty"`
+	Server             *Server `gorm:"foreignKey:ServerID" json:"server,omitempty"`
+	RequestData        string  `gorm:"not null;column:request_data" json:"requestData"`
+	DisplayName        string  `gorm:"-" json:"displayName"` // Computed field, not stored in DB
+	SampleExpectations `gorm:"embedded"`
+	CreatedAt          time.Time      `json:"createdAt"`
+	UpdatedAt          time.Time      `json:"updatedAt"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 func (SampleQuery) TableName() string {
 	return "sample_requests"
 }
 
+// SampleExpectations are the budget a sample query's executions are checked against once
+// they complete. A nil limit is not checked.
+type SampleExpectations struct {
+	MaxQueries    *int   `gorm:"column:max_queries" json:"maxQueries,omitempty"`
+	MaxDurationMS *int64 `gorm:"column:max_duration_ms" json:"maxDurationMs,omitempty"`
+}
+
+// Set reports whether any limit is set
+func (e SampleExpectations) Set() bool {
+	return e.MaxQueries != nil || e.MaxDurationMS != nil
+}
+
+// Violations describes each limit an execution with queryCount SQL queries taking
+// durationMS exceeded, or returns nil if it met all of them
+func (e SampleExpectations) Violations(queryCount int, durationMS int64) []string {
+	var violations []string
+	if e.MaxQueries != nil && queryCount > *e.MaxQueries {
+		violations = append(violations, fmt.Sprintf("%d SQL queries, expected at most %d", queryCount, *e.MaxQueries))
+	}
+	if e.MaxDurationMS != nil && durationMS > *e.MaxDurationMS {
+		violations = append(violations, fmt.Sprintf("took %dms, expected at most %dms", durationMS, *e.MaxDurationMS))
+	}
+	return violations
+}
+
+// Execution statuses, in lifecycle order
+const (
+	RequestStatusPending   = "pending"   // Created, waiting to be sent
+	RequestStatusRunning   = "running"   // Sent, waiting for the response
+	RequestStatusCompleted = "completed" // Response received without an error
+	RequestStatusFailed    = "failed"    // The request failed or its response reported an error
+	RequestStatusCancelled = "cancelled" // Cancelled before the response arrived
+)
+
+// ValidRequestStatus reports whether status is one of the RequestStatus values
+func ValidRequestStatus(status string) bool {
+	switch status {
+	case RequestStatusPending, RequestStatusRunning, RequestStatusCompleted, RequestStatusFailed, RequestStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// FinishedStatus returns the status of an execution that is done with its request:
+// failed when it has an error, completed otherwise
+func FinishedStatus(errorMessage string) string {
+	if errorMessage != "" {
+		return RequestStatusFailed
+	}
+	return RequestStatusCompleted
+}
+
 // Request represents a single request execution
 type Request struct {
-	ID                  uint           `gorm:"primaryKey" json:"id"`
-	SampleID            *uint          `gorm:"column:sample_id;index" json:"sampleId,omitempty"`
-	ServerID            *uint          `gorm:"column:server_id;index" json:"serverId,omitempty"`
-	Server              *Server        `gorm:"foreignKey:ServerID" json:"server,omitempty"`
-	RequestIDHeader     string         `gorm:"not null;column:request_id_header" json:"requestIdHeader"`
-	RequestBody         string         `gorm:"column:request_body" json:"requestBody,omitempty"`
-	StatusCode          int            `gorm:"column:status_code" json:"statusCode"`
-	DurationMS          int64          `gorm:"column:duration_ms" json:"durationMs"`
-	ResponseBody        string         `gorm:"column:response_body" json:"responseBody,omitempty"`
-	ResponseHeaders     string         `gorm:"column:response_headers" json:"responseHeaders,omitempty"`
-	Error               string         `json:"error,omitempty"`
-	IsSync              bool           `gorm:"column:is_sync;index;default:false" json:"isSync"`
-	Name                string         `gorm:"column:name" json:"name"`
-	DisplayName         string         `gorm:"-" json:"displayName"` // Computed field, not stored in DB
-	BearerTokenOverride string         `gorm:"column:bearer_token_override" json:"bearerTokenOverride,omitempty"`
-	DevIDOverride       string         `gorm:"column:dev_id_override" json:"devIdOverride,omitempty"`
-	ExecutedAt          time.Time      `gorm:"not null;column:executed_at;index" json:"executedAt"`
-	CreatedAt           time.Time      `json:"createdAt"`
-	UpdatedAt           time.Time      `json:"updatedAt"`
-	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                   uint           `gorm:"primaryKey" json:"id"`
+	SampleID             *uint          `gorm:"column:sample_id;index" json:"sampleId,omitempty"`
+	ServerID             *uint          `gorm:"column:server_id;index" json:"serverId,omitempty"`
+	Server               *Server        `gorm:"foreignKey:ServerID" json:"server,omitempty"`
+	URL                  string         `gorm:"column:url" json:"url,omitempty"` // Sent to, which differs from the server's with a URL override
+	RequestIDHeader      string         `gorm:"not null;column:request_id_header" json:"requestIdHeader"`
+	RequestBody          string         `gorm:"column:request_body" json:"requestBody,omitempty"`
+	BodyHash             string         `gorm:"column:body_hash;index" json:"bodyHash,omitempty"`
+	Protocol             string         `gorm:"column:protocol" json:"protocol,omitempty"` // graphql, jsonrpc or rest
+	StatusCode           int            `gorm:"column:status_code" json:"statusCode"`
+	DurationMS           int64          `gorm:"column:duration_ms" json:"durationMs"`
+	ResponseBody         string         `gorm:"column:response_body" json:"responseBody,omitempty"`
+	ResponseHeaders      string         `gorm:"column:response_headers" json:"responseHeaders,omitempty"`
+	Error                string         `json:"error,omitempty"`
+	Status               string         `gorm:"column:status;index;not null;default:pending" json:"status"` // One of the RequestStatus values
+	IsSync               bool           `gorm:"column:is_sync;index;default:false" json:"isSync"`
+	Name                 string         `gorm:"column:name" json:"name"`
+	DisplayName          string         `gorm:"-" json:"displayName"`      // Computed field, not stored in DB
+	Operation            string         `gorm:"column:operation" json:"-"` // Display name, falling back to the sample query's, kept current for finding an operation's executions in SQL
+	BearerTokenOverride  string         `gorm:"column:bearer_token_override" json:"bearerTokenOverride,omitempty"`
+	DevIDOverride        string         `gorm:"column:dev_id_override" json:"devIdOverride,omitempty"`
+	ResolverSQL          string         `gorm:"column:resolver_sql" json:"-"`                             // JSON []ResolverSQL, returned parsed in RequestDetailResponse
+	BuildVersion         string         `gorm:"column:build_version" json:"buildVersion,omitempty"`       // Build of the server that handled the request, such as a version or git SHA
+	ExpectationsMet      *bool          `gorm:"column:expectations_met" json:"expectationsMet,omitempty"` // Whether the execution met its sample query's expectations; nil if it has none
+	ExpectationViolation string         `gorm:"column:expectation_violation" json:"expectationViolation,omitempty"`
+	ExecutedAt           time.Time      `gorm:"not null;column:executed_at;index" json:"executedAt"`
+	CreatedAt            time.Time      `json:"createdAt"`
+	UpdatedAt            time.Time      `json:"updatedAt"`
+	DeletedAt            gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 func (Request) TableName() string {
 	return "requests"
 }
 
+// RetryGroup represents executions of the same request body close together in time,
+// typically a client retrying the same logical request with new request IDs
+type RetryGroup struct {
+	BodyHash        string    `json:"bodyHash"`
+	DisplayName     string    `json:"displayName"`
+	RequestIDs      []uint    `json:"requestIds"`
+	Count           int       `json:"count"`
+	FirstExecutedAt time.Time `json:"firstExecutedAt"`
+	LastExecutedAt  time.Time `json:"lastExecutedAt"`
+}
+
+// OperationStats averages the query count, duration and error rate of executions
+type OperationStats struct {
+	Executions int     `json:"executions"`
+	QueryCount float64 `json:"queryCount"`
+	DurationMS float64 `json:"durationMs"`
+	ErrorRate  float64 `json:"errorRate"` // Fraction of executions that failed, 0 to 1
+}
+
+// OperationAnomaly flags a metric of the latest execution that regressed against the baseline
+type OperationAnomaly struct {
+	Metric   string  `json:"metric"` // queryCount, durationMs or errorRate
+	Latest   float64 `json:"latest"`
+	Baseline float64 `json:"baseline"`
+	Change   float64 `json:"change"` // Percentage increase over the baseline, 0 when the baseline is 0
+}
+
+// OperationAnomalies compares the latest execution of an operation with the rolling
+// average of the executions before it
+type OperationAnomalies struct {
+	Operation         string             `json:"operation"`
+	LatestExecutionID uint               `json:"latestExecutionId"`
+	Latest            OperationStats     `json:"latest"`
+	Baseline          OperationStats     `json:"baseline"`
+	Anomalies         []OperationAnomaly `json:"anomalies"`
+}
+
+// Thresholds above which the latest execution counts as anomalous
+const (
+	anomalyQueryCountIncrease = 20.0 // percent
+	anomalyDurationIncrease   = 50.0 // percent
+	anomalyMaxBaselineErrors  = 0.5  // a failure is only anomalous when the baseline mostly succeeded
+)
+
+// ExecutionTrace summarizes the logs and SQL queries of one trace within an execution.
+// Batched requests can fan out into several traces.
+type ExecutionTrace struct {
+	TraceID        string    `json:"traceId"`
+	LogCount       int       `json:"logCount"`
+	QueryCount     int       `json:"queryCount"`
+	FirstTimestamp time.Time `json:"firstTimestamp"`
+	LastTimestamp  time.Time `json:"lastTimestamp"`
+}
+
+// HistoricalContainer summarizes a container seen in stored execution logs. The
+// container may no longer exist; Name and Running are filled in by callers that know
+// the live containers.
+type HistoricalContainer struct {
+	ContainerID  string    `json:"containerId"`
+	Name         string    `json:"name,omitempty"`
+	Running      bool      `json:"running"`
+	LogCount     int       `json:"logCount"`
+	RequestCount int       `json:"requestCount"`
+	FirstSeen    time.Time `json:"firstSeen"`
+	LastSeen     time.Time `json:"lastSeen"`
+}
+
+// ServerExecutionSummary is a server that has executions, with how many and when the
+// latest ran
+type ServerExecutionSummary struct {
+	Server         Server    `json:"server"`
+	ExecutionCount int       `json:"executionCount"`
+	LastExecutedAt time.Time `json:"lastExecutedAt"`
+}
+
+// ExecutionComparisonEntry is a compact summary of one run of a sample query, for
+// choosing two runs to compare
+type ExecutionComparisonEntry struct {
+	ID           uint      `json:"id"`
+	ExecutedAt   time.Time `json:"executedAt"`
+	Status       string    `json:"status"`
+	StatusCode   int       `json:"statusCode"`
+	DurationMS   int64     `json:"durationMs"`
+	QueryCount   int       `json:"queryCount"`
+	BuildVersion string    `json:"buildVersion,omitempty"`
+}
+
+// DurationBucket summarizes the completed executions of a sample query that started
+// within one time bucket. Durations are in milliseconds.
+type DurationBucket struct {
+	Start       time.Time `json:"start"` // In UTC
+	Count       int       `json:"count"`
+	AvgDuration float64   `json:"avgDuration"`
+	P95Duration float64   `json:"p95Duration"`
+}
+
+// maxDurationBuckets bounds the buckets returned for one trend, keeping the most recent
+const maxDurationBuckets = 1000
+
 // RequestLogMessages represents a log entry from an execution
 type RequestLogMessages struct {
 	ID          uint           `gorm:"primaryKey" json:"id"`
@@ -124,8 +338,9 @@ func (RequestLogMessages) TableName() string {
 type ContainerRetention struct {
 	ID             uint      `gorm:"primaryKey" json:"id"`
 	ContainerName  string    `gorm:"not null;uniqueIndex" json:"containerName"`
-	RetentionType  string    `gorm:"not null" json:"retentionType"`  // "count" or "time"
-	RetentionValue int       `gorm:"not null" json:"retentionValue"` // number of logs or seconds
+	RetentionType  string    `gorm:"not null" json:"retentionType"`     // "count" or "time"
+	RetentionValue int       `gorm:"not null" json:"retentionValue"`    // number of logs or seconds
+	MinKeep        int       `gorm:"not null" json:"minKeep,omitempty"` // newest logs a time policy keeps; 0 for the default
 	CreatedAt      time.Time `json:"createdAt"`
 	UpdatedAt      time.Time `json:"updatedAt"`
 }
@@ -134,6 +349,92 @@ func (ContainerRetention) TableName() string {
 	return "container_retention"
 }
 
+// FieldFormat is a display hint telling the UI how to render a log field value
+type FieldFormat struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	FieldName string    `gorm:"not null;uniqueIndex" json:"fieldName"`
+	Format    string    `gorm:"not null" json:"format"` // "duration", "bytes" or "timestamp"
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (FieldFormat) TableName() string {
+	return "field_formats"
+}
+
+// ContainerSQLMarker selects how SQL statements are recognized in a container's logs,
+// either by a preset name (gorm, django, activerecord) or a custom regex
+type ContainerSQLMarker struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	ContainerName string    `gorm:"not null;uniqueIndex" json:"containerName"`
+	Preset        string    `json:"preset,omitempty"`
+	Pattern       string    `json:"pattern,omitempty"` // Regex with a (?P<sql>...) group, used when Preset is empty
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+func (ContainerSQLMarker) TableName() string {
+	return "container_sql_markers"
+}
+
+// ContainerAlias maps container names matching a regex pattern to a display name
+type ContainerAlias struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Pattern   string    `gorm:"not null;uniqueIndex" json:"pattern"`
+	Alias     string    `gorm:"not null" json:"alias"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (ContainerAlias) TableName() string {
+	return "container_aliases"
+}
+
+// SuppressionPattern is a regex pattern for noise lines, such as health checks, that
+// are dropped as they are ingested
+type SuppressionPattern struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Pattern   string    `gorm:"not null;uniqueIndex" json:"pattern"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (SuppressionPattern) TableName() string {
+	return "suppression_patterns"
+}
+
+// Bookmark represents a starred log line. The trace ID, timestamp and message hash
+// are kept so the line can be located again after it has been evicted from memory.
+type Bookmark struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	ContainerName string    `gorm:"not null;column:container_name" json:"containerName"`
+	Timestamp     time.Time `gorm:"not null;index" json:"timestamp"`
+	MessageHash   string    `gorm:"not null;column:message_hash" json:"messageHash"`
+	TraceID       string    `gorm:"column:trace_id;index" json:"traceId,omitempty"`
+	Message       string    `json:"message,omitempty"`
+	Note          string    `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+func (Bookmark) TableName() string {
+	return "bookmarks"
+}
+
+// SavedView represents a persisted log filter and time window that can be shared by ID
+type SavedView struct {
+	ID        string     `gorm:"primaryKey" json:"id"`
+	Filter    string     `gorm:"not null" json:"filter"` // JSON-encoded client filter
+	StartTime *time.Time `gorm:"column:start_time" json:"startTime,omitempty"`
+	EndTime   *time.Time `gorm:"column:end_time" json:"endTime,omitempty"`
+	ExpiresAt *time.Time `gorm:"column:expires_at;index" json:"expiresAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+func (SavedView) TableName() string {
+	return "saved_views"
+}
+
 // SQLQuery represents a SQL query extracted from logs
 type SQLQuery struct {
 	ID               uint           `gorm:"primaryKey;autoIncrement" json:"id"`
@@ -152,6 +453,7 @@ type SQLQuery struct {
 	SpanID           string         `gorm:"column:span_id" json:"spanId,omitempty"`
 	TraceID          string         `gorm:"column:trace_id" json:"traceId,omitempty"`
 	LogFields        string         `gorm:"column:log_fields" json:"logFields,omitempty"` // JSON object of all other log fields
+	LoggedAt         *time.Time     `gorm:"column:logged_at" json:"loggedAt,omitempty"`   // When the query's log line was written
 	CreatedAt        time.Time      `json:"createdAt"`
 	UpdatedAt        time.Time      `json:"updatedAt"`
 	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
@@ -175,18 +477,54 @@ type RequestDetailResponse struct {
 	SQLAnalysis   *SQLAnalysis              `json:"sqlAnalysis,omitempty"`
 	IndexAnalysis *sqlexplain.IndexAnalysis `json:"indexAnalysis,omitempty"`
 	Server        *Server                   `json:"server,omitempty"`
+	Headers       *httputil.ResponseHeaders `json:"responseHeaders,omitempty"` // Parsed from Execution.ResponseHeaders
 	DevID         string                    `json:"devId,omitempty"`
-	DisplayName   string                    `json:"displayName"` // Computed field
+	DisplayName   string                    `json:"displayName"`           // Computed field
+	ResolverSQL   []ResolverSQL             `json:"resolverSql,omitempty"` // Parsed from Execution.ResolverSQL
+}
+
+// ResolverSQL is a GraphQL resolver from the response's tracing extension and the SQL
+// queries logged while it ran. Offsets and durations are in milliseconds.
+type ResolverSQL struct {
+	Path          string  `json:"path"` // Response path, such as user.friends.0
+	ParentType    string  `json:"parentType"`
+	FieldName     string  `json:"fieldName"`
+	ReturnType    string  `json:"returnType"`
+	StartOffsetMS float64 `json:"startOffsetMs"`
+	DurationMS    float64 `json:"durationMs"`
+	QueryIDs      []uint  `json:"queryIds"`
+	SQLDurationMS float64 `json:"sqlDurationMs"`
 }
 
-// SQLAnalysis provides statistics about SQL queries
+// SQLAnalysis provides statistics about SQL queries. Durations are in milliseconds; the
+// percentiles show tail latency that a single slow query hides in the average.
 type SQLAnalysis struct {
-	TotalQueries   int                `json:"totalQueries"`
-	UniqueQueries  int                `json:"uniqueQueries"`
-	AvgDuration    float64            `json:"avgDuration"`
-	TotalDuration  float64            `json:"totalDuration"`
-	TablesAccessed map[string]int     `json:"tablesAccessed"`
-	NPlusOneIssues []QueryGroupResult `json:"nPlusOneIssues,omitempty"`
+	TotalQueries     int                              `json:"totalQueries"`
+	UniqueQueries    int                              `json:"uniqueQueries"`
+	AvgDuration      float64                          `json:"avgDuration"`
+	P50Duration      float64                          `json:"p50Duration"`
+	P95Duration      float64                          `json:"p95Duration"`
+	MaxDuration      float64                          `json:"maxDuration"`
+	TotalDuration    float64                          `json:"totalDuration"`
+	TablesAccessed   map[string]int                   `json:"tablesAccessed"`
+	AccessPatterns   map[sqlexplain.AccessPattern]int `json:"accessPatterns"`             // Query count per access pattern
+	QueryGroups      []QueryGroupResult               `json:"queryGroups"`                // One per normalized query, slowest p95 first
+	NPlusOneIssues   []QueryGroupResult               `json:"nPlusOneIssues,omitempty"`   // Queries executed more than 5 times
+	NPlusOnePatterns []NPlusOnePattern                `json:"nPlusOnePatterns,omitempty"` // Parent and child query pairs
+}
+
+// NPlusOnePattern is the classic N+1 shape: a parent query run once, followed closely by a
+// child query on a related table run once per parent row
+type NPlusOnePattern struct {
+	ParentQuery     string  `json:"parentQuery"` // Normalized
+	ParentTable     string  `json:"parentTable"`
+	ParentQueryID   uint    `json:"parentQueryId,omitempty"`
+	ChildQuery      string  `json:"childQuery"` // Normalized
+	ChildTable      string  `json:"childTable"`
+	ChildCount      int     `json:"childCount"`
+	ChildDurationMS float64 `json:"childDurationMs"` // Total time spent in the child queries
+	ChildQueryIDs   []uint  `json:"childQueryIds,omitempty"`
+	Location        string  `json:"location,omitempty"` // Call site of the child queries, from their log line
 }
 
 // QueryGroupResult represents grouped query statistics
@@ -194,24 +532,28 @@ type QueryGroupResult struct {
 	NormalizedQuery string  `json:"normalizedQuery"`
 	Count           int     `json:"count"`
 	AvgDuration     float64 `json:"avgDuration"`
+	P50Duration     float64 `json:"p50Duration"`
+	P95Duration     float64 `json:"p95Duration"`
+	MaxDuration     float64 `json:"maxDuration"`
 	Example         string  `json:"example"`
 }
 
 // SQLQueryDetail represents detailed information about a specific SQL query
 type SQLQueryDetail struct {
-	QueryHash         string                    `json:"queryHash"`
-	Query             string                    `json:"query"`
-	NormalizedQuery   string                    `json:"normalizedQuery"`
-	Operation         string                    `json:"operation"`
-	TableName         string                    `json:"tableName"`
-	TotalExecutions   int                       `json:"totalExecutions"`
-	AvgDuration       float64                   `json:"avgDuration"`
-	MinDuration       float64                   `json:"minDuration"`
-	MaxDuration       float64                   `json:"maxDuration"`
-	ExplainPlan       string                    `json:"explainPlan,omitempty"`
-	Variables         string                    `json:"variables,omitempty"`
-	IndexAnalysis     *sqlexplain.IndexAnalysis `json:"indexAnalysis,omitempty"`
-	RelatedExecutions []ExecutionReference      `json:"relatedExecutions"`
+	QueryHash         string                         `json:"queryHash"`
+	Query             string                         `json:"query"`
+	NormalizedQuery   string                         `json:"normalizedQuery"`
+	Operation         string                         `json:"operation"`
+	TableName         string                         `json:"tableName"`
+	TotalExecutions   int                            `json:"totalExecutions"`
+	AvgDuration       float64                        `json:"avgDuration"`
+	MinDuration       float64                        `json:"minDuration"`
+	MaxDuration       float64                        `json:"maxDuration"`
+	ExplainPlan       string                         `json:"explainPlan,omitempty"`
+	Variables         string                         `json:"variables,omitempty"`
+	IndexAnalysis     *sqlexplain.IndexAnalysis      `json:"indexAnalysis,omitempty"`
+	Rewrites          []sqlexplain.RewriteSuggestion `json:"rewriteSuggestions,omitempty"`
+	RelatedExecutions []ExecutionReference           `json:"relatedExecutions"`
 }
 
 // ExecutionReference represents a minimal reference to an execution
@@ -253,7 +595,18 @@ func NewStore(dbPath string) (*Store, error) {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	return &Store{db: db}, nil
+	store := &Store{db: db, logBatchSize: DefaultLogBatchSize}
+	if err := store.backfillBodyHashes(); err != nil {
+		return nil, err
+	}
+	if err := store.backfillOperations(); err != nil {
+		return nil, err
+	}
+	if err := store.backfillQueryHashes(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
 }
 
 // Close closes the database connection
@@ -285,7 +638,7 @@ func (s *Store) GetSampleQuery(id int64) (*SampleQuery, error) {
 		return nil, fmt.Errorf("failed to get request: %w", result.Error)
 	}
 	// Compute displayName
-	req.DisplayName = computeDisplayName(req.Name, req.RequestData)
+	req.DisplayName = computeDisplayName(req.Name, req.RequestData, req.Server, "")
 	return &req, nil
 }
 
@@ -298,7 +651,7 @@ func (s *Store) ListSampleQueries() ([]SampleQuery, error) {
 	}
 	// Compute displayName for each sample query
 	for i := range sampleQueries {
-		sampleQueries[i].DisplayName = computeDisplayName(sampleQueries[i].Name, sampleQueries[i].RequestData)
+		sampleQueries[i].DisplayName = computeDisplayName(sampleQueries[i].Name, sampleQueries[i].RequestData, sampleQueries[i].Server, "")
 	}
 	return sampleQueries, nil
 }
@@ -312,6 +665,33 @@ func (s *Store) DeleteSampleQuery(id int64) error {
 	return nil
 }
 
+// SetSampleExpectations replaces a sample query's expectations; nil limits clear them
+func (s *Store) SetSampleExpectations(sampleID int64, expectations SampleExpectations) error {
+	result := s.db.Model(&SampleQuery{}).Where("id = ?", sampleID).
+		Select("max_queries", "max_duration_ms").
+		Updates(&SampleQuery{SampleExpectations: expectations})
+	if result.Error != nil {
+		return fmt.Errorf("failed to set sample expectations: %w", result.Error)
+	}
+	return nil
+}
+
+// ImportSampleExpectations replaces the expectations of several sample queries, keyed by
+// ID, in one transaction so a failed import changes nothing
+func (s *Store) ImportSampleExpectations(expectations map[int64]SampleExpectations) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for sampleID, e := range expectations {
+			result := tx.Model(&SampleQuery{}).Where("id = ?", sampleID).
+				Select("max_queries", "max_duration_ms").
+				Updates(&SampleQuery{SampleExpectations: e})
+			if result.Error != nil {
+				return fmt.Errorf("failed to set expectations of sample query %d: %w", sampleID, result.Error)
+			}
+		}
+		return nil
+	})
+}
+
 // CreateServer creates a new server configuration
 func (s *Store) CreateServer(server *Server) (int64, error) {
 	result := s.db.Create(server)
@@ -350,6 +730,50 @@ func (s *Store) UpdateServer(server *Server) error {
 	if result.Error != nil {
 		return fmt.Errorf("failed to update server: %w", result.Error)
 	}
+
+	// Its requests may be named from its settings, directly or through their sample query
+	samples := s.db.Model(&SampleQuery{}).Select("id").Where("server_id = ?", server.ID)
+	return s.refreshOperations(s.db.Where("server_id = ? OR sample_id IN (?)", server.ID, samples))
+}
+
+// ListServersWithExecutions returns the servers that have at least one execution, most
+// recently used first, counted in a single grouped query
+func (s *Store) ListServersWithExecutions() ([]ServerExecutionSummary, error) {
+	var rows []struct {
+		Server
+		ExecutionCount int
+		LastExecutedAt string
+	}
+	result := s.db.Model(&Server{}).
+		Select("servers.*, COUNT(requests.id) AS execution_count, MAX(requests.executed_at) AS last_executed_at").
+		Joins("JOIN requests ON requests.server_id = servers.id AND requests.deleted_at IS NULL").
+		Group("servers.id").
+		Order("last_executed_at DESC").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list servers with executions: %w", result.Error)
+	}
+
+	summaries := make([]ServerExecutionSummary, 0, len(rows))
+	for _, row := range rows {
+		summaries = append(summaries, ServerExecutionSummary{
+			Server:         row.Server,
+			ExecutionCount: row.ExecutionCount,
+			LastExecutedAt: parseSQLiteTime(row.LastExecutedAt),
+		})
+	}
+	return summaries, nil
+}
+
+// SaveServerSchema caches a server's introspected GraphQL schema
+func (s *Store) SaveServerSchema(id int64, schema string, fetchedAt time.Time) error {
+	result := s.db.Model(&Server{}).Where("id = ?", id).UpdateColumns(map[string]any{
+		"graphql_schema":            schema,
+		"graphql_schema_fetched_at": fetchedAt,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to save server schema: %w", result.Error)
+	}
 	return nil
 }
 
@@ -414,6 +838,20 @@ func (s *Store) DeleteDatabaseURL(id int64) error {
 
 // CreateRequest creates a new execution record
 func (s *Store) CreateRequest(request *Request) (int64, error) {
+	if request.BodyHash == "" && request.RequestBody != "" {
+		request.BodyHash = ComputeBodyHash(request.RequestBody)
+	}
+	if request.Protocol == "" && request.RequestBody != "" {
+		request.Protocol = httputil.DetectProtocol(request.RequestBody)
+	}
+	if request.Operation == "" {
+		request.Operation = s.operationName(request)
+	}
+	// Without a status this records an execution that already finished
+	if request.Status == "" {
+		request.Status = FinishedStatus(request.Error)
+	}
+
 	result := s.db.Create(request)
 	if result.Error != nil {
 		return 0, fmt.Errorf("failed to create request: %w", result.Error)
@@ -436,6 +874,18 @@ func (s *Store) UpdateRequest(exec *Request) error {
 	return nil
 }
 
+// FailUnfinishedRequests marks executions still pending or running as failed. They were
+// interrupted by a restart and will never get a response.
+func (s *Store) FailUnfinishedRequests() (int64, error) {
+	result := s.db.Model(&Request{}).
+		Where("status IN ?", []string{RequestStatusPending, RequestStatusRunning}).
+		Updates(map[string]any{"status": RequestStatusFailed, "error": "Interrupted by a restart"})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to fail unfinished requests: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
 // GetRequest retrieves an execution by ID
 func (s *Store) GetRequest(id int64) (*Request, error) {
 	var exec Request
@@ -459,24 +909,116 @@ func (s *Store) ListRequestsBySample(sampleID int64) ([]Request, error) {
 	return executions, nil
 }
 
-// ListRequests retrieves all requests
-func (s *Store) ListRequests(limit, offset int, search string, showAll bool) ([]Request, int64, error) {
-	query := s.db.Preload("Server").Model(&Request{})
-	countQuery := s.db.Model(&Request{})
+// ListExecutionsForComparison summarizes each execution of a sample query, newest first,
+// with its SQL query count, in a single grouped query
+func (s *Store) ListExecutionsForComparison(sampleID int64) ([]ExecutionComparisonEntry, error) {
+	var entries []ExecutionComparisonEntry
+	result := s.db.Model(&Request{}).
+		Select("requests.id, requests.executed_at, requests.status, requests.status_code, requests.duration_ms, requests.build_version, COUNT(request_sql_statements.id) AS query_count").
+		Joins("LEFT JOIN request_sql_statements ON request_sql_statements.request_id = requests.id AND request_sql_statements.deleted_at IS NULL").
+		Where("requests.sample_id = ?", sampleID).
+		Group("requests.id").
+		Order("requests.executed_at DESC").
+		Scan(&entries)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list executions for comparison: %w", result.Error)
+	}
+	return entries, nil
+}
+
+// ExecutionDurationBuckets groups the completed executions of a sample query into
+// consecutive buckets of the given size, oldest first, with the average and p95 duration
+// of each. Buckets are aligned to UTC, so day buckets start at UTC midnight whatever the
+// server's time zone. Buckets without executions are included with a zero count so
+// charts show the gaps. Failed and cancelled executions are left out, since their
+// durations measure errors rather than latency.
+func (s *Store) ExecutionDurationBuckets(sampleID int64, bucket time.Duration) ([]DurationBucket, error) {
+	var requests []Request
+	result := s.db.Select("executed_at", "duration_ms").
+		Where("sample_id = ? AND status = ?", sampleID, RequestStatusCompleted).
+		Order("executed_at").
+		Find(&requests)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list executions for trend: %w", result.Error)
+	}
+	if len(requests) == 0 {
+		return []DurationBucket{}, nil
+	}
+
+	// Truncate rounds down from the zero time, which is midnight UTC
+	first := requests[0].ExecutedAt.UTC().Truncate(bucket)
+	last := requests[len(requests)-1].ExecutedAt.UTC().Truncate(bucket)
+	if int(last.Sub(first)/bucket) >= maxDurationBuckets {
+		first = last.Add(-time.Duration(maxDurationBuckets-1) * bucket)
+	}
+
+	buckets := make([]DurationBucket, int(last.Sub(first)/bucket)+1)
+	durations := make([][]float64, len(buckets))
+	for i := range buckets {
+		buckets[i].Start = first.Add(time.Duration(i) * bucket)
+	}
+	for _, req := range requests {
+		start := req.ExecutedAt.UTC().Truncate(bucket)
+		if start.Before(first) {
+			continue
+		}
+		i := int(start.Sub(first) / bucket)
+		durations[i] = append(durations[i], float64(req.DurationMS))
+	}
+
+	for i, bucketDurations := range durations {
+		if len(bucketDurations) == 0 {
+			continue
+		}
+		var total float64
+		for _, d := range bucketDurations {
+			total += d
+		}
+		buckets[i].Count = len(bucketDurations)
+		buckets[i].AvgDuration = total / float64(len(bucketDurations))
+		_, buckets[i].P95Duration, _ = durationPercentiles(bucketDurations)
+	}
+	return buckets, nil
+}
+
+// RequestFilter narrows the executions returned by ListRequests and ExportRequests.
+// Zero fields match every execution.
+type RequestFilter struct {
+	Search       string   // Substring of the request ID header or body
+	ContainerIDs []string // Executions with stored logs from one of these containers
+	Status       string   // One of the RequestStatus values
+	ServerID     uint
+}
+
+// filterRequests narrows a query on requests to those matching filter
+func (s *Store) filterRequests(query *gorm.DB, filter RequestFilter) *gorm.DB {
+	if filter.Search != "" {
+		searchPattern := "%" + filter.Search + "%"
+		query = query.Where("request_id_header LIKE ? OR request_body LIKE ?", searchPattern, searchPattern)
+	}
+
+	if len(filter.ContainerIDs) > 0 {
+		withLogs := s.db.Model(&RequestLogMessages{}).Select("request_id").Where("container_id IN ?", filter.ContainerIDs)
+		query = query.Where("id IN (?)", withLogs)
+	}
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
 
-	// Apply search filter to both query and count
-	if search != "" {
-		searchPattern := "%" + search + "%"
-		searchCondition := s.db.Where(
-			"request_id_header LIKE ? OR request_body LIKE ?",
-			searchPattern, searchPattern,
-		)
-		query = query.Where(searchCondition)
-		countQuery = countQuery.Where(
-			"request_id_header LIKE ? OR request_body LIKE ?",
-			searchPattern, searchPattern,
-		)
+	if filter.ServerID != 0 {
+		query = query.Where("server_id = ?", filter.ServerID)
 	}
+	return query
+}
+
+// ListRequests retrieves all requests. When containerIDs is not empty only requests with
+// stored logs from one of those containers are returned, when status is not empty only
+// requests with that status, and when serverID is not zero only requests to that server.
+func (s *Store) ListRequests(limit, offset int, search string, showAll bool, containerIDs []string, status string, serverID uint) ([]Request, int64, error) {
+	filter := RequestFilter{Search: search, ContainerIDs: containerIDs, Status: status, ServerID: serverID}
+	query := s.filterRequests(s.db.Preload("Server").Model(&Request{}), filter)
+	countQuery := s.filterRequests(s.db.Model(&Request{}), filter)
 
 	// If NOT showing all, filter to only async queries (introspection and background queries)
 	if !showAll {
@@ -497,24 +1039,100 @@ func (s *Store) ListRequests(limit, offset int, search string, showAll bool) ([]
 		return nil, 0, fmt.Errorf("failed to list all executions: %w", result.Error)
 	}
 
-	// Compute displayName for each execution
+	s.setDisplayNames(requests)
+	return requests, totalCount, nil
+}
+
+// ExportRequests calls fn with each execution matching filter, newest first, reading them
+// from a cursor so memory use does not grow with the number of executions. Servers and
+// sample query names are loaded up front, since the cursor holds the connection until
+// the last row is read. Iteration stops at the first error fn returns.
+func (s *Store) ExportRequests(filter RequestFilter, fn func(*Request) error) error {
+	servers, err := s.ListServers()
+	if err != nil {
+		return err
+	}
+	serversByID := make(map[uint]*Server, len(servers))
+	for i := range servers {
+		serversByID[servers[i].ID] = &servers[i]
+	}
+	samples, err := s.ListSampleQueries()
+	if err != nil {
+		return err
+	}
+	sampleNames := make(map[uint]string, len(samples))
+	for _, sample := range samples {
+		sampleNames[sample.ID] = sample.DisplayName
+	}
+
+	rows, err := s.filterRequests(s.db.Model(&Request{}), filter).Order("executed_at DESC").Rows()
+	if err != nil {
+		return fmt.Errorf("failed to export executions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var req Request
+		if err := s.db.ScanRows(rows, &req); err != nil {
+			return fmt.Errorf("failed to read execution: %w", err)
+		}
+		if req.ServerID != nil {
+			req.Server = serversByID[*req.ServerID]
+		}
+		req.DisplayName = computeDisplayName(req.Name, req.RequestBody, req.Server, req.URL)
+		// If execution has a sample query, use its name
+		if req.DisplayName == "Unknown" && req.SampleID != nil && sampleNames[*req.SampleID] != "" {
+			req.DisplayName = sampleNames[*req.SampleID]
+		}
+		if err := fn(&req); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// setDisplayNames computes the displayName of each execution, falling back to its
+// sample query's name
+func (s *Store) setDisplayNames(requests []Request) {
 	for i := range requests {
-		displayName := computeDisplayName(requests[i].Name, requests[i].RequestBody)
+		displayName := computeDisplayName(requests[i].Name, requests[i].RequestBody, requests[i].Server, requests[i].URL)
 		// If execution has a sample query, use its name
 		if displayName == "Unknown" && requests[i].SampleID != nil {
 			sampleQuery, err := s.GetSampleQuery(int64(*requests[i].SampleID))
 			if err == nil && sampleQuery != nil {
-				displayName = computeDisplayName(sampleQuery.Name, sampleQuery.RequestData)
+				displayName = computeDisplayName(sampleQuery.Name, sampleQuery.RequestData, sampleQuery.Server, "")
 			}
 		}
 
 		requests[i].DisplayName = displayName
 	}
+}
 
-	return requests, totalCount, nil
+// slowestExecutionsQuery selects executions since a time, slowest first. SQLite would
+// otherwise pick the deleted_at index and scan every execution, so the executed_at
+// index that the time range narrows is named explicitly.
+func slowestExecutionsQuery(db *gorm.DB, since time.Time, limit int) *gorm.DB {
+	return db.Model(&Request{}).
+		Table("requests INDEXED BY idx_executed_requests_executed_at").
+		Where("executed_at >= ?", since).
+		Order("duration_ms DESC").
+		Limit(limit)
+}
+
+// SlowestExecutions returns up to limit executions since the given time, slowest first,
+// with their display names
+func (s *Store) SlowestExecutions(since time.Time, limit int) ([]Request, error) {
+	var requests []Request
+	if err := slowestExecutionsQuery(s.db.Preload("Server"), since, limit).Find(&requests).Error; err != nil {
+		return nil, fmt.Errorf("failed to list slowest executions: %w", err)
+	}
+	s.setDisplayNames(requests)
+	return requests, nil
 }
 
-// SaveRequestLogs saves log entries for an execution
+// SaveRequestLogs saves log entries for an execution, inserting them in batches (see
+// SetLogBatchSize). The batches share a transaction, so either every entry is saved or
+// none is.
 func (s *Store) SaveRequestLogs(requestID int64, logMessages []logs.ContainerMessage) error {
 	var execLogs []RequestLogMessages
 
@@ -543,7 +1161,7 @@ func (s *Store) SaveRequestLogs(requestID int64, logMessages []logs.ContainerMes
 	}
 
 	if len(execLogs) > 0 {
-		result := s.db.Create(&execLogs)
+		result := s.db.CreateInBatches(&execLogs, s.logBatchSize)
 		if result.Error != nil {
 			return fmt.Errorf("failed to insert logs: %w", result.Error)
 		}
@@ -562,92 +1180,368 @@ func (s *Store) GetRequestLogs(requestID int64) ([]RequestLogMessages, error) {
 	return logs, nil
 }
 
-// SaveSQLQueries saves SQL queries for an execution
-func (s *Store) SaveSQLQueries(executionID int64, queries []SQLQuery) error {
-	if len(queries) == 0 {
-		return nil
-	}
-
-	// Set the request ID for all queries
-	for i := range queries {
-		queries[i].RequestID = uint(executionID)
-	}
-
-	result := s.db.Create(&queries)
+// ListHistoricalContainers returns every distinct container found in stored execution
+// logs with its log and request counts, most recently seen first
+func (s *Store) ListHistoricalContainers() ([]HistoricalContainer, error) {
+	var rows []struct {
+		ContainerID  string
+		LogCount     int
+		RequestCount int
+		FirstSeen    string
+		LastSeen     string
+	}
+	result := s.db.Model(&RequestLogMessages{}).
+		Select("container_id, COUNT(*) AS log_count, COUNT(DISTINCT request_id) AS request_count, MIN(timestamp) AS first_seen, MAX(timestamp) AS last_seen").
+		Where("container_id != ''").
+		Group("container_id").
+		Order("last_seen DESC").
+		Scan(&rows)
 	if result.Error != nil {
-		return fmt.Errorf("failed to insert queries: %w", result.Error)
+		return nil, fmt.Errorf("failed to list historical containers: %w", result.Error)
 	}
 
-	return nil
-}
-
-// UpdateQueryExplainPlan updates the explain plan for a query by its hash
-func (s *Store) UpdateQueryExplainPlan(executionID int64, queryHash string, explainPlan string) error {
-	result := s.db.Model(&SQLQuery{}).
-		Where("request_id = ? AND query_hash = ?", executionID, queryHash).
-		Update("explain_plan", explainPlan)
-
-	if result.Error != nil {
-		return fmt.Errorf("failed to update explain plan: %w", result.Error)
+	containers := make([]HistoricalContainer, 0, len(rows))
+	for _, row := range rows {
+		containers = append(containers, HistoricalContainer{
+			ContainerID:  row.ContainerID,
+			LogCount:     row.LogCount,
+			RequestCount: row.RequestCount,
+			FirstSeen:    parseSQLiteTime(row.FirstSeen),
+			LastSeen:     parseSQLiteTime(row.LastSeen),
+		})
 	}
+	return containers, nil
+}
 
-	return nil
+// sqliteTimeLayouts are the layouts SQLite returns for timestamps that come back as text,
+// such as the result of MIN or MAX over a datetime column
+var sqliteTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
 }
 
-// GetSQLQueries retrieves SQL queries for an execution
-func (s *Store) GetSQLQueries(executionID int64) ([]SQLQuery, error) {
-	var queries []SQLQuery
-	result := s.db.Where("request_id = ?", executionID).Order("id").Find(&queries)
-	if result.Error != nil {
-		return nil, fmt.Errorf("failed to get SQL queries: %w", result.Error)
+// parseSQLiteTime parses a timestamp returned as text, or returns the zero time
+func parseSQLiteTime(value string) time.Time {
+	value = strings.TrimSuffix(value, "Z")
+	for _, layout := range sqliteTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
 	}
-	return queries, nil
+	return time.Time{}
 }
 
-// GetRequestDetail retrieves full execution details with logs and SQL analysis
-func (s *Store) GetRequestDetail(executionID int64) (*RequestDetailResponse, error) {
-	exec, err := s.GetRequest(executionID)
+// ListExecutionTraces returns the distinct trace IDs found in an execution's logs with
+// per-trace log and SQL query counts, ordered by when each trace was first seen.
+func (s *Store) ListExecutionTraces(requestID int64) ([]ExecutionTrace, error) {
+	logMessages, err := s.GetRequestLogs(requestID)
 	if err != nil {
 		return nil, err
 	}
-	if exec == nil {
-		return nil, nil
-	}
 
-	var req *SampleQuery
-	if exec.SampleID != nil {
-		req, err = s.GetSampleQuery(int64(*exec.SampleID))
-		if err != nil {
-			return nil, err
+	traces := []ExecutionTrace{}
+	index := make(map[string]int)
+	for _, msg := range logMessages {
+		if msg.Fields == "" {
+			continue
+		}
+		var fields map[string]string
+		if err := json.Unmarshal([]byte(msg.Fields), &fields); err != nil {
+			continue
+		}
+		traceID := fields["trace_id"]
+		if traceID == "" {
+			continue
 		}
-	}
 
-	var server *Server
-	if exec.ServerID != nil {
-		server, err = s.GetServer(int64(*exec.ServerID))
-		if err != nil {
-			return nil, err
+		i, ok := index[traceID]
+		if !ok {
+			i = len(traces)
+			index[traceID] = i
+			traces = append(traces, ExecutionTrace{TraceID: traceID, FirstTimestamp: msg.Timestamp})
 		}
+		traces[i].LogCount++
+		traces[i].LastTimestamp = msg.Timestamp
 	}
 
-	logs, err := s.GetRequestLogs(executionID)
-	if err != nil {
-		return nil, err
+	var queryCounts []struct {
+		TraceID string
+		Count   int
 	}
-
-	sqlQueries, err := s.GetSQLQueries(executionID)
-	if err != nil {
-		return nil, err
+	result := s.db.Model(&SQLQuery{}).
+		Select("trace_id, COUNT(*) AS count").
+		Where("request_id = ? AND trace_id IS NOT NULL AND trace_id != ''", requestID).
+		Group("trace_id").
+		Order("MIN(id)").
+		Scan(&queryCounts)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to count queries by trace: %w", result.Error)
 	}
 
-	// Compute displayName for execution
-	displayName := "Unknown"
-	if req != nil {
-		// Use sample query name if available
-		displayName = computeDisplayName(req.Name, req.RequestData)
-	} else if exec.RequestBody != "" {
-		// Extract from requestBody if no sample query
-		displayName = computeDisplayName("", exec.RequestBody)
+	for _, qc := range queryCounts {
+		i, ok := index[qc.TraceID]
+		if !ok {
+			i = len(traces)
+			index[qc.TraceID] = i
+			traces = append(traces, ExecutionTrace{TraceID: qc.TraceID})
+		}
+		traces[i].QueryCount = qc.Count
+	}
+
+	return traces, nil
+}
+
+// Timeline span kinds
+const (
+	TimelineSpanQuery = "query" // A SQL query
+	TimelineSpanLog   = "log"   // A warning, an error or a log line reporting a duration
+)
+
+// timelineLabelLength caps span labels, which are for scanning the timeline rather
+// than reading whole queries or messages
+const timelineLabelLength = 120
+
+// TimelineSpan is one bar of an execution's timeline, placed relative to when the
+// request was sent
+type TimelineSpan struct {
+	Kind        string  `json:"kind"` // One of the TimelineSpan constants
+	Label       string  `json:"label"`
+	StartMS     float64 `json:"startMs"`
+	DurationMS  float64 `json:"durationMs"` // Zero for log lines without a duration
+	ContainerID string  `json:"containerId,omitempty"`
+	Level       string  `json:"level,omitempty"`
+	QueryID     uint    `json:"queryId,omitempty"`
+	TraceID     string  `json:"traceId,omitempty"`
+}
+
+// Timeline lays out an execution's SQL queries and notable log lines against its
+// request, as a waterfall of where the time went
+type Timeline struct {
+	ExecutionID    int64          `json:"executionId"`
+	TraceID        string         `json:"traceId,omitempty"` // Set when the timeline is limited to one trace
+	StartedAt      time.Time      `json:"startedAt"`
+	DurationMS     int64          `json:"durationMs"` // The request's duration as the client saw it
+	Spans          []TimelineSpan `json:"spans"`      // In order of start
+	UntimedQueries int            `json:"untimedQueries"`
+}
+
+// BuildTraceTimeline lays out an execution's SQL queries and notable log lines, meaning
+// warnings, errors and lines reporting a duration, as spans offset from when the request
+// was sent. A line is written once its work is done, so each span starts its duration
+// before the line's timestamp. Queries saved without a timestamp can't be placed and
+// are only counted. A non-empty traceID keeps only that trace's spans. The timeline is
+// nil if the execution doesn't exist.
+func (s *Store) BuildTraceTimeline(executionID int64, traceID string) (*Timeline, error) {
+	execution, err := s.GetRequest(executionID)
+	if err != nil || execution == nil {
+		return nil, err
+	}
+	queries, err := s.GetSQLQueries(executionID)
+	if err != nil {
+		return nil, err
+	}
+	logMessages, err := s.GetRequestLogs(executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := &Timeline{
+		ExecutionID: executionID,
+		TraceID:     traceID,
+		StartedAt:   execution.ExecutedAt,
+		DurationMS:  execution.DurationMS,
+		Spans:       []TimelineSpan{},
+	}
+	startMS := func(loggedAt time.Time, durationMS float64) float64 {
+		return float64(loggedAt.Sub(execution.ExecutedAt).Microseconds())/1000 - durationMS
+	}
+
+	queryLines := make(map[int64]bool)
+	for _, q := range queries {
+		if traceID != "" && q.TraceID != traceID {
+			continue
+		}
+		if q.LoggedAt == nil {
+			timeline.UntimedQueries++
+			continue
+		}
+		queryLines[q.LoggedAt.UnixNano()] = true
+
+		label := q.DisplayName()
+		if q.QueriedTable == "" {
+			label = timelineLabel(cmp.Or(q.NormalizedQuery, q.Query))
+		}
+		timeline.Spans = append(timeline.Spans, TimelineSpan{
+			Kind:       TimelineSpanQuery,
+			Label:      label,
+			StartMS:    startMS(*q.LoggedAt, q.DurationMS),
+			DurationMS: q.DurationMS,
+			QueryID:    q.ID,
+			TraceID:    q.TraceID,
+		})
+	}
+
+	for _, msg := range logMessages {
+		// The lines queries were read from are already on the timeline as queries
+		if queryLines[msg.Timestamp.UnixNano()] {
+			continue
+		}
+
+		var fields map[string]string
+		if msg.Fields != "" {
+			json.Unmarshal([]byte(msg.Fields), &fields)
+		}
+		if traceID != "" && fields["trace_id"] != traceID {
+			continue
+		}
+
+		level, _ := logs.ParseLevel(msg.Level)
+		durationMS, timed := logs.ParseDurationMS(cmp.Or(fields["duration"], fields["duration_ms"]))
+		if !timed && level != "ERR" && level != "WRN" {
+			continue
+		}
+		timeline.Spans = append(timeline.Spans, TimelineSpan{
+			Kind:        TimelineSpanLog,
+			Label:       timelineLabel(msg.Message),
+			StartMS:     startMS(msg.Timestamp, durationMS),
+			DurationMS:  durationMS,
+			ContainerID: msg.ContainerID,
+			Level:       level,
+			TraceID:     fields["trace_id"],
+		})
+	}
+
+	slices.SortStableFunc(timeline.Spans, func(a, b TimelineSpan) int {
+		return cmp.Compare(a.StartMS, b.StartMS)
+	})
+	return timeline, nil
+}
+
+// timelineLabel shortens s to timelineLabelLength runes
+func timelineLabel(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > timelineLabelLength {
+		return string(runes[:timelineLabelLength-1]) + "…"
+	}
+	return s
+}
+
+// SaveSQLQueries saves SQL queries for an execution
+func (s *Store) SaveSQLQueries(executionID int64, queries []SQLQuery) error {
+	if len(queries) == 0 {
+		return nil
+	}
+
+	// Set the request ID for all queries
+	for i := range queries {
+		queries[i].RequestID = uint(executionID)
+	}
+
+	result := s.db.Create(&queries)
+	if result.Error != nil {
+		return fmt.Errorf("failed to insert queries: %w", result.Error)
+	}
+
+	return nil
+}
+
+// SaveResolverSQL stores the mapping from an execution's GraphQL resolvers to its SQL queries
+func (s *Store) SaveResolverSQL(executionID int64, resolvers []ResolverSQL) error {
+	data, err := json.Marshal(resolvers)
+	if err != nil {
+		return err
+	}
+
+	result := s.db.Model(&Request{}).Where("id = ?", executionID).UpdateColumn("resolver_sql", string(data))
+	if result.Error != nil {
+		return fmt.Errorf("failed to save resolver SQL: %w", result.Error)
+	}
+	return nil
+}
+
+// SaveExpectationResult stores whether an execution met its sample query's expectations,
+// leaving its other columns as they are
+func (s *Store) SaveExpectationResult(executionID int64, met bool, violation string) error {
+	result := s.db.Model(&Request{}).Where("id = ?", executionID).UpdateColumns(map[string]any{
+		"expectations_met":      met,
+		"expectation_violation": violation,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to save expectation result: %w", result.Error)
+	}
+	return nil
+}
+
+// UpdateQueryExplainPlan updates the explain plan for a query by its hash
+func (s *Store) UpdateQueryExplainPlan(executionID int64, queryHash string, explainPlan string) error {
+	result := s.db.Model(&SQLQuery{}).
+		Where("request_id = ? AND query_hash = ?", executionID, queryHash).
+		Update("explain_plan", explainPlan)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update explain plan: %w", result.Error)
+	}
+
+	return nil
+}
+
+// GetSQLQueries retrieves SQL queries for an execution
+func (s *Store) GetSQLQueries(executionID int64) ([]SQLQuery, error) {
+	var queries []SQLQuery
+	result := s.db.Where("request_id = ?", executionID).Order("id").Find(&queries)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get SQL queries: %w", result.Error)
+	}
+	return queries, nil
+}
+
+// GetRequestDetail retrieves full execution details with logs and SQL analysis
+func (s *Store) GetRequestDetail(executionID int64) (*RequestDetailResponse, error) {
+	exec, err := s.GetRequest(executionID)
+	if err != nil {
+		return nil, err
+	}
+	if exec == nil {
+		return nil, nil
+	}
+
+	var req *SampleQuery
+	if exec.SampleID != nil {
+		req, err = s.GetSampleQuery(int64(*exec.SampleID))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var server *Server
+	if exec.ServerID != nil {
+		server, err = s.GetServer(int64(*exec.ServerID))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	logs, err := s.GetRequestLogs(executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlQueries, err := s.GetSQLQueries(executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compute displayName for execution
+	displayName := "Unknown"
+	if req != nil {
+		// Use sample query name if available
+		displayName = computeDisplayName(req.Name, req.RequestData, req.Server, "")
+	} else if exec.RequestBody != "" {
+		// Extract from requestBody if no sample query
+		displayName = computeDisplayName("", exec.RequestBody, server, exec.URL)
 	}
 	exec.DisplayName = displayName
 
@@ -661,88 +1555,441 @@ func (s *Store) GetRequestDetail(executionID int64) (*RequestDetailResponse, err
 		DevID:       exec.DevIDOverride,
 	}
 
+	// Parse response headers; stored headers that fail to parse are still returned raw on the execution
+	if headers, err := httputil.ParseResponseHeaders(exec.ResponseHeaders); err == nil {
+		detail.Headers = headers
+	}
+
+	// Like the headers, a resolver mapping that fails to parse is left out
+	var resolvers []ResolverSQL
+	if err := json.Unmarshal([]byte(exec.ResolverSQL), &resolvers); err == nil {
+		detail.ResolverSQL = resolvers
+	}
+
 	// Calculate SQL analysis
 	if len(sqlQueries) > 0 {
-		detail.SQLAnalysis = s.analyzeSQLQueries(sqlQueries)
+		detail.SQLAnalysis = analyzeSQLQueries(sqlQueries)
 
 		// Calculate index analysis
-		detail.IndexAnalysis = s.analyzeIndexUsage(sqlQueries)
+		detail.IndexAnalysis = analyzeIndexUsage(sqlQueries)
 	}
 
 	return detail, nil
 }
 
-// analyzeSQLQueries performs SQL query analysis
-func (s *Store) analyzeSQLQueries(queries []SQLQuery) *SQLAnalysis {
+// FixtureVersion is the format version of exported execution fixtures
+const FixtureVersion = 1
+
+// ExecutionFixture is a self-contained snapshot of an execution's request, response, logs and
+// SQL queries that can be committed alongside tests and analyzed without a live server
+type ExecutionFixture struct {
+	Version     int                  `json:"version"`
+	ExportedAt  time.Time            `json:"exportedAt"`
+	DisplayName string               `json:"displayName"`
+	Request     FixtureRequest       `json:"request"`
+	Response    FixtureResponse      `json:"response"`
+	Logs        []RequestLogMessages `json:"logs"`
+	SQLQueries  []SQLQuery           `json:"sqlQueries"`
+}
+
+// FixtureRequest is the request half of an ExecutionFixture. Credentials are not included.
+type FixtureRequest struct {
+	Body            string    `json:"body"`
+	Protocol        string    `json:"protocol,omitempty"`
+	RequestIDHeader string    `json:"requestIdHeader"`
+	ServerURL       string    `json:"serverUrl,omitempty"`
+	ExecutedAt      time.Time `json:"executedAt"`
+}
+
+// FixtureResponse is the response half of an ExecutionFixture
+type FixtureResponse struct {
+	StatusCode int    `json:"statusCode"`
+	Body       string `json:"body"`
+	Headers    string `json:"headers,omitempty"` // JSON-encoded response headers
+	DurationMS int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// GetExecutionFixture bundles a stored execution into a fixture. Returns nil if the execution does not exist.
+func (s *Store) GetExecutionFixture(executionID int64) (*ExecutionFixture, error) {
+	detail, err := s.GetRequestDetail(executionID)
+	if err != nil {
+		return nil, err
+	}
+	if detail == nil {
+		return nil, nil
+	}
+
+	exec := detail.Execution
+	fixture := &ExecutionFixture{
+		Version:     FixtureVersion,
+		ExportedAt:  time.Now().UTC(),
+		DisplayName: detail.DisplayName,
+		Request: FixtureRequest{
+			Body:            exec.RequestBody,
+			Protocol:        exec.Protocol,
+			RequestIDHeader: exec.RequestIDHeader,
+			ExecutedAt:      exec.ExecutedAt,
+		},
+		Response: FixtureResponse{
+			StatusCode: exec.StatusCode,
+			Body:       exec.ResponseBody,
+			Headers:    exec.ResponseHeaders,
+			DurationMS: exec.DurationMS,
+			Error:      exec.Error,
+		},
+		Logs:       detail.Logs,
+		SQLQueries: detail.SQLQueries,
+	}
+	if detail.Server != nil {
+		fixture.Request.ServerURL = detail.Server.URL
+	}
+
+	return fixture, nil
+}
+
+// Analyze runs the SQL and index analysis over a fixture's queries, as GetRequestDetail does for stored executions
+func (f *ExecutionFixture) Analyze() (*SQLAnalysis, *sqlexplain.IndexAnalysis) {
+	if len(f.SQLQueries) == 0 {
+		return nil, nil
+	}
+	return analyzeSQLQueries(f.SQLQueries), analyzeIndexUsage(f.SQLQueries)
+}
+
+// RequestDetail converts a fixture back into the detail shape returned by GetRequestDetail,
+// with analysis filled in, so fixtures can stand in for stored executions
+func (f *ExecutionFixture) RequestDetail() *RequestDetailResponse {
+	detail := &RequestDetailResponse{
+		Execution: Request{
+			RequestIDHeader: f.Request.RequestIDHeader,
+			RequestBody:     f.Request.Body,
+			Protocol:        f.Request.Protocol,
+			StatusCode:      f.Response.StatusCode,
+			DurationMS:      f.Response.DurationMS,
+			ResponseBody:    f.Response.Body,
+			ResponseHeaders: f.Response.Headers,
+			Error:           f.Response.Error,
+			DisplayName:     f.DisplayName,
+			ExecutedAt:      f.Request.ExecutedAt,
+		},
+		Logs:        f.Logs,
+		SQLQueries:  f.SQLQueries,
+		DisplayName: f.DisplayName,
+	}
+	if f.Request.ServerURL != "" {
+		detail.Server = &Server{Name: f.Request.ServerURL, URL: f.Request.ServerURL}
+		detail.Execution.Server = detail.Server
+	}
+	detail.SQLAnalysis, detail.IndexAnalysis = f.Analyze()
+
+	return detail
+}
+
+// analyzeSQLQueries performs SQL query analysis, skipping queries on ignored tables
+func analyzeSQLQueries(queries []SQLQuery) *SQLAnalysis {
+	queries = slices.DeleteFunc(slices.Clone(queries), func(q SQLQuery) bool {
+		return sqlexplain.IsIgnoredTable(q.QueriedTable)
+	})
+
 	if len(queries) == 0 {
 		return &SQLAnalysis{
 			TablesAccessed: make(map[string]int),
+			AccessPatterns: make(map[sqlexplain.AccessPattern]int),
+			QueryGroups:    []QueryGroupResult{},
 		}
 	}
 
 	analysis := &SQLAnalysis{
 		TotalQueries:   len(queries),
 		TablesAccessed: make(map[string]int),
+		AccessPatterns: make(map[sqlexplain.AccessPattern]int),
 	}
 
 	// Calculate totals
+	durations := make([]float64, 0, len(queries))
 	for _, q := range queries {
 		analysis.TotalDuration += q.DurationMS
+		durations = append(durations, q.DurationMS)
 		if q.QueriedTable != "" {
 			analysis.TablesAccessed[q.QueriedTable]++
 		}
+		analysis.AccessPatterns[sqlexplain.ClassifyAccessPattern(q.Query, q.ExplainPlan)]++
 	}
 	analysis.AvgDuration = analysis.TotalDuration / float64(len(queries))
+	analysis.P50Duration, analysis.P95Duration, analysis.MaxDuration = durationPercentiles(durations)
 
-	// Group by normalized query
+	// Group by normalized query, keeping the first-seen order so ties sort stably
 	queryGroups := make(map[string]*QueryGroupResult)
+	groupDurations := make(map[string][]float64)
+	var order []string
 	for _, q := range queries {
 		if _, exists := queryGroups[q.NormalizedQuery]; !exists {
 			queryGroups[q.NormalizedQuery] = &QueryGroupResult{
 				NormalizedQuery: q.NormalizedQuery,
 				Example:         q.Query,
 			}
+			order = append(order, q.NormalizedQuery)
 		}
 		group := queryGroups[q.NormalizedQuery]
 		group.Count++
 		group.AvgDuration = (group.AvgDuration*float64(group.Count-1) + q.DurationMS) / float64(group.Count)
+		groupDurations[q.NormalizedQuery] = append(groupDurations[q.NormalizedQuery], q.DurationMS)
 	}
 
 	// Count unique queries
 	analysis.UniqueQueries = len(queryGroups)
 
-	// Detect N+1 issues (queries executed more than 5 times)
-	for _, group := range queryGroups {
+	analysis.QueryGroups = make([]QueryGroupResult, 0, len(order))
+	for _, normalized := range order {
+		group := queryGroups[normalized]
+		group.P50Duration, group.P95Duration, group.MaxDuration = durationPercentiles(groupDurations[normalized])
+		analysis.QueryGroups = append(analysis.QueryGroups, *group)
+
+		// Detect N+1 issues (queries executed more than 5 times)
 		if group.Count > 5 {
 			analysis.NPlusOneIssues = append(analysis.NPlusOneIssues, *group)
 		}
 	}
+	slices.SortStableFunc(analysis.QueryGroups, func(a, b QueryGroupResult) int {
+		return cmp.Compare(b.P95Duration, a.P95Duration)
+	})
+	analysis.NPlusOnePatterns = findNPlusOnePatterns(queries)
 
 	return analysis
 }
 
-// analyzeIndexUsage performs index usage analysis on SQL queries
-func (s *Store) analyzeIndexUsage(queries []SQLQuery) *sqlexplain.IndexAnalysis {
-	// Convert SQLQuery to QueryWithPlan format for sqlexplain package
-	queryWithPlans := make([]sqlexplain.QueryWithPlan, 0, len(queries))
+// N+1 detection thresholds
+const (
+	nPlusOneMinChildren = 3 // Child executions before a parent and child count as an N+1
+	nPlusOneMaxGap      = 3 // Other queries allowed between the parent and the first child
+)
 
+// findNPlusOnePatterns finds N+1s in queries, which are in the order they ran. A query run at least
+// nPlusOneMinChildren times is a child when, shortly before its first run, there is a query
+// run only once on a related table. The nearest such query is its parent.
+func findNPlusOnePatterns(queries []SQLQuery) []NPlusOnePattern {
+	counts := make(map[string]int)
 	for _, q := range queries {
-		qwp := sqlexplain.QueryWithPlan{
-			Query:           q.Query,
-			NormalizedQuery: q.NormalizedQuery,
-			OperationName:   q.GraphQLOperation,
-			Timestamp:       q.CreatedAt.Unix(),
-			DurationMS:      q.DurationMS,
-			QueriedTable:    q.QueriedTable,
-			Operation:       q.Operation,
-			Rows:            q.Rows,
-			ExplainPlan:     q.ExplainPlan,
-			Variables:       q.Variables,
+		counts[q.NormalizedQuery]++
+	}
+
+	var patterns []NPlusOnePattern
+	seen := make(map[string]bool)
+	for i, child := range queries {
+		if seen[child.NormalizedQuery] {
+			continue
+		}
+		seen[child.NormalizedQuery] = true
+		if counts[child.NormalizedQuery] < nPlusOneMinChildren {
+			continue
+		}
+
+		for j := i - 1; j >= max(0, i-1-nPlusOneMaxGap); j-- {
+			parent := queries[j]
+			if counts[parent.NormalizedQuery] != 1 || !relatedQueries(parent, child) {
+				continue
+			}
+
+			pattern := NPlusOnePattern{
+				ParentQuery:   parent.NormalizedQuery,
+				ParentTable:   parent.QueriedTable,
+				ParentQueryID: parent.ID,
+				ChildQuery:    child.NormalizedQuery,
+				ChildTable:    child.QueriedTable,
+				Location:      queryLocation(child),
+			}
+			for _, q := range queries[i:] {
+				if q.NormalizedQuery != child.NormalizedQuery {
+					continue
+				}
+				pattern.ChildCount++
+				pattern.ChildDurationMS += q.DurationMS
+				if q.ID != 0 {
+					pattern.ChildQueryIDs = append(pattern.ChildQueryIDs, q.ID)
+				}
+			}
+			patterns = append(patterns, pattern)
+			break
+		}
+	}
+	return patterns
+}
+
+// primaryKeyLookup matches queries that look a row up by its id, as loaders do
+var primaryKeyLookup = regexp.MustCompile(`(?i)\bwhere\s+("?\w+"?\.)?"?id"?\s*(=|in\b)`)
+
+// relatedQueries reports whether child's table is related to parent's: one references the
+// other through a foreign key column named after it, such as user_id for users, or child
+// looks rows up by id
+func relatedQueries(parent, child SQLQuery) bool {
+	if parent.QueriedTable == "" || child.QueriedTable == "" || parent.QueriedTable == child.QueriedTable {
+		return false
+	}
+	return referencesTable(child.NormalizedQuery, parent.QueriedTable) ||
+		referencesTable(parent.NormalizedQuery, child.QueriedTable) ||
+		primaryKeyLookup.MatchString(child.NormalizedQuery)
+}
+
+// referencesTable reports whether query mentions table's foreign key column
+func referencesTable(query, table string) bool {
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		table = table[i+1:]
+	}
+	singular := strings.TrimSuffix(table, "s")
+	if strings.HasSuffix(table, "ies") {
+		singular = strings.TrimSuffix(table, "ies") + "y"
+	}
+	foreignKey := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(singular+"_id") + `\b`)
+	return foreignKey.MatchString(query)
+}
+
+// queryLocation returns the call site logged with a query, if any
+func queryLocation(q SQLQuery) string {
+	var fields map[string]string
+	if q.LogFields == "" || json.Unmarshal([]byte(q.LogFields), &fields) != nil {
+		return ""
+	}
+	for _, key := range []string{"location", "caller", "source", "file"} {
+		if fields[key] != "" {
+			return fields[key]
+		}
+	}
+	return ""
+}
+
+// durationPercentiles returns the 50th and 95th percentiles and the maximum of durations
+// using the nearest-rank method, so each is a duration that was actually observed.
+// durations is sorted in place.
+func durationPercentiles(durations []float64) (p50, p95, maxDuration float64) {
+	if len(durations) == 0 {
+		return 0, 0, 0
+	}
+	slices.Sort(durations)
+	return nearestRank(durations, 50), nearestRank(durations, 95), durations[len(durations)-1]
+}
+
+// nearestRank returns the p-th percentile of sorted: the smallest value that at least p
+// percent of the values are less than or equal to
+func nearestRank(sorted []float64, p int) float64 {
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	return sorted[max(rank, 1)-1]
+}
+
+// analyzeIndexUsage performs index usage analysis on SQL queries
+func analyzeIndexUsage(queries []SQLQuery) *sqlexplain.IndexAnalysis {
+	return sqlexplain.AnalyzeIndexUsage(QueriesWithPlan(queries, ""))
+}
+
+// ComparisonReport compares the SQL of two executions: queries only in each, plan and
+// performance differences, and each side's index usage. It is both the analyze tool's
+// -json output and the /api/executions/compare response.
+type ComparisonReport struct {
+	Execution1     ExecutionSummary                  `json:"execution1"`
+	Execution2     ExecutionSummary                  `json:"execution2"`
+	Comparison     *sqlexplain.ExplainPlanComparison `json:"comparison"`
+	Regressions    []sqlexplain.QueryPlanComparison  `json:"regressions"`  // Performance differences that got slower
+	Improvements   []sqlexplain.QueryPlanComparison  `json:"improvements"` // Performance differences that got faster
+	IndexAnalysis1 *sqlexplain.IndexAnalysis         `json:"indexAnalysis1"`
+	IndexAnalysis2 *sqlexplain.IndexAnalysis         `json:"indexAnalysis2"`
+}
+
+// ExecutionSummary describes one of the executions in a ComparisonReport
+type ExecutionSummary struct {
+	ID           uint   `json:"id"`
+	RequestID    string `json:"requestId,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Server       string `json:"server,omitempty"`
+	BuildVersion string `json:"buildVersion,omitempty"`
+	StatusCode   int    `json:"statusCode"`
+	DurationMS   int64  `json:"durationMs"`
+	QueryCount   int    `json:"queryCount"`
+}
+
+// CompareExecutions compares the SQL queries of two executions, exec1 being the before
+func CompareExecutions(exec1, exec2 *RequestDetailResponse) *ComparisonReport {
+	queries1 := QueriesWithPlan(exec1.SQLQueries, ExecutionLabel(exec1, 1))
+	queries2 := QueriesWithPlan(exec2.SQLQueries, ExecutionLabel(exec2, 2))
+	comparison := sqlexplain.CompareQuerySets(queries1, queries2)
+
+	report := &ComparisonReport{
+		Execution1:     summarizeExecution(exec1),
+		Execution2:     summarizeExecution(exec2),
+		Comparison:     comparison,
+		Regressions:    []sqlexplain.QueryPlanComparison{},
+		Improvements:   []sqlexplain.QueryPlanComparison{},
+		IndexAnalysis1: sqlexplain.AnalyzeIndexUsage(queries1),
+		IndexAnalysis2: sqlexplain.AnalyzeIndexUsage(queries2),
+	}
+	for _, diff := range comparison.PerformanceDifferences {
+		if diff.DurationDiffPct > 0 {
+			report.Regressions = append(report.Regressions, diff)
+		} else {
+			report.Improvements = append(report.Improvements, diff)
 		}
-		queryWithPlans = append(queryWithPlans, qwp)
 	}
+	return report
+}
+
+// ExecutionLabel names an execution in a comparison. Fixtures have no ID, so they're
+// named by their request ID, or by their position when they have none.
+func ExecutionLabel(exec *RequestDetailResponse, position int) string {
+	if exec.Execution.ID != 0 {
+		return fmt.Sprintf("Execution %d", exec.Execution.ID)
+	}
+	if exec.Execution.RequestIDHeader != "" {
+		return "Request " + exec.Execution.RequestIDHeader
+	}
+	return fmt.Sprintf("Fixture %d", position)
+}
+
+func summarizeExecution(exec *RequestDetailResponse) ExecutionSummary {
+	summary := ExecutionSummary{
+		ID:           exec.Execution.ID,
+		RequestID:    exec.Execution.RequestIDHeader,
+		BuildVersion: exec.Execution.BuildVersion,
+		StatusCode:   exec.Execution.StatusCode,
+		DurationMS:   exec.Execution.DurationMS,
+		QueryCount:   len(exec.SQLQueries),
+	}
+	if exec.Request != nil {
+		summary.Name = exec.Request.Name
+	}
+	if server := cmp.Or(exec.Server, exec.Execution.Server); server != nil {
+		summary.Server = server.Name
+	}
+	return summary
+}
+
+// QueryWithPlan converts the query for analysis with sqlexplain, grouped under its
+// GraphQL operation
+func (q SQLQuery) QueryWithPlan() sqlexplain.QueryWithPlan {
+	return sqlexplain.QueryWithPlan{
+		Query:           q.Query,
+		NormalizedQuery: q.NormalizedQuery,
+		OperationName:   q.GraphQLOperation,
+		Timestamp:       q.CreatedAt.Unix(),
+		DurationMS:      q.DurationMS,
+		QueriedTable:    q.QueriedTable,
+		Operation:       q.Operation,
+		Rows:            q.Rows,
+		ExplainPlan:     q.ExplainPlan,
+		Variables:       q.Variables,
+	}
+}
 
-	return sqlexplain.AnalyzeIndexUsage(queryWithPlans)
+// QueriesWithPlan converts an execution's queries for analysis with sqlexplain. A
+// non-empty operationName groups them all under it, as when comparing executions;
+// otherwise each keeps its GraphQL operation.
+func QueriesWithPlan(queries []SQLQuery, operationName string) []sqlexplain.QueryWithPlan {
+	result := make([]sqlexplain.QueryWithPlan, 0, len(queries))
+	for _, q := range queries {
+		qwp := q.QueryWithPlan()
+		if operationName != "" {
+			qwp.OperationName = operationName
+		}
+		result = append(result, qwp)
+	}
+	return result
 }
 
 // ComputeQueryHash computes a SHA256 hash of the normalized query
@@ -751,6 +1998,291 @@ func ComputeQueryHash(normalizedQuery string) string {
 	return hex.EncodeToString(hash[:])
 }
 
+// ComputeMessageHash computes a SHA256 hash of a raw log line
+func ComputeMessageHash(raw string) string {
+	hash := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(hash[:])
+}
+
+// ComputeBodyHash computes a SHA256 hash of a request body, ignoring surrounding whitespace
+func ComputeBodyHash(body string) string {
+	hash := sha256.Sum256([]byte(strings.TrimSpace(body)))
+	return hex.EncodeToString(hash[:])
+}
+
+// backfillBodyHashes computes body hashes for requests saved before hashes were recorded
+func (s *Store) backfillBodyHashes() error {
+	var requests []Request
+	result := s.db.Select("id", "request_body").
+		Where("body_hash IS NULL AND request_body IS NOT NULL AND request_body != ''").
+		Find(&requests)
+	if result.Error != nil {
+		return fmt.Errorf("failed to list requests without body hash: %w", result.Error)
+	}
+
+	for _, req := range requests {
+		result := s.db.Model(&Request{}).Where("id = ?", req.ID).Update("body_hash", ComputeBodyHash(req.RequestBody))
+		if result.Error != nil {
+			return fmt.Errorf("failed to backfill body hash: %w", result.Error)
+		}
+	}
+	return nil
+}
+
+// backfillQueryHashes normalizes and hashes SQL queries saved without a hash, including
+// those whose hash was cleared when NormalizeQuery changed. The updates run in one
+// transaction.
+func (s *Store) backfillQueryHashes() error {
+	var queries []SQLQuery
+	result := s.db.Unscoped().Select("id", "query").Where("query_hash IS NULL").Find(&queries)
+	if result.Error != nil {
+		return fmt.Errorf("failed to list SQL queries without hash: %w", result.Error)
+	}
+	if len(queries) == 0 {
+		return nil
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, q := range queries {
+			normalized := utils.NormalizeQuery(q.Query)
+			result := tx.Unscoped().Model(&SQLQuery{}).Where("id = ?", q.ID).UpdateColumns(map[string]any{
+				"normalized_query": normalized,
+				"query_hash":       ComputeQueryHash(normalized),
+			})
+			if result.Error != nil {
+				return fmt.Errorf("failed to backfill query hash: %w", result.Error)
+			}
+		}
+		return nil
+	})
+}
+
+// operationName returns the display name an execution is grouped under: its own, or
+// its sample query's when it has none
+func (s *Store) operationName(req *Request) string {
+	server := req.Server
+	if server == nil && req.ServerID != nil {
+		server, _ = s.GetServer(int64(*req.ServerID))
+	}
+	var sample *SampleQuery
+	if req.SampleID != nil {
+		sample, _ = s.GetSampleQuery(int64(*req.SampleID))
+	}
+	return operationNameWith(req, server, sample)
+}
+
+// operationNameWith is operationName with the server and sample query already loaded
+func operationNameWith(req *Request, server *Server, sample *SampleQuery) string {
+	name := computeDisplayName(req.Name, req.RequestBody, server, req.URL)
+	if name == "Unknown" && sample != nil {
+		name = computeDisplayName(sample.Name, sample.RequestData, sample.Server, "")
+	}
+	return name
+}
+
+// backfillOperations sets the operation of requests created before it was stored
+func (s *Store) backfillOperations() error {
+	return s.refreshOperations(s.db.Where("operation IS NULL"))
+}
+
+// refreshOperations recomputes the operation of the requests matched by query, after
+// something their names derive from has changed. Servers and sample queries are loaded
+// up front and the updates run in one transaction.
+func (s *Store) refreshOperations(query *gorm.DB) error {
+	var requests []Request
+	result := query.Model(&Request{}).
+		Select("id", "server_id", "sample_id", "url", "request_body", "name", "operation").
+		Preload("Server").
+		Find(&requests)
+	if result.Error != nil {
+		return fmt.Errorf("failed to list requests to name: %w", result.Error)
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+
+	var sampleIDs []uint
+	for _, req := range requests {
+		if req.SampleID != nil {
+			sampleIDs = append(sampleIDs, *req.SampleID)
+		}
+	}
+	samples := make(map[uint]*SampleQuery)
+	if len(sampleIDs) > 0 {
+		var rows []SampleQuery
+		if err := s.db.Preload("Server").Where("id IN ?", sampleIDs).Find(&rows).Error; err != nil {
+			return fmt.Errorf("failed to load sample queries to name requests: %w", err)
+		}
+		for i := range rows {
+			samples[rows[i].ID] = &rows[i]
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range requests {
+			req := &requests[i]
+			var sample *SampleQuery
+			if req.SampleID != nil {
+				sample = samples[*req.SampleID]
+			}
+			name := operationNameWith(req, req.Server, sample)
+			if err := tx.Model(&Request{}).Where("id = ?", req.ID).UpdateColumn("operation", name).Error; err != nil {
+				return fmt.Errorf("failed to update operation: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ListRetryGroups groups executions that share a request body hash. Executions of the
+// same body are placed in one group while each is within window of the previous one.
+// Only groups with more than one execution are returned, most recent first.
+func (s *Store) ListRetryGroups(window time.Duration) ([]RetryGroup, error) {
+	var hashes []string
+	result := s.db.Model(&Request{}).
+		Where("body_hash IS NOT NULL AND body_hash != ''").
+		Group("body_hash").
+		Having("COUNT(*) > 1").
+		Pluck("body_hash", &hashes)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to group requests by body hash: %w", result.Error)
+	}
+	if len(hashes) == 0 {
+		return []RetryGroup{}, nil
+	}
+
+	var requests []Request
+	result = s.db.Select("id", "server_id", "url", "body_hash", "request_body", "name", "executed_at").
+		Preload("Server").
+		Where("body_hash IN ?", hashes).
+		Order("body_hash, executed_at").
+		Find(&requests)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list requests for retry groups: %w", result.Error)
+	}
+
+	groups := []RetryGroup{}
+	var current *RetryGroup
+	flush := func() {
+		if current != nil && current.Count > 1 {
+			groups = append(groups, *current)
+		}
+	}
+
+	for _, req := range requests {
+		if current == nil || current.BodyHash != req.BodyHash || req.ExecutedAt.Sub(current.LastExecutedAt) > window {
+			flush()
+			current = &RetryGroup{
+				BodyHash:        req.BodyHash,
+				DisplayName:     computeDisplayName(req.Name, req.RequestBody, req.Server, req.URL),
+				FirstExecutedAt: req.ExecutedAt,
+			}
+		}
+		current.RequestIDs = append(current.RequestIDs, req.ID)
+		current.Count++
+		current.LastExecutedAt = req.ExecutedAt
+	}
+	flush()
+
+	slices.SortFunc(groups, func(a, b RetryGroup) int {
+		return b.LastExecutedAt.Compare(a.LastExecutedAt)
+	})
+	return groups, nil
+}
+
+// GetOperationAnomalies compares the most recent execution of the named operation with
+// the average of up to window executions before it and reports the metrics that regressed.
+// Returns nil if the operation has never been executed.
+func (s *Store) GetOperationAnomalies(name string, window int) (*OperationAnomalies, error) {
+	var latestID uint
+	result := s.db.Model(&Request{}).
+		Where("operation = ?", name).
+		Order("executed_at DESC, id DESC").
+		Limit(1).
+		Pluck("id", &latestID)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to find latest execution for anomalies: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	latest, err := s.operationStats(name, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	baseline, err := s.operationStats(name, 1, window)
+	if err != nil {
+		return nil, err
+	}
+
+	anomalies := &OperationAnomalies{
+		Operation:         name,
+		LatestExecutionID: latestID,
+		Latest:            latest,
+		Baseline:          baseline,
+		Anomalies:         []OperationAnomaly{},
+	}
+	if anomalies.Baseline.Executions == 0 {
+		return anomalies, nil
+	}
+
+	if a, ok := increaseAnomaly("queryCount", latest.QueryCount, baseline.QueryCount, anomalyQueryCountIncrease); ok {
+		anomalies.Anomalies = append(anomalies.Anomalies, a)
+	}
+	if a, ok := increaseAnomaly("durationMs", latest.DurationMS, baseline.DurationMS, anomalyDurationIncrease); ok {
+		anomalies.Anomalies = append(anomalies.Anomalies, a)
+	}
+	if latest.ErrorRate > 0 && baseline.ErrorRate < anomalyMaxBaselineErrors {
+		a, _ := increaseAnomaly("errorRate", latest.ErrorRate, baseline.ErrorRate, 0)
+		anomalies.Anomalies = append(anomalies.Anomalies, a)
+	}
+
+	return anomalies, nil
+}
+
+// operationStats averages the metrics of the named operation's executions, newest
+// first, skipping offset of them and taking up to limit
+func (s *Store) operationStats(name string, offset, limit int) (OperationStats, error) {
+	var stats OperationStats
+	if limit <= 0 {
+		return stats, nil
+	}
+
+	executions := s.db.Model(&Request{}).
+		Select("requests.status_code, requests.duration_ms, requests.error, "+
+			"(SELECT COUNT(*) FROM request_sql_statements q WHERE q.request_id = requests.id AND q.deleted_at IS NULL) AS query_count").
+		Where("operation = ?", name).
+		Order("executed_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit)
+	result := s.db.Table("(?) AS executions", executions).
+		Select("COUNT(*) AS executions, " +
+			"COALESCE(AVG(query_count), 0) AS query_count, " +
+			"COALESCE(AVG(duration_ms), 0) AS duration_ms, " +
+			"COALESCE(AVG(CASE WHEN COALESCE(error, '') != '' OR status_code >= 400 THEN 1.0 ELSE 0.0 END), 0) AS error_rate").
+		Scan(&stats)
+	if result.Error != nil {
+		return stats, fmt.Errorf("failed to average executions for anomalies: %w", result.Error)
+	}
+	return stats, nil
+}
+
+// increaseAnomaly reports metric when latest exceeds baseline by more than threshold percent.
+// Any increase over a zero baseline counts.
+func increaseAnomaly(metric string, latest, baseline, threshold float64) (OperationAnomaly, bool) {
+	if latest <= baseline {
+		return OperationAnomaly{}, false
+	}
+
+	anomaly := OperationAnomaly{Metric: metric, Latest: latest, Baseline: baseline}
+	if baseline == 0 {
+		return anomaly, true
+	}
+	anomaly.Change = (latest - baseline) / baseline * 100
+	return anomaly, anomaly.Change > threshold
+}
+
 // GetContainerRetention retrieves retention settings for a container
 func (s *Store) GetContainerRetention(containerName string) (*ContainerRetention, error) {
 	var retention ContainerRetention
@@ -803,15 +2335,230 @@ func (s *Store) DeleteContainerRetention(containerName string) error {
 	return nil
 }
 
+// SaveContainerSQLMarker saves or updates the SQL marker for a container
+func (s *Store) SaveContainerSQLMarker(marker *ContainerSQLMarker) error {
+	var existing ContainerSQLMarker
+	result := s.db.Where("container_name = ?", marker.ContainerName).First(&existing)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		result = s.db.Create(marker)
+	} else if result.Error == nil {
+		marker.ID = existing.ID
+		marker.CreatedAt = existing.CreatedAt
+		result = s.db.Save(marker)
+	}
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to save SQL marker: %w", result.Error)
+	}
+	return nil
+}
+
+// ListContainerSQLMarkers retrieves all container SQL markers
+func (s *Store) ListContainerSQLMarkers() ([]ContainerSQLMarker, error) {
+	var markers []ContainerSQLMarker
+	result := s.db.Order("container_name").Find(&markers)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list SQL markers: %w", result.Error)
+	}
+	return markers, nil
+}
+
+// DeleteContainerSQLMarker deletes the SQL marker for a container
+func (s *Store) DeleteContainerSQLMarker(containerName string) error {
+	result := s.db.Where("container_name = ?", containerName).Delete(&ContainerSQLMarker{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete SQL marker: %w", result.Error)
+	}
+	return nil
+}
+
+// SaveFieldFormat saves or updates the display format for a log field
+func (s *Store) SaveFieldFormat(format *FieldFormat) error {
+	var existing FieldFormat
+	result := s.db.Where("field_name = ?", format.FieldName).First(&existing)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		result = s.db.Create(format)
+	} else if result.Error == nil {
+		format.ID = existing.ID
+		format.CreatedAt = existing.CreatedAt
+		result = s.db.Save(format)
+	}
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to save field format: %w", result.Error)
+	}
+	return nil
+}
+
+// ListFieldFormats retrieves all configured field formats
+func (s *Store) ListFieldFormats() ([]FieldFormat, error) {
+	var formats []FieldFormat
+	result := s.db.Order("field_name").Find(&formats)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list field formats: %w", result.Error)
+	}
+	return formats, nil
+}
+
+// DeleteFieldFormat deletes the display format for a log field
+func (s *Store) DeleteFieldFormat(fieldName string) error {
+	result := s.db.Where("field_name = ?", fieldName).Delete(&FieldFormat{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete field format: %w", result.Error)
+	}
+	return nil
+}
+
+// SaveContainerAlias saves or updates the alias for a container name pattern
+func (s *Store) SaveContainerAlias(alias *ContainerAlias) error {
+	var existing ContainerAlias
+	result := s.db.Where("pattern = ?", alias.Pattern).First(&existing)
+
+	if result.Error == gorm.ErrRecordNotFound {
+		result = s.db.Create(alias)
+	} else if result.Error == nil {
+		alias.ID = existing.ID
+		alias.CreatedAt = existing.CreatedAt
+		result = s.db.Save(alias)
+	}
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to save container alias: %w", result.Error)
+	}
+	return nil
+}
+
+// ListContainerAliases retrieves all container aliases in the order they were created
+func (s *Store) ListContainerAliases() ([]ContainerAlias, error) {
+	var aliases []ContainerAlias
+	result := s.db.Order("id").Find(&aliases)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list container aliases: %w", result.Error)
+	}
+	return aliases, nil
+}
+
+// DeleteContainerAlias deletes a container alias by ID
+func (s *Store) DeleteContainerAlias(id uint) error {
+	result := s.db.Delete(&ContainerAlias{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete container alias: %w", result.Error)
+	}
+	return nil
+}
+
+// SaveSuppressionPattern saves a suppression pattern, or fills in the existing one if
+// the pattern is already saved
+func (s *Store) SaveSuppressionPattern(pattern *SuppressionPattern) error {
+	result := s.db.Where("pattern = ?", pattern.Pattern).FirstOrCreate(pattern)
+	if result.Error != nil {
+		return fmt.Errorf("failed to save suppression pattern: %w", result.Error)
+	}
+	return nil
+}
+
+// ListSuppressionPatterns retrieves all suppression patterns in the order they were created
+func (s *Store) ListSuppressionPatterns() ([]SuppressionPattern, error) {
+	var patterns []SuppressionPattern
+	result := s.db.Order("id").Find(&patterns)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list suppression patterns: %w", result.Error)
+	}
+	return patterns, nil
+}
+
+// DeleteSuppressionPattern deletes a suppression pattern by ID
+func (s *Store) DeleteSuppressionPattern(id uint) error {
+	result := s.db.Delete(&SuppressionPattern{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete suppression pattern: %w", result.Error)
+	}
+	return nil
+}
+
+// CreateBookmark creates a new bookmark
+func (s *Store) CreateBookmark(bookmark *Bookmark) (int64, error) {
+	result := s.db.Create(bookmark)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to create bookmark: %w", result.Error)
+	}
+	return int64(bookmark.ID), nil
+}
+
+// ListBookmarks retrieves bookmarks ordered by log timestamp, optionally filtered by trace ID
+func (s *Store) ListBookmarks(traceID string) ([]Bookmark, error) {
+	var bookmarks []Bookmark
+	query := s.db.Order("timestamp")
+	if traceID != "" {
+		query = query.Where("trace_id = ?", traceID)
+	}
+	result := query.Find(&bookmarks)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list bookmarks: %w", result.Error)
+	}
+	return bookmarks, nil
+}
+
+// DeleteBookmark deletes a bookmark
+func (s *Store) DeleteBookmark(id int64) error {
+	result := s.db.Delete(&Bookmark{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete bookmark: %w", result.Error)
+	}
+	return nil
+}
+
+// CreateSavedView saves a view under a new short random ID
+func (s *Store) CreateSavedView(view *SavedView) (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate view ID: %w", err)
+	}
+	view.ID = hex.EncodeToString(b)
+
+	result := s.db.Create(view)
+	if result.Error != nil {
+		return "", fmt.Errorf("failed to create saved view: %w", result.Error)
+	}
+	return view.ID, nil
+}
+
+// GetSavedView retrieves a saved view by ID. Expired views are treated as not found.
+func (s *Store) GetSavedView(id string) (*SavedView, error) {
+	var view SavedView
+	result := s.db.Where("id = ?", id).First(&view)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get saved view: %w", result.Error)
+	}
+	if view.ExpiresAt != nil && view.ExpiresAt.Before(time.Now()) {
+		return nil, nil
+	}
+	return &view, nil
+}
+
+// NameFieldURLPath configures a server to name requests by its URL path instead of a body field
+const NameFieldURLPath = "$path"
+
 // computeDisplayName computes a display name for a sample query or execution
 // For sample queries: uses the name field, or extracts operationName from requestData
 // For executions: uses sample query name if available, or extracts operationName from requestBody
-func computeDisplayName(name string, requestData string) string {
+// A server's NameField, when configured, takes precedence over operationName extraction
+func computeDisplayName(name string, requestData string, server *Server, requestURL string) string {
 	// If we have an explicit name, use it
 	if name != "" {
 		return name
 	}
 
+	// Use the server's configured name source, for REST and JSON-RPC requests
+	if configured := configuredDisplayName(requestData, server, requestURL); configured != "" {
+		return configured
+	}
+
 	// Try to extract operationName from requestData (JSON)
 	if requestData != "" {
 		// Try parsing as single request
@@ -850,6 +2597,45 @@ func computeDisplayName(name string, requestData string) string {
 	return "Unknown"
 }
 
+// configuredDisplayName returns the display name from a server's NameField: for
+// NameFieldURLPath the path of the URL the request was sent to, or of the server's URL
+// when requestURL is empty, otherwise the value of that body field, with dots selecting
+// nested fields (e.g. "params.action"). Returns an empty string when not configured or
+// not present.
+func configuredDisplayName(requestData string, server *Server, requestURL string) string {
+	if server == nil || server.NameField == "" {
+		return ""
+	}
+
+	if server.NameField == NameFieldURLPath {
+		u, err := url.Parse(cmp.Or(requestURL, server.URL))
+		if err != nil || u.Path == "/" {
+			return ""
+		}
+		return u.Path
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(requestData), &value); err != nil {
+		return ""
+	}
+	for _, key := range strings.Split(server.NameField, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return ""
+		}
+		value = obj[key]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64, bool:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
 // extractOperationFromQuery extracts the operation name from a GraphQL query/mutation string
 func extractOperationFromQuery(query string) string {
 	// Match "query OperationName" or "mutation OperationName"
@@ -920,7 +2706,7 @@ func (s *Store) GetSQLQueryDetailByHash(queryHash string) (*SQLQueryDetail, erro
 	// Fetch execution details
 	var executions []Request
 	if len(executionIDs) > 0 {
-		result = s.db.Where("id IN ?", executionIDs).Order("executed_at DESC").Find(&executions)
+		result = s.db.Preload("Server").Where("id IN ?", executionIDs).Order("executed_at DESC").Find(&executions)
 		if result.Error != nil {
 			return nil, fmt.Errorf("failed to get executions: %w", result.Error)
 		}
@@ -933,11 +2719,11 @@ func (s *Store) GetSQLQueryDetailByHash(queryHash string) (*SQLQueryDetail, erro
 			if exec.SampleID != nil {
 				req, err := s.GetSampleQuery(int64(*exec.SampleID))
 				if err == nil && req != nil {
-					displayName = computeDisplayName(req.Name, req.RequestData)
+					displayName = computeDisplayName(req.Name, req.RequestData, req.Server, "")
 				}
 			}
 			if displayName == "" && exec.RequestBody != "" {
-				displayName = computeDisplayName("", exec.RequestBody)
+				displayName = computeDisplayName("", exec.RequestBody, exec.Server, exec.URL)
 			}
 			if displayName == "" {
 				displayName = "Unknown"
@@ -965,7 +2751,8 @@ func (s *Store) GetSQLQueryDetailByHash(queryHash string) (*SQLQueryDetail, erro
 
 	// Perform index analysis on all queries with this hash
 	if len(queries) > 0 {
-		detail.IndexAnalysis = s.analyzeIndexUsage(queries)
+		detail.IndexAnalysis = analyzeIndexUsage(queries)
+		detail.Rewrites = sqlexplain.SuggestRewrites(firstQuery.QueryWithPlan())
 	}
 
 	return detail, nil
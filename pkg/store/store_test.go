@@ -1,11 +1,22 @@
 package store
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"docker-log-parser/pkg/logs"
+	"docker-log-parser/pkg/sqlexplain"
+	"docker-log-parser/pkg/utils"
+
+	"gorm.io/gorm"
 )
 
 func TestStore(t *testing.T) {
@@ -310,3 +321,1318 @@ func TestDatabaseURL(t *testing.T) {
 		t.Errorf("Expected 0 database URLs after delete, got %d", len(dbURLs))
 	}
 }
+
+func TestBookmarks(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	raw := `level=error msg="payment failed" trace_id=abc123`
+
+	first := &Bookmark{
+		ContainerName: "api",
+		Timestamp:     now,
+		MessageHash:   ComputeMessageHash(raw),
+		TraceID:       "abc123",
+		Message:       "payment failed",
+		Note:          "root cause?",
+	}
+	firstID, err := store.CreateBookmark(first)
+	if err != nil {
+		t.Fatalf("Failed to create bookmark: %v", err)
+	}
+
+	second := &Bookmark{
+		ContainerName: "worker",
+		Timestamp:     now.Add(-time.Minute),
+		MessageHash:   ComputeMessageHash("other line"),
+		TraceID:       "def456",
+	}
+	if _, err := store.CreateBookmark(second); err != nil {
+		t.Fatalf("Failed to create bookmark: %v", err)
+	}
+
+	bookmarks, err := store.ListBookmarks("")
+	if err != nil {
+		t.Fatalf("Failed to list bookmarks: %v", err)
+	}
+	if len(bookmarks) != 2 {
+		t.Fatalf("Expected 2 bookmarks, got %d", len(bookmarks))
+	}
+	if bookmarks[0].ContainerName != "worker" {
+		t.Errorf("Expected bookmarks ordered by timestamp, got %s first", bookmarks[0].ContainerName)
+	}
+
+	bookmarks, err = store.ListBookmarks("abc123")
+	if err != nil {
+		t.Fatalf("Failed to list bookmarks by trace ID: %v", err)
+	}
+	if len(bookmarks) != 1 {
+		t.Fatalf("Expected 1 bookmark for trace, got %d", len(bookmarks))
+	}
+	if bookmarks[0].Note != "root cause?" || bookmarks[0].MessageHash != ComputeMessageHash(raw) {
+		t.Errorf("Unexpected bookmark: %+v", bookmarks[0])
+	}
+	if !bookmarks[0].Timestamp.Equal(now) {
+		t.Errorf("Expected timestamp %v, got %v", now, bookmarks[0].Timestamp)
+	}
+
+	if err := store.DeleteBookmark(firstID); err != nil {
+		t.Fatalf("Failed to delete bookmark: %v", err)
+	}
+	bookmarks, err = store.ListBookmarks("abc123")
+	if err != nil {
+		t.Fatalf("Failed to list bookmarks after delete: %v", err)
+	}
+	if len(bookmarks) != 0 {
+		t.Errorf("Expected 0 bookmarks after delete, got %d", len(bookmarks))
+	}
+}
+
+func TestContainerRetentionMinKeep(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	retention := &ContainerRetention{ContainerName: "debug", RetentionType: "time", RetentionValue: 60, MinKeep: 5}
+	if err := store.SaveContainerRetention(retention); err != nil {
+		t.Fatalf("Failed to save retention: %v", err)
+	}
+
+	saved, err := store.GetContainerRetention("debug")
+	if err != nil || saved == nil {
+		t.Fatalf("Failed to get retention: %v", err)
+	}
+	if saved.MinKeep != 5 {
+		t.Errorf("Expected min keep 5, got %d", saved.MinKeep)
+	}
+
+	// Saving without a floor goes back to the default
+	if err := store.SaveContainerRetention(&ContainerRetention{ContainerName: "debug", RetentionType: "time", RetentionValue: 60}); err != nil {
+		t.Fatalf("Failed to update retention: %v", err)
+	}
+	saved, err = store.GetContainerRetention("debug")
+	if err != nil || saved == nil {
+		t.Fatalf("Failed to get retention: %v", err)
+	}
+	if saved.MinKeep != 0 {
+		t.Errorf("Expected min keep cleared, got %d", saved.MinKeep)
+	}
+}
+
+func TestListRetryGroups(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	base := time.Now().UTC().Truncate(time.Second)
+	body := `{"operationName":"CreateOrder","query":"mutation CreateOrder { createOrder { id } }"}`
+
+	executions := []struct {
+		body   string
+		offset time.Duration
+	}{
+		// Three attempts of the same body within a minute
+		{body, 0},
+		{body, 10 * time.Second},
+		{"  " + body + "\n", 30 * time.Second},
+		// Same body again much later is a separate request, not a retry
+		{body, time.Hour},
+		// A different body executed once
+		{`{"query":"{ me { id } }"}`, 5 * time.Second},
+	}
+
+	for i, e := range executions {
+		_, err := store.CreateRequest(&Request{
+			RequestIDHeader: fmt.Sprintf("req-%d", i),
+			RequestBody:     e.body,
+			StatusCode:      200,
+			ExecutedAt:      base.Add(e.offset),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+	}
+
+	groups, err := store.ListRetryGroups(time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to list retry groups: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("Expected 1 retry group, got %d: %+v", len(groups), groups)
+	}
+
+	group := groups[0]
+	if group.Count != 3 {
+		t.Errorf("Expected 3 attempts, got %d", group.Count)
+	}
+	if len(group.RequestIDs) != 3 || group.RequestIDs[0] != 1 || group.RequestIDs[2] != 3 {
+		t.Errorf("Expected request IDs [1 2 3], got %v", group.RequestIDs)
+	}
+	if group.BodyHash != ComputeBodyHash(body) {
+		t.Errorf("Expected body hash %s, got %s", ComputeBodyHash(body), group.BodyHash)
+	}
+	if group.DisplayName != "CreateOrder" {
+		t.Errorf("Expected display name CreateOrder, got %s", group.DisplayName)
+	}
+	if !group.LastExecutedAt.Equal(base.Add(30 * time.Second)) {
+		t.Errorf("Unexpected last executed at: %v", group.LastExecutedAt)
+	}
+
+	// A wider window joins the later execution into the same group
+	groups, err = store.ListRetryGroups(2 * time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to list retry groups: %v", err)
+	}
+	if len(groups) != 1 || groups[0].Count != 4 {
+		t.Errorf("Expected one group of 4 with a wide window, got %+v", groups)
+	}
+}
+
+func TestGetOperationAnomalies(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	base := time.Now().UTC().Truncate(time.Second)
+	body := `{"operationName":"ListOrders","query":"query ListOrders { orders { id } }"}`
+
+	executions := []struct {
+		queries    int
+		durationMS int64
+		statusCode int
+	}{
+		// Baseline: 4 queries in about 100ms, never failing
+		{4, 90, 200},
+		{4, 110, 200},
+		{4, 100, 200},
+		// Latest: an N+1 doubles the queries and triples the duration
+		{8, 300, 500},
+	}
+
+	var latestID int64
+	for i, e := range executions {
+		latestID, err = store.CreateRequest(&Request{
+			RequestIDHeader: fmt.Sprintf("req-%d", i),
+			RequestBody:     body,
+			StatusCode:      e.statusCode,
+			DurationMS:      e.durationMS,
+			ExecutedAt:      base.Add(time.Duration(i) * time.Minute),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		queries := make([]SQLQuery, e.queries)
+		for j := range queries {
+			queries[j] = SQLQuery{Query: fmt.Sprintf("SELECT %d", j), NormalizedQuery: "SELECT ?"}
+		}
+		if err := store.SaveSQLQueries(latestID, queries); err != nil {
+			t.Fatalf("Failed to save queries: %v", err)
+		}
+	}
+
+	// A different operation must not leak into the baseline
+	if _, err := store.CreateRequest(&Request{
+		RequestIDHeader: "other",
+		RequestBody:     `{"query":"query Me { me { id } }"}`,
+		StatusCode:      200,
+		DurationMS:      5000,
+		ExecutedAt:      base,
+	}); err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	anomalies, err := store.GetOperationAnomalies("ListOrders", 10)
+	if err != nil {
+		t.Fatalf("Failed to get anomalies: %v", err)
+	}
+	if anomalies == nil {
+		t.Fatal("Expected anomalies for ListOrders")
+	}
+	if anomalies.LatestExecutionID != uint(latestID) {
+		t.Errorf("Expected latest execution %d, got %d", latestID, anomalies.LatestExecutionID)
+	}
+	if anomalies.Baseline.Executions != 3 || anomalies.Baseline.QueryCount != 4 || anomalies.Baseline.DurationMS != 100 || anomalies.Baseline.ErrorRate != 0 {
+		t.Errorf("Unexpected baseline: %+v", anomalies.Baseline)
+	}
+
+	metrics := make(map[string]OperationAnomaly)
+	for _, a := range anomalies.Anomalies {
+		metrics[a.Metric] = a
+	}
+	if len(metrics) != 3 {
+		t.Fatalf("Expected queryCount, durationMs and errorRate anomalies, got %+v", anomalies.Anomalies)
+	}
+	if metrics["queryCount"].Change != 100 || metrics["durationMs"].Change != 200 {
+		t.Errorf("Expected 100%% more queries and 200%% longer duration, got %+v", anomalies.Anomalies)
+	}
+
+	// A window of 1 averages only the execution right before the latest
+	anomalies, err = store.GetOperationAnomalies("ListOrders", 1)
+	if err != nil {
+		t.Fatalf("Failed to get anomalies: %v", err)
+	}
+	if anomalies.Baseline.Executions != 1 || anomalies.Baseline.DurationMS != 100 {
+		t.Errorf("Expected a single baseline execution of 100ms, got %+v", anomalies.Baseline)
+	}
+
+	missing, err := store.GetOperationAnomalies("NoSuchOperation", 10)
+	if err != nil || missing != nil {
+		t.Errorf("Expected nil for an unknown operation, got %+v, %v", missing, err)
+	}
+}
+
+func TestOperationAnomaliesBySampleName(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	body := `{"query":"{ cart { id } }"}`
+	sampleID, err := store.CreateSampleQuery(&SampleQuery{Name: "Checkout", RequestData: body})
+	if err != nil {
+		t.Fatalf("Failed to create sample query: %v", err)
+	}
+	sid := uint(sampleID)
+
+	// Anonymous queries run from a sample query are grouped under the sample's name
+	base := time.Now().UTC().Truncate(time.Second)
+	for i := range 2 {
+		if _, err := store.CreateRequest(&Request{
+			RequestIDHeader: fmt.Sprintf("req-%d", i),
+			SampleID:        &sid,
+			RequestBody:     body,
+			StatusCode:      200,
+			DurationMS:      int64(100 * (i + 1)),
+			ExecutedAt:      base.Add(time.Duration(i) * time.Minute),
+		}); err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+	}
+
+	// Requests saved before operations were stored get theirs at startup
+	if err := store.db.Exec("UPDATE requests SET operation = NULL").Error; err != nil {
+		t.Fatalf("Failed to clear operations: %v", err)
+	}
+	if err := store.backfillOperations(); err != nil {
+		t.Fatalf("Failed to backfill operations: %v", err)
+	}
+
+	anomalies, err := store.GetOperationAnomalies("Checkout", 10)
+	if err != nil || anomalies == nil {
+		t.Fatalf("Expected anomalies for Checkout, got %+v, %v", anomalies, err)
+	}
+	if anomalies.Latest.DurationMS != 200 || anomalies.Baseline.Executions != 1 || anomalies.Baseline.DurationMS != 100 {
+		t.Errorf("Expected a 200ms execution against a 100ms baseline, got %+v", anomalies)
+	}
+}
+
+func TestMigrationRehashesSQLQueries(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	store, err := NewStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	requestID, err := store.CreateRequest(&Request{RequestIDHeader: "req-1", StatusCode: 200, ExecutedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	// Normalized the way NormalizeQuery did before it was a lexer
+	stale := "SELECT * FROM items WHERE price > $N.$N AND note = '$S''$S'"
+	current := "SELECT * FROM users WHERE id = $1"
+	err = store.SaveSQLQueries(requestID, []SQLQuery{
+		{Query: "SELECT * FROM items WHERE price > 3.14 AND note = 'it''s'", NormalizedQuery: stale, QueryHash: ComputeQueryHash(stale)},
+		{Query: current, NormalizedQuery: utils.NormalizeQuery(current), QueryHash: ComputeQueryHash(utils.NormalizeQuery(current))},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save SQL queries: %v", err)
+	}
+
+	// Reopening after rolling the version back runs the migration again, as it would
+	// on a database last opened before it existed
+	if err := store.db.Exec("DELETE FROM goose_db_version WHERE version_id = 44").Error; err != nil {
+		t.Fatalf("Failed to roll back migration version: %v", err)
+	}
+	store.Close()
+	store, err = NewStore(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+
+	var queries []SQLQuery
+	if err := store.db.Order("id").Find(&queries).Error; err != nil {
+		t.Fatalf("Failed to list SQL queries: %v", err)
+	}
+	if len(queries) != 2 {
+		t.Fatalf("Expected 2 SQL queries, got %d", len(queries))
+	}
+	for _, q := range queries {
+		want := utils.NormalizeQuery(q.Query)
+		if q.NormalizedQuery != want || q.QueryHash != ComputeQueryHash(want) {
+			t.Errorf("Expected %q rehashed as %q, got %q (%s)", q.Query, want, q.NormalizedQuery, q.QueryHash)
+		}
+	}
+	if queries[0].NormalizedQuery == stale {
+		t.Error("Expected the stale normalization to be replaced")
+	}
+}
+
+func TestOperationAnomaliesFollowServerNaming(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	server := &Server{Name: "rpc", URL: "http://rpc.local/api"}
+	serverID, err := store.CreateServer(server)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	sid := uint(serverID)
+
+	body := `{"method":"orders.list","params":{"action":"ListOrders"}}`
+	base := time.Now().UTC().Truncate(time.Second)
+	for i := range 2 {
+		if _, err := store.CreateRequest(&Request{
+			RequestIDHeader: fmt.Sprintf("req-%d", i),
+			ServerID:        &sid,
+			RequestBody:     body,
+			StatusCode:      200,
+			DurationMS:      int64(100 * (i + 1)),
+			ExecutedAt:      base.Add(time.Duration(i) * time.Minute),
+		}); err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+	}
+
+	// Naming the server's requests by a body field regroups their whole history
+	server.ID = sid
+	server.NameField = "params.action"
+	if err := store.UpdateServer(server); err != nil {
+		t.Fatalf("Failed to update server: %v", err)
+	}
+
+	anomalies, err := store.GetOperationAnomalies("ListOrders", 10)
+	if err != nil || anomalies == nil {
+		t.Fatalf("Expected anomalies for ListOrders, got %+v, %v", anomalies, err)
+	}
+	if anomalies.Baseline.Executions != 1 || anomalies.Baseline.DurationMS != 100 {
+		t.Errorf("Expected the earlier execution in the baseline, got %+v", anomalies.Baseline)
+	}
+	if old, err := store.GetOperationAnomalies("Unknown", 10); err != nil || old != nil {
+		t.Errorf("Expected nothing left under the old name, got %+v, %v", old, err)
+	}
+}
+
+func TestListExecutionTraces(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	execID, err := store.CreateRequest(&Request{
+		RequestIDHeader: "batch-1",
+		RequestBody:     `{"query":"mutation { importOrders { id } }"}`,
+		StatusCode:      200,
+		ExecutedAt:      time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	base := time.Now().UTC().Truncate(time.Second)
+	logMessage := func(offset time.Duration, traceID string) logs.ContainerMessage {
+		fields := map[string]string{"request_id": "batch-1"}
+		if traceID != "" {
+			fields["trace_id"] = traceID
+		}
+		return logs.ContainerMessage{
+			ContainerID: "api",
+			Timestamp:   base.Add(offset),
+			Entry:       &logs.LogEntry{Message: "handling", Fields: fields},
+		}
+	}
+
+	err = store.SaveRequestLogs(execID, []logs.ContainerMessage{
+		logMessage(0, "trace-a"),
+		logMessage(time.Second, "trace-b"),
+		logMessage(2*time.Second, "trace-a"),
+		logMessage(3*time.Second, ""),
+		logMessage(4*time.Second, "trace-a"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to save logs: %v", err)
+	}
+
+	err = store.SaveSQLQueries(execID, []SQLQuery{
+		{Query: "SELECT 1", NormalizedQuery: "SELECT ?", TraceID: "trace-b"},
+		{Query: "SELECT 2", NormalizedQuery: "SELECT ?", TraceID: "trace-b"},
+		{Query: "SELECT 3", NormalizedQuery: "SELECT ?", TraceID: "trace-a"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save queries: %v", err)
+	}
+
+	traces, err := store.ListExecutionTraces(execID)
+	if err != nil {
+		t.Fatalf("Failed to list execution traces: %v", err)
+	}
+	if len(traces) != 2 {
+		t.Fatalf("Expected 2 traces, got %d: %+v", len(traces), traces)
+	}
+
+	a, b := traces[0], traces[1]
+	if a.TraceID != "trace-a" || b.TraceID != "trace-b" {
+		t.Errorf("Expected traces ordered by first log, got %s, %s", a.TraceID, b.TraceID)
+	}
+	if a.LogCount != 3 || a.QueryCount != 1 {
+		t.Errorf("Expected trace-a to have 3 logs and 1 query, got %d and %d", a.LogCount, a.QueryCount)
+	}
+	if b.LogCount != 1 || b.QueryCount != 2 {
+		t.Errorf("Expected trace-b to have 1 log and 2 queries, got %d and %d", b.LogCount, b.QueryCount)
+	}
+	if !a.FirstTimestamp.Equal(base) || !a.LastTimestamp.Equal(base.Add(4*time.Second)) {
+		t.Errorf("Unexpected trace-a time range: %v - %v", a.FirstTimestamp, a.LastTimestamp)
+	}
+}
+
+func TestBuildTraceTimeline(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	start := time.Now().UTC().Truncate(time.Second)
+	execID, err := store.CreateRequest(&Request{
+		RequestIDHeader: "timeline-1",
+		StatusCode:      200,
+		DurationMS:      500,
+		ExecutedAt:      start,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	logLine := func(offset time.Duration, level, message string, fields map[string]string) logs.ContainerMessage {
+		return logs.ContainerMessage{
+			ContainerID: "api",
+			Timestamp:   start.Add(offset),
+			Entry:       &logs.LogEntry{Level: level, Message: message, Fields: fields},
+		}
+	}
+	err = store.SaveRequestLogs(execID, []logs.ContainerMessage{
+		logLine(100*time.Millisecond, "INF", "[sql]: SELECT * FROM users", map[string]string{"trace_id": "t1"}),
+		logLine(150*time.Millisecond, "INF", "handling", map[string]string{"trace_id": "t1"}),
+		logLine(200*time.Millisecond, "WRN", "cache miss", map[string]string{"trace_id": "t1"}),
+		logLine(400*time.Millisecond, "INF", "resolved orders", map[string]string{"trace_id": "t2", "duration": "250ms"}),
+	})
+	if err != nil {
+		t.Fatalf("Failed to save logs: %v", err)
+	}
+
+	err = store.SaveSQLQueries(execID, []SQLQuery{
+		{Query: "SELECT * FROM users", NormalizedQuery: "SELECT * FROM users", QueriedTable: "users", Operation: "SELECT", DurationMS: 40, TraceID: "t1", LoggedAt: new(start.Add(100 * time.Millisecond))},
+		{Query: "SELECT 1", NormalizedQuery: "SELECT ?", DurationMS: 5, TraceID: "t2"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save queries: %v", err)
+	}
+
+	timeline, err := store.BuildTraceTimeline(execID, "")
+	if err != nil {
+		t.Fatalf("Failed to build timeline: %v", err)
+	}
+	if timeline.UntimedQueries != 1 || timeline.DurationMS != 500 {
+		t.Errorf("Expected 1 untimed query and a 500ms request, got %d and %d", timeline.UntimedQueries, timeline.DurationMS)
+	}
+	if len(timeline.Spans) != 3 {
+		t.Fatalf("Expected a query, a warning and a timed line, got %+v", timeline.Spans)
+	}
+
+	query, timed, warning := timeline.Spans[0], timeline.Spans[1], timeline.Spans[2]
+	if query.Kind != TimelineSpanQuery || query.StartMS != 60 || query.DurationMS != 40 || query.Label != "SELECT on users" {
+		t.Errorf("Expected the query to start 40ms before its line, got %+v", query)
+	}
+	if timed.Kind != TimelineSpanLog || timed.StartMS != 150 || timed.DurationMS != 250 {
+		t.Errorf("Expected the timed line to start 250ms before it was logged, got %+v", timed)
+	}
+	if warning.Level != "WRN" || warning.StartMS != 200 || warning.DurationMS != 0 || warning.ContainerID != "api" {
+		t.Errorf("Unexpected warning span: %+v", warning)
+	}
+
+	timeline, err = store.BuildTraceTimeline(execID, "t2")
+	if err != nil {
+		t.Fatalf("Failed to build timeline: %v", err)
+	}
+	if len(timeline.Spans) != 1 || timeline.Spans[0].TraceID != "t2" || timeline.UntimedQueries != 1 {
+		t.Errorf("Expected only trace t2's spans, got %+v", timeline)
+	}
+
+	if timeline, err := store.BuildTraceTimeline(execID+1, ""); err != nil || timeline != nil {
+		t.Errorf("Expected no timeline for a missing execution, got %+v and %v", timeline, err)
+	}
+}
+
+func TestSavedViewExpiry(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	expired := &SavedView{
+		Filter:    `{"searchQuery":"old"}`,
+		ExpiresAt: new(time.Now().Add(-time.Minute)),
+	}
+	expiredID, err := store.CreateSavedView(expired)
+	if err != nil {
+		t.Fatalf("Failed to create saved view: %v", err)
+	}
+
+	active := &SavedView{Filter: `{"searchQuery":"new"}`}
+	activeID, err := store.CreateSavedView(active)
+	if err != nil {
+		t.Fatalf("Failed to create saved view: %v", err)
+	}
+	if activeID == expiredID {
+		t.Fatal("Expected unique view IDs")
+	}
+
+	view, err := store.GetSavedView(expiredID)
+	if err != nil {
+		t.Fatalf("Failed to get saved view: %v", err)
+	}
+	if view != nil {
+		t.Error("Expected expired view to be treated as not found")
+	}
+
+	view, err = store.GetSavedView(activeID)
+	if err != nil {
+		t.Fatalf("Failed to get saved view: %v", err)
+	}
+	if view == nil || view.Filter != `{"searchQuery":"new"}` {
+		t.Errorf("Unexpected saved view: %+v", view)
+	}
+}
+
+func TestDisplayNameFromConfiguredField(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	serverID, err := store.CreateServer(&Server{Name: "rpc", URL: "http://localhost:8545/rpc", NameField: "method"})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	sid := uint(serverID)
+
+	_, err = store.CreateRequest(&Request{
+		ServerID:        &sid,
+		RequestIDHeader: "rpc-1",
+		RequestBody:     `{"jsonrpc":"2.0","method":"eth_getBalance","params":["0xabc"],"id":1}`,
+		ExecutedAt:      time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	requests, _, err := store.ListRequests(10, 0, "", true, nil, "", 0)
+	if err != nil {
+		t.Fatalf("Failed to list requests: %v", err)
+	}
+	if len(requests) != 1 || requests[0].DisplayName != "eth_getBalance" {
+		t.Errorf("Expected display name from method field, got %+v", requests)
+	}
+
+	tests := []struct {
+		name      string
+		nameField string
+		body      string
+		expected  string
+	}{
+		{"nested field", "params.action", `{"params":{"action":"refund"}}`, "refund"},
+		{"missing field falls back to GraphQL", "action", `{"query":"query GetUser { user { id } }"}`, "GetUser"},
+		{"url path", NameFieldURLPath, `{"id":1}`, "/rpc"},
+		{"numeric field", "version", `{"version":2}`, "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &Server{URL: "http://localhost:8545/rpc", NameField: tt.nameField}
+			if got := computeDisplayName("", tt.body, server, ""); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	// An explicit name still wins over the configured field
+	if got := computeDisplayName("Saved", `{"method":"eth_call"}`, &Server{NameField: "method"}, ""); got != "Saved" {
+		t.Errorf("Expected explicit name, got %q", got)
+	}
+
+	// Requests sent with a URL override are named from the URL they were sent to
+	pathServer := &Server{URL: "http://localhost:8545/rpc", NameField: NameFieldURLPath}
+	if got := computeDisplayName("", `{"id":1}`, pathServer, "http://localhost:8545/v2/orders"); got != "/v2/orders" {
+		t.Errorf("Expected the request URL's path, got %q", got)
+	}
+}
+
+func TestAnalyzeSQLQueriesSkipsIgnoredTables(t *testing.T) {
+	queries := []SQLQuery{
+		{Query: "SELECT * FROM users", NormalizedQuery: "SELECT * FROM users", QueriedTable: "users", DurationMS: 10},
+		{Query: "SELECT version_id FROM goose_db_version", NormalizedQuery: "SELECT version_id FROM goose_db_version", QueriedTable: "goose_db_version", DurationMS: 90},
+	}
+
+	analysis := analyzeSQLQueries(queries)
+
+	if _, ok := analysis.TablesAccessed["goose_db_version"]; ok {
+		t.Errorf("Expected goose_db_version to be left out of table stats, got %v", analysis.TablesAccessed)
+	}
+	if analysis.TablesAccessed["users"] != 1 {
+		t.Errorf("Expected users to be counted once, got %v", analysis.TablesAccessed)
+	}
+	if analysis.TotalQueries != 1 || analysis.TotalDuration != 10 {
+		t.Errorf("Expected 1 query totalling 10ms, got %d totalling %v", analysis.TotalQueries, analysis.TotalDuration)
+	}
+	if len(queries) != 2 {
+		t.Error("Expected the caller's queries to be left untouched")
+	}
+}
+
+func TestAnalyzeSQLQueriesCountsAccessPatterns(t *testing.T) {
+	queries := []SQLQuery{
+		{Query: "SELECT * FROM users WHERE id = $1", NormalizedQuery: "SELECT * FROM users WHERE id = $N", QueriedTable: "users"},
+		{Query: "SELECT * FROM users WHERE id = $1", NormalizedQuery: "SELECT * FROM users WHERE id = $N", QueriedTable: "users"},
+		{Query: "SELECT status, COUNT(*) FROM orders GROUP BY status", NormalizedQuery: "SELECT status, COUNT(*) FROM orders GROUP BY status", QueriedTable: "orders"},
+	}
+
+	analysis := analyzeSQLQueries(queries)
+
+	if analysis.AccessPatterns[sqlexplain.AccessPointLookup] != 2 || analysis.AccessPatterns[sqlexplain.AccessAggregate] != 1 {
+		t.Errorf("Expected 2 point lookups and 1 aggregate, got %v", analysis.AccessPatterns)
+	}
+}
+
+func TestAnalyzeSQLQueriesPercentiles(t *testing.T) {
+	// 19 fast lookups and one slow one: the average hides the outlier, the max shows it
+	var queries []SQLQuery
+	for i := 1; i <= 19; i++ {
+		queries = append(queries, SQLQuery{Query: "SELECT * FROM users WHERE id = $1", NormalizedQuery: "SELECT * FROM users WHERE id = $N", QueriedTable: "users", DurationMS: float64(i)})
+	}
+	queries = append(queries,
+		SQLQuery{Query: "SELECT * FROM users WHERE id = $1", NormalizedQuery: "SELECT * FROM users WHERE id = $N", QueriedTable: "users", DurationMS: 1000},
+		SQLQuery{Query: "SELECT * FROM orders", NormalizedQuery: "SELECT * FROM orders", QueriedTable: "orders", DurationMS: 40},
+		SQLQuery{Query: "SELECT * FROM orders", NormalizedQuery: "SELECT * FROM orders", QueriedTable: "orders", DurationMS: 60},
+	)
+
+	analysis := analyzeSQLQueries(queries)
+
+	// Overall: 22 durations, 1..19, 40, 60, 1000
+	if analysis.P50Duration != 11 || analysis.P95Duration != 60 || analysis.MaxDuration != 1000 {
+		t.Errorf("Expected overall p50 11, p95 60, max 1000, got %v, %v, %v", analysis.P50Duration, analysis.P95Duration, analysis.MaxDuration)
+	}
+	if analysis.AvgDuration <= analysis.P50Duration*5 {
+		t.Errorf("Expected the outlier to skew the average well above the median, got avg %v", analysis.AvgDuration)
+	}
+
+	if len(analysis.QueryGroups) != 2 {
+		t.Fatalf("Expected 2 query groups, got %d", len(analysis.QueryGroups))
+	}
+	orders, users := analysis.QueryGroups[0], analysis.QueryGroups[1]
+	if orders.NormalizedQuery != "SELECT * FROM orders" {
+		t.Fatalf("Expected the orders query to sort first by p95, got %q", orders.NormalizedQuery)
+	}
+	// 20 durations, 1..19 and 1000: the 95th percentile is the 19th value
+	if users.Count != 20 || users.P50Duration != 10 || users.P95Duration != 19 || users.MaxDuration != 1000 {
+		t.Errorf("Unexpected users percentiles: %+v", users)
+	}
+	if orders.P50Duration != 40 || orders.P95Duration != 60 || orders.MaxDuration != 60 {
+		t.Errorf("Unexpected orders percentiles: %+v", orders)
+	}
+	if len(analysis.NPlusOneIssues) != 1 || analysis.NPlusOneIssues[0].P95Duration != 19 {
+		t.Errorf("Expected the users lookup flagged as N+1 with its percentiles, got %+v", analysis.NPlusOneIssues)
+	}
+	if len(analysis.NPlusOnePatterns) != 0 {
+		t.Errorf("Expected no parent and child N+1 without a parent query, got %+v", analysis.NPlusOnePatterns)
+	}
+	if queries[19].DurationMS != 1000 {
+		t.Error("Expected the caller's queries to keep their order")
+	}
+}
+
+func TestAnalyzeSQLQueriesLoaderNPlusOne(t *testing.T) {
+	// A feed of 5 posts, then each post's author and comments loaded one at a time
+	data, err := os.ReadFile("testdata/loader_n_plus_one.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	var fixture ExecutionFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+
+	analysis, _ := fixture.Analyze()
+	if len(analysis.NPlusOnePatterns) != 2 {
+		t.Fatalf("Expected the author and comment loaders reported as N+1s, got %+v", analysis.NPlusOnePatterns)
+	}
+
+	users, comments := analysis.NPlusOnePatterns[0], analysis.NPlusOnePatterns[1]
+	for _, pattern := range analysis.NPlusOnePatterns {
+		if pattern.ParentTable != "posts" || pattern.ParentQueryID != 101 || pattern.ChildCount != 5 || len(pattern.ChildQueryIDs) != 5 {
+			t.Errorf("Expected 5 child queries under the posts query, got %+v", pattern)
+		}
+	}
+	if users.ChildTable != "users" || users.Location != "loaders/user.go:58" || users.ChildDurationMS < 7.4 || users.ChildDurationMS > 7.6 {
+		t.Errorf("Unexpected users N+1: %+v", users)
+	}
+	if comments.ChildTable != "comments" || comments.Location != "loaders/comment.go:44" {
+		t.Errorf("Unexpected comments N+1: %+v", comments)
+	}
+}
+
+func TestFindNPlusOnePatternsNeedsRelatedParent(t *testing.T) {
+	lookup := SQLQuery{NormalizedQuery: "SELECT * FROM orders WHERE status = $N", QueriedTable: "orders"}
+	queries := []SQLQuery{
+		{NormalizedQuery: "SELECT * FROM settings", QueriedTable: "settings"},
+		lookup, lookup, lookup,
+	}
+	if patterns := findNPlusOnePatterns(queries); len(patterns) != 0 {
+		t.Errorf("Expected no N+1 for an unrelated parent, got %+v", patterns)
+	}
+
+	// Too far after the parent to have been caused by it
+	queries = []SQLQuery{{NormalizedQuery: "SELECT * FROM customers", QueriedTable: "customers"}}
+	for i := range 4 {
+		queries = append(queries, SQLQuery{NormalizedQuery: fmt.Sprintf("SELECT %d", i)})
+	}
+	child := SQLQuery{NormalizedQuery: "SELECT * FROM orders WHERE customer_id = $N", QueriedTable: "orders"}
+	queries = append(queries, child, child, child)
+	if patterns := findNPlusOnePatterns(queries); len(patterns) != 0 {
+		t.Errorf("Expected no N+1 for a distant parent, got %+v", patterns)
+	}
+
+	queries = slices.Delete(queries, 1, 3)
+	if patterns := findNPlusOnePatterns(queries); len(patterns) != 1 || patterns[0].ParentTable != "customers" {
+		t.Errorf("Expected orders by customer_id reported under customers, got %+v", patterns)
+	}
+}
+
+func TestListHistoricalContainers(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, containers := range [][]string{{"api", "worker"}, {"api", "gone"}} {
+		execID, err := store.CreateRequest(&Request{
+			RequestIDHeader: fmt.Sprintf("req-%d", i),
+			ExecutedAt:      base,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		var messages []logs.ContainerMessage
+		for j, containerID := range containers {
+			messages = append(messages, logs.ContainerMessage{
+				ContainerID: containerID,
+				Timestamp:   base.Add(time.Duration(i*10+j) * time.Second),
+				Entry:       &logs.LogEntry{Message: "handled"},
+			})
+		}
+		if err := store.SaveRequestLogs(execID, messages); err != nil {
+			t.Fatalf("Failed to save logs: %v", err)
+		}
+	}
+
+	containers, err := store.ListHistoricalContainers()
+	if err != nil {
+		t.Fatalf("Failed to list historical containers: %v", err)
+	}
+
+	var ids []string
+	for _, c := range containers {
+		ids = append(ids, c.ContainerID)
+	}
+	if strings.Join(ids, ",") != "gone,api,worker" {
+		t.Fatalf("Expected distinct containers by last seen, got %v", ids)
+	}
+
+	api := containers[1]
+	if api.LogCount != 2 || api.RequestCount != 2 {
+		t.Errorf("Expected api in 2 logs across 2 requests, got %+v", api)
+	}
+	if !api.FirstSeen.Equal(base) || !api.LastSeen.Equal(base.Add(10*time.Second)) {
+		t.Errorf("Unexpected api first/last seen: %v %v", api.FirstSeen, api.LastSeen)
+	}
+
+	requests, total, err := store.ListRequests(10, 0, "", true, []string{"gone"}, "", 0)
+	if err != nil {
+		t.Fatalf("Failed to list requests by container: %v", err)
+	}
+	if total != 1 || len(requests) != 1 || requests[0].RequestIDHeader != "req-1" {
+		t.Errorf("Expected only req-1 for the gone container, got %d %+v", total, requests)
+	}
+}
+
+func TestListServersWithExecutions(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	serverIDs := make(map[string]uint)
+	for _, name := range []string{"staging", "production", "unused"} {
+		id, err := store.CreateServer(&Server{Name: name, URL: "http://" + name})
+		if err != nil {
+			t.Fatalf("Failed to create server: %v", err)
+		}
+		serverIDs[name] = uint(id)
+	}
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, name := range []string{"staging", "production", "staging", "staging"} {
+		serverID := serverIDs[name]
+		if _, err := store.CreateRequest(&Request{
+			ServerID:        &serverID,
+			RequestIDHeader: fmt.Sprintf("req-%d", i),
+			ExecutedAt:      base.Add(time.Duration(i) * time.Minute),
+		}); err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+	}
+	if _, err := store.CreateRequest(&Request{RequestIDHeader: "no-server", ExecutedAt: base}); err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	summaries, err := store.ListServersWithExecutions()
+	if err != nil {
+		t.Fatalf("Failed to list servers with executions: %v", err)
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("Expected only the 2 servers with executions, got %+v", summaries)
+	}
+	staging, production := summaries[0], summaries[1]
+	if staging.Server.Name != "staging" || staging.ExecutionCount != 3 || !staging.LastExecutedAt.Equal(base.Add(3*time.Minute)) {
+		t.Errorf("Expected staging first with 3 executions, got %+v", staging)
+	}
+	if production.Server.Name != "production" || production.ExecutionCount != 1 {
+		t.Errorf("Expected production with 1 execution, got %+v", production)
+	}
+
+	requests, total, err := store.ListRequests(10, 0, "", true, nil, "", serverIDs["production"])
+	if err != nil {
+		t.Fatalf("Failed to list requests by server: %v", err)
+	}
+	if total != 1 || len(requests) != 1 || requests[0].RequestIDHeader != "req-1" {
+		t.Errorf("Expected only req-1 for production, got %d %+v", total, requests)
+	}
+}
+
+func TestListExecutionsForComparison(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	sampleID, err := store.CreateSampleQuery(&SampleQuery{Name: "FetchUsers", RequestData: `{"query": "{ users { id } }"}`})
+	if err != nil {
+		t.Fatalf("Failed to create sample query: %v", err)
+	}
+	sample := uint(sampleID)
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var ids []int64
+	for i, queryCount := range []int{3, 0} {
+		id, err := store.CreateRequest(&Request{
+			SampleID:        &sample,
+			RequestIDHeader: fmt.Sprintf("run-%d", i),
+			StatusCode:      200,
+			DurationMS:      int64(100 * (i + 1)),
+			BuildVersion:    fmt.Sprintf("v1.%d", i),
+			ExecutedAt:      base.Add(time.Duration(i) * time.Minute),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		ids = append(ids, id)
+
+		var queries []SQLQuery
+		for j := 0; j < queryCount; j++ {
+			queries = append(queries, SQLQuery{Query: "SELECT 1", NormalizedQuery: "SELECT 1"})
+		}
+		if err := store.SaveSQLQueries(id, queries); err != nil {
+			t.Fatalf("Failed to save SQL queries: %v", err)
+		}
+	}
+	if _, err := store.CreateRequest(&Request{RequestIDHeader: "other", ExecutedAt: base}); err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	entries, err := store.ListExecutionsForComparison(sampleID)
+	if err != nil {
+		t.Fatalf("Failed to list executions for comparison: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected the sample's 2 executions, got %+v", entries)
+	}
+	newest, oldest := entries[0], entries[1]
+	if int64(newest.ID) != ids[1] || newest.QueryCount != 0 || newest.DurationMS != 200 || !newest.ExecutedAt.Equal(base.Add(time.Minute)) {
+		t.Errorf("Expected the second run first with no queries, got %+v", newest)
+	}
+	if int64(oldest.ID) != ids[0] || oldest.QueryCount != 3 || oldest.Status != RequestStatusCompleted || oldest.BuildVersion != "v1.0" {
+		t.Errorf("Expected the first run with 3 queries, got %+v", oldest)
+	}
+}
+
+func TestSaveRequestLogsInBatches(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	for _, batchSize := range []int{0, 1000} {
+		store.SetLogBatchSize(batchSize)
+		id, err := store.CreateRequest(&Request{RequestIDHeader: fmt.Sprintf("batch-%d", batchSize), ExecutedAt: time.Now()})
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		start := time.Now()
+		messages := make([]logs.ContainerMessage, 10000)
+		for i := range messages {
+			messages[i] = logs.ContainerMessage{
+				ContainerID: "api",
+				Timestamp:   start.Add(time.Duration(i) * time.Millisecond),
+				Entry: &logs.LogEntry{
+					Level:   "INFO",
+					Message: fmt.Sprintf("line %d", i),
+					Raw:     fmt.Sprintf("INFO line %d", i),
+					Fields:  map[string]string{"n": fmt.Sprint(i)},
+				},
+			}
+		}
+		if err := store.SaveRequestLogs(id, messages); err != nil {
+			t.Fatalf("Failed to save 10000 logs with batch size %d: %v", batchSize, err)
+		}
+
+		saved, err := store.GetRequestLogs(id)
+		if err != nil {
+			t.Fatalf("Failed to get logs: %v", err)
+		}
+		if len(saved) != len(messages) || saved[0].Message != "line 0" || saved[len(saved)-1].Message != "line 9999" {
+			t.Errorf("Expected all 10000 logs in order with batch size %d, got %d", batchSize, len(saved))
+		}
+	}
+}
+
+func TestStoreWithContext(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if _, err := store.CreateRequest(&Request{RequestIDHeader: "run", ExecutedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, total, err := store.WithContext(ctx).ListRequests(10, 0, "", true, nil, "", 0); err != nil || total != 1 {
+		t.Fatalf("Expected 1 request with a live context, got %d (%v)", total, err)
+	}
+
+	cancel()
+	if _, _, err := store.WithContext(ctx).ListRequests(10, 0, "", true, nil, "", 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled context to stop the query, got %v", err)
+	}
+	if _, total, err := store.ListRequests(10, 0, "", true, nil, "", 0); err != nil || total != 1 {
+		t.Errorf("Expected the original store to be unaffected, got %d (%v)", total, err)
+	}
+}
+
+func TestSlowestExecutions(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now().UTC()
+	for i, run := range []struct {
+		ago        time.Duration
+		durationMS int64
+	}{
+		{time.Hour, 300},
+		{2 * time.Hour, 900},
+		{48 * time.Hour, 5000}, // Slowest, but outside the window
+		{3 * time.Hour, 100},
+	} {
+		_, err := store.CreateRequest(&Request{
+			RequestIDHeader: fmt.Sprintf("run-%d", i),
+			RequestBody:     `{"query": "query FetchUsers { users { id } }"}`,
+			DurationMS:      run.durationMS,
+			ExecutedAt:      now.Add(-run.ago),
+		})
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+	}
+
+	since := now.Add(-24 * time.Hour)
+	slowest, err := store.SlowestExecutions(since, 2)
+	if err != nil {
+		t.Fatalf("Failed to list slowest executions: %v", err)
+	}
+	if len(slowest) != 2 || slowest[0].DurationMS != 900 || slowest[1].DurationMS != 300 {
+		t.Fatalf("Expected the 900ms and 300ms runs, got %+v", slowest)
+	}
+	if slowest[0].DisplayName != "FetchUsers" {
+		t.Errorf("Expected the display name to be computed, got %q", slowest[0].DisplayName)
+	}
+
+	query := store.db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return slowestExecutionsQuery(tx, since, 2).Find(&[]Request{})
+	})
+	var plan []struct{ Detail string }
+	if err := store.db.Raw("EXPLAIN QUERY PLAN " + query).Scan(&plan).Error; err != nil {
+		t.Fatalf("Failed to explain query: %v", err)
+	}
+	if !slices.ContainsFunc(plan, func(step struct{ Detail string }) bool {
+		return strings.Contains(step.Detail, "idx_executed_requests_executed_at")
+	}) {
+		t.Errorf("Expected the executed_at index to be used, got %+v", plan)
+	}
+}
+
+func TestExecutionDurationBuckets(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	sampleID, err := store.CreateSampleQuery(&SampleQuery{Name: "FetchUsers", RequestData: `{"query": "{ users { id } }"}`})
+	if err != nil {
+		t.Fatalf("Failed to create sample query: %v", err)
+	}
+	sample := uint(sampleID)
+
+	eastern := time.FixedZone("EST", -5*60*60)
+	for _, run := range []struct {
+		executedAt time.Time
+		durationMS int64
+		err        string
+	}{
+		{time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), 100, ""},
+		{time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), 300, ""},
+		{time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC), 9000, "timeout"}, // Failed, so not counted
+		{time.Date(2024, 1, 2, 20, 0, 0, 0, eastern), 200, ""},         // January 3rd in UTC
+	} {
+		if _, err := store.CreateRequest(&Request{
+			SampleID:        &sample,
+			RequestIDHeader: "run",
+			DurationMS:      run.durationMS,
+			Error:           run.err,
+			ExecutedAt:      run.executedAt,
+		}); err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+	}
+
+	buckets, err := store.ExecutionDurationBuckets(sampleID, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to bucket executions: %v", err)
+	}
+	want := []DurationBucket{
+		{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Count: 2, AvgDuration: 200, P95Duration: 300},
+		{Start: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), Count: 1, AvgDuration: 200, P95Duration: 200},
+	}
+	if len(buckets) != len(want) {
+		t.Fatalf("Expected %d buckets, got %+v", len(want), buckets)
+	}
+	for i := range want {
+		if !buckets[i].Start.Equal(want[i].Start) || buckets[i].Start.Location() != time.UTC ||
+			buckets[i].Count != want[i].Count || buckets[i].AvgDuration != want[i].AvgDuration || buckets[i].P95Duration != want[i].P95Duration {
+			t.Errorf("Bucket %d: expected %+v, got %+v", i, want[i], buckets[i])
+		}
+	}
+
+	if buckets, err := store.ExecutionDurationBuckets(sampleID+1, time.Hour); err != nil || len(buckets) != 0 {
+		t.Errorf("Expected no buckets for a sample without executions, got %+v (%v)", buckets, err)
+	}
+}
+
+func TestServerResponseBuildVersion(t *testing.T) {
+	headers := `{"x-build-version": ["v1.3"], "Content-Type": ["application/json"]}`
+
+	tests := []struct {
+		name    string
+		server  Server
+		headers string
+		want    string
+	}{
+		{"header", Server{BuildVersionHeader: "X-Build-Version", BuildVersion: "v1.2"}, headers, "v1.3"},
+		{"header missing from response", Server{BuildVersionHeader: "X-Git-Sha", BuildVersion: "v1.2"}, headers, "v1.2"},
+		{"no stored headers", Server{BuildVersionHeader: "X-Build-Version"}, "", ""},
+		{"configured only", Server{BuildVersion: "abc123"}, headers, "abc123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.server.ResponseBuildVersion(tt.headers); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRequestStatus(t *testing.T) {
+	store, err := NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	for _, req := range []*Request{
+		{RequestIDHeader: "done", StatusCode: 200},
+		{RequestIDHeader: "broken", Error: "connection refused"},
+		{RequestIDHeader: "waiting", Status: RequestStatusPending},
+		{RequestIDHeader: "sending", Status: RequestStatusRunning},
+	} {
+		req.ExecutedAt = time.Now()
+		if _, err := store.CreateRequest(req); err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+	}
+
+	statusOf := func(header string) string {
+		requests, _, err := store.ListRequests(10, 0, header, true, nil, "", 0)
+		if err != nil || len(requests) != 1 {
+			t.Fatalf("Failed to find %s: %v", header, err)
+		}
+		return requests[0].Status
+	}
+	if got := statusOf("done"); got != RequestStatusCompleted {
+		t.Errorf("Expected a finished request to default to completed, got %q", got)
+	}
+	if got := statusOf("broken"); got != RequestStatusFailed {
+		t.Errorf("Expected a request with an error to default to failed, got %q", got)
+	}
+
+	requests, total, err := store.ListRequests(10, 0, "", true, nil, RequestStatusPending, 0)
+	if err != nil {
+		t.Fatalf("Failed to list pending requests: %v", err)
+	}
+	if total != 1 || len(requests) != 1 || requests[0].RequestIDHeader != "waiting" {
+		t.Errorf("Expected only the pending request, got %d %+v", total, requests)
+	}
+
+	n, err := store.FailUnfinishedRequests()
+	if err != nil {
+		t.Fatalf("Failed to fail unfinished requests: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 unfinished requests, got %d", n)
+	}
+	if got := statusOf("sending"); got != RequestStatusFailed {
+		t.Errorf("Expected an interrupted request to be failed, got %q", got)
+	}
+	if got := statusOf("done"); got != RequestStatusCompleted {
+		t.Errorf("Expected a completed request to be left alone, got %q", got)
+	}
+}
+
+func TestQueriesWithPlan(t *testing.T) {
+	now := time.Now()
+	queries := []SQLQuery{
+		{
+			Query:           "SELECT * FROM users WHERE id = $1",
+			NormalizedQuery: "SELECT * FROM users WHERE id = $N",
+			DurationMS:      10.5,
+			QueriedTable:    "users",
+			Operation:       "SELECT",
+			Rows:            1,
+			CreatedAt:       now,
+		},
+		{
+			Query:           "SELECT * FROM posts WHERE user_id = $1",
+			NormalizedQuery: "SELECT * FROM posts WHERE user_id = $N",
+			DurationMS:      25.3,
+			QueriedTable:    "posts",
+			Operation:       "SELECT",
+			Rows:            10,
+			CreatedAt:       now,
+		},
+	}
+
+	result := QueriesWithPlan(queries, "TestOp")
+
+	if len(result) != 2 {
+		t.Errorf("Expected 2 queries, got %d", len(result))
+	}
+
+	if result[0].Query != queries[0].Query {
+		t.Errorf("Query mismatch: expected %s, got %s", queries[0].Query, result[0].Query)
+	}
+
+	if result[0].OperationName != "TestOp" {
+		t.Errorf("OperationName mismatch: expected TestOp, got %s", result[0].OperationName)
+	}
+
+	if result[0].DurationMS != 10.5 {
+		t.Errorf("DurationMS mismatch: expected 10.5, got %f", result[0].DurationMS)
+	}
+
+	if result[1].QueriedTable != "posts" || result[1].Operation != "SELECT" || result[1].Rows != 10 {
+		t.Errorf("Expected the table, operation and rows to be kept, got %+v", result[1])
+	}
+
+	queries[0].GraphQLOperation = "FetchUser"
+	result = QueriesWithPlan(queries, "")
+	if result[0].OperationName != "FetchUser" || result[1].OperationName != "" {
+		t.Errorf("Expected each query's GraphQL operation without an operation name, got %q and %q",
+			result[0].OperationName, result[1].OperationName)
+	}
+}
+
+func TestCompareExecutionsLabelsFixtures(t *testing.T) {
+	query := SQLQuery{Query: "SELECT * FROM users", NormalizedQuery: "SELECT * FROM users", DurationMS: 5}
+	before := (&ExecutionFixture{Request: FixtureRequest{RequestIDHeader: "req-before"}, SQLQueries: []SQLQuery{query}}).RequestDetail()
+	after := (&ExecutionFixture{SQLQueries: []SQLQuery{query}}).RequestDetail()
+
+	report := CompareExecutions(before, after)
+	if len(report.Comparison.CommonQueries) != 1 {
+		t.Fatalf("Expected the query in both fixtures, got %+v", report.Comparison)
+	}
+	if got := report.Comparison.CommonQueries[0].OperationName; got != "Request req-before" {
+		t.Errorf("Expected the first fixture labelled by its request ID, got %q", got)
+	}
+	if got := ExecutionLabel(after, 2); got != "Fixture 2" {
+		t.Errorf("Expected a fixture without a request ID labelled by position, got %q", got)
+	}
+	if got := ExecutionLabel(&RequestDetailResponse{Execution: Request{ID: 7}}, 1); got != "Execution 7" {
+		t.Errorf("Expected a stored execution labelled by ID, got %q", got)
+	}
+}
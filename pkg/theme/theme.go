@@ -0,0 +1,112 @@
+// Package theme holds the colors the terminal front ends use for log levels and
+// secondary text, with dark and light presets
+package theme
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	"docker-log-parser/pkg/logs"
+)
+
+// Preset names
+const (
+	PresetDark  = "dark"  // GitHub dark, matching the web UI
+	PresetLight = "light" // GitHub light, for light terminal backgrounds
+)
+
+// Theme maps log levels and text roles to #rrggbb colors. An empty color leaves the
+// terminal's default.
+type Theme struct {
+	Levels map[string]string // Keyed by normalized level: TRC, DBG, INF, WRN or ERR
+	Muted  string            // Timestamps and other secondary text
+	Accent string            // Container and field names
+}
+
+var presets = map[string]Theme{
+	PresetDark: {
+		Levels: map[string]string{
+			"TRC": "#6e7681",
+			"DBG": "#58a6ff",
+			"INF": "#7ee787",
+			"WRN": "#f0883e",
+			"ERR": "#f85149",
+		},
+		Muted:  "#6e7681",
+		Accent: "#58a6ff",
+	},
+	PresetLight: {
+		Levels: map[string]string{
+			"TRC": "#6e7781",
+			"DBG": "#0969da",
+			"INF": "#1a7f37",
+			"WRN": "#bc4c00",
+			"ERR": "#cf222e",
+		},
+		Muted:  "#6e7781",
+		Accent: "#0550ae",
+	},
+}
+
+// Preset returns a copy of the named preset; an empty name is the dark one
+func Preset(name string) (Theme, error) {
+	if name == "" {
+		name = PresetDark
+	}
+	preset, ok := presets[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q, expected %s or %s", name, PresetDark, PresetLight)
+	}
+	preset.Levels = maps.Clone(preset.Levels)
+	return preset, nil
+}
+
+// WithLevels returns t with the given level colors replaced. Levels may use any spelling
+// ParseLevel accepts, such as warn or WARNING for WRN.
+func (t Theme) WithLevels(colors map[string]string) (Theme, error) {
+	t.Levels = maps.Clone(t.Levels)
+	for level, color := range colors {
+		normalized, ok := logs.ParseLevel(level)
+		if !ok {
+			return t, fmt.Errorf("unknown level %q in theme", level)
+		}
+		if _, _, _, ok := parseHex(color); !ok {
+			return t, fmt.Errorf("invalid color %q for level %s, expected #rrggbb", color, level)
+		}
+		t.Levels[normalized] = color
+	}
+	return t, nil
+}
+
+// LevelColor returns the color for level, or "" for entries without a known level
+func (t Theme) LevelColor(level string) string {
+	normalized, ok := logs.ParseLevel(level)
+	if !ok {
+		return ""
+	}
+	return t.Levels[normalized]
+}
+
+// ANSI returns the 24-bit foreground escape sequence for a #rrggbb color, or "" when
+// color is empty or invalid
+func ANSI(color string) string {
+	r, g, b, ok := parseHex(color)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("\033[38;2;%d;%d;%dm", r, g, b)
+}
+
+func parseHex(color string) (r, g, b uint8, ok bool) {
+	hex, found := strings.CutPrefix(color, "#")
+	if !found || len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(value >> 16), uint8(value >> 8), uint8(value), true
+}
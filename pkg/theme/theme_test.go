@@ -0,0 +1,61 @@
+package theme
+
+import "testing"
+
+func TestPresets(t *testing.T) {
+	dark, err := Preset("")
+	if err != nil {
+		t.Fatalf("Preset(\"\"): %v", err)
+	}
+	if got := dark.LevelColor("error"); got != "#f85149" {
+		t.Errorf("Expected the dark preset by default, got ERR color %q", got)
+	}
+
+	light, err := Preset(PresetLight)
+	if err != nil {
+		t.Fatalf("Preset(light): %v", err)
+	}
+	if light.LevelColor("WARNING") == dark.LevelColor("WARNING") {
+		t.Error("Expected the light preset to use its own warning color")
+	}
+	if got := light.LevelColor("custom"); got != "" {
+		t.Errorf("Expected no color for an unknown level, got %q", got)
+	}
+
+	if _, err := Preset("solarized"); err == nil {
+		t.Error("Expected an error for an unknown preset")
+	}
+}
+
+func TestWithLevels(t *testing.T) {
+	dark, _ := Preset(PresetDark)
+	custom, err := dark.WithLevels(map[string]string{"warn": "#ffcc00"})
+	if err != nil {
+		t.Fatalf("WithLevels: %v", err)
+	}
+	if got := custom.LevelColor("WRN"); got != "#ffcc00" {
+		t.Errorf("Expected the overridden warning color, got %q", got)
+	}
+	if got := dark.LevelColor("WRN"); got == "#ffcc00" {
+		t.Error("Expected overrides not to change the preset they start from")
+	}
+
+	for _, colors := range []map[string]string{
+		{"loud": "#ffffff"},
+		{"ERR": "red"},
+		{"ERR": "#ff00"},
+	} {
+		if _, err := dark.WithLevels(colors); err == nil {
+			t.Errorf("Expected an error for %v", colors)
+		}
+	}
+}
+
+func TestANSI(t *testing.T) {
+	if got := ANSI("#f85149"); got != "\033[38;2;248;81;73m" {
+		t.Errorf("ANSI(#f85149) = %q", got)
+	}
+	if got := ANSI(""); got != "" {
+		t.Errorf("Expected no escape for an empty color, got %q", got)
+	}
+}
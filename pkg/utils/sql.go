@@ -1,20 +1,200 @@
 package utils
 
 import (
-	"regexp"
 	"strings"
 )
 
 // NormalizeQuery normalizes a SQL query by replacing parameters, strings, and numbers
-// with placeholders, making it suitable for grouping similar queries.
+// with placeholders, making it suitable for grouping similar queries. The query is
+// lexed so only literals are replaced; identifiers such as table2 or col_1 and quoted
+// identifiers are kept as written.
 func NormalizeQuery(query string) string {
-	// Replace parameter placeholders ($1, $2, etc.)
-	normalized := regexp.MustCompile(`\$\d+`).ReplaceAllString(query, "?")
-	// Replace quoted strings
-	normalized = regexp.MustCompile(`'[^']*'`).ReplaceAllString(normalized, "?")
-	// Replace numbers
-	normalized = regexp.MustCompile(`\b\d+\b`).ReplaceAllString(normalized, "?")
-	// Collapse whitespace
-	normalized = regexp.MustCompile(`\s+`).ReplaceAllString(normalized, " ")
-	return strings.TrimSpace(normalized)
+	var b strings.Builder
+	b.Grow(len(query))
+
+	// space records pending whitespace, written as a single space before the next token
+	space := false
+	emit := func(s string) {
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteString(s)
+	}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case isSpace(c):
+			space = true
+			i++
+		case c == '-' && peek(query, i+1) == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			emit(query[i : i+end])
+			space = true
+			i += end
+		case c == '/' && peek(query, i+1) == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i
+			} else {
+				end += 4
+			}
+			emit(query[i : i+end])
+			i += end
+		case c == '\'':
+			emit("?")
+			i = skipString(query, i, false)
+		case (c == 'E' || c == 'e') && peek(query, i+1) == '\'' && !isIdentByte(prev(query, i)):
+			// Escape string constant, E'...'
+			emit("?")
+			i = skipString(query, i+1, true)
+		case c == '$' && isDigit(peek(query, i+1)):
+			// Positional parameter, $1
+			emit("?")
+			i++
+			for i < len(query) && isDigit(query[i]) {
+				i++
+			}
+		case c == '$':
+			// Dollar-quoted string, $$...$$ or $tag$...$tag$
+			if end, ok := skipDollarString(query, i); ok {
+				emit("?")
+				i = end
+				continue
+			}
+			emit("$")
+			i++
+		case c == '"':
+			end := skipQuoted(query, i, '"')
+			emit(query[i:end])
+			i = end
+		case isDigit(c) || (c == '.' && isDigit(peek(query, i+1)) && !isIdentByte(prev(query, i))):
+			emit("?")
+			i = skipNumber(query, i)
+		case isIdentByte(c):
+			end := i
+			for end < len(query) && isIdentByte(query[end]) {
+				end++
+			}
+			emit(query[i:end])
+			i = end
+		default:
+			emit(string(c))
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentByte reports whether c can continue an unquoted identifier. Bytes of
+// multibyte UTF-8 characters count, so non-ASCII identifiers stay whole.
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// peek returns the byte at i, or 0 past the end
+func peek(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return 0
+}
+
+// prev returns the byte before i, or 0 at the start
+func prev(s string, i int) byte {
+	if i > 0 {
+		return s[i-1]
+	}
+	return 0
+}
+
+// skipString returns the index past the single-quoted string starting at start,
+// treating a doubled quote as an escaped one, and backslash escapes too when escapes is set
+func skipString(s string, start int, escapes bool) int {
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if escapes {
+				i++
+			}
+		case '\'':
+			if peek(s, i+1) == '\'' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// skipQuoted returns the index past the section quoted with q starting at start,
+// treating a doubled q as an escaped one
+func skipQuoted(s string, start int, q byte) int {
+	for i := start + 1; i < len(s); i++ {
+		if s[i] == q {
+			if peek(s, i+1) == q {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// skipDollarString returns the index past the dollar-quoted string starting at start
+func skipDollarString(s string, start int) (int, bool) {
+	tagEnd := start + 1
+	for tagEnd < len(s) && s[tagEnd] != '$' {
+		if !isIdentByte(s[tagEnd]) {
+			return 0, false
+		}
+		tagEnd++
+	}
+	if tagEnd >= len(s) {
+		return 0, false
+	}
+
+	tag := s[start : tagEnd+1]
+	end := strings.Index(s[tagEnd+1:], tag)
+	if end < 0 {
+		return 0, false
+	}
+	return tagEnd + 1 + end + len(tag), true
+}
+
+// skipNumber returns the index past the numeric literal starting at start, including
+// any fraction and exponent
+func skipNumber(s string, start int) int {
+	i := start
+	for i < len(s) && (isDigit(s[i]) || s[i] == '.') {
+		i++
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && isDigit(s[j]) {
+			i = j
+			for i < len(s) && isDigit(s[i]) {
+				i++
+			}
+		}
+	}
+	return i
 }
@@ -33,6 +33,36 @@ func TestNormalizeQuery(t *testing.T) {
 			query:    "SELECT u.id, u.name FROM users u WHERE u.id IN ($1, $2, $3) AND u.age > 25",
 			expected: "SELECT u.id, u.name FROM users u WHERE u.id IN (?, ?, ?) AND u.age > ?",
 		},
+		{
+			name:     "identifiers with digits are preserved",
+			query:    "SELECT col_1, t2.x FROM table2 t2 WHERE id = 42",
+			expected: "SELECT col_1, t2.x FROM table2 t2 WHERE id = ?",
+		},
+		{
+			name:     "quoted identifiers are preserved",
+			query:    `SELECT "2fa_codes"."code 1" FROM "2fa_codes" WHERE "2fa_codes"."id" = 7`,
+			expected: `SELECT "2fa_codes"."code 1" FROM "2fa_codes" WHERE "2fa_codes"."id" = ?`,
+		},
+		{
+			name:     "decimals and exponents",
+			query:    "SELECT * FROM prices WHERE amount > 3.14 AND rate < 1e-5 AND ratio = .5",
+			expected: "SELECT * FROM prices WHERE amount > ? AND rate < ? AND ratio = ?",
+		},
+		{
+			name:     "strings with escaped quotes and digits",
+			query:    `SELECT * FROM notes WHERE body = 'it''s 42' OR path = 'C:\' OR body = E'a\'b 7'`,
+			expected: "SELECT * FROM notes WHERE body = ? OR path = ? OR body = ?",
+		},
+		{
+			name:     "dollar-quoted strings",
+			query:    "SELECT $$it's 5$$, $tag$x$tag$ FROM t1",
+			expected: "SELECT ?, ? FROM t1",
+		},
+		{
+			name:     "casts and array placeholders",
+			query:    "SELECT * FROM events WHERE ids @> ARRAY[$1]::int8[] LIMIT 10",
+			expected: "SELECT * FROM events WHERE ids @> ARRAY[?]::int8[] LIMIT ?",
+		},
 	}
 
 	for _, tt := range tests {
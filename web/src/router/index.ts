@@ -18,6 +18,11 @@ const router = createRouter({
       name: "logs",
       component: LogsView,
     },
+    {
+      path: "/views/:id",
+      name: "saved-view",
+      component: LogsView,
+    },
     {
       path: "/requests",
       name: "requests",
@@ -22,6 +22,8 @@ export interface LogMessage {
     message?: string;
     raw?: string;
     fields?: Record<string, any>;
+    durationMs?: number;
+    truncated?: boolean; // The line was cut; message and raw end with a truncation marker
   };
 }
 
@@ -39,11 +41,28 @@ export interface SQLAnalysis {
   totalQueries: number;
   uniqueQueries: number;
   avgDuration: number;
+  p50Duration: number;
+  p95Duration: number;
+  maxDuration: number;
   totalDuration: number;
   slowestQueries: SQLQuery[];
   frequentQueries: FrequentQuery[];
   nPlusOne: FrequentQuery[];
+  nPlusOnePatterns?: NPlusOnePattern[];
   tables: TableInfo[];
+  accessPatterns?: Record<"point_lookup" | "range_scan" | "full_scan" | "aggregate" | "join_heavy" | "write" | "other", number>;
+}
+
+export interface NPlusOnePattern {
+  parentQuery: string;
+  parentTable: string;
+  parentQueryId?: number;
+  childQuery: string;
+  childTable: string;
+  childCount: number;
+  childDurationMs: number;
+  childQueryIds?: number[];
+  location?: string;
 }
 
 export interface FrequentQuery {
@@ -51,6 +70,7 @@ export interface FrequentQuery {
   count: number;
   example: SQLQuery;
   avgDuration: number;
+  p95Duration: number;
 }
 
 export interface TableInfo {
@@ -86,18 +106,75 @@ export interface RecentRequest {
 export interface RetentionSettings {
   type: "count" | "time";
   value: number;
+  minKeep?: number;
 }
 
 export interface WebSocketMessage {
-  type: "log" | "logs" | "logs_initial" | "logs_clear" | "containers" | "filter";
+  type: "log" | "logs" | "logs_initial" | "logs_clear" | "containers" | "filter" | "config" | "sql_progress" | "execution_update" | "suppressed" | "eviction";
   data: any;
+  serverTime?: string; // Set on logs and logs_initial, for relative times by the server's clock
+}
+
+export interface SuppressionSummary {
+  counts: Record<string, number>; // Pattern -> lines suppressed since the previous summary
+  total: number;
+}
+
+export interface Eviction {
+  containerId: string;
+  count: number; // Logs evicted since the previous message
+  oldest?: string; // The container's oldest log still stored; unset when none are
+}
+
+export interface ConfigData {
+  fieldFormats: Record<string, string>;
 }
 
 export interface FilterData {
   selectedContainers: string[];
+  excludedContainers?: string[];
   selectedLevels: string[];
   searchQuery: string;
+  caseSensitive?: boolean;
+  wholeWord?: boolean;
   traceFilters: { type: string; value: string }[];
+  hasFields?: string[];
+  rangeFilters?: FieldRangeFilter[];
+  slowThresholdMs?: number;
+  maxAgeSeconds?: number;
+}
+
+export interface ValueCount {
+  value: string;
+  count: number;
+  other?: boolean; // Sums the values beyond the top ones
+}
+
+export interface LogSnapshotInfo {
+  id: string;
+  createdAt: string;
+  count: number;
+  truncated: boolean; // More logs matched than the limit; the oldest were left out
+  url: string; // Download link
+}
+
+export interface LogSnapshot {
+  id: string;
+  createdAt: string;
+  filter: FilterData;
+  count: number;
+  truncated: boolean;
+  from?: string;
+  to?: string;
+  containers: Record<string, number>; // Container name -> logs
+  levels: Record<string, number>; // Level -> logs, NONE for lines without one
+  logs: LogMessage[]; // Oldest first
+}
+
+export interface FieldRangeFilter {
+  name: string;
+  op: ">" | ">=" | "<" | "<=" | "=";
+  value: string;
 }
 
 export interface ContainerData {
@@ -105,6 +182,7 @@ export interface ContainerData {
   portToServerMap?: Record<number, string>;
   logCounts?: Record<string, number>;
   retentions?: Record<string, RetentionSettings>;
+  colors?: Record<string, string>; // Container name -> color to tint its lines with
 }
 
 export interface Server {
@@ -114,8 +192,16 @@ export interface Server {
   bearerToken?: string;
   devId?: string;
   experimentalMode?: string;
+  responseTraceHeader?: string;
+  correlationHeader?: string;
+  correlationFormat?: "" | "id" | "traceparent";
+  nameField?: string;
+  buildVersionHeader?: string; // Response header naming the build, such as X-Build-Version
+  buildVersion?: string; // Build recorded when the response has no build version header
   defaultDatabaseId?: number | null;
   defaultDatabase?: DatabaseURL | null;
+  graphqlSchemaFetchedAt?: string; // When the schema used for validation was introspected
+  warnings?: string[];
   createdAt: string;
   updatedAt: string;
 }
@@ -150,6 +236,27 @@ export interface SaveTraceResponse {
 export interface RetentionResponse {
   retentionType: "count" | "time";
   retentionValue: number;
+  minKeep?: number;
+}
+
+export interface SavedView {
+  id: string;
+  filter: FilterData;
+  startTime?: string;
+  endTime?: string;
+  expiresAt?: string;
+  createdAt: string;
+}
+
+export interface Bookmark {
+  id: number;
+  containerName: string;
+  timestamp: string;
+  messageHash: string;
+  traceId?: string;
+  message?: string;
+  note?: string;
+  createdAt: string;
 }
 
 export interface SampleQuery {
@@ -159,30 +266,138 @@ export interface SampleQuery {
   server?: Server | null;
   requestData: string;
   displayName?: string;
+  maxQueries?: number; // Expectations checked after each execution completes
+  maxDurationMs?: number;
   createdAt: string;
   updatedAt: string;
 }
 
+export interface SampleExpectations {
+  maxQueries?: number | null;
+  maxDurationMs?: number | null;
+}
+
+// Lifecycle of an execution; pending while it waits for an execution slot
+export type ExecutionStatus = "pending" | "running" | "completed" | "failed" | "cancelled";
+
 export interface ExecutedRequest {
   id: number;
   sampleId?: number | null;
   serverId?: number | null;
   server?: Server | null;
+  url?: string;
   requestIdHeader: string;
   requestBody?: string;
+  bodyHash?: string;
+  protocol?: "graphql" | "jsonrpc" | "rest";
   statusCode: number;
   durationMs: number;
   responseBody?: string;
   responseHeaders?: string;
   error?: string;
+  status: ExecutionStatus;
   isSync: boolean;
   displayName?: string;
   name?: string;
+  buildVersion?: string; // Build of the server that handled the request
+  expectationsMet?: boolean; // Absent when the sample query has no expectations
+  expectationViolation?: string;
   executedAt: string;
   createdAt: string;
   updatedAt: string;
 }
 
+export interface RetryGroup {
+  bodyHash: string;
+  displayName: string;
+  requestIds: number[];
+  count: number;
+  firstExecutedAt: string;
+  lastExecutedAt: string;
+}
+
+export interface OperationStats {
+  executions: number;
+  queryCount: number;
+  durationMs: number;
+  errorRate: number;
+}
+
+export interface OperationAnomaly {
+  metric: "queryCount" | "durationMs" | "errorRate";
+  latest: number;
+  baseline: number;
+  change: number;
+}
+
+export interface OperationAnomalies {
+  operation: string;
+  latestExecutionId: number;
+  latest: OperationStats;
+  baseline: OperationStats;
+  anomalies: OperationAnomaly[];
+}
+
+export interface ExecutionTrace {
+  traceId: string;
+  logCount: number;
+  queryCount: number;
+  firstTimestamp: string;
+  lastTimestamp: string;
+}
+
+export interface TimelineSpan {
+  kind: "query" | "log";
+  label: string;
+  startMs: number;
+  durationMs: number;
+  containerId?: string;
+  level?: string;
+  queryId?: number;
+  traceId?: string;
+}
+
+export interface Timeline {
+  executionId: number;
+  traceId?: string;
+  startedAt: string;
+  durationMs: number;
+  spans: TimelineSpan[];
+  untimedQueries: number;
+}
+
+export interface HistoricalContainer {
+  containerId: string;
+  name?: string;
+  running: boolean;
+  logCount: number;
+  requestCount: number;
+  firstSeen: string;
+  lastSeen: string;
+}
+
+export interface ExecutionFixture {
+  version: number;
+  exportedAt: string;
+  displayName: string;
+  request: {
+    body: string;
+    protocol?: string;
+    requestIdHeader: string;
+    serverUrl?: string;
+    executedAt: string;
+  };
+  response: {
+    statusCode: number;
+    body: string;
+    headers?: string;
+    durationMs: number;
+    error?: string;
+  };
+  logs: ExecutionLog[];
+  sqlQueries: SQLQuery[];
+}
+
 export interface ExecutionLog {
   id: number;
   executionId: number;
@@ -213,10 +428,126 @@ export interface ExecutionSQLQuery {
   spanId?: string;
   traceId?: string;
   logFields?: string;
+  loggedAt?: string;
   createdAt: string;
   updatedAt: string;
 }
 
+// A server that has executions, from /api/servers/active
+export interface ServerExecutionSummary {
+  server: Server;
+  executionCount: number;
+  lastExecutedAt: string;
+}
+
+// A compact run of a sample query, from /api/samples/{id}/executions
+export interface ExecutionComparisonEntry {
+  id: number;
+  executedAt: string;
+  status: ExecutionStatus;
+  statusCode: number;
+  durationMs: number;
+  queryCount: number;
+  buildVersion?: string;
+}
+
+// Completed runs of a sample query in one time bucket, from /api/samples/{id}/trend
+export interface DurationBucket {
+  start: string; // UTC
+  count: number;
+  avgDuration: number;
+  p95Duration: number;
+}
+
+// A query compared between two executions, from /api/executions/compare
+export interface QueryPlanComparison {
+  normalizedQuery: string;
+  exampleQuery: string;
+  operationName: string;
+  tableName?: string;
+  set1Count: number;
+  set2Count: number;
+  set1AvgDuration: number;
+  set2AvgDuration: number;
+  durationDiffPct: number;
+  planDifferences?: string[];
+  hasPlanChange: boolean;
+}
+
+export interface ComparedExecution {
+  id: number;
+  requestId?: string;
+  name?: string;
+  server?: string;
+  buildVersion?: string;
+  statusCode: number;
+  durationMs: number;
+  queryCount: number;
+}
+
+// SQL comparison of two executions, the same report as the analyze tool's -json output
+export interface ComparisonReport {
+  execution1: ComparedExecution;
+  execution2: ComparedExecution;
+  comparison: {
+    queriesOnlyInSet1: { query: string; durationMs: number; tableName?: string }[] | null;
+    queriesOnlyInSet2: { query: string; durationMs: number; tableName?: string }[] | null;
+    commonQueries: QueryPlanComparison[] | null;
+    planDifferences: QueryPlanComparison[] | null;
+    performanceDifferences: QueryPlanComparison[] | null;
+    summary: {
+      totalQueriesSet1: number;
+      totalQueriesSet2: number;
+      commonQueries: number;
+      queriesWithPlanChange: number;
+      avgDurationSet1: number;
+      avgDurationSet2: number;
+    };
+  };
+  regressions: QueryPlanComparison[];
+  improvements: QueryPlanComparison[];
+  indexAnalysis1: any;
+  indexAnalysis2: any;
+}
+
+export interface ExecutionQueue {
+  running: number;
+  queued: number;
+  limit: number;
+}
+
+export interface ExecutionUpdateData {
+  executionId: number;
+  status: ExecutionStatus;
+  statusCode: number;
+  durationMs: number;
+  error?: string;
+  queryCount: number; // SQL queries saved for the execution
+  expectationsMet?: boolean;
+  expectationViolation?: string;
+}
+
+export interface SQLProgressData {
+  executionId: number;
+  queries: ExecutionSQLQuery[]; // Saved since the previous message
+  total: number;
+  complete: boolean; // Collection has finished and the analysis is final
+}
+
+export interface ServerTimingMetric {
+  name: string;
+  duration: number;
+  description?: string;
+}
+
+export interface ParsedResponseHeaders {
+  headers: Record<string, string[]>;
+  serverTiming?: ServerTimingMetric[];
+  rateLimit?: { limit?: string; remaining?: string; reset?: string };
+  traceId?: string;
+  requestId?: string;
+}
+
 export interface ExecutionDetail {
   execution: ExecutedRequest;
   request?: SampleQuery | null;
@@ -226,13 +557,34 @@ export interface ExecutionDetail {
   indexAnalysis?: any;
   server?: Server | null;
   displayName: string;
+  responseHeaders?: ParsedResponseHeaders;
   devId?: string;
+  resolverSql?: ResolverSQL[];
+}
+
+// A GraphQL resolver from the response's tracing extension and the SQL queries logged while it ran
+export interface ResolverSQL {
+  path: string;
+  parentType: string;
+  fieldName: string;
+  returnType: string;
+  startOffsetMs: number;
+  durationMs: number;
+  queryIds: number[];
+  sqlDurationMs: number;
 }
 
 export interface ExecuteResponse {
   executionId: number;
 }
 
+export interface LinkLogsResponse {
+  executionId: number;
+  linked: number; // Logs newly saved for the execution
+  alreadyLinked: number; // Matching logs the execution already had
+  queryCount: number; // SQL queries saved from the newly linked logs
+}
+
 export interface AllExecutionsResponse {
   executions: ExecutedRequest[];
   total: number;
@@ -240,6 +592,14 @@ export interface AllExecutionsResponse {
   offset: number;
 }
 
+// Envelope returned by list endpoints called with ?paginated=true
+export interface Page<T> {
+  items: T[];
+  total: number;
+  limit: number;
+  offset: number;
+}
+
 export interface ExecutionReference {
   id: number;
   displayName: string;
@@ -262,9 +622,16 @@ export interface SQLQueryDetail {
   explainPlan?: string;
   variables?: string;
   indexAnalysis?: any;
+  rewriteSuggestions?: RewriteSuggestion[];
   relatedExecutions: ExecutionReference[];
 }
 
+export interface RewriteSuggestion {
+  type: "select_star" | "or_condition" | "function_column" | "not_in_subquery";
+  problem: string;
+  rewrite: string;
+}
+
 export interface PlanNodeType {
   "Node Type": string;
   "Relation Name"?: string;
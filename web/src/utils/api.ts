@@ -8,7 +8,7 @@ export const API = {
         if (contentType && contentType.includes("application/json")) {
           try {
             const errorData = await response.json();
-            errorMessage = errorData.message || errorData.error || errorMessage;
+            errorMessage = errorData.error?.message || errorData.message || errorMessage;
           } catch {
             // Fall back to status text if JSON parsing fails
           }
@@ -56,7 +56,7 @@ export const API = {
         if (contentType && contentType.includes("application/json")) {
           try {
             const errorData = await response.json();
-            errorMessage = errorData.message || errorData.error || errorMessage;
+            errorMessage = errorData.error?.message || errorData.message || errorMessage;
           } catch {
             // Fall back to status text if JSON parsing fails
           }
@@ -104,7 +104,7 @@ export const API = {
         if (contentType && contentType.includes("application/json")) {
           try {
             const errorData = await response.json();
-            errorMessage = errorData.message || errorData.error || errorMessage;
+            errorMessage = errorData.error?.message || errorData.message || errorMessage;
           } catch {
             // Fall back to status text if JSON parsing fails
           }
@@ -143,7 +143,14 @@ export const API = {
         method: "DELETE",
       });
       if (!response.ok) {
-        throw new Error(`HTTP ${response.status}: ${response.statusText}`);
+        let errorMessage = `HTTP ${response.status}: ${response.statusText}`;
+        try {
+          const errorData = await response.json();
+          errorMessage = errorData.error?.message || errorMessage;
+        } catch {
+          // Fall back to status text if JSON parsing fails
+        }
+        throw new Error(errorMessage);
       }
       const text = await response.text();
       return text ? (JSON.parse(text) as T) : null;
@@ -154,6 +161,17 @@ export const API = {
   },
 };
 
+// Extracts the message from an API error response ({error: {code, message}}), falling back to the body text
+export async function responseErrorMessage(response: Response): Promise<string> {
+  const text = await response.text();
+  try {
+    const data = JSON.parse(text);
+    return data.error?.message || text;
+  } catch {
+    return text;
+  }
+}
+
 export const Format = {
   date(date: string | Date | null | undefined): string {
     if (!date) return "";
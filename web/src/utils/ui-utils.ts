@@ -121,6 +121,52 @@ export function normalizeQuery(query: string): string {
     .trim();
 }
 
+/**
+ * Returns the p-th percentile of durations by nearest rank, matching the server's SQL analysis
+ * @param durations - Durations in milliseconds, in any order
+ * @param p - Percentile between 0 and 100
+ */
+export function percentile(durations: number[], p: number): number {
+  if (durations.length === 0) {
+    return 0;
+  }
+  const sorted = [...durations].sort((a, b) => a - b);
+  const rank = Math.ceil((p / 100) * sorted.length);
+  return sorted[Math.max(rank, 1) - 1];
+}
+
+/**
+ * Formats a log field value using a server-provided display hint
+ * @param value - The raw field value
+ * @param format - The display format ("duration", "bytes" or "timestamp")
+ * @returns The formatted value, or the original value if it can't be formatted
+ */
+export function formatFieldWithHint(value: string, format: string): string {
+  const num = Number(value);
+  switch (format) {
+    case "duration":
+      if (isNaN(num)) return value;
+      if (num >= 1000) return `${(num / 1000).toFixed(2)}s`;
+      return `${num.toFixed(1)}ms`;
+    case "bytes": {
+      if (isNaN(num)) return value;
+      const units = ["B", "KB", "MB", "GB", "TB"];
+      let size = num;
+      let unit = 0;
+      while (size >= 1024 && unit < units.length - 1) {
+        size /= 1024;
+        unit++;
+      }
+      return unit === 0 ? `${size} ${units[unit]}` : `${size.toFixed(1)} ${units[unit]}`;
+    }
+    case "timestamp": {
+      const date = isNaN(num) ? new Date(value) : new Date(num < 1e12 ? num * 1000 : num);
+      return isNaN(date.getTime()) ? value : date.toISOString();
+    }
+  }
+  return value;
+}
+
 /**
  * Escapes HTML special characters
  * @param text - The text to escape